@@ -17,6 +17,7 @@ package app
 
 import (
 	"context"
+	"io"
 	"net"
 	"net/http"
 	"sync/atomic"
@@ -33,6 +34,8 @@ type Agent struct {
 	processors []processors.Processor
 	httpServer *http.Server
 	httpAddr   atomic.Value // string, set once agent starts listening
+	closers    []io.Closer  // components serving http requests, closed after the http server
+	clients    clientsHandler
 	logger     *zap.Logger
 }
 
@@ -56,6 +59,12 @@ func (a *Agent) GetHTTPRouter() *mux.Router {
 	return a.httpServer.Handler.(*mux.Router)
 }
 
+// ClientsHandler returns the handler of the admin endpoint listing the recent clients of the agent,
+// with the traffic received from them and the sampling strategy last served to their service.
+func (a *Agent) ClientsHandler() http.Handler {
+	return &a.clients
+}
+
 // Run runs all of agent UDP and HTTP servers in separate go-routines.
 // It returns an error when it's immediately apparent on startup, but
 // any errors happening after starting the servers are only logged.
@@ -97,4 +106,9 @@ func (a *Agent) Stop() {
 	for _, processor := range a.processors {
 		processor.Stop()
 	}
+	for _, closer := range a.closers {
+		if err := closer.Close(); err != nil {
+			a.logger.Error("failed to close agent component", zap.Error(err))
+		}
+	}
 }
@@ -16,21 +16,28 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	athrift "github.com/apache/thrift/lib/go/thrift"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
+	agentTestutils "github.com/jaegertracing/jaeger/cmd/agent/app/testutils"
 	jmetrics "github.com/jaegertracing/jaeger/pkg/metrics"
 	"github.com/jaegertracing/jaeger/pkg/testutils"
+	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
 )
 
 func TestAgentStartError(t *testing.T) {
@@ -82,6 +89,56 @@ func TestAgentMetricsEndpoint(t *testing.T) {
 	})
 }
 
+func TestAgentUnixSocketProcessor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "agent.sock")
+
+	// leave a stale socket behind, as an agent that did not exit cleanly would
+	stale, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	cfg := Builder{
+		Processors: []ProcessorConfiguration{
+			{
+				Model:    jaegerModel,
+				Protocol: binaryProtocol,
+				Server: ServerConfiguration{
+					HostPort:  socket,
+					Transport: unixTransport,
+				},
+			},
+		},
+		HTTPServer: HTTPServerConfiguration{
+			HostPort: "127.0.0.1:0",
+		},
+	}
+	reporter := agentTestutils.NewInMemoryReporter()
+	cfg.WithReporter(reporter)
+	agent, err := cfg.CreateAgent(fakeCollectorProxy{}, zap.NewNop(), metrics.NullFactory)
+	require.NoError(t, err)
+	require.NoError(t, agent.Run())
+	defer agent.Stop()
+
+	client, clientCloser, err := agentTestutils.NewThriftStreamClient("unix", socket, athrift.NewTBinaryProtocolFactoryDefault())
+	require.NoError(t, err)
+	defer clientCloser.Close()
+	batch := &jaeger.Batch{
+		Process: &jaeger.Process{ServiceName: "svc"},
+		Spans:   []*jaeger.Span{{OperationName: "span1"}},
+	}
+	require.NoError(t, client.EmitBatch(context.Background(), batch))
+
+	for i := 0; i < 1000 && len(reporter.Spans()) == 0; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	require.Len(t, reporter.Spans(), 1)
+	assert.Equal(t, "span1", reporter.Spans()[0].OperationName)
+}
+
 func withRunningAgent(t *testing.T, testcase func(string, chan error)) {
 	resetDefaultPrometheusRegistry()
 	cfg := Builder{
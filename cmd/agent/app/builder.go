@@ -18,7 +18,9 @@ package app
 import (
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"os"
 	"strconv"
 
 	"github.com/apache/thrift/lib/go/thrift"
@@ -33,6 +35,7 @@ import (
 	"github.com/jaegertracing/jaeger/cmd/agent/app/servers/thriftudp"
 	"github.com/jaegertracing/jaeger/ports"
 	zipkinThrift "github.com/jaegertracing/jaeger/thrift-gen/agent"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
 
 const (
@@ -45,6 +48,10 @@ const (
 
 	compactProtocol Protocol = "compact"
 	binaryProtocol  Protocol = "binary"
+
+	udpTransport  Transport = "udp"
+	tcpTransport  Transport = "tcp"
+	unixTransport Transport = "unix"
 )
 
 var defaultHTTPServerHostPort = ":" + strconv.Itoa(ports.AgentConfigServerHTTP)
@@ -55,6 +62,9 @@ type Model string
 // Protocol used to distinguish the data transfer protocol
 type Protocol string
 
+// Transport used to distinguish the network transport a server receives data from
+type Transport string
+
 var (
 	protocolFactoryMap = map[Protocol]thrift.TProtocolFactory{
 		compactProtocol: thrift.NewTCompactProtocolFactory(),
@@ -69,6 +79,12 @@ type CollectorProxy interface {
 	io.Closer
 }
 
+// strategyUpdatesProvider is implemented by managers that receive sampling strategy updates pushed by collectors,
+// like grpc.StreamingManager.
+type strategyUpdatesProvider interface {
+	OnUpdate(listener func(serviceName string, strategy *sampling.SamplingStrategyResponse))
+}
+
 // Builder Struct to hold configurations
 type Builder struct {
 	Processors []ProcessorConfiguration `yaml:"processors"`
@@ -91,11 +107,17 @@ type ServerConfiguration struct {
 	MaxPacketSize    int    `yaml:"maxPacketSize"`
 	SocketBufferSize int    `yaml:"socketBufferSize"`
 	HostPort         string `yaml:"hostPort" validate:"nonzero"`
+	// Transport is udp by default. With tcp or unix, the server receives length-prefixed
+	// frames from stream connections, and HostPort is the path of the socket for unix.
+	Transport Transport `yaml:"transport"`
 }
 
-// HTTPServerConfiguration holds config for a server providing sampling strategies and baggage restrictions to clients
+// HTTPServerConfiguration holds config for a server providing sampling strategies and baggage restrictions to clients,
+// and receiving spans from clients that cannot use the UDP servers
 type HTTPServerConfiguration struct {
-	HostPort string `yaml:"hostPort" validate:"nonzero"`
+	HostPort      string                     `yaml:"hostPort" validate:"nonzero"`
+	SamplingCache configmanager.CacheOptions `yaml:"samplingCache"`
+	CORS          httpserver.CORSOptions     `yaml:"cors"`
 }
 
 // WithReporter adds auxiliary reporters.
@@ -111,8 +133,26 @@ func (b *Builder) CreateAgent(primaryProxy CollectorProxy, logger *zap.Logger, m
 	if err != nil {
 		return nil, fmt.Errorf("cannot create processors: %w", err)
 	}
-	server := b.HTTPServer.getHTTPServer(primaryProxy.GetManager(), mFactory)
-	return NewAgent(processors, server, logger), nil
+	manager := primaryProxy.GetManager()
+	var cache *configmanager.CachedManager
+	if b.HTTPServer.SamplingCache.TTL > 0 {
+		cache = configmanager.WrapWithCache(manager, b.HTTPServer.SamplingCache, mFactory, logger)
+		if updates, ok := manager.(strategyUpdatesProvider); ok {
+			updates.OnUpdate(cache.Update)
+		}
+		manager = cache
+	}
+	served := configmanager.WrapWithServedStrategies(manager)
+	server := b.HTTPServer.getHTTPServer(served, r, mFactory, logger)
+	agent := NewAgent(processors, server, logger)
+	if cache != nil {
+		agent.closers = append(agent.closers, cache)
+	}
+	agent.clients.strategies = served
+	if stats, ok := primaryProxy.GetReporter().(clientStatsProvider); ok {
+		agent.clients.stats = stats
+	}
+	return agent, nil
 }
 
 func (b *Builder) getReporter(primaryProxy CollectorProxy) reporter.Reporter {
@@ -141,7 +181,13 @@ func (b *Builder) getProcessors(rep reporter.Reporter, mFactory metrics.Factory,
 		default:
 			return nil, fmt.Errorf("cannot find agent processor for data model %v", cfg.Model)
 		}
-		metrics := mFactory.Namespace(metrics.NSOptions{Name: "", Tags: map[string]string{
+		// stream servers are namespaced by transport, so that they do not share the metrics
+		// of the udp server of the same model and protocol
+		var ns string
+		if cfg.Server.Transport != "" && cfg.Server.Transport != udpTransport {
+			ns = string(cfg.Server.Transport)
+		}
+		metrics := mFactory.Namespace(metrics.NSOptions{Name: ns, Tags: map[string]string{
 			"protocol": string(cfg.Protocol),
 			"model":    string(cfg.Model),
 		}})
@@ -154,12 +200,25 @@ func (b *Builder) getProcessors(rep reporter.Reporter, mFactory metrics.Factory,
 	return retMe, nil
 }
 
-// GetHTTPServer creates an HTTP server that provides sampling strategies and baggage restrictions to client libraries.
-func (c HTTPServerConfiguration) getHTTPServer(manager configmanager.ClientConfigManager, mFactory metrics.Factory) *http.Server {
+// GetHTTPServer creates an HTTP server that provides sampling strategies and baggage restrictions to client libraries,
+// and emits the spans submitted by client libraries through the reporter.
+func (c HTTPServerConfiguration) getHTTPServer(
+	manager configmanager.ClientConfigManager,
+	rep reporter.Reporter,
+	mFactory metrics.Factory,
+	logger *zap.Logger,
+) *http.Server {
 	if c.HostPort == "" {
 		c.HostPort = defaultHTTPServerHostPort
 	}
-	return httpserver.NewHTTPServer(c.HostPort, manager, mFactory)
+	return httpserver.NewHTTPServerWithParams(httpserver.HTTPServerParams{
+		HostPort:       c.HostPort,
+		Manager:        manager,
+		Reporter:       rep,
+		CORS:           c.CORS,
+		MetricsFactory: mFactory,
+		Logger:         logger,
+	})
 }
 
 // GetThriftProcessor gets a TBufferedServer backed Processor using the collector configuration
@@ -171,9 +230,21 @@ func (c *ProcessorConfiguration) GetThriftProcessor(
 ) (processors.Processor, error) {
 	c.applyDefaults()
 
-	server, err := c.Server.getUDPServer(mFactory)
-	if err != nil {
-		return nil, fmt.Errorf("cannot create UDP Server: %w", err)
+	var server servers.Server
+	var err error
+	switch c.Server.Transport {
+	case "", udpTransport:
+		server, err = c.Server.getUDPServer(mFactory)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create UDP Server: %w", err)
+		}
+	case tcpTransport, unixTransport:
+		server, err = c.Server.getStreamServer(mFactory)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create %s Server: %w", c.Server.Transport, err)
+		}
+	default:
+		return nil, fmt.Errorf("cannot find server for transport %v", c.Server.Transport)
 	}
 
 	return processors.NewThriftProcessor(server, c.Workers, mFactory, factory, handler, logger)
@@ -209,6 +280,29 @@ func (c *ServerConfiguration) getUDPServer(mFactory metrics.Factory) (servers.Se
 	return servers.NewTBufferedServer(transport, c.QueueSize, c.MaxPacketSize, mFactory)
 }
 
+// getStreamServer gets a TStreamServer backed server using the server configuration
+func (c *ServerConfiguration) getStreamServer(mFactory metrics.Factory) (servers.Server, error) {
+	c.applyDefaults()
+
+	if c.HostPort == "" {
+		return nil, fmt.Errorf("no address provided for %s server: %+v", c.Transport, *c)
+	}
+	if c.Transport == unixTransport {
+		// remove the socket left behind by a previous agent that did not exit cleanly
+		if fi, err := os.Stat(c.HostPort); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			if err := os.Remove(c.HostPort); err != nil {
+				return nil, fmt.Errorf("cannot remove stale unix socket: %w", err)
+			}
+		}
+	}
+	listener, err := net.Listen(string(c.Transport), c.HostPort)
+	if err != nil {
+		return nil, fmt.Errorf("cannot listen on %s: %w", c.HostPort, err)
+	}
+
+	return servers.NewTStreamServer(listener, c.QueueSize, mFactory)
+}
+
 func defaultInt(value int, defaultVal int) int {
 	if value == 0 {
 		value = defaultVal
@@ -34,6 +34,7 @@ import (
 	yaml "gopkg.in/yaml.v2"
 
 	"github.com/jaegertracing/jaeger/cmd/agent/app/configmanager"
+	"github.com/jaegertracing/jaeger/cmd/agent/app/httpserver"
 	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter"
 	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter/grpc"
 	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
@@ -69,6 +70,12 @@ processors:
 
 httpServer:
     hostPort: 4.4.4.4:5778
+    samplingCache:
+      ttl: 1m
+      file: /var/cache/sampling.json
+    cors:
+      allowedOrigins: "*"
+      allowedHeaders: content-type
 `
 
 func TestBuilderFromConfig(t *testing.T) {
@@ -122,6 +129,8 @@ func TestBuilderFromConfig(t *testing.T) {
 		},
 	}, cfg.Processors[3])
 	assert.Equal(t, "4.4.4.4:5778", cfg.HTTPServer.HostPort)
+	assert.Equal(t, configmanager.CacheOptions{TTL: time.Minute, File: "/var/cache/sampling.json"}, cfg.HTTPServer.SamplingCache)
+	assert.Equal(t, httpserver.CORSOptions{AllowedOrigins: "*", AllowedHeaders: "content-type"}, cfg.HTTPServer.CORS)
 }
 
 func TestBuilderWithExtraReporter(t *testing.T) {
@@ -131,18 +140,60 @@ func TestBuilderWithExtraReporter(t *testing.T) {
 	assert.NotNil(t, agent)
 }
 
+func TestBuilderWithSamplingCache(t *testing.T) {
+	cfg := &Builder{}
+	cfg.HTTPServer.SamplingCache.TTL = time.Minute
+	agent, err := cfg.CreateAgent(fakeCollectorProxy{}, zap.NewNop(), metrics.NullFactory)
+	require.NoError(t, err)
+	require.Len(t, agent.closers, 1)
+	assert.IsType(t, &configmanager.CachedManager{}, agent.closers[0])
+}
+
+type streamingCollectorProxy struct {
+	fakeCollectorProxy
+	listener func(serviceName string, strategy *sampling.SamplingStrategyResponse)
+}
+
+func (f *streamingCollectorProxy) GetManager() configmanager.ClientConfigManager {
+	return f
+}
+
+func (f *streamingCollectorProxy) OnUpdate(listener func(serviceName string, strategy *sampling.SamplingStrategyResponse)) {
+	f.listener = listener
+}
+
+func TestBuilderWithSamplingCacheAndUpdates(t *testing.T) {
+	cfg := &Builder{}
+	cfg.HTTPServer.SamplingCache.TTL = time.Minute
+	proxy := &streamingCollectorProxy{}
+	agent, err := cfg.CreateAgent(proxy, zap.NewNop(), metrics.NullFactory)
+	require.NoError(t, err)
+	require.NotNil(t, proxy.listener)
+
+	// updates pushed by collectors are served from the cache
+	strategy := &sampling.SamplingStrategyResponse{StrategyType: sampling.SamplingStrategyType_RATE_LIMITING}
+	proxy.listener("svc", strategy)
+	r, err := agent.closers[0].(*configmanager.CachedManager).GetSamplingStrategy(context.Background(), "svc")
+	require.NoError(t, err)
+	assert.Equal(t, strategy, r)
+}
+
 func TestBuilderWithProcessorErrors(t *testing.T) {
 	testCases := []struct {
 		model       Model
 		protocol    Protocol
+		transport   Transport
 		hostPort    string
 		err         string
 		errContains string
 	}{
 		{protocol: Protocol("bad"), err: "cannot find protocol factory for protocol bad"},
 		{protocol: compactProtocol, model: Model("bad"), err: "cannot find agent processor for data model bad"},
-		{protocol: compactProtocol, model: jaegerModel, err: "no host:port provided for udp server: {QueueSize:1000 MaxPacketSize:65000 SocketBufferSize:0 HostPort:}"},
+		{protocol: compactProtocol, model: jaegerModel, err: "no host:port provided for udp server: {QueueSize:1000 MaxPacketSize:65000 SocketBufferSize:0 HostPort: Transport:}"},
 		{protocol: compactProtocol, model: zipkinModel, hostPort: "bad-host-port", errContains: "bad-host-port"},
+		{protocol: compactProtocol, model: jaegerModel, transport: Transport("bad"), hostPort: ":0", err: "cannot find server for transport bad"},
+		{protocol: compactProtocol, model: jaegerModel, transport: tcpTransport, err: "cannot create tcp Server: no address provided for tcp server"},
+		{protocol: compactProtocol, model: jaegerModel, transport: tcpTransport, hostPort: "bad-host-port", errContains: "bad-host-port"},
 	}
 	for _, tc := range testCases {
 		testCase := tc // capture loop var
@@ -152,7 +203,8 @@ func TestBuilderWithProcessorErrors(t *testing.T) {
 					Model:    testCase.model,
 					Protocol: testCase.protocol,
 					Server: ServerConfiguration{
-						HostPort: testCase.hostPort,
+						HostPort:  testCase.hostPort,
+						Transport: testCase.transport,
 					},
 				},
 			},
@@ -0,0 +1,67 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jaegertracing/jaeger/cmd/agent/app/configmanager"
+	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter"
+)
+
+// clientStatsProvider is implemented by reporters that track the traffic of clients, like reporter.ClientMetricsReporter.
+type clientStatsProvider interface {
+	ClientStats() []reporter.ClientStats
+}
+
+type clientDiagnostics struct {
+	reporter.ClientStats
+	SamplingStrategy *configmanager.ServedStrategy `json:"samplingStrategy,omitempty"`
+}
+
+type clientsResponse struct {
+	Clients []clientDiagnostics `json:"clients"`
+}
+
+// clientsHandler lists the recent clients of the agent, with the traffic received from them
+// and the sampling strategy last served to their service, optionally filtered by ?service=.
+type clientsHandler struct {
+	stats      clientStatsProvider
+	strategies *configmanager.ServedStrategiesManager
+}
+
+func (h *clientsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	service := r.URL.Query().Get("service")
+	resp := clientsResponse{Clients: []clientDiagnostics{}}
+	if h.stats != nil {
+		for _, stats := range h.stats.ClientStats() {
+			if service != "" && stats.ServiceName != service {
+				continue
+			}
+			client := clientDiagnostics{ClientStats: stats}
+			if h.strategies != nil {
+				if served, ok := h.strategies.LastServed(stats.ServiceName); ok {
+					client.SamplingStrategy = &served
+				}
+			}
+			resp.Clients = append(resp.Clients, client)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "cannot marshal clients", http.StatusInternalServerError)
+	}
+}
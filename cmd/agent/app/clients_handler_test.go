@@ -0,0 +1,111 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/agent/app/configmanager"
+	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter"
+	"github.com/jaegertracing/jaeger/cmd/agent/app/testutils"
+	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+type clientStatsProxy struct {
+	fakeCollectorProxy
+	reporter *reporter.ClientMetricsReporter
+}
+
+func (p clientStatsProxy) GetReporter() reporter.Reporter {
+	return p.reporter
+}
+
+func (p clientStatsProxy) GetManager() configmanager.ClientConfigManager {
+	return p
+}
+
+func (p clientStatsProxy) GetSamplingStrategy(_ context.Context, _ string) (*sampling.SamplingStrategyResponse, error) {
+	return &sampling.SamplingStrategyResponse{
+		StrategyType:          sampling.SamplingStrategyType_PROBABILISTIC,
+		ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 0.25},
+	}, nil
+}
+
+func getClients(t *testing.T, agent *Agent, query string) clientsResponse {
+	w := httptest.NewRecorder()
+	agent.ClientsHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/clients"+query, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var resp clientsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestClientsHandler(t *testing.T) {
+	clientMetrics := reporter.WrapWithClientMetrics(reporter.ClientMetricsReporterParams{
+		Reporter:       testutils.NewInMemoryReporter(),
+		Logger:         zap.NewNop(),
+		MetricsFactory: metrics.NullFactory,
+	})
+	defer clientMetrics.Close()
+	agent, err := new(Builder).CreateAgent(clientStatsProxy{reporter: clientMetrics}, zap.NewNop(), metrics.NullFactory)
+	require.NoError(t, err)
+
+	assert.Empty(t, getClients(t, agent, "").Clients)
+
+	seqNo := int64(1)
+	for _, c := range []struct{ service, uuid string }{{"frontend", "f1"}, {"backend", "b1"}} {
+		uuid := c.uuid
+		err := clientMetrics.EmitBatch(context.Background(), &jaeger.Batch{
+			Process: &jaeger.Process{ServiceName: c.service, Tags: []*jaeger.Tag{{Key: "client-uuid", VStr: &uuid}}},
+			Spans:   []*jaeger.Span{{}, {}},
+			SeqNo:   &seqNo,
+		})
+		require.NoError(t, err)
+	}
+	// the sampling strategy served to the frontend over the HTTP server of the agent
+	_, err = agent.clients.strategies.GetSamplingStrategy(context.Background(), "frontend")
+	require.NoError(t, err)
+
+	clients := getClients(t, agent, "").Clients
+	require.Len(t, clients, 2)
+	assert.Equal(t, "backend", clients[0].ServiceName)
+	assert.Equal(t, "b1", clients[0].ClientUUID)
+	assert.Nil(t, clients[0].SamplingStrategy)
+	assert.Equal(t, "frontend", clients[1].ServiceName)
+	assert.EqualValues(t, 2, clients[1].SpansReceived)
+	require.NotNil(t, clients[1].SamplingStrategy)
+	assert.Equal(t, 0.25, clients[1].SamplingStrategy.Strategy.ProbabilisticSampling.SamplingRate)
+
+	clients = getClients(t, agent, "?service=frontend").Clients
+	require.Len(t, clients, 1)
+	assert.Equal(t, "f1", clients[0].ClientUUID)
+}
+
+func TestClientsHandlerWithoutClientStats(t *testing.T) {
+	agent, err := new(Builder).CreateAgent(fakeCollectorProxy{}, zap.NewNop(), metrics.NullFactory)
+	require.NoError(t, err)
+	assert.Empty(t, getClients(t, agent, "").Clients)
+}
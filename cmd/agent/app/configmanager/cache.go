@@ -0,0 +1,327 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+const (
+	// maxCachedServices bounds the cache so that clients requesting arbitrary
+	// service names cannot grow it without limit.
+	maxCachedServices = 10000
+
+	refreshTimeout = 10 * time.Second
+
+	// persistDelay is how long changes are collected before the cache is written to disk
+	persistDelay = time.Second
+)
+
+// CacheOptions holds configuration for the sampling strategy cache.
+type CacheOptions struct {
+	// TTL is how long a cached strategy is considered fresh. Stale strategies are
+	// still served while they are refreshed in the background. Zero disables the cache.
+	TTL time.Duration `yaml:"ttl"`
+	// File is where the cache is persisted, so that a restarted agent can serve
+	// strategies before it reaches a collector. Empty disables persistence.
+	File string `yaml:"file"`
+}
+
+// cacheMetrics holds metrics related to the sampling strategy cache
+type cacheMetrics struct {
+	// Number of requests served from a fresh cache entry
+	Hits metrics.Counter `metric:"sampling-cache.requests" tags:"result=hit"`
+
+	// Number of requests served from a stale cache entry
+	StaleHits metrics.Counter `metric:"sampling-cache.requests" tags:"result=stale"`
+
+	// Number of requests for services that were not cached
+	Misses metrics.Counter `metric:"sampling-cache.requests" tags:"result=miss"`
+
+	// Number of successful background refreshes
+	RefreshSuccess metrics.Counter `metric:"sampling-cache.refreshes" tags:"result=ok"`
+
+	// Number of failed background refreshes
+	RefreshFailures metrics.Counter `metric:"sampling-cache.refreshes" tags:"result=err"`
+
+	// Number of failures to persist the cache to disk
+	PersistFailures metrics.Counter `metric:"sampling-cache.persist-failures"`
+
+	// Number of services in the cache
+	Services metrics.Gauge `metric:"sampling-cache.services"`
+}
+
+type cacheEntry struct {
+	Strategy  *sampling.SamplingStrategyResponse `json:"strategy"`
+	FetchedAt time.Time                          `json:"fetchedAt"`
+
+	refreshing bool
+}
+
+// fetchCall is the fetch of the strategy of a service that is not cached yet,
+// shared by the concurrent requests for the service
+type fetchCall struct {
+	done     chan struct{}
+	strategy *sampling.SamplingStrategyResponse
+	err      error
+}
+
+// CachedManager is a ClientConfigManager that caches the last good sampling
+// strategy per service and keeps serving it when the collector is unreachable.
+type CachedManager struct {
+	wrapped ClientConfigManager
+	options CacheOptions
+	logger  *zap.Logger
+	metrics cacheMetrics
+	timeNow func() time.Time
+
+	mux     sync.Mutex
+	entries map[string]*cacheEntry
+	fetches map[string]*fetchCall
+	closed  bool
+	wg      sync.WaitGroup
+
+	// dirty is set when the entries changed since they were last persisted
+	dirty        bool
+	persistTimer *time.Timer
+	persistDelay time.Duration
+	persistMux   sync.Mutex
+}
+
+// WrapWithCache wraps ClientConfigManager with a sampling strategy cache.
+// If options.File exists, the cache is pre-populated from it.
+func WrapWithCache(manager ClientConfigManager, options CacheOptions, mFactory metrics.Factory, logger *zap.Logger) *CachedManager {
+	m := &CachedManager{
+		wrapped: manager,
+		options: options,
+		logger:  logger,
+		timeNow: time.Now,
+		entries: make(map[string]*cacheEntry),
+		fetches: make(map[string]*fetchCall),
+
+		persistDelay: persistDelay,
+	}
+	metrics.Init(&m.metrics, mFactory, nil)
+	if options.File != "" {
+		if err := m.load(); err != nil {
+			logger.Warn("Cannot load sampling strategy cache", zap.String("file", options.File), zap.Error(err))
+		}
+	}
+	return m
+}
+
+// GetSamplingStrategy returns the cached sampling strategy for the service, refreshing
+// it in the background when it is stale. Only the first requests for a service wait
+// for the collector, which is called once for all of them.
+func (m *CachedManager) GetSamplingStrategy(ctx context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error) {
+	m.mux.Lock()
+	if entry, ok := m.entries[serviceName]; ok {
+		if m.timeNow().Sub(entry.FetchedAt) < m.options.TTL {
+			m.mux.Unlock()
+			m.metrics.Hits.Inc(1)
+			return entry.Strategy, nil
+		}
+		if !entry.refreshing && !m.closed {
+			entry.refreshing = true
+			m.wg.Add(1)
+			go m.refresh(serviceName)
+		}
+		m.mux.Unlock()
+		m.metrics.StaleHits.Inc(1)
+		return entry.Strategy, nil
+	}
+	call, fetching := m.fetches[serviceName]
+	if !fetching {
+		call = &fetchCall{done: make(chan struct{})}
+		m.fetches[serviceName] = call
+	}
+	m.mux.Unlock()
+
+	m.metrics.Misses.Inc(1)
+	if fetching {
+		select {
+		case <-call.done:
+			return call.strategy, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call.strategy, call.err = m.wrapped.GetSamplingStrategy(ctx, serviceName)
+	if call.err == nil {
+		m.store(serviceName, call.strategy)
+	}
+	m.mux.Lock()
+	delete(m.fetches, serviceName)
+	m.mux.Unlock()
+	close(call.done)
+	return call.strategy, call.err
+}
+
+// Update stores a sampling strategy pushed by collectors, so that it is served
+// without waiting for the cached one to become stale.
+func (m *CachedManager) Update(serviceName string, r *sampling.SamplingStrategyResponse) {
+	m.store(serviceName, r)
+}
+
+// GetBaggageRestrictions returns baggage restrictions from the wrapped manager.
+func (m *CachedManager) GetBaggageRestrictions(ctx context.Context, serviceName string) ([]*baggage.BaggageRestriction, error) {
+	return m.wrapped.GetBaggageRestrictions(ctx, serviceName)
+}
+
+// Close waits for in-flight refreshes to complete and persists the pending changes of the cache.
+func (m *CachedManager) Close() error {
+	m.mux.Lock()
+	m.closed = true
+	if m.persistTimer != nil && m.persistTimer.Stop() {
+		m.persistTimer = nil
+		m.wg.Done()
+	}
+	m.mux.Unlock()
+	m.wg.Wait()
+	m.persistChanges()
+	return nil
+}
+
+func (m *CachedManager) refresh(serviceName string) {
+	defer m.wg.Done()
+	ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+	r, err := m.wrapped.GetSamplingStrategy(ctx, serviceName)
+	cancel()
+	if err != nil {
+		m.metrics.RefreshFailures.Inc(1)
+		m.logger.Debug("Cannot refresh sampling strategy, serving cached one", zap.String("service", serviceName), zap.Error(err))
+		m.mux.Lock()
+		if entry, ok := m.entries[serviceName]; ok {
+			entry.refreshing = false
+		}
+		m.mux.Unlock()
+		return
+	}
+	m.metrics.RefreshSuccess.Inc(1)
+	m.store(serviceName, r)
+}
+
+func (m *CachedManager) store(serviceName string, r *sampling.SamplingStrategyResponse) {
+	m.mux.Lock()
+	if _, ok := m.entries[serviceName]; !ok && len(m.entries) >= maxCachedServices {
+		m.mux.Unlock()
+		return
+	}
+	m.entries[serviceName] = &cacheEntry{Strategy: r, FetchedAt: m.timeNow()}
+	m.metrics.Services.Update(int64(len(m.entries)))
+	m.dirty = true
+	m.schedulePersist()
+	m.mux.Unlock()
+}
+
+// schedulePersist writes the cache to disk after the persist delay, so that the changes
+// of many services are written at once and never on the request path. Must be called
+// with the lock held.
+func (m *CachedManager) schedulePersist() {
+	if m.options.File == "" || m.persistTimer != nil || m.closed {
+		return
+	}
+	m.wg.Add(1)
+	m.persistTimer = time.AfterFunc(m.persistDelay, func() {
+		defer m.wg.Done()
+		m.mux.Lock()
+		m.persistTimer = nil
+		m.mux.Unlock()
+		m.persistChanges()
+	})
+}
+
+// persistChanges writes the cache to disk if it changed since it was last written
+func (m *CachedManager) persistChanges() {
+	if m.options.File == "" {
+		return
+	}
+	m.persistMux.Lock()
+	defer m.persistMux.Unlock()
+
+	m.mux.Lock()
+	if !m.dirty {
+		m.mux.Unlock()
+		return
+	}
+	m.dirty = false
+	data, err := json.Marshal(m.entries)
+	m.mux.Unlock()
+	if err == nil {
+		err = m.persist(data)
+	}
+	if err != nil {
+		m.mux.Lock()
+		m.dirty = true
+		m.mux.Unlock()
+		m.metrics.PersistFailures.Inc(1)
+		m.logger.Warn("Cannot persist sampling strategy cache", zap.String("file", m.options.File), zap.Error(err))
+	}
+}
+
+func (m *CachedManager) load() error {
+	data, err := ioutil.ReadFile(m.options.File)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var entries map[string]*cacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("cannot parse sampling strategy cache: %w", err)
+	}
+	for service, entry := range entries {
+		if entry == nil || entry.Strategy == nil || len(m.entries) >= maxCachedServices {
+			continue
+		}
+		m.entries[service] = entry
+	}
+	m.metrics.Services.Update(int64(len(m.entries)))
+	m.logger.Info("Loaded sampling strategy cache", zap.String("file", m.options.File), zap.Int("services", len(m.entries)))
+	return nil
+}
+
+// persist writes the cache to a temporary file and renames it over the
+// cache file, so that a crash never leaves a partially written cache behind.
+func (m *CachedManager) persist(data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(m.options.File), filepath.Base(m.options.File)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), m.options.File)
+}
@@ -0,0 +1,302 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configmanager
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+type fakeCollectorManager struct {
+	sync.Mutex
+	probability float64
+	err         error
+	calls       int
+}
+
+func (m *fakeCollectorManager) GetSamplingStrategy(_ context.Context, _ string) (*sampling.SamplingStrategyResponse, error) {
+	m.Lock()
+	defer m.Unlock()
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &sampling.SamplingStrategyResponse{
+		StrategyType:          sampling.SamplingStrategyType_PROBABILISTIC,
+		ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: m.probability},
+	}, nil
+}
+
+func (m *fakeCollectorManager) GetBaggageRestrictions(_ context.Context, _ string) ([]*baggage.BaggageRestriction, error) {
+	return []*baggage.BaggageRestriction{{BaggageKey: "foo"}}, nil
+}
+
+func (m *fakeCollectorManager) set(probability float64, err error) {
+	m.Lock()
+	defer m.Unlock()
+	m.probability = probability
+	m.err = err
+}
+
+func (m *fakeCollectorManager) getCalls() int {
+	m.Lock()
+	defer m.Unlock()
+	return m.calls
+}
+
+type testClock struct {
+	sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func samplingRate(t *testing.T, m ClientConfigManager, service string) float64 {
+	r, err := m.GetSamplingStrategy(context.Background(), service)
+	require.NoError(t, err)
+	return r.ProbabilisticSampling.SamplingRate
+}
+
+func TestCachedManager(t *testing.T) {
+	collector := &fakeCollectorManager{probability: 0.1}
+	metricsFactory := metricstest.NewFactory(0)
+	clock := &testClock{now: time.Unix(1000, 0)}
+	m := WrapWithCache(collector, CacheOptions{TTL: time.Minute}, metricsFactory, zap.NewNop())
+	m.timeNow = clock.Now
+
+	// first request goes to the collector, the second one is served from the cache
+	assert.Equal(t, 0.1, samplingRate(t, m, "svc"))
+	collector.set(0.2, nil)
+	assert.Equal(t, 0.1, samplingRate(t, m, "svc"))
+	assert.Equal(t, 1, collector.getCalls())
+
+	// stale entry is served while it is refreshed in the background
+	clock.Advance(time.Minute)
+	assert.Equal(t, 0.1, samplingRate(t, m, "svc"))
+	m.wg.Wait()
+	assert.Equal(t, 2, collector.getCalls())
+	assert.Equal(t, 0.2, samplingRate(t, m, "svc"))
+
+	// when the collector is unreachable, the last good strategy is served
+	collector.set(0.3, errors.New("unavailable"))
+	clock.Advance(time.Minute)
+	assert.Equal(t, 0.2, samplingRate(t, m, "svc"))
+	m.wg.Wait()
+	assert.Equal(t, 0.2, samplingRate(t, m, "svc"))
+	require.NoError(t, m.Close())
+
+	// unknown services are not served when the collector is unreachable
+	_, err := m.GetSamplingStrategy(context.Background(), "other")
+	assert.EqualError(t, err, "unavailable")
+
+	b, err := m.GetBaggageRestrictions(context.Background(), "svc")
+	require.NoError(t, err)
+	assert.Len(t, b, 1)
+
+	metricsFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "sampling-cache.requests", Tags: map[string]string{"result": "hit"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "sampling-cache.requests", Tags: map[string]string{"result": "stale"}, Value: 3},
+		metricstest.ExpectedMetric{Name: "sampling-cache.requests", Tags: map[string]string{"result": "miss"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "sampling-cache.refreshes", Tags: map[string]string{"result": "ok"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "sampling-cache.refreshes", Tags: map[string]string{"result": "err"}, Value: 2},
+	)
+	metricsFactory.AssertGaugeMetrics(t,
+		metricstest.ExpectedMetric{Name: "sampling-cache.services", Value: 1},
+	)
+}
+
+func TestCachedManagerUpdate(t *testing.T) {
+	collector := &fakeCollectorManager{probability: 0.1}
+	m := WrapWithCache(collector, CacheOptions{TTL: time.Minute}, metrics.NullFactory, zap.NewNop())
+	defer m.Close()
+
+	assert.Equal(t, 0.1, samplingRate(t, m, "svc"))
+	m.Update("svc", &sampling.SamplingStrategyResponse{
+		StrategyType:          sampling.SamplingStrategyType_PROBABILISTIC,
+		ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 0.2},
+	})
+	assert.Equal(t, 0.2, samplingRate(t, m, "svc"))
+	assert.Equal(t, 1, collector.getCalls())
+}
+
+func TestCachedManagerPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sampling-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "cache.json")
+
+	collector := &fakeCollectorManager{probability: 0.5}
+	m := WrapWithCache(collector, CacheOptions{TTL: time.Minute, File: file}, metrics.NullFactory, zap.NewNop())
+	assert.Equal(t, 0.5, samplingRate(t, m, "svc"))
+	require.NoError(t, m.Close())
+	assert.FileExists(t, file)
+
+	// a restarted agent serves the persisted strategy while the collector is unreachable
+	offline := &fakeCollectorManager{err: errors.New("unavailable")}
+	m = WrapWithCache(offline, CacheOptions{TTL: time.Minute, File: file}, metrics.NullFactory, zap.NewNop())
+	assert.Equal(t, 0.5, samplingRate(t, m, "svc"))
+	require.NoError(t, m.Close())
+	assert.Equal(t, 0, offline.getCalls())
+}
+
+func TestCachedManagerInvalidFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sampling-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "cache.json")
+	require.NoError(t, ioutil.WriteFile(file, []byte("not json"), 0600))
+
+	collector := &fakeCollectorManager{probability: 0.5}
+	m := WrapWithCache(collector, CacheOptions{TTL: time.Minute, File: file}, metrics.NullFactory, zap.NewNop())
+	assert.Empty(t, m.entries)
+
+	// the invalid file is replaced with the fresh cache
+	assert.Equal(t, 0.5, samplingRate(t, m, "svc"))
+	require.NoError(t, m.Close())
+	data, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"svc"`)
+}
+
+func TestCachedManagerPersistFailure(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	collector := &fakeCollectorManager{probability: 0.5}
+	file := filepath.Join("/non/existent/dir", "cache.json")
+	m := WrapWithCache(collector, CacheOptions{TTL: time.Minute, File: file}, metricsFactory, zap.NewNop())
+
+	assert.Equal(t, 0.5, samplingRate(t, m, "svc"))
+	require.NoError(t, m.Close())
+	metricsFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "sampling-cache.persist-failures", Value: 1},
+	)
+}
+
+func TestCachedManagerPersistDelay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sampling-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "cache.json")
+	collector := &fakeCollectorManager{probability: 0.5}
+	m := WrapWithCache(collector, CacheOptions{TTL: time.Minute, File: file}, metrics.NullFactory, zap.NewNop())
+
+	// the changes are not written on the request path
+	m.persistDelay = time.Hour
+	samplingRate(t, m, "svc1")
+	samplingRate(t, m, "svc2")
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+
+	// they are written at once after the delay
+	m.mux.Lock()
+	m.persistTimer.Reset(time.Millisecond)
+	m.mux.Unlock()
+	for i := 0; i < 100; i++ {
+		if _, err = os.Stat(file); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, m.Close())
+
+	offline := &fakeCollectorManager{err: errors.New("collector unavailable")}
+	m = WrapWithCache(offline, CacheOptions{TTL: time.Minute, File: file}, metrics.NullFactory, zap.NewNop())
+	assert.Equal(t, 0.5, samplingRate(t, m, "svc1"))
+	assert.Equal(t, 0.5, samplingRate(t, m, "svc2"))
+	require.NoError(t, m.Close())
+}
+
+// blockingCollectorManager blocks requests for sampling strategies until it is released
+type blockingCollectorManager struct {
+	fakeCollectorManager
+	release chan struct{}
+}
+
+func (m *blockingCollectorManager) GetSamplingStrategy(ctx context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error) {
+	<-m.release
+	return m.fakeCollectorManager.GetSamplingStrategy(ctx, serviceName)
+}
+
+func TestCachedManagerConcurrentMisses(t *testing.T) {
+	collector := &blockingCollectorManager{
+		fakeCollectorManager: fakeCollectorManager{probability: 0.5},
+		release:              make(chan struct{}),
+	}
+	m := WrapWithCache(collector, CacheOptions{TTL: time.Minute}, metrics.NullFactory, zap.NewNop())
+	defer m.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, 0.5, samplingRate(t, m, "svc"))
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		m.mux.Lock()
+		fetching := len(m.fetches)
+		m.mux.Unlock()
+		if fetching > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(collector.release)
+	wg.Wait()
+	assert.Equal(t, 1, collector.getCalls())
+
+	// a request with a cancelled context does not wait for the fetch of another request
+	collector.release = make(chan struct{})
+	defer close(collector.release)
+	go m.GetSamplingStrategy(context.Background(), "other")
+	for i := 0; i < 100; i++ {
+		m.mux.Lock()
+		fetching := len(m.fetches)
+		m.mux.Unlock()
+		if fetching > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := m.GetSamplingStrategy(ctx, "other")
+	assert.Equal(t, context.Canceled, err)
+}
@@ -16,7 +16,6 @@ package grpc
 
 import (
 	"context"
-	"errors"
 
 	"google.golang.org/grpc"
 
@@ -26,15 +25,17 @@ import (
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
 
-// SamplingManager returns sampling decisions from collector over gRPC.
+// SamplingManager returns sampling decisions and baggage restrictions from collector over gRPC.
 type SamplingManager struct {
-	client api_v2.SamplingManagerClient
+	client        api_v2.SamplingManagerClient
+	baggageClient api_v2.BaggageRestrictionManagerClient
 }
 
 // NewConfigManager creates gRPC sampling manager.
 func NewConfigManager(conn *grpc.ClientConn) *SamplingManager {
 	return &SamplingManager{
-		client: api_v2.NewSamplingManagerClient(conn),
+		client:        api_v2.NewSamplingManagerClient(conn),
+		baggageClient: api_v2.NewBaggageRestrictionManagerClient(conn),
 	}
 }
 
@@ -48,6 +49,10 @@ func (s *SamplingManager) GetSamplingStrategy(ctx context.Context, serviceName s
 }
 
 // GetBaggageRestrictions returns baggage restrictions from collector.
-func (s *SamplingManager) GetBaggageRestrictions(_ context.Context, _ string) ([]*baggage.BaggageRestriction, error) {
-	return nil, errors.New("baggage not implemented")
+func (s *SamplingManager) GetBaggageRestrictions(ctx context.Context, serviceName string) ([]*baggage.BaggageRestriction, error) {
+	r, err := s.baggageClient.GetBaggageRestrictions(ctx, &api_v2.BaggageRestrictionsParameters{ServiceName: serviceName})
+	if err != nil {
+		return nil, err
+	}
+	return jaeger.ConvertBaggageRestrictionsFromDomain(r), nil
 }
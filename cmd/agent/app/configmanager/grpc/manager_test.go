@@ -23,8 +23,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
 
@@ -58,10 +61,40 @@ func TestSamplingManager_GetSamplingStrategy_error(t *testing.T) {
 }
 
 func TestSamplingManager_GetBaggageRestrictions(t *testing.T) {
-	manager := NewConfigManager(nil)
+	s, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		api_v2.RegisterBaggageRestrictionManagerServer(s, &mockBaggageHandler{})
+	})
+	conn, err := grpc.Dial(addr.String(), grpc.WithInsecure())
+	defer close(t, conn)
+	require.NoError(t, err)
+	defer s.GracefulStop()
+	manager := NewConfigManager(conn)
+	rest, err := manager.GetBaggageRestrictions(context.Background(), "foo")
+	require.NoError(t, err)
+	assert.Equal(t, []*baggage.BaggageRestriction{{BaggageKey: "session-id", MaxValueLength: 64}}, rest)
+}
+
+func TestSamplingManager_GetBaggageRestrictions_unimplemented(t *testing.T) {
+	s, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		api_v2.RegisterSamplingManagerServer(s, &mockSamplingHandler{})
+	})
+	conn, err := grpc.Dial(addr.String(), grpc.WithInsecure())
+	defer close(t, conn)
+	require.NoError(t, err)
+	defer s.GracefulStop()
+	manager := NewConfigManager(conn)
 	rest, err := manager.GetBaggageRestrictions(context.Background(), "foo")
 	require.Nil(t, rest)
-	assert.EqualError(t, err, "baggage not implemented")
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+type mockBaggageHandler struct {
+}
+
+func (*mockBaggageHandler) GetBaggageRestrictions(context.Context, *api_v2.BaggageRestrictionsParameters) (*api_v2.BaggageRestrictionsResponse, error) {
+	return &api_v2.BaggageRestrictionsResponse{BaggageRestrictions: []api_v2.BaggageRestriction{
+		{BaggageKey: "session-id", MaxValueLength: 64},
+	}}, nil
 }
 
 type mockSamplingHandler struct {
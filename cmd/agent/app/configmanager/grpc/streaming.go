@@ -0,0 +1,232 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jaegertracing/jaeger/cmd/agent/app/configmanager"
+	"github.com/jaegertracing/jaeger/model/converter/thrift/jaeger"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+// maxSubscribedServices matches the limit of a single subscription in the collector.
+const maxSubscribedServices = 1000
+
+// streamRetryInterval is the time to wait before subscribing again after the stream failed.
+var streamRetryInterval = 5 * time.Second
+
+// streamingMetrics holds metrics related to the stream of sampling strategy updates
+type streamingMetrics struct {
+	// Number of sampling strategy updates received from collectors
+	Updates metrics.Counter `metric:"sampling-updates.received"`
+
+	// Number of times the stream of updates failed
+	StreamErrors metrics.Counter `metric:"sampling-updates.stream-errors"`
+
+	// Whether the stream of updates is established (1) or not (0)
+	Connected metrics.Gauge `metric:"sampling-updates.connected"`
+
+	// Number of requests served from the strategies pushed by collectors
+	Hits metrics.Counter `metric:"sampling-updates.requests" tags:"result=hit"`
+
+	// Number of requests forwarded to the wrapped manager
+	Misses metrics.Counter `metric:"sampling-updates.requests" tags:"result=miss"`
+}
+
+// StreamingManager is a ClientConfigManager that subscribes to the sampling strategy updates
+// pushed by collectors, and serves the strategies of the subscribed services from a local copy
+// while the stream is established. Other requests are forwarded to the wrapped manager, and
+// the service is added to the subscription.
+type StreamingManager struct {
+	wrapped configmanager.ClientConfigManager
+	client  api_v2.SamplingStrategyUpdatesClient
+	logger  *zap.Logger
+	metrics streamingMetrics
+
+	ctx         context.Context
+	cancel      context.CancelFunc
+	resubscribe chan struct{}
+	wg          sync.WaitGroup
+
+	mux          sync.Mutex
+	services     map[string]struct{}
+	strategies   map[string]*sampling.SamplingStrategyResponse
+	connected    bool
+	cancelStream context.CancelFunc
+	listeners    []func(serviceName string, strategy *sampling.SamplingStrategyResponse)
+}
+
+// WrapWithStreaming wraps ClientConfigManager with a subscription to the sampling strategy
+// updates pushed by the collectors reachable over conn.
+func WrapWithStreaming(manager configmanager.ClientConfigManager, conn *grpc.ClientConn, mFactory metrics.Factory, logger *zap.Logger) *StreamingManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &StreamingManager{
+		wrapped:     manager,
+		client:      api_v2.NewSamplingStrategyUpdatesClient(conn),
+		logger:      logger,
+		ctx:         ctx,
+		cancel:      cancel,
+		resubscribe: make(chan struct{}, 1),
+		services:    make(map[string]struct{}),
+		strategies:  make(map[string]*sampling.SamplingStrategyResponse),
+	}
+	metrics.Init(&m.metrics, mFactory, nil)
+	m.wg.Add(1)
+	go m.run()
+	return m
+}
+
+// OnUpdate registers a function called with every sampling strategy update received from collectors.
+func (m *StreamingManager) OnUpdate(listener func(serviceName string, strategy *sampling.SamplingStrategyResponse)) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.listeners = append(m.listeners, listener)
+}
+
+// GetSamplingStrategy returns the sampling strategy pushed by collectors if the stream is established,
+// otherwise the one returned by the wrapped manager.
+func (m *StreamingManager) GetSamplingStrategy(ctx context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error) {
+	m.mux.Lock()
+	if r, ok := m.strategies[serviceName]; ok && m.connected {
+		m.mux.Unlock()
+		m.metrics.Hits.Inc(1)
+		return r, nil
+	}
+	if _, ok := m.services[serviceName]; !ok && len(m.services) < maxSubscribedServices {
+		m.services[serviceName] = struct{}{}
+		if m.cancelStream != nil {
+			m.cancelStream()
+		}
+		m.triggerResubscribe()
+	}
+	m.mux.Unlock()
+	m.metrics.Misses.Inc(1)
+	return m.wrapped.GetSamplingStrategy(ctx, serviceName)
+}
+
+// GetBaggageRestrictions returns baggage restrictions from the wrapped manager.
+func (m *StreamingManager) GetBaggageRestrictions(ctx context.Context, serviceName string) ([]*baggage.BaggageRestriction, error) {
+	return m.wrapped.GetBaggageRestrictions(ctx, serviceName)
+}
+
+// Close closes the stream of updates.
+func (m *StreamingManager) Close() error {
+	m.cancel()
+	m.wg.Wait()
+	return nil
+}
+
+func (m *StreamingManager) triggerResubscribe() {
+	select {
+	case m.resubscribe <- struct{}{}:
+	default:
+	}
+}
+
+func (m *StreamingManager) run() {
+	defer m.wg.Done()
+	for {
+		select {
+		case <-m.resubscribe:
+		case <-m.ctx.Done():
+			return
+		}
+		err := m.stream()
+		if err == nil {
+			continue
+		}
+		if status.Code(err) == codes.Unimplemented {
+			m.logger.Info("Collectors do not push sampling strategy updates, polling them instead", zap.Error(err))
+			return
+		}
+		m.metrics.StreamErrors.Inc(1)
+		m.logger.Debug("Stream of sampling strategy updates failed", zap.Error(err))
+		select {
+		case <-time.After(streamRetryInterval):
+			m.triggerResubscribe()
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// stream subscribes to the updates of the services requested so far, and stores them until the
+// stream fails or is canceled to subscribe to a new service, in which case it returns nil.
+func (m *StreamingManager) stream() error {
+	ctx, cancel := context.WithCancel(m.ctx)
+	defer cancel()
+	m.mux.Lock()
+	services := make([]string, 0, len(m.services))
+	for service := range m.services {
+		services = append(services, service)
+	}
+	m.cancelStream = cancel
+	m.mux.Unlock()
+	sort.Strings(services)
+
+	stream, err := m.client.Subscribe(ctx, &api_v2.SamplingStrategiesSubscription{ServiceNames: services})
+	if err != nil {
+		return m.streamError(ctx, err)
+	}
+	defer m.disconnect()
+	for {
+		u, err := stream.Recv()
+		if err != nil {
+			return m.streamError(ctx, err)
+		}
+		r, err := jaeger.ConvertSamplingResponseFromDomain(u.Strategy)
+		if err != nil {
+			m.logger.Warn("Cannot convert sampling strategy update", zap.String("service", u.ServiceName), zap.Error(err))
+			continue
+		}
+		m.metrics.Updates.Inc(1)
+		m.mux.Lock()
+		m.strategies[u.ServiceName] = r
+		m.connected = true
+		listeners := m.listeners
+		m.mux.Unlock()
+		m.metrics.Connected.Update(1)
+		for _, listener := range listeners {
+			listener(u.ServiceName, r)
+		}
+	}
+}
+
+func (m *StreamingManager) streamError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+func (m *StreamingManager) disconnect() {
+	m.mux.Lock()
+	m.connected = false
+	m.strategies = make(map[string]*sampling.SamplingStrategyResponse)
+	m.mux.Unlock()
+	m.metrics.Connected.Update(0)
+}
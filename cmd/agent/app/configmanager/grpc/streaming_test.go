@@ -0,0 +1,180 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+type mockUpdatesHandler struct {
+	subscriptions chan []string
+	push          chan *api_v2.SamplingStrategyUpdate
+	failFirst     bool
+
+	mux   sync.Mutex
+	calls int
+}
+
+func (h *mockUpdatesHandler) Subscribe(req *api_v2.SamplingStrategiesSubscription, stream api_v2.SamplingStrategyUpdates_SubscribeServer) error {
+	h.mux.Lock()
+	h.calls++
+	fail := h.failFirst && h.calls == 1
+	h.mux.Unlock()
+	if fail {
+		return status.Error(codes.Unavailable, "not ready")
+	}
+	h.subscriptions <- req.ServiceNames
+	for _, service := range req.ServiceNames {
+		if err := stream.Send(probabilisticUpdate(service, 0.5)); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case u := <-h.push:
+			if err := stream.Send(u); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func probabilisticUpdate(service string, rate float64) *api_v2.SamplingStrategyUpdate {
+	return &api_v2.SamplingStrategyUpdate{
+		ServiceName: service,
+		Strategy: &api_v2.SamplingStrategyResponse{
+			StrategyType:          api_v2.SamplingStrategyType_PROBABILISTIC,
+			ProbabilisticSampling: &api_v2.ProbabilisticSamplingStrategy{SamplingRate: rate},
+		},
+	}
+}
+
+type pollingManager struct{}
+
+func (pollingManager) GetSamplingStrategy(context.Context, string) (*sampling.SamplingStrategyResponse, error) {
+	return &sampling.SamplingStrategyResponse{
+		StrategyType:          sampling.SamplingStrategyType_PROBABILISTIC,
+		ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 0.1},
+	}, nil
+}
+
+func (pollingManager) GetBaggageRestrictions(context.Context, string) ([]*baggage.BaggageRestriction, error) {
+	return []*baggage.BaggageRestriction{{BaggageKey: "foo"}}, nil
+}
+
+func streamedRate(t *testing.T, m *StreamingManager, service string) float64 {
+	r, err := m.GetSamplingStrategy(context.Background(), service)
+	require.NoError(t, err)
+	return r.ProbabilisticSampling.SamplingRate
+}
+
+func TestStreamingManager(t *testing.T) {
+	oldInterval := streamRetryInterval
+	streamRetryInterval = time.Millisecond
+	defer func() { streamRetryInterval = oldInterval }()
+
+	handler := &mockUpdatesHandler{
+		subscriptions: make(chan []string, 10),
+		push:          make(chan *api_v2.SamplingStrategyUpdate),
+		failFirst:     true,
+	}
+	s, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		api_v2.RegisterSamplingStrategyUpdatesServer(s, handler)
+	})
+	defer s.Stop()
+	conn, err := grpc.Dial(addr.String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer close(t, conn)
+
+	metricsFactory := metricstest.NewFactory(0)
+	m := WrapWithStreaming(pollingManager{}, conn, metricsFactory, zap.NewNop())
+	var updates []string
+	var updatesMux sync.Mutex
+	m.OnUpdate(func(service string, strategy *sampling.SamplingStrategyResponse) {
+		updatesMux.Lock()
+		defer updatesMux.Unlock()
+		updates = append(updates, service)
+	})
+
+	// the first request is forwarded to the wrapped manager and subscribes to the service
+	assert.Equal(t, 0.1, streamedRate(t, m, "svc"))
+	assert.Equal(t, []string{"svc"}, <-handler.subscriptions)
+	assert.Eventually(t, func() bool {
+		return streamedRate(t, m, "svc") == 0.5
+	}, 5*time.Second, time.Millisecond)
+
+	handler.push <- probabilisticUpdate("svc", 0.7)
+	assert.Eventually(t, func() bool {
+		return streamedRate(t, m, "svc") == 0.7
+	}, 5*time.Second, time.Millisecond)
+
+	// a new service is added to the subscription
+	assert.Equal(t, 0.1, streamedRate(t, m, "other"))
+	assert.Equal(t, []string{"other", "svc"}, <-handler.subscriptions)
+	assert.Eventually(t, func() bool {
+		return streamedRate(t, m, "other") == 0.5
+	}, 5*time.Second, time.Millisecond)
+
+	b, err := m.GetBaggageRestrictions(context.Background(), "svc")
+	require.NoError(t, err)
+	assert.Len(t, b, 1)
+	require.NoError(t, m.Close())
+
+	updatesMux.Lock()
+	assert.Equal(t, []string{"svc", "svc", "other", "svc"}, updates)
+	updatesMux.Unlock()
+	metricsFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "sampling-updates.received", Value: 4},
+		metricstest.ExpectedMetric{Name: "sampling-updates.stream-errors", Value: 1},
+	)
+	metricsFactory.AssertGaugeMetrics(t,
+		metricstest.ExpectedMetric{Name: "sampling-updates.connected", Value: 0},
+	)
+}
+
+func TestStreamingManagerUnimplemented(t *testing.T) {
+	s, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		api_v2.RegisterSamplingManagerServer(s, &mockSamplingHandler{})
+	})
+	defer s.Stop()
+	conn, err := grpc.Dial(addr.String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer close(t, conn)
+
+	m := WrapWithStreaming(pollingManager{}, conn, metrics.NullFactory, zap.NewNop())
+	assert.Equal(t, 0.1, streamedRate(t, m, "svc"))
+	// the subscription stops when collectors do not support it
+	m.wg.Wait()
+	assert.Equal(t, 0.1, streamedRate(t, m, "svc"))
+	require.NoError(t, m.Close())
+}
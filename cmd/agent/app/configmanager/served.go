@@ -0,0 +1,76 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configmanager
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+// ServedStrategy is the sampling strategy last served to a service, for diagnostics.
+type ServedStrategy struct {
+	Strategy *sampling.SamplingStrategyResponse `json:"strategy"`
+	ServedAt time.Time                          `json:"servedAt"`
+}
+
+// ServedStrategiesManager is a decorator that remembers the sampling strategy last served to each service.
+type ServedStrategiesManager struct {
+	wrapped ClientConfigManager
+	timeNow func() time.Time
+
+	mux    sync.RWMutex
+	served map[string]ServedStrategy
+}
+
+// WrapWithServedStrategies wraps ClientConfigManager and remembers the sampling strategies it serves.
+func WrapWithServedStrategies(manager ClientConfigManager) *ServedStrategiesManager {
+	return &ServedStrategiesManager{
+		wrapped: manager,
+		timeNow: time.Now,
+		served:  make(map[string]ServedStrategy),
+	}
+}
+
+// GetSamplingStrategy returns the sampling strategy of the wrapped manager and remembers it.
+func (m *ServedStrategiesManager) GetSamplingStrategy(ctx context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error) {
+	r, err := m.wrapped.GetSamplingStrategy(ctx, serviceName)
+	if err != nil {
+		return r, err
+	}
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	// remembers at most maxCachedServices services, like the cache
+	if _, ok := m.served[serviceName]; ok || len(m.served) < maxCachedServices {
+		m.served[serviceName] = ServedStrategy{Strategy: r, ServedAt: m.timeNow()}
+	}
+	return r, nil
+}
+
+// GetBaggageRestrictions delegates to the wrapped manager.
+func (m *ServedStrategiesManager) GetBaggageRestrictions(ctx context.Context, serviceName string) ([]*baggage.BaggageRestriction, error) {
+	return m.wrapped.GetBaggageRestrictions(ctx, serviceName)
+}
+
+// LastServed returns the sampling strategy last served to the service, if any.
+func (m *ServedStrategiesManager) LastServed(serviceName string) (ServedStrategy, bool) {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	s, ok := m.served[serviceName]
+	return s, ok
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServedStrategiesManager(t *testing.T) {
+	collector := &fakeCollectorManager{probability: 0.1}
+	clock := &testClock{now: time.Unix(1000, 0)}
+	m := WrapWithServedStrategies(collector)
+	m.timeNow = clock.Now
+
+	_, ok := m.LastServed("foo")
+	assert.False(t, ok)
+
+	assert.Equal(t, 0.1, samplingRate(t, m, "foo"))
+	served, ok := m.LastServed("foo")
+	require.True(t, ok)
+	assert.Equal(t, 0.1, served.Strategy.ProbabilisticSampling.SamplingRate)
+	assert.Equal(t, time.Unix(1000, 0), served.ServedAt)
+
+	clock.Advance(time.Minute)
+	collector.set(0.5, nil)
+	assert.Equal(t, 0.5, samplingRate(t, m, "foo"))
+	served, _ = m.LastServed("foo")
+	assert.Equal(t, 0.5, served.Strategy.ProbabilisticSampling.SamplingRate)
+	assert.Equal(t, time.Unix(1060, 0), served.ServedAt)
+
+	// failures do not replace the strategy last served
+	collector.set(0, errors.New("collector unavailable"))
+	_, err := m.GetSamplingStrategy(context.Background(), "foo")
+	require.Error(t, err)
+	served, _ = m.LastServed("foo")
+	assert.Equal(t, 0.5, served.Strategy.ProbabilisticSampling.SamplingRate)
+	_, ok = m.LastServed("bar")
+	assert.False(t, ok)
+
+	restrictions, err := m.GetBaggageRestrictions(context.Background(), "foo")
+	require.NoError(t, err)
+	assert.Len(t, restrictions, 1)
+}
+
+func TestServedStrategiesManagerBounded(t *testing.T) {
+	m := WrapWithServedStrategies(&fakeCollectorManager{probability: 0.1})
+	for i := 0; i < maxCachedServices; i++ {
+		m.served[fmt.Sprintf("service-%d", i)] = ServedStrategy{}
+	}
+	samplingRate(t, m, "new-service")
+	_, ok := m.LastServed("new-service")
+	assert.False(t, ok)
+}
@@ -31,8 +31,15 @@ const (
 	suffixServerMaxPacketSize    = "server-max-packet-size"
 	suffixServerSocketBufferSize = "server-socket-buffer-size"
 	suffixServerHostPort         = "server-host-port"
+	suffixServerTCPHostPort      = "server-tcp-host-port"
+	suffixServerUnixSocket       = "server-unix-socket"
 	// HTTPServerHostPort is the flag for HTTP endpoint
 	HTTPServerHostPort = "http-server.host-port"
+
+	httpServerSamplingCacheTTL  = "http-server.sampling-cache.ttl"
+	httpServerSamplingCacheFile = "http-server.sampling-cache.file"
+	httpServerAllowedOrigins    = "http-server.cors.allowed-origins"
+	httpServerAllowedHeaders    = "http-server.cors.allowed-headers"
 )
 
 var defaultProcessors = []struct {
@@ -54,7 +61,25 @@ func AddFlags(flags *flag.FlagSet) {
 		flags.Int(prefix+suffixServerMaxPacketSize, defaultMaxPacketSize, "max packet size for the UDP server")
 		flags.Int(prefix+suffixServerSocketBufferSize, 0, "socket buffer size for UDP packets in bytes")
 		flags.String(prefix+suffixServerHostPort, ":"+strconv.Itoa(p.port), "host:port for the UDP server")
+		flags.String(prefix+suffixServerTCPHostPort, "", "host:port for a TCP server receiving length-prefixed (framed) Thrift messages, disabled if empty")
+		flags.String(prefix+suffixServerUnixSocket, "", "path of a Unix domain socket receiving length-prefixed (framed) Thrift messages, disabled if empty")
 	}
+	flags.Duration(
+		httpServerSamplingCacheTTL,
+		0,
+		"how long sampling strategies fetched from the collector are served without a refresh; stale strategies are served while refreshed in the background, and when the collector is unreachable (0 disables the cache)")
+	flags.String(
+		httpServerSamplingCacheFile,
+		"",
+		"file to persist cached sampling strategies to, so that they are served after a restart before the collector is reachable")
+	flags.String(
+		httpServerAllowedOrigins,
+		"",
+		"Comma separated list of origins allowed to submit spans to the http server from browsers, cross-domain requests are rejected if empty")
+	flags.String(
+		httpServerAllowedHeaders,
+		"content-type",
+		"Comma separated list of headers allowed in cross-domain span submissions to the http server")
 	AddOTELFlags(flags)
 }
 
@@ -63,7 +88,7 @@ func AddOTELFlags(flags *flag.FlagSet) {
 	flags.String(
 		HTTPServerHostPort,
 		defaultHTTPServerHostPort,
-		"host:port of the http server (e.g. for /sampling point and /baggageRestrictions endpoint)")
+		"host:port of the http server (e.g. for /sampling point, /baggageRestrictions and span submission endpoints)")
 }
 
 // InitFromViper initializes Builder with properties retrieved from Viper.
@@ -77,9 +102,27 @@ func (b *Builder) InitFromViper(v *viper.Viper) *Builder {
 		p.Server.SocketBufferSize = v.GetInt(prefix + suffixServerSocketBufferSize)
 		p.Server.HostPort = portNumToHostPort(v.GetString(prefix + suffixServerHostPort))
 		b.Processors = append(b.Processors, *p)
+
+		// the stream servers share the configuration of the UDP server of the same model and protocol
+		if hostPort := v.GetString(prefix + suffixServerTCPHostPort); hostPort != "" {
+			tcp := *p
+			tcp.Server.Transport = tcpTransport
+			tcp.Server.HostPort = portNumToHostPort(hostPort)
+			b.Processors = append(b.Processors, tcp)
+		}
+		if socket := v.GetString(prefix + suffixServerUnixSocket); socket != "" {
+			unix := *p
+			unix.Server.Transport = unixTransport
+			unix.Server.HostPort = socket
+			b.Processors = append(b.Processors, unix)
+		}
 	}
 
 	b.HTTPServer.HostPort = portNumToHostPort(v.GetString(HTTPServerHostPort))
+	b.HTTPServer.SamplingCache.TTL = v.GetDuration(httpServerSamplingCacheTTL)
+	b.HTTPServer.SamplingCache.File = v.GetString(httpServerSamplingCacheFile)
+	b.HTTPServer.CORS.AllowedOrigins = v.GetString(httpServerAllowedOrigins)
+	b.HTTPServer.CORS.AllowedHeaders = v.GetString(httpServerAllowedHeaders)
 	return b
 }
 
@@ -18,6 +18,7 @@ package app
 import (
 	"flag"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -36,6 +37,9 @@ func TestBindFlags(t *testing.T) {
 
 	err := command.ParseFlags([]string{
 		"--http-server.host-port=:8080",
+		"--http-server.sampling-cache.ttl=1m",
+		"--http-server.sampling-cache.file=/tmp/sampling.json",
+		"--http-server.cors.allowed-origins=https://example.com",
 		"--processor.jaeger-binary.server-host-port=:1111",
 		"--processor.jaeger-binary.server-max-packet-size=4242",
 		"--processor.jaeger-binary.server-queue-size=42",
@@ -46,8 +50,43 @@ func TestBindFlags(t *testing.T) {
 	b.InitFromViper(v)
 	assert.Equal(t, 3, len(b.Processors))
 	assert.Equal(t, ":8080", b.HTTPServer.HostPort)
+	assert.Equal(t, time.Minute, b.HTTPServer.SamplingCache.TTL)
+	assert.Equal(t, "/tmp/sampling.json", b.HTTPServer.SamplingCache.File)
+	assert.Equal(t, "https://example.com", b.HTTPServer.CORS.AllowedOrigins)
+	assert.Equal(t, "content-type", b.HTTPServer.CORS.AllowedHeaders)
 	assert.Equal(t, ":1111", b.Processors[2].Server.HostPort)
 	assert.Equal(t, 4242, b.Processors[2].Server.MaxPacketSize)
 	assert.Equal(t, 42, b.Processors[2].Server.QueueSize)
 	assert.Equal(t, 42, b.Processors[2].Workers)
 }
+
+func TestBindFlagsStreamServers(t *testing.T) {
+	v := viper.New()
+	b := &Builder{}
+	command := cobra.Command{}
+	flags := &flag.FlagSet{}
+	AddFlags(flags)
+	command.PersistentFlags().AddGoFlagSet(flags)
+	v.BindPFlags(command.PersistentFlags())
+
+	err := command.ParseFlags([]string{
+		"--processor.jaeger-compact.server-tcp-host-port=6835",
+		"--processor.jaeger-compact.server-unix-socket=/var/run/jaeger/agent.sock",
+		"--processor.jaeger-compact.server-queue-size=42",
+	})
+	require.NoError(t, err)
+
+	b.InitFromViper(v)
+	require.Equal(t, 5, len(b.Processors))
+	udp, tcp, unix := b.Processors[1], b.Processors[2], b.Processors[3]
+	assert.Equal(t, Transport(""), udp.Server.Transport)
+	assert.Equal(t, tcpTransport, tcp.Server.Transport)
+	assert.Equal(t, ":6835", tcp.Server.HostPort)
+	assert.Equal(t, unixTransport, unix.Server.Transport)
+	assert.Equal(t, "/var/run/jaeger/agent.sock", unix.Server.HostPort)
+	for _, p := range []ProcessorConfiguration{tcp, unix} {
+		assert.Equal(t, jaegerModel, p.Model)
+		assert.Equal(t, compactProtocol, p.Protocol)
+		assert.Equal(t, 42, p.Server.QueueSize)
+	}
+}
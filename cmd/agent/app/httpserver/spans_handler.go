@@ -0,0 +1,280 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/go-openapi/loads"
+	"github.com/go-openapi/strfmt"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gorilla/mux"
+	"github.com/rs/cors"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/zipkin"
+	"github.com/jaegertracing/jaeger/model"
+	jConverter "github.com/jaegertracing/jaeger/model/converter/thrift/jaeger"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	"github.com/jaegertracing/jaeger/swagger-gen/restapi"
+	"github.com/jaegertracing/jaeger/swagger-gen/restapi/operations"
+	tJaeger "github.com/jaegertracing/jaeger/thrift-gen/jaeger"
+)
+
+const (
+	// maxRequestBodySize limits the size of a span submission, after decompression
+	maxRequestBodySize = 16 * 1024 * 1024
+	// reportTimeout bounds the time a request waits for the reporter
+	reportTimeout = 10 * time.Second
+)
+
+var (
+	acceptedThriftFormats = map[string]struct{}{
+		"application/x-thrift":                 {},
+		"application/vnd.apache.thrift.binary": {},
+	}
+
+	errMissingProcess = errors.New("span has no process")
+)
+
+// spansHandler receives spans over HTTP from clients that cannot use the UDP servers
+// and emits them through the reporter of the agent, like the spans received over UDP.
+type spansHandler struct {
+	reporter        reporter.Reporter
+	zipkinV2Formats strfmt.Registry
+	cors            *cors.Cors
+	logger          *zap.Logger
+	metrics         spansHandlerMetrics
+}
+
+type spansHandlerMetrics struct {
+	// Number of requests with Jaeger Thrift binary batches
+	ThriftRequests metrics.Counter `metric:"http-server.requests" tags:"type=spans-jaeger-thrift"`
+
+	// Number of requests with Jaeger protobuf-JSON batches
+	ProtoJSONRequests metrics.Counter `metric:"http-server.requests" tags:"type=spans-jaeger-json"`
+
+	// Number of requests with Zipkin v2 JSON spans
+	ZipkinV2Requests metrics.Counter `metric:"http-server.requests" tags:"type=spans-zipkin-v2"`
+
+	// Number of spans received over HTTP
+	SpansReceived metrics.Counter `metric:"http-server.spans"`
+
+	// Number of span submissions that could not be read or decoded
+	BadRequest metrics.Counter `metric:"http-server.errors" tags:"status=4xx,source=spans"`
+
+	// Number of span submissions the reporter failed to emit
+	ReporterFailures metrics.Counter `metric:"http-server.errors" tags:"status=5xx,source=reporter"`
+}
+
+func newSpansHandler(rep reporter.Reporter, corsOpts CORSOptions, mFactory metrics.Factory, logger *zap.Logger) *spansHandler {
+	swaggerSpec, _ := loads.Analyzed(restapi.SwaggerJSON, "")
+	handler := &spansHandler{
+		reporter:        rep,
+		zipkinV2Formats: operations.NewZipkinAPI(swaggerSpec).Formats(),
+		logger:          logger,
+	}
+	if corsOpts.AllowedOrigins != "" {
+		handler.cors = cors.New(cors.Options{
+			AllowedOrigins: splitList(corsOpts.AllowedOrigins),
+			AllowedMethods: []string{http.MethodPost}, // Allowing only POST, because that's the only handled one
+			AllowedHeaders: splitList(corsOpts.AllowedHeaders),
+		})
+	}
+	metrics.MustInit(&handler.metrics, mFactory, nil)
+	return handler
+}
+
+// RegisterRoutes registers the span submission routes on the given router.
+// With CORS enabled, the routes also answer the preflight requests of browsers.
+func (h *spansHandler) RegisterRoutes(router *mux.Router) {
+	h.handle(router, "/api/traces", h.saveJaegerBatch)
+	h.handle(router, "/api/v2/spans", h.saveZipkinV2Spans)
+}
+
+func (h *spansHandler) handle(router *mux.Router, path string, handler http.HandlerFunc) {
+	if h.cors == nil {
+		router.Handle(path, handler).Methods(http.MethodPost)
+		return
+	}
+	router.Handle(path, h.cors.Handler(handler)).Methods(http.MethodPost, http.MethodOptions)
+}
+
+// saveJaegerBatch accepts a Jaeger batch encoded with Thrift binary, or a PostSpansRequest
+// of the collector gRPC API encoded with protobuf-JSON.
+func (h *spansHandler) saveJaegerBatch(w http.ResponseWriter, r *http.Request) {
+	contentType, body, ok := h.readRequest(w, r)
+	if !ok {
+		return
+	}
+	var batches []*tJaeger.Batch
+	var err error
+	if _, ok := acceptedThriftFormats[contentType]; ok {
+		h.metrics.ThriftRequests.Inc(1)
+		batch := &tJaeger.Batch{}
+		if err = thrift.NewTDeserializer().Read(batch, body); err == nil {
+			batches = []*tJaeger.Batch{batch}
+		}
+	} else if contentType == "application/json" {
+		h.metrics.ProtoJSONRequests.Inc(1)
+		batches, err = protoJSONToThriftBatches(body)
+	} else {
+		h.badRequest(w, fmt.Sprintf("Unsupported Content-Type: %v", contentType))
+		return
+	}
+	if err != nil {
+		h.badRequest(w, fmt.Sprintf("Unable to process request body: %v", err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), reportTimeout)
+	defer cancel()
+	for _, batch := range batches {
+		h.metrics.SpansReceived.Inc(int64(len(batch.Spans)))
+		if err = h.reporter.EmitBatch(ctx, batch); err != nil {
+			h.reporterFailure(w, "Cannot submit Jaeger batch", err)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// saveZipkinV2Spans accepts spans encoded with Zipkin v2 JSON.
+func (h *spansHandler) saveZipkinV2Spans(w http.ResponseWriter, r *http.Request) {
+	contentType, body, ok := h.readRequest(w, r)
+	if !ok {
+		return
+	}
+	if contentType != "application/json" {
+		h.badRequest(w, fmt.Sprintf("Unsupported Content-Type: %v", contentType))
+		return
+	}
+	h.metrics.ZipkinV2Requests.Inc(1)
+	spans, err := zipkin.DeserializeJSONV2(body, h.zipkinV2Formats)
+	if err != nil {
+		h.badRequest(w, fmt.Sprintf("Unable to process request body: %v", err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), reportTimeout)
+	defer cancel()
+	h.metrics.SpansReceived.Inc(int64(len(spans)))
+	if err = h.reporter.EmitZipkinBatch(ctx, spans); err != nil {
+		h.reporterFailure(w, "Cannot submit Zipkin batch", err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// readRequest returns the media type and the uncompressed body of the request,
+// or writes an error response and returns false.
+func (h *spansHandler) readRequest(w http.ResponseWriter, r *http.Request) (string, []byte, bool) {
+	defer r.Body.Close()
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		h.badRequest(w, fmt.Sprintf("Cannot parse Content-Type: %v", err))
+		return "", nil, false
+	}
+	var body io.Reader = r.Body
+	if strings.Contains(r.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			h.badRequest(w, fmt.Sprintf("Unable to process request body: %v", err))
+			return "", nil, false
+		}
+		defer gz.Close()
+		body = gz
+	}
+	bodyBytes, err := ioutil.ReadAll(io.LimitReader(body, maxRequestBodySize+1))
+	if err != nil {
+		h.badRequest(w, fmt.Sprintf("Unable to process request body: %v", err))
+		return "", nil, false
+	}
+	if len(bodyBytes) > maxRequestBodySize {
+		h.metrics.BadRequest.Inc(1)
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return "", nil, false
+	}
+	return contentType, bodyBytes, true
+}
+
+func (h *spansHandler) badRequest(w http.ResponseWriter, msg string) {
+	h.metrics.BadRequest.Inc(1)
+	http.Error(w, msg, http.StatusBadRequest)
+}
+
+func (h *spansHandler) reporterFailure(w http.ResponseWriter, msg string, err error) {
+	h.metrics.ReporterFailures.Inc(1)
+	h.logger.Error(msg, zap.Error(err))
+	http.Error(w, fmt.Sprintf("%s: %v", msg, err), http.StatusInternalServerError)
+}
+
+func splitList(list string) []string {
+	return strings.Split(strings.ReplaceAll(list, " ", ""), ",")
+}
+
+// protoJSONToThriftBatches converts a PostSpansRequest in protobuf-JSON into Thrift batches,
+// one for each distinct process, since spans may override the process of the batch.
+func protoJSONToThriftBatches(body []byte) ([]*tJaeger.Batch, error) {
+	var req api_v2.PostSpansRequest
+	if err := jsonpb.Unmarshal(bytes.NewReader(body), &req); err != nil {
+		return nil, err
+	}
+	var processes []*model.Process
+	var spans [][]*model.Span
+	for _, span := range req.Batch.Spans {
+		process := span.Process
+		if process == nil {
+			process = req.Batch.Process
+		}
+		if process == nil {
+			return nil, errMissingProcess
+		}
+		idx := -1
+		for i := range processes {
+			if processes[i].Equal(process) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			idx = len(processes)
+			processes = append(processes, process)
+			spans = append(spans, nil)
+		}
+		spans[idx] = append(spans[idx], span)
+	}
+	batches := make([]*tJaeger.Batch, len(processes))
+	for i, process := range processes {
+		batches[i] = &tJaeger.Batch{
+			Process: jConverter.FromDomainProcess(process),
+			Spans:   jConverter.FromDomain(spans[i]),
+		}
+	}
+	return batches, nil
+}
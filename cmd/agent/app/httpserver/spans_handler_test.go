@@ -0,0 +1,287 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
+	"github.com/jaegertracing/jaeger/thrift-gen/zipkincore"
+)
+
+const zipkinV2JSON = `[{
+	"id": "1111111111111111",
+	"traceId": "2222222222222222",
+	"name": "get",
+	"timestamp": 1,
+	"duration": 10,
+	"localEndpoint": {"serviceName": "browser"}
+}]`
+
+type fakeReporter struct {
+	mux     sync.Mutex
+	batches []*jaeger.Batch
+	zSpans  []*zipkincore.Span
+	emitErr error
+}
+
+func (r *fakeReporter) EmitBatch(ctx context.Context, batch *jaeger.Batch) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.batches = append(r.batches, batch)
+	return r.emitErr
+}
+
+func (r *fakeReporter) EmitZipkinBatch(ctx context.Context, spans []*zipkincore.Span) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.zSpans = append(r.zSpans, spans...)
+	return r.emitErr
+}
+
+func newSpansServer(t *testing.T, rep *fakeReporter, cors CORSOptions) (*httptest.Server, *metricstest.Factory) {
+	mFactory := metricstest.NewFactory(0)
+	server := NewHTTPServerWithParams(HTTPServerParams{
+		HostPort:       ":0",
+		Reporter:       rep,
+		CORS:           cors,
+		MetricsFactory: mFactory,
+	})
+	ts := httptest.NewServer(server.Handler)
+	t.Cleanup(ts.Close)
+	return ts, mFactory
+}
+
+func post(t *testing.T, url, contentType string, body []byte, headers ...string) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+func TestSpansHandlerJaegerThrift(t *testing.T) {
+	rep := &fakeReporter{}
+	ts, mFactory := newSpansServer(t, rep, CORSOptions{})
+
+	batch := &jaeger.Batch{
+		Process: &jaeger.Process{ServiceName: "lambda"},
+		Spans:   []*jaeger.Span{{TraceIdLow: 1, SpanId: 2, OperationName: "invoke"}},
+	}
+	body, err := thrift.NewTSerializer().Write(context.Background(), batch)
+	require.NoError(t, err)
+
+	for _, contentType := range []string{"application/x-thrift", "application/vnd.apache.thrift.binary"} {
+		resp := post(t, ts.URL+"/api/traces", contentType, body)
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	}
+	require.Len(t, rep.batches, 2)
+	assert.Equal(t, batch, rep.batches[0])
+
+	mFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "http-server.requests", Tags: map[string]string{"type": "spans-jaeger-thrift"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "http-server.spans", Value: 2},
+	)
+}
+
+func TestSpansHandlerJaegerProtoJSON(t *testing.T) {
+	rep := &fakeReporter{}
+	ts, mFactory := newSpansServer(t, rep, CORSOptions{})
+
+	req := &api_v2.PostSpansRequest{Batch: model.Batch{
+		Process: model.NewProcess("browser", nil),
+		Spans: []*model.Span{
+			{TraceID: model.NewTraceID(0, 1), SpanID: 1, OperationName: "click"},
+			{TraceID: model.NewTraceID(0, 1), SpanID: 2, OperationName: "fetch", Process: model.NewProcess("worker", []model.KeyValue{model.String("k", "v")})},
+			{TraceID: model.NewTraceID(0, 1), SpanID: 3, OperationName: "render"},
+		},
+	}}
+	body := &bytes.Buffer{}
+	require.NoError(t, new(jsonpb.Marshaler).Marshal(body, req))
+
+	resp := post(t, ts.URL+"/api/traces", "application/json; charset=utf-8", body.Bytes())
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	require.Len(t, rep.batches, 2)
+	assert.Equal(t, "browser", rep.batches[0].Process.ServiceName)
+	require.Len(t, rep.batches[0].Spans, 2)
+	assert.Equal(t, "click", rep.batches[0].Spans[0].OperationName)
+	assert.Equal(t, "render", rep.batches[0].Spans[1].OperationName)
+	assert.Equal(t, "worker", rep.batches[1].Process.ServiceName)
+	assert.Equal(t, []*jaeger.Tag{{Key: "k", VType: jaeger.TagType_STRING, VStr: stringPtr("v")}}, rep.batches[1].Process.Tags)
+	require.Len(t, rep.batches[1].Spans, 1)
+	assert.Equal(t, "fetch", rep.batches[1].Spans[0].OperationName)
+
+	mFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "http-server.requests", Tags: map[string]string{"type": "spans-jaeger-json"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "http-server.spans", Value: 3},
+	)
+}
+
+func TestSpansHandlerZipkinV2(t *testing.T) {
+	rep := &fakeReporter{}
+	ts, mFactory := newSpansServer(t, rep, CORSOptions{})
+
+	resp := post(t, ts.URL+"/api/v2/spans", "application/json", []byte(zipkinV2JSON))
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	gzipped := &bytes.Buffer{}
+	gz := gzip.NewWriter(gzipped)
+	_, err := gz.Write([]byte(zipkinV2JSON))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	resp = post(t, ts.URL+"/api/v2/spans", "application/json", gzipped.Bytes(), "Content-Encoding", "gzip")
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	require.Len(t, rep.zSpans, 2)
+	assert.Equal(t, "get", rep.zSpans[0].Name)
+	assert.Equal(t, int64(0x1111111111111111), rep.zSpans[0].ID)
+
+	mFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "http-server.requests", Tags: map[string]string{"type": "spans-zipkin-v2"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "http-server.spans", Value: 2},
+	)
+}
+
+func TestSpansHandlerBadRequests(t *testing.T) {
+	rep := &fakeReporter{}
+	ts, mFactory := newSpansServer(t, rep, CORSOptions{})
+
+	noProcess := &bytes.Buffer{}
+	require.NoError(t, new(jsonpb.Marshaler).Marshal(noProcess, &api_v2.PostSpansRequest{Batch: model.Batch{
+		Spans: []*model.Span{{TraceID: model.NewTraceID(0, 1), SpanID: 1}},
+	}}))
+
+	testCases := []struct {
+		name        string
+		path        string
+		contentType string
+		body        []byte
+		headers     []string
+		status      int
+		message     string
+	}{
+		{name: "thrift", path: "/api/traces", contentType: "application/x-thrift", body: []byte("bad"), status: http.StatusBadRequest, message: "Unable to process request body"},
+		{name: "proto json", path: "/api/traces", contentType: "application/json", body: []byte("{bad"), status: http.StatusBadRequest, message: "Unable to process request body"},
+		{name: "no process", path: "/api/traces", contentType: "application/json", body: noProcess.Bytes(), status: http.StatusBadRequest, message: errMissingProcess.Error()},
+		{name: "jaeger content type", path: "/api/traces", contentType: "text/plain", status: http.StatusBadRequest, message: "Unsupported Content-Type: text/plain"},
+		{name: "invalid content type", path: "/api/traces", contentType: "application/json;;", status: http.StatusBadRequest, message: "Cannot parse Content-Type"},
+		{name: "zipkin", path: "/api/v2/spans", contentType: "application/json", body: []byte("[{}]"), status: http.StatusBadRequest, message: "Unable to process request body"},
+		{name: "zipkin content type", path: "/api/v2/spans", contentType: "application/x-protobuf", status: http.StatusBadRequest, message: "Unsupported Content-Type: application/x-protobuf"},
+		{name: "gzip", path: "/api/v2/spans", contentType: "application/json", body: []byte(zipkinV2JSON), headers: []string{"Content-Encoding", "gzip"}, status: http.StatusBadRequest, message: "Unable to process request body"},
+		{name: "too large", path: "/api/traces", contentType: "application/json", body: make([]byte, maxRequestBodySize+1), status: http.StatusRequestEntityTooLarge, message: "Request body too large"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, ts.URL+tc.path, bytes.NewReader(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", tc.contentType)
+			for i := 0; i+1 < len(tc.headers); i += 2 {
+				req.Header.Set(tc.headers[i], tc.headers[i+1])
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.status, resp.StatusCode)
+			buf := &bytes.Buffer{}
+			_, err = buf.ReadFrom(resp.Body)
+			require.NoError(t, err)
+			assert.Contains(t, buf.String(), tc.message)
+		})
+	}
+	assert.Empty(t, rep.batches)
+	assert.Empty(t, rep.zSpans)
+	mFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "http-server.errors", Tags: map[string]string{"status": "4xx", "source": "spans"}, Value: len(testCases),
+	})
+}
+
+func TestSpansHandlerReporterFailure(t *testing.T) {
+	rep := &fakeReporter{emitErr: errors.New("collector unavailable")}
+	ts, mFactory := newSpansServer(t, rep, CORSOptions{})
+
+	body, err := thrift.NewTSerializer().Write(context.Background(), &jaeger.Batch{Process: &jaeger.Process{ServiceName: "lambda"}})
+	require.NoError(t, err)
+	resp := post(t, ts.URL+"/api/traces", "application/x-thrift", body)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	resp = post(t, ts.URL+"/api/v2/spans", "application/json", []byte(zipkinV2JSON))
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	mFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "http-server.errors", Tags: map[string]string{"status": "5xx", "source": "reporter"}, Value: 2,
+	})
+}
+
+func TestSpansHandlerCORS(t *testing.T) {
+	preflight := func(t *testing.T, url string) *http.Response {
+		req, err := http.NewRequest(http.MethodOptions, url+"/api/v2/spans", nil)
+		require.NoError(t, err)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	ts, _ := newSpansServer(t, &fakeReporter{}, CORSOptions{AllowedOrigins: "https://app.example.com, https://other.example.com", AllowedHeaders: "content-type"})
+	resp := preflight(t, ts.URL)
+	assert.Equal(t, "https://app.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+	assert.True(t, strings.EqualFold("content-type", resp.Header.Get("Access-Control-Allow-Headers")))
+
+	ts, _ = newSpansServer(t, &fakeReporter{}, CORSOptions{})
+	resp = preflight(t, ts.URL)
+	assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+
+	// the agent exposes the router of the server to add routes
+	server := NewHTTPServerWithParams(HTTPServerParams{Reporter: &fakeReporter{}, CORS: CORSOptions{AllowedOrigins: "*"}})
+	assert.IsType(t, &mux.Router{}, server.Handler)
+}
+
+func TestHTTPServerWithoutReporter(t *testing.T) {
+	server := NewHTTPServerWithParams(HTTPServerParams{HostPort: ":0"})
+	ts := httptest.NewServer(server.Handler)
+	defer ts.Close()
+	resp := post(t, ts.URL+"/api/traces", "application/x-thrift", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
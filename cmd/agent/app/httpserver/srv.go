@@ -20,20 +20,58 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
 
 	"github.com/jaegertracing/jaeger/cmd/agent/app/configmanager"
+	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter"
 	"github.com/jaegertracing/jaeger/pkg/clientcfg/clientcfghttp"
 )
 
+// CORSOptions holds the comma separated origins and headers allowed in cross-domain span submissions
+type CORSOptions struct {
+	AllowedOrigins string `yaml:"allowedOrigins"`
+	AllowedHeaders string `yaml:"allowedHeaders"`
+}
+
+// HTTPServerParams holds the parameters of a server created with NewHTTPServerWithParams
+type HTTPServerParams struct {
+	HostPort string
+	Manager  configmanager.ClientConfigManager
+	// Reporter receives the spans submitted over HTTP, span submission is disabled if nil
+	Reporter reporter.Reporter
+	// CORS configures cross-domain span submissions, which are rejected if CORS.AllowedOrigins is empty
+	CORS           CORSOptions
+	MetricsFactory metrics.Factory
+	Logger         *zap.Logger
+}
+
 // NewHTTPServer creates a new server that hosts an HTTP/JSON endpoint for clients
 // to query for sampling strategies and baggage restrictions.
 func NewHTTPServer(hostPort string, manager configmanager.ClientConfigManager, mFactory metrics.Factory) *http.Server {
+	return NewHTTPServerWithParams(HTTPServerParams{
+		HostPort:       hostPort,
+		Manager:        manager,
+		MetricsFactory: mFactory,
+	})
+}
+
+// NewHTTPServerWithParams creates a new server that hosts an HTTP/JSON endpoint for clients
+// to query for sampling strategies and baggage restrictions, and, if a reporter is given,
+// endpoints for clients to submit spans in Jaeger Thrift, Jaeger protobuf-JSON or Zipkin v2 JSON.
+func NewHTTPServerWithParams(params HTTPServerParams) *http.Server {
+	if params.Logger == nil {
+		params.Logger = zap.NewNop()
+	}
 	handler := clientcfghttp.NewHTTPHandler(clientcfghttp.HTTPHandlerParams{
-		ConfigManager:          manager,
-		MetricsFactory:         mFactory,
+		ConfigManager:          params.Manager,
+		MetricsFactory:         params.MetricsFactory,
 		LegacySamplingEndpoint: true,
 	})
 	r := mux.NewRouter()
 	handler.RegisterRoutes(r)
-	return &http.Server{Addr: hostPort, Handler: r}
+	if params.Reporter != nil {
+		spans := newSpansHandler(params.Reporter, params.CORS, params.MetricsFactory, params.Logger)
+		spans.RegisterRoutes(r)
+	}
+	return &http.Server{Addr: params.HostPort, Handler: r}
 }
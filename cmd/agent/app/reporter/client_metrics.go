@@ -16,6 +16,7 @@ package reporter
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -61,12 +62,34 @@ type clientMetrics struct {
 type lastReceivedClientStats struct {
 	lock        sync.Mutex
 	lastUpdated time.Time
+	serviceName string
 
 	// Thrift stats are reported as signed i64, so keep the type to avoid multiple conversions back and forth.
 	batchSeqNo            int64
 	fullQueueDroppedSpans int64
 	tooLargeDroppedSpans  int64
 	failedToEmitSpans     int64
+
+	// totals since the client was first seen, for diagnostics
+	firstSeqNo      int64
+	batchesReceived int64
+	spansReceived   int64
+}
+
+// ClientStats describes the recent traffic received from a client, for diagnostics.
+type ClientStats struct {
+	ClientUUID  string    `json:"clientUUID"`
+	ServiceName string    `json:"serviceName"`
+	LastSeen    time.Time `json:"lastSeen"`
+	// BatchesReceived and SpansReceived count the data received since the client was first seen
+	BatchesReceived int64 `json:"batchesReceived"`
+	SpansReceived   int64 `json:"spansReceived"`
+	// DroppedBatches counts the batches sent by the client but never received, from the gaps in their sequence numbers
+	DroppedBatches int64 `json:"droppedBatches"`
+	// The counts of spans dropped by the client itself, as last reported by the client
+	FullQueueDroppedSpans int64 `json:"fullQueueDroppedSpans"`
+	TooLargeDroppedSpans  int64 `json:"tooLargeDroppedSpans"`
+	FailedToEmitSpans     int64 `json:"failedToEmitSpans"`
 }
 
 // ClientMetricsReporter is a decorator that emits data loss metrics on behalf of clients.
@@ -181,6 +204,24 @@ func (r *ClientMetricsReporter) updateClientMetrics(batch *jaeger.Batch) {
 	}
 	clientStats := entry.(*lastReceivedClientStats)
 	clientStats.update(*batch.SeqNo, batch.Stats, r.clientMetrics)
+	clientStats.record(batch.Process.ServiceName, *batch.SeqNo, len(batch.Spans))
+}
+
+// ClientStats returns the stats of the clients heard from within the expiration TTL,
+// sorted by service name and client UUID.
+func (r *ClientMetricsReporter) ClientStats() []ClientStats {
+	var clients []ClientStats
+	r.lastReceivedClientStats.Range(func(k, v interface{}) bool {
+		clients = append(clients, v.(*lastReceivedClientStats).snapshot(k.(string)))
+		return true
+	})
+	sort.Slice(clients, func(i, j int) bool {
+		if clients[i].ServiceName != clients[j].ServiceName {
+			return clients[i].ServiceName < clients[j].ServiceName
+		}
+		return clients[i].ClientUUID < clients[j].ClientUUID
+	})
+	return clients
 }
 
 func (s *lastReceivedClientStats) update(
@@ -219,6 +260,41 @@ func (s *lastReceivedClientStats) update(
 	}
 }
 
+func (s *lastReceivedClientStats) record(serviceName string, batchSeqNo int64, spans int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.batchesReceived == 0 || batchSeqNo < s.firstSeqNo {
+		s.firstSeqNo = batchSeqNo
+	}
+	s.serviceName = serviceName
+	s.batchesReceived++
+	s.spansReceived += int64(spans)
+}
+
+func (s *lastReceivedClientStats) snapshot(clientUUID string) ClientStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var dropped int64
+	if s.batchesReceived > 0 {
+		if dropped = s.batchSeqNo - s.firstSeqNo + 1 - s.batchesReceived; dropped < 0 {
+			dropped = 0
+		}
+	}
+	return ClientStats{
+		ClientUUID:            clientUUID,
+		ServiceName:           s.serviceName,
+		LastSeen:              s.lastUpdated,
+		BatchesReceived:       s.batchesReceived,
+		SpansReceived:         s.spansReceived,
+		DroppedBatches:        dropped,
+		FullQueueDroppedSpans: s.fullQueueDroppedSpans,
+		TooLargeDroppedSpans:  s.tooLargeDroppedSpans,
+		FailedToEmitSpans:     s.failedToEmitSpans,
+	}
+}
+
 func clientUUID(batch *jaeger.Batch) string {
 	if batch.Process == nil {
 		return ""
@@ -223,6 +223,49 @@ func TestClientMetricsReporter_ClientUUID(t *testing.T) {
 	}
 }
 
+func TestClientMetricsReporter_ClientStats(t *testing.T) {
+	testClientMetrics(func(tr *clientMetricsTest) {
+		nPtr := func(v int64) *int64 { return &v }
+		batch := func(service, clientUUID string, seqNo int64, spans int, stats *jaeger.ClientStats) *jaeger.Batch {
+			return &jaeger.Batch{
+				Spans: make([]*jaeger.Span, spans),
+				Process: &jaeger.Process{
+					ServiceName: service,
+					Tags:        []*jaeger.Tag{{Key: "client-uuid", VStr: &clientUUID}},
+				},
+				SeqNo: nPtr(seqNo),
+				Stats: stats,
+			}
+		}
+		assert.Empty(t, tr.r.ClientStats())
+
+		batches := []*jaeger.Batch{
+			batch("frontend", "b", 10, 2, nil),
+			batch("frontend", "b", 11, 3, nil),
+			// batches 12 and 13 are lost, 15 arrives before 14
+			batch("frontend", "b", 15, 1, nil),
+			batch("frontend", "b", 14, 1, &jaeger.ClientStats{FullQueueDroppedSpans: 1}),
+			batch("backend", "c", 1, 5, &jaeger.ClientStats{TooLargeDroppedSpans: 2, FailedToEmitSpans: 3}),
+			batch("frontend", "a", 7, 1, nil),
+		}
+		for _, b := range batches {
+			require.NoError(t, tr.r.EmitBatch(context.Background(), b))
+		}
+
+		clients := tr.r.ClientStats()
+		require.Len(t, clients, 3)
+		for i := range clients {
+			assert.False(t, clients[i].LastSeen.IsZero())
+			clients[i].LastSeen = time.Time{}
+		}
+		assert.Equal(t, []ClientStats{
+			{ClientUUID: "c", ServiceName: "backend", BatchesReceived: 1, SpansReceived: 5, TooLargeDroppedSpans: 2, FailedToEmitSpans: 3},
+			{ClientUUID: "a", ServiceName: "frontend", BatchesReceived: 1, SpansReceived: 1},
+			{ClientUUID: "b", ServiceName: "frontend", BatchesReceived: 4, SpansReceived: 7, DroppedBatches: 2},
+		}, clients)
+	})
+}
+
 func TestClientMetricsReporter_Expire(t *testing.T) {
 	const expireTTL = 50 * time.Millisecond
 	params := ClientMetricsReporterParams{
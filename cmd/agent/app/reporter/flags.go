@@ -31,6 +31,10 @@ const (
 	// AgentTagsDeprecated is a configuration property name for adding process tags to incoming spans.
 	AgentTagsDeprecated = "jaeger.tags"
 	agentTags           = "agent.tags"
+	dropOperations      = "agent.drop-operations"
+	redactionRulesFile  = "agent.redaction.rules-file"
+	hostMetadataFiles   = "agent.host-metadata.files"
+	hostLabelsFiles     = "agent.host-metadata.labels-files"
 	// GRPC is name of gRPC reporter.
 	GRPC Type = "grpc"
 )
@@ -42,6 +46,7 @@ type Type string
 type Options struct {
 	ReporterType Type
 	AgentTags    map[string]string
+	Processing   ProcessingOptions
 }
 
 // AddFlags adds flags for Options.
@@ -50,6 +55,10 @@ func AddFlags(flags *flag.FlagSet) {
 	if !setupcontext.IsAllInOne() {
 		flags.String(AgentTagsDeprecated, "", "(deprecated) see --"+agentTags)
 		flags.String(agentTags, "", "One or more tags to be added to the Process tags of all spans passing through this agent. Ex: key1=value1,key2=${envVar:defaultValue}")
+		flags.String(dropOperations, "", "A regular expression matching the operation names of spans dropped by this agent instead of being reported. Ex: ^GET /health$")
+		flags.String(redactionRulesFile, "", "The path for the file with rules in JSON format for hashing, masking or removing sensitive values of span tags, log fields and process tags before spans leave the host. Redaction is disabled if empty")
+		flags.String(hostMetadataFiles, "", "One or more tags to be added to the Process tags of all spans, with their values read from files, e.g. a node name or an availability zone. Ex: k8s.node.name=/etc/podinfo/nodename,host.zone=${ZONE_FILE}")
+		flags.String(hostLabelsFiles, "", "One or more prefixes of tags to be added to the Process tags of all spans for the key=\"value\" lines of files, e.g. pod labels from the Kubernetes downward API. Ex: k8s.pod.label.=/etc/podinfo/labels")
 	}
 }
 
@@ -64,6 +73,10 @@ func (b *Options) InitFromViper(v *viper.Viper, logger *zap.Logger) *Options {
 		if len(v.GetString(agentTags)) > 0 {
 			b.AgentTags = flags.ParseJaegerTags(v.GetString(agentTags))
 		}
+		b.Processing.DropOperations = v.GetString(dropOperations)
+		b.Processing.RedactionRulesFile = v.GetString(redactionRulesFile)
+		b.Processing.HostMetadataFiles = flags.ParseJaegerTags(v.GetString(hostMetadataFiles))
+		b.Processing.HostLabelsFiles = flags.ParseJaegerTags(v.GetString(hostLabelsFiles))
 	}
 	return b
 }
@@ -92,6 +92,32 @@ func TestBindFlags(t *testing.T) {
 	assert.Equal(t, expectedTags, b.AgentTags)
 }
 
+func TestBindFlagsProcessing(t *testing.T) {
+	v := viper.New()
+	command := cobra.Command{}
+	flags := &flag.FlagSet{}
+	AddFlags(flags)
+	command.PersistentFlags().AddGoFlagSet(flags)
+	v.BindPFlags(command.PersistentFlags())
+
+	err := command.ParseFlags([]string{
+		"--agent.drop-operations=^GET /health$",
+		"--agent.redaction.rules-file=/etc/jaeger/redaction.json",
+		"--agent.host-metadata.files=k8s.node.name=/etc/podinfo/nodename,host.zone=/etc/zone",
+		"--agent.host-metadata.labels-files=k8s.pod.label.=/etc/podinfo/labels",
+	})
+	require.NoError(t, err)
+
+	b := &Options{}
+	b.InitFromViper(v, zap.NewNop())
+	assert.Equal(t, ProcessingOptions{
+		DropOperations:     "^GET /health$",
+		RedactionRulesFile: "/etc/jaeger/redaction.json",
+		HostMetadataFiles:  map[string]string{"k8s.node.name": "/etc/podinfo/nodename", "host.zone": "/etc/zone"},
+		HostLabelsFiles:    map[string]string{"k8s.pod.label.": "/etc/podinfo/labels"},
+	}, b.Processing)
+}
+
 func TestBindFlagsAllInOne(t *testing.T) {
 
 	setupcontext.SetAllInOne()
@@ -0,0 +1,272 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// minAgeCheckInterval bounds how often pending batches are checked for their age
+const minAgeCheckInterval = 10 * time.Millisecond
+
+// BatchOptions configures merging the spans received by the agent into larger batches before sending them to collectors.
+type BatchOptions struct {
+	// MaxSpans is the number of spans at which a batch is sent, zero disables batching
+	MaxSpans int
+	// MaxAge is the max time spans are held in a batch before it is sent
+	MaxAge time.Duration
+	// MaxPendingSpans is the max number of spans held in the batches of all processes, the oldest
+	// batches are sent early when it is exceeded. Zero means no limit.
+	MaxPendingSpans int
+}
+
+type batcherMetrics struct {
+	// Number of batches flushed because they reached the max number of spans
+	FlushedBySize metrics.Counter `metric:"flushes" tags:"reason=size"`
+
+	// Number of batches flushed because they reached the max age
+	FlushedByAge metrics.Counter `metric:"flushes" tags:"reason=age"`
+
+	// Number of batches flushed early because the spans of all batches exceeded the max pending spans
+	FlushedByLimit metrics.Counter `metric:"flushes" tags:"reason=pending-limit"`
+
+	// Number of batches flushed when the reporter is closed
+	FlushedOnClose metrics.Counter `metric:"flushes" tags:"reason=close"`
+
+	// Number of batches that could not be sent to collectors
+	FlushFailures metrics.Counter `metric:"flush-failures"`
+
+	// Number of spans in the batches sent to collectors
+	BatchSize metrics.Histogram `metric:"batch-size" buckets:"1,10,50,100,250,500,1000,2500,5000"`
+
+	// Number of spans waiting in batches
+	PendingSpans metrics.Gauge `metric:"pending-spans"`
+}
+
+// pendingBatch holds the spans of a single process waiting to be sent
+type pendingBatch struct {
+	process *model.Process
+	spans   []*model.Span
+	created time.Time
+	// formats counts the spans of each data format, to report the spans that could not be sent
+	formats map[string]int
+}
+
+// batcher merges spans of the same process into batches, that are sent once they
+// reach the max number of spans or the max age, whichever comes first.
+type batcher struct {
+	options BatchOptions
+	send    func(ctx context.Context, batch model.Batch) error
+	failed  func(format string, spans int64)
+	logger  *zap.Logger
+	metrics batcherMetrics
+	timeNow func() time.Time
+
+	lock         sync.Mutex
+	batches      map[uint64][]*pendingBatch
+	pendingSpans int
+
+	stopCh    chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// newBatcher creates a batcher that sends the batches with send, and reports the spans
+// of the batches that could not be sent to failed, if not nil.
+func newBatcher(
+	options BatchOptions,
+	send func(ctx context.Context, batch model.Batch) error,
+	failed func(format string, spans int64),
+	mFactory metrics.Factory,
+	logger *zap.Logger,
+) *batcher {
+	b := &batcher{
+		options: options,
+		send:    send,
+		failed:  failed,
+		logger:  logger,
+		timeNow: time.Now,
+		batches: make(map[uint64][]*pendingBatch),
+		stopCh:  make(chan struct{}),
+	}
+	metrics.MustInit(&b.metrics, mFactory.Namespace(metrics.NSOptions{Name: "reporter.batcher"}), nil)
+	b.wg.Add(1)
+	go b.flushOldBatches()
+	return b
+}
+
+// add groups the spans of the data format by process and adds them to the pending batches. The spans
+// use their own process if set, otherwise the given one. Batches that reach the max number of spans,
+// and the oldest batches while the max pending spans is exceeded, are sent in the calling goroutine.
+func (b *batcher) add(spans []*model.Span, process *model.Process, format string) {
+	var full, oldest []*pendingBatch
+	b.lock.Lock()
+	for _, span := range spans {
+		spanProcess := process
+		if span.Process != nil {
+			spanProcess = span.Process
+			span.Process = nil
+		}
+		batch := b.batchFor(spanProcess)
+		batch.spans = append(batch.spans, span)
+		batch.formats[format]++
+		b.pendingSpans++
+		if len(batch.spans) >= b.options.MaxSpans {
+			full = append(full, b.remove(batch))
+		}
+	}
+	for b.options.MaxPendingSpans > 0 && b.pendingSpans > b.options.MaxPendingSpans {
+		oldest = append(oldest, b.remove(b.oldest()))
+	}
+	b.metrics.PendingSpans.Update(int64(b.pendingSpans))
+	b.lock.Unlock()
+
+	for _, batch := range full {
+		b.flush(batch, b.metrics.FlushedBySize)
+	}
+	for _, batch := range oldest {
+		b.flush(batch, b.metrics.FlushedByLimit)
+	}
+}
+
+// oldest returns the pending batch created first. Must be called with the lock held and pending batches.
+func (b *batcher) oldest() *pendingBatch {
+	var oldest *pendingBatch
+	for _, batches := range b.batches {
+		for _, batch := range batches {
+			if oldest == nil || batch.created.Before(oldest.created) {
+				oldest = batch
+			}
+		}
+	}
+	return oldest
+}
+
+// batchFor returns the pending batch of the process, creating it if needed. Must be called with the lock held.
+func (b *batcher) batchFor(process *model.Process) *pendingBatch {
+	key := processKey(process)
+	for _, batch := range b.batches[key] {
+		if sameProcess(batch.process, process) {
+			return batch
+		}
+	}
+	batch := &pendingBatch{process: process, created: b.timeNow(), formats: make(map[string]int)}
+	b.batches[key] = append(b.batches[key], batch)
+	return batch
+}
+
+// remove removes the batch from the pending ones. Must be called with the lock held.
+func (b *batcher) remove(batch *pendingBatch) *pendingBatch {
+	key := processKey(batch.process)
+	batches := b.batches[key]
+	for i := range batches {
+		if batches[i] == batch {
+			batches = append(batches[:i], batches[i+1:]...)
+			break
+		}
+	}
+	if len(batches) == 0 {
+		delete(b.batches, key)
+	} else {
+		b.batches[key] = batches
+	}
+	b.pendingSpans -= len(batch.spans)
+	return batch
+}
+
+// removeAll removes the pending batches created before the deadline, or all of them if the deadline is zero
+func (b *batcher) removeAll(deadline time.Time) []*pendingBatch {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	var removed []*pendingBatch
+	for _, batches := range b.batches {
+		for _, batch := range batches {
+			if deadline.IsZero() || !batch.created.After(deadline) {
+				removed = append(removed, batch)
+			}
+		}
+	}
+	for _, batch := range removed {
+		b.remove(batch)
+	}
+	b.metrics.PendingSpans.Update(int64(b.pendingSpans))
+	return removed
+}
+
+func (b *batcher) flushOldBatches() {
+	defer b.wg.Done()
+	interval := b.options.MaxAge / 4
+	if interval < minAgeCheckInterval {
+		interval = minAgeCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, batch := range b.removeAll(b.timeNow().Add(-b.options.MaxAge)) {
+				b.flush(batch, b.metrics.FlushedByAge)
+			}
+		case <-b.stopCh:
+			return
+		}
+	}
+}
+
+func (b *batcher) flush(batch *pendingBatch, reason metrics.Counter) {
+	reason.Inc(1)
+	b.metrics.BatchSize.Record(float64(len(batch.spans)))
+	if err := b.send(context.Background(), model.Batch{Process: batch.process, Spans: batch.spans}); err != nil {
+		b.metrics.FlushFailures.Inc(1)
+		if b.failed != nil {
+			for format, spans := range batch.formats {
+				b.failed(format, int64(spans))
+			}
+		}
+	}
+}
+
+// close stops flushing batches by age and sends all pending batches
+func (b *batcher) close() {
+	b.closeOnce.Do(func() {
+		close(b.stopCh)
+		b.wg.Wait()
+		for _, batch := range b.removeAll(time.Time{}) {
+			b.flush(batch, b.metrics.FlushedOnClose)
+		}
+	})
+}
+
+func processKey(process *model.Process) uint64 {
+	if process == nil {
+		return 0
+	}
+	key, _ := model.HashCode(process)
+	return key
+}
+
+func sameProcess(p1, p2 *model.Process) bool {
+	if p1 == nil || p2 == nil {
+		return p1 == p2
+	}
+	return p1.Equal(p2)
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter"
+	"github.com/jaegertracing/jaeger/model"
+)
+
+type mockBatchSender struct {
+	mux     sync.Mutex
+	err     error
+	batches []model.Batch
+}
+
+func (s *mockBatchSender) send(_ context.Context, batch model.Batch) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.batches = append(s.batches, batch)
+	return s.err
+}
+
+func (s *mockBatchSender) getBatches() []model.Batch {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.batches
+}
+
+func makeSpans(operations ...string) []*model.Span {
+	spans := make([]*model.Span, len(operations))
+	for i, operation := range operations {
+		spans[i] = &model.Span{OperationName: operation}
+	}
+	return spans
+}
+
+func TestBatcherFlushBySize(t *testing.T) {
+	sender := &mockBatchSender{}
+	mFactory := metricstest.NewFactory(time.Hour)
+	b := newBatcher(BatchOptions{MaxSpans: 3, MaxAge: time.Hour}, sender.send, nil, mFactory, zap.NewNop())
+	defer b.close()
+
+	nodeProcess := model.NewProcess("node", []model.KeyValue{model.String("ip", "10.0.0.1")})
+	b.add(makeSpans("a", "b"), nodeProcess, reporter.JaegerFormat)
+	b.add(makeSpans("c"), model.NewProcess("java", nil), reporter.JaegerFormat)
+	assert.Empty(t, sender.getBatches())
+
+	// spans of an equal process are merged into the same batch
+	b.add(makeSpans("d", "e"), model.NewProcess("node", []model.KeyValue{model.String("ip", "10.0.0.1")}), reporter.JaegerFormat)
+	batches := sender.getBatches()
+	require.Len(t, batches, 1)
+	assert.Equal(t, model.Batch{Process: nodeProcess, Spans: makeSpans("a", "b", "d")}, batches[0])
+
+	mFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "reporter.batcher.flushes", Tags: map[string]string{"reason": "size"}, Value: 1,
+	})
+	mFactory.AssertGaugeMetrics(t, metricstest.ExpectedMetric{
+		Name: "reporter.batcher.pending-spans", Value: 2,
+	})
+}
+
+func TestBatcherSpanProcess(t *testing.T) {
+	sender := &mockBatchSender{}
+	b := newBatcher(BatchOptions{MaxSpans: 10, MaxAge: time.Hour}, sender.send, nil, metricstest.NewFactory(time.Hour), zap.NewNop())
+
+	spans := makeSpans("a", "b", "c")
+	spans[0].Process = model.NewProcess("zipkin-service", nil)
+	spans[2].Process = model.NewProcess("zipkin-service", nil)
+	b.add(spans, nil, reporter.JaegerFormat)
+	b.close()
+
+	batches := sender.getBatches()
+	require.Len(t, batches, 2)
+	byProcess := make(map[string][]*model.Span)
+	for _, batch := range batches {
+		service := ""
+		if batch.Process != nil {
+			service = batch.Process.ServiceName
+		}
+		byProcess[service] = batch.Spans
+	}
+	assert.Equal(t, makeSpans("a", "c"), byProcess["zipkin-service"])
+	assert.Equal(t, makeSpans("b"), byProcess[""])
+}
+
+func TestBatcherFlushByAge(t *testing.T) {
+	sender := &mockBatchSender{}
+	mFactory := metricstest.NewFactory(time.Hour)
+	b := newBatcher(BatchOptions{MaxSpans: 100, MaxAge: 10 * time.Millisecond}, sender.send, nil, mFactory, zap.NewNop())
+	defer b.close()
+
+	b.add(makeSpans("a", "b"), model.NewProcess("node", nil), reporter.JaegerFormat)
+	for i := 0; i < 100 && len(sender.getBatches()) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	batches := sender.getBatches()
+	require.Len(t, batches, 1)
+	assert.Len(t, batches[0].Spans, 2)
+
+	mFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "reporter.batcher.flushes", Tags: map[string]string{"reason": "age"}, Value: 1,
+	})
+}
+
+func TestBatcherFlushOnClose(t *testing.T) {
+	sender := &mockBatchSender{err: errors.New("collector unavailable")}
+	mFactory := metricstest.NewFactory(time.Hour)
+	failed := make(map[string]int64)
+	b := newBatcher(BatchOptions{MaxSpans: 100, MaxAge: time.Hour}, sender.send, func(format string, spans int64) {
+		failed[format] += spans
+	}, mFactory, zap.NewNop())
+
+	b.add(makeSpans("a"), model.NewProcess("node", nil), reporter.JaegerFormat)
+	b.add(makeSpans("b", "c"), model.NewProcess("java", nil), reporter.JaegerFormat)
+	b.add(makeSpans("d"), model.NewProcess("java", nil), reporter.ZipkinFormat)
+	b.close()
+	b.close()
+	assert.Len(t, sender.getBatches(), 2)
+	assert.Equal(t, map[string]int64{reporter.JaegerFormat: 3, reporter.ZipkinFormat: 1}, failed)
+
+	mFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "reporter.batcher.flushes", Tags: map[string]string{"reason": "close"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "reporter.batcher.flush-failures", Value: 2},
+	)
+	mFactory.AssertGaugeMetrics(t, metricstest.ExpectedMetric{
+		Name: "reporter.batcher.pending-spans", Value: 0,
+	})
+}
+
+func TestBatcherMaxPendingSpans(t *testing.T) {
+	sender := &mockBatchSender{}
+	mFactory := metricstest.NewFactory(time.Hour)
+	b := newBatcher(BatchOptions{MaxSpans: 10, MaxAge: time.Hour, MaxPendingSpans: 4}, sender.send, nil, mFactory, zap.NewNop())
+	defer b.close()
+	now := time.Now()
+	b.timeNow = func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+
+	b.add(makeSpans("a", "b"), model.NewProcess("node", nil), reporter.JaegerFormat)
+	b.add(makeSpans("c", "d"), model.NewProcess("java", nil), reporter.JaegerFormat)
+	assert.Empty(t, sender.getBatches())
+
+	// the oldest batch is sent when the spans of all batches exceed the limit
+	b.add(makeSpans("e"), model.NewProcess("go", nil), reporter.JaegerFormat)
+	batches := sender.getBatches()
+	require.Len(t, batches, 1)
+	assert.Equal(t, "node", batches[0].Process.ServiceName)
+
+	mFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "reporter.batcher.flushes", Tags: map[string]string{"reason": "pending-limit"}, Value: 1,
+	})
+	mFactory.AssertGaugeMetrics(t, metricstest.ExpectedMetric{
+		Name: "reporter.batcher.pending-spans", Value: 3,
+	})
+}
@@ -17,18 +17,22 @@ package grpc
 import (
 	"errors"
 	"fmt"
+	"io"
 	"strings"
+	"time"
 
 	grpc_retry "github.com/grpc-ecosystem/go-grpc-middleware/retry"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/grpc/resolver"
 	"google.golang.org/grpc/resolver/manual"
 
 	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
 	"github.com/jaegertracing/jaeger/pkg/discovery"
 	"github.com/jaegertracing/jaeger/pkg/discovery/grpcresolver"
+	_ "github.com/jaegertracing/jaeger/pkg/gogocodec" // force gogo codec registration
 )
 
 // ConnBuilder Struct to hold configurations
@@ -42,6 +46,22 @@ type ConnBuilder struct {
 	DiscoveryMinPeers int
 	Notifier          discovery.Notifier
 	Discoverer        discovery.Discoverer
+
+	// DiscoveryDNS is the DNS name to discover collectors from, see discovery.DNSDiscoverer
+	DiscoveryDNS string
+	// DiscoveryDNSRefreshInterval is the interval for looking up the DNS records again
+	DiscoveryDNSRefreshInterval time.Duration
+	// DiscoveryFile is the path of a JSON or YAML file with the list of collectors, see discovery.FileDiscoverer
+	DiscoveryFile string
+
+	// Batch configures merging spans into larger batches before sending them to collectors
+	Batch BatchOptions
+	// Compression is the compressor of the requests sent to collectors, none or gzip
+	Compression string
+	// Spill configures storing batches on disk while collectors are unreachable
+	Spill SpillOptions
+	// SamplingUpdates enables subscribing to the sampling strategy updates pushed by collectors
+	SamplingUpdates bool
 }
 
 // NewConnBuilder creates a new grpc connection builder.
@@ -49,6 +69,33 @@ func NewConnBuilder() *ConnBuilder {
 	return &ConnBuilder{}
 }
 
+// CreateDiscovery creates the discoverer of collectors configured by DiscoveryDNS or DiscoveryFile, and sets it
+// as the Notifier and Discoverer of the builder. The returned discoverer must be closed after the connection,
+// it is nil if no discovery is configured.
+func (b *ConnBuilder) CreateDiscovery(logger *zap.Logger) (io.Closer, error) {
+	switch {
+	case b.DiscoveryDNS != "" && b.DiscoveryFile != "":
+		return nil, errors.New("at most one of DNS and file discovery of collectors can be configured")
+	case (b.DiscoveryDNS != "" || b.DiscoveryFile != "") && len(b.CollectorHostPorts) > 0:
+		return nil, errors.New("discovery of collectors cannot be configured with a static list of collectors")
+	case b.DiscoveryDNS != "":
+		d, err := discovery.NewDNSDiscoverer(b.DiscoveryDNS, b.DiscoveryDNSRefreshInterval, nil, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create DNS discovery of collectors: %w", err)
+		}
+		b.Notifier, b.Discoverer = d, d
+		return d, nil
+	case b.DiscoveryFile != "":
+		d, err := discovery.NewFileDiscoverer(b.DiscoveryFile, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create file discovery of collectors: %w", err)
+		}
+		b.Notifier, b.Discoverer = d, d
+		return d, nil
+	}
+	return nil, nil
+}
+
 // CreateConnection creates the gRPC connection
 func (b *ConnBuilder) CreateConnection(logger *zap.Logger) (*grpc.ClientConn, error) {
 	var dialOptions []grpc.DialOption
@@ -76,18 +123,26 @@ func (b *ConnBuilder) CreateConnection(logger *zap.Logger) (*grpc.ClientConn, er
 			return nil, errors.New("at least one collector hostPort address is required when resolver is not available")
 		}
 		if len(b.CollectorHostPorts) > 1 {
-			r, _ := manual.GenerateAndRegisterManualResolver()
+			r := manual.NewBuilderWithScheme("jaeger-manual")
 			var resolvedAddrs []resolver.Address
 			for _, addr := range b.CollectorHostPorts {
 				resolvedAddrs = append(resolvedAddrs, resolver.Address{Addr: addr})
 			}
 			r.InitialState(resolver.State{Addresses: resolvedAddrs})
+			dialOptions = append(dialOptions, grpc.WithResolvers(r))
 			dialTarget = r.Scheme() + ":///round_robin"
 			logger.Info("Agent is connecting to a static list of collectors", zap.String("dialTarget", dialTarget), zap.String("collector hosts", strings.Join(b.CollectorHostPorts, ",")))
 		} else {
 			dialTarget = b.CollectorHostPorts[0]
 		}
 	}
+	switch b.Compression {
+	case "", compressionNone:
+	case gzip.Name:
+		dialOptions = append(dialOptions, grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)))
+	default:
+		return nil, fmt.Errorf("unsupported compression %q, expected %s or %s", b.Compression, compressionNone, gzip.Name)
+	}
 	dialOptions = append(dialOptions, grpc.WithDefaultServiceConfig(grpcresolver.GRPCServiceConfig))
 	dialOptions = append(dialOptions, grpc.WithUnaryInterceptor(grpc_retry.UnaryClientInterceptor(grpc_retry.WithMax(b.MaxRetry))))
 	return grpc.Dial(dialTarget, dialOptions...)
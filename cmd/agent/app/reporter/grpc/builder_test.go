@@ -15,7 +15,10 @@ package grpc
 
 import (
 	"context"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -29,6 +32,7 @@ import (
 	"google.golang.org/grpc/credentials"
 	yaml "gopkg.in/yaml.v2"
 
+	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter"
 	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
 	"github.com/jaegertracing/jaeger/pkg/discovery"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
@@ -134,6 +138,71 @@ func TestBuilderWithCollectors(t *testing.T) {
 	}
 }
 
+func TestBuilderWithCompression(t *testing.T) {
+	tests := []struct {
+		compression   string
+		expectedError string
+	}{
+		{compression: ""},
+		{compression: "none"},
+		{compression: "gzip"},
+		{compression: "zstd", expectedError: `unsupported compression "zstd", expected none or gzip`},
+	}
+	for _, test := range tests {
+		t.Run(test.compression, func(t *testing.T) {
+			cfg := &ConnBuilder{CollectorHostPorts: []string{"127.0.0.1:9876"}, Compression: test.compression}
+			conn, err := cfg.CreateConnection(zap.NewNop())
+			if test.expectedError == "" {
+				require.NoError(t, err)
+				conn.Close()
+			} else {
+				assert.EqualError(t, err, test.expectedError)
+			}
+		})
+	}
+}
+
+func TestBuilderCreateDiscovery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collector-discovery")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "collectors.yaml")
+	require.NoError(t, ioutil.WriteFile(file, []byte("- 127.0.0.1:14250\n"), 0600))
+
+	cfg := &ConnBuilder{}
+	d, err := cfg.CreateDiscovery(zap.NewNop())
+	require.NoError(t, err)
+	assert.Nil(t, d)
+	assert.Nil(t, cfg.Discoverer)
+
+	cfg = &ConnBuilder{DiscoveryFile: file}
+	d, err = cfg.CreateDiscovery(zap.NewNop())
+	require.NoError(t, err)
+	defer d.Close()
+	assert.Equal(t, d, cfg.Discoverer)
+	assert.Equal(t, d, cfg.Notifier)
+	instances, err := cfg.Discoverer.Instances()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1:14250"}, instances)
+
+	conn, err := cfg.CreateConnection(zap.NewNop())
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(conn.Target(), "///round_robin"))
+	conn.Close()
+
+	_, err = (&ConnBuilder{DiscoveryFile: filepath.Join(dir, "missing.yaml")}).CreateDiscovery(zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to create file discovery of collectors")
+
+	_, err = (&ConnBuilder{DiscoveryDNS: "collector"}).CreateDiscovery(zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to create DNS discovery of collectors")
+
+	_, err = (&ConnBuilder{DiscoveryDNS: "collector:14250", DiscoveryFile: file}).CreateDiscovery(zap.NewNop())
+	assert.EqualError(t, err, "at most one of DNS and file discovery of collectors can be configured")
+
+	_, err = (&ConnBuilder{CollectorHostPorts: []string{"localhost:14250"}, DiscoveryFile: file}).CreateDiscovery(zap.NewNop())
+	assert.EqualError(t, err, "discovery of collectors cannot be configured with a static list of collectors")
+}
+
 func TestProxyBuilder(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -147,6 +216,14 @@ func TestProxyBuilder(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "should pass with batching",
+			grpcBuilder: &ConnBuilder{
+				CollectorHostPorts: []string{"localhost:0000"},
+				Batch:              BatchOptions{MaxSpans: 100, MaxAge: time.Second},
+			},
+			expectError: false,
+		},
 		{
 			name: "should fail with secure grpc connection and a CA file which does not exist",
 			grpcBuilder: &ConnBuilder{
@@ -175,7 +252,7 @@ func TestProxyBuilder(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			proxy, err := NewCollectorProxy(test.grpcBuilder, nil, metrics.NullFactory, zap.NewNop())
+			proxy, err := NewCollectorProxy(test.grpcBuilder, reporter.Options{}, metrics.NullFactory, zap.NewNop())
 			if test.expectError {
 				require.Error(t, err)
 			} else {
@@ -322,7 +399,7 @@ func TestProxyClientTLS(t *testing.T) {
 			}
 			proxy, err := NewCollectorProxy(
 				grpcBuilder,
-				nil,
+				reporter.Options{},
 				mFactory,
 				zap.NewNop())
 
@@ -26,29 +26,54 @@ import (
 
 // ProxyBuilder holds objects communicating with collector
 type ProxyBuilder struct {
-	reporter *reporter.ClientMetricsReporter
-	manager  configmanager.ClientConfigManager
-	conn     *grpc.ClientConn
+	grpcReporter *Reporter
+	reporter     *reporter.ClientMetricsReporter
+	manager      configmanager.ClientConfigManager
+	streaming    *grpcManager.StreamingManager
+	conn         *grpc.ClientConn
 }
 
 // NewCollectorProxy creates ProxyBuilder
-func NewCollectorProxy(builder *ConnBuilder, agentTags map[string]string, mFactory metrics.Factory, logger *zap.Logger) (*ProxyBuilder, error) {
+func NewCollectorProxy(builder *ConnBuilder, opts reporter.Options, mFactory metrics.Factory, logger *zap.Logger) (*ProxyBuilder, error) {
+	processor, err := reporter.NewSpanProcessor(opts.Processing, mFactory, logger)
+	if err != nil {
+		return nil, err
+	}
 	conn, err := builder.CreateConnection(logger)
 	if err != nil {
 		return nil, err
 	}
 	grpcMetrics := mFactory.Namespace(metrics.NSOptions{Name: "", Tags: map[string]string{"protocol": "grpc"}})
-	r1 := NewReporter(conn, agentTags, logger)
+	r1, err := NewReporterWithParams(conn, ReporterParams{
+		AgentTags:      opts.AgentTags,
+		Processor:      processor,
+		Batch:          builder.Batch,
+		Spill:          builder.Spill,
+		MetricsFactory: grpcMetrics,
+		Logger:         logger,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
 	r2 := reporter.WrapWithMetrics(r1, grpcMetrics)
 	r3 := reporter.WrapWithClientMetrics(reporter.ClientMetricsReporterParams{
 		Reporter:       r2,
 		Logger:         logger,
 		MetricsFactory: mFactory,
 	})
+	var manager configmanager.ClientConfigManager = configmanager.WrapWithMetrics(grpcManager.NewConfigManager(conn), grpcMetrics)
+	var streaming *grpcManager.StreamingManager
+	if builder.SamplingUpdates {
+		streaming = grpcManager.WrapWithStreaming(manager, conn, grpcMetrics, logger)
+		manager = streaming
+	}
 	return &ProxyBuilder{
-		conn:     conn,
-		reporter: r3,
-		manager:  configmanager.WrapWithMetrics(grpcManager.NewConfigManager(conn), grpcMetrics),
+		conn:         conn,
+		grpcReporter: r1,
+		reporter:     r3,
+		manager:      manager,
+		streaming:    streaming,
 	}, nil
 }
 
@@ -70,5 +95,9 @@ func (b ProxyBuilder) GetManager() configmanager.ClientConfigManager {
 // Close closes connections used by proxy.
 func (b ProxyBuilder) Close() error {
 	b.reporter.Close()
+	b.grpcReporter.Close()
+	if b.streaming != nil {
+		b.streaming.Close()
+	}
 	return b.conn.Close()
 }
@@ -17,6 +17,7 @@ package grpc
 import (
 	"flag"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 
@@ -24,11 +25,28 @@ import (
 )
 
 const (
-	gRPCPrefix        = "reporter.grpc"
-	collectorHostPort = gRPCPrefix + ".host-port"
-	retry             = gRPCPrefix + ".retry.max"
-	defaultMaxRetry   = 3
-	discoveryMinPeers = gRPCPrefix + ".discovery.min-peers"
+	gRPCPrefix                  = "reporter.grpc"
+	collectorHostPort           = gRPCPrefix + ".host-port"
+	retry                       = gRPCPrefix + ".retry.max"
+	defaultMaxRetry             = 3
+	discoveryMinPeers           = gRPCPrefix + ".discovery.min-peers"
+	discoveryDNS                = gRPCPrefix + ".discovery.dns"
+	discoveryDNSRefreshInterval = gRPCPrefix + ".discovery.dns-refresh-interval"
+	discoveryFile               = gRPCPrefix + ".discovery.file"
+	batchMaxSpans               = gRPCPrefix + ".batch.max-spans"
+	batchMaxAge                 = gRPCPrefix + ".batch.max-age"
+	batchMaxPendingSpans        = gRPCPrefix + ".batch.max-pending-spans"
+	compression                 = gRPCPrefix + ".compression"
+	compressionNone             = "none"
+	spillDirectory              = gRPCPrefix + ".spill.directory"
+	spillMaxSize                = gRPCPrefix + ".spill.max-size"
+	spillMaxAge                 = gRPCPrefix + ".spill.max-age"
+	samplingUpdatesEnabled      = gRPCPrefix + ".sampling-updates.enabled"
+
+	defaultBatchMaxAge          = time.Second
+	defaultBatchMaxPendingSpans = 100000
+	defaultSpillMaxAge          = 24 * time.Hour
+	defaultDNSRefreshInterval   = 30 * time.Second
 )
 
 var tlsFlagsConfig = tlscfg.ClientFlagsConfig{
@@ -41,6 +59,17 @@ var tlsFlagsConfig = tlscfg.ClientFlagsConfig{
 func AddFlags(flags *flag.FlagSet) {
 	flags.Uint(retry, defaultMaxRetry, "Sets the maximum number of retries for a call")
 	flags.Int(discoveryMinPeers, 3, "Max number of collectors to which the agent will try to connect at any given time")
+	flags.String(discoveryDNS, "", "The DNS name to discover collectors from, instead of --"+collectorHostPort+". Either a host:port resolved with A/AAAA records, or an SRV name starting with an underscore, e.g. _jaeger-collector._tcp.example.com")
+	flags.Duration(discoveryDNSRefreshInterval, defaultDNSRefreshInterval, "The interval for looking up the DNS records of collectors again")
+	flags.String(discoveryFile, "", "The path of a JSON or YAML file with a list of host:port of collectors, instead of --"+collectorHostPort+". The file is watched for changes")
+	flags.Int(batchMaxSpans, 0, "The number of spans of the same process at which they are sent to collectors in a single batch. Zero disables batching and sends every received batch as is")
+	flags.Duration(batchMaxAge, defaultBatchMaxAge, "The max time spans are held in a batch before it is sent to collectors, when batching is enabled")
+	flags.Int(batchMaxPendingSpans, defaultBatchMaxPendingSpans, "The max number of spans held in the batches of all processes, the oldest batches are sent early when it is exceeded. Zero means no limit")
+	flags.String(compression, compressionNone, "The compression of the spans sent to collectors: none or gzip")
+	flags.String(spillDirectory, "", "The directory where batches are stored while collectors are unreachable, and sent from in order once they are reachable again. Batches are dropped after the retries if empty")
+	flags.Uint(spillMaxSize, 512, "The max size in MiB of the batches stored while collectors are unreachable, new batches are dropped when it is reached")
+	flags.Duration(spillMaxAge, defaultSpillMaxAge, "The max time batches are stored while collectors are unreachable, older batches are dropped")
+	flags.Bool(samplingUpdatesEnabled, false, "Subscribe to the sampling strategy updates pushed by collectors, and serve the strategies from a local copy while subscribed instead of polling collectors")
 	AddOTELFlags(flags)
 }
 
@@ -59,5 +88,16 @@ func (b *ConnBuilder) InitFromViper(v *viper.Viper) *ConnBuilder {
 	b.MaxRetry = uint(v.GetInt(retry))
 	b.TLS = tlsFlagsConfig.InitFromViper(v)
 	b.DiscoveryMinPeers = v.GetInt(discoveryMinPeers)
+	b.DiscoveryDNS = v.GetString(discoveryDNS)
+	b.DiscoveryDNSRefreshInterval = v.GetDuration(discoveryDNSRefreshInterval)
+	b.DiscoveryFile = v.GetString(discoveryFile)
+	b.Batch.MaxSpans = v.GetInt(batchMaxSpans)
+	b.Batch.MaxAge = v.GetDuration(batchMaxAge)
+	b.Batch.MaxPendingSpans = v.GetInt(batchMaxPendingSpans)
+	b.Compression = v.GetString(compression)
+	b.Spill.Directory = v.GetString(spillDirectory)
+	b.Spill.MaxSize = int64(v.GetUint(spillMaxSize)) * 1024 * 1024
+	b.Spill.MaxAge = v.GetDuration(spillMaxAge)
+	b.SamplingUpdates = v.GetBool(samplingUpdatesEnabled)
 	return b
 }
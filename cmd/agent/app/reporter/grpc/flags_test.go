@@ -17,6 +17,7 @@ package grpc
 import (
 	"flag"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -25,16 +26,27 @@ import (
 )
 
 func TestBindFlags(t *testing.T) {
+	defaultSpill := SpillOptions{MaxSize: 512 * 1024 * 1024, MaxAge: defaultSpillMaxAge}
 	tests := []struct {
 		cOpts    []string
 		expected *ConnBuilder
 	}{
 		{cOpts: []string{"--reporter.grpc.host-port=localhost:1111", "--reporter.grpc.retry.max=15"},
-			expected: &ConnBuilder{CollectorHostPorts: []string{"localhost:1111"}, MaxRetry: 15, DiscoveryMinPeers: 3}},
+			expected: &ConnBuilder{CollectorHostPorts: []string{"localhost:1111"}, MaxRetry: 15, DiscoveryMinPeers: 3, DiscoveryDNSRefreshInterval: defaultDNSRefreshInterval, Batch: BatchOptions{MaxAge: defaultBatchMaxAge, MaxPendingSpans: defaultBatchMaxPendingSpans}, Compression: compressionNone, Spill: defaultSpill}},
 		{cOpts: []string{"--reporter.grpc.host-port=localhost:1111,localhost:2222"},
-			expected: &ConnBuilder{CollectorHostPorts: []string{"localhost:1111", "localhost:2222"}, MaxRetry: defaultMaxRetry, DiscoveryMinPeers: 3}},
+			expected: &ConnBuilder{CollectorHostPorts: []string{"localhost:1111", "localhost:2222"}, MaxRetry: defaultMaxRetry, DiscoveryMinPeers: 3, DiscoveryDNSRefreshInterval: defaultDNSRefreshInterval, Batch: BatchOptions{MaxAge: defaultBatchMaxAge, MaxPendingSpans: defaultBatchMaxPendingSpans}, Compression: compressionNone, Spill: defaultSpill}},
 		{cOpts: []string{"--reporter.grpc.host-port=localhost:1111,localhost:2222", "--reporter.grpc.discovery.min-peers=5"},
-			expected: &ConnBuilder{CollectorHostPorts: []string{"localhost:1111", "localhost:2222"}, MaxRetry: defaultMaxRetry, DiscoveryMinPeers: 5}},
+			expected: &ConnBuilder{CollectorHostPorts: []string{"localhost:1111", "localhost:2222"}, MaxRetry: defaultMaxRetry, DiscoveryMinPeers: 5, DiscoveryDNSRefreshInterval: defaultDNSRefreshInterval, Batch: BatchOptions{MaxAge: defaultBatchMaxAge, MaxPendingSpans: defaultBatchMaxPendingSpans}, Compression: compressionNone, Spill: defaultSpill}},
+		{cOpts: []string{"--reporter.grpc.host-port=localhost:1111", "--reporter.grpc.batch.max-spans=500", "--reporter.grpc.batch.max-age=200ms", "--reporter.grpc.batch.max-pending-spans=5000", "--reporter.grpc.compression=gzip"},
+			expected: &ConnBuilder{CollectorHostPorts: []string{"localhost:1111"}, MaxRetry: defaultMaxRetry, DiscoveryMinPeers: 3, DiscoveryDNSRefreshInterval: defaultDNSRefreshInterval, Batch: BatchOptions{MaxSpans: 500, MaxAge: 200 * time.Millisecond, MaxPendingSpans: 5000}, Compression: "gzip", Spill: defaultSpill}},
+		{cOpts: []string{"--reporter.grpc.discovery.dns=_jaeger-collector._tcp.example.com", "--reporter.grpc.discovery.dns-refresh-interval=1m"},
+			expected: &ConnBuilder{MaxRetry: defaultMaxRetry, DiscoveryMinPeers: 3, DiscoveryDNS: "_jaeger-collector._tcp.example.com", DiscoveryDNSRefreshInterval: time.Minute, Batch: BatchOptions{MaxAge: defaultBatchMaxAge, MaxPendingSpans: defaultBatchMaxPendingSpans}, Compression: compressionNone, Spill: defaultSpill}},
+		{cOpts: []string{"--reporter.grpc.discovery.file=/etc/jaeger/collectors.yaml"},
+			expected: &ConnBuilder{MaxRetry: defaultMaxRetry, DiscoveryMinPeers: 3, DiscoveryDNSRefreshInterval: defaultDNSRefreshInterval, DiscoveryFile: "/etc/jaeger/collectors.yaml", Batch: BatchOptions{MaxAge: defaultBatchMaxAge, MaxPendingSpans: defaultBatchMaxPendingSpans}, Compression: compressionNone, Spill: defaultSpill}},
+		{cOpts: []string{"--reporter.grpc.host-port=localhost:1111", "--reporter.grpc.spill.directory=/var/lib/jaeger-agent", "--reporter.grpc.spill.max-size=64", "--reporter.grpc.spill.max-age=1h"},
+			expected: &ConnBuilder{CollectorHostPorts: []string{"localhost:1111"}, MaxRetry: defaultMaxRetry, DiscoveryMinPeers: 3, DiscoveryDNSRefreshInterval: defaultDNSRefreshInterval, Batch: BatchOptions{MaxAge: defaultBatchMaxAge, MaxPendingSpans: defaultBatchMaxPendingSpans}, Compression: compressionNone, Spill: SpillOptions{Directory: "/var/lib/jaeger-agent", MaxSize: 64 * 1024 * 1024, MaxAge: time.Hour}}},
+		{cOpts: []string{"--reporter.grpc.host-port=localhost:1111", "--reporter.grpc.sampling-updates.enabled=true"},
+			expected: &ConnBuilder{CollectorHostPorts: []string{"localhost:1111"}, MaxRetry: defaultMaxRetry, DiscoveryMinPeers: 3, DiscoveryDNSRefreshInterval: defaultDNSRefreshInterval, Batch: BatchOptions{MaxAge: defaultBatchMaxAge, MaxPendingSpans: defaultBatchMaxPendingSpans}, Compression: compressionNone, Spill: defaultSpill, SamplingUpdates: true}},
 	}
 	for _, test := range tests {
 		v := viper.New()
@@ -17,10 +17,13 @@ package grpc
 
 import (
 	"context"
+	"sync"
 
+	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 
+	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter"
 	zipkin2 "github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer/zipkin"
 	"github.com/jaegertracing/jaeger/model"
 	jConverter "github.com/jaegertracing/jaeger/model/converter/thrift/jaeger"
@@ -36,6 +39,12 @@ type Reporter struct {
 	agentTags []model.KeyValue
 	logger    *zap.Logger
 	sanitizer zipkin2.Sanitizer
+	processor *reporter.SpanProcessor
+	batcher   *batcher
+	spill     *spillBuffer
+
+	failureLock    sync.Mutex
+	failureReports []func(format string, spans int64)
 }
 
 // NewReporter creates gRPC reporter.
@@ -48,9 +57,44 @@ func NewReporter(conn *grpc.ClientConn, agentTags map[string]string, logger *zap
 	}
 }
 
+// ReporterParams holds the parameters of a reporter created with NewReporterWithParams
+type ReporterParams struct {
+	AgentTags map[string]string
+	// Batch configures merging the spans of the same process into larger batches, disabled if Batch.MaxSpans is not positive
+	Batch BatchOptions
+	// Spill configures storing batches on disk while collectors are unreachable, disabled if Spill.Directory is empty
+	Spill SpillOptions
+	// Processor drops and redacts spans before they are sent, and provides host tags added with the agent tags, if not nil
+	Processor      *reporter.SpanProcessor
+	MetricsFactory metrics.Factory
+	Logger         *zap.Logger
+}
+
+// NewReporterWithParams creates a gRPC reporter with optional batching and spilling to disk.
+// The reporter must be closed to send the pending batches.
+func NewReporterWithParams(conn *grpc.ClientConn, params ReporterParams) (*Reporter, error) {
+	r := NewReporter(conn, params.AgentTags, params.Logger)
+	if params.Processor != nil {
+		r.processor = params.Processor
+		r.agentTags = append(r.agentTags, params.Processor.HostTags()...)
+	}
+	if params.Spill.Directory != "" {
+		spill, err := newSpillBuffer(params.Spill, r.postBatch, params.MetricsFactory, params.Logger)
+		if err != nil {
+			return nil, err
+		}
+		r.spill = spill
+		spill.start()
+	}
+	if params.Batch.MaxSpans > 0 {
+		r.batcher = newBatcher(params.Batch, r.sendBatch, r.sendFailed, params.MetricsFactory, params.Logger)
+	}
+	return r, nil
+}
+
 // EmitBatch implements EmitBatch() of Reporter
 func (r *Reporter) EmitBatch(ctx context.Context, b *thrift.Batch) error {
-	return r.send(ctx, jConverter.ToDomain(b.Spans, nil), jConverter.ToDomainProcess(b.Process))
+	return r.send(ctx, jConverter.ToDomain(b.Spans, nil), jConverter.ToDomainProcess(b.Process), reporter.JaegerFormat)
 }
 
 // EmitZipkinBatch implements EmitZipkinBatch() of Reporter
@@ -62,12 +106,49 @@ func (r *Reporter) EmitZipkinBatch(ctx context.Context, zSpans []*zipkincore.Spa
 	if err != nil {
 		return err
 	}
-	return r.send(ctx, trace.Spans, nil)
+	return r.send(ctx, trace.Spans, nil, reporter.ZipkinFormat)
+}
+
+// OnSendFailure implements reporter.AsyncReporter. The function is called for the spans
+// of the batches that could not be sent, when batching is enabled.
+func (r *Reporter) OnSendFailure(fn func(format string, spans int64)) {
+	r.failureLock.Lock()
+	defer r.failureLock.Unlock()
+	r.failureReports = append(r.failureReports, fn)
+}
+
+func (r *Reporter) sendFailed(format string, spans int64) {
+	r.failureLock.Lock()
+	defer r.failureLock.Unlock()
+	for _, fn := range r.failureReports {
+		fn(format, spans)
+	}
 }
 
-func (r *Reporter) send(ctx context.Context, spans []*model.Span, process *model.Process) error {
+func (r *Reporter) send(ctx context.Context, spans []*model.Span, process *model.Process, format string) error {
+	if r.processor != nil && len(spans) > 0 {
+		if spans, process = r.processor.Process(spans, process); len(spans) == 0 {
+			return nil
+		}
+	}
 	spans, process = addProcessTags(spans, process, r.agentTags)
-	batch := model.Batch{Spans: spans, Process: process}
+	if r.batcher != nil {
+		r.batcher.add(spans, process, format)
+		return nil
+	}
+	return r.sendBatch(ctx, model.Batch{Spans: spans, Process: process})
+}
+
+// sendBatch sends the batch to the collector, or stores it in the spill buffer if the collector is unreachable
+func (r *Reporter) sendBatch(ctx context.Context, batch model.Batch) error {
+	err := r.postBatch(ctx, batch)
+	if err != nil && r.spill != nil && isRetryable(err) && r.spill.add(batch) {
+		return nil
+	}
+	return err
+}
+
+func (r *Reporter) postBatch(ctx context.Context, batch model.Batch) error {
 	req := &api_v2.PostSpansRequest{Batch: batch}
 	_, err := r.collector.PostSpans(ctx, req)
 	if err != nil {
@@ -76,6 +157,18 @@ func (r *Reporter) send(ctx context.Context, spans []*model.Span, process *model
 	return err
 }
 
+// Close sends the pending batches, if batching is enabled, and stops draining the spill buffer.
+// Batches left in the spill buffer are sent when the reporter is created again on the same directory.
+func (r *Reporter) Close() error {
+	if r.batcher != nil {
+		r.batcher.close()
+	}
+	if r.spill != nil {
+		r.spill.close()
+	}
+	return nil
+}
+
 // addTags appends jaeger tags for the agent to every span it sends to the collector.
 func addProcessTags(spans []*model.Span, process *model.Process, agentTags []model.KeyValue) ([]*model.Span, *model.Process) {
 	if len(agentTags) == 0 {
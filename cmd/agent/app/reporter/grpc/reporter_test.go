@@ -16,15 +16,21 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 
+	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter"
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
 	jThrift "github.com/jaegertracing/jaeger/thrift-gen/jaeger"
@@ -119,11 +125,133 @@ func TestReporter_EmitBatch(t *testing.T) {
 	}
 }
 
+func TestReporter_EmitBatchWithBatching(t *testing.T) {
+	handler := &mockSpanHandler{}
+	s, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		api_v2.RegisterCollectorServiceServer(s, handler)
+	})
+	defer s.Stop()
+	conn, err := (&ConnBuilder{CollectorHostPorts: []string{addr.String()}, Compression: "gzip"}).CreateConnection(zap.NewNop())
+	require.NoError(t, err)
+	defer conn.Close()
+	rep, err := NewReporterWithParams(conn, ReporterParams{
+		Batch:          BatchOptions{MaxSpans: 3, MaxAge: time.Hour},
+		MetricsFactory: metrics.NullFactory,
+		Logger:         zap.NewNop(),
+	})
+	require.NoError(t, err)
+
+	for _, service := range []string{"node", "java", "node", "node"} {
+		err = rep.EmitBatch(context.Background(), &jThrift.Batch{
+			Process: &jThrift.Process{ServiceName: service},
+			Spans:   []*jThrift.Span{{OperationName: "foo"}},
+		})
+		require.NoError(t, err)
+	}
+	err = rep.EmitZipkinBatch(context.Background(), []*zipkincore.Span{
+		{Name: "bar", TraceID: 1, ID: 2, Annotations: []*zipkincore.Annotation{{Value: zipkincore.CLIENT_SEND, Host: &zipkincore.Endpoint{ServiceName: "java"}}}},
+	})
+	require.NoError(t, err)
+
+	// the batch of node is sent once it reaches the max spans
+	requests := handler.getRequests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, &model.Process{ServiceName: "node"}, requests[0].Batch.Process)
+	assert.Len(t, requests[0].Batch.Spans, 3)
+
+	// the batch of java, with spans from both formats, is sent on close
+	require.NoError(t, rep.Close())
+	requests = handler.getRequests()
+	require.Len(t, requests, 2)
+	assert.Equal(t, &model.Process{ServiceName: "java"}, requests[1].Batch.Process)
+	require.Len(t, requests[1].Batch.Spans, 2)
+	assert.Equal(t, "foo", requests[1].Batch.Spans[0].OperationName)
+	assert.Equal(t, "bar", requests[1].Batch.Spans[1].OperationName)
+	assert.Nil(t, requests[1].Batch.Spans[1].Process)
+}
+
+func TestReporter_BatchingSendFailure(t *testing.T) {
+	collector := &mockCollector{}
+	conn, err := grpc.Dial("localhost:0", grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	rep, err := NewReporterWithParams(conn, ReporterParams{
+		Batch:          BatchOptions{MaxSpans: 2, MaxAge: time.Hour},
+		MetricsFactory: metrics.NullFactory,
+		Logger:         zap.NewNop(),
+	})
+	require.NoError(t, err)
+	defer rep.Close()
+	rep.collector = collector
+	failed := make(map[string]int64)
+	rep.OnSendFailure(func(format string, spans int64) {
+		failed[format] += spans
+	})
+
+	collector.setFailures(errors.New("collector down"))
+	require.NoError(t, rep.EmitBatch(context.Background(), &jThrift.Batch{
+		Process: &jThrift.Process{ServiceName: "node"},
+		Spans:   []*jThrift.Span{{OperationName: "foo"}, {OperationName: "bar"}},
+	}))
+	assert.Equal(t, map[string]int64{reporter.JaegerFormat: 2}, failed)
+}
+
+func TestReporter_EmitBatchWithProcessor(t *testing.T) {
+	handler := &mockSpanHandler{}
+	s, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		api_v2.RegisterCollectorServiceServer(s, handler)
+	})
+	defer s.Stop()
+	conn, err := grpc.Dial(addr.String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	dir, err := ioutil.TempDir("", "reporter")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	zoneFile := filepath.Join(dir, "zone")
+	require.NoError(t, ioutil.WriteFile(zoneFile, []byte("us-east-1a\n"), 0600))
+
+	processor, err := reporter.NewSpanProcessor(reporter.ProcessingOptions{
+		DropOperations:    "^health$",
+		HostMetadataFiles: map[string]string{"zone": zoneFile},
+	}, metrics.NullFactory, zap.NewNop())
+	require.NoError(t, err)
+	rep, err := NewReporterWithParams(conn, ReporterParams{
+		AgentTags:      map[string]string{"agent": "a1"},
+		Processor:      processor,
+		MetricsFactory: metrics.NullFactory,
+		Logger:         zap.NewNop(),
+	})
+	require.NoError(t, err)
+
+	// a batch of dropped spans only is not sent
+	err = rep.EmitBatch(context.Background(), &jThrift.Batch{
+		Process: &jThrift.Process{ServiceName: "node"},
+		Spans:   []*jThrift.Span{{OperationName: "health"}},
+	})
+	require.NoError(t, err)
+	err = rep.EmitBatch(context.Background(), &jThrift.Batch{
+		Process: &jThrift.Process{ServiceName: "node"},
+		Spans:   []*jThrift.Span{{OperationName: "health"}, {OperationName: "foo"}},
+	})
+	require.NoError(t, err)
+
+	requests := handler.getRequests()
+	require.Len(t, requests, 1)
+	require.Len(t, requests[0].Batch.Spans, 1)
+	assert.Equal(t, "foo", requests[0].Batch.Spans[0].OperationName)
+	assert.Equal(t, &model.Process{
+		ServiceName: "node",
+		Tags:        []model.KeyValue{model.String("agent", "a1"), model.String("zone", "us-east-1a")},
+	}, requests[0].Batch.Process)
+}
+
 func TestReporter_SendFailure(t *testing.T) {
 	conn, err := grpc.Dial("", grpc.WithInsecure())
 	require.NoError(t, err)
 	rep := NewReporter(conn, nil, zap.NewNop())
-	err = rep.send(context.Background(), nil, nil)
+	err = rep.send(context.Background(), nil, nil, reporter.JaegerFormat)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "transport: Error while dialing dial tcp: missing address")
 }
@@ -0,0 +1,214 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/queue"
+)
+
+const (
+	// spillSegmentSize is the size of the segment files of the spill buffer, or the max size if smaller
+	spillSegmentSize = 16 * 1024 * 1024
+	// spillTimestampSize is the size of the timestamp prefixed to each spilled batch
+	spillTimestampSize = 8
+
+	drainRetryInitialInterval = time.Second
+	drainRetryMaxInterval     = 30 * time.Second
+)
+
+var errSpillClosed = errors.New("spill buffer is closed")
+
+// SpillOptions configures the on-disk buffer for batches that could not be sent to collectors.
+type SpillOptions struct {
+	// Directory is where the batches are stored, spilling is disabled if empty
+	Directory string
+	// MaxSize is the max size in bytes of the stored batches, new batches are dropped when it is reached
+	MaxSize int64
+	// MaxAge is the max time a batch is kept, older batches are dropped instead of being sent
+	MaxAge time.Duration
+}
+
+type spillMetrics struct {
+	// Number of batches stored on disk because collectors were unreachable
+	Spilled metrics.Counter `metric:"batches.spilled"`
+
+	// Number of stored batches sent to collectors once they were reachable again
+	Drained metrics.Counter `metric:"batches.drained"`
+
+	// Number of batches dropped because the max size of the buffer was reached
+	DroppedFull metrics.Counter `metric:"batches.dropped" tags:"reason=full"`
+
+	// Number of stored batches dropped because they reached the max age
+	DroppedExpired metrics.Counter `metric:"batches.dropped" tags:"reason=expired"`
+
+	// Number of stored batches dropped because collectors rejected them
+	DroppedRejected metrics.Counter `metric:"batches.dropped" tags:"reason=rejected"`
+
+	// Number of batches stored on disk
+	Pending metrics.Gauge `metric:"batches.pending"`
+
+	// Size in bytes of the stored batches
+	DiskSize metrics.Gauge `metric:"disk-size"`
+}
+
+// spillBuffer stores batches that could not be sent on disk, and sends them in order once
+// collectors are reachable again.
+type spillBuffer struct {
+	options SpillOptions
+	queue   *queue.PersistentQueue
+	send    func(ctx context.Context, batch model.Batch) error
+	logger  *zap.Logger
+	metrics spillMetrics
+	timeNow func() time.Time
+
+	retryInitialInterval time.Duration
+	retryMaxInterval     time.Duration
+
+	stopCh    chan struct{}
+	closeOnce sync.Once
+}
+
+func newSpillBuffer(options SpillOptions, send func(ctx context.Context, batch model.Batch) error, mFactory metrics.Factory, logger *zap.Logger) (*spillBuffer, error) {
+	segmentSize := int64(spillSegmentSize)
+	if options.MaxSize < segmentSize {
+		segmentSize = options.MaxSize
+	}
+	q, err := queue.NewPersistentQueue(queue.PersistentQueueOptions{
+		Directory:    options.Directory,
+		SegmentSize:  segmentSize,
+		MaxDiskSize:  options.MaxSize,
+		SyncPolicy:   queue.SyncInterval,
+		SyncInterval: time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s := &spillBuffer{
+		options:              options,
+		queue:                q,
+		send:                 send,
+		logger:               logger,
+		timeNow:              time.Now,
+		retryInitialInterval: drainRetryInitialInterval,
+		retryMaxInterval:     drainRetryMaxInterval,
+		stopCh:               make(chan struct{}),
+	}
+	metrics.MustInit(&s.metrics, mFactory.Namespace(metrics.NSOptions{Name: "reporter.spill"}), nil)
+	s.updateGauges()
+	return s, nil
+}
+
+// start starts sending the stored batches, including those left from a previous run
+func (s *spillBuffer) start() {
+	// a single consumer sends the stored batches in order
+	s.queue.StartConsumers(1, s.drain)
+}
+
+// add stores the batch on disk, it returns false if the batch was dropped
+func (s *spillBuffer) add(batch model.Batch) bool {
+	data, err := batch.Marshal()
+	if err != nil {
+		s.logger.Error("Could not serialize spans for the spill buffer", zap.Error(err))
+		return false
+	}
+	item := make([]byte, spillTimestampSize+len(data))
+	binary.BigEndian.PutUint64(item, uint64(s.timeNow().UnixNano()))
+	copy(item[spillTimestampSize:], data)
+	ok := s.queue.Produce(item)
+	if ok {
+		s.metrics.Spilled.Inc(1)
+	} else {
+		s.metrics.DroppedFull.Inc(1)
+	}
+	s.updateGauges()
+	return ok
+}
+
+// drain sends a stored batch, retrying with backoff while collectors are unreachable.
+// Returning an error leaves the batch on disk, which only happens when the buffer is closed.
+func (s *spillBuffer) drain(item []byte) error {
+	defer s.updateGauges()
+	if len(item) < spillTimestampSize {
+		return nil
+	}
+	spilled := time.Unix(0, int64(binary.BigEndian.Uint64(item)))
+	var batch model.Batch
+	if err := batch.Unmarshal(item[spillTimestampSize:]); err != nil {
+		s.logger.Error("Could not deserialize spans from the spill buffer", zap.Error(err))
+		s.metrics.DroppedRejected.Inc(1)
+		return nil
+	}
+	interval := s.retryInitialInterval
+	for {
+		if s.options.MaxAge > 0 && s.timeNow().Sub(spilled) > s.options.MaxAge {
+			s.metrics.DroppedExpired.Inc(1)
+			return nil
+		}
+		err := s.send(context.Background(), batch)
+		if err == nil {
+			s.metrics.Drained.Inc(1)
+			return nil
+		}
+		if !isRetryable(err) {
+			s.metrics.DroppedRejected.Inc(1)
+			return nil
+		}
+		select {
+		case <-time.After(interval):
+		case <-s.stopCh:
+			return errSpillClosed
+		}
+		if interval *= 2; interval > s.retryMaxInterval {
+			interval = s.retryMaxInterval
+		}
+	}
+}
+
+func (s *spillBuffer) updateGauges() {
+	// the batch being drained is still counted until it is acknowledged
+	s.metrics.Pending.Update(int64(s.queue.Size()))
+	s.metrics.DiskSize.Update(s.queue.DiskSize())
+}
+
+// close stops draining, the stored batches are sent after a restart
+func (s *spillBuffer) close() {
+	s.closeOnce.Do(func() {
+		close(s.stopCh)
+		s.queue.Stop()
+	})
+}
+
+// isRetryable returns true if the error means that collectors are unreachable or overloaded,
+// and the same batch may be accepted later
+func isRetryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted, codes.Canceled:
+		return true
+	default:
+		return false
+	}
+}
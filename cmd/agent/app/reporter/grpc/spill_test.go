@@ -0,0 +1,245 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+)
+
+type mockCollector struct {
+	mux      sync.Mutex
+	failures []error
+	batches  []model.Batch
+}
+
+func (c *mockCollector) send(_ context.Context, batch model.Batch) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if len(c.failures) > 0 {
+		err := c.failures[0]
+		c.failures = c.failures[1:]
+		return err
+	}
+	c.batches = append(c.batches, batch)
+	return nil
+}
+
+func (c *mockCollector) PostSpans(ctx context.Context, r *api_v2.PostSpansRequest, _ ...grpc.CallOption) (*api_v2.PostSpansResponse, error) {
+	if err := c.send(ctx, r.Batch); err != nil {
+		return nil, err
+	}
+	return &api_v2.PostSpansResponse{}, nil
+}
+
+func (c *mockCollector) setFailures(failures ...error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.failures = failures
+}
+
+func (c *mockCollector) getBatches() []model.Batch {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.batches
+}
+
+func makeSpillDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "agent-spill")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func newTestSpillBuffer(t *testing.T, options SpillOptions, send func(ctx context.Context, batch model.Batch) error) (*spillBuffer, *metricstest.Factory) {
+	mFactory := metricstest.NewFactory(time.Hour)
+	s, err := newSpillBuffer(options, send, mFactory, zap.NewNop())
+	require.NoError(t, err)
+	s.retryInitialInterval = time.Millisecond
+	s.retryMaxInterval = 5 * time.Millisecond
+	return s, mFactory
+}
+
+type testClock struct {
+	mux sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) timeNow() time.Time {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.now
+}
+
+func (c *testClock) set(now time.Time) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.now = now
+}
+
+func makeBatch(service string) model.Batch {
+	return model.Batch{
+		Process: &model.Process{ServiceName: service},
+		Spans:   []*model.Span{{OperationName: "op"}},
+	}
+}
+
+func waitForBatches(t *testing.T, collector *mockCollector, expected int) []model.Batch {
+	for i := 0; i < 200 && len(collector.getBatches()) < expected; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	batches := collector.getBatches()
+	require.Len(t, batches, expected)
+	return batches
+}
+
+func TestSpillBufferDrainsInOrder(t *testing.T) {
+	collector := &mockCollector{}
+	unavailable := status.Error(codes.Unavailable, "collector down")
+	collector.setFailures(unavailable, unavailable, unavailable)
+	s, mFactory := newTestSpillBuffer(t, SpillOptions{Directory: makeSpillDir(t), MaxSize: 1024 * 1024, MaxAge: time.Hour}, collector.send)
+	s.start()
+	defer s.close()
+
+	for _, service := range []string{"a", "b", "c"} {
+		require.True(t, s.add(makeBatch(service)))
+	}
+	batches := waitForBatches(t, collector, 3)
+	for i, service := range []string{"a", "b", "c"} {
+		assert.Equal(t, service, batches[i].Process.ServiceName)
+	}
+
+	mFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "reporter.spill.batches.spilled", Value: 3},
+		metricstest.ExpectedMetric{Name: "reporter.spill.batches.drained", Value: 3},
+	)
+}
+
+func TestSpillBufferDropsBatches(t *testing.T) {
+	collector := &mockCollector{}
+	collector.setFailures(status.Error(codes.InvalidArgument, "bad batch"))
+	s, mFactory := newTestSpillBuffer(t, SpillOptions{Directory: makeSpillDir(t), MaxSize: 1024 * 1024, MaxAge: time.Minute}, collector.send)
+	defer s.close()
+
+	now := time.Now()
+	clock := &testClock{now: now}
+	s.timeNow = clock.timeNow
+	require.True(t, s.add(makeBatch("rejected")))
+	clock.set(now.Add(-time.Hour))
+	require.True(t, s.add(makeBatch("expired")))
+	clock.set(now)
+	require.True(t, s.add(makeBatch("sent")))
+	s.start()
+
+	batches := waitForBatches(t, collector, 1)
+	assert.Equal(t, "sent", batches[0].Process.ServiceName)
+
+	mFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "reporter.spill.batches.dropped", Tags: map[string]string{"reason": "rejected"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "reporter.spill.batches.dropped", Tags: map[string]string{"reason": "expired"}, Value: 1},
+	)
+}
+
+func TestSpillBufferMaxSize(t *testing.T) {
+	collector := &mockCollector{}
+	collector.setFailures(status.Error(codes.Unavailable, "collector down"))
+	s, mFactory := newTestSpillBuffer(t, SpillOptions{Directory: makeSpillDir(t), MaxSize: 100, MaxAge: time.Hour}, collector.send)
+	defer s.close()
+
+	require.True(t, s.add(makeBatch("a")))
+	assert.False(t, s.add(makeBatch("b")))
+
+	mFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "reporter.spill.batches.dropped", Tags: map[string]string{"reason": "full"}, Value: 1,
+	})
+}
+
+func TestSpillBufferSurvivesRestart(t *testing.T) {
+	dir := makeSpillDir(t)
+	options := SpillOptions{Directory: dir, MaxSize: 1024 * 1024, MaxAge: time.Hour}
+	down := &mockCollector{}
+	down.setFailures(make([]error, 1000)...)
+	for i := range down.failures {
+		down.failures[i] = status.Error(codes.Unavailable, "collector down")
+	}
+	s, _ := newTestSpillBuffer(t, options, down.send)
+	s.start()
+	require.True(t, s.add(makeBatch("a")))
+	require.True(t, s.add(makeBatch("b")))
+	s.close()
+	s.close()
+	assert.Empty(t, down.getBatches())
+
+	up := &mockCollector{}
+	s, mFactory := newTestSpillBuffer(t, options, up.send)
+	s.start()
+	defer s.close()
+	batches := waitForBatches(t, up, 2)
+	assert.Equal(t, "a", batches[0].Process.ServiceName)
+	assert.Equal(t, "b", batches[1].Process.ServiceName)
+	mFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "reporter.spill.batches.drained", Value: 2})
+}
+
+func TestReporterSpillsWhenCollectorUnavailable(t *testing.T) {
+	collector := &mockCollector{}
+	conn, err := grpc.Dial("localhost:0", grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	rep, err := NewReporterWithParams(conn, ReporterParams{
+		Spill:          SpillOptions{Directory: makeSpillDir(t), MaxSize: 1024 * 1024, MaxAge: time.Hour},
+		MetricsFactory: metricstest.NewFactory(time.Hour),
+		Logger:         zap.NewNop(),
+	})
+	require.NoError(t, err)
+	defer rep.Close()
+	rep.collector = collector
+
+	collector.setFailures(status.Error(codes.Unavailable, "collector down"))
+	require.NoError(t, rep.send(context.Background(), []*model.Span{{OperationName: "op"}}, &model.Process{ServiceName: "svc"}, reporter.JaegerFormat))
+	batches := waitForBatches(t, collector, 1)
+	assert.Equal(t, "svc", batches[0].Process.ServiceName)
+
+	// errors that would fail again are not spilled
+	collector.setFailures(status.Error(codes.InvalidArgument, "bad batch"))
+	assert.Error(t, rep.send(context.Background(), []*model.Span{{OperationName: "op"}}, &model.Process{ServiceName: "svc"}, reporter.JaegerFormat))
+}
+
+func TestNewReporterWithInvalidSpillDirectory(t *testing.T) {
+	dir := makeSpillDir(t)
+	file := dir + "/file"
+	require.NoError(t, ioutil.WriteFile(file, nil, 0600))
+	_, err := NewReporterWithParams(nil, ReporterParams{
+		Spill:          SpillOptions{Directory: file + "/spill", MaxSize: 1024 * 1024},
+		MetricsFactory: metricstest.NewFactory(time.Hour),
+		Logger:         zap.NewNop(),
+	})
+	require.Error(t, err)
+}
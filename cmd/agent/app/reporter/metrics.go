@@ -23,11 +23,6 @@ import (
 	"github.com/jaegertracing/jaeger/thrift-gen/zipkincore"
 )
 
-const (
-	jaegerBatches = "jaeger"
-	zipkinBatches = "zipkin"
-)
-
 type batchMetrics struct {
 	// Number of successful batch submissions to collector
 	BatchesSubmitted metrics.Counter `metric:"batches.submitted"`
@@ -56,7 +51,7 @@ type MetricsReporter struct {
 // WrapWithMetrics wraps Reporter and creates metrics for its invocations.
 func WrapWithMetrics(reporter Reporter, mFactory metrics.Factory) *MetricsReporter {
 	batchesMetrics := map[string]batchMetrics{}
-	for _, s := range []string{zipkinBatches, jaegerBatches} {
+	for _, s := range []string{ZipkinFormat, JaegerFormat} {
 		bm := batchMetrics{}
 		metrics.MustInit(&bm,
 			mFactory.Namespace(metrics.NSOptions{
@@ -66,13 +61,32 @@ func WrapWithMetrics(reporter Reporter, mFactory metrics.Factory) *MetricsReport
 			nil)
 		batchesMetrics[s] = bm
 	}
-	return &MetricsReporter{wrapped: reporter, metrics: batchesMetrics}
+	r := &MetricsReporter{wrapped: reporter, metrics: batchesMetrics}
+	if async, ok := reporter.(AsyncReporter); ok {
+		async.OnSendFailure(r.sendFailed)
+	}
+	return r
+}
+
+// OnSendFailure implements AsyncReporter, the function is registered with the wrapped reporter if it is asynchronous.
+func (r *MetricsReporter) OnSendFailure(fn func(format string, spans int64)) {
+	if async, ok := r.wrapped.(AsyncReporter); ok {
+		async.OnSendFailure(fn)
+	}
+}
+
+// sendFailed counts the spans that the wrapped reporter could not send after they were emitted
+func (r *MetricsReporter) sendFailed(format string, spans int64) {
+	if m, ok := r.metrics[format]; ok {
+		m.BatchesFailures.Inc(1)
+		m.SpansFailures.Inc(spans)
+	}
 }
 
 // EmitZipkinBatch emits batch to collector.
 func (r *MetricsReporter) EmitZipkinBatch(ctx context.Context, spans []*zipkincore.Span) error {
 	err := r.wrapped.EmitZipkinBatch(ctx, spans)
-	updateMetrics(r.metrics[zipkinBatches], int64(len(spans)), err)
+	updateMetrics(r.metrics[ZipkinFormat], int64(len(spans)), err)
 	return err
 }
 
@@ -83,7 +97,7 @@ func (r *MetricsReporter) EmitBatch(ctx context.Context, batch *jaeger.Batch) er
 		size = int64(len(batch.GetSpans()))
 	}
 	err := r.wrapped.EmitBatch(ctx, batch)
-	updateMetrics(r.metrics[jaegerBatches], size, err)
+	updateMetrics(r.metrics[JaegerFormat], size, err)
 	return err
 }
 
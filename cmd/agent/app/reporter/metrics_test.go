@@ -22,6 +22,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
 
 	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
 	"github.com/jaegertracing/jaeger/thrift-gen/zipkincore"
@@ -108,3 +109,38 @@ func TestMetricsReporter(t *testing.T) {
 		metricsFactory.AssertGaugeMetrics(t, test.expectedGauges...)
 	}
 }
+
+// mockAsyncReporter reports send failures to the registered functions on demand
+type mockAsyncReporter struct {
+	mockReporter
+	failureReports []func(format string, spans int64)
+}
+
+func (r *mockAsyncReporter) OnSendFailure(fn func(format string, spans int64)) {
+	r.failureReports = append(r.failureReports, fn)
+}
+
+func (r *mockAsyncReporter) fail(format string, spans int64) {
+	for _, fn := range r.failureReports {
+		fn(format, spans)
+	}
+}
+
+func TestMetricsReporterAsyncSendFailure(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(time.Hour)
+	async := &mockAsyncReporter{}
+	r := WrapWithClientMetrics(ClientMetricsReporterParams{
+		Reporter:       WrapWithMetrics(async, metricsFactory),
+		Logger:         zap.NewNop(),
+		MetricsFactory: metricsFactory,
+	})
+	defer r.Close()
+
+	async.fail(JaegerFormat, 3)
+	metricsFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "reporter.batches.failures", Tags: map[string]string{"format": "jaeger"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "reporter.spans.failures", Tags: map[string]string{"format": "jaeger"}, Value: 3},
+		// the spans dropped by the clients do not include the send failures of the agent
+		metricstest.ExpectedMetric{Name: "client_stats.spans_dropped", Tags: map[string]string{"cause": "send-failure"}, Value: 0},
+	)
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// ProcessingOptions configures how the agent processes spans before reporting them to collectors.
+type ProcessingOptions struct {
+	// DropOperations is a regular expression matching the operation names of spans to drop
+	DropOperations string
+	// RedactionRulesFile is the path of a JSON file with rules for removing, masking or hashing tags,
+	// in the format of the redaction rules of the collector
+	RedactionRulesFile string
+	// HostMetadataFiles maps process tags to the files holding their values, e.g. a node name
+	HostMetadataFiles map[string]string
+	// HostLabelsFiles maps tag prefixes to files of key="value" lines, e.g. pod labels from the Kubernetes downward API
+	HostLabelsFiles map[string]string
+}
+
+// SpanProcessor drops and redacts spans in the agent, so that unwanted spans and sensitive data
+// never leave the host, and provides the host metadata added to the process tags of every span.
+type SpanProcessor struct {
+	dropOperations *regexp.Regexp
+	redact         sanitizer.SanitizeSpan
+	hostTags       []model.KeyValue
+	metrics        spanProcessorMetrics
+}
+
+type spanProcessorMetrics struct {
+	// Number of spans dropped because of their operation name
+	DroppedSpans metrics.Counter `metric:"span-processor.dropped-spans"`
+}
+
+// NewSpanProcessor creates a SpanProcessor. The redaction rules and host metadata are read once,
+// and it is an error if a configured file cannot be read.
+func NewSpanProcessor(opts ProcessingOptions, mFactory metrics.Factory, logger *zap.Logger) (*SpanProcessor, error) {
+	p := &SpanProcessor{}
+	metrics.MustInit(&p.metrics, mFactory, nil)
+	if opts.DropOperations != "" {
+		re, err := regexp.Compile(opts.DropOperations)
+		if err != nil {
+			return nil, fmt.Errorf("cannot compile the pattern of dropped operations: %w", err)
+		}
+		p.dropOperations = re
+	}
+	if opts.RedactionRulesFile != "" {
+		cfg, err := sanitizer.LoadRedactionConfig(opts.RedactionRulesFile)
+		if err != nil {
+			return nil, err
+		}
+		p.redact = sanitizer.NewRedactionSanitizer(*cfg)
+		logger.Info("Redacting spans", zap.Int("rules", len(cfg.Rules)))
+	}
+	hostTags, err := readHostMetadata(opts.HostMetadataFiles, opts.HostLabelsFiles)
+	if err != nil {
+		return nil, err
+	}
+	p.hostTags = hostTags
+	return p, nil
+}
+
+// HostTags returns the tags read from the host metadata files, sorted by key.
+func (p *SpanProcessor) HostTags() []model.KeyValue {
+	return p.hostTags
+}
+
+// Process drops the spans of matching operations and redacts the remaining spans and the process.
+// The slice of spans is filtered in place.
+func (p *SpanProcessor) Process(spans []*model.Span, process *model.Process) ([]*model.Span, *model.Process) {
+	if p.dropOperations != nil {
+		kept := spans[:0]
+		for _, span := range spans {
+			if !p.dropOperations.MatchString(span.OperationName) {
+				kept = append(kept, span)
+			}
+		}
+		if dropped := len(spans) - len(kept); dropped > 0 {
+			p.metrics.DroppedSpans.Inc(int64(dropped))
+		}
+		spans = kept
+	}
+	if p.redact != nil {
+		for i := range spans {
+			spans[i] = p.redact(spans[i])
+		}
+		if process != nil {
+			process = p.redact(&model.Span{Process: process}).Process
+		}
+	}
+	return spans, process
+}
+
+func readHostMetadata(files map[string]string, labelsFiles map[string]string) ([]model.KeyValue, error) {
+	tags := make(map[string]string)
+	for prefix, path := range labelsFiles {
+		labels, err := readLabelsFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range labels {
+			tags[prefix+k] = v
+		}
+	}
+	// tags from single value files take precedence over labels
+	for tag, path := range files {
+		data, err := ioutil.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read host metadata file: %w", err)
+		}
+		tags[tag] = strings.TrimSpace(string(data))
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	kvs := make([]model.KeyValue, len(keys))
+	for i, k := range keys {
+		kvs[i] = model.String(k, tags[k])
+	}
+	return kvs, nil
+}
+
+// readLabelsFile reads a file of key="value" lines, the format of labels and annotations
+// exposed by the Kubernetes downward API.
+func readLabelsFile(path string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read host labels file: %w", err)
+	}
+	labels := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		kv := strings.SplitN(text, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid label on line %d of %s: %q", line, path, text)
+		}
+		value, err := strconv.Unquote(kv[1])
+		if err != nil {
+			value = kv[1]
+		}
+		labels[kv[0]] = value
+	}
+	return labels, scanner.Err()
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+const redactionRules = `{
+	"rules": [
+		{"name": "tokens", "key_pattern": "^auth\\.token$", "action": "remove"},
+		{"name": "emails", "key_pattern": "email", "action": "hash"}
+	]
+}`
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestSpanProcessorProcess(t *testing.T) {
+	dir, err := ioutil.TempDir("", "span-processor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	mFactory := metricstest.NewFactory(0)
+	p, err := NewSpanProcessor(ProcessingOptions{
+		DropOperations:     "^GET /health$",
+		RedactionRulesFile: writeFile(t, dir, "redaction.json", redactionRules),
+	}, mFactory, zap.NewNop())
+	require.NoError(t, err)
+	assert.Empty(t, p.HostTags())
+
+	process := model.NewProcess("frontend", []model.KeyValue{model.String("owner.email", "a@example.com")})
+	spans := []*model.Span{
+		{OperationName: "GET /health"},
+		{OperationName: "GET /users", Tags: []model.KeyValue{model.String("auth.token", "secret"), model.Int64("status", 200)}},
+		{OperationName: "GET /health/details"},
+	}
+	spans, processed := p.Process(spans, process)
+	require.Len(t, spans, 2)
+	assert.Equal(t, "GET /users", spans[0].OperationName)
+	assert.Equal(t, []model.KeyValue{model.Int64("status", 200)}, spans[0].Tags)
+	assert.Equal(t, "GET /health/details", spans[1].OperationName)
+
+	require.Len(t, processed.Tags, 1)
+	assert.Contains(t, processed.Tags[0].VStr, "sha256:")
+	// the original process, which may be shared, is not modified
+	assert.Equal(t, "a@example.com", process.Tags[0].VStr)
+
+	mFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "span-processor.dropped-spans", Value: 1})
+}
+
+func TestSpanProcessorNoop(t *testing.T) {
+	p, err := NewSpanProcessor(ProcessingOptions{}, nil, zap.NewNop())
+	require.NoError(t, err)
+	spans := []*model.Span{{OperationName: "foo"}}
+	process := model.NewProcess("frontend", nil)
+	actualSpans, actualProcess := p.Process(spans, process)
+	assert.Equal(t, spans, actualSpans)
+	assert.Same(t, process, actualProcess)
+}
+
+func TestSpanProcessorHostTags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "span-processor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	labels := writeFile(t, dir, "labels", "app=\"checkout\"\n\npod-template-hash=\"7f9c\"\nteam=payments\nzone=\"label\"\n")
+	p, err := NewSpanProcessor(ProcessingOptions{
+		HostMetadataFiles: map[string]string{
+			"k8s.node.name": writeFile(t, dir, "nodename", "node-1\n"),
+			"k8s.pod.zone":  writeFile(t, dir, "zone", "us-east-1a"),
+		},
+		HostLabelsFiles: map[string]string{"k8s.pod.": labels},
+	}, nil, zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, []model.KeyValue{
+		model.String("k8s.node.name", "node-1"),
+		model.String("k8s.pod.app", "checkout"),
+		model.String("k8s.pod.pod-template-hash", "7f9c"),
+		model.String("k8s.pod.team", "payments"),
+		model.String("k8s.pod.zone", "us-east-1a"),
+	}, p.HostTags())
+}
+
+func TestSpanProcessorErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "span-processor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	testCases := []struct {
+		name string
+		opts ProcessingOptions
+		err  string
+	}{
+		{
+			name: "invalid drop pattern",
+			opts: ProcessingOptions{DropOperations: "("},
+			err:  "cannot compile the pattern of dropped operations",
+		},
+		{
+			name: "missing redaction rules",
+			opts: ProcessingOptions{RedactionRulesFile: filepath.Join(dir, "missing.json")},
+			err:  "failed to open redaction rules file",
+		},
+		{
+			name: "missing metadata file",
+			opts: ProcessingOptions{HostMetadataFiles: map[string]string{"zone": filepath.Join(dir, "missing")}},
+			err:  "cannot read host metadata file",
+		},
+		{
+			name: "missing labels file",
+			opts: ProcessingOptions{HostLabelsFiles: map[string]string{"label.": filepath.Join(dir, "missing")}},
+			err:  "cannot read host labels file",
+		},
+		{
+			name: "invalid labels file",
+			opts: ProcessingOptions{HostLabelsFiles: map[string]string{"label.": writeFile(t, dir, "labels", "app=\"a\"\nbroken\n")}},
+			err:  "invalid label on line 2",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewSpanProcessor(tc.opts, nil, zap.NewNop())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
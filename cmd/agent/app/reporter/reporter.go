@@ -30,6 +30,20 @@ type Reporter interface {
 	EmitBatch(ctx context.Context, batch *jaeger.Batch) (err error)
 }
 
+// Data formats of the spans emitted to reporters
+const (
+	JaegerFormat = "jaeger"
+	ZipkinFormat = "zipkin"
+)
+
+// AsyncReporter is implemented by reporters that may send spans after EmitZipkinBatch or EmitBatch
+// return, e.g. in larger batches, so that they cannot return the errors of sending them.
+type AsyncReporter interface {
+	// OnSendFailure registers a function called with the number of spans of a data format
+	// that could not be sent after they were emitted.
+	OnSendFailure(fn func(format string, spans int64))
+}
+
 // MultiReporter provides serial span emission to one or more reporters.  If
 // more than one expensive reporter are needed, one or more of them should be
 // wrapped and hidden behind a channel.
@@ -0,0 +1,222 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servers
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+)
+
+// defaultMaxFrameSize bounds the size of a frame, so that a corrupted length
+// prefix cannot make the server allocate arbitrary amounts of memory.
+const defaultMaxFrameSize = 64 * 1024 * 1024
+
+// TStreamServer is a custom thrift server that accepts stream connections, e.g. TCP or Unix
+// domain sockets, from the listener provided. It reads messages prefixed with their 4 bytes
+// big-endian length, which is the framing of thrift.TFramedTransport, and places them into
+// a buffered channel to be processed by the processor provided. Unlike TBufferedServer, it does
+// not drop messages when the channel is full, but stops reading from the connections instead,
+// which pushes back on the clients.
+type TStreamServer struct {
+	// NB. queueSize HAS to be at the top of the struct or it will SIGSEV for certain architectures.
+	// See https://github.com/golang/go/issues/13868
+	queueSize    int64
+	dataChan     chan *ReadBuf
+	listener     net.Listener
+	maxFrameSize int
+	serving      uint32
+	readBufPool  *sync.Pool
+
+	connsMu sync.Mutex
+	conns   map[net.Conn]struct{}
+	connsWG sync.WaitGroup
+	stopCh  chan struct{}
+
+	metrics struct {
+		// Size of the current server queue
+		QueueSize metrics.Gauge `metric:"thrift.server.queue_size"`
+
+		// Size (in bytes) of frames received by server
+		FrameSize metrics.Gauge `metric:"thrift.server.frame_size"`
+
+		// Number of frames processed by server
+		FramesProcessed metrics.Counter `metric:"thrift.server.frames.processed"`
+
+		// Number of connections closed because of malformed or truncated frames
+		ReadError metrics.Counter `metric:"thrift.server.read.errors"`
+
+		// Number of open client connections
+		Connections metrics.Gauge `metric:"thrift.server.connections"`
+	}
+}
+
+// NewTStreamServer creates a TStreamServer
+func NewTStreamServer(
+	listener net.Listener,
+	maxQueueSize int,
+	mFactory metrics.Factory,
+) (*TStreamServer, error) {
+	res := &TStreamServer{
+		dataChan:     make(chan *ReadBuf, maxQueueSize),
+		listener:     listener,
+		maxFrameSize: defaultMaxFrameSize,
+		readBufPool: &sync.Pool{
+			New: func() interface{} {
+				return &ReadBuf{}
+			},
+		},
+		conns:  make(map[net.Conn]struct{}),
+		stopCh: make(chan struct{}),
+	}
+	metrics.MustInit(&res.metrics, mFactory, nil)
+	return res, nil
+}
+
+// Addr returns the address the server is listening on
+func (s *TStreamServer) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Serve accepts connections until the server is stopped
+func (s *TStreamServer) Serve() {
+	select {
+	case <-s.stopCh:
+		return
+	default:
+	}
+	atomic.StoreUint32(&s.serving, 1)
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.stopCh:
+				return
+			default:
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			return
+		}
+		s.connsMu.Lock()
+		select {
+		case <-s.stopCh:
+			s.connsMu.Unlock()
+			_ = conn.Close()
+			return
+		default:
+		}
+		s.conns[conn] = struct{}{}
+		s.metrics.Connections.Update(int64(len(s.conns)))
+		s.connsWG.Add(1)
+		s.connsMu.Unlock()
+		go s.serveConn(conn)
+	}
+}
+
+func (s *TStreamServer) serveConn(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+		s.connsMu.Lock()
+		delete(s.conns, conn)
+		s.metrics.Connections.Update(int64(len(s.conns)))
+		s.connsMu.Unlock()
+		s.connsWG.Done()
+	}()
+	reader := bufio.NewReader(conn)
+	var header [4]byte
+	for {
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			if err != io.EOF {
+				s.readError()
+			}
+			return
+		}
+		size := int(binary.BigEndian.Uint32(header[:]))
+		if size > s.maxFrameSize {
+			s.readError()
+			return
+		}
+		readBuf := s.readBufPool.Get().(*ReadBuf)
+		if len(readBuf.bytes) < size {
+			readBuf.bytes = make([]byte, size)
+		}
+		if _, err := io.ReadFull(reader, readBuf.bytes[:size]); err != nil {
+			s.readBufPool.Put(readBuf)
+			s.readError()
+			return
+		}
+		readBuf.n = size
+		s.metrics.FrameSize.Update(int64(size))
+		select {
+		case s.dataChan <- readBuf:
+			s.metrics.FramesProcessed.Inc(1)
+			s.updateQueueSize(1)
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// readError counts read errors, except those caused by the server closing the connections.
+func (s *TStreamServer) readError() {
+	if s.IsServing() {
+		s.metrics.ReadError.Inc(1)
+	}
+}
+
+func (s *TStreamServer) updateQueueSize(delta int64) {
+	atomic.AddInt64(&s.queueSize, delta)
+	s.metrics.QueueSize.Update(atomic.LoadInt64(&s.queueSize))
+}
+
+// IsServing indicates whether the server is currently serving traffic
+func (s *TStreamServer) IsServing() bool {
+	return atomic.LoadUint32(&s.serving) == 1
+}
+
+// Stop stops accepting connections, closes the open ones and waits until
+// they are released before closing the data channel
+func (s *TStreamServer) Stop() {
+	atomic.StoreUint32(&s.serving, 0)
+	_ = s.listener.Close()
+	s.connsMu.Lock()
+	close(s.stopCh)
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.connsMu.Unlock()
+	s.connsWG.Wait()
+	close(s.dataChan)
+}
+
+// DataChan returns the data chan of the stream server
+func (s *TStreamServer) DataChan() chan *ReadBuf {
+	return s.dataChan
+}
+
+// DataRecd is called by the consumers every time they read a data item from DataChan
+func (s *TStreamServer) DataRecd(buf *ReadBuf) {
+	s.updateQueueSize(-1)
+	s.readBufPool.Put(buf)
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servers
+
+import (
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	athrift "github.com/apache/thrift/lib/go/thrift"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/jaegertracing/jaeger/cmd/agent/app/customtransport"
+	"github.com/jaegertracing/jaeger/cmd/agent/app/testutils"
+	"github.com/jaegertracing/jaeger/thrift-gen/agent"
+	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
+)
+
+func newTestStreamServer(t *testing.T, network, address string, queueSize int) (*TStreamServer, *metricstest.Factory) {
+	listener, err := net.Listen(network, address)
+	require.NoError(t, err)
+	metricsFactory := metricstest.NewFactory(0)
+	server, err := NewTStreamServer(listener, queueSize, metricsFactory)
+	require.NoError(t, err)
+	return server, metricsFactory
+}
+
+func TestTStreamServer_SendReceive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		network string
+		address string
+	}{
+		{network: "tcp", address: "127.0.0.1:0"},
+		{network: "unix", address: filepath.Join(dir, "agent.sock")},
+	}
+	for _, test := range tests {
+		t.Run(test.network, func(t *testing.T) {
+			server, metricsFactory := newTestStreamServer(t, test.network, test.address, 10)
+			go server.Serve()
+			defer server.Stop()
+
+			client, clientCloser, err := testutils.NewThriftStreamClient(test.network, server.Addr().String(), athrift.NewTCompactProtocolFactory())
+			require.NoError(t, err)
+			defer clientCloser.Close()
+
+			batch := &jaeger.Batch{
+				Process: jaeger.NewProcess(),
+				Spans:   []*jaeger.Span{{OperationName: "span1"}, {OperationName: "span2"}},
+			}
+			batch.Process.ServiceName = "svc"
+			for i := 0; i < 3; i++ {
+				require.NoError(t, client.EmitBatch(context.Background(), batch))
+			}
+
+			for i := 0; i < 3; i++ {
+				select {
+				case readBuf := <-server.DataChan():
+					inMemReporter := testutils.NewInMemoryReporter()
+					protocol := athrift.NewTCompactProtocolFactory().GetProtocol(&customtransport.TBufferedReadTransport{})
+					_, err = protocol.Transport().Write(readBuf.GetBytes())
+					require.NoError(t, err)
+					server.DataRecd(readBuf)
+
+					_, err = agent.NewAgentProcessor(inMemReporter).Process(context.Background(), protocol, protocol)
+					require.NoError(t, err)
+					require.Len(t, inMemReporter.Spans(), 2)
+					assert.Equal(t, "span1", inMemReporter.Spans()[0].OperationName)
+				case <-time.After(5 * time.Second):
+					t.Fatal("server did not receive frames")
+				}
+			}
+			metricsFactory.AssertCounterMetrics(t,
+				metricstest.ExpectedMetric{Name: "thrift.server.frames.processed", Value: 3},
+			)
+			metricsFactory.AssertGaugeMetrics(t,
+				metricstest.ExpectedMetric{Name: "thrift.server.connections", Value: 1},
+				metricstest.ExpectedMetric{Name: "thrift.server.queue_size", Value: 0},
+			)
+		})
+	}
+}
+
+func writeFrame(t *testing.T, conn net.Conn, payload []byte) {
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(payload)))
+	_, err := conn.Write(append(header[:], payload...))
+	require.NoError(t, err)
+}
+
+func TestTStreamServer_Backpressure(t *testing.T) {
+	server, metricsFactory := newTestStreamServer(t, "tcp", "127.0.0.1:0", 1)
+	go server.Serve()
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// frames are never dropped: the second one waits until the first is consumed
+	writeFrame(t, conn, []byte("first"))
+	writeFrame(t, conn, []byte("second"))
+	writeFrame(t, conn, []byte("third"))
+
+	for _, expected := range []string{"first", "second"} {
+		select {
+		case readBuf := <-server.DataChan():
+			assert.Equal(t, expected, string(readBuf.GetBytes()))
+			server.DataRecd(readBuf)
+		case <-time.After(5 * time.Second):
+			t.Fatal("server did not receive frames")
+		}
+	}
+
+	// Stop releases the connection blocked on the full queue
+	for i := 0; i < 1000; i++ {
+		c, _ := metricsFactory.Snapshot()
+		if c["thrift.server.frames.processed"] == 3 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	writeFrame(t, conn, []byte("fourth"))
+	server.Stop()
+	var received []string
+	for readBuf := range server.DataChan() {
+		received = append(received, string(readBuf.GetBytes()))
+	}
+	assert.Equal(t, []string{"third"}, received)
+}
+
+func TestTStreamServer_ReadErrors(t *testing.T) {
+	server, metricsFactory := newTestStreamServer(t, "tcp", "127.0.0.1:0", 10)
+	server.maxFrameSize = 10
+	go server.Serve()
+	defer server.Stop()
+
+	// too large frame
+	conn, err := net.Dial("tcp", server.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	writeFrame(t, conn, make([]byte, 11))
+
+	// truncated frame
+	conn2, err := net.Dial("tcp", server.Addr().String())
+	require.NoError(t, err)
+	_, err = conn2.Write([]byte{0, 0, 0, 5, 1, 2})
+	require.NoError(t, err)
+	require.NoError(t, conn2.Close())
+
+	for i := 0; i < 5000; i++ {
+		c, g := metricsFactory.Snapshot()
+		if c["thrift.server.read.errors"] == 2 && g["thrift.server.connections"] == 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	metricsFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "thrift.server.read.errors", Value: 2},
+		metricstest.ExpectedMetric{Name: "thrift.server.frames.processed", Value: 0},
+	)
+	metricsFactory.AssertGaugeMetrics(t,
+		metricstest.ExpectedMetric{Name: "thrift.server.connections", Value: 0},
+	)
+}
+
+func TestTStreamServer_StopBeforeServe(t *testing.T) {
+	server, _ := newTestStreamServer(t, "tcp", "127.0.0.1:0", 10)
+	server.Stop()
+	server.Serve()
+	assert.False(t, server.IsServing())
+	_, ok := <-server.DataChan()
+	assert.False(t, ok)
+}
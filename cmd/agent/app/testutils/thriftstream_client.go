@@ -0,0 +1,37 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"io"
+	"net"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	"github.com/jaegertracing/jaeger/thrift-gen/agent"
+)
+
+// NewThriftStreamClient creates a new agent client that sends framed Thrift messages over
+// a stream connection, e.g. "tcp" or "unix", to the agent
+func NewThriftStreamClient(network, address string, protocolFactory thrift.TProtocolFactory) (*agent.AgentClient, io.Closer, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clientTransport := thrift.NewTFramedTransport(thrift.NewStreamTransportRW(conn))
+	client := agent.NewAgentClientFactory(clientTransport, protocolFactory)
+	return client, conn, nil
+}
@@ -55,6 +55,10 @@ func main() {
 
 			rOpts := new(reporter.Options).InitFromViper(v, logger)
 			grpcBuilder := grpc.NewConnBuilder().InitFromViper(v)
+			collectorDiscovery, err := grpcBuilder.CreateDiscovery(logger)
+			if err != nil {
+				logger.Fatal("Could not create collector discovery", zap.Error(err))
+			}
 			builders := map[reporter.Type]app.CollectorProxyBuilder{
 				reporter.GRPC: app.GRPCCollectorProxyBuilder(grpcBuilder),
 			}
@@ -67,14 +71,14 @@ func main() {
 				logger.Fatal("Could not create collector proxy", zap.Error(err))
 			}
 
-			// TODO illustrate discovery service wiring
-
 			builder := new(app.Builder).InitFromViper(v)
 			agent, err := builder.CreateAgent(cp, logger, mFactory)
 			if err != nil {
 				return fmt.Errorf("unable to initialize Jaeger Agent: %w", err)
 			}
 
+			svc.Admin.Handle("/clients", agent.ClientsHandler())
+
 			logger.Info("Starting agent")
 			if err := agent.Run(); err != nil {
 				return fmt.Errorf("failed to run the agent: %w", err)
@@ -82,6 +86,9 @@ func main() {
 			svc.RunAndThen(func() {
 				agent.Stop()
 				cp.Close()
+				if collectorDiscovery != nil {
+					collectorDiscovery.Close()
+				}
 			})
 			return nil
 		},
@@ -41,10 +41,12 @@ import (
 	queryApp "github.com/jaegertracing/jaeger/cmd/query/app"
 	"github.com/jaegertracing/jaeger/cmd/query/app/querysvc"
 	"github.com/jaegertracing/jaeger/pkg/config"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/pkg/version"
 	ss "github.com/jaegertracing/jaeger/plugin/sampling/strategystore"
 	"github.com/jaegertracing/jaeger/plugin/storage"
 	"github.com/jaegertracing/jaeger/ports"
+	jaegerStorage "github.com/jaegertracing/jaeger/storage"
 	"github.com/jaegertracing/jaeger/storage/dependencystore"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 	storageMetrics "github.com/jaegertracing/jaeger/storage/spanstore/metrics"
@@ -102,11 +104,16 @@ by default uses only in-memory database.`,
 				logger.Fatal("Failed to create dependency reader", zap.Error(err))
 			}
 
+			ssFactory, err := storageFactory.CreateSamplingStoreFactory()
+			if err != nil {
+				logger.Fatal("Failed to create sampling store factory", zap.Error(err))
+			}
+
 			strategyStoreFactory.InitFromViper(v)
-			if err := strategyStoreFactory.Initialize(metricsFactory, logger); err != nil {
+			if err := strategyStoreFactory.Initialize(metricsFactory, ssFactory, logger); err != nil {
 				logger.Fatal("Failed to init sampling strategy store factory", zap.Error(err))
 			}
-			strategyStore, err := strategyStoreFactory.CreateStrategyStore()
+			strategyStore, aggregator, err := strategyStoreFactory.CreateStrategyStore()
 			if err != nil {
 				logger.Fatal("Failed to create sampling strategy store", zap.Error(err))
 			}
@@ -116,16 +123,31 @@ by default uses only in-memory database.`,
 			grpcBuilder := agentGrpcRep.NewConnBuilder().InitFromViper(v)
 			cOpts := new(collectorApp.CollectorOptions).InitFromViper(v)
 			qOpts := new(queryApp.QueryOptions).InitFromViper(v, logger)
+			if err := qOpts.Tenancy.Validate(); err != nil {
+				logger.Fatal("Invalid multi-tenancy configuration", zap.Error(err))
+			}
+
+			if qOpts.Tenancy.Enabled {
+				if !storageFactory.SupportsTenancy() {
+					logger.Fatal("Multi-tenancy is enabled but the span storage does not support it")
+				}
+				spanReader = jaegerStorage.NewTenantSpanReader(storageFactory, spanReader)
+			}
 
 			// collector
-			c := collectorApp.New(&collectorApp.CollectorParams{
+			collectorParams := &collectorApp.CollectorParams{
 				ServiceName:    "jaeger-collector",
 				Logger:         logger,
 				MetricsFactory: metricsFactory,
 				SpanWriter:     spanWriter,
 				StrategyStore:  strategyStore,
+				Aggregator:     aggregator,
 				HealthCheck:    svc.HC(),
-			})
+			}
+			if storageFactory.SupportsTenancy() {
+				collectorParams.TenantFactory = storageFactory
+			}
+			c := collectorApp.New(collectorParams)
 			c.Start(cOpts)
 
 			// agent
@@ -182,6 +204,7 @@ by default uses only in-memory database.`,
 		agentGrpcRep.AddFlags,
 		collectorApp.AddFlags,
 		queryApp.AddFlags,
+		tenancy.AddFlags,
 		strategyStoreFactory.AddFlags,
 	)
 
@@ -0,0 +1,44 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baggage
+
+import (
+	"context"
+
+	"github.com/jaegertracing/jaeger/model/converter/thrift/jaeger"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	tBaggage "github.com/jaegertracing/jaeger/thrift-gen/baggage"
+)
+
+// GRPCHandler is baggage restrictions handler for gRPC.
+type GRPCHandler struct {
+	manager tBaggage.BaggageRestrictionManager
+}
+
+// NewGRPCHandler creates a handler that serves baggage restrictions for services.
+func NewGRPCHandler(manager tBaggage.BaggageRestrictionManager) GRPCHandler {
+	return GRPCHandler{
+		manager: manager,
+	}
+}
+
+// GetBaggageRestrictions returns baggage restrictions of the service from the manager.
+func (h GRPCHandler) GetBaggageRestrictions(ctx context.Context, param *api_v2.BaggageRestrictionsParameters) (*api_v2.BaggageRestrictionsResponse, error) {
+	r, err := h.manager.GetBaggageRestrictions(ctx, param.GetServiceName())
+	if err != nil {
+		return nil, err
+	}
+	return jaeger.ConvertBaggageRestrictionsToDomain(r), nil
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baggage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	tBaggage "github.com/jaegertracing/jaeger/thrift-gen/baggage"
+)
+
+type mockBaggageManager struct{}
+
+func (mockBaggageManager) GetBaggageRestrictions(_ context.Context, serviceName string) ([]*tBaggage.BaggageRestriction, error) {
+	if serviceName == "error" {
+		return nil, errors.New("some error")
+	}
+	return []*tBaggage.BaggageRestriction{{BaggageKey: "session-id", MaxValueLength: 64}}, nil
+}
+
+func TestNewGRPCHandler(t *testing.T) {
+	tests := []struct {
+		req  *api_v2.BaggageRestrictionsParameters
+		resp *api_v2.BaggageRestrictionsResponse
+		err  string
+	}{
+		{req: &api_v2.BaggageRestrictionsParameters{ServiceName: "error"}, err: "some error"},
+		{
+			req: &api_v2.BaggageRestrictionsParameters{ServiceName: "foo"},
+			resp: &api_v2.BaggageRestrictionsResponse{BaggageRestrictions: []api_v2.BaggageRestriction{
+				{BaggageKey: "session-id", MaxValueLength: 64},
+			}},
+		},
+	}
+	h := NewGRPCHandler(mockBaggageManager{})
+	for _, test := range tests {
+		resp, err := h.GetBaggageRestrictions(context.Background(), test.req)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err)
+			require.Nil(t, resp)
+		} else {
+			require.NoError(t, err)
+			assert.Equal(t, test.resp, resp)
+		}
+	}
+}
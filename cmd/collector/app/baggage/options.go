@@ -0,0 +1,49 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baggage
+
+import (
+	"flag"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	baggageRestrictionsFile   = "collector.baggage.restrictions-file"
+	baggageRestrictionsReload = "collector.baggage.restrictions-reload-interval"
+)
+
+// Options holds configuration for the file-based baggage restriction store.
+type Options struct {
+	// RestrictionsFile is the path for the baggage restrictions file in JSON format.
+	// Baggage restrictions are not served when it is empty.
+	RestrictionsFile string
+	// ReloadInterval is the time interval to check and reload the restrictions file
+	ReloadInterval time.Duration
+}
+
+// AddFlags adds flags for Options
+func AddFlags(flagSet *flag.FlagSet) {
+	flagSet.String(baggageRestrictionsFile, "", "The path for the baggage restrictions file in JSON format, defining the baggage keys services are allowed to set and the max length of their values. Baggage restrictions are not served if empty")
+	flagSet.Duration(baggageRestrictionsReload, 0, "Reload interval to check and reload the baggage restrictions file. Zero value means no reloading")
+}
+
+// InitFromViper initializes Options with properties from viper
+func (opts *Options) InitFromViper(v *viper.Viper) *Options {
+	opts.RestrictionsFile = v.GetString(baggageRestrictionsFile)
+	opts.ReloadInterval = v.GetDuration(baggageRestrictionsReload)
+	return opts
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baggage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	tBaggage "github.com/jaegertracing/jaeger/thrift-gen/baggage"
+)
+
+type restriction struct {
+	Key            string `json:"key"`
+	MaxValueLength int32  `json:"max_value_length"`
+}
+
+type serviceRestrictions struct {
+	Service      string         `json:"service"`
+	Restrictions []*restriction `json:"restrictions"`
+}
+
+type restrictions struct {
+	DefaultRestrictions []*restriction         `json:"default_restrictions"`
+	ServiceRestrictions []*serviceRestrictions `json:"service_restrictions"`
+}
+
+type storedRestrictions struct {
+	defaultRestrictions []*tBaggage.BaggageRestriction
+	serviceRestrictions map[string][]*tBaggage.BaggageRestriction
+}
+
+// Store serves baggage restrictions loaded from a restrictions file.
+// It implements thrift baggage.BaggageRestrictionManager.
+type Store struct {
+	logger *zap.Logger
+
+	restrictions atomic.Value // holds *storedRestrictions
+
+	ctx        context.Context
+	cancelFunc context.CancelFunc
+}
+
+// NewStore creates a Store with restrictions loaded from options.RestrictionsFile.
+// If options.ReloadInterval is positive, the file is periodically checked for changes.
+func NewStore(options Options, logger *zap.Logger) (*Store, error) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	s := &Store{
+		logger:     logger,
+		ctx:        ctx,
+		cancelFunc: cancelFunc,
+	}
+	data, err := ioutil.ReadFile(filepath.Clean(options.RestrictionsFile))
+	if err != nil {
+		cancelFunc()
+		return nil, fmt.Errorf("failed to open baggage restrictions file: %w", err)
+	}
+	if err := s.updateRestrictions(data); err != nil {
+		cancelFunc()
+		return nil, err
+	}
+	if options.ReloadInterval > 0 {
+		go s.autoUpdateRestrictions(options.ReloadInterval, options.RestrictionsFile, string(data))
+	}
+	return s, nil
+}
+
+// GetBaggageRestrictions returns the baggage restrictions of the service. Services without
+// their own restrictions get the default ones.
+func (s *Store) GetBaggageRestrictions(_ context.Context, serviceName string) ([]*tBaggage.BaggageRestriction, error) {
+	stored := s.restrictions.Load().(*storedRestrictions)
+	if r, ok := stored.serviceRestrictions[serviceName]; ok {
+		return r, nil
+	}
+	return stored.defaultRestrictions, nil
+}
+
+// Close stops reloading the restrictions file.
+func (s *Store) Close() error {
+	s.cancelFunc()
+	return nil
+}
+
+func (s *Store) autoUpdateRestrictions(interval time.Duration, filePath string, lastValue string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			lastValue = s.reloadRestrictionsFile(filePath, lastValue)
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Store) reloadRestrictionsFile(filePath string, lastValue string) string {
+	currBytes, err := ioutil.ReadFile(filepath.Clean(filePath))
+	if err != nil {
+		s.logger.Error("failed to load baggage restrictions", zap.String("file", filePath), zap.Error(err))
+		return lastValue
+	}
+	newValue := string(currBytes)
+	if lastValue == newValue {
+		return lastValue
+	}
+	if err = s.updateRestrictions(currBytes); err != nil {
+		s.logger.Error("failed to update baggage restrictions from file", zap.Error(err))
+		return lastValue
+	}
+	s.logger.Info("Updated baggage restrictions", zap.String("file", filePath))
+	return newValue
+}
+
+func (s *Store) updateRestrictions(data []byte) error {
+	stored, err := parseRestrictions(data)
+	if err != nil {
+		return err
+	}
+	s.restrictions.Store(stored)
+	return nil
+}
+
+// parseRestrictions validates the restrictions and merges the restrictions of every service
+// with the default ones, the service restrictions taking precedence.
+func parseRestrictions(data []byte) (*storedRestrictions, error) {
+	var r restrictions
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal baggage restrictions: %w", err)
+	}
+	defaults, err := toMap(r.DefaultRestrictions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid default baggage restrictions: %w", err)
+	}
+	stored := &storedRestrictions{
+		defaultRestrictions: toThrift(defaults),
+		serviceRestrictions: make(map[string][]*tBaggage.BaggageRestriction, len(r.ServiceRestrictions)),
+	}
+	for _, sr := range r.ServiceRestrictions {
+		if sr == nil || sr.Service == "" {
+			return nil, fmt.Errorf("baggage restrictions without a service name")
+		}
+		if _, ok := stored.serviceRestrictions[sr.Service]; ok {
+			return nil, fmt.Errorf("duplicate baggage restrictions for service %s", sr.Service)
+		}
+		m, err := toMap(sr.Restrictions)
+		if err != nil {
+			return nil, fmt.Errorf("invalid baggage restrictions for service %s: %w", sr.Service, err)
+		}
+		for key, maxValueLength := range defaults {
+			if _, ok := m[key]; !ok {
+				m[key] = maxValueLength
+			}
+		}
+		stored.serviceRestrictions[sr.Service] = toThrift(m)
+	}
+	return stored, nil
+}
+
+func toMap(restrictions []*restriction) (map[string]int32, error) {
+	m := make(map[string]int32, len(restrictions))
+	for _, r := range restrictions {
+		if r == nil || r.Key == "" {
+			return nil, fmt.Errorf("baggage key must not be empty")
+		}
+		if r.MaxValueLength <= 0 {
+			return nil, fmt.Errorf("max_value_length of baggage key %s must be positive", r.Key)
+		}
+		if _, ok := m[r.Key]; ok {
+			return nil, fmt.Errorf("duplicate baggage key %s", r.Key)
+		}
+		m[r.Key] = r.MaxValueLength
+	}
+	return m, nil
+}
+
+func toThrift(m map[string]int32) []*tBaggage.BaggageRestriction {
+	restrictions := make([]*tBaggage.BaggageRestriction, 0, len(m))
+	for key, maxValueLength := range m {
+		restrictions = append(restrictions, &tBaggage.BaggageRestriction{
+			BaggageKey:     key,
+			MaxValueLength: maxValueLength,
+		})
+	}
+	sort.Slice(restrictions, func(i, j int) bool {
+		return restrictions[i].BaggageKey < restrictions[j].BaggageKey
+	})
+	return restrictions
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baggage
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	tBaggage "github.com/jaegertracing/jaeger/thrift-gen/baggage"
+)
+
+func TestNewStoreErrors(t *testing.T) {
+	_, err := NewStore(Options{RestrictionsFile: "fixtures/missing.json"}, zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to open baggage restrictions file")
+
+	_, err = NewStore(Options{RestrictionsFile: "store_test.go"}, zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to unmarshal baggage restrictions")
+}
+
+func TestGetBaggageRestrictions(t *testing.T) {
+	s, err := NewStore(Options{RestrictionsFile: "fixtures/restrictions.json"}, zap.NewNop())
+	require.NoError(t, err)
+	defer s.Close()
+
+	tests := []struct {
+		service  string
+		expected []*tBaggage.BaggageRestriction
+	}{
+		{
+			service: "frontend",
+			expected: []*tBaggage.BaggageRestriction{
+				{BaggageKey: "experiment", MaxValueLength: 8},
+				{BaggageKey: "session-id", MaxValueLength: 64},
+				{BaggageKey: "tenant", MaxValueLength: 32},
+			},
+		},
+		{
+			service: "batch",
+			expected: []*tBaggage.BaggageRestriction{
+				{BaggageKey: "session-id", MaxValueLength: 64},
+				{BaggageKey: "tenant", MaxValueLength: 16},
+			},
+		},
+		{
+			service: "unknown",
+			expected: []*tBaggage.BaggageRestriction{
+				{BaggageKey: "session-id", MaxValueLength: 64},
+				{BaggageKey: "tenant", MaxValueLength: 16},
+			},
+		},
+	}
+	for _, test := range tests {
+		r, err := s.GetBaggageRestrictions(context.Background(), test.service)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, r, test.service)
+	}
+}
+
+func TestParseRestrictionsErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		err  string
+	}{
+		{
+			data: `{"default_restrictions": [{"key": "", "max_value_length": 1}]}`,
+			err:  "invalid default baggage restrictions: baggage key must not be empty",
+		},
+		{
+			data: `{"default_restrictions": [{"key": "a", "max_value_length": 0}]}`,
+			err:  "invalid default baggage restrictions: max_value_length of baggage key a must be positive",
+		},
+		{
+			data: `{"default_restrictions": [{"key": "a", "max_value_length": 1}, {"key": "a", "max_value_length": 2}]}`,
+			err:  "invalid default baggage restrictions: duplicate baggage key a",
+		},
+		{
+			data: `{"service_restrictions": [{"restrictions": []}]}`,
+			err:  "baggage restrictions without a service name",
+		},
+		{
+			data: `{"service_restrictions": [{"service": "a"}, {"service": "a"}]}`,
+			err:  "duplicate baggage restrictions for service a",
+		},
+		{
+			data: `{"service_restrictions": [{"service": "a", "restrictions": [null]}]}`,
+			err:  "invalid baggage restrictions for service a: baggage key must not be empty",
+		},
+	}
+	for _, test := range tests {
+		_, err := parseRestrictions([]byte(test.data))
+		assert.EqualError(t, err, test.err, test.data)
+	}
+}
+
+func TestStoreAutoReload(t *testing.T) {
+	tempFile, err := ioutil.TempFile("", "restrictions.json")
+	require.NoError(t, err)
+	defer os.Remove(tempFile.Name())
+	require.NoError(t, ioutil.WriteFile(tempFile.Name(), []byte(`{"default_restrictions": [{"key": "a", "max_value_length": 1}]}`), 0644))
+
+	s, err := NewStore(Options{RestrictionsFile: tempFile.Name(), ReloadInterval: 10 * time.Millisecond}, zap.NewNop())
+	require.NoError(t, err)
+	defer s.Close()
+
+	keys := func() []string {
+		r, err := s.GetBaggageRestrictions(context.Background(), "svc")
+		require.NoError(t, err)
+		var keys []string
+		for _, br := range r {
+			keys = append(keys, br.BaggageKey)
+		}
+		return keys
+	}
+	assert.Equal(t, []string{"a"}, keys())
+
+	require.NoError(t, ioutil.WriteFile(tempFile.Name(), []byte(`{"default_restrictions": [{"key": "b", "max_value_length": 1}]}`), 0644))
+	for i := 0; i < 100 && keys()[0] == "a"; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, []string{"b"}, keys())
+}
+
+func TestReloadRestrictionsFileErrors(t *testing.T) {
+	s, err := NewStore(Options{RestrictionsFile: "fixtures/restrictions.json"}, zap.NewNop())
+	require.NoError(t, err)
+	defer s.Close()
+
+	assert.Equal(t, "last", s.reloadRestrictionsFile("fixtures/missing.json", "last"))
+	assert.Equal(t, "last", s.reloadRestrictionsFile("store_test.go", "last"))
+	r, err := s.GetBaggageRestrictions(context.Background(), "frontend")
+	require.NoError(t, err)
+	assert.Len(t, r, 3)
+}
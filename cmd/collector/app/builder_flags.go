@@ -17,17 +17,27 @@ package app
 
 import (
 	"flag"
+	"time"
 
 	"github.com/spf13/viper"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/baggage"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/deadletter"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/ratelimit"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/spanfilter"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/spanmetrics"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/tailsampling"
 	"github.com/jaegertracing/jaeger/cmd/flags"
 	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
+	"github.com/jaegertracing/jaeger/pkg/queue"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/ports"
 )
 
 const (
 	collectorDynQueueSizeMemory = "collector.queue-size-memory"
 	collectorQueueSize          = "collector.queue-size"
+	collectorTenantQueueSize    = "collector.queue-size-per-tenant"
 	collectorNumWorkers         = "collector.num-workers"
 	collectorHTTPPort           = "collector.http-port"
 	collectorGRPCPort           = "collector.grpc-port"
@@ -41,6 +51,22 @@ const (
 	collectorTags                 = "collector.tags"
 	collectorZipkinAllowedOrigins = "collector.zipkin.allowed-origins"
 	collectorZipkinAllowedHeaders = "collector.zipkin.allowed-headers"
+	collectorRedactionRulesFile   = "collector.redaction.rules-file"
+	collectorOTLPEnabled          = "collector.otlp.enabled"
+	collectorOTLPGRPCHostPort     = "collector.otlp.grpc.host-port"
+	collectorOTLPHTTPHostPort     = "collector.otlp.http.host-port"
+
+	collectorPersistentQueueDirectory     = "collector.queue.persistent.directory"
+	collectorPersistentQueueSegmentSize   = "collector.queue.persistent.segment-size"
+	collectorPersistentQueueMaxDiskSize   = "collector.queue.persistent.max-disk-size"
+	collectorPersistentQueueSyncPolicy    = "collector.queue.persistent.sync-policy"
+	collectorPersistentQueueSyncInterval  = "collector.queue.persistent.sync-interval"
+	collectorPersistentQueueRetryInterval = "collector.queue.persistent.retry-interval"
+	collectorPersistentQueueMaxRetries    = "collector.queue.persistent.max-retries"
+
+	collectorWriteRetryMaxRetries      = "collector.write-retry.max-retries"
+	collectorWriteRetryInitialInterval = "collector.write-retry.initial-interval"
+	collectorWriteRetryMaxInterval     = "collector.write-retry.max-interval"
 
 	collectorHTTPPortWarning       = "(deprecated, will be removed after 2020-06-30 or in release v1.20.0, whichever is later)"
 	collectorGRPCPortWarning       = "(deprecated, will be removed after 2020-06-30 or in release v1.20.0, whichever is later)"
@@ -53,14 +79,34 @@ var tlsFlagsConfig = tlscfg.ServerFlagsConfig{
 	ShowClientCA: true,
 }
 
+var otlpGRPCTLSFlagsConfig = tlscfg.ServerFlagsConfig{
+	Prefix:       "collector.otlp.grpc",
+	ShowEnabled:  true,
+	ShowClientCA: true,
+}
+
+var otlpHTTPTLSFlagsConfig = tlscfg.ServerFlagsConfig{
+	Prefix:       "collector.otlp.http",
+	ShowEnabled:  true,
+	ShowClientCA: true,
+}
+
 // CollectorOptions holds configuration for collector
 type CollectorOptions struct {
 	// DynQueueSizeMemory determines how much memory to use for the queue
 	DynQueueSizeMemory uint
 	// QueueSize is the size of collector's queue
 	QueueSize int
+	// TenantQueueSize is the maximum number of queued spans of a single tenant, zero means no limit
+	TenantQueueSize int
 	// NumWorkers is the number of internal workers in a collector
 	NumWorkers int
+	// PersistentQueue configures the optional disk-backed queue used instead of the in-memory one
+	PersistentQueue PersistentQueueOptions
+	// WriteRetry configures retries of failed span writes from the in-memory queue
+	WriteRetry WriteRetryOptions
+	// DeadLetter configures the optional sink for spans that could not be saved
+	DeadLetter deadletter.Options
 	// CollectorHTTPHostPort is the host:port address that the collector service listens in on for http requests
 	CollectorHTTPHostPort string
 	// CollectorGRPCHostPort is the host:port address that the collector service listens in on for gRPC requests
@@ -75,11 +121,80 @@ type CollectorOptions struct {
 	CollectorZipkinAllowedOrigins string
 	// CollectorZipkinAllowedHeaders is a list of headers that the Zipkin collector service allowes the client to use with cross-domain requests
 	CollectorZipkinAllowedHeaders string
+	// TailSampling configures the optional tail-based sampling stage
+	TailSampling tailsampling.Options
+	// SpanFilter configures the optional rule-based span filter
+	SpanFilter spanfilter.Options
+	// SpanMetrics configures the optional RED metrics derived from spans
+	SpanMetrics spanmetrics.Options
+	// RedactionRulesFile is the path to the JSON file with rules for redacting tag and log values
+	RedactionRulesFile string
+	// OTLP configures the receivers for the OpenTelemetry protocol
+	OTLP OTLPOptions
+	// Tenancy configures multi-tenancy, its flags are registered with tenancy.AddFlags
+	Tenancy tenancy.Options
+	// RateLimit configures the admission control of spans at the collector endpoints
+	RateLimit ratelimit.Options
+	// Baggage configures the optional store of baggage restrictions served to agents and clients
+	Baggage baggage.Options
+}
+
+// OTLPOptions holds configuration for the OTLP gRPC and HTTP receivers
+type OTLPOptions struct {
+	// Enabled starts the OTLP receivers when true
+	Enabled bool
+	// GRPCHostPort is the host:port address that the collector listens in on for OTLP/gRPC requests
+	GRPCHostPort string
+	// GRPCTLS configures secure transport for OTLP/gRPC
+	GRPCTLS tlscfg.Options
+	// HTTPHostPort is the host:port address that the collector listens in on for OTLP/HTTP requests
+	HTTPHostPort string
+	// HTTPTLS configures secure transport for OTLP/HTTP
+	HTTPTLS tlscfg.Options
+}
+
+// PersistentQueueOptions holds configuration for the disk-backed span queue
+type PersistentQueueOptions struct {
+	// PersistentQueueOptions configures the write-ahead log, the queue is disabled if its Directory is empty
+	queue.PersistentQueueOptions
+	// RetryInterval is the upper bound of the first backoff interval before writing a span from the queue again after a failure
+	RetryInterval time.Duration
+	// MaxRetries is the number of times a failed write is retried before the span is sent to the dead-letter sink
+	// or dropped, zero means no limit
+	MaxRetries int
+}
+
+// WriteRetryOptions holds configuration for retrying failed span writes with exponential backoff
+type WriteRetryOptions struct {
+	// MaxRetries is the number of times a failed write is retried, a negative value means no limit
+	MaxRetries int
+	// InitialInterval is the upper bound of the first backoff interval
+	InitialInterval time.Duration
+	// MaxInterval is the upper bound of all backoff intervals
+	MaxInterval time.Duration
+}
+
+// writeRetry returns the retries of the span processor. Spans of the persistent queue are retried
+// according to the options of the queue, which keep them queued until they are written by default.
+func (cOpts *CollectorOptions) writeRetry() WriteRetryOptions {
+	if cOpts.PersistentQueue.Directory == "" {
+		return cOpts.WriteRetry
+	}
+	maxRetries := cOpts.PersistentQueue.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = -1
+	}
+	return WriteRetryOptions{
+		MaxRetries:      maxRetries,
+		InitialInterval: cOpts.PersistentQueue.RetryInterval,
+		MaxInterval:     cOpts.WriteRetry.MaxInterval,
+	}
 }
 
 // AddFlags adds flags for CollectorOptions
 func AddFlags(flags *flag.FlagSet) {
 	flags.Int(collectorQueueSize, DefaultQueueSize, "The queue size of the collector")
+	flags.Int(collectorTenantQueueSize, 0, "The max number of spans of a single tenant in the queue of the collector when multi-tenancy is enabled, zero means no limit other than the queue size")
 	flags.Int(collectorNumWorkers, DefaultNumWorkers, "The number of workers pulling items from the queue")
 	flags.Int(collectorHTTPPort, 0, collectorHTTPPortWarning+" see --"+CollectorHTTPHostPort)
 	flags.Int(collectorGRPCPort, 0, collectorGRPCPortWarning+" see --"+CollectorGRPCHostPort)
@@ -88,8 +203,18 @@ func AddFlags(flags *flag.FlagSet) {
 	flags.String(collectorTags, "", "One or more tags to be added to the Process tags of all spans passing through this collector. Ex: key1=value1,key2=${envVar:defaultValue}")
 	flags.String(collectorZipkinAllowedOrigins, "*", "Comma separated list of allowed origins for the Zipkin collector service, default accepts all")
 	flags.String(collectorZipkinAllowedHeaders, "content-type", "Comma separated list of allowed headers for the Zipkin collector service, default content-type")
+	flags.String(collectorRedactionRulesFile, "", "The path for the file with rules in JSON format for hashing, masking or removing sensitive values of span tags, log fields and process tags. Redaction is disabled if empty")
 	AddOTELJaegerFlags(flags)
 	AddOTELZipkinFlags(flags)
+	addOTLPFlags(flags)
+	addPersistentQueueFlags(flags)
+	addWriteRetryFlags(flags)
+	deadletter.AddFlags(flags)
+	tailsampling.AddFlags(flags)
+	spanfilter.AddFlags(flags)
+	spanmetrics.AddFlags(flags)
+	ratelimit.AddFlags(flags)
+	baggage.AddFlags(flags)
 }
 
 // AddOTELJaegerFlags adds flags that are exposed by OTEL Jaeger receier
@@ -104,17 +229,65 @@ func AddOTELZipkinFlags(flags *flag.FlagSet) {
 	flags.String(CollectorZipkinHTTPHostPort, ports.PortToHostPort(0), "The host:port (e.g. 127.0.0.1:5555 or :5555) of the collector's Zipkin server")
 }
 
+func addOTLPFlags(flags *flag.FlagSet) {
+	flags.Bool(collectorOTLPEnabled, false, "Enables receiving spans in the OpenTelemetry protocol (OTLP) over gRPC and HTTP")
+	flags.String(collectorOTLPGRPCHostPort, ports.PortToHostPort(ports.CollectorOTLPGRPC), "The host:port (e.g. 127.0.0.1:4317 or :4317) of the collector's OTLP gRPC server")
+	flags.String(collectorOTLPHTTPHostPort, ports.PortToHostPort(ports.CollectorOTLPHTTP), "The host:port (e.g. 127.0.0.1:4318 or :4318) of the collector's OTLP HTTP server")
+	otlpGRPCTLSFlagsConfig.AddFlags(flags)
+	otlpHTTPTLSFlagsConfig.AddFlags(flags)
+}
+
+func addPersistentQueueFlags(flags *flag.FlagSet) {
+	flags.String(collectorPersistentQueueDirectory, "", "The directory for the write-ahead log of the disk-backed span queue. Spans are queued in memory if empty")
+	flags.Uint(collectorPersistentQueueSegmentSize, 64, "The size in MiB of each segment file of the disk-backed span queue")
+	flags.Uint(collectorPersistentQueueMaxDiskSize, 1024, "The max total size in MiB of the disk-backed span queue, new spans are dropped when it is reached")
+	flags.String(collectorPersistentQueueSyncPolicy, string(queue.SyncInterval), "When spans in the disk-backed queue are flushed to disk: always, interval, never")
+	flags.Duration(collectorPersistentQueueSyncInterval, time.Second, "The interval for flushing the disk-backed span queue to disk when the sync policy is interval")
+	flags.Duration(collectorPersistentQueueRetryInterval, DefaultRetryInitialInterval, "The upper bound of the first backoff interval before writing a span from the disk-backed queue again after the storage failed")
+	flags.Int(collectorPersistentQueueMaxRetries, 0, "The number of times writing a span from the disk-backed queue is retried before the span is sent to the dead-letter sink or dropped. Zero means no limit")
+}
+
+func addWriteRetryFlags(flags *flag.FlagSet) {
+	flags.Int(collectorWriteRetryMaxRetries, 0, "The number of times a failed span write from the in-memory queue is retried with exponential backoff and jitter before the span is sent to the dead-letter sink or dropped. Zero disables retries, a negative value retries until the span is written. The disk-backed queue uses --"+collectorPersistentQueueMaxRetries+" instead")
+	flags.Duration(collectorWriteRetryInitialInterval, DefaultRetryInitialInterval, "The upper bound of the first backoff interval between span write retries, doubled after each retry")
+	flags.Duration(collectorWriteRetryMaxInterval, DefaultRetryMaxInterval, "The upper bound of the backoff interval between span write retries")
+}
+
 // InitFromViper initializes CollectorOptions with properties from viper
 func (cOpts *CollectorOptions) InitFromViper(v *viper.Viper) *CollectorOptions {
 	cOpts.DynQueueSizeMemory = v.GetUint(collectorDynQueueSizeMemory) * 1024 * 1024 // we receive in MiB and store in bytes
 	cOpts.QueueSize = v.GetInt(collectorQueueSize)
+	cOpts.TenantQueueSize = v.GetInt(collectorTenantQueueSize)
 	cOpts.NumWorkers = v.GetInt(collectorNumWorkers)
+	cOpts.PersistentQueue.Directory = v.GetString(collectorPersistentQueueDirectory)
+	cOpts.PersistentQueue.SegmentSize = int64(v.GetUint(collectorPersistentQueueSegmentSize)) * 1024 * 1024
+	cOpts.PersistentQueue.MaxDiskSize = int64(v.GetUint(collectorPersistentQueueMaxDiskSize)) * 1024 * 1024
+	cOpts.PersistentQueue.SyncPolicy = queue.SyncPolicy(v.GetString(collectorPersistentQueueSyncPolicy))
+	cOpts.PersistentQueue.SyncInterval = v.GetDuration(collectorPersistentQueueSyncInterval)
+	cOpts.PersistentQueue.RetryInterval = v.GetDuration(collectorPersistentQueueRetryInterval)
+	cOpts.PersistentQueue.MaxRetries = v.GetInt(collectorPersistentQueueMaxRetries)
+	cOpts.WriteRetry.MaxRetries = v.GetInt(collectorWriteRetryMaxRetries)
+	cOpts.WriteRetry.InitialInterval = v.GetDuration(collectorWriteRetryInitialInterval)
+	cOpts.WriteRetry.MaxInterval = v.GetDuration(collectorWriteRetryMaxInterval)
+	cOpts.DeadLetter.InitFromViper(v)
 	cOpts.CollectorHTTPHostPort = ports.GetAddressFromCLIOptions(v.GetInt(collectorHTTPPort), v.GetString(CollectorHTTPHostPort))
 	cOpts.CollectorGRPCHostPort = ports.GetAddressFromCLIOptions(v.GetInt(collectorGRPCPort), v.GetString(CollectorGRPCHostPort))
 	cOpts.CollectorZipkinHTTPHostPort = ports.GetAddressFromCLIOptions(v.GetInt(collectorZipkinHTTPPort), v.GetString(CollectorZipkinHTTPHostPort))
 	cOpts.CollectorTags = flags.ParseJaegerTags(v.GetString(collectorTags))
 	cOpts.CollectorZipkinAllowedOrigins = v.GetString(collectorZipkinAllowedOrigins)
 	cOpts.CollectorZipkinAllowedHeaders = v.GetString(collectorZipkinAllowedHeaders)
+	cOpts.RedactionRulesFile = v.GetString(collectorRedactionRulesFile)
+	cOpts.OTLP.Enabled = v.GetBool(collectorOTLPEnabled)
+	cOpts.OTLP.GRPCHostPort = ports.GetAddressFromCLIOptions(0, v.GetString(collectorOTLPGRPCHostPort))
+	cOpts.OTLP.GRPCTLS = otlpGRPCTLSFlagsConfig.InitFromViper(v)
+	cOpts.OTLP.HTTPHostPort = ports.GetAddressFromCLIOptions(0, v.GetString(collectorOTLPHTTPHostPort))
+	cOpts.OTLP.HTTPTLS = otlpHTTPTLSFlagsConfig.InitFromViper(v)
 	cOpts.TLS = tlsFlagsConfig.InitFromViper(v)
+	cOpts.TailSampling.InitFromViper(v)
+	cOpts.SpanFilter.InitFromViper(v)
+	cOpts.SpanMetrics.InitFromViper(v)
+	cOpts.Tenancy.InitFromViper(v)
+	cOpts.RateLimit.InitFromViper(v)
+	cOpts.Baggage.InitFromViper(v)
 	return cOpts
 }
@@ -16,10 +16,14 @@ package app
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/ratelimit"
 	"github.com/jaegertracing/jaeger/pkg/config"
+	"github.com/jaegertracing/jaeger/pkg/queue"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 )
 
 func TestCollectorOptionsWithFlags_CheckHostPort(t *testing.T) {
@@ -51,3 +55,131 @@ func TestCollectorOptionsWithFlags_CheckFullHostPort(t *testing.T) {
 	assert.Equal(t, "127.0.0.1:1234", c.CollectorGRPCHostPort)
 	assert.Equal(t, "0.0.0.0:3456", c.CollectorZipkinHTTPHostPort)
 }
+
+func TestCollectorOptionsWithFlags_CheckOTLP(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.otlp.enabled=true",
+		"--collector.otlp.grpc.host-port=127.0.0.1:1234",
+		"--collector.otlp.http.host-port=5678",
+		"--collector.otlp.http.tls.enabled=true",
+	})
+	c.InitFromViper(v)
+
+	assert.True(t, c.OTLP.Enabled)
+	assert.Equal(t, "127.0.0.1:1234", c.OTLP.GRPCHostPort)
+	assert.Equal(t, ":5678", c.OTLP.HTTPHostPort)
+	assert.False(t, c.OTLP.GRPCTLS.Enabled)
+	assert.True(t, c.OTLP.HTTPTLS.Enabled)
+}
+
+func TestCollectorOptionsWithFlags_CheckSpanMetrics(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.span-metrics.enabled=true",
+		"--collector.span-metrics.max-cardinality=500",
+	})
+	c.InitFromViper(v)
+
+	assert.True(t, c.SpanMetrics.Enabled)
+	assert.Equal(t, 500, c.SpanMetrics.MaxCardinality)
+}
+
+func TestCollectorOptionsWithFlags_CheckPersistentQueue(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.queue.persistent.directory=/var/lib/jaeger/queue",
+		"--collector.queue.persistent.segment-size=16",
+		"--collector.queue.persistent.sync-policy=always",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, "/var/lib/jaeger/queue", c.PersistentQueue.Directory)
+	assert.EqualValues(t, 16*1024*1024, c.PersistentQueue.SegmentSize)
+	assert.EqualValues(t, 1024*1024*1024, c.PersistentQueue.MaxDiskSize)
+	assert.Equal(t, queue.SyncAlways, c.PersistentQueue.SyncPolicy)
+	assert.Equal(t, time.Second, c.PersistentQueue.SyncInterval)
+	assert.Equal(t, DefaultRetryInitialInterval, c.PersistentQueue.RetryInterval)
+	assert.Equal(t, 0, c.PersistentQueue.MaxRetries)
+}
+
+func TestCollectorOptionsWriteRetry(t *testing.T) {
+	c := &CollectorOptions{
+		WriteRetry: WriteRetryOptions{MaxRetries: 0, InitialInterval: time.Second, MaxInterval: time.Minute},
+	}
+	assert.Equal(t, c.WriteRetry, c.writeRetry())
+
+	// spans of the persistent queue are retried until written unless a limit is set
+	c.PersistentQueue.Directory = "/var/lib/jaeger/queue"
+	c.PersistentQueue.RetryInterval = 5 * time.Second
+	assert.Equal(t, WriteRetryOptions{MaxRetries: -1, InitialInterval: 5 * time.Second, MaxInterval: time.Minute}, c.writeRetry())
+	c.PersistentQueue.MaxRetries = 3
+	assert.Equal(t, 3, c.writeRetry().MaxRetries)
+}
+
+func TestCollectorOptionsWithFlags_CheckWriteRetry(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.write-retry.max-retries=5",
+		"--collector.write-retry.max-interval=1m",
+		"--collector.dead-letter.type=file",
+		"--collector.dead-letter.file.path=/var/lib/jaeger/dead-letter.json",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, 5, c.WriteRetry.MaxRetries)
+	assert.Equal(t, DefaultRetryInitialInterval, c.WriteRetry.InitialInterval)
+	assert.Equal(t, time.Minute, c.WriteRetry.MaxInterval)
+	assert.Equal(t, "file", c.DeadLetter.Type)
+	assert.Equal(t, "/var/lib/jaeger/dead-letter.json", c.DeadLetter.File.Path)
+}
+
+func TestCollectorOptionsWithFlags_CheckTenancy(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags, tenancy.AddFlags)
+	command.ParseFlags([]string{
+		"--multi-tenancy.enabled=true",
+		"--multi-tenancy.tenants=acme, megacorp",
+		"--collector.queue-size-per-tenant=100",
+	})
+	c.InitFromViper(v)
+
+	assert.True(t, c.Tenancy.Enabled)
+	assert.Equal(t, tenancy.DefaultHeader, c.Tenancy.Header)
+	assert.Equal(t, []string{"acme", "megacorp"}, c.Tenancy.Tenants)
+	assert.Equal(t, 100, c.TenantQueueSize)
+}
+
+func TestCollectorOptionsWithFlags_CheckRateLimit(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.rate-limit.service-spans-per-second=1000",
+		"--collector.rate-limit.client-header=x-client-id",
+		"--collector.rate-limit.reject-when-queue-full=true",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, 1000.0, c.RateLimit.ServiceSpansPerSecond)
+	assert.Zero(t, c.RateLimit.ClientSpansPerSecond)
+	assert.Equal(t, ratelimit.DefaultBurst, c.RateLimit.Burst)
+	assert.Equal(t, "x-client-id", c.RateLimit.ClientHeader)
+	assert.True(t, c.RateLimit.RejectWhenBusy)
+}
+
+func TestCollectorOptionsWithFlags_CheckBaggage(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.baggage.restrictions-file=restrictions.json",
+		"--collector.baggage.restrictions-reload-interval=1m",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, "restrictions.json", c.Baggage.RestrictionsFile)
+	assert.Equal(t, time.Minute, c.Baggage.ReloadInterval)
+}
@@ -16,6 +16,7 @@ package app
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"time"
 
@@ -23,11 +24,22 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/baggage"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/deadletter"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/ratelimit"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/server"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/spanfilter"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/spanmetrics"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/tailsampling"
 	"github.com/jaegertracing/jaeger/pkg/healthcheck"
+	"github.com/jaegertracing/jaeger/pkg/queue"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+	"github.com/jaegertracing/jaeger/storage"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
+	tBaggage "github.com/jaegertracing/jaeger/thrift-gen/baggage"
 )
 
 // Collector returns the collector as a manageable unit of work
@@ -37,15 +49,25 @@ type Collector struct {
 	logger         *zap.Logger
 	metricsFactory metrics.Factory
 	spanWriter     spanstore.Writer
+	tenantFactory  storage.TenantFactory
 	strategyStore  strategystore.StrategyStore
+	aggregator     strategystore.Aggregator
 	hCheck         *healthcheck.HealthCheck
 	spanProcessor  processor.SpanProcessor
 	spanHandlers   *SpanHandlers
+	tailSampler    *tailsampling.Sampler
+	spanFilter     *spanfilter.Filter
+	deadLetter     deadletter.Writer
+	tenancyMgr     *tenancy.Manager
+	baggageStore   *baggage.Store
 
 	// state, read only
-	hServer    *http.Server
-	zkServer   *http.Server
-	grpcServer *grpc.Server
+	hServer        *http.Server
+	zkServer       *http.Server
+	grpcServer     *grpc.Server
+	grpcShutdown   chan struct{}
+	otlpGRPCServer *grpc.Server
+	otlpHTTPServer *http.Server
 }
 
 // CollectorParams to construct a new Jaeger Collector.
@@ -55,7 +77,10 @@ type CollectorParams struct {
 	MetricsFactory metrics.Factory
 	SpanWriter     spanstore.Writer
 	StrategyStore  strategystore.StrategyStore
+	Aggregator     strategystore.Aggregator
 	HealthCheck    *healthcheck.HealthCheck
+	// TenantFactory creates the span writers of tenants, it is required if multi-tenancy is enabled
+	TenantFactory storage.TenantFactory
 }
 
 // New constructs a new collector component, ready to be started
@@ -65,29 +90,116 @@ func New(params *CollectorParams) *Collector {
 		logger:         params.Logger,
 		metricsFactory: params.MetricsFactory,
 		spanWriter:     params.SpanWriter,
+		tenantFactory:  params.TenantFactory,
 		strategyStore:  params.StrategyStore,
+		aggregator:     params.Aggregator,
 		hCheck:         params.HealthCheck,
 	}
 }
 
 // Start the component and underlying dependencies
 func (c *Collector) Start(builderOpts *CollectorOptions) error {
+	c.tenancyMgr = tenancy.NewManager(&builderOpts.Tenancy)
+	if c.tenancyMgr.Enabled {
+		if c.tenantFactory == nil {
+			c.logger.Fatal("multi-tenancy is enabled but the span storage does not support it")
+		}
+		if builderOpts.TailSampling.PoliciesFile != "" {
+			c.logger.Fatal("tail-based sampling is not supported with multi-tenancy")
+		}
+	}
+	if builderOpts.TailSampling.PoliciesFile != "" && builderOpts.PersistentQueue.Directory != "" {
+		// the spans buffered by the tail sampler are removed from the persistent queue and lost on restart
+		c.logger.Fatal("tail-based sampling is not supported with the persistent span queue")
+	}
+
 	handlerBuilder := &SpanHandlerBuilder{
 		SpanWriter:     c.spanWriter,
 		CollectorOpts:  *builderOpts,
 		Logger:         c.logger,
 		MetricsFactory: c.metricsFactory,
+		TenancyMgr:     c.tenancyMgr,
+		RateLimiter:    ratelimit.NewLimiter(builderOpts.RateLimit, c.metricsFactory),
+	}
+	if builderOpts.TailSampling.PoliciesFile != "" {
+		policies, err := tailsampling.LoadPolicies(builderOpts.TailSampling.PoliciesFile)
+		if err != nil {
+			c.logger.Fatal("could not load tail-based sampling policies", zap.Error(err))
+		}
+		handlerBuilder.TailSampler = func(keptSpanWriter spanstore.Writer) spanstore.Writer {
+			c.tailSampler = tailsampling.NewSampler(keptSpanWriter, policies, builderOpts.TailSampling, c.metricsFactory, c.logger)
+			return c.tailSampler
+		}
+	}
+	if c.tenancyMgr.Enabled {
+		handlerBuilder.TenantSpanWriter = storage.NewTenantSpanWriters(c.tenantFactory).SpanWriter
+	}
+
+	if builderOpts.SpanFilter.RulesFile != "" {
+		spanFilter, err := spanfilter.NewFilter(builderOpts.SpanFilter, c.metricsFactory, c.logger)
+		if err != nil {
+			c.logger.Fatal("could not load span filtering rules", zap.Error(err))
+		}
+		c.spanFilter = spanFilter
+		handlerBuilder.SpanFilter = spanFilter.FilterSpan
+	}
+
+	if builderOpts.RedactionRulesFile != "" {
+		redactionConfig, err := sanitizer.LoadRedactionConfig(builderOpts.RedactionRulesFile)
+		if err != nil {
+			c.logger.Fatal("could not load redaction rules", zap.Error(err))
+		}
+		handlerBuilder.Sanitizer = sanitizer.NewRedactionSanitizer(*redactionConfig)
 	}
 
-	c.spanProcessor = handlerBuilder.BuildSpanProcessor()
+	if builderOpts.PersistentQueue.Directory != "" {
+		persistentQueue, err := queue.NewPersistentQueue(builderOpts.PersistentQueue.PersistentQueueOptions)
+		if err != nil {
+			c.logger.Fatal("could not open the persistent span queue", zap.Error(err))
+		}
+		handlerBuilder.PersistentQueue = persistentQueue
+	}
+
+	if builderOpts.DeadLetter.Type != "" {
+		deadLetter, err := deadletter.NewWriter(builderOpts.DeadLetter, c.metricsFactory, c.logger)
+		if err != nil {
+			c.logger.Fatal("could not create the dead-letter sink", zap.Error(err))
+		}
+		c.deadLetter = deadLetter
+		handlerBuilder.DeadLetter = deadLetter
+	}
+
+	var additionalProcessors []ProcessSpan
+	if c.aggregator != nil {
+		additionalProcessors = append(additionalProcessors, handleRootSpan(c.aggregator))
+	}
+	if builderOpts.SpanMetrics.Enabled {
+		spanMetrics := spanmetrics.NewGenerator(builderOpts.SpanMetrics, c.metricsFactory)
+		additionalProcessors = append(additionalProcessors, spanMetrics.ProcessSpan)
+	}
+
+	c.spanProcessor = handlerBuilder.BuildSpanProcessor(additionalProcessors...)
 	c.spanHandlers = handlerBuilder.BuildHandlers(c.spanProcessor)
 
+	var baggageManager tBaggage.BaggageRestrictionManager
+	if builderOpts.Baggage.RestrictionsFile != "" {
+		baggageStore, err := baggage.NewStore(builderOpts.Baggage, c.logger)
+		if err != nil {
+			c.logger.Fatal("could not load baggage restrictions", zap.Error(err))
+		}
+		c.baggageStore = baggageStore
+		baggageManager = baggageStore
+	}
+
+	c.grpcShutdown = make(chan struct{})
 	if grpcServer, err := server.StartGRPCServer(&server.GRPCServerParams{
-		HostPort:      builderOpts.CollectorGRPCHostPort,
-		Handler:       c.spanHandlers.GRPCHandler,
-		TLSConfig:     builderOpts.TLS,
-		SamplingStore: c.strategyStore,
-		Logger:        c.logger,
+		HostPort:       builderOpts.CollectorGRPCHostPort,
+		Handler:        c.spanHandlers.GRPCHandler,
+		TLSConfig:      builderOpts.TLS,
+		SamplingStore:  c.strategyStore,
+		BaggageManager: baggageManager,
+		Shutdown:       c.grpcShutdown,
+		Logger:         c.logger,
 	}); err != nil {
 		c.logger.Fatal("could not start gRPC collector", zap.Error(err))
 	} else {
@@ -100,7 +212,10 @@ func (c *Collector) Start(builderOpts *CollectorOptions) error {
 		HealthCheck:    c.hCheck,
 		MetricsFactory: c.metricsFactory,
 		SamplingStore:  c.strategyStore,
+		BaggageManager: baggageManager,
 		Logger:         c.logger,
+		TenancyMgr:     c.tenancyMgr,
+		RateLimiter:    handlerBuilder.RateLimiter,
 	}); err != nil {
 		c.logger.Fatal("could not start the HTTP server", zap.Error(err))
 	} else {
@@ -113,19 +228,52 @@ func (c *Collector) Start(builderOpts *CollectorOptions) error {
 		AllowedHeaders: builderOpts.CollectorZipkinAllowedHeaders,
 		AllowedOrigins: builderOpts.CollectorZipkinAllowedOrigins,
 		Logger:         c.logger,
+		TenancyMgr:     c.tenancyMgr,
+		RateLimiter:    handlerBuilder.RateLimiter,
 	}); err != nil {
 		c.logger.Fatal("could not start the Zipkin server", zap.Error(err))
 	} else {
 		c.zkServer = zkServer
 	}
 
+	if builderOpts.OTLP.Enabled {
+		c.startOTLPServers(builderOpts.OTLP)
+	}
+
 	return nil
 }
 
+func (c *Collector) startOTLPServers(opts OTLPOptions) {
+	if otlpGRPCServer, err := server.StartOTLPGRPCServer(&server.OTLPServerParams{
+		HostPort:    opts.GRPCHostPort,
+		TLSConfig:   opts.GRPCTLS,
+		Handler:     c.spanHandlers.OTLPHandler,
+		HealthCheck: c.hCheck,
+		Logger:      c.logger,
+	}); err != nil {
+		c.logger.Fatal("could not start the OTLP gRPC server", zap.Error(err))
+	} else {
+		c.otlpGRPCServer = otlpGRPCServer
+	}
+
+	if otlpHTTPServer, err := server.StartOTLPHTTPServer(&server.OTLPServerParams{
+		HostPort:    opts.HTTPHostPort,
+		TLSConfig:   opts.HTTPTLS,
+		Handler:     c.spanHandlers.OTLPHandler,
+		HealthCheck: c.hCheck,
+		Logger:      c.logger,
+	}); err != nil {
+		c.logger.Fatal("could not start the OTLP HTTP server", zap.Error(err))
+	} else {
+		c.otlpHTTPServer = otlpHTTPServer
+	}
+}
+
 // Close the component and all its underlying dependencies
 func (c *Collector) Close() error {
 	// gRPC server
 	if c.grpcServer != nil {
+		close(c.grpcShutdown)
 		c.grpcServer.GracefulStop()
 	}
 
@@ -149,10 +297,64 @@ func (c *Collector) Close() error {
 		defer cancel()
 	}
 
+	// OTLP servers
+	if c.otlpGRPCServer != nil {
+		c.otlpGRPCServer.GracefulStop()
+	}
+	if c.otlpHTTPServer != nil {
+		timeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := c.otlpHTTPServer.Shutdown(timeout)
+		if err != nil {
+			c.logger.Error("failed to stop the OTLP HTTP server", zap.Error(err))
+		}
+		defer cancel()
+	}
+
 	if err := c.spanProcessor.Close(); err != nil {
 		c.logger.Error("failed to close span processor.", zap.Error(err))
 	}
 
+	// the tail sampler flushes buffered traces, so it must be closed after the span processor,
+	// which then sends the spans it fails to write to the dead-letter sink without retrying them
+	if c.tailSampler != nil {
+		if err := c.tailSampler.Close(); err != nil {
+			c.logger.Error("failed to close tail-based sampler.", zap.Error(err))
+		}
+	}
+
+	// the span processor and the tail sampler write to the dead-letter sink until they are closed
+	if c.deadLetter != nil {
+		if err := c.deadLetter.Close(); err != nil {
+			c.logger.Error("failed to close dead-letter sink.", zap.Error(err))
+		}
+	}
+
+	if c.baggageStore != nil {
+		if err := c.baggageStore.Close(); err != nil {
+			c.logger.Error("failed to close baggage restriction store.", zap.Error(err))
+		}
+	}
+
+	if c.spanFilter != nil {
+		if err := c.spanFilter.Close(); err != nil {
+			c.logger.Error("failed to close span filter.", zap.Error(err))
+		}
+	}
+
+	// aggregator does not exist for all strategy stores. only Close() if exists.
+	if c.aggregator != nil {
+		if err := c.aggregator.Close(); err != nil {
+			c.logger.Error("failed to close aggregator.", zap.Error(err))
+		}
+	}
+
+	// strategy stores running in the background, like the adaptive one, are closed if they can be.
+	if closer, ok := c.strategyStore.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			c.logger.Error("failed to close strategy store.", zap.Error(err))
+		}
+	}
+
 	return nil
 }
 
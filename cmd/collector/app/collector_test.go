@@ -17,14 +17,21 @@ package app
 import (
 	"context"
 	"io"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/atomic"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/deadletter"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/spanmetrics"
+	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/pkg/healthcheck"
+	"github.com/jaegertracing/jaeger/pkg/queue"
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
 
@@ -55,9 +62,219 @@ func TestNewCollector(t *testing.T) {
 	assert.NoError(t, c.Close())
 }
 
+func TestCollectorWithTailSampling(t *testing.T) {
+	spanWriter := &fakeSpanWriter{}
+	c := New(&CollectorParams{
+		ServiceName:    "collector",
+		Logger:         zap.NewNop(),
+		MetricsFactory: metricstest.NewFactory(time.Hour),
+		SpanWriter:     spanWriter,
+		StrategyStore:  &mockStrategyStore{},
+		HealthCheck:    healthcheck.New(),
+	})
+	collectorOpts := &CollectorOptions{}
+	collectorOpts.TailSampling.PoliciesFile = "tailsampling/fixtures/policies.json"
+	c.Start(collectorOpts)
+	assert.NotNil(t, c.tailSampler)
+	assert.NoError(t, c.Close())
+}
+
+func TestCollectorWithSpanFilter(t *testing.T) {
+	c := New(&CollectorParams{
+		ServiceName:    "collector",
+		Logger:         zap.NewNop(),
+		MetricsFactory: metricstest.NewFactory(time.Hour),
+		SpanWriter:     &fakeSpanWriter{},
+		StrategyStore:  &mockStrategyStore{},
+		HealthCheck:    healthcheck.New(),
+	})
+	collectorOpts := &CollectorOptions{}
+	collectorOpts.SpanFilter.RulesFile = "spanfilter/fixtures/rules.json"
+	c.Start(collectorOpts)
+	assert.NotNil(t, c.spanFilter)
+	assert.NoError(t, c.Close())
+}
+
+func TestCollectorWithRedaction(t *testing.T) {
+	c := New(&CollectorParams{
+		ServiceName:    "collector",
+		Logger:         zap.NewNop(),
+		MetricsFactory: metricstest.NewFactory(time.Hour),
+		SpanWriter:     &fakeSpanWriter{},
+		StrategyStore:  &mockStrategyStore{},
+		HealthCheck:    healthcheck.New(),
+	})
+	collectorOpts := &CollectorOptions{RedactionRulesFile: "sanitizer/fixtures/redaction_rules.json"}
+	c.Start(collectorOpts)
+	assert.NotNil(t, c.spanHandlers)
+	assert.NoError(t, c.Close())
+}
+
+func TestCollectorWithOTLP(t *testing.T) {
+	c := New(&CollectorParams{
+		ServiceName:    "collector",
+		Logger:         zap.NewNop(),
+		MetricsFactory: metricstest.NewFactory(time.Hour),
+		SpanWriter:     &fakeSpanWriter{},
+		StrategyStore:  &mockStrategyStore{},
+		HealthCheck:    healthcheck.New(),
+	})
+	collectorOpts := &CollectorOptions{}
+	collectorOpts.OTLP = OTLPOptions{
+		Enabled:      true,
+		GRPCHostPort: ":0",
+		HTTPHostPort: ":0",
+	}
+	c.Start(collectorOpts)
+	assert.NotNil(t, c.otlpGRPCServer)
+	assert.NotNil(t, c.otlpHTTPServer)
+	assert.NoError(t, c.Close())
+}
+
+func TestCollectorWithPersistentQueue(t *testing.T) {
+	c := New(&CollectorParams{
+		ServiceName:    "collector",
+		Logger:         zap.NewNop(),
+		MetricsFactory: metricstest.NewFactory(time.Hour),
+		SpanWriter:     &fakeSpanWriter{},
+		StrategyStore:  &mockStrategyStore{},
+		HealthCheck:    healthcheck.New(),
+	})
+	collectorOpts := &CollectorOptions{
+		PersistentQueue: PersistentQueueOptions{
+			PersistentQueueOptions: queue.PersistentQueueOptions{
+				Directory:   persistentQueueDir(t),
+				SegmentSize: 1024,
+				MaxDiskSize: 1024 * 1024,
+				SyncPolicy:  queue.SyncNever,
+			},
+		},
+	}
+	c.Start(collectorOpts)
+	_, ok := c.spanProcessor.(*spanProcessor).queue.(persistentSpanQueue)
+	assert.True(t, ok)
+	assert.Equal(t, -1, c.spanProcessor.(*spanProcessor).maxRetries)
+	assert.NoError(t, c.Close())
+}
+
+func TestCollectorWithDeadLetter(t *testing.T) {
+	c := New(&CollectorParams{
+		ServiceName:    "collector",
+		Logger:         zap.NewNop(),
+		MetricsFactory: metricstest.NewFactory(time.Hour),
+		SpanWriter:     &fakeSpanWriter{},
+		StrategyStore:  &mockStrategyStore{},
+		HealthCheck:    healthcheck.New(),
+	})
+	collectorOpts := &CollectorOptions{
+		DeadLetter: deadletter.Options{
+			Type: deadletter.TypeFile,
+			File: deadletter.FileOptions{Path: filepath.Join(persistentQueueDir(t), "dead-letter.json")},
+		},
+	}
+	c.Start(collectorOpts)
+	assert.NotNil(t, c.deadLetter)
+	assert.Equal(t, c.deadLetter, c.spanProcessor.(*spanProcessor).deadLetter)
+	assert.NoError(t, c.Close())
+}
+
+func TestCollectorWithSpanMetrics(t *testing.T) {
+	baseMetrics := metricstest.NewFactory(time.Hour)
+	c := New(&CollectorParams{
+		ServiceName:    "collector",
+		Logger:         zap.NewNop(),
+		MetricsFactory: baseMetrics,
+		SpanWriter:     &fakeSpanWriter{},
+		StrategyStore:  &mockStrategyStore{},
+		HealthCheck:    healthcheck.New(),
+	})
+	c.Start(&CollectorOptions{
+		QueueSize:   10,
+		NumWorkers:  1,
+		SpanMetrics: spanmetrics.Options{Enabled: true, MaxCardinality: 10},
+	})
+
+	span := &model.Span{
+		OperationName: "y",
+		Process:       &model.Process{ServiceName: "x"},
+		Tags:          model.KeyValues{model.Bool("error", true)},
+	}
+	_, err := c.spanProcessor.ProcessSpans([]*model.Span{span}, processor.SpansOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, c.Close())
+
+	tags := map[string]string{"service": "x", "operation": "y", "span_kind": "unset"}
+	baseMetrics.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "span-metrics.calls", Tags: tags, Value: 1},
+		metricstest.ExpectedMetric{Name: "span-metrics.errors", Tags: tags, Value: 1},
+	)
+}
+
+func TestCollectorWithAggregator(t *testing.T) {
+	hc := healthcheck.New()
+	logger := zap.NewNop()
+	baseMetrics := metricstest.NewFactory(time.Hour)
+	spanWriter := &fakeSpanWriter{}
+	agg := &mockAggregator{}
+	strategyStore := &closableStrategyStore{}
+
+	c := New(&CollectorParams{
+		ServiceName:    "collector",
+		Logger:         logger,
+		MetricsFactory: baseMetrics,
+		SpanWriter:     spanWriter,
+		StrategyStore:  strategyStore,
+		Aggregator:     agg,
+		HealthCheck:    hc,
+	})
+	c.Start(&CollectorOptions{QueueSize: 10, NumWorkers: 1})
+
+	span := &model.Span{
+		OperationName: "y",
+		Process:       &model.Process{ServiceName: "x"},
+		Tags: model.KeyValues{
+			model.String("sampler.type", "probabilistic"),
+			model.Float64("sampler.param", 0.1),
+		},
+	}
+	_, err := c.spanProcessor.ProcessSpans([]*model.Span{span}, processor.SpansOptions{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Close())
+	assert.EqualValues(t, 1, agg.callCount.Load())
+	assert.True(t, agg.closed.Load())
+	assert.True(t, strategyStore.closed.Load())
+}
+
+type mockAggregator struct {
+	callCount atomic.Int32
+	closed    atomic.Bool
+}
+
+func (t *mockAggregator) RecordThroughput(service, operation, samplerType string, probability float64) {
+	t.callCount.Inc()
+}
+
+func (t *mockAggregator) Start() {}
+
+func (t *mockAggregator) Close() error {
+	t.closed.Store(true)
+	return nil
+}
+
 type mockStrategyStore struct {
 }
 
 func (m *mockStrategyStore) GetSamplingStrategy(_ context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error) {
 	return &sampling.SamplingStrategyResponse{}, nil
 }
+
+type closableStrategyStore struct {
+	mockStrategyStore
+	closed atomic.Bool
+}
+
+func (m *closableStrategyStore) Close() error {
+	m.closed.Store(true)
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package deadletter
+
+import (
+	"fmt"
+
+	"github.com/Shopify/sarama"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/kafka/producer"
+	"github.com/jaegertracing/jaeger/plugin/storage/kafka"
+)
+
+// TenantHeader is the Kafka message header that carries the tenant of a dead-lettered span
+const TenantHeader = "tenant"
+
+// Writer is a dead-letter sink for spans
+type Writer interface {
+	// WriteSpan writes a span that could not be saved. The tenant is empty
+	// when multi-tenancy is disabled.
+	WriteSpan(span *model.Span, tenant string) error
+	Close() error
+}
+
+// NewWriter creates the dead-letter sink configured by options.
+func NewWriter(options Options, metricsFactory metrics.Factory, logger *zap.Logger) (Writer, error) {
+	switch options.Type {
+	case TypeFile:
+		return NewFileWriter(options.File)
+	case TypeKafka:
+		return newKafkaWriter(options.Kafka, metricsFactory, logger)
+	default:
+		return nil, fmt.Errorf("unknown dead-letter sink type: %q", options.Type)
+	}
+}
+
+func newKafkaWriter(options KafkaOptions, metricsFactory metrics.Factory, logger *zap.Logger) (Writer, error) {
+	f := kafka.NewFactory()
+	f.InitFromOptions(kafka.Options{
+		Config: producer.Configuration{
+			Brokers:      options.Brokers,
+			RequiredAcks: sarama.WaitForLocal,
+			// message headers, used for the tenant, need protocol version 0.11
+			ProtocolVersion: "0.11.0.0",
+		},
+		Topic:    options.Topic,
+		Encoding: options.Encoding,
+	})
+	if err := f.Initialize(metricsFactory.Namespace(metrics.NSOptions{Name: "dead-letter"}), logger); err != nil {
+		return nil, fmt.Errorf("failed to create dead-letter Kafka producer: %w", err)
+	}
+	w, err := f.CreateSpanWriter()
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaWriter{SpanWriter: w.(*kafka.SpanWriter)}, nil
+}
+
+// kafkaWriter sends dead-lettered spans to Kafka, with the tenant in the TenantHeader.
+type kafkaWriter struct {
+	*kafka.SpanWriter
+}
+
+func (w *kafkaWriter) WriteSpan(span *model.Span, tenant string) error {
+	if tenant == "" {
+		return w.SpanWriter.WriteSpan(span)
+	}
+	return w.WriteSpanWithHeaders(span, []sarama.RecordHeader{
+		{Key: []byte(TenantHeader), Value: []byte(tenant)},
+	})
+}
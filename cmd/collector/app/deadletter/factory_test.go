@@ -0,0 +1,46 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package deadletter
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+)
+
+func TestNewWriter(t *testing.T) {
+	w, err := NewWriter(Options{
+		Type: TypeFile,
+		File: FileOptions{Path: filepath.Join(tempDir(t), "dead-letter.json")},
+	}, metrics.NullFactory, zap.NewNop())
+	require.NoError(t, err)
+	assert.IsType(t, &FileWriter{}, w)
+	assert.NoError(t, w.Close())
+
+	_, err = NewWriter(Options{Type: "s3"}, metrics.NullFactory, zap.NewNop())
+	assert.EqualError(t, err, `unknown dead-letter sink type: "s3"`)
+}
+
+func TestNewKafkaWriterError(t *testing.T) {
+	_, err := NewWriter(Options{
+		Type:  TypeKafka,
+		Kafka: KafkaOptions{Brokers: []string{"127.0.0.1:1"}, Topic: "dead-letter", Encoding: "protobuf"},
+	}, metrics.NullFactory, zap.NewNop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to create dead-letter Kafka producer")
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package deadletter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gogo/protobuf/jsonpb"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// FileWriter appends spans to a file as JSON, one span per line, rotating the file
+// when it grows over the max size. Spans received for a tenant are wrapped in
+// a tenantSpan, e.g. {"tenant":"acme","span":{...}}. Implements Writer.
+type FileWriter struct {
+	options    FileOptions
+	marshaller *jsonpb.Marshaler
+
+	lock sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewFileWriter opens the dead-letter file for appending, creating it if necessary.
+func NewFileWriter(options FileOptions) (*FileWriter, error) {
+	if options.Path == "" {
+		return nil, errors.New("dead-letter file path must be set")
+	}
+	w := &FileWriter{
+		options:    options,
+		marshaller: &jsonpb.Marshaler{},
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// tenantSpan is the line written for a span received for a tenant
+type tenantSpan struct {
+	Tenant string          `json:"tenant"`
+	Span   json.RawMessage `json:"span"`
+}
+
+// WriteSpan appends the span to the dead-letter file.
+func (w *FileWriter) WriteSpan(span *model.Span, tenant string) error {
+	buf := new(bytes.Buffer)
+	if err := w.marshaller.Marshal(buf, span); err != nil {
+		return err
+	}
+	if tenant != "" {
+		line, err := json.Marshal(tenantSpan{Tenant: tenant, Span: buf.Bytes()})
+		if err != nil {
+			return err
+		}
+		buf = bytes.NewBuffer(line)
+	}
+	buf.WriteByte('\n')
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.options.MaxSize > 0 && w.size > 0 && w.size+int64(buf.Len()) > w.options.MaxSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := w.file.Write(buf.Bytes())
+	w.size += int64(n)
+	return err
+}
+
+// Close closes the dead-letter file.
+func (w *FileWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.file.Close()
+}
+
+func (w *FileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.options.Path), 0750); err != nil {
+		return fmt.Errorf("failed to create dead-letter directory: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Clean(w.options.Path), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open dead-letter file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat dead-letter file: %w", err)
+	}
+	w.file, w.size = f, info.Size()
+	return nil
+}
+
+// rotate renames the current file to <path>.1, shifting older backups and
+// removing the ones over MaxBackups, then starts a new file.
+func (w *FileWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	if w.options.MaxBackups <= 0 {
+		if err := os.Remove(w.options.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return w.open()
+	}
+	if err := os.Remove(w.backupPath(w.options.MaxBackups)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := w.options.MaxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(w.backupPath(i), w.backupPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(w.options.Path, w.backupPath(1)); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+func (w *FileWriter) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", w.options.Path, i)
+}
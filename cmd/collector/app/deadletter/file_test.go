@@ -0,0 +1,136 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package deadletter
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "dead-letter")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func readSpans(t *testing.T, path string) []*model.Span {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var spans []*model.Span
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		span := &model.Span{}
+		require.NoError(t, jsonpb.UnmarshalString(scanner.Text(), span))
+		spans = append(spans, span)
+	}
+	require.NoError(t, scanner.Err())
+	return spans
+}
+
+func makeSpan(operation string) *model.Span {
+	return &model.Span{
+		TraceID:       model.NewTraceID(1, 2),
+		SpanID:        model.NewSpanID(3),
+		OperationName: operation,
+		Process:       model.NewProcess("svc", nil),
+	}
+}
+
+func TestFileWriter(t *testing.T) {
+	path := filepath.Join(tempDir(t), "nested", "dead-letter.json")
+	w, err := NewFileWriter(FileOptions{Path: path})
+	require.NoError(t, err)
+	require.NoError(t, w.WriteSpan(makeSpan("a"), ""))
+	require.NoError(t, w.WriteSpan(makeSpan("b"), ""))
+	require.NoError(t, w.Close())
+
+	// spans are appended to an existing file
+	w, err = NewFileWriter(FileOptions{Path: path})
+	require.NoError(t, err)
+	require.NoError(t, w.WriteSpan(makeSpan("c"), ""))
+	require.NoError(t, w.Close())
+
+	spans := readSpans(t, path)
+	require.Len(t, spans, 3)
+	assert.Equal(t, makeSpan("a"), spans[0])
+	assert.Equal(t, "c", spans[2].OperationName)
+}
+
+func TestFileWriterTenant(t *testing.T) {
+	path := filepath.Join(tempDir(t), "dead-letter.json")
+	w, err := NewFileWriter(FileOptions{Path: path})
+	require.NoError(t, err)
+	require.NoError(t, w.WriteSpan(makeSpan("a"), "acme"))
+	require.NoError(t, w.Close())
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	var line tenantSpan
+	require.NoError(t, json.Unmarshal(data, &line))
+	assert.Equal(t, "acme", line.Tenant)
+	span := &model.Span{}
+	require.NoError(t, jsonpb.UnmarshalString(string(line.Span), span))
+	assert.Equal(t, makeSpan("a"), span)
+}
+
+func TestFileWriterRotation(t *testing.T) {
+	path := filepath.Join(tempDir(t), "dead-letter.json")
+	w, err := NewFileWriter(FileOptions{Path: path, MaxSize: 1, MaxBackups: 2})
+	require.NoError(t, err)
+	for _, operation := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, w.WriteSpan(makeSpan(operation), ""))
+	}
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, "d", readSpans(t, path)[0].OperationName)
+	assert.Equal(t, "c", readSpans(t, path+".1")[0].OperationName)
+	assert.Equal(t, "b", readSpans(t, path+".2")[0].OperationName)
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFileWriterRotationWithoutBackups(t *testing.T) {
+	path := filepath.Join(tempDir(t), "dead-letter.json")
+	w, err := NewFileWriter(FileOptions{Path: path, MaxSize: 1})
+	require.NoError(t, err)
+	require.NoError(t, w.WriteSpan(makeSpan("a"), ""))
+	require.NoError(t, w.WriteSpan(makeSpan("b"), ""))
+	require.NoError(t, w.Close())
+
+	spans := readSpans(t, path)
+	require.Len(t, spans, 1)
+	assert.Equal(t, "b", spans[0].OperationName)
+	_, err = os.Stat(path + ".1")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFileWriterErrors(t *testing.T) {
+	_, err := NewFileWriter(FileOptions{})
+	assert.EqualError(t, err, "dead-letter file path must be set")
+
+	_, err = NewFileWriter(FileOptions{Path: tempDir(t)})
+	assert.Contains(t, err.Error(), "failed to open dead-letter file")
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package deadletter
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/jaegertracing/jaeger/plugin/storage/kafka"
+)
+
+const (
+	// TypeFile writes spans to a local rotating file, one JSON-encoded span per line
+	TypeFile = "file"
+	// TypeKafka writes spans to a Kafka topic, which can be replayed by jaeger-ingester
+	TypeKafka = "kafka"
+
+	deadLetterType            = "collector.dead-letter.type"
+	deadLetterFilePath        = "collector.dead-letter.file.path"
+	deadLetterFileMaxSize     = "collector.dead-letter.file.max-size"
+	deadLetterFileMaxBackups  = "collector.dead-letter.file.max-backups"
+	deadLetterKafkaBrokers    = "collector.dead-letter.kafka.brokers"
+	deadLetterKafkaTopic      = "collector.dead-letter.kafka.topic"
+	deadLetterKafkaEncoding   = "collector.dead-letter.kafka.encoding"
+	defaultDeadLetterTopic    = "jaeger-spans-dead-letter"
+	defaultDeadLetterBrokers  = "127.0.0.1:9092"
+	defaultFileMaxSizeMiB     = 100
+	defaultFileMaxBackupFiles = 5
+)
+
+// Options holds configuration for the dead-letter sink of spans that could not be saved.
+type Options struct {
+	// Type is the kind of sink, file or kafka. The dead-letter sink is disabled if empty.
+	Type string
+	// File configures the file sink
+	File FileOptions
+	// Kafka configures the Kafka sink
+	Kafka KafkaOptions
+}
+
+// FileOptions holds configuration for the rotating file sink.
+type FileOptions struct {
+	// Path is the path of the current dead-letter file, rotated files get a numeric suffix
+	Path string
+	// MaxSize is the size in bytes after which the file is rotated
+	MaxSize int64
+	// MaxBackups is the number of rotated files to keep
+	MaxBackups int
+}
+
+// KafkaOptions holds configuration for the Kafka sink.
+type KafkaOptions struct {
+	// Brokers is the list of Kafka brokers
+	Brokers []string
+	// Topic is the Kafka topic spans are written to
+	Topic string
+	// Encoding is the encoding of spans, protobuf or json
+	Encoding string
+}
+
+// AddFlags adds flags for Options
+func AddFlags(flagSet *flag.FlagSet) {
+	flagSet.String(deadLetterType, "", fmt.Sprintf("The sink for spans that could not be saved after all retries: %s, %s. Such spans are discarded if empty", TypeFile, TypeKafka))
+	flagSet.String(deadLetterFilePath, "", "The path of the dead-letter file, with one JSON-encoded span per line")
+	flagSet.Uint(deadLetterFileMaxSize, defaultFileMaxSizeMiB, "The size in MiB after which the dead-letter file is rotated")
+	flagSet.Int(deadLetterFileMaxBackups, defaultFileMaxBackupFiles, "The number of rotated dead-letter files to keep")
+	flagSet.String(deadLetterKafkaBrokers, defaultDeadLetterBrokers, "The comma-separated list of Kafka brokers for the dead-letter topic")
+	flagSet.String(deadLetterKafkaTopic, defaultDeadLetterTopic, "The Kafka topic for spans that could not be saved, it can be replayed with jaeger-ingester")
+	flagSet.String(deadLetterKafkaEncoding, kafka.EncodingProto, fmt.Sprintf(`Encoding of spans ("%s" or "%s") sent to the dead-letter topic`, kafka.EncodingJSON, kafka.EncodingProto))
+}
+
+// InitFromViper initializes Options with properties from viper
+func (opts *Options) InitFromViper(v *viper.Viper) *Options {
+	opts.Type = v.GetString(deadLetterType)
+	opts.File.Path = v.GetString(deadLetterFilePath)
+	opts.File.MaxSize = int64(v.GetUint(deadLetterFileMaxSize)) * 1024 * 1024
+	opts.File.MaxBackups = v.GetInt(deadLetterFileMaxBackups)
+	opts.Kafka.Brokers = strings.Split(strings.ReplaceAll(v.GetString(deadLetterKafkaBrokers), " ", ""), ",")
+	opts.Kafka.Topic = v.GetString(deadLetterKafkaTopic)
+	opts.Kafka.Encoding = v.GetString(deadLetterKafkaEncoding)
+	return opts
+}
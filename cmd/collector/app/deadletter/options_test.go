@@ -0,0 +1,40 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package deadletter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jaegertracing/jaeger/pkg/config"
+)
+
+func TestOptionsWithFlags(t *testing.T) {
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.dead-letter.type=kafka",
+		"--collector.dead-letter.file.max-size=10",
+		"--collector.dead-letter.kafka.brokers=broker1:9092, broker2:9092",
+		"--collector.dead-letter.kafka.encoding=json",
+	})
+	opts := new(Options).InitFromViper(v)
+	assert.Equal(t, TypeKafka, opts.Type)
+	assert.Equal(t, "", opts.File.Path)
+	assert.EqualValues(t, 10*1024*1024, opts.File.MaxSize)
+	assert.Equal(t, defaultFileMaxBackupFiles, opts.File.MaxBackups)
+	assert.Equal(t, []string{"broker1:9092", "broker2:9092"}, opts.Kafka.Brokers)
+	assert.Equal(t, defaultDeadLetterTopic, opts.Kafka.Topic)
+	assert.Equal(t, "json", opts.Kafka.Encoding)
+}
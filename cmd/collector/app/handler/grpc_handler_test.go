@@ -20,14 +20,22 @@ import (
 	"net"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/ratelimit"
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
 )
 
@@ -35,12 +43,14 @@ type mockSpanProcessor struct {
 	expectedError error
 	mux           sync.Mutex
 	spans         []*model.Span
+	tenants       []string
 }
 
 func (p *mockSpanProcessor) ProcessSpans(spans []*model.Span, opts processor.SpansOptions) ([]bool, error) {
 	p.mux.Lock()
 	defer p.mux.Unlock()
 	p.spans = append(p.spans, spans...)
+	p.tenants = append(p.tenants, opts.Tenant)
 	oks := make([]bool, len(spans))
 	return oks, p.expectedError
 }
@@ -51,10 +61,17 @@ func (p *mockSpanProcessor) getSpans() []*model.Span {
 	return p.spans
 }
 
+func (p *mockSpanProcessor) getTenants() []string {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.tenants
+}
+
 func (p *mockSpanProcessor) reset() {
 	p.mux.Lock()
 	defer p.mux.Unlock()
 	p.spans = nil
+	p.tenants = nil
 }
 
 func (p *mockSpanProcessor) Close() error {
@@ -82,7 +99,7 @@ func newClient(t *testing.T, addr net.Addr) (api_v2.CollectorServiceClient, *grp
 func TestPostSpans(t *testing.T) {
 	processor := &mockSpanProcessor{}
 	server, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
-		handler := NewGRPCHandler(zap.NewNop(), processor)
+		handler := NewGRPCHandler(zap.NewNop(), processor, tenancy.NewManager(&tenancy.Options{}), nil)
 		api_v2.RegisterCollectorServiceServer(s, handler)
 	})
 	defer server.Stop()
@@ -114,7 +131,7 @@ func TestPostSpansWithError(t *testing.T) {
 	expectedError := errors.New("test-error")
 	processor := &mockSpanProcessor{expectedError: expectedError}
 	server, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
-		handler := NewGRPCHandler(zap.NewNop(), processor)
+		handler := NewGRPCHandler(zap.NewNop(), processor, tenancy.NewManager(&tenancy.Options{}), nil)
 		api_v2.RegisterCollectorServiceServer(s, handler)
 	})
 	defer server.Stop()
@@ -134,3 +151,86 @@ func TestPostSpansWithError(t *testing.T) {
 	require.Contains(t, err.Error(), expectedError.Error())
 	require.Len(t, processor.getSpans(), 1)
 }
+
+func TestPostTenantedSpans(t *testing.T) {
+	processor := &mockSpanProcessor{}
+	tenancyMgr := tenancy.NewManager(&tenancy.Options{
+		Enabled: true,
+		Tenants: []string{"acme"},
+	})
+	server, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		handler := NewGRPCHandler(zap.NewNop(), processor, tenancyMgr, nil)
+		api_v2.RegisterCollectorServiceServer(s, handler)
+	})
+	defer server.Stop()
+	client, conn := newClient(t, addr)
+	defer conn.Close()
+
+	request := &api_v2.PostSpansRequest{
+		Batch: model.Batch{
+			Process: &model.Process{ServiceName: "batch-process"},
+			Spans:   []*model.Span{{OperationName: "test-op"}},
+		},
+	}
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected codes.Code
+	}{
+		{
+			name:     "no tenant",
+			ctx:      context.Background(),
+			expected: codes.Unauthenticated,
+		},
+		{
+			name:     "unknown tenant",
+			ctx:      metadata.AppendToOutgoingContext(context.Background(), tenancyMgr.Header, "megacorp"),
+			expected: codes.PermissionDenied,
+		},
+		{
+			name:     "valid tenant",
+			ctx:      metadata.AppendToOutgoingContext(context.Background(), tenancyMgr.Header, "acme"),
+			expected: codes.OK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			processor.reset()
+			_, err := client.PostSpans(test.ctx, request)
+			assert.Equal(t, test.expected, status.Code(err))
+			if test.expected == codes.OK {
+				assert.Equal(t, []string{"acme"}, processor.getTenants())
+			} else {
+				assert.Empty(t, processor.getSpans())
+			}
+		})
+	}
+}
+
+func TestPostSpansRateLimited(t *testing.T) {
+	processor := &mockSpanProcessor{}
+	limiter := ratelimit.NewLimiter(ratelimit.Options{ServiceSpansPerSecond: 1, Burst: time.Second}, metrics.NullFactory)
+	server, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		handler := NewGRPCHandler(zap.NewNop(), processor, tenancy.NewManager(&tenancy.Options{}), limiter)
+		api_v2.RegisterCollectorServiceServer(s, handler)
+	})
+	defer server.Stop()
+	client, conn := newClient(t, addr)
+	defer conn.Close()
+
+	batch := model.Batch{
+		Process: &model.Process{ServiceName: "batch-process"},
+		Spans:   []*model.Span{{OperationName: "test-op"}, {OperationName: "test-op"}},
+	}
+	_, err := client.PostSpans(context.Background(), &api_v2.PostSpansRequest{Batch: batch})
+	require.NoError(t, err)
+	processor.reset()
+
+	_, err = client.PostSpans(context.Background(), &api_v2.PostSpansRequest{Batch: batch})
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+	assert.IsType(t, &errdetails.RetryInfo{}, st.Details()[0])
+	assert.Empty(t, processor.getSpans())
+}
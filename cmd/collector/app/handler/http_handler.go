@@ -25,6 +25,8 @@ import (
 	"github.com/gorilla/mux"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/ratelimit"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	tJaeger "github.com/jaegertracing/jaeger/thrift-gen/jaeger"
 )
 
@@ -43,20 +45,26 @@ var (
 // APIHandler handles all HTTP calls to the collector
 type APIHandler struct {
 	jaegerBatchesHandler JaegerBatchesHandler
+	tenancyMgr           *tenancy.Manager
+	limiter              *ratelimit.Limiter
 }
 
-// NewAPIHandler returns a new APIHandler
+// NewAPIHandler returns a new APIHandler, the limiter identifies the clients for rate limiting and may be nil
 func NewAPIHandler(
 	jaegerBatchesHandler JaegerBatchesHandler,
+	tenancyMgr *tenancy.Manager,
+	limiter *ratelimit.Limiter,
 ) *APIHandler {
 	return &APIHandler{
 		jaegerBatchesHandler: jaegerBatchesHandler,
+		tenancyMgr:           tenancyMgr,
+		limiter:              limiter,
 	}
 }
 
 // RegisterRoutes registers routes for this handler on the given router
 func (aH *APIHandler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/api/traces", aH.SaveSpan).Methods(http.MethodPost)
+	router.Handle("/api/traces", tenancy.ExtractTenantHTTPHandler(aH.tenancyMgr, http.HandlerFunc(aH.SaveSpan))).Methods(http.MethodPost)
 }
 
 // SaveSpan submits the span provided in the request body to the JaegerBatchesHandler
@@ -87,9 +95,13 @@ func (aH *APIHandler) SaveSpan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	batches := []*tJaeger.Batch{batch}
-	opts := SubmitBatchOptions{InboundTransport: processor.HTTPTransport}
+	opts := SubmitBatchOptions{
+		InboundTransport: processor.HTTPTransport,
+		Tenant:           tenancy.GetTenant(r.Context()),
+		Client:           aH.limiter.ClientFromHTTP(r),
+	}
 	if _, err = aH.jaegerBatchesHandler.SubmitBatches(batches, opts); err != nil {
-		http.Error(w, fmt.Sprintf("Cannot submit Jaeger batch: %v", err), http.StatusInternalServerError)
+		WriteSubmitError(w, "Cannot submit Jaeger batch", err)
 		return
 	}
 
@@ -29,9 +29,14 @@ import (
 	"github.com/apache/thrift/lib/go/thrift"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	jaegerClient "github.com/uber/jaeger-client-go"
 	"github.com/uber/jaeger-client-go/transport"
+	"github.com/uber/jaeger-lib/metrics"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/ratelimit"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
 )
 
@@ -41,12 +46,16 @@ type mockJaegerHandler struct {
 	err     error
 	mux     sync.Mutex
 	batches []*jaeger.Batch
+	tenants []string
+	clients []string
 }
 
-func (p *mockJaegerHandler) SubmitBatches(batches []*jaeger.Batch, _ SubmitBatchOptions) ([]*jaeger.BatchSubmitResponse, error) {
+func (p *mockJaegerHandler) SubmitBatches(batches []*jaeger.Batch, opts SubmitBatchOptions) ([]*jaeger.BatchSubmitResponse, error) {
 	p.mux.Lock()
 	defer p.mux.Unlock()
 	p.batches = append(p.batches, batches...)
+	p.tenants = append(p.tenants, opts.Tenant)
+	p.clients = append(p.clients, opts.Client)
 	return nil, p.err
 }
 
@@ -58,7 +67,7 @@ func (p *mockJaegerHandler) getBatches() []*jaeger.Batch {
 
 func initializeTestServer(err error) (*httptest.Server, *APIHandler) {
 	r := mux.NewRouter()
-	handler := NewAPIHandler(&mockJaegerHandler{err: err})
+	handler := NewAPIHandler(&mockJaegerHandler{err: err}, tenancy.NewManager(&tenancy.Options{}), nil)
 	handler.RegisterRoutes(r)
 	return httptest.NewServer(r), handler
 }
@@ -96,6 +105,115 @@ func TestThriftFormat(t *testing.T) {
 	assert.EqualValues(t, "Cannot submit Jaeger batch: Bad times ahead\n", resBodyStr)
 }
 
+func TestTenantedThriftFormat(t *testing.T) {
+	batch := jaeger.Batch{
+		Process: &jaeger.Process{ServiceName: "serviceName"},
+		Spans:   []*jaeger.Span{{OperationName: "opName"}},
+	}
+	someBytes, err := thrift.NewTSerializer().Write(context.Background(), &batch)
+	require.NoError(t, err)
+
+	jaegerHandler := &mockJaegerHandler{}
+	tenancyMgr := tenancy.NewManager(&tenancy.Options{Enabled: true, Tenants: []string{"acme"}})
+	r := mux.NewRouter()
+	NewAPIHandler(jaegerHandler, tenancyMgr, nil).RegisterRoutes(r)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		tenant   string
+		expected int
+	}{
+		{name: "no tenant", expected: http.StatusUnauthorized},
+		{name: "unknown tenant", tenant: "megacorp", expected: http.StatusForbidden},
+		{name: "valid tenant", tenant: "acme", expected: http.StatusAccepted},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, server.URL+`/api/traces`, bytes.NewReader(someBytes))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/x-thrift")
+			if test.tenant != "" {
+				req.Header.Set(tenancyMgr.Header, test.tenant)
+			}
+			res, err := httpClient.Do(req)
+			require.NoError(t, err)
+			res.Body.Close()
+			assert.Equal(t, test.expected, res.StatusCode)
+		})
+	}
+	assert.Equal(t, []string{"acme"}, jaegerHandler.tenants)
+}
+
+func TestThriftFormatRejected(t *testing.T) {
+	batch := jaeger.Batch{
+		Process: &jaeger.Process{ServiceName: "serviceName"},
+		Spans:   []*jaeger.Span{{OperationName: "opName"}},
+	}
+	someBytes, err := thrift.NewTSerializer().Write(context.Background(), &batch)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		err        error
+		statusCode int
+		retryAfter string
+	}{
+		{
+			name:       "rate limited",
+			err:        &ratelimit.Error{Limit: ratelimit.ServiceLimit, Key: "serviceName", RetryAfter: 2500 * time.Millisecond},
+			statusCode: http.StatusTooManyRequests,
+			retryAfter: "3",
+		},
+		{
+			name:       "busy",
+			err:        processor.ErrBusy,
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, _ := initializeTestServer(test.err)
+			defer server.Close()
+			req, err := http.NewRequest(http.MethodPost, server.URL+`/api/traces`, bytes.NewReader(someBytes))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/x-thrift")
+			res, err := httpClient.Do(req)
+			require.NoError(t, err)
+			res.Body.Close()
+			assert.Equal(t, test.statusCode, res.StatusCode)
+			assert.Equal(t, test.retryAfter, res.Header.Get("Retry-After"))
+		})
+	}
+}
+
+func TestThriftFormatClient(t *testing.T) {
+	batch := jaeger.Batch{
+		Process: &jaeger.Process{ServiceName: "serviceName"},
+		Spans:   []*jaeger.Span{{OperationName: "opName"}},
+	}
+	someBytes, err := thrift.NewTSerializer().Write(context.Background(), &batch)
+	require.NoError(t, err)
+
+	jaegerHandler := &mockJaegerHandler{}
+	limiter := ratelimit.NewLimiter(ratelimit.Options{ClientSpansPerSecond: 1, ClientHeader: "X-Client-Id"}, metrics.NullFactory)
+	r := mux.NewRouter()
+	NewAPIHandler(jaegerHandler, tenancy.NewManager(&tenancy.Options{}), limiter).RegisterRoutes(r)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+`/api/traces`, bytes.NewReader(someBytes))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-thrift")
+	req.Header.Set("X-Client-Id", "client-1")
+	res, err := httpClient.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusAccepted, res.StatusCode)
+	assert.Equal(t, []string{"client-1"}, jaegerHandler.clients)
+}
+
 func TestViaClient(t *testing.T) {
 	server, handler := initializeTestServer(nil)
 	defer server.Close()
@@ -159,7 +277,7 @@ func TestMalformedFormat(t *testing.T) {
 }
 
 func TestCannotReadBodyFromRequest(t *testing.T) {
-	handler := NewAPIHandler(&mockJaegerHandler{})
+	handler := NewAPIHandler(&mockJaegerHandler{}, tenancy.NewManager(&tenancy.Options{}), nil)
 	req, err := http.NewRequest(http.MethodPost, "whatever", &errReader{})
 	assert.NoError(t, err)
 	rw := dummyResponseWriter{}
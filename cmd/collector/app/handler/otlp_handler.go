@@ -0,0 +1,252 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/ratelimit"
+	"github.com/jaegertracing/jaeger/model/converter/otlp"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+)
+
+const (
+	// OTLPTracesPath is the OTLP/HTTP endpoint for traces
+	OTLPTracesPath = "/v1/traces"
+
+	otlpProtobufContentType = "application/x-protobuf"
+	otlpJSONContentType     = "application/json"
+
+	// OTLP/JSON encodes IDs as hex strings, which protojson reads as base64,
+	// yielding byte slices of these lengths instead of 16 and 8 bytes.
+	hexTraceIDBase64Len = 24
+	hexSpanIDBase64Len  = 12
+)
+
+// errNoValidSpans occurs when a request contains spans but none of them can be converted
+var errNoValidSpans = errors.New("no valid spans in the request")
+
+var _ coltracepb.TraceServiceServer = (*OTLPHandler)(nil)
+
+// OTLPHandler receives spans in the OpenTelemetry protocol (OTLP) over gRPC and HTTP.
+type OTLPHandler struct {
+	coltracepb.UnimplementedTraceServiceServer
+	logger        *zap.Logger
+	spanProcessor processor.SpanProcessor
+	tenancyMgr    *tenancy.Manager
+	limiter       *ratelimit.Limiter
+}
+
+// NewOTLPHandler returns a new OTLPHandler, spans are not rate limited if the limiter is nil
+func NewOTLPHandler(logger *zap.Logger, spanProcessor processor.SpanProcessor, tenancyMgr *tenancy.Manager, limiter *ratelimit.Limiter) *OTLPHandler {
+	return &OTLPHandler{
+		logger:        logger,
+		spanProcessor: spanProcessor,
+		tenancyMgr:    tenancyMgr,
+		limiter:       limiter,
+	}
+}
+
+// Export implements gRPC TraceService.
+func (h *OTLPHandler) Export(ctx context.Context, r *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	var tenant string
+	if h.tenancyMgr.Enabled {
+		var err error
+		if tenant, err = tenancy.GetValidTenant(ctx, h.tenancyMgr); err != nil {
+			return nil, err
+		}
+	}
+	options := SubmitBatchOptions{
+		InboundTransport: processor.GRPCTransport,
+		Tenant:           tenant,
+		Client:           h.limiter.ClientFromGRPC(ctx),
+	}
+	if err := h.processTraces(r, options); err != nil {
+		if _, ok := ratelimit.AsError(err); ok {
+			return nil, err
+		}
+		switch err {
+		case processor.ErrBusy:
+			return nil, status.Errorf(codes.ResourceExhausted, err.Error())
+		case errNoValidSpans:
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		h.logger.Error("cannot process spans", zap.Error(err))
+		return nil, err
+	}
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+// RegisterRoutes registers the OTLP/HTTP routes on the given router
+func (h *OTLPHandler) RegisterRoutes(router *mux.Router) {
+	router.Handle(OTLPTracesPath, tenancy.ExtractTenantHTTPHandler(h.tenancyMgr, http.HandlerFunc(h.SaveTraces))).Methods(http.MethodPost)
+}
+
+// SaveTraces submits the OTLP traces, encoded as protobuf or JSON, provided in the request body
+func (h *OTLPHandler) SaveTraces(w http.ResponseWriter, r *http.Request) {
+	bRead := r.Body
+	defer r.Body.Close()
+	if strings.Contains(r.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(bRead)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(UnableToReadBodyErrFormat, err), http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+		bRead = gz
+	}
+
+	bodyBytes, err := ioutil.ReadAll(bRead)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(UnableToReadBodyErrFormat, err), http.StatusInternalServerError)
+		return
+	}
+
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Cannot parse content type: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	req := &coltracepb.ExportTraceServiceRequest{}
+	switch contentType {
+	case otlpProtobufContentType:
+		err = proto.Unmarshal(bodyBytes, req)
+	case otlpJSONContentType:
+		err = unmarshalOTLPJSON(bodyBytes, req)
+	default:
+		http.Error(w, fmt.Sprintf("Unsupported content type: %v", contentType), http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf(UnableToReadBodyErrFormat, err), http.StatusBadRequest)
+		return
+	}
+
+	options := SubmitBatchOptions{
+		InboundTransport: processor.HTTPTransport,
+		Tenant:           tenancy.GetTenant(r.Context()),
+		Client:           h.limiter.ClientFromHTTP(r),
+	}
+	if err := h.processTraces(req, options); err != nil {
+		if rlErr, ok := ratelimit.AsError(err); ok {
+			ratelimit.WriteHTTPError(w, rlErr)
+			return
+		}
+		switch err {
+		case processor.ErrBusy:
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		case errNoValidSpans:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, fmt.Sprintf("Cannot submit OTLP traces: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeOTLPResponse(w, contentType)
+}
+
+func (h *OTLPHandler) processTraces(r *coltracepb.ExportTraceServiceRequest, options SubmitBatchOptions) error {
+	spans, err := otlp.ToDomain(r.GetResourceSpans())
+	if err != nil {
+		if len(spans) == 0 {
+			h.logger.Debug("Rejecting OTLP request without valid spans", zap.Error(err))
+			return errNoValidSpans
+		}
+		h.logger.Warn("Skipping invalid OTLP spans", zap.Error(err))
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+	if err := h.limiter.Admit(options.Client, spans); err != nil {
+		return err
+	}
+	_, err = h.spanProcessor.ProcessSpans(spans, processor.SpansOptions{
+		InboundTransport: options.InboundTransport,
+		SpanFormat:       processor.OTLPSpanFormat,
+		Tenant:           options.Tenant,
+	})
+	return err
+}
+
+func writeOTLPResponse(w http.ResponseWriter, contentType string) {
+	resp := &coltracepb.ExportTraceServiceResponse{}
+	var body []byte
+	if contentType == otlpJSONContentType {
+		body, _ = protojson.Marshal(resp)
+	} else {
+		body, _ = proto.Marshal(resp)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+func unmarshalOTLPJSON(data []byte, req *coltracepb.ExportTraceServiceRequest) error {
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, req); err != nil {
+		return err
+	}
+	for _, rs := range req.GetResourceSpans() {
+		for _, ss := range rs.GetScopeSpans() {
+			for _, span := range ss.GetSpans() {
+				fixHexIDs(span)
+			}
+		}
+	}
+	return nil
+}
+
+// fixHexIDs restores IDs that were encoded as hex strings, as mandated by OTLP/JSON,
+// but decoded by protojson as base64. IDs encoded as base64 are left as is.
+func fixHexIDs(span *tracev1.Span) {
+	span.TraceId = hexIDFromBase64(span.TraceId, hexTraceIDBase64Len)
+	span.SpanId = hexIDFromBase64(span.SpanId, hexSpanIDBase64Len)
+	span.ParentSpanId = hexIDFromBase64(span.ParentSpanId, hexSpanIDBase64Len)
+	for _, link := range span.Links {
+		link.TraceId = hexIDFromBase64(link.TraceId, hexTraceIDBase64Len)
+		link.SpanId = hexIDFromBase64(link.SpanId, hexSpanIDBase64Len)
+	}
+}
+
+func hexIDFromBase64(id []byte, base64Len int) []byte {
+	if len(id) != base64Len {
+		return id
+	}
+	decoded, err := hex.DecodeString(base64.StdEncoding.EncodeToString(id))
+	if err != nil {
+		return id
+	}
+	return decoded
+}
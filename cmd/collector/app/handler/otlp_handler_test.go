@@ -0,0 +1,234 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+)
+
+func makeOTLPRequest(traceID, spanID []byte) *coltracepb.ExportTraceServiceRequest {
+	return &coltracepb.ExportTraceServiceRequest{
+		ResourceSpans: []*tracev1.ResourceSpans{{
+			Resource: &resourcev1.Resource{
+				Attributes: []*commonv1.KeyValue{{
+					Key:   "service.name",
+					Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: "frontend"}},
+				}},
+			},
+			ScopeSpans: []*tracev1.ScopeSpans{{
+				Spans: []*tracev1.Span{{TraceId: traceID, SpanId: spanID, Name: "test-op"}},
+			}},
+		}},
+	}
+}
+
+var (
+	otlpTraceID = []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2}
+	otlpSpanID  = []byte{0, 0, 0, 0, 0, 0, 0, 3}
+)
+
+func TestOTLPExport(t *testing.T) {
+	processor := &mockSpanProcessor{}
+	server, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		coltracepb.RegisterTraceServiceServer(s, NewOTLPHandler(zap.NewNop(), processor, tenancy.NewManager(&tenancy.Options{}), nil))
+	})
+	defer server.Stop()
+	conn, err := grpc.Dial(addr.String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	client := coltracepb.NewTraceServiceClient(conn)
+
+	_, err = client.Export(context.Background(), makeOTLPRequest(otlpTraceID, otlpSpanID))
+	require.NoError(t, err)
+	spans := processor.getSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "test-op", spans[0].OperationName)
+	assert.Equal(t, model.NewTraceID(1, 2), spans[0].TraceID)
+	assert.Equal(t, "frontend", spans[0].Process.ServiceName)
+	processor.reset()
+
+	_, err = client.Export(context.Background(), makeOTLPRequest([]byte{1}, otlpSpanID))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Empty(t, processor.getSpans())
+}
+
+func TestOTLPExportWithError(t *testing.T) {
+	tests := []struct {
+		processorErr error
+		code         codes.Code
+	}{
+		{processorErr: processor.ErrBusy, code: codes.ResourceExhausted},
+		{processorErr: errors.New("test-error"), code: codes.Unknown},
+	}
+	for _, test := range tests {
+		handler := NewOTLPHandler(zap.NewNop(), &mockSpanProcessor{expectedError: test.processorErr}, tenancy.NewManager(&tenancy.Options{}), nil)
+		_, err := handler.Export(context.Background(), makeOTLPRequest(otlpTraceID, otlpSpanID))
+		require.Error(t, err)
+		assert.Equal(t, test.code, status.Code(err))
+		assert.Contains(t, err.Error(), test.processorErr.Error())
+	}
+}
+
+func initializeOTLPTestServer(processor processor.SpanProcessor) *httptest.Server {
+	r := mux.NewRouter()
+	NewOTLPHandler(zap.NewNop(), processor, tenancy.NewManager(&tenancy.Options{}), nil).RegisterRoutes(r)
+	return httptest.NewServer(r)
+}
+
+func postOTLP(t *testing.T, url, contentType string, body []byte, gzipped bool) (*http.Response, string) {
+	req, err := http.NewRequest(http.MethodPost, url+OTLPTracesPath, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	if gzipped {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(respBody)
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestOTLPSaveTraces(t *testing.T) {
+	protoBody, err := proto.Marshal(makeOTLPRequest(otlpTraceID, otlpSpanID))
+	require.NoError(t, err)
+	hexJSONBody := []byte(`{"resourceSpans":[{"scopeSpans":[{"spans":[{
+		"traceId":"00000000000000010000000000000002","spanId":"0000000000000003",
+		"parentSpanId":"0000000000000004","name":"test-op","kind":2}]}]}]}`)
+	base64JSONBody := []byte(`{"resourceSpans":[{"scopeSpans":[{"spans":[{
+		"traceId":"AAAAAAAAAAEAAAAAAAAAAg==","spanId":"AAAAAAAAAAM=","name":"test-op","unknownField":1}]}]}]}`)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		gzipped     bool
+		parentID    model.SpanID
+	}{
+		{name: "protobuf", contentType: "application/x-protobuf", body: protoBody},
+		{name: "gzipped protobuf", contentType: "application/x-protobuf", body: gzipBytes(t, protoBody), gzipped: true},
+		{name: "json with hex IDs", contentType: "application/json", body: hexJSONBody, parentID: model.NewSpanID(4)},
+		{name: "json with base64 IDs", contentType: "application/json; charset=utf-8", body: base64JSONBody},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			processor := &mockSpanProcessor{}
+			server := initializeOTLPTestServer(processor)
+			defer server.Close()
+
+			resp, _ := postOTLP(t, server.URL, test.contentType, test.body, test.gzipped)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			spans := processor.getSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "test-op", spans[0].OperationName)
+			assert.Equal(t, model.NewTraceID(1, 2), spans[0].TraceID)
+			assert.Equal(t, model.NewSpanID(3), spans[0].SpanID)
+			assert.Equal(t, test.parentID, spans[0].ParentSpanID())
+		})
+	}
+}
+
+func TestOTLPSaveTracesErrors(t *testing.T) {
+	protoBody, err := proto.Marshal(makeOTLPRequest(otlpTraceID, otlpSpanID))
+	require.NoError(t, err)
+	invalidBody, err := proto.Marshal(makeOTLPRequest([]byte{1}, otlpSpanID))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		contentType  string
+		body         []byte
+		gzipped      bool
+		processorErr error
+		statusCode   int
+		message      string
+	}{
+		{name: "bad content type", contentType: "application/json; =", body: protoBody,
+			statusCode: http.StatusBadRequest, message: "Cannot parse content type"},
+		{name: "unsupported content type", contentType: "application/x-thrift", body: protoBody,
+			statusCode: http.StatusUnsupportedMediaType, message: "Unsupported content type: application/x-thrift"},
+		{name: "bad gzip", contentType: "application/x-protobuf", body: protoBody, gzipped: true,
+			statusCode: http.StatusBadRequest, message: "Unable to process request body"},
+		{name: "bad protobuf", contentType: "application/x-protobuf", body: []byte("bad"),
+			statusCode: http.StatusBadRequest, message: "Unable to process request body"},
+		{name: "bad json", contentType: "application/json", body: []byte("{"),
+			statusCode: http.StatusBadRequest, message: "Unable to process request body"},
+		{name: "no valid spans", contentType: "application/x-protobuf", body: invalidBody,
+			statusCode: http.StatusBadRequest, message: "no valid spans in the request"},
+		{name: "busy", contentType: "application/x-protobuf", body: protoBody, processorErr: processor.ErrBusy,
+			statusCode: http.StatusServiceUnavailable, message: "server busy"},
+		{name: "processor error", contentType: "application/x-protobuf", body: protoBody, processorErr: errors.New("test-error"),
+			statusCode: http.StatusInternalServerError, message: "Cannot submit OTLP traces: test-error"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := initializeOTLPTestServer(&mockSpanProcessor{expectedError: test.processorErr})
+			defer server.Close()
+
+			resp, body := postOTLP(t, server.URL, test.contentType, test.body, test.gzipped)
+			assert.Equal(t, test.statusCode, resp.StatusCode)
+			assert.Contains(t, body, test.message)
+		})
+	}
+}
+
+func TestOTLPEmptyRequest(t *testing.T) {
+	processor := &mockSpanProcessor{expectedError: errors.New("should not be called")}
+	handler := NewOTLPHandler(zap.NewNop(), processor, tenancy.NewManager(&tenancy.Options{}), nil)
+	_, err := handler.Export(context.Background(), &coltracepb.ExportTraceServiceRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, processor.getSpans())
+}
+
+func TestHexIDFromBase64(t *testing.T) {
+	// base64 of 12 bytes that are not a valid hex string when re-encoded
+	notHex := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	assert.Equal(t, notHex, hexIDFromBase64(notHex, hexSpanIDBase64Len))
+	assert.Equal(t, otlpSpanID, hexIDFromBase64(otlpSpanID, hexSpanIDBase64Len))
+}
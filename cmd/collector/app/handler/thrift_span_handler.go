@@ -16,9 +16,13 @@
 package handler
 
 import (
+	"fmt"
+	"net/http"
+
 	"go.uber.org/zap"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/ratelimit"
 	zipkinS "github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer/zipkin"
 	"github.com/jaegertracing/jaeger/model"
 	jConv "github.com/jaegertracing/jaeger/model/converter/thrift/jaeger"
@@ -30,6 +34,20 @@ import (
 // SubmitBatchOptions are passed to Submit methods of the handlers.
 type SubmitBatchOptions struct {
 	InboundTransport processor.InboundTransport
+	// Tenant owning the spans, empty when multi-tenancy is disabled
+	Tenant string
+	// Client is the identity of the client sending the spans, used for rate limiting
+	Client string
+}
+
+// WriteSubmitError replies to the HTTP request with the error of submitting spans: 429 with a Retry-After header
+// if the spans were rejected by a rate limit, 500 otherwise.
+func WriteSubmitError(w http.ResponseWriter, message string, err error) {
+	if rlErr, ok := ratelimit.AsError(err); ok {
+		ratelimit.WriteHTTPError(w, rlErr)
+		return
+	}
+	http.Error(w, fmt.Sprintf("%s: %v", message, err), http.StatusInternalServerError)
 }
 
 // ZipkinSpansHandler consumes and handles zipkin spans
@@ -47,27 +65,40 @@ type JaegerBatchesHandler interface {
 type jaegerBatchesHandler struct {
 	logger         *zap.Logger
 	modelProcessor processor.SpanProcessor
+	limiter        *ratelimit.Limiter
 }
 
-// NewJaegerSpanHandler returns a JaegerBatchesHandler
-func NewJaegerSpanHandler(logger *zap.Logger, modelProcessor processor.SpanProcessor) JaegerBatchesHandler {
+// NewJaegerSpanHandler returns a JaegerBatchesHandler, spans are not rate limited if the limiter is nil
+func NewJaegerSpanHandler(logger *zap.Logger, modelProcessor processor.SpanProcessor, limiter *ratelimit.Limiter) JaegerBatchesHandler {
 	return &jaegerBatchesHandler{
 		logger:         logger,
 		modelProcessor: modelProcessor,
+		limiter:        limiter,
 	}
 }
 
 func (jbh *jaegerBatchesHandler) SubmitBatches(batches []*jaeger.Batch, options SubmitBatchOptions) ([]*jaeger.BatchSubmitResponse, error) {
-	responses := make([]*jaeger.BatchSubmitResponse, 0, len(batches))
+	batchSpans := make([][]*model.Span, 0, len(batches))
+	var allSpans []*model.Span
 	for _, batch := range batches {
 		mSpans := make([]*model.Span, 0, len(batch.Spans))
 		for _, span := range batch.Spans {
 			mSpan := jConv.ToDomainSpan(span, batch.Process)
 			mSpans = append(mSpans, mSpan)
 		}
+		batchSpans = append(batchSpans, mSpans)
+		allSpans = append(allSpans, mSpans...)
+	}
+	// admit all the spans at once, so that a rejected request leaves no batch processed
+	if err := jbh.limiter.Admit(options.Client, allSpans); err != nil {
+		return nil, err
+	}
+	responses := make([]*jaeger.BatchSubmitResponse, 0, len(batches))
+	for _, mSpans := range batchSpans {
 		oks, err := jbh.modelProcessor.ProcessSpans(mSpans, processor.SpansOptions{
 			InboundTransport: options.InboundTransport,
 			SpanFormat:       processor.JaegerSpanFormat,
+			Tenant:           options.Tenant,
 		})
 		if err != nil {
 			jbh.logger.Error("Collector failed to process span batch", zap.Error(err))
@@ -94,14 +125,16 @@ type zipkinSpanHandler struct {
 	logger         *zap.Logger
 	sanitizer      zipkinS.Sanitizer
 	modelProcessor processor.SpanProcessor
+	limiter        *ratelimit.Limiter
 }
 
-// NewZipkinSpanHandler returns a ZipkinSpansHandler
-func NewZipkinSpanHandler(logger *zap.Logger, modelHandler processor.SpanProcessor, sanitizer zipkinS.Sanitizer) ZipkinSpansHandler {
+// NewZipkinSpanHandler returns a ZipkinSpansHandler, spans are not rate limited if the limiter is nil
+func NewZipkinSpanHandler(logger *zap.Logger, modelHandler processor.SpanProcessor, sanitizer zipkinS.Sanitizer, limiter *ratelimit.Limiter) ZipkinSpansHandler {
 	return &zipkinSpanHandler{
 		logger:         logger,
 		modelProcessor: modelHandler,
 		sanitizer:      sanitizer,
+		limiter:        limiter,
 	}
 }
 
@@ -112,9 +145,13 @@ func (h *zipkinSpanHandler) SubmitZipkinBatch(spans []*zipkincore.Span, options
 		sanitized := h.sanitizer.Sanitize(span)
 		mSpans = append(mSpans, convertZipkinToModel(sanitized, h.logger)...)
 	}
+	if err := h.limiter.Admit(options.Client, mSpans); err != nil {
+		return nil, err
+	}
 	bools, err := h.modelProcessor.ProcessSpans(mSpans, processor.SpansOptions{
 		InboundTransport: options.InboundTransport,
 		SpanFormat:       processor.ZipkinSpanFormat,
+		Tenant:           options.Tenant,
 	})
 	if err != nil {
 		h.logger.Error("Collector failed to process Zipkin span batch", zap.Error(err))
@@ -18,11 +18,15 @@ package handler
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/ratelimit"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer/zipkin"
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
@@ -42,7 +46,7 @@ func TestJaegerSpanHandler(t *testing.T) {
 	}
 	for _, tc := range testChunks {
 		logger := zap.NewNop()
-		h := NewJaegerSpanHandler(logger, &shouldIErrorProcessor{tc.expectedErr != nil})
+		h := NewJaegerSpanHandler(logger, &shouldIErrorProcessor{tc.expectedErr != nil}, nil)
 		res, err := h.SubmitBatches([]*jaeger.Batch{
 			{
 				Process: &jaeger.Process{ServiceName: "someServiceName"},
@@ -93,7 +97,7 @@ func TestZipkinSpanHandler(t *testing.T) {
 	}
 	for _, tc := range testChunks {
 		logger := zap.NewNop()
-		h := NewZipkinSpanHandler(logger, &shouldIErrorProcessor{tc.expectedErr != nil}, zipkin.NewParentIDSanitizer())
+		h := NewZipkinSpanHandler(logger, &shouldIErrorProcessor{tc.expectedErr != nil}, zipkin.NewParentIDSanitizer(), nil)
 		res, err := h.SubmitZipkinBatch([]*zipkincore.Span{
 			{
 				ID: 12345,
@@ -109,3 +113,45 @@ func TestZipkinSpanHandler(t *testing.T) {
 		}
 	}
 }
+
+func TestSpanHandlersRateLimited(t *testing.T) {
+	newLimiter := func() *ratelimit.Limiter {
+		return ratelimit.NewLimiter(ratelimit.Options{ClientSpansPerSecond: 1, Burst: time.Second}, metrics.NullFactory)
+	}
+	jaegerBatches := []*jaeger.Batch{
+		{
+			Process: &jaeger.Process{ServiceName: "someServiceName"},
+			Spans:   []*jaeger.Span{{SpanId: 1}, {SpanId: 2}},
+		},
+	}
+	zipkinSpans := []*zipkincore.Span{{ID: 1}, {ID: 2}}
+	options := SubmitBatchOptions{Client: "client-1"}
+
+	jh := NewJaegerSpanHandler(zap.NewNop(), &shouldIErrorProcessor{}, newLimiter())
+	_, err := jh.SubmitBatches(jaegerBatches, options)
+	require.NoError(t, err)
+	res, err := jh.SubmitBatches(jaegerBatches, options)
+	assert.Nil(t, res)
+	_, ok := ratelimit.AsError(err)
+	assert.True(t, ok)
+
+	// the spans of all batches are admitted before any batch is processed
+	jh = NewJaegerSpanHandler(zap.NewNop(), &shouldIErrorProcessor{},
+		ratelimit.NewLimiter(ratelimit.Options{ClientSpansPerSecond: 2, Burst: time.Second}, metrics.NullFactory))
+	oneSpan := &jaeger.Batch{Process: jaegerBatches[0].Process, Spans: jaegerBatches[0].Spans[:1]}
+	_, err = jh.SubmitBatches([]*jaeger.Batch{oneSpan}, options)
+	require.NoError(t, err)
+	jh.(*jaegerBatchesHandler).modelProcessor = &shouldIErrorProcessor{shouldError: true}
+	res, err = jh.SubmitBatches([]*jaeger.Batch{oneSpan, oneSpan}, options)
+	assert.Nil(t, res)
+	_, ok = ratelimit.AsError(err)
+	assert.True(t, ok)
+
+	zh := NewZipkinSpanHandler(zap.NewNop(), &shouldIErrorProcessor{}, zipkin.NewParentIDSanitizer(), newLimiter())
+	_, err = zh.SubmitZipkinBatch(zipkinSpans, options)
+	require.NoError(t, err)
+	zRes, err := zh.SubmitZipkinBatch(zipkinSpans, options)
+	assert.Nil(t, zRes)
+	_, ok = ratelimit.AsError(err)
+	assert.True(t, ok)
+}
@@ -33,6 +33,12 @@ const (
 	// otherServices is the catch-all label when number of services exceeds maxServiceNames
 	otherServices = "other-services"
 
+	// maxTenants is the number of tenants with their own metrics
+	maxTenants = 1000
+
+	// otherTenants is the catch-all label when number of tenants exceeds maxTenants
+	otherTenants = "other-tenants"
+
 	samplerTypeKey           = "sampler_type"
 	samplerTypeConst         = "const"
 	samplerTypeProbabilistic = "probabilistic"
@@ -60,6 +66,15 @@ type SpanProcessorMetrics struct {
 	InQueueLatency metrics.Timer
 	// SpansDropped measures the number of spans we discarded because the queue was full
 	SpansDropped metrics.Counter
+	// WriteRetries measures the number of retries of failed span writes
+	WriteRetries metrics.Counter
+	// WriteDropped measures the number of spans discarded because they could not be written
+	// and no dead-letter sink is configured
+	WriteDropped metrics.Counter
+	// DeadLetteredOk measures the number of spans written to the dead-letter sink
+	DeadLetteredOk metrics.Counter
+	// DeadLetteredErr measures the number of spans that failed to be written to the dead-letter sink
+	DeadLetteredErr metrics.Counter
 	// SpansBytes records how many bytes were processed
 	SpansBytes metrics.Gauge
 	// BatchSize measures the span batch size
@@ -73,6 +88,29 @@ type SpanProcessorMetrics struct {
 	SavedErrBySvc metricsBySvc  // spans failed to save
 	serviceNames  metrics.Gauge // total number of unique service name metrics reported by this collector
 	spanCounts    SpanCountsByFormat
+	// tenantCounts contains span counts by tenant
+	tenantCounts *countsByTenant
+}
+
+// TenantCounts contains the span counts of a tenant.
+type TenantCounts struct {
+	// Received is the number of spans received from the tenant
+	Received metrics.Counter
+	// Rejected is the number of spans of the tenant rejected by the span filter
+	Rejected metrics.Counter
+	// Dropped is the number of spans of the tenant discarded because the queue, or the tenant's share of it, was full
+	Dropped metrics.Counter
+	// SavedOk is the number of spans of the tenant saved to storage
+	SavedOk metrics.Counter
+	// SavedErr is the number of spans of the tenant that failed to be saved
+	SavedErr metrics.Counter
+}
+
+type countsByTenant struct {
+	counts     map[string]*TenantCounts
+	factory    metrics.Factory
+	lock       sync.Mutex
+	maxTenants int
 }
 
 type countsBySvc struct {
@@ -118,28 +156,73 @@ func NewSpanProcessorMetrics(serviceMetrics metrics.Factory, hostMetrics metrics
 		processor.ZipkinSpanFormat:  newCountsByTransport(serviceMetrics, processor.ZipkinSpanFormat),
 		processor.JaegerSpanFormat:  newCountsByTransport(serviceMetrics, processor.JaegerSpanFormat),
 		processor.ProtoSpanFormat:   newCountsByTransport(serviceMetrics, processor.ProtoSpanFormat),
+		processor.OTLPSpanFormat:    newCountsByTransport(serviceMetrics, processor.OTLPSpanFormat),
 		processor.UnknownSpanFormat: newCountsByTransport(serviceMetrics, processor.UnknownSpanFormat),
 	}
 	for _, otherFormatType := range otherFormatTypes {
 		spanCounts[otherFormatType] = newCountsByTransport(serviceMetrics, otherFormatType)
 	}
 	m := &SpanProcessorMetrics{
-		SaveLatency:    hostMetrics.Timer(metrics.TimerOptions{Name: "save-latency", Tags: nil}),
-		InQueueLatency: hostMetrics.Timer(metrics.TimerOptions{Name: "in-queue-latency", Tags: nil}),
-		SpansDropped:   hostMetrics.Counter(metrics.Options{Name: "spans.dropped", Tags: nil}),
-		BatchSize:      hostMetrics.Gauge(metrics.Options{Name: "batch-size", Tags: nil}),
-		QueueCapacity:  hostMetrics.Gauge(metrics.Options{Name: "queue-capacity", Tags: nil}),
-		QueueLength:    hostMetrics.Gauge(metrics.Options{Name: "queue-length", Tags: nil}),
-		SpansBytes:     hostMetrics.Gauge(metrics.Options{Name: "spans.bytes", Tags: nil}),
-		SavedOkBySvc:   newMetricsBySvc(serviceMetrics.Namespace(metrics.NSOptions{Name: "", Tags: map[string]string{"result": "ok"}}), "saved-by-svc"),
-		SavedErrBySvc:  newMetricsBySvc(serviceMetrics.Namespace(metrics.NSOptions{Name: "", Tags: map[string]string{"result": "err"}}), "saved-by-svc"),
-		spanCounts:     spanCounts,
-		serviceNames:   hostMetrics.Gauge(metrics.Options{Name: "spans.serviceNames", Tags: nil}),
+		SaveLatency:     hostMetrics.Timer(metrics.TimerOptions{Name: "save-latency", Tags: nil}),
+		InQueueLatency:  hostMetrics.Timer(metrics.TimerOptions{Name: "in-queue-latency", Tags: nil}),
+		SpansDropped:    hostMetrics.Counter(metrics.Options{Name: "spans.dropped", Tags: nil}),
+		WriteRetries:    hostMetrics.Counter(metrics.Options{Name: "spans.write-retries", Tags: nil}),
+		WriteDropped:    hostMetrics.Counter(metrics.Options{Name: "spans.write-dropped", Tags: nil}),
+		DeadLetteredOk:  hostMetrics.Counter(metrics.Options{Name: "spans.dead-lettered", Tags: map[string]string{"result": "ok"}}),
+		DeadLetteredErr: hostMetrics.Counter(metrics.Options{Name: "spans.dead-lettered", Tags: map[string]string{"result": "err"}}),
+		BatchSize:       hostMetrics.Gauge(metrics.Options{Name: "batch-size", Tags: nil}),
+		QueueCapacity:   hostMetrics.Gauge(metrics.Options{Name: "queue-capacity", Tags: nil}),
+		QueueLength:     hostMetrics.Gauge(metrics.Options{Name: "queue-length", Tags: nil}),
+		SpansBytes:      hostMetrics.Gauge(metrics.Options{Name: "spans.bytes", Tags: nil}),
+		SavedOkBySvc:    newMetricsBySvc(serviceMetrics.Namespace(metrics.NSOptions{Name: "", Tags: map[string]string{"result": "ok"}}), "saved-by-svc"),
+		SavedErrBySvc:   newMetricsBySvc(serviceMetrics.Namespace(metrics.NSOptions{Name: "", Tags: map[string]string{"result": "err"}}), "saved-by-svc"),
+		spanCounts:      spanCounts,
+		serviceNames:    hostMetrics.Gauge(metrics.Options{Name: "spans.serviceNames", Tags: nil}),
+		tenantCounts:    newCountsByTenant(hostMetrics.Namespace(metrics.NSOptions{Name: "tenant", Tags: nil}), maxTenants),
 	}
 
 	return m
 }
 
+func newCountsByTenant(factory metrics.Factory, maxTenants int) *countsByTenant {
+	c := &countsByTenant{
+		counts:     make(map[string]*TenantCounts),
+		factory:    factory,
+		maxTenants: maxTenants,
+	}
+	c.counts[otherTenants] = newTenantCounts(factory, otherTenants)
+	return c
+}
+
+func newTenantCounts(factory metrics.Factory, tenant string) *TenantCounts {
+	factory = factory.Namespace(metrics.NSOptions{Tags: map[string]string{"tenant": tenant}})
+	return &TenantCounts{
+		Received: factory.Counter(metrics.Options{Name: "spans.received", Tags: nil}),
+		Rejected: factory.Counter(metrics.Options{Name: "spans.rejected", Tags: nil}),
+		Dropped:  factory.Counter(metrics.Options{Name: "spans.dropped", Tags: nil}),
+		SavedOk:  factory.Counter(metrics.Options{Name: "spans.saved", Tags: map[string]string{"result": "ok"}}),
+		SavedErr: factory.Counter(metrics.Options{Name: "spans.saved", Tags: map[string]string{"result": "err"}}),
+	}
+}
+
+// GetCountsForTenant gets the TenantCounts of a tenant. Tenants beyond the first maxTenants share the same counts.
+func (m *SpanProcessorMetrics) GetCountsForTenant(tenant string) *TenantCounts {
+	c := m.tenantCounts
+	tenant = normalizer.ServiceName(tenant)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if counts, ok := c.counts[tenant]; ok {
+		return counts
+	}
+	// the counts of otherTenants are not included in the limit
+	if len(c.counts) > c.maxTenants {
+		return c.counts[otherTenants]
+	}
+	counts := newTenantCounts(c.factory, tenant)
+	c.counts[tenant] = counts
+	return counts
+}
+
 func newMetricsBySvc(factory metrics.Factory, category string) metricsBySvc {
 	spansFactory := factory.Namespace(metrics.NSOptions{Name: "spans", Tags: nil})
 	tracesFactory := factory.Namespace(metrics.NSOptions{Name: "traces", Tags: nil})
@@ -125,3 +125,20 @@ func TestBuildKey(t *testing.T) {
 	key = tc.buildKey("sample-service2", "const")
 	assert.Equal(t, "sample-service2$_$const", key)
 }
+
+func TestCountsByTenant(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(time.Hour)
+	counts := newCountsByTenant(metricsFactory, 2)
+
+	spm := &SpanProcessorMetrics{tenantCounts: counts}
+	spm.GetCountsForTenant("acme").Received.Inc(1)
+	spm.GetCountsForTenant("acme").Received.Inc(1)
+	spm.GetCountsForTenant("megacorp").Received.Inc(1)
+	// the limit of two tenants is reached
+	spm.GetCountsForTenant("initech").Received.Inc(1)
+
+	counters, _ := metricsFactory.Backend.Snapshot()
+	assert.EqualValues(t, 2, counters["spans.received|tenant=acme"])
+	assert.EqualValues(t, 1, counters["spans.received|tenant=megacorp"])
+	assert.EqualValues(t, 1, counters["spans.received|tenant=other-tenants"])
+}
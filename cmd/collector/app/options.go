@@ -16,12 +16,17 @@
 package app
 
 import (
+	"time"
+
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/deadletter"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/queue"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
 const (
@@ -29,6 +34,10 @@ const (
 	DefaultNumWorkers = 50
 	// DefaultQueueSize is the size of the processor's queue
 	DefaultQueueSize = 2000
+	// DefaultRetryInitialInterval is the default upper bound of the first backoff interval between span write retries
+	DefaultRetryInitialInterval = time.Second
+	// DefaultRetryMaxInterval is the default upper bound of the backoff interval between span write retries
+	DefaultRetryMaxInterval = 30 * time.Second
 )
 
 type options struct {
@@ -47,8 +56,23 @@ type options struct {
 	reportBusy         bool
 	extraFormatTypes   []processor.SpanFormat
 	collectorTags      map[string]string
+	persistentQueue    *queue.PersistentQueue
+	maxRetries         int
+	retryInitial       time.Duration
+	retryMax           time.Duration
+	deadLetter         deadletter.Writer
+	tenantSpanWriter   TenantSpanWriter
+	tenantQueueSize    int
+	tailSampler        TailSampler
 }
 
+// TenantSpanWriter returns the span writer of a tenant
+type TenantSpanWriter func(tenant string) (spanstore.Writer, error)
+
+// TailSampler returns the writer buffering the spans until their tail-based sampling decision,
+// which writes the spans of the kept traces with the given writer
+type TailSampler func(keptSpanWriter spanstore.Writer) spanstore.Writer
+
 // Option is a function that sets some option on StorageBuilder.
 type Option func(c *options)
 
@@ -160,6 +184,66 @@ func (options) CollectorTags(extraTags map[string]string) Option {
 	}
 }
 
+// PersistentQueue creates an Option that replaces the in-memory queue with the given disk-backed queue
+func (options) PersistentQueue(persistentQueue *queue.PersistentQueue) Option {
+	return func(b *options) {
+		b.persistentQueue = persistentQueue
+	}
+}
+
+// MaxRetries creates an Option that initializes the number of times a failed span write
+// is retried, a negative value means no limit
+func (options) MaxRetries(maxRetries int) Option {
+	return func(b *options) {
+		b.maxRetries = maxRetries
+	}
+}
+
+// RetryInitialInterval creates an Option that initializes the upper bound of the first backoff interval
+func (options) RetryInitialInterval(retryInitial time.Duration) Option {
+	return func(b *options) {
+		b.retryInitial = retryInitial
+	}
+}
+
+// RetryMaxInterval creates an Option that initializes the upper bound of all backoff intervals
+func (options) RetryMaxInterval(retryMax time.Duration) Option {
+	return func(b *options) {
+		b.retryMax = retryMax
+	}
+}
+
+// DeadLetter creates an Option that initializes the writer for spans that could not be saved after all retries
+func (options) DeadLetter(deadLetter deadletter.Writer) Option {
+	return func(b *options) {
+		b.deadLetter = deadLetter
+	}
+}
+
+// TenantSpanWriter creates an Option that initializes the function returning the span writer of a tenant,
+// spans without a tenant are written with the default span writer
+func (options) TenantSpanWriter(tenantSpanWriter TenantSpanWriter) Option {
+	return func(b *options) {
+		b.tenantSpanWriter = tenantSpanWriter
+	}
+}
+
+// TenantQueueSize creates an Option that initializes the maximum number of queued spans of a single tenant,
+// zero means no limit other than the queue size
+func (options) TenantQueueSize(tenantQueueSize int) Option {
+	return func(b *options) {
+		b.tenantQueueSize = tenantQueueSize
+	}
+}
+
+// TailSampler creates an Option that initializes the tail-based sampler of the spans, the spans of the
+// kept traces are then written with the retries and the dead-letter sink of the processor
+func (options) TailSampler(tailSampler TailSampler) Option {
+	return func(b *options) {
+		b.tailSampler = tailSampler
+	}
+}
+
 func (o options) apply(opts ...Option) options {
 	ret := options{}
 	for _, opt := range opts {
@@ -189,5 +273,11 @@ func (o options) apply(opts ...Option) options {
 	if ret.numWorkers == 0 {
 		ret.numWorkers = DefaultNumWorkers
 	}
+	if ret.retryInitial == 0 {
+		ret.retryInitial = DefaultRetryInitialInterval
+	}
+	if ret.retryMax == 0 {
+		ret.retryMax = DefaultRetryMaxInterval
+	}
 	return ret
 }
@@ -17,6 +17,7 @@ package app
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/uber/jaeger-lib/metrics"
@@ -44,12 +45,20 @@ func TestAllOptionSet(t *testing.T) {
 		Options.DynQueueSizeMemory(1024),
 		Options.PreSave(func(span *model.Span) {}),
 		Options.CollectorTags(map[string]string{"extra": "tags"}),
+		Options.MaxRetries(3),
+		Options.RetryInitialInterval(time.Millisecond),
+		Options.RetryMaxInterval(time.Minute),
+		Options.DeadLetter(&recordingDeadLetter{}),
 	)
 	assert.EqualValues(t, 5, opts.numWorkers)
 	assert.EqualValues(t, 10, opts.queueSize)
 	assert.EqualValues(t, map[string]string{"extra": "tags"}, opts.collectorTags)
 	assert.EqualValues(t, 1000, opts.dynQueueSizeWarmup)
 	assert.EqualValues(t, 1024, opts.dynQueueSizeMemory)
+	assert.Equal(t, 3, opts.maxRetries)
+	assert.Equal(t, time.Millisecond, opts.retryInitial)
+	assert.Equal(t, time.Minute, opts.retryMax)
+	assert.NotNil(t, opts.deadLetter)
 }
 
 func TestNoOptionsSet(t *testing.T) {
@@ -65,4 +74,8 @@ func TestNoOptionsSet(t *testing.T) {
 	span := model.Span{}
 	assert.EqualValues(t, &span, opts.sanitizer(&span))
 	assert.EqualValues(t, 0, opts.dynQueueSizeWarmup)
+	assert.Nil(t, opts.persistentQueue)
+	assert.Equal(t, DefaultRetryInitialInterval, opts.retryInitial)
+	assert.Equal(t, DefaultRetryMaxInterval, opts.retryMax)
+	assert.Nil(t, opts.deadLetter)
 }
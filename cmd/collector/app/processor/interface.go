@@ -28,6 +28,8 @@ var ErrBusy = errors.New("server busy")
 type SpansOptions struct {
 	SpanFormat       SpanFormat
 	InboundTransport InboundTransport
+	// Tenant owning the spans, empty when multi-tenancy is disabled
+	Tenant string
 }
 
 // SpanProcessor handles model spans
@@ -59,6 +61,8 @@ const (
 	ZipkinSpanFormat SpanFormat = "zipkin"
 	// ProtoSpanFormat is for Jaeger protobuf Spans.
 	ProtoSpanFormat SpanFormat = "proto"
+	// OTLPSpanFormat is for OpenTelemetry protocol (OTLP) Spans.
+	OTLPSpanFormat SpanFormat = "otlp"
 	// UnknownSpanFormat is the fallback/catch-all category.
 	UnknownSpanFormat SpanFormat = "unknown"
 )
@@ -0,0 +1,305 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+const (
+	// ServiceLimit is the kind of limit applied to the spans of a service
+	ServiceLimit = "service"
+	// ClientLimit is the kind of limit applied to the spans sent by a client
+	ClientLimit = "client"
+
+	// otherKey is the key sharing a single limit once the number of keys reaches the max
+	otherKey = "other"
+
+	// evictionInterval is the minimum interval between scans for idle keys
+	evictionInterval = time.Second
+)
+
+// Error is returned when spans are rejected because a rate limit is exceeded.
+// It is converted to the gRPC status RESOURCE_EXHAUSTED with a RetryInfo detail.
+type Error struct {
+	// Limit is the kind of the exceeded limit, ServiceLimit or ClientLimit
+	Limit string
+	// Key is the service or the client over its limit
+	Key string
+	// RetryAfter is the time after which the spans would be admitted
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rate limit of %s %q exceeded, retry after %v", e.Limit, e.Key, e.RetryAfter)
+}
+
+// RetryAfterSeconds returns RetryAfter rounded up to whole seconds, as expected in the Retry-After HTTP header
+func (e *Error) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// GRPCStatus returns the gRPC status of the error
+func (e *Error) GRPCStatus() *status.Status {
+	st := status.New(codes.ResourceExhausted, e.Error())
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(e.RetryAfter)}); err == nil {
+		return detailed
+	}
+	return st
+}
+
+// AsError returns the rate limit error of err, if any
+func AsError(err error) (*Error, bool) {
+	var rlErr *Error
+	if errors.As(err, &rlErr) {
+		return rlErr, true
+	}
+	return nil, false
+}
+
+// WriteHTTPError replies to the request with HTTP 429 and the Retry-After header
+func WriteHTTPError(w http.ResponseWriter, err *Error) {
+	w.Header().Set("Retry-After", strconv.Itoa(err.RetryAfterSeconds()))
+	http.Error(w, err.Error(), http.StatusTooManyRequests)
+}
+
+// bucket is a token bucket that can go into debt, so that batches larger than its capacity
+// are admitted when the bucket is full, and the following ones wait until the debt is paid.
+type bucket struct {
+	balance  float64
+	lastTick time.Time
+}
+
+// keyedLimiter holds the buckets of either the services or the clients
+type keyedLimiter struct {
+	limit        string
+	rate         float64
+	capacity     float64
+	maxKeys      int
+	buckets      map[string]*bucket
+	lastEviction time.Time
+	rejected     metrics.Counter
+}
+
+func newKeyedLimiter(limit string, rate float64, options Options, metricsFactory metrics.Factory) *keyedLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	capacity := rate * options.Burst.Seconds()
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &keyedLimiter{
+		limit:    limit,
+		rate:     rate,
+		capacity: capacity,
+		maxKeys:  options.MaxKeys,
+		buckets:  make(map[string]*bucket),
+		rejected: metricsFactory.Counter(metrics.Options{Name: "spans.rate-limited", Tags: map[string]string{"limit": limit}}),
+	}
+}
+
+// bucket returns the refilled bucket of the key, and the key of the bucket which differs
+// from the given one if the key shares the limit of the other keys
+func (k *keyedLimiter) bucket(key string, now time.Time) (string, *bucket) {
+	if b, ok := k.buckets[key]; ok {
+		k.refill(b, now)
+		return key, b
+	}
+	if len(k.buckets) >= k.maxKeys && now.Sub(k.lastEviction) >= evictionInterval {
+		k.evictIdle(now)
+	}
+	if len(k.buckets) >= k.maxKeys {
+		key = otherKey
+		if b, ok := k.buckets[key]; ok {
+			k.refill(b, now)
+			return key, b
+		}
+	}
+	b := &bucket{balance: k.capacity, lastTick: now}
+	k.buckets[key] = b
+	return key, b
+}
+
+func (k *keyedLimiter) refill(b *bucket, now time.Time) {
+	b.balance = math.Min(k.capacity, b.balance+now.Sub(b.lastTick).Seconds()*k.rate)
+	b.lastTick = now
+}
+
+// evictIdle removes the buckets that are full, since they behave as new ones
+func (k *keyedLimiter) evictIdle(now time.Time) {
+	k.lastEviction = now
+	for key, b := range k.buckets {
+		k.refill(b, now)
+		if b.balance >= k.capacity {
+			delete(k.buckets, key)
+		}
+	}
+}
+
+// retryAfter returns the time until the bucket can pay for the cost, zero if it can pay now
+func (k *keyedLimiter) retryAfter(b *bucket, cost float64) time.Duration {
+	required := math.Min(cost, k.capacity)
+	if b.balance >= required {
+		return 0
+	}
+	return time.Duration((required - b.balance) / k.rate * float64(time.Second))
+}
+
+// Limiter limits the rate of spans accepted from each service and from each client.
+// A nil Limiter accepts all spans.
+type Limiter struct {
+	lock         sync.Mutex
+	services     *keyedLimiter
+	clients      *keyedLimiter
+	clientHeader string
+	timeNow      func() time.Time
+}
+
+// NewLimiter creates a Limiter, it returns nil if neither the service nor the client rate is limited.
+func NewLimiter(options Options, metricsFactory metrics.Factory) *Limiter {
+	if options.ServiceSpansPerSecond <= 0 && options.ClientSpansPerSecond <= 0 {
+		return nil
+	}
+	if options.Burst <= 0 {
+		options.Burst = DefaultBurst
+	}
+	if options.MaxKeys <= 0 {
+		options.MaxKeys = DefaultMaxKeys
+	}
+	return &Limiter{
+		services:     newKeyedLimiter(ServiceLimit, options.ServiceSpansPerSecond, options, metricsFactory),
+		clients:      newKeyedLimiter(ClientLimit, options.ClientSpansPerSecond, options, metricsFactory),
+		clientHeader: http.CanonicalHeaderKey(options.ClientHeader),
+		timeNow:      time.Now,
+	}
+}
+
+// Admit checks that the spans sent by the client are within the limits of the client and of their services.
+// The spans are charged to the limits only if all of them are admitted, otherwise an *Error
+// with the longest retry hint is returned.
+func (l *Limiter) Admit(client string, spans []*model.Span) error {
+	if l == nil || len(spans) == 0 {
+		return nil
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := l.timeNow()
+
+	var rlErr *Error
+	check := func(k *keyedLimiter, key string, cost float64) *bucket {
+		key, b := k.bucket(key, now)
+		if retryAfter := k.retryAfter(b, cost); retryAfter > 0 && (rlErr == nil || retryAfter > rlErr.RetryAfter) {
+			rlErr = &Error{Limit: k.limit, Key: key, RetryAfter: retryAfter}
+		}
+		return b
+	}
+
+	var clientBucket *bucket
+	if l.clients != nil {
+		clientBucket = check(l.clients, client, float64(len(spans)))
+	}
+	var serviceCosts map[*bucket]float64
+	if l.services != nil {
+		spansByService := make(map[string]float64)
+		for _, span := range spans {
+			spansByService[serviceName(span)]++
+		}
+		serviceCosts = make(map[*bucket]float64, len(spansByService))
+		for service, cost := range spansByService {
+			// services beyond the max share the same bucket
+			serviceCosts[check(l.services, service, cost)] += cost
+		}
+	}
+
+	if rlErr != nil {
+		if rlErr.Limit == ClientLimit {
+			l.clients.rejected.Inc(int64(len(spans)))
+		} else {
+			l.services.rejected.Inc(int64(len(spans)))
+		}
+		return rlErr
+	}
+	if clientBucket != nil {
+		clientBucket.balance -= float64(len(spans))
+	}
+	for b, cost := range serviceCosts {
+		b.balance -= cost
+	}
+	return nil
+}
+
+// ClientFromHTTP returns the identity of the client sending the request
+func (l *Limiter) ClientFromHTTP(r *http.Request) string {
+	if l == nil {
+		return ""
+	}
+	if l.clientHeader != "" {
+		if client := r.Header.Get(l.clientHeader); client != "" {
+			return client
+		}
+	}
+	return hostOf(r.RemoteAddr)
+}
+
+// ClientFromGRPC returns the identity of the client sending the gRPC request
+func (l *Limiter) ClientFromGRPC(ctx context.Context) string {
+	if l == nil {
+		return ""
+	}
+	if l.clientHeader != "" {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(l.clientHeader); len(values) > 0 && values[0] != "" {
+				return values[0]
+			}
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return hostOf(p.Addr.String())
+	}
+	return ""
+}
+
+func hostOf(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+func serviceName(span *model.Span) string {
+	if span.Process == nil {
+		return ""
+	}
+	return span.Process.ServiceName
+}
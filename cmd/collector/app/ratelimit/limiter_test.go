@@ -0,0 +1,195 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func makeSpans(n int, service string) []*model.Span {
+	spans := make([]*model.Span, n)
+	for i := range spans {
+		spans[i] = &model.Span{Process: &model.Process{ServiceName: service}}
+	}
+	return spans
+}
+
+func newTestLimiter(options Options) (*Limiter, *metricstest.Factory, *time.Time) {
+	mFactory := metricstest.NewFactory(time.Hour)
+	l := NewLimiter(options, mFactory)
+	now := time.Unix(1000, 0)
+	l.timeNow = func() time.Time { return now }
+	return l, mFactory, &now
+}
+
+func TestNewLimiterDisabled(t *testing.T) {
+	l := NewLimiter(Options{}, metricstest.NewFactory(time.Hour))
+	assert.Nil(t, l)
+	assert.NoError(t, l.Admit("client", makeSpans(1000, "svc")))
+	assert.Equal(t, "", l.ClientFromHTTP(httptest.NewRequest(http.MethodPost, "/", nil)))
+	assert.Equal(t, "", l.ClientFromGRPC(context.Background()))
+}
+
+func TestServiceLimit(t *testing.T) {
+	l, mFactory, now := newTestLimiter(Options{ServiceSpansPerSecond: 10, Burst: time.Second})
+
+	require.NoError(t, l.Admit("", makeSpans(10, "noisy")))
+	err := l.Admit("", makeSpans(5, "noisy"))
+	require.Error(t, err)
+	rlErr, ok := AsError(err)
+	require.True(t, ok)
+	assert.Equal(t, &Error{Limit: ServiceLimit, Key: "noisy", RetryAfter: 500 * time.Millisecond}, rlErr)
+	assert.Equal(t, 1, rlErr.RetryAfterSeconds())
+
+	// other services are not affected by the noisy one
+	assert.NoError(t, l.Admit("", makeSpans(10, "quiet")))
+
+	*now = now.Add(500 * time.Millisecond)
+	assert.NoError(t, l.Admit("", makeSpans(5, "noisy")))
+
+	mFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "spans.rate-limited", Tags: map[string]string{"limit": "service"}, Value: 5,
+	})
+}
+
+func TestServiceLimitMixedBatch(t *testing.T) {
+	l, _, _ := newTestLimiter(Options{ServiceSpansPerSecond: 10, Burst: time.Second})
+
+	require.NoError(t, l.Admit("", makeSpans(8, "a")))
+	spans := append(makeSpans(5, "a"), makeSpans(5, "b")...)
+	err := l.Admit("", spans)
+	rlErr, ok := AsError(err)
+	require.True(t, ok)
+	assert.Equal(t, "a", rlErr.Key)
+
+	// rejected batches are not charged to any limit
+	assert.NoError(t, l.Admit("", makeSpans(10, "b")))
+}
+
+func TestClientLimit(t *testing.T) {
+	l, mFactory, _ := newTestLimiter(Options{ClientSpansPerSecond: 4, Burst: 2 * time.Second})
+
+	require.NoError(t, l.Admit("client-1", append(makeSpans(4, "a"), makeSpans(4, "b")...)))
+	err := l.Admit("client-1", makeSpans(2, "c"))
+	rlErr, ok := AsError(err)
+	require.True(t, ok)
+	assert.Equal(t, &Error{Limit: ClientLimit, Key: "client-1", RetryAfter: 500 * time.Millisecond}, rlErr)
+	assert.NoError(t, l.Admit("client-2", makeSpans(8, "c")))
+
+	mFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "spans.rate-limited", Tags: map[string]string{"limit": "client"}, Value: 2,
+	})
+}
+
+func TestLongestRetryHint(t *testing.T) {
+	l, _, _ := newTestLimiter(Options{ServiceSpansPerSecond: 10, ClientSpansPerSecond: 1, Burst: time.Second})
+
+	require.NoError(t, l.Admit("client", makeSpans(1, "svc")))
+	require.NoError(t, l.Admit("other-client", makeSpans(9, "svc")))
+	err := l.Admit("client", makeSpans(1, "svc"))
+	rlErr, ok := AsError(err)
+	require.True(t, ok)
+	assert.Equal(t, ClientLimit, rlErr.Limit)
+	assert.Equal(t, time.Second, rlErr.RetryAfter)
+}
+
+func TestBatchLargerThanBurst(t *testing.T) {
+	l, _, now := newTestLimiter(Options{ServiceSpansPerSecond: 10, Burst: time.Second})
+
+	// a full bucket admits a batch larger than its capacity and goes into debt
+	require.NoError(t, l.Admit("", makeSpans(30, "svc")))
+	err := l.Admit("", makeSpans(1, "svc"))
+	rlErr, ok := AsError(err)
+	require.True(t, ok)
+	assert.Equal(t, 2100*time.Millisecond, rlErr.RetryAfter)
+
+	*now = now.Add(2100 * time.Millisecond)
+	assert.NoError(t, l.Admit("", makeSpans(1, "svc")))
+}
+
+func TestMaxKeys(t *testing.T) {
+	l, _, now := newTestLimiter(Options{ServiceSpansPerSecond: 10, Burst: time.Second, MaxKeys: 2})
+
+	require.NoError(t, l.Admit("", makeSpans(10, "a")))
+	require.NoError(t, l.Admit("", makeSpans(5, "b")))
+	// services beyond the max share a single limit
+	require.NoError(t, l.Admit("", makeSpans(10, "c")))
+	err := l.Admit("", makeSpans(1, "d"))
+	rlErr, ok := AsError(err)
+	require.True(t, ok)
+	assert.Equal(t, otherKey, rlErr.Key)
+	assert.Len(t, l.services.buckets, 3)
+
+	// idle services are evicted to make room for new ones
+	*now = now.Add(time.Second)
+	require.NoError(t, l.Admit("", makeSpans(1, "d")))
+	assert.Len(t, l.services.buckets, 1)
+	assert.Contains(t, l.services.buckets, "d")
+}
+
+func TestErrorGRPCStatus(t *testing.T) {
+	err := &Error{Limit: ServiceLimit, Key: "svc", RetryAfter: 1500 * time.Millisecond}
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	assert.Equal(t, err.Error(), st.Message())
+	require.Len(t, st.Details(), 1)
+	retryInfo, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	assert.Equal(t, 1500*time.Millisecond, retryInfo.RetryDelay.AsDuration())
+}
+
+func TestWriteHTTPError(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteHTTPError(w, &Error{Limit: ClientLimit, Key: "client", RetryAfter: 1500 * time.Millisecond})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), `rate limit of client "client" exceeded`)
+}
+
+func TestClientFromHTTP(t *testing.T) {
+	l := NewLimiter(Options{ClientSpansPerSecond: 1, ClientHeader: "x-client-id"}, metricstest.NewFactory(time.Hour))
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	assert.Equal(t, "10.0.0.1", l.ClientFromHTTP(r))
+	r.Header.Set("X-Client-Id", "client-1")
+	assert.Equal(t, "client-1", l.ClientFromHTTP(r))
+}
+
+func TestClientFromGRPC(t *testing.T) {
+	l := NewLimiter(Options{ClientSpansPerSecond: 1, ClientHeader: "X-Client-Id"}, metricstest.NewFactory(time.Hour))
+	assert.Equal(t, "", l.ClientFromGRPC(context.Background()))
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}})
+	assert.Equal(t, "10.0.0.1", l.ClientFromGRPC(ctx))
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-client-id", "client-1"))
+	assert.Equal(t, "client-1", l.ClientFromGRPC(ctx))
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"flag"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	rateLimitServiceSpansPerSecond = "collector.rate-limit.service-spans-per-second"
+	rateLimitClientSpansPerSecond  = "collector.rate-limit.client-spans-per-second"
+	rateLimitBurst                 = "collector.rate-limit.burst"
+	rateLimitClientHeader          = "collector.rate-limit.client-header"
+	rateLimitMaxKeys               = "collector.rate-limit.max-keys"
+	rateLimitRejectWhenBusy        = "collector.rate-limit.reject-when-queue-full"
+
+	// DefaultBurst is the default burst allowance, expressed as the duration of traffic at the limit
+	DefaultBurst = time.Second
+	// DefaultMaxKeys is the default number of services and of clients with their own limit
+	DefaultMaxKeys = 10000
+)
+
+// Options holds configuration for the admission control of spans at the collector endpoints.
+type Options struct {
+	// ServiceSpansPerSecond is the max rate of spans of a single service, zero means no limit
+	ServiceSpansPerSecond float64
+	// ClientSpansPerSecond is the max rate of spans of a single client, zero means no limit
+	ClientSpansPerSecond float64
+	// Burst is the duration of traffic at the limit that can be accepted at once after a period of inactivity
+	Burst time.Duration
+	// ClientHeader is the HTTP header or gRPC metadata key identifying the client,
+	// the remote IP address is used if it is empty or missing in the request
+	ClientHeader string
+	// MaxKeys is the number of services and of clients with their own limit, the others share a single limit
+	MaxKeys int
+	// RejectWhenBusy rejects spans with a retry hint instead of dropping them when the queue is full
+	RejectWhenBusy bool
+}
+
+// AddFlags adds flags for Options
+func AddFlags(flagSet *flag.FlagSet) {
+	flagSet.Float64(rateLimitServiceSpansPerSecond, 0, "The max number of spans per second accepted from a single service, zero means no limit. Spans over the limit are rejected with HTTP 429 or gRPC RESOURCE_EXHAUSTED")
+	flagSet.Float64(rateLimitClientSpansPerSecond, 0, "The max number of spans per second accepted from a single client, zero means no limit. Spans over the limit are rejected with HTTP 429 or gRPC RESOURCE_EXHAUSTED")
+	flagSet.Duration(rateLimitBurst, DefaultBurst, "The burst allowance of the rate limits, expressed as the duration of traffic at the limit that is accepted at once")
+	flagSet.String(rateLimitClientHeader, "", "The HTTP header or gRPC metadata key identifying a client for the client rate limit. The remote IP address is used if empty or missing in the request")
+	flagSet.Int(rateLimitMaxKeys, DefaultMaxKeys, "The max number of services and of clients with their own rate limit, the others share a single limit")
+	flagSet.Bool(rateLimitRejectWhenBusy, false, "Reject spans with HTTP 429 or gRPC RESOURCE_EXHAUSTED when the queue of the collector is full, instead of accepting and dropping them")
+}
+
+// InitFromViper initializes Options with properties from viper
+func (opts *Options) InitFromViper(v *viper.Viper) *Options {
+	opts.ServiceSpansPerSecond = v.GetFloat64(rateLimitServiceSpansPerSecond)
+	opts.ClientSpansPerSecond = v.GetFloat64(rateLimitClientSpansPerSecond)
+	opts.Burst = v.GetDuration(rateLimitBurst)
+	opts.ClientHeader = v.GetString(rateLimitClientHeader)
+	opts.MaxKeys = v.GetInt(rateLimitMaxKeys)
+	opts.RejectWhenBusy = v.GetBool(rateLimitRejectWhenBusy)
+	return opts
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jaegertracing/jaeger/pkg/config"
+)
+
+func TestOptionsWithFlags(t *testing.T) {
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.rate-limit.service-spans-per-second=100",
+		"--collector.rate-limit.client-spans-per-second=50.5",
+		"--collector.rate-limit.burst=5s",
+		"--collector.rate-limit.client-header=x-client-id",
+		"--collector.rate-limit.max-keys=10",
+		"--collector.rate-limit.reject-when-queue-full=true",
+	})
+	opts := new(Options).InitFromViper(v)
+	assert.Equal(t, 100.0, opts.ServiceSpansPerSecond)
+	assert.Equal(t, 50.5, opts.ClientSpansPerSecond)
+	assert.Equal(t, 5*time.Second, opts.Burst)
+	assert.Equal(t, "x-client-id", opts.ClientHeader)
+	assert.Equal(t, 10, opts.MaxKeys)
+	assert.True(t, opts.RejectWhenBusy)
+}
+
+func TestOptionsDefaults(t *testing.T) {
+	v, _ := config.Viperize(AddFlags)
+	opts := new(Options).InitFromViper(v)
+	assert.Equal(t, Options{Burst: DefaultBurst, MaxKeys: DefaultMaxKeys}, *opts)
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func TestHandleRootSpan(t *testing.T) {
+	aggregator := &mockAggregator{}
+	processor := handleRootSpan(aggregator)
+
+	samplerTags := model.KeyValues{
+		model.String("sampler.type", "probabilistic"),
+		model.Float64("sampler.param", 0.001),
+	}
+
+	// Testing non-root span
+	span := &model.Span{References: []model.SpanRef{{SpanID: model.NewSpanID(1), RefType: model.ChildOf}}}
+	processor(span)
+	assert.EqualValues(t, 0, aggregator.callCount.Load())
+
+	// Testing span with service name but no operation
+	span.References = []model.SpanRef{}
+	span.Process = &model.Process{
+		ServiceName: "service",
+	}
+	processor(span)
+	assert.EqualValues(t, 0, aggregator.callCount.Load())
+
+	// Testing span with service name and operation but no sampler tags
+	span.OperationName = "GET"
+	processor(span)
+	assert.EqualValues(t, 0, aggregator.callCount.Load())
+
+	// Testing span with service name, operation, and sampler tags
+	span.Tags = samplerTags
+	processor(span)
+	assert.EqualValues(t, 1, aggregator.callCount.Load())
+}
@@ -17,20 +17,30 @@ package sampling
 import (
 	"context"
 
+	"github.com/gogo/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
 	"github.com/jaegertracing/jaeger/model/converter/thrift/jaeger"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
 )
 
+// maxSubscribedServices bounds the services of a single subscription to sampling strategy updates.
+const maxSubscribedServices = 1000
+
 // GRPCHandler is sampling strategy handler for gRPC.
 type GRPCHandler struct {
-	store strategystore.StrategyStore
+	store    strategystore.StrategyStore
+	shutdown <-chan struct{}
 }
 
 // NewGRPCHandler creates a handler that controls sampling strategies for services.
-func NewGRPCHandler(store strategystore.StrategyStore) GRPCHandler {
+// The subscriptions to sampling strategy updates end when the shutdown channel is closed.
+func NewGRPCHandler(store strategystore.StrategyStore, shutdown <-chan struct{}) GRPCHandler {
 	return GRPCHandler{
-		store: store,
+		store:    store,
+		shutdown: shutdown,
 	}
 }
 
@@ -42,3 +52,57 @@ func (s GRPCHandler) GetSamplingStrategy(ctx context.Context, param *api_v2.Samp
 	}
 	return jaeger.ConvertSamplingResponseToDomain(r)
 }
+
+// Subscribe sends the current sampling strategies of the subscribed services, and then the
+// strategies that changed every time the store notifies of an update, until the stream is closed
+// or the handler is shut down.
+func (s GRPCHandler) Subscribe(req *api_v2.SamplingStrategiesSubscription, stream api_v2.SamplingStrategyUpdates_SubscribeServer) error {
+	notifier, ok := s.store.(strategystore.UpdateNotifier)
+	if !ok {
+		return status.Error(codes.Unimplemented, "the sampling strategy store does not support updates")
+	}
+	services := make([]string, 0, len(req.GetServiceNames()))
+	seen := make(map[string]struct{})
+	for _, service := range req.GetServiceNames() {
+		if _, ok := seen[service]; ok {
+			continue
+		}
+		seen[service] = struct{}{}
+		services = append(services, service)
+	}
+	if len(services) == 0 {
+		return status.Error(codes.InvalidArgument, "at least one service name is required")
+	}
+	if len(services) > maxSubscribedServices {
+		return status.Errorf(codes.InvalidArgument, "at most %d services can be subscribed to", maxSubscribedServices)
+	}
+
+	// subscribe before getting the current strategies so that no update is missed
+	updates, unsubscribe := notifier.Subscribe()
+	defer unsubscribe()
+
+	ctx := stream.Context()
+	sent := make(map[string]*api_v2.SamplingStrategyResponse, len(services))
+	for {
+		for _, service := range services {
+			r, err := s.GetSamplingStrategy(ctx, &api_v2.SamplingStrategyParameters{ServiceName: service})
+			if err != nil {
+				return err
+			}
+			if r == nil || proto.Equal(r, sent[service]) {
+				continue
+			}
+			if err := stream.Send(&api_v2.SamplingStrategyUpdate{ServiceName: service, Strategy: r}); err != nil {
+				return err
+			}
+			sent[service] = r
+		}
+		select {
+		case <-updates:
+		case <-ctx.Done():
+			return nil
+		case <-s.shutdown:
+			return status.Error(codes.Unavailable, "the collector is shutting down")
+		}
+	}
+}
@@ -16,12 +16,19 @@ package sampling
 
 import (
 	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
@@ -47,7 +54,7 @@ func TestNewGRPCHandler(t *testing.T) {
 		{req: &api_v2.SamplingStrategyParameters{ServiceName: "nil"}, resp: nil},
 		{req: &api_v2.SamplingStrategyParameters{ServiceName: "foo"}, resp: &api_v2.SamplingStrategyResponse{StrategyType: api_v2.SamplingStrategyType_PROBABILISTIC}},
 	}
-	h := NewGRPCHandler(mockSamplingStore{})
+	h := NewGRPCHandler(mockSamplingStore{}, nil)
 	for _, test := range tests {
 		resp, err := h.GetSamplingStrategy(context.Background(), test.req)
 		if test.err != "" {
@@ -59,3 +66,111 @@ func TestNewGRPCHandler(t *testing.T) {
 		}
 	}
 }
+
+type notifyingSamplingStore struct {
+	strategystore.Notifier
+	mux   sync.Mutex
+	rates map[string]float64
+}
+
+func (s *notifyingSamplingStore) GetSamplingStrategy(ctx context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if serviceName == "error" {
+		return nil, errors.New("some error")
+	}
+	return &sampling.SamplingStrategyResponse{
+		StrategyType:          sampling.SamplingStrategyType_PROBABILISTIC,
+		ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: s.rates[serviceName]},
+	}, nil
+}
+
+func (s *notifyingSamplingStore) setRate(serviceName string, rate float64) {
+	s.mux.Lock()
+	s.rates[serviceName] = rate
+	s.mux.Unlock()
+	s.Notify()
+}
+
+type mockUpdatesStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	updates chan *api_v2.SamplingStrategyUpdate
+}
+
+func (s *mockUpdatesStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *mockUpdatesStream) Send(u *api_v2.SamplingStrategyUpdate) error {
+	s.updates <- u
+	return nil
+}
+
+func (s *mockUpdatesStream) next(t *testing.T) *api_v2.SamplingStrategyUpdate {
+	select {
+	case u := <-s.updates:
+		return u
+	case <-time.After(5 * time.Second):
+		t.Fatal("no sampling strategy update received")
+		return nil
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	store := &notifyingSamplingStore{rates: map[string]float64{"foo": 0.1, "bar": 0.2}}
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &mockUpdatesStream{ctx: ctx, updates: make(chan *api_v2.SamplingStrategyUpdate, 10)}
+	done := make(chan error)
+	go func() {
+		done <- NewGRPCHandler(store, nil).Subscribe(&api_v2.SamplingStrategiesSubscription{ServiceNames: []string{"foo", "bar", "foo"}}, stream)
+	}()
+
+	rate := func(u *api_v2.SamplingStrategyUpdate) float64 {
+		return u.Strategy.ProbabilisticSampling.SamplingRate
+	}
+	u := stream.next(t)
+	assert.Equal(t, "foo", u.ServiceName)
+	assert.Equal(t, 0.1, rate(u))
+	u = stream.next(t)
+	assert.Equal(t, "bar", u.ServiceName)
+	assert.Equal(t, 0.2, rate(u))
+
+	// only the strategies that changed are sent
+	store.setRate("baz", 0.3)
+	store.setRate("bar", 0.4)
+	u = stream.next(t)
+	assert.Equal(t, "bar", u.ServiceName)
+	assert.Equal(t, 0.4, rate(u))
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Len(t, stream.updates, 0)
+}
+
+func TestSubscribeErrors(t *testing.T) {
+	tooMany := make([]string, maxSubscribedServices+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("service-%d", i)
+	}
+	tests := []struct {
+		store    strategystore.StrategyStore
+		services []string
+		code     codes.Code
+		err      string
+	}{
+		{store: mockSamplingStore{}, services: []string{"foo"}, code: codes.Unimplemented},
+		{store: &notifyingSamplingStore{}, services: nil, code: codes.InvalidArgument},
+		{store: &notifyingSamplingStore{}, services: tooMany, code: codes.InvalidArgument},
+		{store: &notifyingSamplingStore{}, services: []string{"error"}, err: "some error"},
+	}
+	for _, test := range tests {
+		stream := &mockUpdatesStream{ctx: context.Background(), updates: make(chan *api_v2.SamplingStrategyUpdate, 10)}
+		err := NewGRPCHandler(test.store, nil).Subscribe(&api_v2.SamplingStrategiesSubscription{ServiceNames: test.services}, stream)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err)
+		} else {
+			assert.Equal(t, test.code, status.Code(err))
+		}
+	}
+}
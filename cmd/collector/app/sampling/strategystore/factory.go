@@ -17,6 +17,8 @@ package strategystore
 import (
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/storage"
 )
 
 // Factory defines an interface for a factory that can create implementations of different strategy storage components.
@@ -27,8 +29,10 @@ import (
 // plugin.Configurable
 type Factory interface {
 	// Initialize performs internal initialization of the factory.
-	Initialize(metricsFactory metrics.Factory, logger *zap.Logger) error
+	// The ssFactory may be nil if the storage backend does not support adaptive sampling.
+	Initialize(metricsFactory metrics.Factory, ssFactory storage.SamplingStoreFactory, logger *zap.Logger) error
 
-	// CreateStrategyStore initializes the StrategyStore and returns it.
-	CreateStrategyStore() (StrategyStore, error)
+	// CreateStrategyStore initializes the StrategyStore and returns it. The returned Aggregator
+	// may be nil if the strategy store does not need to observe span throughput.
+	CreateStrategyStore() (StrategyStore, Aggregator, error)
 }
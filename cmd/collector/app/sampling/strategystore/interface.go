@@ -16,6 +16,7 @@ package strategystore
 
 import (
 	"context"
+	"io"
 
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
@@ -25,3 +26,24 @@ type StrategyStore interface {
 	// GetSamplingStrategy retrieves the sampling strategy for the specified service.
 	GetSamplingStrategy(ctx context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error)
 }
+
+// UpdateNotifier is implemented by strategy stores that notify when their strategies change,
+// e.g. when the strategies file is reloaded or adaptive probabilities are recalculated.
+type UpdateNotifier interface {
+	// Subscribe returns a channel receiving a signal after the strategies change, and a function
+	// to unsubscribe. Signals are coalesced, so that subscribers only learn that strategies may
+	// have changed, and must get the strategies of the services they are interested in again.
+	Subscribe() (<-chan struct{}, func())
+}
+
+// Aggregator defines an interface used to aggregate operation throughput.
+type Aggregator interface {
+	// Close() from io.Closer stops the aggregator from aggregating throughput.
+	io.Closer
+
+	// RecordThroughput records throughput for an operation for aggregation.
+	RecordThroughput(service, operation, samplerType string, probability float64)
+
+	// Start starts aggregating operation throughput.
+	Start()
+}
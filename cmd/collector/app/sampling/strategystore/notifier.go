@@ -0,0 +1,56 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package strategystore
+
+import (
+	"sync"
+)
+
+// Notifier notifies subscribers when the strategies of a strategy store change.
+// Strategy stores implement UpdateNotifier by embedding it and calling Notify
+// after every change. The zero value is ready to use.
+type Notifier struct {
+	mux         sync.Mutex
+	subscribers map[chan struct{}]struct{}
+}
+
+// Subscribe implements UpdateNotifier.
+func (n *Notifier) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	if n.subscribers == nil {
+		n.subscribers = make(map[chan struct{}]struct{})
+	}
+	n.subscribers[ch] = struct{}{}
+	return ch, func() {
+		n.mux.Lock()
+		defer n.mux.Unlock()
+		delete(n.subscribers, ch)
+	}
+}
+
+// Notify signals every subscriber without blocking. A subscriber that has not consumed
+// the previous signal yet receives a single signal for both changes.
+func (n *Notifier) Notify() {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	for ch := range n.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package strategystore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifier(t *testing.T) {
+	var n Notifier
+	n.Notify() // no subscribers
+
+	ch1, unsubscribe1 := n.Subscribe()
+	ch2, unsubscribe2 := n.Subscribe()
+
+	n.Notify()
+	n.Notify() // coalesced with the previous signal
+	for _, ch := range []<-chan struct{}{ch1, ch2} {
+		assert.Len(t, ch, 1)
+		<-ch
+	}
+
+	unsubscribe1()
+	n.Notify()
+	assert.Len(t, ch1, 0)
+	assert.Len(t, ch2, 1)
+	unsubscribe2()
+}
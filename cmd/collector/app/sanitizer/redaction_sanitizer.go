@@ -0,0 +1,225 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sanitizer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// RedactionAction defines what happens to a tag or log field matched by a RedactionRule.
+type RedactionAction string
+
+const (
+	// RedactionActionRemove removes the matched tag or log field.
+	RedactionActionRemove RedactionAction = "remove"
+	// RedactionActionMask replaces the matched value, or the matched parts of it, with a mask.
+	RedactionActionMask RedactionAction = "mask"
+	// RedactionActionHash replaces the matched value, or the matched parts of it, with a salted
+	// SHA-256 hash, which keeps equal values correlatable without revealing them.
+	RedactionActionHash RedactionAction = "hash"
+
+	defaultRedactionMask = "****"
+	hashPrefix           = "sha256:"
+)
+
+// RedactionRule describes which tags and log fields to redact and how.
+// A rule matches when the key matches KeyPattern (if set) and the value contains
+// a match of ValuePattern (if set). At least one of the patterns must be set.
+type RedactionRule struct {
+	Name         string
+	KeyPattern   *regexp.Regexp
+	ValuePattern *regexp.Regexp
+	Action       RedactionAction
+}
+
+// RedactionConfig holds the redaction rules and their shared settings.
+type RedactionConfig struct {
+	Rules []RedactionRule
+	// Mask is the replacement used by the mask action
+	Mask string
+	// HashSalt is prepended to values before hashing
+	HashSalt string
+}
+
+type redactionRuleJSON struct {
+	Name         string `json:"name"`
+	KeyPattern   string `json:"key_pattern,omitempty"`
+	ValuePattern string `json:"value_pattern,omitempty"`
+	Action       string `json:"action"`
+}
+
+type redactionConfigJSON struct {
+	Mask     string              `json:"mask,omitempty"`
+	HashSalt string              `json:"hash_salt,omitempty"`
+	Rules    []redactionRuleJSON `json:"rules"`
+}
+
+// LoadRedactionConfig reads redaction rules from a JSON file.
+func LoadRedactionConfig(path string) (*RedactionConfig, error) {
+	data, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open redaction rules file: %w", err)
+	}
+	var cfgJSON redactionConfigJSON
+	if err := json.Unmarshal(data, &cfgJSON); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal redaction rules: %w", err)
+	}
+	cfg := &RedactionConfig{Mask: cfgJSON.Mask, HashSalt: cfgJSON.HashSalt}
+	for i, r := range cfgJSON.Rules {
+		rule, err := parseRedactionRule(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction rule #%d %q: %w", i, r.Name, err)
+		}
+		cfg.Rules = append(cfg.Rules, rule)
+	}
+	return cfg, nil
+}
+
+func parseRedactionRule(r redactionRuleJSON) (RedactionRule, error) {
+	rule := RedactionRule{Name: r.Name, Action: RedactionAction(r.Action)}
+	switch rule.Action {
+	case RedactionActionRemove, RedactionActionMask, RedactionActionHash:
+	default:
+		return rule, fmt.Errorf("unknown action %q", r.Action)
+	}
+	if r.KeyPattern == "" && r.ValuePattern == "" {
+		return rule, fmt.Errorf("one of key_pattern or value_pattern must be set")
+	}
+	var err error
+	if r.KeyPattern != "" {
+		if rule.KeyPattern, err = regexp.Compile(r.KeyPattern); err != nil {
+			return rule, fmt.Errorf("cannot compile key_pattern: %w", err)
+		}
+	}
+	if r.ValuePattern != "" {
+		if rule.ValuePattern, err = regexp.Compile(r.ValuePattern); err != nil {
+			return rule, fmt.Errorf("cannot compile value_pattern: %w", err)
+		}
+	}
+	return rule, nil
+}
+
+// redactionSanitizer redacts span tags, log fields and process tags
+type redactionSanitizer struct {
+	rules    []RedactionRule
+	mask     string
+	hashSalt string
+}
+
+// NewRedactionSanitizer creates a sanitizer that hashes, masks or removes tag and log field values
+// matching the configured rules, across span tags, span logs and process tags.
+func NewRedactionSanitizer(cfg RedactionConfig) SanitizeSpan {
+	s := redactionSanitizer{
+		rules:    cfg.Rules,
+		mask:     cfg.Mask,
+		hashSalt: cfg.HashSalt,
+	}
+	if s.mask == "" {
+		s.mask = defaultRedactionMask
+	}
+	return s.Sanitize
+}
+
+// Sanitize redacts the span. Modified tag slices and processes are copied rather than updated in place,
+// because a Process may be shared by all spans of a batch and hashing is not idempotent.
+func (s *redactionSanitizer) Sanitize(span *model.Span) *model.Span {
+	if tags, changed := s.redactKVs(span.Tags); changed {
+		span.Tags = tags
+	}
+	for i := range span.Logs {
+		if fields, changed := s.redactKVs(span.Logs[i].Fields); changed {
+			span.Logs[i].Fields = fields
+		}
+	}
+	if span.Process != nil {
+		if tags, changed := s.redactKVs(span.Process.Tags); changed {
+			span.Process = &model.Process{
+				ServiceName: span.Process.ServiceName,
+				Tags:        tags,
+			}
+		}
+	}
+	return span
+}
+
+func (s *redactionSanitizer) redactKVs(kvs model.KeyValues) (model.KeyValues, bool) {
+	var out model.KeyValues
+	changed := false
+	for i, kv := range kvs {
+		redacted, keep, modified := s.redactKV(kv)
+		if !modified {
+			if changed {
+				out = append(out, kv)
+			}
+			continue
+		}
+		if !changed {
+			out = make(model.KeyValues, i, len(kvs))
+			copy(out, kvs[:i])
+			changed = true
+		}
+		if keep {
+			out = append(out, redacted)
+		}
+	}
+	if !changed {
+		return kvs, false
+	}
+	return out, true
+}
+
+// redactKV applies all matching rules in order. It returns the redacted value,
+// whether the value should be kept, and whether it was modified at all.
+func (s *redactionSanitizer) redactKV(kv model.KeyValue) (model.KeyValue, bool, bool) {
+	modified := false
+	for _, rule := range s.rules {
+		if rule.KeyPattern != nil && !rule.KeyPattern.MatchString(kv.Key) {
+			continue
+		}
+		if rule.ValuePattern != nil && (kv.VType == model.BinaryType || !rule.ValuePattern.MatchString(kv.AsString())) {
+			continue
+		}
+		if rule.Action == RedactionActionRemove {
+			return kv, false, true
+		}
+		kv = model.String(kv.Key, s.replace(rule, kv.AsString()))
+		modified = true
+	}
+	return kv, true, modified
+}
+
+func (s *redactionSanitizer) replace(rule RedactionRule, value string) string {
+	replaceFn := func(string) string { return s.mask }
+	if rule.Action == RedactionActionHash {
+		replaceFn = s.hash
+	}
+	if rule.ValuePattern == nil {
+		return replaceFn(value)
+	}
+	return rule.ValuePattern.ReplaceAllStringFunc(value, replaceFn)
+}
+
+func (s *redactionSanitizer) hash(value string) string {
+	sum := sha256.Sum256([]byte(s.hashSalt + value))
+	return hashPrefix + hex.EncodeToString(sum[:])
+}
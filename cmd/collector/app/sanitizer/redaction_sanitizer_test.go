@@ -0,0 +1,155 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sanitizer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func loadTestRedactionSanitizer(t *testing.T) SanitizeSpan {
+	cfg, err := LoadRedactionConfig("fixtures/redaction_rules.json")
+	require.NoError(t, err)
+	return NewRedactionSanitizer(*cfg)
+}
+
+func TestLoadRedactionConfig(t *testing.T) {
+	cfg, err := LoadRedactionConfig("fixtures/redaction_rules.json")
+	require.NoError(t, err)
+	assert.Equal(t, "[REDACTED]", cfg.Mask)
+	assert.Equal(t, "pepper", cfg.HashSalt)
+	require.Len(t, cfg.Rules, 5)
+	assert.Equal(t, "authorization", cfg.Rules[0].Name)
+	assert.Equal(t, RedactionActionRemove, cfg.Rules[0].Action)
+	assert.NotNil(t, cfg.Rules[0].KeyPattern)
+	assert.Nil(t, cfg.Rules[0].ValuePattern)
+}
+
+func TestLoadRedactionConfigErrors(t *testing.T) {
+	_, err := LoadRedactionConfig("fixtures/missing.json")
+	assert.Contains(t, err.Error(), "failed to open redaction rules file")
+
+	_, err = LoadRedactionConfig("fixtures/redaction_bad_action.json")
+	assert.EqualError(t, err, `invalid redaction rule #0 "scramble": unknown action "scramble"`)
+}
+
+func TestParseRedactionRule(t *testing.T) {
+	tests := []struct {
+		rule redactionRuleJSON
+		err  string
+	}{
+		{rule: redactionRuleJSON{Action: "mask", KeyPattern: "password"}},
+		{rule: redactionRuleJSON{Action: "hash", ValuePattern: "\\d+"}},
+		{rule: redactionRuleJSON{Action: "remove"}, err: "one of key_pattern or value_pattern must be set"},
+		{rule: redactionRuleJSON{Action: "mask", KeyPattern: "("}, err: "cannot compile key_pattern"},
+		{rule: redactionRuleJSON{Action: "mask", ValuePattern: "("}, err: "cannot compile value_pattern"},
+		{rule: redactionRuleJSON{Action: "", KeyPattern: "password"}, err: `unknown action ""`},
+	}
+	for _, test := range tests {
+		_, err := parseRedactionRule(test.rule)
+		if test.err == "" {
+			assert.NoError(t, err)
+		} else {
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		}
+	}
+}
+
+func TestRedactionSanitizerTags(t *testing.T) {
+	sanitize := loadTestRedactionSanitizer(t)
+	sum := sha256.Sum256([]byte("pepper" + "42"))
+	tests := []struct {
+		tags     []model.KeyValue
+		expected []model.KeyValue
+	}{
+		{
+			tags:     []model.KeyValue{model.String("http.method", "GET")},
+			expected: []model.KeyValue{model.String("http.method", "GET")},
+		},
+		{
+			tags:     []model.KeyValue{model.String("Authorization", "Bearer xyz"), model.String("http.method", "GET")},
+			expected: []model.KeyValue{model.String("http.method", "GET")},
+		},
+		{
+			tags:     []model.KeyValue{model.String("http.url", "/users?email=jane.doe@example.com&page=2")},
+			expected: []model.KeyValue{model.String("http.url", "/users?email=[REDACTED]&page=2")},
+		},
+		{
+			tags:     []model.KeyValue{model.String("payment", "card 4111 1111 1111 1111 accepted")},
+			expected: []model.KeyValue{model.String("payment", "card [REDACTED] accepted")},
+		},
+		{
+			tags:     []model.KeyValue{model.String("db.statement", "SELECT * FROM users WHERE name = 'jane'")},
+			expected: []model.KeyValue{model.String("db.statement", "SELECT * FROM users WHERE name = [REDACTED]")},
+		},
+		{
+			tags:     []model.KeyValue{model.String("sql", "name = 'jane'")},
+			expected: []model.KeyValue{model.String("sql", "name = 'jane'")},
+		},
+		{
+			tags:     []model.KeyValue{model.Int64("user.id", 42)},
+			expected: []model.KeyValue{model.String("user.id", "sha256:"+hex.EncodeToString(sum[:]))},
+		},
+		{
+			tags:     []model.KeyValue{model.Binary("blob", []byte("jane.doe@example.com"))},
+			expected: []model.KeyValue{model.Binary("blob", []byte("jane.doe@example.com"))},
+		},
+	}
+	for _, test := range tests {
+		span := sanitize(&model.Span{Tags: test.tags})
+		assert.Equal(t, test.expected, span.Tags)
+	}
+}
+
+func TestRedactionSanitizerLogsAndProcess(t *testing.T) {
+	sanitize := loadTestRedactionSanitizer(t)
+	process := &model.Process{
+		ServiceName: "frontend",
+		Tags:        []model.KeyValue{model.String("cookie", "session=abc"), model.String("hostname", "host1")},
+	}
+	span1 := &model.Span{
+		Process: process,
+		Logs: []model.Log{{
+			Fields: []model.KeyValue{model.String("event", "login"), model.String("email", "jane@example.com")},
+		}},
+	}
+	span2 := &model.Span{Process: process}
+
+	span1 = sanitize(span1)
+	span2 = sanitize(span2)
+
+	assert.Equal(t, []model.KeyValue{model.String("event", "login"), model.String("email", "[REDACTED]")}, span1.Logs[0].Fields)
+	expectedProcess := &model.Process{ServiceName: "frontend", Tags: []model.KeyValue{model.String("hostname", "host1")}}
+	assert.Equal(t, expectedProcess, span1.Process)
+	assert.Equal(t, expectedProcess, span2.Process)
+	// the shared process must not be modified in place
+	assert.Len(t, process.Tags, 2)
+}
+
+func TestRedactionSanitizerDefaultMask(t *testing.T) {
+	sanitize := NewRedactionSanitizer(RedactionConfig{
+		Rules: []RedactionRule{{KeyPattern: regexp.MustCompile("password"), Action: RedactionActionMask}},
+	})
+	span := sanitize(&model.Span{Tags: []model.KeyValue{model.String("password", "hunter2")}})
+	assert.Equal(t, []model.KeyValue{model.String("password", defaultRedactionMask)}, span.Tags)
+}
@@ -21,12 +21,16 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	_ "google.golang.org/grpc/encoding/gzip" // register gzip to accept compressed spans from agents
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/baggage"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
 	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
+	_ "github.com/jaegertracing/jaeger/pkg/gogocodec" // force gogo codec registration
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	tBaggage "github.com/jaegertracing/jaeger/thrift-gen/baggage"
 )
 
 // GRPCServerParams to construct a new Jaeger Collector gRPC Server
@@ -35,8 +39,13 @@ type GRPCServerParams struct {
 	HostPort      string
 	Handler       *handler.GRPCHandler
 	SamplingStore strategystore.StrategyStore
-	Logger        *zap.Logger
-	OnError       func(error)
+	// BaggageManager serves baggage restrictions, the service is not registered if it is nil
+	BaggageManager tBaggage.BaggageRestrictionManager
+	// Shutdown is closed before the server is stopped to end the streams of sampling strategy updates,
+	// which a graceful stop would otherwise wait for
+	Shutdown <-chan struct{}
+	Logger   *zap.Logger
+	OnError  func(error)
 }
 
 // StartGRPCServer based on the given parameters
@@ -71,7 +80,14 @@ func StartGRPCServer(params *GRPCServerParams) (*grpc.Server, error) {
 
 func serveGRPC(server *grpc.Server, listener net.Listener, params *GRPCServerParams) error {
 	api_v2.RegisterCollectorServiceServer(server, params.Handler)
-	api_v2.RegisterSamplingManagerServer(server, sampling.NewGRPCHandler(params.SamplingStore))
+	samplingHandler := sampling.NewGRPCHandler(params.SamplingStore, params.Shutdown)
+	api_v2.RegisterSamplingManagerServer(server, samplingHandler)
+	if _, ok := params.SamplingStore.(strategystore.UpdateNotifier); ok {
+		api_v2.RegisterSamplingStrategyUpdatesServer(server, samplingHandler)
+	}
+	if params.BaggageManager != nil {
+		api_v2.RegisterBaggageRestrictionManagerServer(server, baggage.NewGRPCHandler(params.BaggageManager))
+	}
 
 	params.Logger.Info("Starting jaeger-collector gRPC server", zap.String("grpc.host-port", params.HostPort))
 	go func() {
@@ -19,6 +19,7 @@ import (
 	"net"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -26,10 +27,16 @@ import (
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
 
 // test wrong port number
@@ -37,7 +44,7 @@ func TestFailToListen(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 	server, err := StartGRPCServer(&GRPCServerParams{
 		HostPort:      ":-1",
-		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}),
+		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}, tenancy.NewManager(&tenancy.Options{}), nil),
 		SamplingStore: &mockSamplingStore{},
 		Logger:        logger,
 	})
@@ -54,7 +61,7 @@ func TestFailServe(t *testing.T) {
 
 	logger := zap.New(core)
 	serveGRPC(grpc.NewServer(), lis, &GRPCServerParams{
-		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}),
+		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}, tenancy.NewManager(&tenancy.Options{}), nil),
 		SamplingStore: &mockSamplingStore{},
 		Logger:        logger,
 		OnError: func(e error) {
@@ -69,7 +76,7 @@ func TestFailServe(t *testing.T) {
 func TestSpanCollector(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 	params := &GRPCServerParams{
-		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}),
+		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}, tenancy.NewManager(&tenancy.Options{}), nil),
 		SamplingStore: &mockSamplingStore{},
 		Logger:        logger,
 	}
@@ -92,3 +99,109 @@ func TestSpanCollector(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, response)
 }
+
+type mockBaggageManager struct{}
+
+func (mockBaggageManager) GetBaggageRestrictions(context.Context, string) ([]*baggage.BaggageRestriction, error) {
+	return []*baggage.BaggageRestriction{{BaggageKey: "session-id", MaxValueLength: 64}}, nil
+}
+
+func TestBaggageRestrictionManager(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	params := &GRPCServerParams{
+		Handler:        handler.NewGRPCHandler(logger, &mockSpanProcessor{}, tenancy.NewManager(&tenancy.Options{}), nil),
+		SamplingStore:  &mockSamplingStore{},
+		BaggageManager: mockBaggageManager{},
+		Logger:         logger,
+	}
+
+	server := grpc.NewServer()
+	defer server.Stop()
+
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	serveGRPC(server, listener, params)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	c := api_v2.NewBaggageRestrictionManagerClient(conn)
+	response, err := c.GetBaggageRestrictions(context.Background(), &api_v2.BaggageRestrictionsParameters{ServiceName: "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, []api_v2.BaggageRestriction{{BaggageKey: "session-id", MaxValueLength: 64}}, response.BaggageRestrictions)
+}
+
+type notifyingSamplingStore struct {
+	mockSamplingStore
+	strategystore.Notifier
+}
+
+func TestSamplingStrategyUpdates(t *testing.T) {
+	logger := zap.NewNop()
+	for _, store := range []strategystore.StrategyStore{&mockSamplingStore{}, &notifyingSamplingStore{}} {
+		server := grpc.NewServer()
+		listener, err := net.Listen("tcp", ":0")
+		require.NoError(t, err)
+		serveGRPC(server, listener, &GRPCServerParams{
+			Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}, tenancy.NewManager(&tenancy.Options{}), nil),
+			SamplingStore: store,
+			Logger:        logger,
+		})
+		_, registered := server.GetServiceInfo()["jaeger.api_v2.SamplingStrategyUpdates"]
+		_, notifies := store.(strategystore.UpdateNotifier)
+		assert.Equal(t, notifies, registered)
+		server.Stop()
+	}
+}
+
+type strategySamplingStore struct {
+	strategystore.Notifier
+}
+
+func (s *strategySamplingStore) GetSamplingStrategy(_ context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error) {
+	return &sampling.SamplingStrategyResponse{
+		StrategyType:          sampling.SamplingStrategyType_PROBABILISTIC,
+		ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 0.1},
+	}, nil
+}
+
+func TestGracefulStopWithSubscription(t *testing.T) {
+	logger := zap.NewNop()
+	shutdown := make(chan struct{})
+	server := grpc.NewServer()
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	serveGRPC(server, listener, &GRPCServerParams{
+		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}, tenancy.NewManager(&tenancy.Options{}), nil),
+		SamplingStore: &strategySamplingStore{},
+		Shutdown:      shutdown,
+		Logger:        logger,
+	})
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	stream, err := api_v2.NewSamplingStrategyUpdatesClient(conn).Subscribe(context.Background(),
+		&api_v2.SamplingStrategiesSubscription{ServiceNames: []string{"foo"}})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	close(shutdown)
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		server.Stop()
+		t.Fatal("graceful stop waited for the subscription")
+	}
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
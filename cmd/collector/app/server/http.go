@@ -23,10 +23,13 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/ratelimit"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
 	clientcfgHandler "github.com/jaegertracing/jaeger/pkg/clientcfg/clientcfghttp"
 	"github.com/jaegertracing/jaeger/pkg/healthcheck"
 	"github.com/jaegertracing/jaeger/pkg/recoveryhandler"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
 )
 
 // HTTPServerParams to construct a new Jaeger Collector HTTP Server
@@ -34,9 +37,12 @@ type HTTPServerParams struct {
 	HostPort       string
 	Handler        handler.JaegerBatchesHandler
 	SamplingStore  strategystore.StrategyStore
+	BaggageManager baggage.BaggageRestrictionManager
 	MetricsFactory metrics.Factory
 	HealthCheck    *healthcheck.HealthCheck
 	Logger         *zap.Logger
+	TenancyMgr     *tenancy.Manager
+	RateLimiter    *ratelimit.Limiter
 }
 
 // StartHTTPServer based on the given parameters
@@ -56,13 +62,13 @@ func StartHTTPServer(params *HTTPServerParams) (*http.Server, error) {
 
 func serveHTTP(server *http.Server, listener net.Listener, params *HTTPServerParams) {
 	r := mux.NewRouter()
-	apiHandler := handler.NewAPIHandler(params.Handler)
+	apiHandler := handler.NewAPIHandler(params.Handler, params.TenancyMgr, params.RateLimiter)
 	apiHandler.RegisterRoutes(r)
 
 	cfgHandler := clientcfgHandler.NewHTTPHandler(clientcfgHandler.HTTPHandlerParams{
 		ConfigManager: &clientcfgHandler.ConfigManager{
 			SamplingStrategyStore: params.SamplingStore,
-			// TODO provide baggage manager
+			BaggageManager:        params.BaggageManager,
 		},
 		MetricsFactory:         params.MetricsFactory,
 		BasePath:               "/api",
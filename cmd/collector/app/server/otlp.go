@@ -0,0 +1,113 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
+	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
+	"github.com/jaegertracing/jaeger/pkg/healthcheck"
+	"github.com/jaegertracing/jaeger/pkg/recoveryhandler"
+)
+
+// OTLPServerParams to construct the Jaeger Collector OTLP gRPC or HTTP Server
+type OTLPServerParams struct {
+	TLSConfig   tlscfg.Options
+	HostPort    string
+	Handler     *handler.OTLPHandler
+	HealthCheck *healthcheck.HealthCheck
+	Logger      *zap.Logger
+}
+
+// StartOTLPGRPCServer starts the OTLP/gRPC server based on the given parameters
+func StartOTLPGRPCServer(params *OTLPServerParams) (*grpc.Server, error) {
+	var server *grpc.Server
+	if params.TLSConfig.Enabled {
+		tlsCfg, err := params.TLSConfig.Config()
+		if err != nil {
+			return nil, err
+		}
+		server = grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsCfg)))
+	} else {
+		server = grpc.NewServer()
+	}
+
+	listener, err := net.Listen("tcp", params.HostPort)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on OTLP gRPC port: %w", err)
+	}
+
+	coltracepb.RegisterTraceServiceServer(server, params.Handler)
+
+	params.Logger.Info("Starting OTLP gRPC server", zap.String("otlp.grpc.host-port", params.HostPort))
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			params.Logger.Error("Could not launch OTLP gRPC service", zap.Error(err))
+			params.HealthCheck.Set(healthcheck.Unavailable)
+		}
+	}()
+
+	return server, nil
+}
+
+// StartOTLPHTTPServer starts the OTLP/HTTP server based on the given parameters
+func StartOTLPHTTPServer(params *OTLPServerParams) (*http.Server, error) {
+	listener, err := net.Listen("tcp", params.HostPort)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on OTLP HTTP port: %w", err)
+	}
+
+	if params.TLSConfig.Enabled {
+		tlsCfg, err := params.TLSConfig.Config()
+		if err != nil {
+			listener.Close()
+			return nil, err
+		}
+		listener = tls.NewListener(listener, tlsCfg)
+	}
+
+	server := &http.Server{Addr: params.HostPort}
+	serveOTLPHTTP(server, listener, params)
+
+	return server, nil
+}
+
+func serveOTLPHTTP(server *http.Server, listener net.Listener, params *OTLPServerParams) {
+	r := mux.NewRouter()
+	params.Handler.RegisterRoutes(r)
+
+	recoveryHandler := recoveryhandler.NewRecoveryHandler(params.Logger, true)
+	server.Handler = recoveryHandler(r)
+
+	params.Logger.Info("Starting OTLP HTTP server", zap.String("otlp.http.host-port", params.HostPort))
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			if err != http.ErrServerClosed {
+				params.Logger.Error("Could not launch OTLP HTTP service", zap.Error(err))
+			}
+		}
+		params.HealthCheck.Set(healthcheck.Unavailable)
+	}()
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
+	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
+	"github.com/jaegertracing/jaeger/pkg/healthcheck"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+)
+
+func newOTLPServerParams(hostPort string) *OTLPServerParams {
+	logger := zap.NewNop()
+	return &OTLPServerParams{
+		HostPort:    hostPort,
+		Handler:     handler.NewOTLPHandler(logger, &mockSpanProcessor{}, tenancy.NewManager(&tenancy.Options{}), nil),
+		HealthCheck: healthcheck.New(),
+		Logger:      logger,
+	}
+}
+
+func TestOTLPFailToListen(t *testing.T) {
+	grpcServer, err := StartOTLPGRPCServer(newOTLPServerParams(":-1"))
+	assert.Nil(t, grpcServer)
+	assert.EqualError(t, err, "failed to listen on OTLP gRPC port: listen tcp: address -1: invalid port")
+
+	httpServer, err := StartOTLPHTTPServer(newOTLPServerParams(":-1"))
+	assert.Nil(t, httpServer)
+	assert.EqualError(t, err, "failed to listen on OTLP HTTP port: listen tcp: address -1: invalid port")
+}
+
+func TestOTLPInvalidTLS(t *testing.T) {
+	params := newOTLPServerParams(":0")
+	params.TLSConfig = tlscfg.Options{
+		Enabled:  true,
+		CertPath: "invalid/path",
+		KeyPath:  "invalid/path",
+	}
+	grpcServer, err := StartOTLPGRPCServer(params)
+	assert.Nil(t, grpcServer)
+	assert.Error(t, err)
+
+	httpServer, err := StartOTLPHTTPServer(params)
+	assert.Nil(t, httpServer)
+	assert.Error(t, err)
+}
+
+func TestOTLPGRPCServerExport(t *testing.T) {
+	params := newOTLPServerParams("localhost:14317")
+	server, err := StartOTLPGRPCServer(params)
+	require.NoError(t, err)
+	defer server.Stop()
+	assert.Contains(t, server.GetServiceInfo(), "opentelemetry.proto.collector.trace.v1.TraceService")
+
+	conn, err := grpc.Dial(params.HostPort, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = coltracepb.NewTraceServiceClient(conn).Export(context.Background(), &coltracepb.ExportTraceServiceRequest{})
+	require.NoError(t, err)
+}
+
+func TestOTLPHTTPServer(t *testing.T) {
+	params := newOTLPServerParams("localhost:14318")
+	server, err := StartOTLPHTTPServer(params)
+	require.NoError(t, err)
+	defer server.Close()
+
+	resp, err := http.Post("http://"+params.HostPort+handler.OTLPTracesPath, "application/json", bytes.NewBufferString("{}"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+}
@@ -24,9 +24,11 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/ratelimit"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/zipkin"
 	"github.com/jaegertracing/jaeger/pkg/healthcheck"
 	"github.com/jaegertracing/jaeger/pkg/recoveryhandler"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 )
 
 // ZipkinServerParams to construct a new Jaeger Collector Zipkin Server
@@ -37,6 +39,8 @@ type ZipkinServerParams struct {
 	AllowedHeaders string
 	HealthCheck    *healthcheck.HealthCheck
 	Logger         *zap.Logger
+	TenancyMgr     *tenancy.Manager
+	RateLimiter    *ratelimit.Limiter
 }
 
 // StartZipkinServer based on the given parameters
@@ -60,7 +64,7 @@ func StartZipkinServer(params *ZipkinServerParams) (*http.Server, error) {
 
 func serveZipkin(server *http.Server, listener net.Listener, params *ZipkinServerParams) {
 	r := mux.NewRouter()
-	zHandler := zipkin.NewAPIHandler(params.Handler)
+	zHandler := zipkin.NewAPIHandler(params.Handler, params.TenancyMgr, params.RateLimiter)
 	zHandler.RegisterRoutes(r)
 
 	origins := strings.Split(strings.ReplaceAll(params.AllowedOrigins, " ", ""), ",")
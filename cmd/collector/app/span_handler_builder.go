@@ -21,10 +21,15 @@ import (
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/deadletter"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/ratelimit"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
 	zs "github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer/zipkin"
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/queue"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -34,6 +39,22 @@ type SpanHandlerBuilder struct {
 	CollectorOpts  CollectorOptions
 	Logger         *zap.Logger
 	MetricsFactory metrics.Factory
+	// SpanFilter decides which spans are accepted, all spans are accepted if nil
+	SpanFilter FilterSpan
+	// Sanitizer is applied to spans before they are saved, if not nil
+	Sanitizer sanitizer.SanitizeSpan
+	// PersistentQueue is used instead of the in-memory queue, if not nil
+	PersistentQueue *queue.PersistentQueue
+	// DeadLetter receives spans that could not be saved after all retries, if not nil
+	DeadLetter deadletter.Writer
+	// TailSampler buffers the spans until their tail-based sampling decision, spans are written right away if nil
+	TailSampler TailSampler
+	// TenancyMgr validates the tenants of incoming spans, tenancy is disabled if nil
+	TenancyMgr *tenancy.Manager
+	// TenantSpanWriter returns the span writer of a tenant, spans of all tenants are written with SpanWriter if nil
+	TenantSpanWriter TenantSpanWriter
+	// RateLimiter rejects spans of services and clients over their rate limit, spans are not rate limited if nil
+	RateLimiter *ratelimit.Limiter
 }
 
 // SpanHandlers holds instances to the span handlers built by the SpanHandlerBuilder
@@ -41,35 +62,50 @@ type SpanHandlers struct {
 	ZipkinSpansHandler   handler.ZipkinSpansHandler
 	JaegerBatchesHandler handler.JaegerBatchesHandler
 	GRPCHandler          *handler.GRPCHandler
+	OTLPHandler          *handler.OTLPHandler
 }
 
-// BuildSpanProcessor builds the span processor to be used with the handlers
-func (b *SpanHandlerBuilder) BuildSpanProcessor() processor.SpanProcessor {
+// BuildSpanProcessor builds the span processor to be used with the handlers.
+// The additional functions, if any, are invoked for every span before it is saved.
+func (b *SpanHandlerBuilder) BuildSpanProcessor(additional ...ProcessSpan) processor.SpanProcessor {
 	hostname, _ := os.Hostname()
 	svcMetrics := b.metricsFactory()
 	hostMetrics := svcMetrics.Namespace(metrics.NSOptions{Tags: map[string]string{"host": hostname}})
+	retry := b.CollectorOpts.writeRetry()
 
 	return NewSpanProcessor(
 		b.SpanWriter,
+		Options.PreSave(ChainedProcessSpan(additional...)),
 		Options.ServiceMetrics(svcMetrics),
 		Options.HostMetrics(hostMetrics),
 		Options.Logger(b.logger()),
-		Options.SpanFilter(defaultSpanFilter),
+		Options.SpanFilter(b.spanFilter()),
+		Options.Sanitizer(b.Sanitizer),
 		Options.NumWorkers(b.CollectorOpts.NumWorkers),
 		Options.QueueSize(b.CollectorOpts.QueueSize),
 		Options.CollectorTags(b.CollectorOpts.CollectorTags),
 		Options.DynQueueSizeWarmup(uint(b.CollectorOpts.QueueSize)), // same as queue size for now
 		Options.DynQueueSizeMemory(b.CollectorOpts.DynQueueSizeMemory),
+		Options.PersistentQueue(b.PersistentQueue),
+		Options.MaxRetries(retry.MaxRetries),
+		Options.RetryInitialInterval(retry.InitialInterval),
+		Options.RetryMaxInterval(retry.MaxInterval),
+		Options.DeadLetter(b.DeadLetter),
+		Options.TailSampler(b.TailSampler),
+		Options.TenantSpanWriter(b.TenantSpanWriter),
+		Options.TenantQueueSize(b.CollectorOpts.TenantQueueSize),
+		Options.ReportBusy(b.CollectorOpts.RateLimit.RejectWhenBusy),
 	)
 
 }
 
-// BuildHandlers builds span handlers (Zipkin, Jaeger)
+// BuildHandlers builds span handlers (Zipkin, Jaeger, OTLP)
 func (b *SpanHandlerBuilder) BuildHandlers(spanProcessor processor.SpanProcessor) *SpanHandlers {
 	return &SpanHandlers{
-		handler.NewZipkinSpanHandler(b.Logger, spanProcessor, zs.NewChainedSanitizer(zs.StandardSanitizers...)),
-		handler.NewJaegerSpanHandler(b.Logger, spanProcessor),
-		handler.NewGRPCHandler(b.Logger, spanProcessor),
+		handler.NewZipkinSpanHandler(b.Logger, spanProcessor, zs.NewChainedSanitizer(zs.StandardSanitizers...), b.RateLimiter),
+		handler.NewJaegerSpanHandler(b.Logger, spanProcessor, b.RateLimiter),
+		handler.NewGRPCHandler(b.Logger, spanProcessor, b.tenancyMgr(), b.RateLimiter),
+		handler.NewOTLPHandler(b.Logger, spanProcessor, b.tenancyMgr(), b.RateLimiter),
 	}
 }
 
@@ -77,6 +113,13 @@ func defaultSpanFilter(*model.Span) bool {
 	return true
 }
 
+func (b *SpanHandlerBuilder) spanFilter() FilterSpan {
+	if b.SpanFilter == nil {
+		return defaultSpanFilter
+	}
+	return b.SpanFilter
+}
+
 func (b *SpanHandlerBuilder) logger() *zap.Logger {
 	if b.Logger == nil {
 		return zap.NewNop()
@@ -84,6 +127,13 @@ func (b *SpanHandlerBuilder) logger() *zap.Logger {
 	return b.Logger
 }
 
+func (b *SpanHandlerBuilder) tenancyMgr() *tenancy.Manager {
+	if b.TenancyMgr == nil {
+		return tenancy.NewManager(&tenancy.Options{})
+	}
+	return b.TenancyMgr
+}
+
 func (b *SpanHandlerBuilder) metricsFactory() metrics.Factory {
 	if b.MetricsFactory == nil {
 		return metrics.NullFactory
@@ -42,6 +42,7 @@ func TestNewSpanHandlerBuilder(t *testing.T) {
 	}
 	assert.NotNil(t, builder.logger())
 	assert.NotNil(t, builder.metricsFactory())
+	assert.NotNil(t, builder.spanFilter())
 
 	builder = &SpanHandlerBuilder{
 		SpanWriter:     spanWriter,
@@ -16,12 +16,14 @@
 package app
 
 import (
+	"math/rand"
 	"sync"
 	"time"
 
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/deadletter"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
 	"github.com/jaegertracing/jaeger/model"
@@ -38,16 +40,28 @@ const (
 )
 
 type spanProcessor struct {
-	queue              *queue.BoundedQueue
+	queue              spanQueue
 	queueResizeMu      sync.Mutex
 	metrics            *SpanProcessorMetrics
 	preProcessSpans    ProcessSpans
 	filterSpan         FilterSpan             // filter is called before the sanitizer but after preProcessSpans
-	sanitizer          sanitizer.SanitizeSpan // sanitizer is called before processSpan
-	processSpan        ProcessSpan
+	sanitizer          sanitizer.SanitizeSpan // sanitizer is called after the filter, before the span is queued
+	processSpan        ProcessSpan            // processSpan is called before the span is saved
 	logger             *zap.Logger
 	spanWriter         spanstore.Writer
 	reportBusy         bool
+	persistent         bool // spans are queued on disk and stay there if the processor is closed while retrying
+	maxRetries         int
+	retryInitial       time.Duration
+	retryMax           time.Duration
+	deadLetter         deadletter.Writer
+	tailSampler        spanstore.Writer
+	tenantSpanWriter   TenantSpanWriter
+	tenantQueueSize    int
+	tenantQueuedLock   sync.Mutex
+	tenantQueued       map[string]int // number of queued spans by tenant, tracked only if tenantQueueSize is set
+	randLock           sync.Mutex
+	rand               *rand.Rand
 	numWorkers         int
 	collectorTags      map[string]string
 	dynQueueSizeWarmup uint
@@ -60,19 +74,17 @@ type spanProcessor struct {
 type queueItem struct {
 	queuedTime time.Time
 	span       *model.Span
+	tenant     string
 }
 
-// NewSpanProcessor returns a SpanProcessor that preProcesses, filters, queues, sanitizes, and processes spans
+// NewSpanProcessor returns a SpanProcessor that preProcesses, filters, sanitizes, queues, and processes spans
 func NewSpanProcessor(
 	spanWriter spanstore.Writer,
 	opts ...Option,
 ) processor.SpanProcessor {
 	sp := newSpanProcessor(spanWriter, opts...)
 
-	sp.queue.StartConsumers(sp.numWorkers, func(item interface{}) {
-		value := item.(*queueItem)
-		sp.processItemFromQueue(value)
-	})
+	sp.queue.StartConsumers(sp.numWorkers, sp.processItemFromQueue)
 
 	sp.background(1*time.Second, sp.updateGauges)
 
@@ -89,19 +101,50 @@ func newSpanProcessor(spanWriter spanstore.Writer, opts ...Option) *spanProcesso
 		options.serviceMetrics,
 		options.hostMetrics,
 		options.extraFormatTypes)
+	sp := &spanProcessor{}
 	droppedItemHandler := func(item interface{}) {
 		handlerMetrics.SpansDropped.Inc(1)
+		if qItem, ok := item.(*queueItem); ok && qItem.tenant != "" {
+			handlerMetrics.GetCountsForTenant(qItem.tenant).Dropped.Inc(1)
+			sp.releaseTenantSlot(qItem.tenant)
+		}
+	}
+	var spanQueue spanQueue
+	if options.persistentQueue != nil {
+		if options.maxRetries == 0 {
+			// spans of the persistent queue stay queued until they are written, unless a limit is set
+			options.maxRetries = -1
+		}
+		spanQueue = persistentSpanQueue{
+			PersistentQueue: options.persistentQueue,
+			logger:          options.logger,
+			onDroppedItem:   func(item *queueItem) { droppedItemHandler(item) },
+		}
+		if options.dynQueueSizeMemory > 0 {
+			options.logger.Info("Dynamic queue size is not supported by the persistent queue, ignoring it")
+			options.dynQueueSizeMemory = 0
+		}
+	} else {
+		spanQueue = boundedSpanQueue{queue.NewBoundedQueue(options.queueSize, droppedItemHandler)}
 	}
-	boundedQueue := queue.NewBoundedQueue(options.queueSize, droppedItemHandler)
 
-	sp := spanProcessor{
-		queue:              boundedQueue,
+	*sp = spanProcessor{
+		queue:              spanQueue,
 		metrics:            handlerMetrics,
 		logger:             options.logger,
 		preProcessSpans:    options.preProcessSpans,
 		filterSpan:         options.spanFilter,
 		sanitizer:          options.sanitizer,
 		reportBusy:         options.reportBusy,
+		persistent:         options.persistentQueue != nil,
+		maxRetries:         options.maxRetries,
+		retryInitial:       options.retryInitial,
+		retryMax:           options.retryMax,
+		deadLetter:         options.deadLetter,
+		tenantSpanWriter:   options.tenantSpanWriter,
+		tenantQueueSize:    options.tenantQueueSize,
+		tenantQueued:       make(map[string]int),
+		rand:               rand.New(rand.NewSource(time.Now().UnixNano())),
 		numWorkers:         options.numWorkers,
 		spanWriter:         spanWriter,
 		collectorTags:      options.collectorTags,
@@ -112,7 +155,11 @@ func newSpanProcessor(spanWriter spanstore.Writer, opts ...Option) *spanProcesso
 		spansProcessed:     atomic.NewUint64(0),
 	}
 
-	processSpanFuncs := []ProcessSpan{options.preSave, sp.saveSpan}
+	if options.tailSampler != nil {
+		sp.tailSampler = options.tailSampler(keptSpanWriter{sp})
+	}
+
+	processSpanFuncs := []ProcessSpan{options.preSave}
 	if options.dynQueueSizeMemory > 0 {
 		// add to processSpanFuncs
 		options.logger.Info("Dynamically adjusting the queue size at runtime.",
@@ -122,7 +169,7 @@ func newSpanProcessor(spanWriter spanstore.Writer, opts ...Option) *spanProcesso
 	}
 
 	sp.processSpan = ChainedProcessSpan(processSpanFuncs...)
-	return &sp
+	return sp
 }
 
 func (sp *spanProcessor) Close() error {
@@ -132,23 +179,106 @@ func (sp *spanProcessor) Close() error {
 	return nil
 }
 
-func (sp *spanProcessor) saveSpan(span *model.Span) {
+// saveSpan writes the span to the storage of the tenant, returning the error of the writer if it failed
+func (sp *spanProcessor) saveSpan(span *model.Span, tenant string) error {
 	if nil == span.Process {
 		sp.logger.Error("process is empty for the span")
 		sp.metrics.SavedErrBySvc.ReportServiceNameForSpan(span)
-		return
+		return nil // writing the span again would not help
 	}
 
 	startTime := time.Now()
-	if err := sp.spanWriter.WriteSpan(span); err != nil {
-		sp.logger.Error("Failed to save span", zap.Error(err))
+	spanWriter, err := sp.spanWriterFor(tenant)
+	if err == nil {
+		err = spanWriter.WriteSpan(span)
+	}
+	if err != nil {
 		sp.metrics.SavedErrBySvc.ReportServiceNameForSpan(span)
+		if tenant != "" {
+			sp.logger.Error("Failed to save span", zap.String("tenant", tenant), zap.Error(err))
+			sp.metrics.GetCountsForTenant(tenant).SavedErr.Inc(1)
+		} else {
+			sp.logger.Error("Failed to save span", zap.Error(err))
+		}
 	} else {
 		sp.logger.Debug("Span written to the storage by the collector",
 			zap.Stringer("trace-id", span.TraceID), zap.Stringer("span-id", span.SpanID))
 		sp.metrics.SavedOkBySvc.ReportServiceNameForSpan(span)
+		if tenant != "" {
+			sp.metrics.GetCountsForTenant(tenant).SavedOk.Inc(1)
+		}
 	}
 	sp.metrics.SaveLatency.Record(time.Since(startTime))
+	return err
+}
+
+func (sp *spanProcessor) spanWriterFor(tenant string) (spanstore.Writer, error) {
+	if tenant == "" || sp.tenantSpanWriter == nil {
+		return sp.spanWriter, nil
+	}
+	return sp.tenantSpanWriter(tenant)
+}
+
+// writeSpan saves the span, retrying failed writes with exponential backoff and jitter.
+// Spans that still fail are sent to the dead-letter sink, if any. It returns an error
+// only if the processor is closed while the span of the persistent queue is retried,
+// in which case the span stays in the queue.
+func (sp *spanProcessor) writeSpan(span *model.Span, tenant string) error {
+	err := sp.saveSpan(span, tenant)
+	for attempt := 0; err != nil && (sp.maxRetries < 0 || attempt < sp.maxRetries); attempt++ {
+		select {
+		case <-time.After(sp.backoff(attempt)):
+		case <-sp.stopCh:
+			if sp.persistent {
+				return err
+			}
+			sp.sendToDeadLetter(span, tenant)
+			return nil
+		}
+		sp.metrics.WriteRetries.Inc(1)
+		err = sp.saveSpan(span, tenant)
+	}
+	if err != nil {
+		sp.sendToDeadLetter(span, tenant)
+	}
+	return nil
+}
+
+// keptSpanWriter writes the spans of the traces kept by the tail-based sampler
+type keptSpanWriter struct {
+	sp *spanProcessor
+}
+
+func (w keptSpanWriter) WriteSpan(span *model.Span) error {
+	return w.sp.writeSpan(span, "")
+}
+
+// backoff returns a random interval up to the exponentially growing upper bound for the attempt
+func (sp *spanProcessor) backoff(attempt int) time.Duration {
+	limit := sp.retryMax
+	if attempt < 32 {
+		if d := sp.retryInitial << uint(attempt); d > 0 && d < limit {
+			limit = d
+		}
+	}
+	sp.randLock.Lock()
+	defer sp.randLock.Unlock()
+	return time.Duration(sp.rand.Int63n(int64(limit) + 1))
+}
+
+func (sp *spanProcessor) sendToDeadLetter(span *model.Span, tenant string) {
+	if sp.deadLetter == nil {
+		sp.logger.Error("Dropping span that could not be written, no dead-letter sink is configured",
+			zap.Stringer("trace-id", span.TraceID), zap.Stringer("span-id", span.SpanID))
+		sp.metrics.WriteDropped.Inc(1)
+		return
+	}
+	if err := sp.deadLetter.WriteSpan(span, tenant); err != nil {
+		sp.logger.Error("Failed to write span to the dead-letter sink", zap.Error(err))
+		sp.metrics.DeadLetteredErr.Inc(1)
+		return
+	}
+	sp.metrics.DeadLetteredOk.Inc(1)
 }
 
 func (sp *spanProcessor) countSpan(span *model.Span) {
@@ -161,7 +291,7 @@ func (sp *spanProcessor) ProcessSpans(mSpans []*model.Span, options processor.Sp
 	sp.metrics.BatchSize.Update(int64(len(mSpans)))
 	retMe := make([]bool, len(mSpans))
 	for i, mSpan := range mSpans {
-		ok := sp.enqueueSpan(mSpan, options.SpanFormat, options.InboundTransport)
+		ok := sp.enqueueSpan(mSpan, options)
 		if !ok && sp.reportBusy {
 			return nil, processor.ErrBusy
 		}
@@ -170,9 +300,19 @@ func (sp *spanProcessor) ProcessSpans(mSpans []*model.Span, options processor.Sp
 	return retMe, nil
 }
 
-func (sp *spanProcessor) processItemFromQueue(item *queueItem) {
-	sp.processSpan(sp.sanitizer(item.span))
+func (sp *spanProcessor) processItemFromQueue(item *queueItem) error {
+	sp.processSpan(item.span)
+	var err error
+	if sp.tailSampler != nil {
+		err = sp.tailSampler.WriteSpan(item.span)
+	} else {
+		err = sp.writeSpan(item.span, item.tenant)
+	}
 	sp.metrics.InQueueLatency.Record(time.Since(item.queuedTime))
+	if err == nil {
+		sp.releaseTenantSlot(item.tenant)
+	}
+	return err
 }
 
 func (sp *spanProcessor) addCollectorTags(span *model.Span) {
@@ -182,28 +322,77 @@ func (sp *spanProcessor) addCollectorTags(span *model.Span) {
 	}
 }
 
-func (sp *spanProcessor) enqueueSpan(span *model.Span, originalFormat processor.SpanFormat, transport processor.InboundTransport) bool {
-	spanCounts := sp.metrics.GetCountsForFormat(originalFormat, transport)
+func (sp *spanProcessor) enqueueSpan(span *model.Span, options processor.SpansOptions) bool {
+	originalFormat := options.SpanFormat
+	spanCounts := sp.metrics.GetCountsForFormat(originalFormat, options.InboundTransport)
 	spanCounts.ReceivedBySvc.ReportServiceNameForSpan(span)
+	var tenantCounts *TenantCounts
+	if options.Tenant != "" {
+		tenantCounts = sp.metrics.GetCountsForTenant(options.Tenant)
+		tenantCounts.Received.Inc(1)
+	}
 
 	if !sp.filterSpan(span) {
 		spanCounts.RejectedBySvc.ReportServiceNameForSpan(span)
+		if tenantCounts != nil {
+			tenantCounts.Rejected.Inc(1)
+		}
 		return true // as in "not dropped", because it's actively rejected
 	}
 
+	if !sp.acquireTenantSlot(options.Tenant) {
+		sp.metrics.SpansDropped.Inc(1)
+		tenantCounts.Dropped.Inc(1)
+		return false
+	}
+
 	//add format tag
 	span.Tags = append(span.Tags, model.String("internal.span.format", string(originalFormat)))
 
 	// append the collector tags
 	sp.addCollectorTags(span)
 
+	// sanitize before queueing, so the persistent queue never stores spans the sanitizers would redact
+	span = sp.sanitizer(span)
+
 	item := &queueItem{
 		queuedTime: time.Now(),
 		span:       span,
+		tenant:     options.Tenant,
 	}
 	return sp.queue.Produce(item)
 }
 
+// acquireTenantSlot reserves a place in the queue for a span of the tenant,
+// it returns false if the tenant already has tenantQueueSize spans in the queue.
+func (sp *spanProcessor) acquireTenantSlot(tenant string) bool {
+	if tenant == "" || sp.tenantQueueSize <= 0 {
+		return true
+	}
+	sp.tenantQueuedLock.Lock()
+	defer sp.tenantQueuedLock.Unlock()
+	if sp.tenantQueued[tenant] >= sp.tenantQueueSize {
+		return false
+	}
+	sp.tenantQueued[tenant]++
+	return true
+}
+
+// releaseTenantSlot frees the place of a span of the tenant once it leaves the queue
+func (sp *spanProcessor) releaseTenantSlot(tenant string) {
+	if tenant == "" || sp.tenantQueueSize <= 0 {
+		return
+	}
+	sp.tenantQueuedLock.Lock()
+	defer sp.tenantQueuedLock.Unlock()
+	// spans replayed from the persistent queue were never counted
+	if sp.tenantQueued[tenant] <= 1 {
+		delete(sp.tenantQueued, tenant)
+		return
+	}
+	sp.tenantQueued[tenant]--
+}
+
 func (sp *spanProcessor) background(reportPeriod time.Duration, callback func()) {
 	go func() {
 		ticker := time.NewTicker(reportPeriod)
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics"
 	"github.com/uber/jaeger-lib/metrics/metricstest"
 	"go.uber.org/atomic"
@@ -33,6 +34,7 @@ import (
 	zipkinSanitizer "github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer/zipkin"
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/pkg/testutils"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
 	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
 	zc "github.com/jaegertracing/jaeger/thrift-gen/zipkincore"
 )
@@ -95,13 +97,13 @@ func TestBySvcMetrics(t *testing.T) {
 		switch test.format {
 		case processor.ZipkinSpanFormat:
 			span := makeZipkinSpan(test.serviceName, test.rootSpan, test.debug)
-			zHandler := handler.NewZipkinSpanHandler(logger, sp, zipkinSanitizer.NewParentIDSanitizer())
+			zHandler := handler.NewZipkinSpanHandler(logger, sp, zipkinSanitizer.NewParentIDSanitizer(), nil)
 			zHandler.SubmitZipkinBatch([]*zc.Span{span, span}, handler.SubmitBatchOptions{})
 			metricPrefix = "service"
 			format = "zipkin"
 		case processor.JaegerSpanFormat:
 			span, process := makeJaegerSpan(test.serviceName, test.rootSpan, test.debug)
-			jHandler := handler.NewJaegerSpanHandler(logger, sp)
+			jHandler := handler.NewJaegerSpanHandler(logger, sp, nil)
 			jHandler.SubmitBatches([]*jaeger.Batch{
 				{
 					Spans: []*jaeger.Span{
@@ -323,7 +325,7 @@ func TestSpanProcessorWithNilProcess(t *testing.T) {
 	p := NewSpanProcessor(w, Options.ServiceMetrics(serviceMetrics)).(*spanProcessor)
 	defer assert.NoError(t, p.Close())
 
-	p.saveSpan(&model.Span{})
+	p.saveSpan(&model.Span{}, "")
 
 	expected := []metricstest.ExpectedMetric{{
 		Name: "service.spans.saved-by-svc|debug=false|result=err|svc=__unknown", Value: 1,
@@ -504,3 +506,223 @@ func TestStartDynQueueSizeUpdater(t *testing.T) {
 
 	assert.EqualValues(t, 104857, p.queue.Capacity())
 }
+
+// recordingDeadLetter is a dead-letter sink recording the spans and their tenants
+type recordingDeadLetter struct {
+	recordingSpanWriter
+	tenants []string
+}
+
+func (w *recordingDeadLetter) WriteSpan(span *model.Span, tenant string) error {
+	if err := w.recordingSpanWriter.WriteSpan(span); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.tenants = append(w.tenants, tenant)
+	return nil
+}
+
+func (w *recordingDeadLetter) Close() error {
+	return nil
+}
+
+func TestSpanProcessorRetriesWithDeadLetter(t *testing.T) {
+	mb := metricstest.NewFactory(time.Hour)
+	w := &recordingSpanWriter{failures: 4}
+	deadLetter := &recordingDeadLetter{}
+	p := NewSpanProcessor(w,
+		Options.HostMetrics(mb.Namespace(metrics.NSOptions{})),
+		Options.NumWorkers(1),
+		Options.QueueSize(10),
+		Options.MaxRetries(2),
+		Options.RetryInitialInterval(time.Millisecond),
+		Options.DeadLetter(deadLetter),
+	).(*spanProcessor)
+
+	// the first span fails three times and goes to the dead-letter sink, the second one is retried once
+	_, err := p.ProcessSpans([]*model.Span{testSpan("dead"), testSpan("retried")}, processor.SpansOptions{Tenant: "acme"})
+	assert.NoError(t, err)
+	waitForWrittenSpans(t, w, 1)
+	assert.NoError(t, p.Close())
+
+	assert.Equal(t, "retried", w.written()[0].OperationName)
+	require.Len(t, deadLetter.written(), 1)
+	assert.Equal(t, "dead", deadLetter.written()[0].OperationName)
+	assert.Equal(t, []string{"acme"}, deadLetter.tenants)
+	mb.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "spans.write-retries", Value: 3},
+		metricstest.ExpectedMetric{Name: "spans.dead-lettered|result=ok", Value: 1},
+	)
+}
+
+func TestSpanProcessorDropsWithoutDeadLetter(t *testing.T) {
+	mb := metricstest.NewFactory(time.Hour)
+	w := &recordingSpanWriter{failures: 1}
+	p := NewSpanProcessor(w,
+		Options.HostMetrics(mb.Namespace(metrics.NSOptions{})),
+		Options.NumWorkers(1),
+		Options.QueueSize(10),
+	)
+	_, err := p.ProcessSpans([]*model.Span{testSpan("dropped"), testSpan("written")}, processor.SpansOptions{})
+	assert.NoError(t, err)
+	waitForWrittenSpans(t, w, 1)
+	assert.NoError(t, p.Close())
+	mb.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "spans.write-dropped", Value: 1})
+}
+
+func TestSpanProcessorDeadLetterOnClose(t *testing.T) {
+	mb := metricstest.NewFactory(time.Hour)
+	deadLetter := &recordingDeadLetter{recordingSpanWriter: recordingSpanWriter{failures: 1}}
+	p := NewSpanProcessor(&recordingSpanWriter{failures: -1},
+		Options.HostMetrics(mb.Namespace(metrics.NSOptions{})),
+		Options.QueueSize(10),
+		Options.MaxRetries(-1),
+		Options.RetryInitialInterval(time.Hour),
+		Options.DeadLetter(deadLetter),
+	).(*spanProcessor)
+
+	_, err := p.ProcessSpans([]*model.Span{testSpan("a"), testSpan("b")}, processor.SpansOptions{})
+	assert.NoError(t, err)
+	for i := 0; i < 1000 && p.queue.Size() > 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	// the in-memory queue would lose the spans being retried, they go to the dead-letter sink instead
+	assert.NoError(t, p.Close())
+	assert.Len(t, deadLetter.written(), 1)
+	mb.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "spans.dead-lettered|result=ok", Value: 1},
+		metricstest.ExpectedMetric{Name: "spans.dead-lettered|result=err", Value: 1},
+	)
+}
+
+func TestSpanProcessorTailSampler(t *testing.T) {
+	mb := metricstest.NewFactory(time.Hour)
+	w := &recordingSpanWriter{failures: 4}
+	deadLetter := &recordingDeadLetter{}
+	sampler := &recordingSpanWriter{}
+	var keptSpans spanstore.Writer
+	p := NewSpanProcessor(w,
+		Options.HostMetrics(mb.Namespace(metrics.NSOptions{})),
+		Options.NumWorkers(1),
+		Options.QueueSize(10),
+		Options.MaxRetries(2),
+		Options.RetryInitialInterval(time.Millisecond),
+		Options.DeadLetter(deadLetter),
+		Options.TailSampler(func(keptSpanWriter spanstore.Writer) spanstore.Writer {
+			keptSpans = keptSpanWriter
+			return sampler
+		}),
+	)
+
+	// the spans are buffered by the sampler rather than written
+	_, err := p.ProcessSpans([]*model.Span{testSpan("kept")}, processor.SpansOptions{})
+	assert.NoError(t, err)
+	waitForWrittenSpans(t, sampler, 1)
+	assert.Empty(t, w.written())
+
+	// the kept spans are retried and then sent to the dead-letter sink
+	assert.NoError(t, keptSpans.WriteSpan(testSpan("dead")))
+	assert.NoError(t, keptSpans.WriteSpan(testSpan("retried")))
+	assert.NoError(t, p.Close())
+	require.Len(t, w.written(), 1)
+	assert.Equal(t, "retried", w.written()[0].OperationName)
+	require.Len(t, deadLetter.written(), 1)
+	assert.Equal(t, "dead", deadLetter.written()[0].OperationName)
+	mb.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "spans.write-retries", Value: 3})
+}
+
+func TestSpanProcessorBackoff(t *testing.T) {
+	p := NewSpanProcessor(&fakeSpanWriter{},
+		Options.RetryInitialInterval(time.Second),
+		Options.RetryMaxInterval(10*time.Second),
+	).(*spanProcessor)
+	defer p.Close()
+
+	for _, test := range []struct {
+		attempt int
+		limit   time.Duration
+	}{
+		{attempt: 0, limit: time.Second},
+		{attempt: 2, limit: 4 * time.Second},
+		{attempt: 4, limit: 10 * time.Second},
+		{attempt: 100, limit: 10 * time.Second},
+	} {
+		for i := 0; i < 100; i++ {
+			d := p.backoff(test.attempt)
+			assert.True(t, d >= 0 && d <= test.limit, "attempt %d: %v must not exceed %v", test.attempt, d, test.limit)
+		}
+	}
+}
+
+func TestSpanProcessorTenantWriters(t *testing.T) {
+	mb := metricstest.NewFactory(time.Hour)
+	defaultWriter := &recordingSpanWriter{}
+	acmeWriter := &recordingSpanWriter{}
+	p := NewSpanProcessor(defaultWriter,
+		Options.HostMetrics(mb.Namespace(metrics.NSOptions{})),
+		Options.QueueSize(10),
+		Options.TenantSpanWriter(func(tenant string) (spanstore.Writer, error) {
+			if tenant == "acme" {
+				return acmeWriter, nil
+			}
+			return nil, fmt.Errorf("unknown tenant %s", tenant)
+		}),
+	).(*spanProcessor)
+
+	for _, tenant := range []string{"", "acme", "megacorp"} {
+		_, err := p.ProcessSpans([]*model.Span{testSpan(tenant)}, processor.SpansOptions{Tenant: tenant})
+		require.NoError(t, err)
+	}
+	waitForWrittenSpans(t, defaultWriter, 1)
+	waitForWrittenSpans(t, acmeWriter, 1)
+	assert.NoError(t, p.Close())
+
+	assert.Equal(t, "", defaultWriter.written()[0].OperationName)
+	assert.Equal(t, "acme", acmeWriter.written()[0].OperationName)
+	mb.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "tenant.spans.received|tenant=acme", Value: 1},
+		metricstest.ExpectedMetric{Name: "tenant.spans.saved|result=ok|tenant=acme", Value: 1},
+		metricstest.ExpectedMetric{Name: "tenant.spans.received|tenant=megacorp", Value: 1},
+		metricstest.ExpectedMetric{Name: "tenant.spans.saved|result=err|tenant=megacorp", Value: 1},
+	)
+}
+
+func TestSpanProcessorTenantQueueSize(t *testing.T) {
+	mb := metricstest.NewFactory(time.Hour)
+	w := &blockingWriter{}
+	p := NewSpanProcessor(w,
+		Options.HostMetrics(mb.Namespace(metrics.NSOptions{})),
+		Options.NumWorkers(1),
+		Options.QueueSize(10),
+		Options.TenantQueueSize(1),
+	).(*spanProcessor)
+	defer p.Close()
+
+	// the first span of a tenant keeps its place in the queue until it is written
+	w.Lock()
+	res, err := p.ProcessSpans([]*model.Span{testSpan("a"), testSpan("b")}, processor.SpansOptions{Tenant: "acme"})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, res)
+	res, err = p.ProcessSpans([]*model.Span{testSpan("c")}, processor.SpansOptions{Tenant: "megacorp"})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true}, res)
+	w.Unlock()
+
+	for i := 0; i < 1000 && p.queuedSpans("acme") > 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	res, err = p.ProcessSpans([]*model.Span{testSpan("d")}, processor.SpansOptions{Tenant: "acme"})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true}, res)
+	mb.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "spans.dropped", Value: 1},
+		metricstest.ExpectedMetric{Name: "tenant.spans.dropped|tenant=acme", Value: 1},
+	)
+}
+
+func (sp *spanProcessor) queuedSpans(tenant string) int {
+	sp.tenantQueuedLock.Lock()
+	defer sp.tenantQueuedLock.Unlock()
+	return sp.tenantQueued[tenant]
+}
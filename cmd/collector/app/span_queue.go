@@ -0,0 +1,130 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package app
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/queue"
+)
+
+// spanQueue holds the spans between the handlers and the storage writer.
+type spanQueue interface {
+	Produce(item *queueItem) bool
+	StartConsumers(num int, consumer func(item *queueItem) error)
+	Size() int
+	Capacity() int
+	Resize(capacity int) bool
+	Stop()
+}
+
+// boundedSpanQueue is an in-memory spanQueue, spans that fail to be written are discarded
+type boundedSpanQueue struct {
+	*queue.BoundedQueue
+}
+
+func (q boundedSpanQueue) Produce(item *queueItem) bool {
+	return q.BoundedQueue.Produce(item)
+}
+
+func (q boundedSpanQueue) StartConsumers(num int, consumer func(item *queueItem) error) {
+	q.BoundedQueue.StartConsumers(num, func(item interface{}) {
+		_ = consumer(item.(*queueItem))
+	})
+}
+
+// persistentSpanQueue is a disk-backed spanQueue, spans are removed from disk once written
+type persistentSpanQueue struct {
+	*queue.PersistentQueue
+	logger        *zap.Logger
+	onDroppedItem func(item *queueItem)
+}
+
+func (q persistentSpanQueue) Produce(item *queueItem) bool {
+	data, err := item.marshal()
+	if err != nil {
+		q.logger.Error("Failed to serialize span for the persistent queue", zap.Error(err))
+		q.onDroppedItem(item)
+		return false
+	}
+	if !q.PersistentQueue.Produce(data) {
+		q.onDroppedItem(item)
+		return false
+	}
+	return true
+}
+
+func (q persistentSpanQueue) StartConsumers(num int, consumer func(item *queueItem) error) {
+	q.PersistentQueue.StartConsumers(num, func(data []byte) error {
+		item, err := unmarshalQueueItem(data)
+		if err != nil {
+			// retrying would not help, acknowledge the item to remove it from the queue
+			q.logger.Error("Failed to deserialize span from the persistent queue", zap.Error(err))
+			return nil
+		}
+		return consumer(item)
+	})
+}
+
+// Capacity of the persistent queue is limited by disk size rather than number of spans.
+func (q persistentSpanQueue) Capacity() int {
+	return 0
+}
+
+// Resize is not supported by the persistent queue.
+func (q persistentSpanQueue) Resize(capacity int) bool {
+	return false
+}
+
+// marshal encodes the queued time, the length of the tenant and the tenant,
+// followed by the span in protobuf format
+func (item *queueItem) marshal() ([]byte, error) {
+	if len(item.tenant) > math.MaxUint16 {
+		return nil, errors.New("tenant is too long")
+	}
+	header := 8 + 2 + len(item.tenant)
+	data := make([]byte, header+item.span.Size())
+	binary.BigEndian.PutUint64(data, uint64(item.queuedTime.UnixNano()))
+	binary.BigEndian.PutUint16(data[8:], uint16(len(item.tenant)))
+	copy(data[10:], item.tenant)
+	if _, err := item.span.MarshalTo(data[header:]); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func unmarshalQueueItem(data []byte) (*queueItem, error) {
+	if len(data) < 10 {
+		return nil, errors.New("queued span is too short")
+	}
+	header := 10 + int(binary.BigEndian.Uint16(data[8:]))
+	if len(data) < header {
+		return nil, errors.New("queued span is too short")
+	}
+	span := &model.Span{}
+	if err := span.Unmarshal(data[header:]); err != nil {
+		return nil, err
+	}
+	return &queueItem{
+		queuedTime: time.Unix(0, int64(binary.BigEndian.Uint64(data))),
+		tenant:     string(data[10:header]),
+		span:       span,
+	}, nil
+}
@@ -0,0 +1,235 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package app
+
+import (
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/queue"
+)
+
+// recordingSpanWriter fails the first failures writes and records the spans written afterwards
+type recordingSpanWriter struct {
+	mu       sync.Mutex
+	failures int
+	spans    []*model.Span
+}
+
+func (w *recordingSpanWriter) WriteSpan(span *model.Span) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.failures != 0 {
+		w.failures--
+		return errors.New("storage is down")
+	}
+	w.spans = append(w.spans, span)
+	return nil
+}
+
+func (w *recordingSpanWriter) written() []*model.Span {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]*model.Span(nil), w.spans...)
+}
+
+func newTestPersistentQueue(t *testing.T, dir string) *queue.PersistentQueue {
+	q, err := queue.NewPersistentQueue(queue.PersistentQueueOptions{
+		Directory:   dir,
+		SegmentSize: 1024 * 1024,
+		MaxDiskSize: 10 * 1024 * 1024,
+		SyncPolicy:  queue.SyncAlways,
+	})
+	require.NoError(t, err)
+	return q
+}
+
+func persistentQueueDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "span-queue")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func waitForWrittenSpans(t *testing.T, w *recordingSpanWriter, count int) {
+	for i := 0; i < 1000 && len(w.written()) < count; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	require.Len(t, w.written(), count)
+}
+
+func testSpan(operation string) *model.Span {
+	return &model.Span{
+		TraceID:       model.NewTraceID(0, 1),
+		SpanID:        model.NewSpanID(2),
+		OperationName: operation,
+		StartTime:     time.Unix(1500000000, 0).UTC(),
+		Process:       model.NewProcess("svc", []model.KeyValue{model.String("k", "v")}),
+	}
+}
+
+func TestSpanProcessorPersistentQueueRetries(t *testing.T) {
+	w := &recordingSpanWriter{failures: 2}
+	var preSaveCount int
+	p := NewSpanProcessor(w,
+		Options.PersistentQueue(newTestPersistentQueue(t, persistentQueueDir(t))),
+		Options.MaxRetries(-1),
+		Options.RetryInitialInterval(time.Millisecond),
+		Options.PreSave(func(span *model.Span) { preSaveCount++ }),
+		Options.NumWorkers(1),
+	).(*spanProcessor)
+
+	res, err := p.ProcessSpans([]*model.Span{testSpan("op")}, processor.SpansOptions{SpanFormat: processor.ProtoSpanFormat})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true}, res)
+
+	waitForWrittenSpans(t, w, 1)
+	assert.NoError(t, p.Close())
+	assert.Equal(t, "op", w.written()[0].OperationName)
+	assert.Equal(t, 1, preSaveCount, "retries must not run the pre-save hooks again")
+	assert.EqualValues(t, 0, p.queue.Capacity())
+	assert.False(t, p.queue.Resize(10))
+}
+
+func TestSpanProcessorPersistentQueueRetriesByDefault(t *testing.T) {
+	w := &recordingSpanWriter{failures: 3}
+	p := NewSpanProcessor(w,
+		Options.PersistentQueue(newTestPersistentQueue(t, persistentQueueDir(t))),
+		Options.RetryInitialInterval(time.Millisecond),
+		Options.NumWorkers(1),
+	)
+	_, err := p.ProcessSpans([]*model.Span{testSpan("op")}, processor.SpansOptions{})
+	require.NoError(t, err)
+	waitForWrittenSpans(t, w, 1)
+	assert.NoError(t, p.Close())
+}
+
+func TestSpanProcessorPersistentQueueMaxRetries(t *testing.T) {
+	w := &recordingSpanWriter{failures: 2}
+	p := NewSpanProcessor(w,
+		Options.PersistentQueue(newTestPersistentQueue(t, persistentQueueDir(t))),
+		Options.MaxRetries(1),
+		Options.RetryInitialInterval(time.Millisecond),
+		Options.NumWorkers(1),
+	).(*spanProcessor)
+
+	_, err := p.ProcessSpans([]*model.Span{testSpan("dropped"), testSpan("written")}, processor.SpansOptions{})
+	require.NoError(t, err)
+	waitForWrittenSpans(t, w, 1)
+	assert.NoError(t, p.Close())
+	assert.Equal(t, "written", w.written()[0].OperationName)
+}
+
+func TestSpanProcessorPersistentQueueReplay(t *testing.T) {
+	dir := persistentQueueDir(t)
+	// the storage is down, the span stays on disk when the processor is closed
+	w := &recordingSpanWriter{failures: -1}
+	p := NewSpanProcessor(w, Options.PersistentQueue(newTestPersistentQueue(t, dir)), Options.MaxRetries(-1))
+	_, err := p.ProcessSpans([]*model.Span{testSpan("op")}, processor.SpansOptions{SpanFormat: processor.ProtoSpanFormat})
+	require.NoError(t, err)
+	assert.NoError(t, p.Close())
+	assert.Empty(t, w.written())
+
+	w = &recordingSpanWriter{}
+	p = NewSpanProcessor(w, Options.PersistentQueue(newTestPersistentQueue(t, dir)))
+	waitForWrittenSpans(t, w, 1)
+	assert.NoError(t, p.Close())
+
+	expected := testSpan("op")
+	expected.Tags = append(expected.Tags, model.String("internal.span.format", string(processor.ProtoSpanFormat)))
+	assert.Equal(t, expected, w.written()[0])
+}
+
+func TestSpanProcessorPersistentQueueSanitizesBeforeQueueing(t *testing.T) {
+	dir := persistentQueueDir(t)
+	redact := func(span *model.Span) *model.Span {
+		span.OperationName = "redacted"
+		return span
+	}
+	w := &recordingSpanWriter{failures: -1}
+	p := NewSpanProcessor(w,
+		Options.PersistentQueue(newTestPersistentQueue(t, dir)),
+		Options.MaxRetries(-1),
+		Options.Sanitizer(redact),
+	)
+	_, err := p.ProcessSpans([]*model.Span{testSpan("secret")}, processor.SpansOptions{})
+	require.NoError(t, err)
+	assert.NoError(t, p.Close())
+
+	// the span replayed without sanitizer was redacted before it was written to disk
+	w = &recordingSpanWriter{}
+	p = NewSpanProcessor(w, Options.PersistentQueue(newTestPersistentQueue(t, dir)))
+	waitForWrittenSpans(t, w, 1)
+	assert.NoError(t, p.Close())
+	assert.Equal(t, "redacted", w.written()[0].OperationName)
+}
+
+func TestSpanProcessorPersistentQueueFull(t *testing.T) {
+	mb := metricstest.NewFactory(time.Hour)
+	q, err := queue.NewPersistentQueue(queue.PersistentQueueOptions{
+		Directory:   persistentQueueDir(t),
+		SegmentSize: 1024,
+		MaxDiskSize: 10,
+		SyncPolicy:  queue.SyncNever,
+	})
+	require.NoError(t, err)
+	p := NewSpanProcessor(&fakeSpanWriter{},
+		Options.PersistentQueue(q),
+		Options.HostMetrics(mb.Namespace(metrics.NSOptions{})),
+		Options.DynQueueSizeMemory(1024),
+	)
+	defer p.Close()
+
+	res, err := p.ProcessSpans([]*model.Span{testSpan("op")}, processor.SpansOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false}, res)
+	mb.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "spans.dropped", Value: 1})
+}
+
+func TestQueueItemMarshal(t *testing.T) {
+	for _, tenant := range []string{"", "acme"} {
+		item := &queueItem{queuedTime: time.Unix(0, 1234567890), tenant: tenant, span: testSpan("op")}
+		data, err := item.marshal()
+		require.NoError(t, err)
+		decoded, err := unmarshalQueueItem(data)
+		require.NoError(t, err)
+		assert.True(t, item.queuedTime.Equal(decoded.queuedTime))
+		assert.Equal(t, tenant, decoded.tenant)
+		assert.Equal(t, item.span, decoded.span)
+
+		_, err = unmarshalQueueItem(append(data[:8:8], 0xff, 0xff))
+		assert.Error(t, err)
+		_, err = unmarshalQueueItem(append(data[:8:8], 0, 0, 0xff, 0xff))
+		assert.Error(t, err)
+	}
+
+	_, err := unmarshalQueueItem([]byte{1, 2})
+	assert.Error(t, err)
+
+	_, err = (&queueItem{tenant: strings.Repeat("a", math.MaxUint16+1), span: testSpan("op")}).marshal()
+	assert.EqualError(t, err, "tenant is too long")
+}
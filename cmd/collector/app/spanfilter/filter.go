@@ -0,0 +1,147 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanfilter
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// Filter drops spans matching any of the rules loaded from a rules file.
+type Filter struct {
+	logger         *zap.Logger
+	metricsFactory metrics.Factory
+
+	rules atomic.Value // holds []*rule
+
+	countersMu sync.Mutex
+	counters   map[string]metrics.Counter
+
+	ctx        context.Context
+	cancelFunc context.CancelFunc
+}
+
+// NewFilter creates a Filter with rules loaded from options.RulesFile.
+// If options.ReloadInterval is positive, the file is periodically checked for changes.
+func NewFilter(options Options, metricsFactory metrics.Factory, logger *zap.Logger) (*Filter, error) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	f := &Filter{
+		logger:         logger,
+		metricsFactory: metricsFactory,
+		counters:       make(map[string]metrics.Counter),
+		ctx:            ctx,
+		cancelFunc:     cancelFunc,
+	}
+	data, err := ioutil.ReadFile(filepath.Clean(options.RulesFile))
+	if err != nil {
+		cancelFunc()
+		return nil, fmt.Errorf("failed to open span filtering rules file: %w", err)
+	}
+	if err := f.updateRules(data); err != nil {
+		cancelFunc()
+		return nil, err
+	}
+	if options.ReloadInterval > 0 {
+		go f.autoUpdateRules(options.ReloadInterval, options.RulesFile, string(data))
+	}
+	return f, nil
+}
+
+// FilterSpan returns false if the span matches any of the rules and must be dropped.
+// It has the signature of app.FilterSpan.
+func (f *Filter) FilterSpan(span *model.Span) bool {
+	rules := f.rules.Load().([]*rule)
+	for _, r := range rules {
+		if r.matches(span) {
+			f.counter(r.name).Inc(1)
+			return false
+		}
+	}
+	return true
+}
+
+// Close stops reloading the rules file.
+func (f *Filter) Close() error {
+	f.cancelFunc()
+	return nil
+}
+
+func (f *Filter) counter(ruleName string) metrics.Counter {
+	f.countersMu.Lock()
+	defer f.countersMu.Unlock()
+	c, ok := f.counters[ruleName]
+	if !ok {
+		c = f.metricsFactory.Counter(metrics.Options{
+			Name: "span_filter.spans_dropped",
+			Tags: map[string]string{"rule": ruleName},
+		})
+		f.counters[ruleName] = c
+	}
+	return c
+}
+
+func (f *Filter) autoUpdateRules(interval time.Duration, filePath string, lastValue string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			lastValue = f.reloadRulesFile(filePath, lastValue)
+		case <-f.ctx.Done():
+			return
+		}
+	}
+}
+
+func (f *Filter) reloadRulesFile(filePath string, lastValue string) string {
+	currBytes, err := ioutil.ReadFile(filepath.Clean(filePath))
+	if err != nil {
+		f.logger.Error("failed to load span filtering rules", zap.String("file", filePath), zap.Error(err))
+		return lastValue
+	}
+	newValue := string(currBytes)
+	if lastValue == newValue {
+		return lastValue
+	}
+	if err = f.updateRules(currBytes); err != nil {
+		f.logger.Error("failed to update span filtering rules from file", zap.Error(err))
+		return lastValue
+	}
+	f.logger.Info("Updated span filtering rules", zap.String("file", filePath))
+	return newValue
+}
+
+func (f *Filter) updateRules(data []byte) error {
+	rules, err := parseRules(data)
+	if err != nil {
+		return err
+	}
+	for _, r := range rules {
+		// initialize the counters so that every rule is reported, even if it never matched
+		f.counter(r.name)
+	}
+	f.rules.Store(rules)
+	return nil
+}
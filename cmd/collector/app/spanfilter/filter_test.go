@@ -0,0 +1,117 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanfilter
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func TestNewFilterErrors(t *testing.T) {
+	_, err := NewFilter(Options{RulesFile: "fixtures/missing.json"}, metricstest.NewFactory(0), zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to open span filtering rules file")
+
+	_, err = NewFilter(Options{RulesFile: "filter_test.go"}, metricstest.NewFactory(0), zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to unmarshal span filtering rules")
+}
+
+func TestFilterSpan(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	f, err := NewFilter(Options{RulesFile: "fixtures/rules.json"}, metricsFactory, zap.NewNop())
+	require.NoError(t, err)
+	defer f.Close()
+
+	tests := []struct {
+		span *model.Span
+		keep bool
+	}{
+		{
+			span: &model.Span{OperationName: "GET /health", Process: &model.Process{ServiceName: "any"}},
+		},
+		{
+			span: &model.Span{OperationName: "GET /metrics", Process: &model.Process{ServiceName: "frontend"}},
+		},
+		{
+			span: &model.Span{OperationName: "GET /metrics", Process: &model.Process{ServiceName: "backend"}},
+			keep: true,
+		},
+		{
+			span: &model.Span{
+				OperationName: "get",
+				Process:       &model.Process{ServiceName: "cache"},
+				Tags:          model.KeyValues{model.String("span.kind", "client"), model.String("cache.hit", "true")},
+			},
+		},
+		{
+			span: &model.Span{
+				OperationName: "get",
+				Process:       &model.Process{ServiceName: "cache"},
+				Tags:          model.KeyValues{model.String("span.kind", "client"), model.String("cache.hit", "false")},
+			},
+			keep: true,
+		},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.keep, f.FilterSpan(test.span), test.span.OperationName)
+	}
+
+	metricsFactory.AssertCounterMetrics(t, []metricstest.ExpectedMetric{
+		{Name: "span_filter.spans_dropped", Tags: map[string]string{"rule": "health-checks"}, Value: 1},
+		{Name: "span_filter.spans_dropped", Tags: map[string]string{"rule": "metrics-scrapes"}, Value: 1},
+		{Name: "span_filter.spans_dropped", Tags: map[string]string{"rule": "cache-hits"}, Value: 1},
+	}...)
+}
+
+func TestFilterAutoReload(t *testing.T) {
+	tempFile, err := ioutil.TempFile("", "rules.json")
+	require.NoError(t, err)
+	defer os.Remove(tempFile.Name())
+	require.NoError(t, ioutil.WriteFile(tempFile.Name(), []byte(`{"rules": [{"name": "a", "service": "a"}]}`), 0644))
+
+	f, err := NewFilter(Options{RulesFile: tempFile.Name(), ReloadInterval: 10 * time.Millisecond}, metricstest.NewFactory(0), zap.NewNop())
+	require.NoError(t, err)
+	defer f.Close()
+
+	spanA := &model.Span{Process: &model.Process{ServiceName: "a"}}
+	spanB := &model.Span{Process: &model.Process{ServiceName: "b"}}
+	assert.False(t, f.FilterSpan(spanA))
+	assert.True(t, f.FilterSpan(spanB))
+
+	require.NoError(t, ioutil.WriteFile(tempFile.Name(), []byte(`{"rules": [{"name": "b", "service": "b"}]}`), 0644))
+	for i := 0; i < 100 && f.FilterSpan(spanB); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, f.FilterSpan(spanA))
+	assert.False(t, f.FilterSpan(spanB))
+}
+
+func TestReloadRulesFileErrors(t *testing.T) {
+	f, err := NewFilter(Options{RulesFile: "fixtures/rules.json"}, metricstest.NewFactory(0), zap.NewNop())
+	require.NoError(t, err)
+	defer f.Close()
+
+	assert.Equal(t, "last", f.reloadRulesFile("fixtures/missing.json", "last"))
+	assert.Equal(t, "last", f.reloadRulesFile("filter_test.go", "last"))
+	assert.Len(t, f.rules.Load().([]*rule), 3)
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanfilter
+
+import (
+	"flag"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	spanFilterRulesFile      = "collector.span-filter.rules-file"
+	spanFilterReloadInterval = "collector.span-filter.reload-interval"
+)
+
+// Options holds configuration for the rule-based span filter.
+type Options struct {
+	// RulesFile is the path for the span filtering rules file in JSON format.
+	// Span filtering is disabled when it is empty.
+	RulesFile string
+	// ReloadInterval is the time interval to check and reload the rules file
+	ReloadInterval time.Duration
+}
+
+// AddFlags adds flags for Options
+func AddFlags(flagSet *flag.FlagSet) {
+	flagSet.String(spanFilterRulesFile, "", "The path for the span filtering rules file in JSON format. Spans matching any of the rules are dropped. Span filtering is disabled if empty")
+	flagSet.Duration(spanFilterReloadInterval, 0, "Reload interval to check and reload the span filtering rules file. Zero value means no reloading")
+}
+
+// InitFromViper initializes Options with properties from viper
+func (opts *Options) InitFromViper(v *viper.Viper) *Options {
+	opts.RulesFile = v.GetString(spanFilterRulesFile)
+	opts.ReloadInterval = v.GetDuration(spanFilterReloadInterval)
+	return opts
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanfilter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// ruleConfig is the JSON representation of a rule in the rules file.
+// All conditions that are set must match for the rule to apply.
+type ruleConfig struct {
+	Name           string            `json:"name"`
+	Service        string            `json:"service,omitempty"`
+	Operation      string            `json:"operation,omitempty"`
+	OperationRegex string            `json:"operation_regex,omitempty"`
+	SpanKind       string            `json:"span_kind,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
+}
+
+type rulesConfig struct {
+	Rules []ruleConfig `json:"rules"`
+}
+
+// rule drops spans that match all of its conditions.
+type rule struct {
+	name           string
+	service        string
+	operation      string
+	operationRegex *regexp.Regexp
+	spanKind       string
+	tags           map[string]string
+}
+
+func parseRules(data []byte) ([]*rule, error) {
+	var cfg rulesConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal span filtering rules: %w", err)
+	}
+	rules := make([]*rule, 0, len(cfg.Rules))
+	names := make(map[string]struct{}, len(cfg.Rules))
+	for i, rc := range cfg.Rules {
+		if rc.Name == "" {
+			return nil, fmt.Errorf("span filtering rule #%d has no name", i)
+		}
+		if _, ok := names[rc.Name]; ok {
+			return nil, fmt.Errorf("duplicate span filtering rule name %q", rc.Name)
+		}
+		names[rc.Name] = struct{}{}
+		r, err := newRule(rc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid span filtering rule %q: %w", rc.Name, err)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func newRule(rc ruleConfig) (*rule, error) {
+	if rc.Service == "" && rc.Operation == "" && rc.OperationRegex == "" && rc.SpanKind == "" && len(rc.Tags) == 0 {
+		return nil, errors.New("rule has no conditions and would drop every span")
+	}
+	r := &rule{
+		name:      rc.Name,
+		service:   rc.Service,
+		operation: rc.Operation,
+		spanKind:  rc.SpanKind,
+		tags:      rc.Tags,
+	}
+	if rc.OperationRegex != "" {
+		re, err := regexp.Compile(rc.OperationRegex)
+		if err != nil {
+			return nil, fmt.Errorf("cannot compile operation_regex: %w", err)
+		}
+		r.operationRegex = re
+	}
+	return r, nil
+}
+
+func (r *rule) matches(span *model.Span) bool {
+	if r.service != "" && (span.Process == nil || span.Process.ServiceName != r.service) {
+		return false
+	}
+	if r.operation != "" && span.OperationName != r.operation {
+		return false
+	}
+	if r.operationRegex != nil && !r.operationRegex.MatchString(span.OperationName) {
+		return false
+	}
+	if r.spanKind != "" {
+		if kind, ok := span.GetSpanKind(); !ok || kind != r.spanKind {
+			return false
+		}
+	}
+	for k, v := range r.tags {
+		tag, ok := model.KeyValues(span.Tags).FindByKey(k)
+		if !ok || tag.AsString() != v {
+			return false
+		}
+	}
+	return true
+}
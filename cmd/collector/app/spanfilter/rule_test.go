@@ -0,0 +1,103 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanfilter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func TestParseRulesErrors(t *testing.T) {
+	tests := []struct {
+		data   string
+		errMsg string
+	}{
+		{
+			data:   `{"rules": {}}`,
+			errMsg: "failed to unmarshal span filtering rules",
+		},
+		{
+			data:   `{"rules": [{"service": "a"}]}`,
+			errMsg: "span filtering rule #0 has no name",
+		},
+		{
+			data:   `{"rules": [{"name": "a", "service": "a"}, {"name": "a", "service": "b"}]}`,
+			errMsg: `duplicate span filtering rule name "a"`,
+		},
+		{
+			data:   `{"rules": [{"name": "a"}]}`,
+			errMsg: `invalid span filtering rule "a": rule has no conditions and would drop every span`,
+		},
+		{
+			data:   `{"rules": [{"name": "a", "operation_regex": "("}]}`,
+			errMsg: `invalid span filtering rule "a": cannot compile operation_regex`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.errMsg, func(t *testing.T) {
+			_, err := parseRules([]byte(test.data))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errMsg)
+		})
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	r, err := newRule(ruleConfig{
+		Name:           "r",
+		Service:        "cache",
+		OperationRegex: "^get",
+		SpanKind:       "client",
+		Tags:           map[string]string{"cache.hit": "true"},
+	})
+	require.NoError(t, err)
+
+	span := func() *model.Span {
+		return &model.Span{
+			OperationName: "get-item",
+			Process:       &model.Process{ServiceName: "cache"},
+			Tags: model.KeyValues{
+				model.String("span.kind", "client"),
+				model.Bool("cache.hit", true),
+			},
+		}
+	}
+	assert.True(t, r.matches(span()))
+
+	s := span()
+	s.Process = nil
+	assert.False(t, r.matches(s))
+
+	s = span()
+	s.OperationName = "put-item"
+	assert.False(t, r.matches(s))
+
+	s = span()
+	s.Tags = s.Tags[1:]
+	assert.False(t, r.matches(s))
+
+	s = span()
+	s.Tags = s.Tags[:1]
+	assert.False(t, r.matches(s))
+
+	r.operation = "get-item"
+	assert.True(t, r.matches(span()))
+	r.operation = "get-other"
+	assert.False(t, r.matches(span()))
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package spanmetrics
+
+import (
+	"sync"
+
+	"github.com/uber/jaeger-lib/metrics"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/normalizer"
+)
+
+const (
+	// otherLabel is the catch-all label used when the number of combinations exceeds the cardinality cap
+	otherLabel = "other"
+
+	// unsetSpanKind is the label used for spans without a span.kind tag
+	unsetSpanKind = "unset"
+)
+
+type seriesKey struct {
+	service   string
+	operation string
+	spanKind  string
+}
+
+// redMetrics holds the rate, errors and duration metrics for a single series
+type redMetrics struct {
+	calls   metrics.Counter
+	errors  metrics.Counter
+	latency metrics.Timer
+}
+
+// Generator derives request rate, error count and latency metrics from spans,
+// grouped by service, operation and span kind.
+type Generator struct {
+	factory        metrics.Factory
+	maxCardinality int
+
+	lock   sync.Mutex
+	series map[seriesKey]*redMetrics
+	other  *redMetrics
+}
+
+// NewGenerator creates a Generator reporting to the given metrics factory.
+func NewGenerator(options Options, metricsFactory metrics.Factory) *Generator {
+	maxCardinality := options.MaxCardinality
+	if maxCardinality <= 0 {
+		maxCardinality = DefaultMaxCardinality
+	}
+	factory := metricsFactory.Namespace(metrics.NSOptions{Name: "span-metrics"})
+	g := &Generator{
+		factory:        factory,
+		maxCardinality: maxCardinality,
+		series:         make(map[seriesKey]*redMetrics),
+	}
+	g.other = g.newRedMetrics(seriesKey{service: otherLabel, operation: otherLabel, spanKind: otherLabel})
+	return g
+}
+
+// ProcessSpan records the span in the metrics of its service, operation and span kind.
+// It has the signature of app.ProcessSpan.
+func (g *Generator) ProcessSpan(span *model.Span) {
+	if span.Process == nil || span.Process.ServiceName == "" {
+		return
+	}
+	m := g.metricsFor(keyForSpan(span))
+	m.calls.Inc(1)
+	if span.IsError() {
+		m.errors.Inc(1)
+	}
+	m.latency.Record(span.Duration)
+}
+
+func (g *Generator) metricsFor(key seriesKey) *redMetrics {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if m, ok := g.series[key]; ok {
+		return m
+	}
+	if len(g.series) >= g.maxCardinality {
+		return g.other
+	}
+	m := g.newRedMetrics(key)
+	g.series[key] = m
+	return m
+}
+
+func (g *Generator) newRedMetrics(key seriesKey) *redMetrics {
+	tags := map[string]string{
+		"service":   key.service,
+		"operation": key.operation,
+		"span_kind": key.spanKind,
+	}
+	return &redMetrics{
+		calls:   g.factory.Counter(metrics.Options{Name: "calls", Tags: tags, Help: "Number of spans received"}),
+		errors:  g.factory.Counter(metrics.Options{Name: "errors", Tags: tags, Help: "Number of spans received with the error tag set"}),
+		latency: g.factory.Timer(metrics.TimerOptions{Name: "latency", Tags: tags, Help: "Duration of spans received"}),
+	}
+}
+
+func keyForSpan(span *model.Span) seriesKey {
+	spanKind, ok := span.GetSpanKind()
+	if !ok || spanKind == "" {
+		spanKind = unsetSpanKind
+	}
+	return seriesKey{
+		service:   normalizer.ServiceName(span.Process.ServiceName),
+		operation: span.OperationName,
+		spanKind:  spanKind,
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package spanmetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-lib/metrics"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func makeSpan(service, operation string, duration time.Duration, tags ...model.KeyValue) *model.Span {
+	return &model.Span{
+		OperationName: operation,
+		Duration:      duration,
+		Process:       &model.Process{ServiceName: service},
+		Tags:          tags,
+	}
+}
+
+func tagsFor(service, operation, spanKind string) map[string]string {
+	return map[string]string{"service": service, "operation": operation, "span_kind": spanKind}
+}
+
+func TestGeneratorProcessSpan(t *testing.T) {
+	mf := metricstest.NewFactory(time.Hour)
+	g := NewGenerator(Options{Enabled: true}, mf)
+
+	g.ProcessSpan(makeSpan("frontend", "GET /", 10*time.Millisecond, model.String("span.kind", "server")))
+	g.ProcessSpan(makeSpan("frontend", "GET /", 30*time.Millisecond, model.String("span.kind", "server"), model.Bool("error", true)))
+	g.ProcessSpan(makeSpan("frontend", "GET /", 5*time.Millisecond, model.String("span.kind", "client"), model.String("error", "true")))
+	g.ProcessSpan(makeSpan("frontend", "query", time.Millisecond, model.Bool("error", false)))
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "span-metrics.calls", Tags: tagsFor("frontend", "GET /", "server"), Value: 2},
+		metricstest.ExpectedMetric{Name: "span-metrics.errors", Tags: tagsFor("frontend", "GET /", "server"), Value: 1},
+		metricstest.ExpectedMetric{Name: "span-metrics.calls", Tags: tagsFor("frontend", "GET /", "client"), Value: 1},
+		metricstest.ExpectedMetric{Name: "span-metrics.errors", Tags: tagsFor("frontend", "GET /", "client"), Value: 1},
+		metricstest.ExpectedMetric{Name: "span-metrics.calls", Tags: tagsFor("frontend", "query", unsetSpanKind), Value: 1},
+		metricstest.ExpectedMetric{Name: "span-metrics.errors", Tags: tagsFor("frontend", "query", unsetSpanKind), Value: 0},
+	)
+
+	_, gauges := mf.Snapshot()
+	latencyKey := metrics.GetKey("span-metrics.latency", tagsFor("frontend", "query", unsetSpanKind), "|", "=")
+	assert.EqualValues(t, 1, gauges[latencyKey+".P99"])
+}
+
+func TestGeneratorMaxCardinality(t *testing.T) {
+	mf := metricstest.NewFactory(time.Hour)
+	g := NewGenerator(Options{Enabled: true, MaxCardinality: 2}, mf)
+
+	g.ProcessSpan(makeSpan("svc", "op1", time.Millisecond))
+	g.ProcessSpan(makeSpan("svc", "op2", time.Millisecond))
+	g.ProcessSpan(makeSpan("svc", "op3", time.Millisecond))
+	g.ProcessSpan(makeSpan("svc", "op4", time.Millisecond, model.Bool("error", true)))
+	g.ProcessSpan(makeSpan("svc", "op1", time.Millisecond))
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "span-metrics.calls", Tags: tagsFor("svc", "op1", unsetSpanKind), Value: 2},
+		metricstest.ExpectedMetric{Name: "span-metrics.calls", Tags: tagsFor("svc", "op2", unsetSpanKind), Value: 1},
+		metricstest.ExpectedMetric{Name: "span-metrics.calls", Tags: tagsFor(otherLabel, otherLabel, otherLabel), Value: 2},
+		metricstest.ExpectedMetric{Name: "span-metrics.errors", Tags: tagsFor(otherLabel, otherLabel, otherLabel), Value: 1},
+	)
+	assert.Len(t, g.series, 2)
+}
+
+func TestGeneratorSkipsSpansWithoutService(t *testing.T) {
+	mf := metricstest.NewFactory(time.Hour)
+	g := NewGenerator(Options{}, mf)
+	assert.Equal(t, DefaultMaxCardinality, g.maxCardinality)
+
+	g.ProcessSpan(&model.Span{OperationName: "op"})
+	g.ProcessSpan(makeSpan("", "op", time.Millisecond))
+	assert.Empty(t, g.series)
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package spanmetrics
+
+import (
+	"flag"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	spanMetricsEnabled        = "collector.span-metrics.enabled"
+	spanMetricsMaxCardinality = "collector.span-metrics.max-cardinality"
+
+	// DefaultMaxCardinality is the default maximum number of distinct
+	// service, operation and span kind combinations that are reported
+	DefaultMaxCardinality = 10000
+)
+
+// Options holds configuration for the RED metrics derived from spans.
+type Options struct {
+	// Enabled turns on the generation of request, error and latency metrics from spans
+	Enabled bool
+	// MaxCardinality is the maximum number of distinct service, operation and span kind
+	// combinations to report. Spans beyond this limit are reported under a catch-all label.
+	MaxCardinality int
+}
+
+// AddFlags adds flags for Options
+func AddFlags(flagSet *flag.FlagSet) {
+	flagSet.Bool(spanMetricsEnabled, false, "Enables request rate, error count and latency metrics per service, operation and span kind, derived from received spans")
+	flagSet.Int(spanMetricsMaxCardinality, DefaultMaxCardinality, "The maximum number of distinct service, operation and span kind combinations for span metrics. Further combinations are reported as "+otherLabel)
+}
+
+// InitFromViper initializes Options with properties from viper
+func (opts *Options) InitFromViper(v *viper.Viper) *Options {
+	opts.Enabled = v.GetBool(spanMetricsEnabled)
+	opts.MaxCardinality = v.GetInt(spanMetricsMaxCardinality)
+	return opts
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tailsampling
+
+import (
+	"flag"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	tailSamplingPoliciesFile     = "collector.tail-sampling.policies-file"
+	tailSamplingDecisionWait     = "collector.tail-sampling.decision-wait"
+	tailSamplingMaxTraces        = "collector.tail-sampling.max-traces"
+	tailSamplingMaxSpansPerTrace = "collector.tail-sampling.max-spans-per-trace"
+
+	defaultDecisionWait     = 10 * time.Second
+	defaultMaxTraces        = 50000
+	defaultMaxSpansPerTrace = 5000
+)
+
+// Options holds configuration for the tail-based sampling stage.
+type Options struct {
+	// PoliciesFile is the path to the JSON file with sampling policies. Tail-based sampling
+	// is disabled when it is empty.
+	PoliciesFile string
+	// DecisionWait is how long spans of a trace are buffered, counting from the arrival of the
+	// first span, before the sampling decision for the whole trace is made.
+	DecisionWait time.Duration
+	// MaxTraces is the maximum number of traces buffered in memory. When it is reached, the
+	// decision for the oldest trace is made early to make room for a new one.
+	MaxTraces int
+	// MaxSpansPerTrace is the maximum number of spans buffered for a single trace. When it is
+	// reached, the decision for that trace is made early.
+	MaxSpansPerTrace int
+}
+
+// AddFlags adds flags for Options
+func AddFlags(flagSet *flag.FlagSet) {
+	flagSet.String(tailSamplingPoliciesFile, "", "(experimental) The path for the tail-based sampling policies file in JSON format. Tail-based sampling is disabled if empty")
+	flagSet.Duration(tailSamplingDecisionWait, defaultDecisionWait, "(experimental) How long to buffer the spans of a trace before making the tail-based sampling decision")
+	flagSet.Int(tailSamplingMaxTraces, defaultMaxTraces, "(experimental) The maximum number of traces buffered in memory by the tail-based sampler")
+	flagSet.Int(tailSamplingMaxSpansPerTrace, defaultMaxSpansPerTrace, "(experimental) The maximum number of spans buffered for a single trace by the tail-based sampler")
+}
+
+// InitFromViper initializes Options with properties from viper
+func (opts *Options) InitFromViper(v *viper.Viper) *Options {
+	opts.PoliciesFile = v.GetString(tailSamplingPoliciesFile)
+	opts.DecisionWait = v.GetDuration(tailSamplingDecisionWait)
+	opts.MaxTraces = v.GetInt(tailSamplingMaxTraces)
+	opts.MaxSpansPerTrace = v.GetInt(tailSamplingMaxSpansPerTrace)
+	return opts
+}
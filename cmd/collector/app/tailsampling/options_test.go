@@ -0,0 +1,48 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tailsampling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jaegertracing/jaeger/pkg/config"
+)
+
+func TestOptionsWithFlags(t *testing.T) {
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.tail-sampling.policies-file=fixtures/policies.json",
+		"--collector.tail-sampling.decision-wait=30s",
+		"--collector.tail-sampling.max-traces=100",
+		"--collector.tail-sampling.max-spans-per-trace=10",
+	})
+	opts := new(Options).InitFromViper(v)
+	assert.Equal(t, "fixtures/policies.json", opts.PoliciesFile)
+	assert.Equal(t, 30*time.Second, opts.DecisionWait)
+	assert.Equal(t, 100, opts.MaxTraces)
+	assert.Equal(t, 10, opts.MaxSpansPerTrace)
+}
+
+func TestOptionsDefaults(t *testing.T) {
+	v, _ := config.Viperize(AddFlags)
+	opts := new(Options).InitFromViper(v)
+	assert.Empty(t, opts.PoliciesFile)
+	assert.Equal(t, defaultDecisionWait, opts.DecisionWait)
+	assert.Equal(t, defaultMaxTraces, opts.MaxTraces)
+	assert.Equal(t, defaultMaxSpansPerTrace, opts.MaxSpansPerTrace)
+}
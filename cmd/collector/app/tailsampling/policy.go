@@ -0,0 +1,263 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tailsampling
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+const (
+	errorPolicyType         = "error"
+	latencyPolicyType       = "latency"
+	tagPolicyType           = "tag"
+	probabilisticPolicyType = "probabilistic"
+)
+
+// Policy decides whether a trace should be kept, based on all spans buffered for it.
+type Policy interface {
+	// Name returns the name of the policy, used in metrics.
+	Name() string
+	// ShouldSample returns true if the trace with the given spans should be kept.
+	ShouldSample(traceID model.TraceID, spans []*model.Span) bool
+}
+
+// policyConfig is the JSON representation of a policy in the policies file.
+type policyConfig struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+
+	// Threshold is used by the latency policy, e.g. "1.5s"
+	Threshold string `json:"threshold,omitempty"`
+
+	// Key and Values are used by the tag policy. Any value matches if Values is empty.
+	Key    string   `json:"key,omitempty"`
+	Values []string `json:"values,omitempty"`
+
+	// DefaultRate and ServiceRates are used by the probabilistic policy.
+	DefaultRate  float64            `json:"default_rate,omitempty"`
+	ServiceRates map[string]float64 `json:"service_rates,omitempty"`
+}
+
+type policiesConfig struct {
+	Policies []policyConfig `json:"policies"`
+}
+
+// LoadPolicies reads tail-based sampling policies from a JSON file.
+func LoadPolicies(path string) ([]Policy, error) {
+	data, err := ioutil.ReadFile(path) /* nolint #nosec , this comes from an admin, not user */
+	if err != nil {
+		return nil, fmt.Errorf("failed to open policies file: %w", err)
+	}
+	var cfg policiesConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal policies: %w", err)
+	}
+	return parsePolicies(cfg)
+}
+
+func parsePolicies(cfg policiesConfig) ([]Policy, error) {
+	if len(cfg.Policies) == 0 {
+		return nil, errors.New("no tail-based sampling policies defined")
+	}
+	policies := make([]Policy, 0, len(cfg.Policies))
+	names := make(map[string]struct{})
+	for _, pc := range cfg.Policies {
+		if pc.Name == "" {
+			pc.Name = pc.Type
+		}
+		if _, ok := names[pc.Name]; ok {
+			return nil, fmt.Errorf("duplicate tail-based sampling policy name %q", pc.Name)
+		}
+		names[pc.Name] = struct{}{}
+		p, err := newPolicy(pc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tail-based sampling policy %q: %w", pc.Name, err)
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
+
+func newPolicy(pc policyConfig) (Policy, error) {
+	switch pc.Type {
+	case errorPolicyType:
+		return &errorPolicy{name: pc.Name}, nil
+	case latencyPolicyType:
+		threshold, err := time.ParseDuration(pc.Threshold)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse threshold: %w", err)
+		}
+		return &latencyPolicy{name: pc.Name, threshold: threshold}, nil
+	case tagPolicyType:
+		if pc.Key == "" {
+			return nil, errors.New("tag key must not be empty")
+		}
+		values := make(map[string]struct{}, len(pc.Values))
+		for _, v := range pc.Values {
+			values[v] = struct{}{}
+		}
+		return &tagPolicy{name: pc.Name, key: pc.Key, values: values}, nil
+	case probabilisticPolicyType:
+		if err := validateRate(pc.DefaultRate); err != nil {
+			return nil, err
+		}
+		p := &probabilisticPolicy{
+			name:           pc.Name,
+			defaultSampler: spanstore.NewSampler(pc.DefaultRate, ""),
+			serviceSampler: make(map[string]*spanstore.Sampler, len(pc.ServiceRates)),
+		}
+		for svc, rate := range pc.ServiceRates {
+			if err := validateRate(rate); err != nil {
+				return nil, err
+			}
+			p.serviceSampler[svc] = spanstore.NewSampler(rate, "")
+		}
+		return p, nil
+	default:
+		return nil, fmt.Errorf("unknown policy type %q", pc.Type)
+	}
+}
+
+func validateRate(rate float64) error {
+	if rate < 0 || rate > 1 {
+		return fmt.Errorf("sampling rate %v must be between 0 and 1", rate)
+	}
+	return nil
+}
+
+// errorPolicy keeps traces where any span is marked with error=true.
+type errorPolicy struct {
+	name string
+}
+
+func (p *errorPolicy) Name() string {
+	return p.name
+}
+
+func (p *errorPolicy) ShouldSample(_ model.TraceID, spans []*model.Span) bool {
+	for _, span := range spans {
+		if span.IsError() {
+			return true
+		}
+	}
+	return false
+}
+
+// latencyPolicy keeps traces where the root span took longer than the threshold.
+// If the root span has not been received, the time range covered by all buffered spans is used.
+type latencyPolicy struct {
+	name      string
+	threshold time.Duration
+}
+
+func (p *latencyPolicy) Name() string {
+	return p.name
+}
+
+func (p *latencyPolicy) ShouldSample(_ model.TraceID, spans []*model.Span) bool {
+	if root := findRoot(spans); root != nil {
+		return root.Duration > p.threshold
+	}
+	var start, end time.Time
+	for i, span := range spans {
+		spanEnd := span.StartTime.Add(span.Duration)
+		if i == 0 || span.StartTime.Before(start) {
+			start = span.StartTime
+		}
+		if i == 0 || spanEnd.After(end) {
+			end = spanEnd
+		}
+	}
+	return end.Sub(start) > p.threshold
+}
+
+// tagPolicy keeps traces where any span has a tag, or a process tag, with the given key
+// and one of the given values. Any value matches if no values are configured.
+type tagPolicy struct {
+	name   string
+	key    string
+	values map[string]struct{}
+}
+
+func (p *tagPolicy) Name() string {
+	return p.name
+}
+
+func (p *tagPolicy) ShouldSample(_ model.TraceID, spans []*model.Span) bool {
+	for _, span := range spans {
+		if p.matches(span.Tags) {
+			return true
+		}
+		if span.Process != nil && p.matches(span.Process.Tags) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *tagPolicy) matches(tags model.KeyValues) bool {
+	tag, ok := tags.FindByKey(p.key)
+	if !ok {
+		return false
+	}
+	if len(p.values) == 0 {
+		return true
+	}
+	_, ok = p.values[tag.AsString()]
+	return ok
+}
+
+// probabilisticPolicy keeps a percentage of traces, with the rate chosen by the service of the
+// root span. The decision is a deterministic function of the trace ID, so that collectors that
+// each receive a part of the same trace arrive at the same decision.
+type probabilisticPolicy struct {
+	name           string
+	defaultSampler *spanstore.Sampler
+	serviceSampler map[string]*spanstore.Sampler
+}
+
+func (p *probabilisticPolicy) Name() string {
+	return p.name
+}
+
+func (p *probabilisticPolicy) ShouldSample(traceID model.TraceID, spans []*model.Span) bool {
+	sampler := p.defaultSampler
+	span := findRoot(spans)
+	if span == nil && len(spans) > 0 {
+		span = spans[0]
+	}
+	if span != nil && span.Process != nil {
+		if s, ok := p.serviceSampler[span.Process.ServiceName]; ok {
+			sampler = s
+		}
+	}
+	return sampler.ShouldSample(&model.Span{TraceID: traceID})
+}
+
+func findRoot(spans []*model.Span) *model.Span {
+	for _, span := range spans {
+		if span.ParentSpanID() == model.NewSpanID(0) {
+			return span
+		}
+	}
+	return nil
+}
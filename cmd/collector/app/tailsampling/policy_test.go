@@ -0,0 +1,159 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tailsampling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func TestLoadPolicies(t *testing.T) {
+	policies, err := LoadPolicies("fixtures/policies.json")
+	require.NoError(t, err)
+	require.Len(t, policies, 4)
+	assert.Equal(t, "errors", policies[0].Name())
+	assert.Equal(t, "slow", policies[1].Name())
+	assert.Equal(t, "vip", policies[2].Name())
+	assert.Equal(t, "baseline", policies[3].Name())
+
+	_, err = LoadPolicies("fixtures/missing.json")
+	assert.Contains(t, err.Error(), "failed to open policies file")
+
+	_, err = LoadPolicies("fixtures/bad_policies.json")
+	assert.Contains(t, err.Error(), `invalid tail-based sampling policy "latency": cannot parse threshold`)
+
+	_, err = LoadPolicies("policy_test.go")
+	assert.Contains(t, err.Error(), "failed to unmarshal policies")
+}
+
+func TestParsePoliciesErrors(t *testing.T) {
+	tests := []struct {
+		cfg    policiesConfig
+		errMsg string
+	}{
+		{
+			cfg:    policiesConfig{},
+			errMsg: "no tail-based sampling policies defined",
+		},
+		{
+			cfg:    policiesConfig{Policies: []policyConfig{{Type: "error"}, {Type: "error"}}},
+			errMsg: `duplicate tail-based sampling policy name "error"`,
+		},
+		{
+			cfg:    policiesConfig{Policies: []policyConfig{{Type: "nonsense"}}},
+			errMsg: `invalid tail-based sampling policy "nonsense": unknown policy type "nonsense"`,
+		},
+		{
+			cfg:    policiesConfig{Policies: []policyConfig{{Type: "tag"}}},
+			errMsg: `invalid tail-based sampling policy "tag": tag key must not be empty`,
+		},
+		{
+			cfg:    policiesConfig{Policies: []policyConfig{{Type: "probabilistic", DefaultRate: 2}}},
+			errMsg: `invalid tail-based sampling policy "probabilistic": sampling rate 2 must be between 0 and 1`,
+		},
+		{
+			cfg:    policiesConfig{Policies: []policyConfig{{Type: "probabilistic", ServiceRates: map[string]float64{"a": -1}}}},
+			errMsg: `invalid tail-based sampling policy "probabilistic": sampling rate -1 must be between 0 and 1`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.errMsg, func(t *testing.T) {
+			_, err := parsePolicies(test.cfg)
+			assert.EqualError(t, err, test.errMsg)
+		})
+	}
+}
+
+func TestErrorPolicy(t *testing.T) {
+	p := &errorPolicy{name: "errors"}
+	assert.False(t, p.ShouldSample(model.TraceID{}, []*model.Span{{}}))
+	assert.False(t, p.ShouldSample(model.TraceID{}, []*model.Span{{Tags: model.KeyValues{model.Bool("error", false)}}}))
+	assert.True(t, p.ShouldSample(model.TraceID{}, []*model.Span{{}, {Tags: model.KeyValues{model.Bool("error", true)}}}))
+	assert.True(t, p.ShouldSample(model.TraceID{}, []*model.Span{{Tags: model.KeyValues{model.String("error", "true")}}}))
+}
+
+func TestLatencyPolicy(t *testing.T) {
+	p := &latencyPolicy{name: "slow", threshold: time.Second}
+	traceID := model.NewTraceID(0, 1)
+	start := time.Unix(100, 0)
+	root := &model.Span{TraceID: traceID, SpanID: 1, StartTime: start, Duration: 500 * time.Millisecond}
+	child := &model.Span{
+		TraceID:    traceID,
+		SpanID:     2,
+		References: []model.SpanRef{model.NewChildOfRef(traceID, 1)},
+		StartTime:  start.Add(time.Second),
+		Duration:   time.Second,
+	}
+	// root span determines the latency
+	assert.False(t, p.ShouldSample(traceID, []*model.Span{root, child}))
+	root.Duration = 2 * time.Second
+	assert.True(t, p.ShouldSample(traceID, []*model.Span{child, root}))
+
+	// without root span, the range covered by all spans is used
+	other := &model.Span{
+		TraceID:    traceID,
+		SpanID:     3,
+		References: []model.SpanRef{model.NewChildOfRef(traceID, 1)},
+		StartTime:  start,
+		Duration:   100 * time.Millisecond,
+	}
+	assert.False(t, p.ShouldSample(traceID, []*model.Span{other}))
+	assert.True(t, p.ShouldSample(traceID, []*model.Span{other, child}))
+}
+
+func TestTagPolicy(t *testing.T) {
+	p := &tagPolicy{name: "vip", key: "tier", values: map[string]struct{}{"gold": {}}}
+	assert.False(t, p.ShouldSample(model.TraceID{}, []*model.Span{{}}))
+	assert.False(t, p.ShouldSample(model.TraceID{}, []*model.Span{{Tags: model.KeyValues{model.String("tier", "bronze")}}}))
+	assert.True(t, p.ShouldSample(model.TraceID{}, []*model.Span{{Tags: model.KeyValues{model.String("tier", "gold")}}}))
+	assert.True(t, p.ShouldSample(model.TraceID{}, []*model.Span{{
+		Process: &model.Process{Tags: model.KeyValues{model.String("tier", "gold")}},
+	}}))
+
+	anyValue := &tagPolicy{name: "any", key: "tier"}
+	assert.True(t, anyValue.ShouldSample(model.TraceID{}, []*model.Span{{Tags: model.KeyValues{model.String("tier", "bronze")}}}))
+}
+
+func TestProbabilisticPolicy(t *testing.T) {
+	policies, err := parsePolicies(policiesConfig{Policies: []policyConfig{{
+		Type:         "probabilistic",
+		DefaultRate:  0,
+		ServiceRates: map[string]float64{"all": 1},
+	}}})
+	require.NoError(t, err)
+	p := policies[0]
+
+	spans := func(service string) []*model.Span {
+		return []*model.Span{{Process: &model.Process{ServiceName: service}}}
+	}
+	sampled := map[string]int{}
+	for i := uint64(1); i <= 100; i++ {
+		traceID := model.NewTraceID(0, i)
+		if p.ShouldSample(traceID, spans("all")) {
+			sampled["all"]++
+		}
+		if p.ShouldSample(traceID, spans("other")) {
+			sampled["other"]++
+		}
+	}
+	assert.Equal(t, 100, sampled["all"])
+	assert.Equal(t, 0, sampled["other"])
+	assert.False(t, p.ShouldSample(model.NewTraceID(0, 1), nil))
+}
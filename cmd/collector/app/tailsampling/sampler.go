@@ -0,0 +1,265 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tailsampling
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/cache"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+const (
+	// decisionCacheSizeFactor determines how many past decisions are remembered, relative to
+	// MaxTraces, so that spans arriving after the decision follow the fate of their trace.
+	decisionCacheSizeFactor = 2
+	// maxTickInterval caps how often buffered traces are checked for an expired decision window.
+	maxTickInterval = time.Second
+)
+
+// samplerMetrics holds metrics for the tail-based sampler.
+type samplerMetrics struct {
+	// TracesNotSampled counts traces for which no policy decided to keep them
+	TracesNotSampled metrics.Counter `metric:"tail_sampling.traces" tags:"decision=not_sampled"`
+	// SpansSampled counts spans written to storage as part of a kept trace
+	SpansSampled metrics.Counter `metric:"tail_sampling.spans" tags:"decision=sampled"`
+	// SpansNotSampled counts spans dropped as part of a dropped trace
+	SpansNotSampled metrics.Counter `metric:"tail_sampling.spans" tags:"decision=not_sampled"`
+	// LateSpans counts spans that arrived after the decision for their trace was made
+	LateSpans metrics.Counter `metric:"tail_sampling.late_spans"`
+	// EarlyDecisions counts traces decided before the end of the decision window because a limit was reached
+	EarlyDecisions metrics.Counter `metric:"tail_sampling.early_decisions"`
+	// WriteErrors counts failures to write kept spans to the underlying writer
+	WriteErrors metrics.Counter `metric:"tail_sampling.write_errors"`
+	// BufferedTraces is the number of traces awaiting a decision
+	BufferedTraces metrics.Gauge `metric:"tail_sampling.buffered_traces"`
+}
+
+type bufferedTrace struct {
+	traceID   model.TraceID
+	spans     []*model.Span
+	firstSeen time.Time
+	sampled   bool
+}
+
+// Sampler is a spanstore.Writer that buffers spans by trace ID for a decision window, and then
+// either writes all spans of the trace to the underlying writer or drops them, depending on the
+// configured policies. A trace is kept if any of the policies decides to keep it.
+type Sampler struct {
+	writer           spanstore.Writer
+	policies         []Policy
+	decisionWait     time.Duration
+	maxTraces        int
+	maxSpansPerTrace int
+	logger           *zap.Logger
+	metrics          samplerMetrics
+	sampledByPolicy  map[string]metrics.Counter
+	timeNow          func() time.Time
+
+	mu sync.Mutex
+	// traces maps trace IDs to the elements of the order list
+	traces map[model.TraceID]*list.Element
+	// order holds *bufferedTrace in the order of arrival of their first span
+	order *list.List
+	// decisions remembers recent decisions, keyed by trace ID
+	decisions *cache.LRU
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// NewSampler creates a tail-based Sampler that writes kept traces to the given writer.
+func NewSampler(
+	writer spanstore.Writer,
+	policies []Policy,
+	opts Options,
+	metricsFactory metrics.Factory,
+	logger *zap.Logger,
+) *Sampler {
+	if opts.DecisionWait <= 0 {
+		opts.DecisionWait = defaultDecisionWait
+	}
+	if opts.MaxTraces <= 0 {
+		opts.MaxTraces = defaultMaxTraces
+	}
+	if opts.MaxSpansPerTrace <= 0 {
+		opts.MaxSpansPerTrace = defaultMaxSpansPerTrace
+	}
+	s := &Sampler{
+		writer:           writer,
+		policies:         policies,
+		decisionWait:     opts.DecisionWait,
+		maxTraces:        opts.MaxTraces,
+		maxSpansPerTrace: opts.MaxSpansPerTrace,
+		logger:           logger,
+		sampledByPolicy:  make(map[string]metrics.Counter, len(policies)),
+		timeNow:          time.Now,
+		traces:           make(map[model.TraceID]*list.Element),
+		order:            list.New(),
+		decisions:        cache.NewLRU(opts.MaxTraces * decisionCacheSizeFactor),
+		stopCh:           make(chan struct{}),
+		doneCh:           make(chan struct{}),
+	}
+	metrics.Init(&s.metrics, metricsFactory, nil)
+	for _, p := range policies {
+		s.sampledByPolicy[p.Name()] = metricsFactory.Counter(metrics.Options{
+			Name: "tail_sampling.traces",
+			Tags: map[string]string{"decision": "sampled", "policy": p.Name()},
+		})
+	}
+	go s.runDecisionLoop()
+	return s
+}
+
+// WriteSpan buffers the span until the sampling decision for its trace is made.
+// Spans of traces that have already been decided are written or dropped immediately.
+func (s *Sampler) WriteSpan(span *model.Span) error {
+	s.mu.Lock()
+	if decision := s.decisions.Get(span.TraceID.String()); decision != nil {
+		s.mu.Unlock()
+		s.metrics.LateSpans.Inc(1)
+		if decision.(bool) {
+			return s.write([]*model.Span{span})
+		}
+		s.metrics.SpansNotSampled.Inc(1)
+		return nil
+	}
+
+	var ready []*bufferedTrace
+	elem, ok := s.traces[span.TraceID]
+	if !ok {
+		if s.order.Len() >= s.maxTraces {
+			ready = append(ready, s.decideLocked(s.order.Front()))
+			s.metrics.EarlyDecisions.Inc(1)
+		}
+		elem = s.order.PushBack(&bufferedTrace{traceID: span.TraceID, firstSeen: s.timeNow()})
+		s.traces[span.TraceID] = elem
+	}
+	trace := elem.Value.(*bufferedTrace)
+	trace.spans = append(trace.spans, span)
+	if len(trace.spans) >= s.maxSpansPerTrace {
+		ready = append(ready, s.decideLocked(elem))
+		s.metrics.EarlyDecisions.Inc(1)
+	}
+	s.mu.Unlock()
+
+	return s.flush(ready)
+}
+
+// Close stops the decision loop and makes decisions for all traces still buffered.
+// It does not close the underlying writer.
+func (s *Sampler) Close() error {
+	close(s.stopCh)
+	<-s.doneCh
+
+	s.mu.Lock()
+	var ready []*bufferedTrace
+	for s.order.Len() > 0 {
+		ready = append(ready, s.decideLocked(s.order.Front()))
+	}
+	s.mu.Unlock()
+	return s.flush(ready)
+}
+
+func (s *Sampler) runDecisionLoop() {
+	defer close(s.doneCh)
+	interval := s.decisionWait / 10
+	if interval > maxTickInterval || interval <= 0 {
+		interval = maxTickInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.flush(s.expiredTraces()); err != nil {
+				s.logger.Error("Failed to write tail-sampled spans", zap.Error(err))
+			}
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// expiredTraces decides, removes and returns traces whose decision window has elapsed.
+func (s *Sampler) expiredTraces() []*bufferedTrace {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deadline := s.timeNow().Add(-s.decisionWait)
+	var ready []*bufferedTrace
+	for elem := s.order.Front(); elem != nil; elem = s.order.Front() {
+		if elem.Value.(*bufferedTrace).firstSeen.After(deadline) {
+			break
+		}
+		ready = append(ready, s.decideLocked(elem))
+	}
+	s.metrics.BufferedTraces.Update(int64(s.order.Len()))
+	return ready
+}
+
+// decideLocked removes the trace from the buffer, evaluates the policies for it and remembers
+// the decision, so that late spans of the trace follow it. The caller must hold s.mu.
+func (s *Sampler) decideLocked(elem *list.Element) *bufferedTrace {
+	trace := s.order.Remove(elem).(*bufferedTrace)
+	delete(s.traces, trace.traceID)
+	trace.sampled = s.evaluate(trace)
+	s.decisions.Put(trace.traceID.String(), trace.sampled)
+	return trace
+}
+
+// flush writes the spans of the kept traces to the underlying writer.
+func (s *Sampler) flush(traces []*bufferedTrace) error {
+	var lastErr error
+	for _, trace := range traces {
+		if !trace.sampled {
+			s.metrics.TracesNotSampled.Inc(1)
+			s.metrics.SpansNotSampled.Inc(int64(len(trace.spans)))
+			continue
+		}
+		if err := s.write(trace.spans); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (s *Sampler) evaluate(trace *bufferedTrace) bool {
+	for _, p := range s.policies {
+		if p.ShouldSample(trace.traceID, trace.spans) {
+			s.sampledByPolicy[p.Name()].Inc(1)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Sampler) write(spans []*model.Span) error {
+	var lastErr error
+	for _, span := range spans {
+		if err := s.writer.WriteSpan(span); err != nil {
+			s.metrics.WriteErrors.Inc(1)
+			lastErr = err
+			continue
+		}
+		s.metrics.SpansSampled.Inc(1)
+	}
+	return lastErr
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tailsampling
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+type fakeWriter struct {
+	sync.Mutex
+	spans []*model.Span
+	err   error
+}
+
+func (w *fakeWriter) WriteSpan(span *model.Span) error {
+	w.Lock()
+	defer w.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.spans = append(w.spans, span)
+	return nil
+}
+
+func (w *fakeWriter) count() int {
+	w.Lock()
+	defer w.Unlock()
+	return len(w.spans)
+}
+
+func makeSpan(traceID uint64, spanID uint64, tags ...model.KeyValue) *model.Span {
+	return &model.Span{
+		TraceID: model.NewTraceID(0, traceID),
+		SpanID:  model.NewSpanID(spanID),
+		Tags:    tags,
+		Process: &model.Process{ServiceName: "svc"},
+	}
+}
+
+func newTestSampler(t *testing.T, writer *fakeWriter, opts Options) (*Sampler, *metricstest.Factory) {
+	policies, err := parsePolicies(policiesConfig{Policies: []policyConfig{{Name: "errors", Type: errorPolicyType}}})
+	require.NoError(t, err)
+	metricsFactory := metricstest.NewFactory(0)
+	return NewSampler(writer, policies, opts, metricsFactory, zap.NewNop()), metricsFactory
+}
+
+func TestSamplerDecisionWindow(t *testing.T) {
+	writer := &fakeWriter{}
+	s, metricsFactory := newTestSampler(t, writer, Options{DecisionWait: 10 * time.Millisecond})
+	defer s.Close()
+
+	require.NoError(t, s.WriteSpan(makeSpan(1, 1)))
+	require.NoError(t, s.WriteSpan(makeSpan(1, 2, model.Bool("error", true))))
+	require.NoError(t, s.WriteSpan(makeSpan(2, 1)))
+	assert.Equal(t, 0, writer.count(), "spans must be buffered until the decision is made")
+
+	for i := 0; i < 100 && writer.count() < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, 2, writer.count())
+
+	// late spans follow the decision made for their trace
+	require.NoError(t, s.WriteSpan(makeSpan(1, 3)))
+	require.NoError(t, s.WriteSpan(makeSpan(2, 2)))
+	assert.Equal(t, 3, writer.count())
+
+	metricsFactory.AssertCounterMetrics(t, []metricstest.ExpectedMetric{
+		{Name: "tail_sampling.traces", Tags: map[string]string{"decision": "sampled", "policy": "errors"}, Value: 1},
+		{Name: "tail_sampling.traces", Tags: map[string]string{"decision": "not_sampled"}, Value: 1},
+		{Name: "tail_sampling.spans", Tags: map[string]string{"decision": "sampled"}, Value: 3},
+		{Name: "tail_sampling.spans", Tags: map[string]string{"decision": "not_sampled"}, Value: 2},
+		{Name: "tail_sampling.late_spans", Value: 2},
+	}...)
+}
+
+func TestSamplerMaxTraces(t *testing.T) {
+	writer := &fakeWriter{}
+	s, metricsFactory := newTestSampler(t, writer, Options{DecisionWait: time.Hour, MaxTraces: 2})
+	defer s.Close()
+
+	require.NoError(t, s.WriteSpan(makeSpan(1, 1, model.Bool("error", true))))
+	require.NoError(t, s.WriteSpan(makeSpan(2, 1)))
+	assert.Equal(t, 0, writer.count())
+
+	// third trace forces the decision for the oldest one
+	require.NoError(t, s.WriteSpan(makeSpan(3, 1)))
+	assert.Equal(t, 1, writer.count())
+	assert.Equal(t, model.NewTraceID(0, 1), writer.spans[0].TraceID)
+
+	metricsFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "tail_sampling.early_decisions", Value: 1,
+	})
+}
+
+func TestSamplerMaxSpansPerTrace(t *testing.T) {
+	writer := &fakeWriter{}
+	s, _ := newTestSampler(t, writer, Options{DecisionWait: time.Hour, MaxSpansPerTrace: 2})
+	defer s.Close()
+
+	require.NoError(t, s.WriteSpan(makeSpan(1, 1, model.Bool("error", true))))
+	assert.Equal(t, 0, writer.count())
+	require.NoError(t, s.WriteSpan(makeSpan(1, 2)))
+	assert.Equal(t, 2, writer.count())
+}
+
+func TestSamplerCloseFlushes(t *testing.T) {
+	writer := &fakeWriter{}
+	s, _ := newTestSampler(t, writer, Options{DecisionWait: time.Hour})
+
+	require.NoError(t, s.WriteSpan(makeSpan(1, 1, model.Bool("error", true))))
+	require.NoError(t, s.WriteSpan(makeSpan(2, 1)))
+	require.NoError(t, s.Close())
+	assert.Equal(t, 1, writer.count())
+}
+
+func TestSamplerWriteError(t *testing.T) {
+	writer := &fakeWriter{err: errors.New("write error")}
+	s, metricsFactory := newTestSampler(t, writer, Options{DecisionWait: time.Hour, MaxSpansPerTrace: 1})
+	defer s.Close()
+
+	assert.EqualError(t, s.WriteSpan(makeSpan(1, 1, model.Bool("error", true))), "write error")
+	metricsFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "tail_sampling.write_errors", Value: 1,
+	})
+}
@@ -27,12 +27,14 @@ import (
 	"github.com/go-openapi/loads"
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
-	"github.com/golang/protobuf/proto"
+	"github.com/gogo/protobuf/proto"
 	"github.com/gorilla/mux"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/ratelimit"
 	"github.com/jaegertracing/jaeger/model/converter/thrift/zipkin"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	zipkinProto "github.com/jaegertracing/jaeger/proto-gen/zipkin"
 	"github.com/jaegertracing/jaeger/swagger-gen/models"
 	"github.com/jaegertracing/jaeger/swagger-gen/restapi"
@@ -44,23 +46,29 @@ import (
 type APIHandler struct {
 	zipkinSpansHandler handler.ZipkinSpansHandler
 	zipkinV2Formats    strfmt.Registry
+	tenancyMgr         *tenancy.Manager
+	limiter            *ratelimit.Limiter
 }
 
-// NewAPIHandler returns a new APIHandler
+// NewAPIHandler returns a new APIHandler, the limiter identifies the clients for rate limiting and may be nil
 func NewAPIHandler(
 	zipkinSpansHandler handler.ZipkinSpansHandler,
+	tenancyMgr *tenancy.Manager,
+	limiter *ratelimit.Limiter,
 ) *APIHandler {
 	swaggerSpec, _ := loads.Analyzed(restapi.SwaggerJSON, "")
 	return &APIHandler{
 		zipkinSpansHandler: zipkinSpansHandler,
 		zipkinV2Formats:    operations.NewZipkinAPI(swaggerSpec).Formats(),
+		tenancyMgr:         tenancyMgr,
+		limiter:            limiter,
 	}
 }
 
 // RegisterRoutes registers Zipkin routes
 func (aH *APIHandler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/api/v1/spans", aH.saveSpans).Methods(http.MethodPost)
-	router.HandleFunc("/api/v2/spans", aH.saveSpansV2).Methods(http.MethodPost)
+	router.Handle("/api/v1/spans", tenancy.ExtractTenantHTTPHandler(aH.tenancyMgr, http.HandlerFunc(aH.saveSpans))).Methods(http.MethodPost)
+	router.Handle("/api/v2/spans", tenancy.ExtractTenantHTTPHandler(aH.tenancyMgr, http.HandlerFunc(aH.saveSpansV2))).Methods(http.MethodPost)
 }
 
 func (aH *APIHandler) saveSpans(w http.ResponseWriter, r *http.Request) {
@@ -104,8 +112,8 @@ func (aH *APIHandler) saveSpans(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := aH.saveThriftSpans(tSpans); err != nil {
-		http.Error(w, fmt.Sprintf("Cannot submit Zipkin batch: %v", err), http.StatusInternalServerError)
+	if err := aH.saveThriftSpans(tSpans, r); err != nil {
+		handler.WriteSubmitError(w, "Cannot submit Zipkin batch", err)
 		return
 	}
 
@@ -154,14 +162,20 @@ func (aH *APIHandler) saveSpansV2(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err = aH.saveThriftSpans(tSpans); err != nil {
-		http.Error(w, fmt.Sprintf("Cannot submit Zipkin batch: %v", err), http.StatusInternalServerError)
+	if err = aH.saveThriftSpans(tSpans, r); err != nil {
+		handler.WriteSubmitError(w, "Cannot submit Zipkin batch", err)
 		return
 	}
 
 	w.WriteHeader(operations.PostSpansAcceptedCode)
 }
 
+// DeserializeJSONV2 deserializes zipkin v2 json spans into zipkin thrift,
+// validating them with the given formats of the Zipkin API
+func DeserializeJSONV2(bodyBytes []byte, zipkinV2Formats strfmt.Registry) ([]*zipkincore.Span, error) {
+	return jsonToThriftSpansV2(bodyBytes, zipkinV2Formats)
+}
+
 func jsonToThriftSpansV2(bodyBytes []byte, zipkinV2Formats strfmt.Registry) ([]*zipkincore.Span, error) {
 	var spans models.ListOfSpans
 	if err := swag.ReadJSON(bodyBytes, &spans); err != nil {
@@ -199,9 +213,13 @@ func gunzip(r io.ReadCloser) (*gzip.Reader, error) {
 	return gz, nil
 }
 
-func (aH *APIHandler) saveThriftSpans(tSpans []*zipkincore.Span) error {
+func (aH *APIHandler) saveThriftSpans(tSpans []*zipkincore.Span, r *http.Request) error {
 	if len(tSpans) > 0 {
-		opts := handler.SubmitBatchOptions{InboundTransport: processor.HTTPTransport}
+		opts := handler.SubmitBatchOptions{
+			InboundTransport: processor.HTTPTransport,
+			Tenant:           tenancy.GetTenant(r.Context()),
+			Client:           aH.limiter.ClientFromHTTP(r),
+		}
 		if _, err := aH.zipkinSpansHandler.SubmitZipkinBatch(tSpans, opts); err != nil {
 			return err
 		}
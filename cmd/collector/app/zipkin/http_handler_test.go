@@ -27,7 +27,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/golang/protobuf/proto"
+	"github.com/gogo/protobuf/proto"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -35,7 +35,9 @@ import (
 	zipkinTransport "github.com/uber/jaeger-client-go/transport/zipkin"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/ratelimit"
 	zipkinTrift "github.com/jaegertracing/jaeger/model/converter/thrift/zipkin"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	zipkinProto "github.com/jaegertracing/jaeger/proto-gen/zipkin"
 	"github.com/jaegertracing/jaeger/thrift-gen/zipkincore"
 )
@@ -63,7 +65,7 @@ func (p *mockZipkinHandler) getSpans() []*zipkincore.Span {
 
 func initializeTestServer(err error) (*httptest.Server, *APIHandler) {
 	r := mux.NewRouter()
-	handler := NewAPIHandler(&mockZipkinHandler{err: err})
+	handler := NewAPIHandler(&mockZipkinHandler{err: err}, tenancy.NewManager(&tenancy.Options{}), nil)
 	handler.RegisterRoutes(r)
 	return httptest.NewServer(r), handler
 }
@@ -116,6 +118,20 @@ func TestThriftFormat(t *testing.T) {
 	assert.EqualValues(t, "", resBodyStr)
 }
 
+func TestThriftFormatRateLimited(t *testing.T) {
+	server, _ := initializeTestServer(&ratelimit.Error{Limit: ratelimit.ClientLimit, Key: "127.0.0.1", RetryAfter: time.Second})
+	defer server.Close()
+	bodyBytes := zipkinTrift.SerializeThrift([]*zipkincore.Span{{}})
+	req, err := http.NewRequest(http.MethodPost, server.URL+`/api/v1/spans`, bytes.NewReader(bodyBytes))
+	require.NoError(t, err)
+	req.Header = *createHeader("application/x-thrift")
+	res, err := httpClient.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.EqualValues(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.Equal(t, "1", res.Header.Get("Retry-After"))
+}
+
 func TestJsonFormat(t *testing.T) {
 	server, handler := initializeTestServer(nil)
 	defer server.Close()
@@ -229,7 +245,7 @@ func TestFormatBadBody(t *testing.T) {
 }
 
 func TestCannotReadBodyFromRequest(t *testing.T) {
-	handler := NewAPIHandler(&mockZipkinHandler{})
+	handler := NewAPIHandler(&mockZipkinHandler{}, tenancy.NewManager(&tenancy.Options{}), nil)
 	req, err := http.NewRequest(http.MethodPost, "whatever", &errReader{})
 	assert.NoError(t, err)
 	rw := dummyResponseWriter{}
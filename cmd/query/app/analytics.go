@@ -0,0 +1,145 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jaegertracing/jaeger/cmd/query/app/querysvc"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+const percentilesParam = "percentiles"
+
+var (
+	defaultPercentiles = []float64{50, 75, 90, 95, 99}
+
+	errStartAfterEnd = fmt.Errorf("'%s' should not be greater than '%s'", startTimeParam, endTimeParam)
+)
+
+// operationStatsResponse is the JSON representation of querysvc.OperationStatsResult.
+// Times are in unix microseconds and latencies in microseconds, like in the UI model.
+type operationStatsResponse struct {
+	ServiceName string           `json:"serviceName"`
+	Start       uint64           `json:"start"`
+	End         uint64           `json:"end"`
+	Sampled     bool             `json:"sampled"`
+	Operations  []operationStats `json:"operations"`
+}
+
+type operationStats struct {
+	Operation string              `json:"operation"`
+	Count     int64               `json:"count"`
+	Errors    int64               `json:"errors"`
+	ErrorRate float64             `json:"errorRate"`
+	Latencies []latencyPercentile `json:"latencies"`
+}
+
+type latencyPercentile struct {
+	Percentile float64 `json:"percentile"`
+	Latency    uint64  `json:"latency"`
+}
+
+// getOperationStats implements the REST API /analytics/operations.
+// Query syntax:
+//
+//	query ::= service '&' [start '&'] [end '&'] [percentiles]
+//	service ::= 'service=' strValue
+//	start ::= 'start=' intValue in unix microseconds, defaults to the trace query lookback
+//	end ::= 'end=' intValue in unix microseconds, defaults to now
+//	percentiles ::= 'percentiles=' comma-separated floatValues between 0 and 100
+func (aH *APIHandler) getOperationStats(w http.ResponseWriter, r *http.Request) {
+	query, err := aH.parseOperationStatsQuery(r)
+	if aH.handleError(w, err, http.StatusBadRequest) {
+		return
+	}
+	result, err := aH.queryService.GetOperationStats(r.Context(), query)
+	if aH.handleError(w, err, http.StatusInternalServerError) {
+		return
+	}
+	structuredRes := structuredResponse{
+		Data:   convertOperationStats(query, result),
+		Errors: []structuredError{},
+	}
+	aH.writeJSON(w, r, &structuredRes)
+}
+
+func (aH *APIHandler) parseOperationStatsQuery(r *http.Request) (*spanstore.OperationStatsQueryParameters, error) {
+	service := r.FormValue(serviceParam)
+	if service == "" {
+		return nil, ErrServiceParameterRequired
+	}
+	startTime, err := aH.queryParser.parseTime(startTimeParam, r)
+	if err != nil {
+		return nil, err
+	}
+	endTime, err := aH.queryParser.parseTime(endTimeParam, r)
+	if err != nil {
+		return nil, err
+	}
+	if startTime.After(endTime) {
+		return nil, errStartAfterEnd
+	}
+	percentiles := defaultPercentiles
+	if value := r.FormValue(percentilesParam); value != "" {
+		percentiles = nil
+		for _, p := range strings.Split(value, ",") {
+			percentile, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+			if err != nil || percentile <= 0 || percentile > 100 {
+				return nil, fmt.Errorf("malformed '%s' parameter, expecting numbers between 0 and 100: %s", percentilesParam, p)
+			}
+			percentiles = append(percentiles, percentile)
+		}
+	}
+	return &spanstore.OperationStatsQueryParameters{
+		ServiceName:  service,
+		StartTimeMin: startTime,
+		StartTimeMax: endTime,
+		Percentiles:  percentiles,
+	}, nil
+}
+
+func convertOperationStats(query *spanstore.OperationStatsQueryParameters, result *querysvc.OperationStatsResult) *operationStatsResponse {
+	res := &operationStatsResponse{
+		ServiceName: query.ServiceName,
+		Start:       model.TimeAsEpochMicroseconds(query.StartTimeMin),
+		End:         model.TimeAsEpochMicroseconds(query.StartTimeMax),
+		Sampled:     result.Sampled,
+		Operations:  make([]operationStats, len(result.Operations)),
+	}
+	for i, stats := range result.Operations {
+		opStats := operationStats{
+			Operation: stats.Operation,
+			Count:     stats.Count,
+			Errors:    stats.Errors,
+			Latencies: make([]latencyPercentile, len(query.Percentiles)),
+		}
+		if stats.Count > 0 {
+			opStats.ErrorRate = float64(stats.Errors) / float64(stats.Count)
+		}
+		for j, percentile := range query.Percentiles {
+			opStats.Latencies[j].Percentile = percentile
+			if j < len(stats.Latencies) {
+				opStats.Latencies[j].Latency = model.DurationAsMicroseconds(stats.Latencies[j])
+			}
+		}
+		res.Operations[i] = opStats
+	}
+	return res
+}
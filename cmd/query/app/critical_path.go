@@ -0,0 +1,87 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/jaegertracing/jaeger/cmd/query/app/querysvc"
+	"github.com/jaegertracing/jaeger/model"
+	ui "github.com/jaegertracing/jaeger/model/json"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+// criticalPath is the JSON representation of the critical path of a trace.
+// Durations are in microseconds, like in the UI model.
+type criticalPath struct {
+	TraceID  ui.TraceID         `json:"traceID"`
+	Duration uint64             `json:"duration"`
+	Spans    []criticalPathSpan `json:"spans"`
+}
+
+type criticalPathSpan struct {
+	SpanID        ui.SpanID `json:"spanID"`
+	ServiceName   string    `json:"serviceName"`
+	OperationName string    `json:"operationName"`
+	SelfTime      uint64    `json:"selfTime"`
+}
+
+// getCriticalPath implements the REST API /traces/{trace-id}/critical-path.
+// It responds with the spans that determined the latency of the trace, and the time spent in each of them.
+func (aH *APIHandler) getCriticalPath(w http.ResponseWriter, r *http.Request) {
+	traceID, ok := aH.parseTraceID(w, r)
+	if !ok {
+		return
+	}
+	path, err := aH.queryService.GetCriticalPath(r.Context(), traceID)
+	if errors.Is(err, spanstore.ErrTraceNotFound) {
+		aH.handleError(w, err, http.StatusNotFound)
+		return
+	}
+	if aH.handleError(w, err, http.StatusInternalServerError) {
+		return
+	}
+	structuredRes := structuredResponse{
+		Data:   convertCriticalPath(traceID, path),
+		Errors: []structuredError{},
+	}
+	aH.writeJSON(w, r, &structuredRes)
+}
+
+func convertCriticalPath(traceID model.TraceID, path []querysvc.CriticalPathSpan) *criticalPath {
+	res := &criticalPath{
+		TraceID: ui.TraceID(traceID.String()),
+		Spans:   make([]criticalPathSpan, len(path)),
+	}
+	for i, s := range path {
+		selfTime := model.DurationAsMicroseconds(s.SelfTime)
+		res.Duration += selfTime
+		res.Spans[i] = criticalPathSpan{
+			SpanID:        ui.SpanID(s.Span.SpanID.String()),
+			ServiceName:   serviceName(s.Span),
+			OperationName: s.Span.OperationName,
+			SelfTime:      selfTime,
+		}
+	}
+	return res
+}
+
+func serviceName(span *model.Span) string {
+	if span.Process == nil {
+		return ""
+	}
+	return span.Process.ServiceName
+}
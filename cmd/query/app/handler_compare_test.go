@@ -0,0 +1,141 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/cmd/query/app/querysvc"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+type structuredCompareResponse struct {
+	Comparison traceComparison   `json:"data"`
+	Errors     []structuredError `json:"errors"`
+}
+
+func compareTestTrace(traceID model.TraceID, childDuration time.Duration, childTags ...model.KeyValue) *model.Trace {
+	start := time.Unix(1000, 0)
+	return &model.Trace{Spans: []*model.Span{
+		{
+			TraceID:       traceID,
+			SpanID:        model.NewSpanID(1),
+			OperationName: "HTTP GET",
+			StartTime:     start,
+			Duration:      childDuration + time.Millisecond,
+			Process:       &model.Process{ServiceName: "frontend"},
+		},
+		{
+			TraceID:       traceID,
+			SpanID:        model.NewSpanID(2),
+			OperationName: "query",
+			References:    []model.SpanRef{model.NewChildOfRef(traceID, model.NewSpanID(1))},
+			StartTime:     start,
+			Duration:      childDuration,
+			Tags:          childTags,
+			Process:       &model.Process{ServiceName: "db"},
+		},
+	}}
+}
+
+func TestCompareTraces(t *testing.T) {
+	idA, idB := model.NewTraceID(0, 1), model.NewTraceID(0, 2)
+	withTestServer(t, func(ts *testServer) {
+		ts.spanReader.On("GetTrace", mock.AnythingOfType("*context.valueCtx"), idA).
+			Return(compareTestTrace(idA, 10*time.Millisecond), nil).Once()
+		ts.spanReader.On("GetTrace", mock.AnythingOfType("*context.valueCtx"), idB).
+			Return(compareTestTrace(idB, 30*time.Millisecond, model.Bool("error", true)), nil).Once()
+
+		var response structuredCompareResponse
+		err := getJSON(ts.server.URL+"/api/traces/compare?a="+idA.String()+"&b="+idB.String(), &response)
+		require.NoError(t, err)
+		assert.Empty(t, response.Errors)
+		c := response.Comparison
+		assert.Equal(t, traceSummary{TraceID: "0000000000000001", Duration: 11000, Spans: 2}, c.TraceA)
+		assert.Equal(t, traceSummary{TraceID: "0000000000000002", Duration: 31000, Spans: 2}, c.TraceB)
+		assert.Equal(t, 2, c.Common)
+		require.Len(t, c.Spans, 2)
+		value := "true"
+		assert.Equal(t, spanDiff{
+			Path: []serviceOperation{
+				{ServiceName: "frontend", OperationName: "HTTP GET"},
+				{ServiceName: "db", OperationName: "query"},
+			},
+			Status:        spanDiffCommon,
+			A:             &spanSummary{SpanID: "0000000000000002", Duration: 10000},
+			B:             &spanSummary{SpanID: "0000000000000002", Duration: 30000, Error: true},
+			DurationDelta: 20000,
+			TagDiffs:      []tagDiff{{Key: "error", B: &value}},
+		}, c.Spans[1])
+	}, querysvc.QueryServiceOptions{})
+}
+
+func TestCompareTracesAddedAndRemoved(t *testing.T) {
+	idA, idB := model.NewTraceID(0, 1), model.NewTraceID(0, 2)
+	withTestServer(t, func(ts *testServer) {
+		a := compareTestTrace(idA, time.Millisecond)
+		b := compareTestTrace(idB, time.Millisecond)
+		b.Spans[1].OperationName = "insert"
+		ts.spanReader.On("GetTrace", mock.AnythingOfType("*context.valueCtx"), idA).Return(a, nil).Once()
+		ts.spanReader.On("GetTrace", mock.AnythingOfType("*context.valueCtx"), idB).Return(b, nil).Once()
+
+		var response structuredCompareResponse
+		err := getJSON(ts.server.URL+"/api/traces/compare?a="+idA.String()+"&b="+idB.String(), &response)
+		require.NoError(t, err)
+		c := response.Comparison
+		assert.Equal(t, 1, c.Common)
+		assert.Equal(t, 1, c.Added)
+		assert.Equal(t, 1, c.Removed)
+		require.Len(t, c.Spans, 3)
+		assert.Equal(t, spanDiffRemoved, c.Spans[1].Status)
+		assert.Nil(t, c.Spans[1].B)
+		assert.Equal(t, spanDiffAdded, c.Spans[2].Status)
+		assert.Nil(t, c.Spans[2].A)
+	}, querysvc.QueryServiceOptions{})
+}
+
+func TestCompareTracesErrors(t *testing.T) {
+	idA := model.NewTraceID(0, 1)
+	withTestServer(t, func(ts *testServer) {
+		ts.spanReader.On("GetTrace", mock.AnythingOfType("*context.valueCtx"), idA).
+			Return(compareTestTrace(idA, time.Millisecond), nil)
+		ts.spanReader.On("GetTrace", mock.AnythingOfType("*context.valueCtx"), model.NewTraceID(0, 2)).
+			Return(nil, spanstore.ErrTraceNotFound)
+		ts.spanReader.On("GetTrace", mock.AnythingOfType("*context.valueCtx"), model.NewTraceID(0, 3)).
+			Return(nil, assert.AnError)
+
+		tests := []struct {
+			query string
+			err   string
+		}{
+			{query: "b=1", err: `400 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":400,"msg":"parameter 'a' is required"}]}` + "\n"},
+			{query: "a=1", err: `400 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":400,"msg":"parameter 'b' is required"}]}` + "\n"},
+			{query: "a=1&b=xyz", err: `400 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":400,"msg":"strconv.ParseUint: parsing \"xyz\": invalid syntax"}]}` + "\n"},
+			{query: "a=1&b=2", err: `404 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":404,"msg":"cannot get trace 0000000000000002: trace not found"}]}` + "\n"},
+			{query: "a=1&b=3", err: `500 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":500,"msg":"cannot get trace 0000000000000003: assert.AnError general error for testing"}]}` + "\n"},
+		}
+		for _, test := range tests {
+			var response structuredResponse
+			err := getJSON(ts.server.URL+"/api/traces/compare?"+test.query, &response)
+			assert.EqualError(t, err, test.err, test.query)
+		}
+	}, querysvc.QueryServiceOptions{})
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/cmd/query/app/querysvc"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+type structuredCriticalPathResponse struct {
+	CriticalPath criticalPath      `json:"data"`
+	Errors       []structuredError `json:"errors"`
+}
+
+func TestGetCriticalPath(t *testing.T) {
+	withTestServer(t, func(ts *testServer) {
+		// the root spends 1ms in itself and 10ms in the query
+		ts.spanReader.On("GetTrace", mock.AnythingOfType("*context.valueCtx"), mockTraceID).
+			Return(compareTestTrace(mockTraceID, 10*time.Millisecond), nil).Once()
+
+		var response structuredCriticalPathResponse
+		err := getJSON(ts.server.URL+"/api/traces/"+mockTraceID.String()+"/critical-path", &response)
+		require.NoError(t, err)
+		assert.Empty(t, response.Errors)
+		assert.Equal(t, criticalPath{
+			TraceID:  "000000000001e240",
+			Duration: 11000,
+			Spans: []criticalPathSpan{
+				{SpanID: "0000000000000001", ServiceName: "frontend", OperationName: "HTTP GET", SelfTime: 1000},
+				{SpanID: "0000000000000002", ServiceName: "db", OperationName: "query", SelfTime: 10000},
+			},
+		}, response.CriticalPath)
+	}, querysvc.QueryServiceOptions{})
+}
+
+func TestGetCriticalPathErrors(t *testing.T) {
+	withTestServer(t, func(ts *testServer) {
+		ts.spanReader.On("GetTrace", mock.AnythingOfType("*context.valueCtx"), model.NewTraceID(0, 1)).
+			Return(nil, spanstore.ErrTraceNotFound).Once()
+		ts.spanReader.On("GetTrace", mock.AnythingOfType("*context.valueCtx"), model.NewTraceID(0, 2)).
+			Return(nil, assert.AnError).Once()
+
+		tests := []struct {
+			traceID string
+			err     string
+		}{
+			{traceID: "xyz", err: `400 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":400,"msg":"strconv.ParseUint: parsing \"xyz\": invalid syntax"}]}` + "\n"},
+			{traceID: "1", err: `404 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":404,"msg":"cannot get trace 0000000000000001: trace not found"}]}` + "\n"},
+			{traceID: "2", err: `500 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":500,"msg":"cannot get trace 0000000000000002: assert.AnError general error for testing"}]}` + "\n"},
+		}
+		for _, test := range tests {
+			var response structuredResponse
+			err := getJSON(ts.server.URL+"/api/traces/"+test.traceID+"/critical-path", &response)
+			assert.EqualError(t, err, test.err, test.traceID)
+		}
+	}, querysvc.QueryServiceOptions{})
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/cmd/query/app/querysvc"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+type structuredOperationStatsResponse struct {
+	OperationStats operationStatsResponse `json:"data"`
+	Errors         []structuredError      `json:"errors"`
+}
+
+func TestGetOperationStats(t *testing.T) {
+	withTestServer(t, func(ts *testServer) {
+		ts.spanReader.On("FindTraces", mock.AnythingOfType("*context.valueCtx"), mock.MatchedBy(func(q *spanstore.TraceQueryParameters) bool {
+			return q.ServiceName == "db" && q.StartTimeMax.Equal(time.Unix(2000, 0))
+		})).Return([]*model.Trace{
+			compareTestTrace(model.NewTraceID(0, 1), 10*time.Millisecond),
+			compareTestTrace(model.NewTraceID(0, 2), 30*time.Millisecond, model.Bool("error", true)),
+		}, nil).Once()
+
+		var response structuredOperationStatsResponse
+		err := getJSON(ts.server.URL+"/api/analytics/operations?service=db&start=0&end=2000000000&percentiles=50,100", &response)
+		require.NoError(t, err)
+		assert.Empty(t, response.Errors)
+		assert.Equal(t, operationStatsResponse{
+			ServiceName: "db",
+			Start:       0,
+			End:         2000000000,
+			Sampled:     true,
+			Operations: []operationStats{
+				{
+					Operation: "query",
+					Count:     2,
+					Errors:    1,
+					ErrorRate: 0.5,
+					Latencies: []latencyPercentile{
+						{Percentile: 50, Latency: 10000},
+						{Percentile: 100, Latency: 30000},
+					},
+				},
+			},
+		}, response.OperationStats)
+	}, querysvc.QueryServiceOptions{})
+}
+
+func TestGetOperationStatsDefaultPercentiles(t *testing.T) {
+	withTestServer(t, func(ts *testServer) {
+		ts.spanReader.On("FindTraces", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("*spanstore.TraceQueryParameters")).
+			Return([]*model.Trace{}, nil).Once()
+
+		var response structuredOperationStatsResponse
+		err := getJSON(ts.server.URL+"/api/analytics/operations?service=db", &response)
+		require.NoError(t, err)
+		assert.Empty(t, response.OperationStats.Operations)
+		assert.True(t, response.OperationStats.Start < response.OperationStats.End)
+	}, querysvc.QueryServiceOptions{})
+}
+
+func TestGetOperationStatsErrors(t *testing.T) {
+	withTestServer(t, func(ts *testServer) {
+		ts.spanReader.On("FindTraces", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("*spanstore.TraceQueryParameters")).
+			Return(nil, assert.AnError).Once()
+
+		tests := []struct {
+			query string
+			err   string
+		}{
+			{query: "", err: `400 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":400,"msg":"parameter 'service' is required"}]}` + "\n"},
+			{query: "service=db&start=x", err: `400 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":400,"msg":"strconv.ParseInt: parsing \"x\": invalid syntax"}]}` + "\n"},
+			{query: "service=db&end=x", err: `400 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":400,"msg":"strconv.ParseInt: parsing \"x\": invalid syntax"}]}` + "\n"},
+			{query: "service=db&start=2000000&end=1000000", err: `400 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":400,"msg":"'start' should not be greater than 'end'"}]}` + "\n"},
+			{query: "service=db&percentiles=50,101", err: `400 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":400,"msg":"malformed 'percentiles' parameter, expecting numbers between 0 and 100: 101"}]}` + "\n"},
+			{query: "service=db&percentiles=p99", err: `400 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":400,"msg":"malformed 'percentiles' parameter, expecting numbers between 0 and 100: p99"}]}` + "\n"},
+			{query: "service=db", err: `500 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":500,"msg":"assert.AnError general error for testing"}]}` + "\n"},
+		}
+		for _, test := range tests {
+			var response structuredResponse
+			err := getJSON(ts.server.URL+"/api/analytics/operations?"+test.query, &response)
+			assert.EqualError(t, err, test.err, test.query)
+		}
+	}, querysvc.QueryServiceOptions{})
+}
@@ -20,6 +20,8 @@ import (
 
 	"github.com/opentracing/opentracing-go"
 	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 )
 
 // HandlerOption is a function that sets some option on the APIHandler
@@ -65,3 +67,11 @@ func (handlerOptions) Tracer(tracer opentracing.Tracer) HandlerOption {
 		apiHandler.tracer = tracer
 	}
 }
+
+// Tenancy creates a HandlerOption that initializes the tenancy manager,
+// which requires a valid tenant header on all API routes if tenancy is enabled
+func (handlerOptions) Tenancy(tenancyMgr *tenancy.Manager) HandlerOption {
+	return func(apiHandler *APIHandler) {
+		apiHandler.tenancyMgr = tenancyMgr
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/cmd/query/app/querysvc"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+func TestStructuralSearch(t *testing.T) {
+	id1, id2 := model.NewTraceID(0, 1), model.NewTraceID(0, 2)
+	withTestServer(t, func(ts *testServer) {
+		ts.spanReader.On("FindTraceIDs", mock.AnythingOfType("*context.valueCtx"), mock.MatchedBy(func(q *spanstore.TraceQueryParameters) bool {
+			return q.ServiceName == "db" && q.OperationName == "query" &&
+				len(q.TagPredicates) == 1 && q.TagPredicates[0].Key == "error" &&
+				q.NumTraces == structuralQueryCandidates && q.StartTimeMax.Equal(time.Unix(2000, 0))
+		})).Return([]model.TraceID{id1, id2}, nil).Once()
+		ts.spanReader.On("GetTrace", mock.AnythingOfType("*context.valueCtx"), id1).
+			Return(compareTestTrace(id1, 10*time.Millisecond, model.Bool("error", true)), nil).Once()
+		ts.spanReader.On("GetTrace", mock.AnythingOfType("*context.valueCtx"), id2).
+			Return(compareTestTrace(id2, 30*time.Millisecond), nil).Once()
+
+		query := url.QueryEscape(`{service=frontend} > {service=db, operation=query, tag.error=true}`)
+		var response structuredTraceResponse
+		err := getJSON(ts.server.URL+"/api/traces/structural?end=2000000000&query="+query, &response)
+		require.NoError(t, err)
+		assert.Empty(t, response.Errors)
+		require.Len(t, response.Traces, 1)
+		assert.Equal(t, "0000000000000001", string(response.Traces[0].TraceID))
+		assert.Len(t, response.Traces[0].Spans, 2)
+	}, querysvc.QueryServiceOptions{})
+}
+
+func TestStructuralSearchLimit(t *testing.T) {
+	id1, id2 := model.NewTraceID(0, 1), model.NewTraceID(0, 2)
+	withTestServer(t, func(ts *testServer) {
+		ts.spanReader.On("FindTraceIDs", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("*spanstore.TraceQueryParameters")).
+			Return([]model.TraceID{id1, id2}, nil).Twice()
+		ts.spanReader.On("GetTrace", mock.AnythingOfType("*context.valueCtx"), id1).
+			Return(compareTestTrace(id1, 10*time.Millisecond), nil).Once()
+
+		query := url.QueryEscape(`{service=frontend} || {service=db}`)
+		var response structuredTraceResponse
+		err := getJSON(ts.server.URL+"/api/traces/structural?limit=1&query="+query, &response)
+		require.NoError(t, err)
+		require.Len(t, response.Traces, 1)
+		ts.spanReader.AssertExpectations(t)
+	}, querysvc.QueryServiceOptions{})
+}
+
+func TestStructuralSearchErrors(t *testing.T) {
+	withTestServer(t, func(ts *testServer) {
+		ts.spanReader.On("FindTraceIDs", mock.AnythingOfType("*context.valueCtx"), mock.MatchedBy(func(q *spanstore.TraceQueryParameters) bool {
+			return q.ServiceName == "a"
+		})).Return(nil, assert.AnError).Once()
+		ts.spanReader.On("FindTraceIDs", mock.AnythingOfType("*context.valueCtx"), mock.MatchedBy(func(q *spanstore.TraceQueryParameters) bool {
+			return q.ServiceName == "b"
+		})).Return(nil, spanstore.ErrUnsupportedTagOperator).Once()
+
+		tests := []struct {
+			query string
+			err   string
+		}{
+			{query: "", err: `400 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":400,"msg":"parameter 'query' is required"}]}` + "\n"},
+			{query: "query=" + url.QueryEscape("{name=a}"), err: `400 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":400,"msg":"malformed 'query' parameter: unknown field 'name' at position 1, expecting service, operation, duration or tag.KEY"}]}` + "\n"},
+			{query: "query=" + url.QueryEscape("{operation=x}"), err: `400 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":400,"msg":"every alternative of a structural query must have a selector with a service= condition, which is used to look up candidate traces"}]}` + "\n"},
+			{query: "start=x&query=" + url.QueryEscape("{service=a}"), err: `400 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":400,"msg":"strconv.ParseInt: parsing \"x\": invalid syntax"}]}` + "\n"},
+			{query: "end=x&query=" + url.QueryEscape("{service=a}"), err: `400 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":400,"msg":"strconv.ParseInt: parsing \"x\": invalid syntax"}]}` + "\n"},
+			{query: "limit=x&query=" + url.QueryEscape("{service=a}"), err: `400 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":400,"msg":"strconv.ParseInt: parsing \"x\": invalid syntax"}]}` + "\n"},
+			{query: "query=" + url.QueryEscape("{service=a}"), err: `500 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":500,"msg":"assert.AnError general error for testing"}]}` + "\n"},
+			{query: "query=" + url.QueryEscape("{service=b}"), err: `400 error from server: {"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":400,"msg":"tag operator is not supported by the span storage"}]}` + "\n"},
+		}
+		for _, test := range tests {
+			var response structuredResponse
+			err := getJSON(ts.server.URL+"/api/traces/structural?"+test.query, &response)
+			assert.EqualError(t, err, test.err, test.query)
+		}
+	}, querysvc.QueryServiceOptions{})
+}
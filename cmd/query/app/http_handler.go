@@ -18,6 +18,7 @@ package app
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -34,6 +35,7 @@ import (
 	uiconv "github.com/jaegertracing/jaeger/model/converter/json"
 	ui "github.com/jaegertracing/jaeger/model/json"
 	"github.com/jaegertracing/jaeger/pkg/multierror"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -79,6 +81,7 @@ type APIHandler struct {
 	apiPrefix    string
 	logger       *zap.Logger
 	tracer       opentracing.Tracer
+	tenancyMgr   *tenancy.Manager
 }
 
 // NewAPIHandler returns an APIHandler
@@ -103,12 +106,19 @@ func NewAPIHandler(queryService *querysvc.QueryService, options ...HandlerOption
 	if aH.tracer == nil {
 		aH.tracer = opentracing.NoopTracer{}
 	}
+	if aH.tenancyMgr == nil {
+		aH.tenancyMgr = tenancy.NewManager(&tenancy.Options{})
+	}
 	return aH
 }
 
 // RegisterRoutes registers routes for this handler on the given router
 func (aH *APIHandler) RegisterRoutes(router *mux.Router) {
+	// registered before /traces/{traceID}, which would match it too
+	aH.handleFunc(router, aH.compareTraces, "/traces/compare").Methods(http.MethodGet)
+	aH.handleFunc(router, aH.structuralSearch, "/traces/structural").Methods(http.MethodGet)
 	aH.handleFunc(router, aH.getTrace, "/traces/{%s}", traceIDParam).Methods(http.MethodGet)
+	aH.handleFunc(router, aH.getCriticalPath, "/traces/{%s}/critical-path", traceIDParam).Methods(http.MethodGet)
 	aH.handleFunc(router, aH.archiveTrace, "/archive/{%s}", traceIDParam).Methods(http.MethodPost)
 	aH.handleFunc(router, aH.search, "/traces").Methods(http.MethodGet)
 	aH.handleFunc(router, aH.getServices, "/services").Methods(http.MethodGet)
@@ -117,6 +127,7 @@ func (aH *APIHandler) RegisterRoutes(router *mux.Router) {
 	// TODO - remove this when UI catches up
 	aH.handleFunc(router, aH.getOperationsLegacy, "/services/{%s}/operations", serviceParam).Methods(http.MethodGet)
 	aH.handleFunc(router, aH.dependencies, "/dependencies").Methods(http.MethodGet)
+	aH.handleFunc(router, aH.getOperationStats, "/analytics/operations").Methods(http.MethodGet)
 }
 
 func (aH *APIHandler) handleFunc(
@@ -132,7 +143,7 @@ func (aH *APIHandler) handleFunc(
 		nethttp.OperationNameFunc(func(r *http.Request) string {
 			return route
 		}))
-	return router.HandleFunc(route, traceMiddleware.ServeHTTP)
+	return router.Handle(route, tenancy.ExtractTenantHTTPHandler(aH.tenancyMgr, traceMiddleware))
 }
 
 func (aH *APIHandler) route(route string, args ...interface{}) string {
@@ -220,6 +231,10 @@ func (aH *APIHandler) search(w http.ResponseWriter, r *http.Request) {
 		}
 	} else {
 		tracesFromStorage, err = aH.queryService.FindTraces(r.Context(), &tQuery.TraceQueryParameters)
+		if errors.Is(err, spanstore.ErrUnsupportedTagOperator) {
+			aH.handleError(w, err, http.StatusBadRequest)
+			return
+		}
 		if aH.handleError(w, err, http.StatusInternalServerError) {
 			return
 		}
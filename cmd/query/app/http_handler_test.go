@@ -424,6 +424,17 @@ func TestSearchDBFailure(t *testing.T) {
 	assert.EqualError(t, err, parsedError(500, "whatsamattayou"))
 }
 
+func TestSearchUnsupportedTagOperator(t *testing.T) {
+	server, readMock, _ := initializeTestServer()
+	defer server.Close()
+	readMock.On("FindTraces", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("*spanstore.TraceQueryParameters")).
+		Return(nil, fmt.Errorf("%w: =~", spanstore.ErrUnsupportedTagOperator)).Once()
+
+	var response structuredResponse
+	err := getJSON(server.URL+`/api/traces?service=service&start=0&end=0&tagFilter=http.url=~/api/.*`, &response)
+	assert.EqualError(t, err, parsedError(400, "tag operator is not supported by the span storage: =~"))
+}
+
 func TestSearchFailures(t *testing.T) {
 	tests := []struct {
 		urlStr string
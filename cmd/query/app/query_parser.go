@@ -33,6 +33,7 @@ const (
 	operationParam   = "operation"
 	tagParam         = "tag"
 	tagsParam        = "tags"
+	tagFilterParam   = "tagFilter"
 	startTimeParam   = "start"
 	limitParam       = "limit"
 	minDurationParam = "minDuration"
@@ -64,7 +65,7 @@ type traceQueryParameters struct {
 // parse takes a request and constructs a model of parameters
 // Trace query syntax:
 //     query ::= param | param '&' query
-//     param ::= service | operation | limit | start | end | minDuration | maxDuration | tag | tags
+//     param ::= service | operation | limit | start | end | minDuration | maxDuration | tag | tags | tagFilter
 //     service ::= 'service=' strValue
 //     operation ::= 'operation=' strValue
 //     limit ::= 'limit=' intValue
@@ -76,6 +77,8 @@ type traceQueryParameters struct {
 //     key := strValue
 //     keyValue := strValue ':' strValue
 //     tags :== 'tags=' jsonMap
+//     tagFilter ::= 'tagFilter=' key | 'tagFilter=' key operator strValue
+//     operator ::= '=' | '!=' | '=~' | '>' | '>=' | '<' | '<='
 func (p *queryParser) parse(r *http.Request) (*traceQueryParameters, error) {
 	service := r.FormValue(serviceParam)
 	operation := r.FormValue(operationParam)
@@ -94,6 +97,11 @@ func (p *queryParser) parse(r *http.Request) (*traceQueryParameters, error) {
 		return nil, err
 	}
 
+	tagPredicates, err := p.parseTagFilters(r.Form[tagFilterParam])
+	if err != nil {
+		return nil, err
+	}
+
 	limitParam := r.FormValue(limitParam)
 	limit := defaultQueryLimit
 	if limitParam != "" {
@@ -130,6 +138,7 @@ func (p *queryParser) parse(r *http.Request) (*traceQueryParameters, error) {
 			StartTimeMin:  startTime,
 			StartTimeMax:  endTime,
 			Tags:          tags,
+			TagPredicates: tagPredicates,
 			NumTraces:     limit,
 			DurationMin:   minDuration,
 			DurationMax:   maxDuration,
@@ -203,3 +212,40 @@ func (p *queryParser) parseTags(simpleTags []string, jsonTags []string) (map[str
 	}
 	return retMe, nil
 }
+
+// tagFilterOperators are sorted so that the operators prefixed by another one come first.
+var tagFilterOperators = []spanstore.TagOperator{
+	spanstore.TagNotEquals,
+	spanstore.TagMatches,
+	spanstore.TagGreaterThanOrEqual,
+	spanstore.TagLessThanOrEqual,
+	spanstore.TagGreaterThan,
+	spanstore.TagLessThan,
+	spanstore.TagEquals,
+}
+
+func (p *queryParser) parseTagFilters(tagFilters []string) ([]spanstore.TagPredicate, error) {
+	var predicates []spanstore.TagPredicate
+	for _, tagFilter := range tagFilters {
+		predicate := spanstore.TagPredicate{Key: tagFilter, Operator: spanstore.TagExists}
+		if i := strings.IndexAny(tagFilter, "=!<>"); i >= 0 {
+			predicate.Key = tagFilter[:i]
+			predicate.Operator = ""
+			for _, operator := range tagFilterOperators {
+				if strings.HasPrefix(tagFilter[i:], string(operator)) {
+					predicate.Operator = operator
+					predicate.Value = tagFilter[i+len(operator):]
+					break
+				}
+			}
+		}
+		if predicate.Key == "" || predicate.Operator == "" {
+			return nil, fmt.Errorf("malformed '%s' parameter, expecting key or key followed by one of =, !=, =~, >, >=, <, <= and a value, received: %s", tagFilterParam, tagFilter)
+		}
+		if _, err := spanstore.NewTagMatcher(predicate); err != nil {
+			return nil, fmt.Errorf("malformed '%s' parameter: %w", tagFilterParam, err)
+		}
+		predicates = append(predicates, predicate)
+	}
+	return predicates, nil
+}
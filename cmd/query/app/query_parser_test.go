@@ -112,6 +112,27 @@ func TestParseTraceQuery(t *testing.T) {
 				},
 			},
 		},
+		{"x?service=service&start=0&end=0&tagFilter=http.status_code>=500&tagFilter=error&tagFilter=http.url=~/api/.*&tagFilter=db.type!=sql&tagFilter=k=v=w", noErr,
+			&traceQueryParameters{
+				TraceQueryParameters: spanstore.TraceQueryParameters{
+					ServiceName:  "service",
+					StartTimeMin: time.Unix(0, 0),
+					StartTimeMax: time.Unix(0, 0),
+					NumTraces:    100,
+					Tags:         make(map[string]string),
+					TagPredicates: []spanstore.TagPredicate{
+						{Key: "http.status_code", Operator: spanstore.TagGreaterThanOrEqual, Value: "500"},
+						{Key: "error", Operator: spanstore.TagExists},
+						{Key: "http.url", Operator: spanstore.TagMatches, Value: "/api/.*"},
+						{Key: "db.type", Operator: spanstore.TagNotEquals, Value: "sql"},
+						{Key: "k", Operator: spanstore.TagEquals, Value: "v=w"},
+					},
+				},
+			},
+		},
+		{"x?service=service&tagFilter=>=500", "malformed 'tagFilter' parameter, expecting key or key followed by one of =, !=, =~, >, >=, <, <= and a value, received: >=500", nil},
+		{"x?service=service&tagFilter=k!500", "malformed 'tagFilter' parameter, expecting key or key followed by one of =, !=, =~, >, >=, <, <= and a value, received: k!500", nil},
+		{"x?service=service&tagFilter=http.status_code<5xx", `malformed 'tagFilter' parameter: invalid number for tag http.status_code: strconv.ParseFloat: parsing "5xx": invalid syntax`, nil},
 		// trace ID in upper/lower case
 		{"x?traceID=1f00&traceID=1E00", noErr,
 			&traceQueryParameters{
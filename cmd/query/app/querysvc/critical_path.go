@@ -0,0 +1,136 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querysvc
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// CriticalPathSpan is a span on the critical path of a trace.
+type CriticalPathSpan struct {
+	Span *model.Span
+	// SelfTime is the time of the critical path spent in the span itself rather than in its children.
+	SelfTime time.Duration
+}
+
+// ComputeCriticalPath returns the spans that determined the latency of the root span of the trace,
+// in the order they are first found on the critical path, which sums up to the duration of the root.
+//
+// Walking back from the end of a span, the critical path goes through the child that finished last,
+// then from the start of that child through the child that finished last before it, and so on; the
+// gaps between them are the self time of the span. Children are clamped to the time of their parent,
+// since clock skew may remain after the ClockSkew adjuster. Spans that only follow from another span
+// are asynchronous, so they and their descendants are not on the critical path.
+func ComputeCriticalPath(trace *model.Trace) []CriticalPathSpan {
+	root := criticalPathRoot(trace)
+	if root == nil {
+		return nil
+	}
+	tree := newSpanTree(trace)
+	var segments []CriticalPathSpan
+	var walk func(span *model.Span, start, end time.Time)
+	walk = func(span *model.Span, start, end time.Time) {
+		// children sorted by the end of their time clamped to the parent, latest first
+		children := tree.children[span.SpanID]
+		type window struct {
+			span       *model.Span
+			start, end time.Time
+		}
+		windows := make([]window, 0, len(children))
+		for _, child := range children {
+			childStart, childEnd := clampSpan(child, start, end)
+			if childStart.Before(childEnd) {
+				windows = append(windows, window{span: child, start: childStart, end: childEnd})
+			}
+		}
+		sort.SliceStable(windows, func(i, j int) bool {
+			return windows[i].end.After(windows[j].end)
+		})
+
+		cursor := end
+		for _, w := range windows {
+			if !w.start.Before(cursor) {
+				// ran in parallel with a child already on the critical path
+				continue
+			}
+			if w.end.After(cursor) {
+				w.end = cursor
+			}
+			segments = append(segments, CriticalPathSpan{Span: span, SelfTime: cursor.Sub(w.end)})
+			walk(w.span, w.start, w.end)
+			cursor = w.start
+		}
+		segments = append(segments, CriticalPathSpan{Span: span, SelfTime: cursor.Sub(start)})
+	}
+	walk(root, root.StartTime, root.StartTime.Add(root.Duration))
+
+	// segments were found walking back in time
+	var path []CriticalPathSpan
+	index := make(map[*model.Span]int)
+	for i := len(segments) - 1; i >= 0; i-- {
+		s := segments[i]
+		if j, ok := index[s.Span]; ok {
+			path[j].SelfTime += s.SelfTime
+			continue
+		}
+		index[s.Span] = len(path)
+		path = append(path, s)
+	}
+	return path
+}
+
+// criticalPathRoot returns the earliest span that has neither a parent nor a follows-from reference
+// in the trace, and the longest one among those that started at the same time.
+func criticalPathRoot(trace *model.Trace) *model.Span {
+	spanIDs := make(map[model.SpanID]struct{}, len(trace.Spans))
+	for _, span := range trace.Spans {
+		spanIDs[span.SpanID] = struct{}{}
+	}
+	var root *model.Span
+	for _, span := range trace.Spans {
+		if referencesSpanInTrace(span, spanIDs) {
+			continue
+		}
+		if root == nil || span.StartTime.Before(root.StartTime) ||
+			(span.StartTime.Equal(root.StartTime) && span.Duration > root.Duration) {
+			root = span
+		}
+	}
+	return root
+}
+
+func referencesSpanInTrace(span *model.Span, spanIDs map[model.SpanID]struct{}) bool {
+	for _, ref := range span.References {
+		if _, ok := spanIDs[ref.SpanID]; ok && ref.TraceID == span.TraceID && ref.SpanID != span.SpanID {
+			return true
+		}
+	}
+	return false
+}
+
+// clampSpan returns the start and end of the span within the start and end of its parent.
+func clampSpan(span *model.Span, parentStart, parentEnd time.Time) (time.Time, time.Time) {
+	start, end := span.StartTime, span.StartTime.Add(span.Duration)
+	if start.Before(parentStart) {
+		start = parentStart
+	}
+	if end.After(parentEnd) {
+		end = parentEnd
+	}
+	return start, end
+}
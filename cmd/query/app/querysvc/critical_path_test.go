@@ -0,0 +1,110 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querysvc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+type criticalPathSelfTime struct {
+	spanID   model.SpanID
+	selfTime time.Duration
+}
+
+func criticalPathSelfTimes(path []CriticalPathSpan) []criticalPathSelfTime {
+	var res []criticalPathSelfTime
+	for _, s := range path {
+		res = append(res, criticalPathSelfTime{spanID: s.Span.SpanID, selfTime: s.SelfTime})
+	}
+	return res
+}
+
+func TestComputeCriticalPath(t *testing.T) {
+	id := model.NewTraceID(0, 1)
+	ms := time.Millisecond
+	followsFrom := compareSpan(id, 5, 0, "worker", "process", 50*ms, 150*ms)
+	followsFrom.References = []model.SpanRef{model.NewFollowsFromRef(id, model.NewSpanID(1))}
+	trace := &model.Trace{Spans: []*model.Span{
+		followsFrom,
+		compareSpan(id, 1, 0, "frontend", "HTTP GET", 0, 100*ms),
+		compareSpan(id, 2, 1, "auth", "check", 10*ms, 30*ms),
+		compareSpan(id, 3, 1, "db", "query", 20*ms, 70*ms),
+		// ends after its parent because of clock skew
+		compareSpan(id, 4, 3, "db", "read", 30*ms, 65*ms),
+		// runs in parallel with span 3
+		compareSpan(id, 6, 1, "cache", "get", 40*ms, 10*ms),
+	}}
+
+	path := ComputeCriticalPath(trace)
+	assert.Equal(t, []criticalPathSelfTime{
+		{spanID: model.NewSpanID(1), selfTime: 20 * ms},
+		{spanID: model.NewSpanID(2), selfTime: 10 * ms},
+		{spanID: model.NewSpanID(3), selfTime: 10 * ms},
+		{spanID: model.NewSpanID(4), selfTime: 60 * ms},
+	}, criticalPathSelfTimes(path))
+	var total time.Duration
+	for _, s := range path {
+		total += s.SelfTime
+	}
+	assert.Equal(t, 100*ms, total)
+}
+
+func TestComputeCriticalPathRoot(t *testing.T) {
+	id := model.NewTraceID(0, 1)
+	ms := time.Millisecond
+
+	// the parent of both spans is missing, the earliest one is the root
+	trace := &model.Trace{Spans: []*model.Span{
+		compareSpan(id, 2, 1, "svc", "late", 10*ms, 100*ms),
+		compareSpan(id, 3, 1, "svc", "early", 0, 5*ms),
+	}}
+	assert.Equal(t, []criticalPathSelfTime{
+		{spanID: model.NewSpanID(3), selfTime: 5 * ms},
+	}, criticalPathSelfTimes(ComputeCriticalPath(trace)))
+
+	// every span has a parent
+	trace = &model.Trace{Spans: []*model.Span{
+		compareSpan(id, 1, 2, "svc", "a", 0, ms),
+		compareSpan(id, 2, 1, "svc", "b", 0, ms),
+	}}
+	assert.Nil(t, ComputeCriticalPath(trace))
+	assert.Nil(t, ComputeCriticalPath(&model.Trace{}))
+}
+
+func TestGetCriticalPath(t *testing.T) {
+	qs, readMock, _ := initializeTestService()
+	id := model.NewTraceID(0, 1)
+	readMock.On("GetTrace", mock.Anything, id).Return(&model.Trace{Spans: []*model.Span{
+		compareSpan(id, 1, 0, "svc", "op", 0, time.Millisecond),
+	}}, nil)
+	readMock.On("GetTrace", mock.Anything, mock.Anything).Return(nil, spanstore.ErrTraceNotFound)
+
+	path, err := qs.GetCriticalPath(context.Background(), id)
+	require.NoError(t, err)
+	require.Len(t, path, 1)
+	assert.Equal(t, time.Millisecond, path[0].SelfTime)
+
+	_, err = qs.GetCriticalPath(context.Background(), model.NewTraceID(0, 2))
+	assert.EqualError(t, err, "cannot get trace 0000000000000002: trace not found")
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querysvc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+	depsmocks "github.com/jaegertracing/jaeger/storage/dependencystore/mocks"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+	spanstoremocks "github.com/jaegertracing/jaeger/storage/spanstore/mocks"
+)
+
+type statsReader struct {
+	spanstoremocks.Reader
+	stats []spanstore.OperationStats
+	err   error
+}
+
+func (r *statsReader) GetOperationStats(ctx context.Context, query *spanstore.OperationStatsQueryParameters) ([]spanstore.OperationStats, error) {
+	return r.stats, r.err
+}
+
+func TestGetOperationStatsNative(t *testing.T) {
+	stats := []spanstore.OperationStats{{Operation: "op", Count: 1}}
+	qs := NewQueryService(&statsReader{stats: stats}, &depsmocks.Reader{}, QueryServiceOptions{})
+	res, err := qs.GetOperationStats(context.Background(), &spanstore.OperationStatsQueryParameters{ServiceName: "svc"})
+	require.NoError(t, err)
+	assert.Equal(t, &OperationStatsResult{Operations: stats}, res)
+
+	qs = NewQueryService(&statsReader{err: errors.New("storage failure")}, &depsmocks.Reader{}, QueryServiceOptions{})
+	_, err = qs.GetOperationStats(context.Background(), &spanstore.OperationStatsQueryParameters{ServiceName: "svc"})
+	assert.EqualError(t, err, "storage failure")
+}
+
+func TestGetOperationStatsSampled(t *testing.T) {
+	id := model.NewTraceID(0, 1)
+	trace := &model.Trace{Spans: []*model.Span{
+		compareSpan(id, 1, 0, "svc", "op", 0, 2*time.Millisecond),
+		compareSpan(id, 2, 1, "svc", "op", 0, 1*time.Millisecond, model.Bool("error", true)),
+		compareSpan(id, 3, 1, "other", "op", 0, time.Second),
+	}}
+	query := &spanstore.OperationStatsQueryParameters{ServiceName: "svc", Percentiles: []float64{50, 100}}
+	expected := &OperationStatsResult{
+		Operations: []spanstore.OperationStats{
+			{Operation: "op", Count: 2, Errors: 1, Latencies: []time.Duration{time.Millisecond, 2 * time.Millisecond}},
+		},
+		Sampled: true,
+	}
+	matchQuery := mock.MatchedBy(func(q *spanstore.TraceQueryParameters) bool {
+		return q.ServiceName == "svc" && q.NumTraces == operationStatsSampleSize
+	})
+
+	t.Run("reader without stats", func(t *testing.T) {
+		qs, readMock, _ := initializeTestService()
+		readMock.On("FindTraces", mock.Anything, matchQuery).Return([]*model.Trace{trace}, nil)
+		res, err := qs.GetOperationStats(context.Background(), query)
+		require.NoError(t, err)
+		assert.Equal(t, expected, res)
+	})
+	t.Run("stats not supported", func(t *testing.T) {
+		reader := &statsReader{err: spanstore.ErrOperationStatsNotSupported}
+		reader.On("FindTraces", mock.Anything, matchQuery).Return([]*model.Trace{trace}, nil)
+		qs := NewQueryService(reader, &depsmocks.Reader{}, QueryServiceOptions{})
+		res, err := qs.GetOperationStats(context.Background(), query)
+		require.NoError(t, err)
+		assert.Equal(t, expected, res)
+	})
+	t.Run("find traces error", func(t *testing.T) {
+		qs, readMock, _ := initializeTestService()
+		readMock.On("FindTraces", mock.Anything, matchQuery).Return(nil, errors.New("storage failure"))
+		_, err := qs.GetOperationStats(context.Background(), query)
+		assert.EqualError(t, err, "storage failure")
+	})
+}
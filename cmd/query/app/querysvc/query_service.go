@@ -17,6 +17,7 @@ package querysvc
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"go.uber.org/zap"
@@ -24,6 +25,7 @@ import (
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/model/adjuster"
 	"github.com/jaegertracing/jaeger/pkg/multierror"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/storage"
 	"github.com/jaegertracing/jaeger/storage/dependencystore"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
@@ -31,10 +33,16 @@ import (
 
 var (
 	errNoArchiveSpanStorage = errors.New("archive span storage was not configured")
+	errInvalidTenant        = errors.New("missing or unknown tenant")
+	errTenantDependencies   = errors.New("dependencies are not supported with multi-tenancy")
 )
 
 const (
 	defaultMaxClockSkewAdjust = time.Second
+
+	// operationStatsSampleSize is the number of traces aggregated when the span storage
+	// does not aggregate the stats of operations natively.
+	operationStatsSampleSize = 1000
 )
 
 // QueryServiceOptions has optional members of QueryService
@@ -42,6 +50,8 @@ type QueryServiceOptions struct {
 	ArchiveSpanReader spanstore.Reader
 	ArchiveSpanWriter spanstore.Writer
 	Adjuster          adjuster.Adjuster
+	// TenancyMgr requires a valid tenant in the context of every request if tenancy is enabled
+	TenancyMgr *tenancy.Manager
 }
 
 // QueryService contains span utils required by the query-service.
@@ -67,6 +77,9 @@ func NewQueryService(spanReader spanstore.Reader, dependencyReader dependencysto
 
 // GetTrace is the queryService implementation of spanstore.Reader.GetTrace
 func (qs QueryService) GetTrace(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
+	if err := qs.checkTenant(ctx); err != nil {
+		return nil, err
+	}
 	trace, err := qs.spanReader.GetTrace(ctx, traceID)
 	if err == spanstore.ErrTraceNotFound {
 		if qs.options.ArchiveSpanReader == nil {
@@ -79,6 +92,9 @@ func (qs QueryService) GetTrace(ctx context.Context, traceID model.TraceID) (*mo
 
 // GetServices is the queryService implementation of spanstore.Reader.GetServices
 func (qs QueryService) GetServices(ctx context.Context) ([]string, error) {
+	if err := qs.checkTenant(ctx); err != nil {
+		return nil, err
+	}
 	return qs.spanReader.GetServices(ctx)
 }
 
@@ -87,14 +103,95 @@ func (qs QueryService) GetOperations(
 	ctx context.Context,
 	query spanstore.OperationQueryParameters,
 ) ([]spanstore.Operation, error) {
+	if err := qs.checkTenant(ctx); err != nil {
+		return nil, err
+	}
 	return qs.spanReader.GetOperations(ctx, query)
 }
 
 // FindTraces is the queryService implementation of spanstore.Reader.FindTraces
 func (qs QueryService) FindTraces(ctx context.Context, query *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
+	if err := qs.checkTenant(ctx); err != nil {
+		return nil, err
+	}
 	return qs.spanReader.FindTraces(ctx, query)
 }
 
+// CompareTraces fetches and adjusts two traces, and compares trace B against trace A.
+func (qs QueryService) CompareTraces(ctx context.Context, traceIDA, traceIDB model.TraceID) (*TraceComparison, error) {
+	a, err := qs.getAdjustedTrace(ctx, traceIDA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := qs.getAdjustedTrace(ctx, traceIDB)
+	if err != nil {
+		return nil, err
+	}
+	return CompareTraces(a, b), nil
+}
+
+// GetCriticalPath fetches and adjusts a trace, and computes its critical path.
+func (qs QueryService) GetCriticalPath(ctx context.Context, traceID model.TraceID) ([]CriticalPathSpan, error) {
+	trace, err := qs.getAdjustedTrace(ctx, traceID)
+	if err != nil {
+		return nil, err
+	}
+	return ComputeCriticalPath(trace), nil
+}
+
+// getAdjustedTrace returns the trace with the adjusters applied. Adjusters always return a usable trace,
+// their errors are warnings about the spans that could not be adjusted, so they are ignored.
+func (qs QueryService) getAdjustedTrace(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
+	trace, err := qs.GetTrace(ctx, traceID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get trace %v: %w", traceID, err)
+	}
+	trace, _ = qs.Adjust(trace)
+	return trace, nil
+}
+
+// OperationStatsResult holds the stats of the operations of a service.
+type OperationStatsResult struct {
+	Operations []spanstore.OperationStats
+	// Sampled is true if the span storage cannot aggregate the stats natively, in which case
+	// they are aggregated from the spans of at most operationStatsSampleSize recent traces.
+	Sampled bool
+}
+
+// GetOperationStats returns the number of spans, the number of errors and the latency percentiles
+// of the operations of a service, aggregated by the span storage if it implements spanstore.OperationStatsReader.
+func (qs QueryService) GetOperationStats(ctx context.Context, query *spanstore.OperationStatsQueryParameters) (*OperationStatsResult, error) {
+	if err := qs.checkTenant(ctx); err != nil {
+		return nil, err
+	}
+	if statsReader, ok := qs.spanReader.(spanstore.OperationStatsReader); ok {
+		stats, err := statsReader.GetOperationStats(ctx, query)
+		if err != spanstore.ErrOperationStatsNotSupported {
+			if err != nil {
+				return nil, err
+			}
+			return &OperationStatsResult{Operations: stats}, nil
+		}
+	}
+	traces, err := qs.spanReader.FindTraces(ctx, &spanstore.TraceQueryParameters{
+		ServiceName:  query.ServiceName,
+		StartTimeMin: query.StartTimeMin,
+		StartTimeMax: query.StartTimeMax,
+		NumTraces:    operationStatsSampleSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var spans []*model.Span
+	for _, trace := range traces {
+		spans = append(spans, trace.Spans...)
+	}
+	return &OperationStatsResult{
+		Operations: spanstore.AggregateOperationStats(spans, query),
+		Sampled:    true,
+	}, nil
+}
+
 // ArchiveTrace is the queryService utility to archive traces.
 func (qs QueryService) ArchiveTrace(ctx context.Context, traceID model.TraceID) error {
 	if qs.options.ArchiveSpanWriter == nil {
@@ -121,10 +218,26 @@ func (qs QueryService) Adjust(trace *model.Trace) (*model.Trace, error) {
 }
 
 // GetDependencies implements dependencystore.Reader.GetDependencies
+// Dependencies are not stored by tenant, so they are not available when tenancy is enabled.
 func (qs QueryService) GetDependencies(endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
+	if qs.tenancyEnabled() {
+		return nil, errTenantDependencies
+	}
 	return qs.dependencyReader.GetDependencies(endTs, lookback)
 }
 
+func (qs QueryService) tenancyEnabled() bool {
+	return qs.options.TenancyMgr != nil && qs.options.TenancyMgr.Enabled
+}
+
+// checkTenant returns an error if tenancy is enabled and the context has no valid tenant
+func (qs QueryService) checkTenant(ctx context.Context) error {
+	if qs.tenancyEnabled() && !qs.options.TenancyMgr.Valid(tenancy.GetTenant(ctx)) {
+		return errInvalidTenant
+	}
+	return nil
+}
+
 // InitArchiveStorage tries to initialize archive storage reader/writer if storage factory supports them.
 func (opts *QueryServiceOptions) InitArchiveStorage(storageFactory storage.Factory, logger *zap.Logger) bool {
 	archiveFactory, ok := storageFactory.(storage.ArchiveFactory)
@@ -27,6 +27,7 @@ import (
 
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/model/adjuster"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/storage"
 	"github.com/jaegertracing/jaeger/storage/dependencystore"
 	depsmocks "github.com/jaegertracing/jaeger/storage/dependencystore/mocks"
@@ -323,3 +324,30 @@ func TestInitArchiveStorage(t *testing.T) {
 	assert.Equal(t, reader, opts.ArchiveSpanReader)
 	assert.Equal(t, writer, opts.ArchiveSpanWriter)
 }
+
+// Test QueryService with multi-tenancy enabled.
+func TestTenancy(t *testing.T) {
+	readStorage := &spanstoremocks.Reader{}
+	dependencyStorage := &depsmocks.Reader{}
+	qs := NewQueryService(readStorage, dependencyStorage, QueryServiceOptions{
+		TenancyMgr: tenancy.NewManager(&tenancy.Options{Enabled: true, Tenants: []string{"acme"}}),
+	})
+	expectedServices := []string{"trifle", "bling"}
+	readStorage.On("GetServices", mock.AnythingOfType("*context.valueCtx")).Return(expectedServices, nil).Once()
+
+	_, err := qs.GetServices(context.Background())
+	assert.Equal(t, errInvalidTenant, err)
+	_, err = qs.GetTrace(tenancy.WithTenant(context.Background(), "megacorp"), mockTraceID)
+	assert.Equal(t, errInvalidTenant, err)
+	_, err = qs.GetOperations(context.Background(), spanstore.OperationQueryParameters{ServiceName: "trifle"})
+	assert.Equal(t, errInvalidTenant, err)
+	_, err = qs.FindTraces(context.Background(), &spanstore.TraceQueryParameters{})
+	assert.Equal(t, errInvalidTenant, err)
+	_, err = qs.GetDependencies(time.Now(), defaultDependencyLookbackDuration)
+	assert.Equal(t, errTenantDependencies, err)
+
+	actualServices, err := qs.GetServices(tenancy.WithTenant(context.Background(), "acme"))
+	assert.NoError(t, err)
+	assert.Equal(t, expectedServices, actualServices)
+	readStorage.AssertExpectations(t)
+}
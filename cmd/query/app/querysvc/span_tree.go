@@ -0,0 +1,56 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querysvc
+
+import (
+	"sort"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// spanTree holds the spans of a trace by their child-of references, like the adjusters see them.
+// Spans whose parent is not in the trace are roots, including the spans that only follow from another one.
+type spanTree struct {
+	roots    []*model.Span
+	children map[model.SpanID][]*model.Span
+}
+
+// newSpanTree builds the tree of the spans of the trace, with roots and siblings sorted by start time.
+func newSpanTree(trace *model.Trace) *spanTree {
+	spanIDs := make(map[model.SpanID]struct{}, len(trace.Spans))
+	for _, span := range trace.Spans {
+		spanIDs[span.SpanID] = struct{}{}
+	}
+	tree := &spanTree{children: make(map[model.SpanID][]*model.Span)}
+	for _, span := range trace.Spans {
+		parentID := span.ParentSpanID()
+		if _, ok := spanIDs[parentID]; ok && parentID != span.SpanID {
+			tree.children[parentID] = append(tree.children[parentID], span)
+		} else {
+			tree.roots = append(tree.roots, span)
+		}
+	}
+	sortByStartTime(tree.roots)
+	for _, children := range tree.children {
+		sortByStartTime(children)
+	}
+	return tree
+}
+
+func sortByStartTime(spans []*model.Span) {
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].StartTime.Before(spans[j].StartTime)
+	})
+}
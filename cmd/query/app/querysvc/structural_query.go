@@ -0,0 +1,93 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querysvc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+// StructuralQuery finds traces by conditions on several of their spans, which span storages cannot
+// evaluate. The storage only looks up the IDs of candidate traces, which are then loaded and filtered in memory.
+type StructuralQuery struct {
+	// Candidates are the span storage queries looking up the candidate traces. The NumTraces
+	// of each query bounds the number of traces loaded for it.
+	Candidates []*spanstore.TraceQueryParameters
+	// Match returns true if the adjusted candidate trace satisfies the query.
+	Match func(trace *model.Trace) bool
+	// NumTraces is the maximum number of matching traces returned, zero meaning no limit.
+	NumTraces int
+	// MaxCandidates is the maximum number of candidate traces loaded across all the Candidates
+	// queries, zero meaning no limit.
+	MaxCandidates int
+}
+
+// FindTracesByStructure returns the adjusted candidate traces of the query that it matches, in the order
+// the span storage returned their IDs, taking the IDs of the Candidates queries in turn so that every
+// query has candidates loaded when MaxCandidates is reached.
+func (qs QueryService) FindTracesByStructure(ctx context.Context, query *StructuralQuery) ([]*model.Trace, error) {
+	if err := qs.checkTenant(ctx); err != nil {
+		return nil, err
+	}
+	lookups := make([][]model.TraceID, len(query.Candidates))
+	maxIDs := 0
+	for i, candidates := range query.Candidates {
+		ids, err := qs.spanReader.FindTraceIDs(ctx, candidates)
+		if err != nil {
+			return nil, err
+		}
+		lookups[i] = ids
+		if len(ids) > maxIDs {
+			maxIDs = len(ids)
+		}
+	}
+	var traceIDs []model.TraceID
+	seen := make(map[model.TraceID]struct{})
+	for j := 0; j < maxIDs; j++ {
+		for _, ids := range lookups {
+			if j >= len(ids) {
+				continue
+			}
+			if _, ok := seen[ids[j]]; !ok {
+				seen[ids[j]] = struct{}{}
+				traceIDs = append(traceIDs, ids[j])
+			}
+		}
+	}
+	if query.MaxCandidates > 0 && len(traceIDs) > query.MaxCandidates {
+		traceIDs = traceIDs[:query.MaxCandidates]
+	}
+	var traces []*model.Trace
+	for _, traceID := range traceIDs {
+		if query.NumTraces > 0 && len(traces) >= query.NumTraces {
+			break
+		}
+		trace, err := qs.getAdjustedTrace(ctx, traceID)
+		if errors.Is(err, spanstore.ErrTraceNotFound) {
+			// the trace expired between the lookup and the load
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if query.Match(trace) {
+			traces = append(traces, trace)
+		}
+	}
+	return traces, nil
+}
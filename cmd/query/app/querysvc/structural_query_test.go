@@ -0,0 +1,119 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querysvc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+func structuralTrace(id model.TraceID, service string) *model.Trace {
+	return &model.Trace{Spans: []*model.Span{compareSpan(id, 1, 0, service, "op", 0, 0)}}
+}
+
+func TestFindTracesByStructure(t *testing.T) {
+	id1, id2, id3, id4 := model.NewTraceID(0, 1), model.NewTraceID(0, 2), model.NewTraceID(0, 3), model.NewTraceID(0, 4)
+	queryA := &spanstore.TraceQueryParameters{ServiceName: "a", NumTraces: 10}
+	queryB := &spanstore.TraceQueryParameters{ServiceName: "b", NumTraces: 10}
+	matchB := func(trace *model.Trace) bool {
+		return trace.Spans[0].Process.ServiceName == "b"
+	}
+
+	qs, readMock, _ := initializeTestService()
+	readMock.On("FindTraceIDs", mock.Anything, queryA).Return([]model.TraceID{id1, id2}, nil)
+	readMock.On("FindTraceIDs", mock.Anything, queryB).Return([]model.TraceID{id2, id3, id4}, nil)
+	readMock.On("GetTrace", mock.Anything, id1).Return(structuralTrace(id1, "a"), nil)
+	readMock.On("GetTrace", mock.Anything, id2).Return(structuralTrace(id2, "b"), nil)
+	readMock.On("GetTrace", mock.Anything, id3).Return(nil, spanstore.ErrTraceNotFound)
+	readMock.On("GetTrace", mock.Anything, id4).Return(structuralTrace(id4, "b"), nil)
+
+	traces, err := qs.FindTracesByStructure(context.Background(), &StructuralQuery{
+		Candidates: []*spanstore.TraceQueryParameters{queryA, queryB},
+		Match:      matchB,
+	})
+	require.NoError(t, err)
+	require.Len(t, traces, 2)
+	assert.Equal(t, id2, traces[0].Spans[0].TraceID)
+	assert.Equal(t, id4, traces[1].Spans[0].TraceID)
+	readMock.AssertNumberOfCalls(t, "GetTrace", 4)
+
+	traces, err = qs.FindTracesByStructure(context.Background(), &StructuralQuery{
+		Candidates: []*spanstore.TraceQueryParameters{queryA, queryB},
+		Match:      matchB,
+		NumTraces:  1,
+	})
+	require.NoError(t, err)
+	require.Len(t, traces, 1)
+	assert.Equal(t, id2, traces[0].Spans[0].TraceID)
+}
+
+func TestFindTracesByStructureMaxCandidates(t *testing.T) {
+	id1, id2, id3, id4 := model.NewTraceID(0, 1), model.NewTraceID(0, 2), model.NewTraceID(0, 3), model.NewTraceID(0, 4)
+	queryA := &spanstore.TraceQueryParameters{ServiceName: "a", NumTraces: 10}
+	queryB := &spanstore.TraceQueryParameters{ServiceName: "b", NumTraces: 10}
+	matchAll := func(trace *model.Trace) bool { return true }
+
+	qs, readMock, _ := initializeTestService()
+	readMock.On("FindTraceIDs", mock.Anything, queryA).Return([]model.TraceID{id1, id2, id3}, nil)
+	readMock.On("FindTraceIDs", mock.Anything, queryB).Return([]model.TraceID{id4}, nil)
+	readMock.On("GetTrace", mock.Anything, id1).Return(structuralTrace(id1, "a"), nil)
+	readMock.On("GetTrace", mock.Anything, id4).Return(structuralTrace(id4, "b"), nil)
+
+	// the candidates of both queries are loaded, although the first one has enough
+	traces, err := qs.FindTracesByStructure(context.Background(), &StructuralQuery{
+		Candidates:    []*spanstore.TraceQueryParameters{queryA, queryB},
+		Match:         matchAll,
+		MaxCandidates: 2,
+	})
+	require.NoError(t, err)
+	require.Len(t, traces, 2)
+	assert.Equal(t, id1, traces[0].Spans[0].TraceID)
+	assert.Equal(t, id4, traces[1].Spans[0].TraceID)
+	readMock.AssertNumberOfCalls(t, "GetTrace", 2)
+}
+
+func TestFindTracesByStructureErrors(t *testing.T) {
+	id := model.NewTraceID(0, 1)
+	query := &spanstore.TraceQueryParameters{ServiceName: "a"}
+	matchAll := func(trace *model.Trace) bool { return true }
+
+	t.Run("lookup", func(t *testing.T) {
+		qs, readMock, _ := initializeTestService()
+		readMock.On("FindTraceIDs", mock.Anything, query).Return(nil, errors.New("lookup failure"))
+		_, err := qs.FindTracesByStructure(context.Background(), &StructuralQuery{
+			Candidates: []*spanstore.TraceQueryParameters{query},
+			Match:      matchAll,
+		})
+		assert.EqualError(t, err, "lookup failure")
+	})
+	t.Run("load", func(t *testing.T) {
+		qs, readMock, _ := initializeTestService()
+		readMock.On("FindTraceIDs", mock.Anything, query).Return([]model.TraceID{id}, nil)
+		readMock.On("GetTrace", mock.Anything, id).Return(nil, errors.New("load failure"))
+		_, err := qs.FindTracesByStructure(context.Background(), &StructuralQuery{
+			Candidates: []*spanstore.TraceQueryParameters{query},
+			Match:      matchAll,
+		})
+		assert.EqualError(t, err, "cannot get trace 0000000000000001: load failure")
+	})
+}
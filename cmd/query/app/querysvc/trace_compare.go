@@ -0,0 +1,201 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querysvc
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// ServiceOperation is an operation of a service.
+type ServiceOperation struct {
+	Service   string
+	Operation string
+}
+
+// TagDiff is a tag whose values differ between two spans. A value is nil when the span does not have the tag.
+type TagDiff struct {
+	Key string
+	A   *model.KeyValue
+	B   *model.KeyValue
+}
+
+// SpanDiff compares the spans found at the same path in two traces. Spans at the same path are aligned
+// in the order of their start time. A is nil for spans added in trace B, B is nil for spans removed from it.
+type SpanDiff struct {
+	// Path holds the service and operation of the root span, of each ancestor of the span, and of the span.
+	Path []ServiceOperation
+	A    *model.Span
+	B    *model.Span
+	// TagDiffs holds the tags that differ between A and B, sorted by key.
+	TagDiffs []TagDiff
+}
+
+// Added returns true if the span is only found in trace B.
+func (d SpanDiff) Added() bool {
+	return d.A == nil
+}
+
+// Removed returns true if the span is only found in trace A.
+func (d SpanDiff) Removed() bool {
+	return d.B == nil
+}
+
+// DurationDelta returns how much longer the span took in trace B than in trace A,
+// or zero if the span is only found in one of the traces.
+func (d SpanDiff) DurationDelta() time.Duration {
+	if d.A == nil || d.B == nil {
+		return 0
+	}
+	return d.B.Duration - d.A.Duration
+}
+
+// ErrorA returns true if the span of trace A is tagged as an error.
+func (d SpanDiff) ErrorA() bool {
+	return d.A != nil && d.A.IsError()
+}
+
+// ErrorB returns true if the span of trace B is tagged as an error.
+func (d SpanDiff) ErrorB() bool {
+	return d.B != nil && d.B.IsError()
+}
+
+// TraceComparison is the comparison of trace B against trace A.
+type TraceComparison struct {
+	A *model.Trace
+	B *model.Trace
+	// Spans holds the aligned spans of both traces, in the order they are found walking trace A
+	// and then trace B depth-first, with siblings sorted by start time.
+	Spans []SpanDiff
+}
+
+// CompareTraces aligns the spans of two traces by the services and operations of their ancestors and
+// themselves, so that e.g. a slow trace can be compared with a normal one for the same endpoint.
+func CompareTraces(a, b *model.Trace) *TraceComparison {
+	var paths []string
+	pathSpans := make(map[string][][]*model.Span)
+	pathElements := make(map[string][]ServiceOperation)
+	for i, trace := range []*model.Trace{a, b} {
+		walkSpanPaths(trace, func(span *model.Span, path []ServiceOperation) {
+			key := pathKey(path)
+			spans, ok := pathSpans[key]
+			if !ok {
+				spans = make([][]*model.Span, 2)
+				paths = append(paths, key)
+				pathElements[key] = path
+			}
+			spans[i] = append(spans[i], span)
+			pathSpans[key] = spans
+		})
+	}
+
+	comparison := &TraceComparison{A: a, B: b}
+	for _, key := range paths {
+		spans := pathSpans[key]
+		sortByStartTime(spans[0])
+		sortByStartTime(spans[1])
+		for i := 0; i < len(spans[0]) || i < len(spans[1]); i++ {
+			d := SpanDiff{Path: pathElements[key]}
+			if i < len(spans[0]) {
+				d.A = spans[0][i]
+			}
+			if i < len(spans[1]) {
+				d.B = spans[1][i]
+			}
+			if d.A != nil && d.B != nil {
+				d.TagDiffs = diffTags(d.A.Tags, d.B.Tags)
+			}
+			comparison.Spans = append(comparison.Spans, d)
+		}
+	}
+	return comparison
+}
+
+// walkSpanPaths calls fn for every span of the trace, depth-first, with siblings sorted by start time.
+// Spans whose parent is not in the trace are walked as roots.
+func walkSpanPaths(trace *model.Trace, fn func(span *model.Span, path []ServiceOperation)) {
+	tree := newSpanTree(trace)
+	visited := make(map[*model.Span]struct{}, len(trace.Spans))
+	var walk func(span *model.Span, parentPath []ServiceOperation)
+	walk = func(span *model.Span, parentPath []ServiceOperation) {
+		if _, ok := visited[span]; ok {
+			return
+		}
+		visited[span] = struct{}{}
+		path := make([]ServiceOperation, len(parentPath), len(parentPath)+1)
+		copy(path, parentPath)
+		path = append(path, ServiceOperation{Service: serviceName(span), Operation: span.OperationName})
+		fn(span, path)
+		for _, child := range tree.children[span.SpanID] {
+			walk(child, path)
+		}
+	}
+	for _, root := range tree.roots {
+		walk(root, nil)
+	}
+	// spans with cyclic references are not reachable from any root
+	for _, span := range trace.Spans {
+		walk(span, nil)
+	}
+}
+
+func serviceName(span *model.Span) string {
+	if span.Process == nil {
+		return ""
+	}
+	return span.Process.ServiceName
+}
+
+func pathKey(path []ServiceOperation) string {
+	var sb strings.Builder
+	for _, so := range path {
+		sb.WriteString(so.Service)
+		sb.WriteByte(0)
+		sb.WriteString(so.Operation)
+		sb.WriteByte(0)
+	}
+	return sb.String()
+}
+
+func diffTags(a, b []model.KeyValue) []TagDiff {
+	tagsA := tagsByKey(a)
+	tagsB := tagsByKey(b)
+	var diffs []TagDiff
+	for key, tagA := range tagsA {
+		if tagB, ok := tagsB[key]; !ok || !tagA.Equal(tagB) {
+			diffs = append(diffs, TagDiff{Key: key, A: tagA, B: tagsB[key]})
+		}
+	}
+	for key, tagB := range tagsB {
+		if _, ok := tagsA[key]; !ok {
+			diffs = append(diffs, TagDiff{Key: key, B: tagB})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Key < diffs[j].Key
+	})
+	return diffs
+}
+
+func tagsByKey(tags []model.KeyValue) map[string]*model.KeyValue {
+	byKey := make(map[string]*model.KeyValue, len(tags))
+	for i := range tags {
+		byKey[tags[i].Key] = &tags[i]
+	}
+	return byKey
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querysvc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+var compareStartTime = time.Unix(1000, 0)
+
+func compareSpan(traceID model.TraceID, id, parent uint64, service, operation string, start, duration time.Duration, tags ...model.KeyValue) *model.Span {
+	span := &model.Span{
+		TraceID:       traceID,
+		SpanID:        model.NewSpanID(id),
+		OperationName: operation,
+		StartTime:     compareStartTime.Add(start),
+		Duration:      duration,
+		Tags:          tags,
+		Process:       &model.Process{ServiceName: service},
+	}
+	if parent != 0 {
+		span.References = []model.SpanRef{model.NewChildOfRef(traceID, model.NewSpanID(parent))}
+	}
+	return span
+}
+
+func diffPath(d SpanDiff) string {
+	var path string
+	for _, so := range d.Path {
+		path += "/" + so.Service + ":" + so.Operation
+	}
+	return path
+}
+
+func TestCompareTraces(t *testing.T) {
+	idA, idB := model.NewTraceID(0, 1), model.NewTraceID(0, 2)
+	a := &model.Trace{Spans: []*model.Span{
+		compareSpan(idA, 1, 0, "frontend", "HTTP GET", 0, 100*time.Millisecond),
+		compareSpan(idA, 3, 1, "db", "query", 20*time.Millisecond, 10*time.Millisecond),
+		compareSpan(idA, 2, 1, "db", "query", 10*time.Millisecond, 5*time.Millisecond, model.String("table", "users")),
+		compareSpan(idA, 4, 1, "cache", "get", 5*time.Millisecond, time.Millisecond),
+	}}
+	b := &model.Trace{Spans: []*model.Span{
+		compareSpan(idB, 1, 0, "frontend", "HTTP GET", 0, 300*time.Millisecond),
+		compareSpan(idB, 2, 1, "db", "query", 10*time.Millisecond, 200*time.Millisecond, model.String("table", "orders"), model.Bool("error", true)),
+		compareSpan(idB, 5, 1, "auth", "check", 2*time.Millisecond, time.Millisecond),
+		compareSpan(idB, 6, 5, "auth", "check", 3*time.Millisecond, time.Millisecond),
+	}}
+
+	c := CompareTraces(a, b)
+	assert.Same(t, a, c.A)
+	assert.Same(t, b, c.B)
+	var paths []string
+	for _, d := range c.Spans {
+		paths = append(paths, diffPath(d))
+	}
+	assert.Equal(t, []string{
+		"/frontend:HTTP GET",
+		"/frontend:HTTP GET/cache:get",
+		"/frontend:HTTP GET/db:query",
+		"/frontend:HTTP GET/db:query",
+		"/frontend:HTTP GET/auth:check",
+		"/frontend:HTTP GET/auth:check/auth:check",
+	}, paths)
+
+	root := c.Spans[0]
+	assert.False(t, root.Added())
+	assert.False(t, root.Removed())
+	assert.Equal(t, 200*time.Millisecond, root.DurationDelta())
+	assert.Empty(t, root.TagDiffs)
+
+	assert.True(t, c.Spans[1].Removed())
+	assert.Equal(t, time.Duration(0), c.Spans[1].DurationDelta())
+
+	// spans at the same path are aligned by start time
+	query := c.Spans[2]
+	assert.Equal(t, model.NewSpanID(2), query.A.SpanID)
+	assert.Equal(t, model.NewSpanID(2), query.B.SpanID)
+	assert.Equal(t, 195*time.Millisecond, query.DurationDelta())
+	assert.False(t, query.ErrorA())
+	assert.True(t, query.ErrorB())
+	require.Len(t, query.TagDiffs, 2)
+	assert.Equal(t, "error", query.TagDiffs[0].Key)
+	assert.Nil(t, query.TagDiffs[0].A)
+	assert.Equal(t, "true", query.TagDiffs[0].B.AsString())
+	assert.Equal(t, "table", query.TagDiffs[1].Key)
+	assert.Equal(t, "users", query.TagDiffs[1].A.AsString())
+	assert.Equal(t, "orders", query.TagDiffs[1].B.AsString())
+
+	assert.Equal(t, model.NewSpanID(3), c.Spans[3].A.SpanID)
+	assert.True(t, c.Spans[3].Removed())
+	assert.True(t, c.Spans[4].Added())
+	assert.False(t, c.Spans[4].ErrorA())
+	assert.True(t, c.Spans[5].Added())
+}
+
+func TestCompareTracesWithCycle(t *testing.T) {
+	id := model.NewTraceID(0, 1)
+	trace := &model.Trace{Spans: []*model.Span{
+		compareSpan(id, 1, 2, "svc", "a", 0, time.Millisecond),
+		compareSpan(id, 2, 1, "svc", "b", 0, time.Millisecond),
+	}}
+	c := CompareTraces(trace, &model.Trace{})
+	require.Len(t, c.Spans, 2)
+	assert.Equal(t, "/svc:a/svc:b", diffPath(c.Spans[1]))
+	assert.True(t, c.Spans[0].Removed())
+}
+
+func TestCompareTracesQueryService(t *testing.T) {
+	qs, readMock, _ := initializeTestService()
+	idA, idB := model.NewTraceID(0, 1), model.NewTraceID(0, 2)
+	readMock.On("GetTrace", mock.Anything, idA).Return(&model.Trace{Spans: []*model.Span{
+		compareSpan(idA, 1, 0, "svc", "op", 0, time.Millisecond),
+	}}, nil)
+	readMock.On("GetTrace", mock.Anything, idB).Return(&model.Trace{Spans: []*model.Span{
+		compareSpan(idB, 1, 0, "svc", "op", 0, 2*time.Millisecond),
+	}}, nil)
+	readMock.On("GetTrace", mock.Anything, mock.Anything).Return(nil, spanstore.ErrTraceNotFound)
+
+	c, err := qs.CompareTraces(context.Background(), idA, idB)
+	require.NoError(t, err)
+	require.Len(t, c.Spans, 1)
+	assert.Equal(t, time.Millisecond, c.Spans[0].DurationDelta())
+
+	_, err = qs.CompareTraces(context.Background(), idA, model.NewTraceID(0, 3))
+	assert.True(t, errors.Is(err, spanstore.ErrTraceNotFound))
+	_, err = qs.CompareTraces(context.Background(), model.NewTraceID(0, 3), idB)
+	assert.EqualError(t, err, "cannot get trace 0000000000000003: trace not found")
+}
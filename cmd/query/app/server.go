@@ -27,9 +27,11 @@ import (
 	"google.golang.org/grpc/credentials"
 
 	"github.com/jaegertracing/jaeger/cmd/query/app/querysvc"
+	_ "github.com/jaegertracing/jaeger/pkg/gogocodec" // force gogo codec registration
 	"github.com/jaegertracing/jaeger/pkg/healthcheck"
 	"github.com/jaegertracing/jaeger/pkg/netutils"
 	"github.com/jaegertracing/jaeger/pkg/recoveryhandler"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
 )
 
@@ -83,6 +85,13 @@ func createGRPCServer(querySvc *querysvc.QueryService, options *QueryOptions, lo
 		grpcOpts = append(grpcOpts, grpc.Creds(creds))
 	}
 
+	if tenancyMgr := tenancy.NewManager(&options.Tenancy); tenancyMgr.Enabled {
+		grpcOpts = append(grpcOpts,
+			grpc.UnaryInterceptor(tenancy.NewGuardingUnaryInterceptor(tenancyMgr)),
+			grpc.StreamInterceptor(tenancy.NewGuardingStreamInterceptor(tenancyMgr)),
+		)
+	}
+
 	server := grpc.NewServer(grpcOpts...)
 
 	handler := NewGRPCHandler(querySvc, logger, tracer)
@@ -94,6 +103,7 @@ func createHTTPServer(querySvc *querysvc.QueryService, queryOpts *QueryOptions,
 	apiHandlerOptions := []HandlerOption{
 		HandlerOptions.Logger(logger),
 		HandlerOptions.Tracer(tracer),
+		HandlerOptions.Tenancy(tenancy.NewManager(&queryOpts.Tenancy)),
 	}
 	apiHandler := NewAPIHandler(
 		querySvc,
@@ -16,19 +16,25 @@ package app
 
 import (
 	"context"
+	"net/http"
 	"testing"
 	"time"
 
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/jaegertracing/jaeger/cmd/flags"
 	"github.com/jaegertracing/jaeger/cmd/query/app/querysvc"
 	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
 	"github.com/jaegertracing/jaeger/pkg/healthcheck"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/ports"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
 	depsmocks "github.com/jaegertracing/jaeger/storage/dependencystore/mocks"
@@ -149,3 +155,52 @@ func TestServerHandlesPortZero(t *testing.T) {
 	port := onlyEntry.ContextMap()["port"]
 	assert.Greater(t, port, int64(0))
 }
+
+func TestServerTenancy(t *testing.T) {
+	spanReader := &spanstoremocks.Reader{}
+	dependencyReader := &depsmocks.Reader{}
+	expectedServices := []string{"test"}
+	spanReader.On("GetServices", mock.AnythingOfType("*context.valueCtx")).Return(expectedServices, nil)
+
+	tenancyOpts := tenancy.Options{Enabled: true, Tenants: []string{"acme"}}
+	querySvc := querysvc.NewQueryService(spanReader, dependencyReader, querysvc.QueryServiceOptions{
+		TenancyMgr: tenancy.NewManager(&tenancyOpts),
+	})
+	server, err := NewServer(zap.NewNop(), querySvc,
+		&QueryOptions{HostPort: "localhost:0", BasePath: "/", Tenancy: tenancyOpts},
+		opentracing.NoopTracer{})
+	require.NoError(t, err)
+	require.NoError(t, server.Start())
+	defer server.Close()
+	hostPort := server.conn.Addr().String()
+
+	client := newGRPCClient(t, hostPort)
+	defer client.conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, err = client.GetServices(ctx, &api_v2.GetServicesRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	res, err := client.GetServices(metadata.AppendToOutgoingContext(ctx, tenancy.DefaultHeader, "acme"), &api_v2.GetServicesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, expectedServices, res.Services)
+
+	for _, test := range []struct {
+		tenant   string
+		expected int
+	}{
+		{tenant: "", expected: http.StatusUnauthorized},
+		{tenant: "megacorp", expected: http.StatusForbidden},
+		{tenant: "acme", expected: http.StatusOK},
+	} {
+		req, err := http.NewRequest(http.MethodGet, "http://"+hostPort+"/api/services", nil)
+		require.NoError(t, err)
+		if test.tenant != "" {
+			req.Header.Set(tenancy.DefaultHeader, test.tenant)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, test.expected, resp.StatusCode, "tenant %q", test.tenant)
+	}
+}
@@ -0,0 +1,355 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jaegertracing/jaeger/cmd/query/app/querysvc"
+	"github.com/jaegertracing/jaeger/model"
+	ui "github.com/jaegertracing/jaeger/model/json"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+const (
+	structuralQueryParam = "query"
+
+	// structuralQueryCandidates is the maximum number of candidate traces looked up in the span storage
+	// for each alternative of a structural query. Only the candidates are loaded and filtered.
+	structuralQueryCandidates = 200
+
+	// structuralQueryMaxCandidates is the maximum number of candidate traces loaded for a structural query,
+	// whatever its number of alternatives, since each candidate is loaded with its own GetTrace call.
+	structuralQueryMaxCandidates = 500
+)
+
+var errStructuralQueryUnbounded = errors.New("every alternative of a structural query must have a selector with a service= condition, " +
+	"which is used to look up candidate traces")
+
+// structuralExpr is a node of a parsed structural query.
+type structuralExpr interface {
+	// matches returns true if the trace satisfies the expression.
+	matches(graph *spanGraph) bool
+	// lookups returns the selectors whose candidate traces include all the traces satisfying
+	// the expression, or nil if no selector bounds the candidates.
+	lookups() []*spanSelector
+}
+
+type andExpr struct {
+	left, right structuralExpr
+}
+
+func (e *andExpr) matches(graph *spanGraph) bool {
+	return e.left.matches(graph) && e.right.matches(graph)
+}
+
+func (e *andExpr) lookups() []*spanSelector {
+	if selectors := e.left.lookups(); selectors != nil {
+		return selectors
+	}
+	return e.right.lookups()
+}
+
+type orExpr struct {
+	left, right structuralExpr
+}
+
+func (e *orExpr) matches(graph *spanGraph) bool {
+	return e.left.matches(graph) || e.right.matches(graph)
+}
+
+func (e *orExpr) lookups() []*spanSelector {
+	left, right := e.left.lookups(), e.right.lookups()
+	if left == nil || right == nil {
+		return nil
+	}
+	return append(append([]*spanSelector{}, left...), right...)
+}
+
+type spanRelation int
+
+const (
+	childRelation spanRelation = iota
+	descendantRelation
+)
+
+// pathExpr matches the spans of its last selector related to the spans matched by the preceding selectors,
+// relations[i] being the relation between the spans of selectors[i] and selectors[i+1].
+type pathExpr struct {
+	selectors []*spanSelector
+	relations []spanRelation
+}
+
+func (e *pathExpr) matches(graph *spanGraph) bool {
+	return len(e.spans(graph)) > 0
+}
+
+func (e *pathExpr) spans(graph *spanGraph) []*model.Span {
+	var matched []*model.Span
+	for _, span := range graph.spans {
+		if e.selectors[0].matches(span) {
+			matched = append(matched, span)
+		}
+	}
+	for i, selector := range e.selectors[1:] {
+		if len(matched) == 0 {
+			return nil
+		}
+		parents := make(map[model.SpanID]struct{}, len(matched))
+		for _, span := range matched {
+			parents[span.SpanID] = struct{}{}
+		}
+		matched = nil
+		for _, span := range graph.spans {
+			if selector.matches(span) && graph.isRelated(span, parents, e.relations[i]) {
+				matched = append(matched, span)
+			}
+		}
+	}
+	return matched
+}
+
+func (e *pathExpr) lookups() []*spanSelector {
+	for i := len(e.selectors) - 1; i >= 0; i-- {
+		if e.selectors[i].service != "" {
+			return []*spanSelector{e.selectors[i]}
+		}
+	}
+	return nil
+}
+
+// spanSelector matches the spans satisfying all its conditions.
+type spanSelector struct {
+	conditions []spanCondition
+
+	// service, operation and tags are the equality conditions span storages can look up.
+	service   string
+	operation string
+	tags      []spanstore.TagPredicate
+}
+
+func (s *spanSelector) matches(span *model.Span) bool {
+	for _, condition := range s.conditions {
+		if !condition.matches(span) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *spanSelector) addLookup(field, value string) {
+	switch field {
+	case "service":
+		if s.service == "" {
+			s.service = value
+		}
+	case "operation":
+		if s.operation == "" {
+			s.operation = value
+		}
+	case "duration":
+	default:
+		s.tags = append(s.tags, spanstore.TagPredicate{
+			Key:      field[len(tagFieldPrefix):],
+			Operator: spanstore.TagEquals,
+			Value:    value,
+		})
+	}
+}
+
+// traceQuery returns the span storage query looking up the candidate traces of the selector.
+func (s *spanSelector) traceQuery(startTimeMin, startTimeMax time.Time) *spanstore.TraceQueryParameters {
+	return &spanstore.TraceQueryParameters{
+		ServiceName:   s.service,
+		OperationName: s.operation,
+		TagPredicates: s.tags,
+		StartTimeMin:  startTimeMin,
+		StartTimeMax:  startTimeMax,
+		NumTraces:     structuralQueryCandidates,
+	}
+}
+
+type spanCondition interface {
+	matches(span *model.Span) bool
+}
+
+type serviceCondition struct {
+	matcher *spanstore.TagMatcher
+}
+
+func (c *serviceCondition) matches(span *model.Span) bool {
+	return span.Process != nil && c.matcher.MatchValue(span.Process.ServiceName)
+}
+
+type operationCondition struct {
+	matcher *spanstore.TagMatcher
+}
+
+func (c *operationCondition) matches(span *model.Span) bool {
+	return c.matcher.MatchValue(span.OperationName)
+}
+
+type durationCondition struct {
+	operator spanstore.TagOperator
+	duration time.Duration
+}
+
+func (c *durationCondition) matches(span *model.Span) bool {
+	switch c.operator {
+	case spanstore.TagEquals:
+		return span.Duration == c.duration
+	case spanstore.TagNotEquals:
+		return span.Duration != c.duration
+	case spanstore.TagGreaterThan:
+		return span.Duration > c.duration
+	case spanstore.TagGreaterThanOrEqual:
+		return span.Duration >= c.duration
+	case spanstore.TagLessThan:
+		return span.Duration < c.duration
+	default:
+		return span.Duration <= c.duration
+	}
+}
+
+type tagCondition struct {
+	matcher *spanstore.TagMatcher
+}
+
+func (c *tagCondition) matches(span *model.Span) bool {
+	return c.matcher.Match(span)
+}
+
+// spanGraph indexes the spans of a trace by ID to follow their parent references.
+type spanGraph struct {
+	spans []*model.Span
+	byID  map[model.SpanID]*model.Span
+}
+
+func newSpanGraph(trace *model.Trace) *spanGraph {
+	graph := &spanGraph{
+		spans: trace.Spans,
+		byID:  make(map[model.SpanID]*model.Span, len(trace.Spans)),
+	}
+	for _, span := range trace.Spans {
+		graph.byID[span.SpanID] = span
+	}
+	return graph
+}
+
+// isRelated returns true if the parent, or with descendantRelation any ancestor, of the span is in parents.
+func (g *spanGraph) isRelated(span *model.Span, parents map[model.SpanID]struct{}, relation spanRelation) bool {
+	// the number of steps is bounded in case parent references form a cycle
+	for steps := 0; steps < len(g.spans); steps++ {
+		parentID := span.ParentSpanID()
+		if parentID == 0 {
+			return false
+		}
+		if _, ok := parents[parentID]; ok {
+			return true
+		}
+		if relation == childRelation {
+			return false
+		}
+		if span = g.byID[parentID]; span == nil {
+			return false
+		}
+	}
+	return false
+}
+
+// structuralSearch implements the REST API /traces/structural, which finds traces by conditions on
+// several of their spans. The syntax of the structural query is described by parseStructuralQuery.
+// Query syntax:
+//
+//	query ::= 'query=' structuralQuery ['&' start] ['&' end] ['&' limit]
+//	start ::= 'start=' intValue in unix microseconds, defaults to the trace query lookback
+//	end ::= 'end=' intValue in unix microseconds, defaults to now
+//	limit ::= 'limit=' intValue, the maximum number of traces returned, defaults to 100
+func (aH *APIHandler) structuralSearch(w http.ResponseWriter, r *http.Request) {
+	query, err := aH.parseStructuralSearch(r)
+	if aH.handleError(w, err, http.StatusBadRequest) {
+		return
+	}
+	traces, err := aH.queryService.FindTracesByStructure(r.Context(), query)
+	if errors.Is(err, spanstore.ErrUnsupportedTagOperator) {
+		aH.handleError(w, err, http.StatusBadRequest)
+		return
+	}
+	if aH.handleError(w, err, http.StatusInternalServerError) {
+		return
+	}
+	var uiErrors []structuredError
+	uiTraces := make([]*ui.Trace, len(traces))
+	for i, trace := range traces {
+		// the query service matched the adjusted traces
+		uiTrace, uiErr := aH.convertModelToUI(trace, false)
+		if uiErr != nil {
+			uiErrors = append(uiErrors, *uiErr)
+		}
+		uiTraces[i] = uiTrace
+	}
+	structuredRes := structuredResponse{
+		Data:   uiTraces,
+		Errors: uiErrors,
+	}
+	aH.writeJSON(w, r, &structuredRes)
+}
+
+func (aH *APIHandler) parseStructuralSearch(r *http.Request) (*querysvc.StructuralQuery, error) {
+	value := r.FormValue(structuralQueryParam)
+	if value == "" {
+		return nil, fmt.Errorf("parameter '%s' is required", structuralQueryParam)
+	}
+	expr, err := parseStructuralQuery(value)
+	if err != nil {
+		return nil, fmt.Errorf("malformed '%s' parameter: %w", structuralQueryParam, err)
+	}
+	selectors := expr.lookups()
+	if selectors == nil {
+		return nil, errStructuralQueryUnbounded
+	}
+	startTime, err := aH.queryParser.parseTime(startTimeParam, r)
+	if err != nil {
+		return nil, err
+	}
+	endTime, err := aH.queryParser.parseTime(endTimeParam, r)
+	if err != nil {
+		return nil, err
+	}
+	limit := defaultQueryLimit
+	if value := r.FormValue(limitParam); value != "" {
+		limitParsed, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		limit = int(limitParsed)
+	}
+	candidates := make([]*spanstore.TraceQueryParameters, len(selectors))
+	for i, selector := range selectors {
+		candidates[i] = selector.traceQuery(startTime, endTime)
+	}
+	return &querysvc.StructuralQuery{
+		Candidates: candidates,
+		Match: func(trace *model.Trace) bool {
+			return expr.matches(newSpanGraph(trace))
+		},
+		NumTraces:     limit,
+		MaxCandidates: structuralQueryMaxCandidates,
+	}, nil
+}
@@ -0,0 +1,358 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+const tagFieldPrefix = "tag."
+
+type lexKind int
+
+const (
+	lexEOF lexKind = iota
+	lexSymbol
+	lexString
+	lexWord
+)
+
+// lexSymbols are the operators and punctuation of structural queries, longest first.
+var lexSymbols = []string{"&&", "||", ">>", ">=", "<=", "!=", "=~", "{", "}", "(", ")", ",", ">", "<", "="}
+
+// lexReserved are the characters which cannot appear in unquoted words.
+const lexReserved = `{}(),"=!<>~&|`
+
+type lexToken struct {
+	kind lexKind
+	text string
+	pos  int
+}
+
+func (t lexToken) String() string {
+	switch t.kind {
+	case lexEOF:
+		return "end of query"
+	case lexString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("'%s'", t.text)
+	}
+}
+
+func lexStructuralQuery(query string) ([]lexToken, error) {
+	var tokens []lexToken
+	pos := 0
+	for pos < len(query) {
+		c := query[pos]
+		if unicode.IsSpace(rune(c)) {
+			pos++
+			continue
+		}
+		if c == '"' {
+			end := pos + 1
+			for end < len(query) && query[end] != '"' {
+				if query[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(query) {
+				return nil, fmt.Errorf("unterminated string at position %d", pos)
+			}
+			value, err := strconv.Unquote(query[pos : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at position %d: %w", pos, err)
+			}
+			tokens = append(tokens, lexToken{kind: lexString, text: value, pos: pos})
+			pos = end + 1
+			continue
+		}
+		if symbol := matchSymbol(query[pos:]); symbol != "" {
+			tokens = append(tokens, lexToken{kind: lexSymbol, text: symbol, pos: pos})
+			pos += len(symbol)
+			continue
+		}
+		if strings.IndexByte(lexReserved, c) >= 0 {
+			return nil, fmt.Errorf("unexpected character '%c' at position %d", c, pos)
+		}
+		end := pos
+		for end < len(query) && !unicode.IsSpace(rune(query[end])) && strings.IndexByte(lexReserved, query[end]) < 0 {
+			end++
+		}
+		tokens = append(tokens, lexToken{kind: lexWord, text: query[pos:end], pos: pos})
+		pos = end
+	}
+	return append(tokens, lexToken{kind: lexEOF, pos: pos}), nil
+}
+
+func matchSymbol(s string) string {
+	for _, symbol := range lexSymbols {
+		if strings.HasPrefix(s, symbol) {
+			return symbol
+		}
+	}
+	return ""
+}
+
+// structuralQueryParser is a recursive descent parser of structural queries.
+type structuralQueryParser struct {
+	tokens []lexToken
+	next   int
+}
+
+// parseStructuralQuery parses a query matching traces by conditions on several of their spans.
+// Query syntax:
+//
+//	query ::= and { '||' and }
+//	and ::= term { '&&' term }
+//	term ::= '(' query ')' | path
+//	path ::= selector { ('>' | '>>') selector }
+//	selector ::= '{' [ condition { ',' condition } ] '}'
+//	condition ::= 'service' ('=' | '!=' | '=~') value
+//	            | 'operation' ('=' | '!=' | '=~') value
+//	            | 'duration' ('=' | '!=' | '>' | '>=' | '<' | '<=') durationValue
+//	            | 'tag.' key [ ('=' | '!=' | '=~' | '>' | '>=' | '<' | '<=') value ]
+//	value ::= quoted string | unquoted word
+//
+// A path matches the spans of its last selector whose parent ('>') or ancestor ('>>') is matched by
+// the preceding path, and a trace satisfies a path if the path matches one of its spans. A tag
+// condition without an operator requires the tag to exist. For example:
+//
+//	{service="frontend"} > {service="db", tag.error=true}
+//	{operation="checkout"} && {operation="refund"}
+func parseStructuralQuery(query string) (structuralExpr, error) {
+	tokens, err := lexStructuralQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &structuralQueryParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if token := p.peek(); token.kind != lexEOF {
+		return nil, p.unexpected(token)
+	}
+	return expr, nil
+}
+
+func (p *structuralQueryParser) peek() lexToken {
+	return p.tokens[p.next]
+}
+
+func (p *structuralQueryParser) advance() lexToken {
+	token := p.tokens[p.next]
+	if token.kind != lexEOF {
+		p.next++
+	}
+	return token
+}
+
+// accept consumes the next token if it is the symbol.
+func (p *structuralQueryParser) accept(symbol string) bool {
+	if token := p.peek(); token.kind == lexSymbol && token.text == symbol {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *structuralQueryParser) expect(symbol string) error {
+	if !p.accept(symbol) {
+		return fmt.Errorf("expecting '%s' at position %d, found %s", symbol, p.peek().pos, p.peek())
+	}
+	return nil
+}
+
+func (p *structuralQueryParser) unexpected(token lexToken) error {
+	return fmt.Errorf("unexpected %s at position %d", token, token.pos)
+}
+
+func (p *structuralQueryParser) parseOr() (structuralExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *structuralQueryParser) parseAnd() (structuralExpr, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *structuralQueryParser) parseTerm() (structuralExpr, error) {
+	if p.accept("(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	}
+	return p.parsePath()
+}
+
+func (p *structuralQueryParser) parsePath() (structuralExpr, error) {
+	selector, err := p.parseSelector()
+	if err != nil {
+		return nil, err
+	}
+	path := &pathExpr{selectors: []*spanSelector{selector}}
+	for {
+		var relation spanRelation
+		switch {
+		case p.accept(">"):
+			relation = childRelation
+		case p.accept(">>"):
+			relation = descendantRelation
+		default:
+			return path, nil
+		}
+		selector, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		path.relations = append(path.relations, relation)
+		path.selectors = append(path.selectors, selector)
+	}
+}
+
+func (p *structuralQueryParser) parseSelector() (*spanSelector, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	selector := &spanSelector{}
+	if p.accept("}") {
+		return selector, nil
+	}
+	for {
+		if err := p.parseCondition(selector); err != nil {
+			return nil, err
+		}
+		if p.accept("}") {
+			return selector, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseCondition parses a condition and adds it to the selector.
+func (p *structuralQueryParser) parseCondition(selector *spanSelector) error {
+	field := p.advance()
+	if field.kind != lexWord {
+		return p.unexpected(field)
+	}
+	if field.text != "service" && field.text != "operation" && field.text != "duration" &&
+		(!strings.HasPrefix(field.text, tagFieldPrefix) || len(field.text) == len(tagFieldPrefix)) {
+		return fmt.Errorf("unknown field '%s' at position %d, expecting service, operation, duration or tag.KEY", field.text, field.pos)
+	}
+	operator := spanstore.TagExists
+	var value string
+	if token := p.peek(); token.kind == lexSymbol && isConditionOperator(token.text) {
+		p.advance()
+		operator = spanstore.TagOperator(token.text)
+		valueToken := p.advance()
+		if valueToken.kind != lexString && valueToken.kind != lexWord {
+			return p.unexpected(valueToken)
+		}
+		value = valueToken.text
+	}
+	condition, err := newSpanCondition(field.text, operator, value)
+	if err != nil {
+		return fmt.Errorf("invalid condition at position %d: %w", field.pos, err)
+	}
+	selector.conditions = append(selector.conditions, condition)
+	if operator == spanstore.TagEquals {
+		selector.addLookup(field.text, value)
+	}
+	return nil
+}
+
+func isConditionOperator(symbol string) bool {
+	switch spanstore.TagOperator(symbol) {
+	case spanstore.TagEquals, spanstore.TagNotEquals, spanstore.TagMatches,
+		spanstore.TagGreaterThan, spanstore.TagGreaterThanOrEqual, spanstore.TagLessThan, spanstore.TagLessThanOrEqual:
+		return true
+	}
+	return false
+}
+
+func newSpanCondition(field string, operator spanstore.TagOperator, value string) (spanCondition, error) {
+	switch field {
+	case "service", "operation":
+		switch operator {
+		case spanstore.TagEquals, spanstore.TagNotEquals, spanstore.TagMatches:
+		default:
+			return nil, fmt.Errorf("%s expects one of =, !=, =~", field)
+		}
+		matcher, err := spanstore.NewTagMatcher(spanstore.TagPredicate{Key: field, Operator: operator, Value: value})
+		if err != nil {
+			return nil, err
+		}
+		if field == "service" {
+			return &serviceCondition{matcher: matcher}, nil
+		}
+		return &operationCondition{matcher: matcher}, nil
+	case "duration":
+		switch operator {
+		case spanstore.TagMatches, spanstore.TagExists:
+			return nil, errors.New("duration expects one of =, !=, >, >=, <, <=")
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, err
+		}
+		return &durationCondition{operator: operator, duration: duration}, nil
+	}
+	matcher, err := spanstore.NewTagMatcher(spanstore.TagPredicate{
+		Key:      strings.TrimPrefix(field, tagFieldPrefix),
+		Operator: operator,
+		Value:    value,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tagCondition{matcher: matcher}, nil
+}
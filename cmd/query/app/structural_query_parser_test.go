@@ -0,0 +1,138 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+func TestLexStructuralQuery(t *testing.T) {
+	tokens, err := lexStructuralQuery(`({service="a\"b"}>>{tag.http.status_code>=500})||`)
+	require.NoError(t, err)
+	assert.Equal(t, []lexToken{
+		{kind: lexSymbol, text: "(", pos: 0},
+		{kind: lexSymbol, text: "{", pos: 1},
+		{kind: lexWord, text: "service", pos: 2},
+		{kind: lexSymbol, text: "=", pos: 9},
+		{kind: lexString, text: `a"b`, pos: 10},
+		{kind: lexSymbol, text: "}", pos: 16},
+		{kind: lexSymbol, text: ">>", pos: 17},
+		{kind: lexSymbol, text: "{", pos: 19},
+		{kind: lexWord, text: "tag.http.status_code", pos: 20},
+		{kind: lexSymbol, text: ">=", pos: 40},
+		{kind: lexWord, text: "500", pos: 42},
+		{kind: lexSymbol, text: "}", pos: 45},
+		{kind: lexSymbol, text: ")", pos: 46},
+		{kind: lexSymbol, text: "||", pos: 47},
+		{kind: lexEOF, pos: 49},
+	}, tokens)
+}
+
+func TestParseStructuralQuery(t *testing.T) {
+	expr, err := parseStructuralQuery(`{service="frontend"} > {service=db, tag.error=true, duration>10ms} || ({operation="x"} && {service=b} >> {tag.retry})`)
+	require.NoError(t, err)
+
+	or, ok := expr.(*orExpr)
+	require.True(t, ok)
+	path, ok := or.left.(*pathExpr)
+	require.True(t, ok)
+	assert.Len(t, path.selectors, 2)
+	assert.Equal(t, []spanRelation{childRelation}, path.relations)
+	assert.Equal(t, "frontend", path.selectors[0].service)
+	assert.Len(t, path.selectors[1].conditions, 3)
+	assert.Equal(t, "db", path.selectors[1].service)
+	assert.Equal(t, []spanstore.TagPredicate{{Key: "error", Operator: spanstore.TagEquals, Value: "true"}}, path.selectors[1].tags)
+
+	and, ok := or.right.(*andExpr)
+	require.True(t, ok)
+	path, ok = and.right.(*pathExpr)
+	require.True(t, ok)
+	assert.Equal(t, []spanRelation{descendantRelation}, path.relations)
+	assert.Empty(t, path.selectors[1].tags)
+
+	lookups := expr.lookups()
+	require.Len(t, lookups, 2)
+	assert.Equal(t, "db", lookups[0].service)
+	assert.Equal(t, "b", lookups[1].service)
+}
+
+func TestParseStructuralQueryLookups(t *testing.T) {
+	tests := []struct {
+		query    string
+		services []string
+	}{
+		{query: `{}`},
+		{query: `{service!=a}`},
+		{query: `{service=a}`, services: []string{"a"}},
+		{query: `{service=a} > {service=~"b.*"}`, services: []string{"a"}},
+		{query: `{operation=x} && {service=a}`, services: []string{"a"}},
+		{query: `{service=a} && {service=b}`, services: []string{"a"}},
+		{query: `{service=a} || {operation=x}`},
+		{query: `({service=a} || {service=b}) && {}`, services: []string{"a", "b"}},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			expr, err := parseStructuralQuery(test.query)
+			require.NoError(t, err)
+			var services []string
+			for _, selector := range expr.lookups() {
+				services = append(services, selector.service)
+			}
+			assert.Equal(t, test.services, services)
+		})
+	}
+}
+
+func TestParseStructuralQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{query: ``, err: "expecting '{' at position 0, found end of query"},
+		{query: `{service=a`, err: "expecting ',' at position 10, found end of query"},
+		{query: `{service=a} {service=b}`, err: "unexpected '{' at position 12"},
+		{query: `({service=a}`, err: "expecting ')' at position 12, found end of query"},
+		{query: `{service=a} &&`, err: "expecting '{' at position 14, found end of query"},
+		{query: `{service=a} > `, err: "expecting '{' at position 14, found end of query"},
+		{query: `{service="a}`, err: "unterminated string at position 9"},
+		{query: `{service="\q"}`, err: "invalid string at position 9: invalid syntax"},
+		{query: `{service=a} & {}`, err: "unexpected character '&' at position 12"},
+		{query: `{name=a}`, err: "unknown field 'name' at position 1, expecting service, operation, duration or tag.KEY"},
+		{query: `{tag.=a}`, err: "unknown field 'tag.' at position 1, expecting service, operation, duration or tag.KEY"},
+		{query: `{=a}`, err: "unexpected '=' at position 1"},
+		{query: `{service=}`, err: "unexpected '}' at position 9"},
+		{query: `{service}`, err: "invalid condition at position 1: service expects one of =, !=, =~"},
+		{query: `{operation>1}`, err: "invalid condition at position 1: operation expects one of =, !=, =~"},
+		{query: `{service=~"("}`, err: "invalid condition at position 1: invalid regular expression for tag service: error parsing regexp: missing closing ): `^(?:()$`"},
+		{query: `{duration=~1s}`, err: "invalid condition at position 1: duration expects one of =, !=, >, >=, <, <="},
+		{query: `{tag.code>x}`, err: `invalid condition at position 1: invalid number for tag code: strconv.ParseFloat: parsing "x": invalid syntax`},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			_, err := parseStructuralQuery(test.query)
+			assert.EqualError(t, err, test.err)
+		})
+	}
+
+	// the message of duration errors depends on the Go version
+	_, err := parseStructuralQuery(`{duration>1}`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid condition at position 1: time: missing unit in duration")
+}
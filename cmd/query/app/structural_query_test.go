@@ -0,0 +1,94 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func structuralTestTrace() *model.Trace {
+	traceID := model.NewTraceID(0, 1)
+	span := func(id, parent uint64, service, operation string, duration time.Duration, tags ...model.KeyValue) *model.Span {
+		span := &model.Span{
+			TraceID:       traceID,
+			SpanID:        model.NewSpanID(id),
+			OperationName: operation,
+			Duration:      duration,
+			Tags:          tags,
+			Process:       &model.Process{ServiceName: service},
+		}
+		if parent != 0 {
+			span.References = []model.SpanRef{model.NewChildOfRef(traceID, model.NewSpanID(parent))}
+		}
+		return span
+	}
+	return &model.Trace{Spans: []*model.Span{
+		span(1, 0, "frontend", "HTTP GET /checkout", 100*time.Millisecond),
+		span(2, 1, "auth", "authorize", 5*time.Millisecond),
+		span(3, 1, "api", "checkout", 80*time.Millisecond, model.Int64("http.status_code", 500)),
+		span(4, 3, "db", "query", 50*time.Millisecond, model.Bool("error", true)),
+		span(5, 3, "db", "query", 10*time.Millisecond, model.Bool("error", false)),
+	}}
+}
+
+func TestStructuralQueryMatches(t *testing.T) {
+	tests := []struct {
+		query   string
+		matches bool
+	}{
+		{query: `{}`, matches: true},
+		{query: `{service=api} > {service=db, tag.error=true}`, matches: true},
+		{query: `{service=frontend} > {service=db, tag.error=true}`, matches: false},
+		{query: `{service=frontend} >> {service=db, tag.error=true}`, matches: true},
+		{query: `{service=frontend} > {service=api} > {service=db}`, matches: true},
+		{query: `{service=frontend} > {service=auth} > {service=db}`, matches: false},
+		{query: `{service=db} > {service=api}`, matches: false},
+		{query: `{service=api} > {service=db, tag.error!=true, duration<20ms}`, matches: true},
+		{query: `{service=api} > {service=db, tag.error!=true, duration>20ms}`, matches: false},
+		{query: `{operation=authorize} && {operation=checkout}`, matches: true},
+		{query: `{operation=authorize} && {operation=refund}`, matches: false},
+		{query: `{operation=refund} || {operation=~"HTTP .*"}`, matches: true},
+		{query: `({operation=refund} || {service=auth}) && {tag.http.status_code>=500}`, matches: true},
+		{query: `{tag.http.status_code>=500, service!=api}`, matches: false},
+		{query: `{tag.error}`, matches: true},
+		{query: `{service=auth, tag.error}`, matches: false},
+		{query: `{duration>=100ms, service=frontend}`, matches: true},
+		{query: `{duration=5ms} && {duration!=5ms}`, matches: true},
+		{query: `{duration<=5ms} > {}`, matches: false},
+	}
+	graph := newSpanGraph(structuralTestTrace())
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			expr, err := parseStructuralQuery(test.query)
+			require.NoError(t, err)
+			assert.Equal(t, test.matches, expr.matches(graph))
+		})
+	}
+}
+
+func TestStructuralQueryParentCycle(t *testing.T) {
+	trace := structuralTestTrace()
+	// make the root a child of the db span, which is one of its descendants
+	trace.Spans[0].References = []model.SpanRef{model.NewChildOfRef(trace.Spans[0].TraceID, model.NewSpanID(4))}
+	expr, err := parseStructuralQuery(`{service=auth} >> {service=frontend}`)
+	require.NoError(t, err)
+	assert.False(t, expr.matches(newSpanGraph(trace)))
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jaegertracing/jaeger/cmd/query/app/querysvc"
+	"github.com/jaegertracing/jaeger/model"
+	ui "github.com/jaegertracing/jaeger/model/json"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+const (
+	compareTraceAParam = "a"
+	compareTraceBParam = "b"
+
+	spanDiffAdded   = "added"
+	spanDiffRemoved = "removed"
+	spanDiffCommon  = "common"
+)
+
+// traceComparison is the JSON representation of querysvc.TraceComparison.
+// Durations are in microseconds, like in the UI model.
+type traceComparison struct {
+	TraceA  traceSummary `json:"traceA"`
+	TraceB  traceSummary `json:"traceB"`
+	Added   int          `json:"added"`
+	Removed int          `json:"removed"`
+	Common  int          `json:"common"`
+	Spans   []spanDiff   `json:"spans"`
+}
+
+type traceSummary struct {
+	TraceID  ui.TraceID `json:"traceID"`
+	Duration uint64     `json:"duration"`
+	Spans    int        `json:"spans"`
+}
+
+type spanDiff struct {
+	Path          []serviceOperation `json:"path"`
+	Status        string             `json:"status"`
+	A             *spanSummary       `json:"a,omitempty"`
+	B             *spanSummary       `json:"b,omitempty"`
+	DurationDelta int64              `json:"durationDelta"`
+	TagDiffs      []tagDiff          `json:"tagDiffs,omitempty"`
+}
+
+type serviceOperation struct {
+	ServiceName   string `json:"serviceName"`
+	OperationName string `json:"operationName"`
+}
+
+type spanSummary struct {
+	SpanID   ui.SpanID `json:"spanID"`
+	Duration uint64    `json:"duration"`
+	Error    bool      `json:"error"`
+}
+
+type tagDiff struct {
+	Key string  `json:"key"`
+	A   *string `json:"a"`
+	B   *string `json:"b"`
+}
+
+// compareTraces implements the REST API /traces/compare?a={trace-id}&b={trace-id}.
+// It compares trace b against trace a, aligned by the services and operations of their spans.
+func (aH *APIHandler) compareTraces(w http.ResponseWriter, r *http.Request) {
+	traceIDA, err := parseCompareTraceID(r, compareTraceAParam)
+	if aH.handleError(w, err, http.StatusBadRequest) {
+		return
+	}
+	traceIDB, err := parseCompareTraceID(r, compareTraceBParam)
+	if aH.handleError(w, err, http.StatusBadRequest) {
+		return
+	}
+	comparison, err := aH.queryService.CompareTraces(r.Context(), traceIDA, traceIDB)
+	if errors.Is(err, spanstore.ErrTraceNotFound) {
+		aH.handleError(w, err, http.StatusNotFound)
+		return
+	}
+	if aH.handleError(w, err, http.StatusInternalServerError) {
+		return
+	}
+	structuredRes := structuredResponse{
+		Data:   convertTraceComparison(comparison),
+		Errors: []structuredError{},
+	}
+	aH.writeJSON(w, r, &structuredRes)
+}
+
+func parseCompareTraceID(r *http.Request, param string) (model.TraceID, error) {
+	value := r.FormValue(param)
+	if value == "" {
+		return model.TraceID{}, fmt.Errorf("parameter '%s' is required", param)
+	}
+	return model.TraceIDFromString(value)
+}
+
+func convertTraceComparison(c *querysvc.TraceComparison) *traceComparison {
+	res := &traceComparison{
+		TraceA: summarizeTrace(c.A),
+		TraceB: summarizeTrace(c.B),
+		Spans:  make([]spanDiff, 0, len(c.Spans)),
+	}
+	for _, d := range c.Spans {
+		diff := spanDiff{
+			Path:          make([]serviceOperation, len(d.Path)),
+			A:             summarizeSpan(d.A, d.ErrorA()),
+			B:             summarizeSpan(d.B, d.ErrorB()),
+			DurationDelta: d.DurationDelta().Microseconds(),
+		}
+		for i, so := range d.Path {
+			diff.Path[i] = serviceOperation{ServiceName: so.Service, OperationName: so.Operation}
+		}
+		switch {
+		case d.Added():
+			diff.Status = spanDiffAdded
+			res.Added++
+		case d.Removed():
+			diff.Status = spanDiffRemoved
+			res.Removed++
+		default:
+			diff.Status = spanDiffCommon
+			res.Common++
+		}
+		for _, td := range d.TagDiffs {
+			diff.TagDiffs = append(diff.TagDiffs, tagDiff{Key: td.Key, A: tagValue(td.A), B: tagValue(td.B)})
+		}
+		res.Spans = append(res.Spans, diff)
+	}
+	return res
+}
+
+func summarizeTrace(trace *model.Trace) traceSummary {
+	var summary traceSummary
+	var start, end time.Time
+	for i, span := range trace.Spans {
+		if i == 0 || span.StartTime.Before(start) {
+			start = span.StartTime
+		}
+		if spanEnd := span.StartTime.Add(span.Duration); i == 0 || spanEnd.After(end) {
+			end = spanEnd
+		}
+	}
+	if len(trace.Spans) > 0 {
+		summary.TraceID = ui.TraceID(trace.Spans[0].TraceID.String())
+	}
+	summary.Duration = model.DurationAsMicroseconds(end.Sub(start))
+	summary.Spans = len(trace.Spans)
+	return summary
+}
+
+func summarizeSpan(span *model.Span, isError bool) *spanSummary {
+	if span == nil {
+		return nil
+	}
+	return &spanSummary{
+		SpanID:   ui.SpanID(span.SpanID.String()),
+		Duration: model.DurationAsMicroseconds(span.Duration),
+		Error:    isError,
+	}
+}
+
+func tagValue(kv *model.KeyValue) *string {
+	if kv == nil {
+		return nil
+	}
+	value := kv.AsString()
+	return &value
+}
@@ -35,9 +35,11 @@ import (
 	"github.com/jaegertracing/jaeger/cmd/query/app"
 	"github.com/jaegertracing/jaeger/cmd/query/app/querysvc"
 	"github.com/jaegertracing/jaeger/pkg/config"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/pkg/version"
 	"github.com/jaegertracing/jaeger/plugin/storage"
 	"github.com/jaegertracing/jaeger/ports"
+	jaegerStorage "github.com/jaegertracing/jaeger/storage"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 	storageMetrics "github.com/jaegertracing/jaeger/storage/spanstore/metrics"
 )
@@ -85,6 +87,9 @@ func main() {
 			defer closer.Close()
 			opentracing.SetGlobalTracer(tracer)
 			queryOpts := new(app.QueryOptions).InitFromViper(v, logger)
+			if err := queryOpts.Tenancy.Validate(); err != nil {
+				logger.Fatal("Invalid multi-tenancy configuration", zap.Error(err))
+			}
 			// TODO: Need to figure out set enable/disable propagation on storage plugins.
 			v.Set(spanstore.StoragePropagationKey, queryOpts.BearerTokenPropagation)
 			storageFactory.InitFromViper(v)
@@ -95,6 +100,12 @@ func main() {
 			if err != nil {
 				logger.Fatal("Failed to create span reader", zap.Error(err))
 			}
+			if queryOpts.Tenancy.Enabled {
+				if !storageFactory.SupportsTenancy() {
+					logger.Fatal("Multi-tenancy is enabled but the span storage does not support it")
+				}
+				spanReader = jaegerStorage.NewTenantSpanReader(storageFactory, spanReader)
+			}
 			spanReader = storageMetrics.NewReadMetricsDecorator(spanReader, metricsFactory)
 			dependencyReader, err := storageFactory.CreateDependencyReader()
 			if err != nil {
@@ -138,6 +149,7 @@ func main() {
 		svc.AddFlags,
 		storageFactory.AddFlags,
 		app.AddFlags,
+		tenancy.AddFlags,
 	)
 
 	if error := command.Execute(); error != nil {
@@ -0,0 +1,255 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/opentracing/opentracing-go/ext"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/multierror"
+)
+
+const (
+	// NoServiceName is the service name given to model.Process if the resource has no service.name attribute
+	NoServiceName = "OTLPResourceNoServiceName"
+
+	serviceNameAttribute = "service.name"
+	eventNameField       = "event"
+
+	scopeNameTag         = "otel.scope.name"
+	scopeVersionTag      = "otel.scope.version"
+	statusCodeTag        = "otel.status_code"
+	statusDescriptionTag = "otel.status_description"
+	traceStateTag        = "w3c.tracestate"
+
+	statusOK    = "OK"
+	statusError = "ERROR"
+)
+
+var spanKinds = map[tracev1.Span_SpanKind]string{
+	tracev1.Span_SPAN_KIND_INTERNAL: "internal",
+	tracev1.Span_SPAN_KIND_SERVER:   string(ext.SpanKindRPCServerEnum),
+	tracev1.Span_SPAN_KIND_CLIENT:   string(ext.SpanKindRPCClientEnum),
+	tracev1.Span_SPAN_KIND_PRODUCER: string(ext.SpanKindProducerEnum),
+	tracev1.Span_SPAN_KIND_CONSUMER: string(ext.SpanKindConsumerEnum),
+}
+
+// ToDomain transforms OTLP resource spans into model spans. All spans of the same resource share a Process.
+// Spans with invalid trace or span IDs are skipped, and the returned error describes them.
+func ToDomain(resourceSpans []*tracev1.ResourceSpans) ([]*model.Span, error) {
+	var spans []*model.Span
+	var errs []error
+	for _, rs := range resourceSpans {
+		process := resourceToProcess(rs.GetResource())
+		for _, ss := range rs.GetScopeSpans() {
+			scopeTags := scopeToTags(ss.GetScope())
+			for _, s := range ss.GetSpans() {
+				span, err := spanToDomain(s, process, scopeTags)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				spans = append(spans, span)
+			}
+		}
+	}
+	return spans, multierror.Wrap(errs)
+}
+
+func resourceToProcess(resource *resourcev1.Resource) *model.Process {
+	process := &model.Process{ServiceName: NoServiceName}
+	for _, attr := range resource.GetAttributes() {
+		if attr.GetKey() == serviceNameAttribute {
+			process.ServiceName = attr.GetValue().GetStringValue()
+			continue
+		}
+		process.Tags = append(process.Tags, attributeToKeyValue(attr))
+	}
+	return process
+}
+
+func scopeToTags(scope *commonv1.InstrumentationScope) []model.KeyValue {
+	var tags []model.KeyValue
+	if name := scope.GetName(); name != "" {
+		tags = append(tags, model.String(scopeNameTag, name))
+	}
+	if version := scope.GetVersion(); version != "" {
+		tags = append(tags, model.String(scopeVersionTag, version))
+	}
+	return tags
+}
+
+func spanToDomain(s *tracev1.Span, process *model.Process, scopeTags []model.KeyValue) (*model.Span, error) {
+	traceID, err := model.TraceIDFromBytes(s.GetTraceId())
+	if err != nil {
+		return nil, fmt.Errorf("invalid trace ID in span %q: %w", s.GetName(), err)
+	}
+	spanID, err := model.SpanIDFromBytes(s.GetSpanId())
+	if err != nil {
+		return nil, fmt.Errorf("invalid span ID in span %q: %w", s.GetName(), err)
+	}
+	refs, err := spanReferences(traceID, s)
+	if err != nil {
+		return nil, err
+	}
+	startTime := time.Unix(0, int64(s.GetStartTimeUnixNano())).UTC()
+	var duration time.Duration
+	if s.GetEndTimeUnixNano() > s.GetStartTimeUnixNano() {
+		duration = time.Duration(s.GetEndTimeUnixNano() - s.GetStartTimeUnixNano())
+	}
+	return &model.Span{
+		TraceID:       traceID,
+		SpanID:        spanID,
+		OperationName: s.GetName(),
+		References:    refs,
+		Flags:         model.SampledFlag,
+		StartTime:     startTime,
+		Duration:      duration,
+		Tags:          spanTags(s, scopeTags),
+		Logs:          eventsToLogs(s.GetEvents()),
+		Process:       process,
+	}, nil
+}
+
+func spanReferences(traceID model.TraceID, s *tracev1.Span) ([]model.SpanRef, error) {
+	var refs []model.SpanRef
+	if len(s.GetParentSpanId()) > 0 {
+		parentID, err := model.SpanIDFromBytes(s.GetParentSpanId())
+		if err != nil {
+			return nil, fmt.Errorf("invalid parent span ID in span %q: %w", s.GetName(), err)
+		}
+		if parentID != 0 {
+			refs = append(refs, model.NewChildOfRef(traceID, parentID))
+		}
+	}
+	for _, link := range s.GetLinks() {
+		linkTraceID, err := model.TraceIDFromBytes(link.GetTraceId())
+		if err != nil {
+			return nil, fmt.Errorf("invalid link trace ID in span %q: %w", s.GetName(), err)
+		}
+		linkSpanID, err := model.SpanIDFromBytes(link.GetSpanId())
+		if err != nil {
+			return nil, fmt.Errorf("invalid link span ID in span %q: %w", s.GetName(), err)
+		}
+		refs = append(refs, model.NewFollowsFromRef(linkTraceID, linkSpanID))
+	}
+	return refs, nil
+}
+
+func spanTags(s *tracev1.Span, scopeTags []model.KeyValue) []model.KeyValue {
+	tags := make([]model.KeyValue, 0, len(s.GetAttributes())+len(scopeTags)+4)
+	for _, attr := range s.GetAttributes() {
+		tags = append(tags, attributeToKeyValue(attr))
+	}
+	tags = append(tags, scopeTags...)
+	if kind, ok := spanKinds[s.GetKind()]; ok {
+		tags = append(tags, model.String(string(ext.SpanKind), kind))
+	}
+	if traceState := s.GetTraceState(); traceState != "" {
+		tags = append(tags, model.String(traceStateTag, traceState))
+	}
+	switch s.GetStatus().GetCode() {
+	case tracev1.Status_STATUS_CODE_OK:
+		tags = append(tags, model.String(statusCodeTag, statusOK))
+	case tracev1.Status_STATUS_CODE_ERROR:
+		tags = append(tags, model.String(statusCodeTag, statusError), model.Bool(string(ext.Error), true))
+	}
+	if message := s.GetStatus().GetMessage(); message != "" {
+		tags = append(tags, model.String(statusDescriptionTag, message))
+	}
+	return tags
+}
+
+func eventsToLogs(events []*tracev1.Span_Event) []model.Log {
+	if len(events) == 0 {
+		return nil
+	}
+	logs := make([]model.Log, 0, len(events))
+	for _, event := range events {
+		fields := make([]model.KeyValue, 0, len(event.GetAttributes())+1)
+		if name := event.GetName(); name != "" {
+			fields = append(fields, model.String(eventNameField, name))
+		}
+		for _, attr := range event.GetAttributes() {
+			fields = append(fields, attributeToKeyValue(attr))
+		}
+		logs = append(logs, model.Log{
+			Timestamp: time.Unix(0, int64(event.GetTimeUnixNano())).UTC(),
+			Fields:    fields,
+		})
+	}
+	return logs
+}
+
+// attributeToKeyValue converts an OTLP attribute into a tag. Arrays and maps have no equivalent
+// in the Jaeger model, so they are stored as JSON strings.
+func attributeToKeyValue(attr *commonv1.KeyValue) model.KeyValue {
+	key := attr.GetKey()
+	switch v := attr.GetValue().GetValue().(type) {
+	case *commonv1.AnyValue_BoolValue:
+		return model.Bool(key, v.BoolValue)
+	case *commonv1.AnyValue_IntValue:
+		return model.Int64(key, v.IntValue)
+	case *commonv1.AnyValue_DoubleValue:
+		return model.Float64(key, v.DoubleValue)
+	case *commonv1.AnyValue_BytesValue:
+		return model.Binary(key, v.BytesValue)
+	case *commonv1.AnyValue_ArrayValue, *commonv1.AnyValue_KvlistValue:
+		return model.String(key, anyValueToJSON(attr.GetValue()))
+	default:
+		return model.String(key, attr.GetValue().GetStringValue())
+	}
+}
+
+func anyValueToJSON(value *commonv1.AnyValue) string {
+	data, _ := json.Marshal(anyValueToInterface(value))
+	return string(data)
+}
+
+func anyValueToInterface(value *commonv1.AnyValue) interface{} {
+	switch v := value.GetValue().(type) {
+	case *commonv1.AnyValue_StringValue:
+		return v.StringValue
+	case *commonv1.AnyValue_BoolValue:
+		return v.BoolValue
+	case *commonv1.AnyValue_IntValue:
+		return v.IntValue
+	case *commonv1.AnyValue_DoubleValue:
+		return v.DoubleValue
+	case *commonv1.AnyValue_BytesValue:
+		return v.BytesValue
+	case *commonv1.AnyValue_ArrayValue:
+		values := make([]interface{}, 0, len(v.ArrayValue.GetValues()))
+		for _, item := range v.ArrayValue.GetValues() {
+			values = append(values, anyValueToInterface(item))
+		}
+		return values
+	case *commonv1.AnyValue_KvlistValue:
+		values := make(map[string]interface{}, len(v.KvlistValue.GetValues()))
+		for _, kv := range v.KvlistValue.GetValues() {
+			values[kv.GetKey()] = anyValueToInterface(kv.GetValue())
+		}
+		return values
+	default:
+		return nil
+	}
+}
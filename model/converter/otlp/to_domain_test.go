@@ -0,0 +1,225 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+var (
+	testTraceID  = []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2}
+	testSpanID   = []byte{0, 0, 0, 0, 0, 0, 0, 3}
+	testParentID = []byte{0, 0, 0, 0, 0, 0, 0, 4}
+	testLinkID   = []byte{0, 0, 0, 0, 0, 0, 0, 5}
+	testStart    = time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC)
+)
+
+func stringAttr(key, value string) *commonv1.KeyValue {
+	return &commonv1.KeyValue{Key: key, Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: value}}}
+}
+
+func TestToDomain(t *testing.T) {
+	resourceSpans := []*tracev1.ResourceSpans{{
+		Resource: &resourcev1.Resource{
+			Attributes: []*commonv1.KeyValue{
+				stringAttr("service.name", "frontend"),
+				stringAttr("host.name", "host1"),
+			},
+		},
+		ScopeSpans: []*tracev1.ScopeSpans{{
+			Scope: &commonv1.InstrumentationScope{Name: "net/http", Version: "1.0"},
+			Spans: []*tracev1.Span{{
+				TraceId:           testTraceID,
+				SpanId:            testSpanID,
+				ParentSpanId:      testParentID,
+				TraceState:        "k=v",
+				Name:              "GET /",
+				Kind:              tracev1.Span_SPAN_KIND_SERVER,
+				StartTimeUnixNano: uint64(testStart.UnixNano()),
+				EndTimeUnixNano:   uint64(testStart.Add(time.Second).UnixNano()),
+				Attributes: []*commonv1.KeyValue{
+					stringAttr("http.method", "GET"),
+					{Key: "http.status_code", Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: 500}}},
+				},
+				Events: []*tracev1.Span_Event{{
+					TimeUnixNano: uint64(testStart.Add(time.Millisecond).UnixNano()),
+					Name:         "exception",
+					Attributes:   []*commonv1.KeyValue{stringAttr("exception.message", "boom")},
+				}},
+				Links: []*tracev1.Span_Link{{TraceId: testTraceID, SpanId: testLinkID}},
+				Status: &tracev1.Status{
+					Code:    tracev1.Status_STATUS_CODE_ERROR,
+					Message: "internal error",
+				},
+			}},
+		}},
+	}}
+
+	spans, err := ToDomain(resourceSpans)
+	require.NoError(t, err)
+	require.Len(t, spans, 1)
+
+	traceID := model.NewTraceID(1, 2)
+	expected := &model.Span{
+		TraceID:       traceID,
+		SpanID:        model.NewSpanID(3),
+		OperationName: "GET /",
+		References: []model.SpanRef{
+			model.NewChildOfRef(traceID, model.NewSpanID(4)),
+			model.NewFollowsFromRef(traceID, model.NewSpanID(5)),
+		},
+		Flags:     model.SampledFlag,
+		StartTime: testStart,
+		Duration:  time.Second,
+		Tags: []model.KeyValue{
+			model.String("http.method", "GET"),
+			model.Int64("http.status_code", 500),
+			model.String("otel.scope.name", "net/http"),
+			model.String("otel.scope.version", "1.0"),
+			model.String("span.kind", "server"),
+			model.String("w3c.tracestate", "k=v"),
+			model.String("otel.status_code", "ERROR"),
+			model.Bool("error", true),
+			model.String("otel.status_description", "internal error"),
+		},
+		Logs: []model.Log{{
+			Timestamp: testStart.Add(time.Millisecond),
+			Fields: []model.KeyValue{
+				model.String("event", "exception"),
+				model.String("exception.message", "boom"),
+			},
+		}},
+		Process: &model.Process{
+			ServiceName: "frontend",
+			Tags:        []model.KeyValue{model.String("host.name", "host1")},
+		},
+	}
+	assert.Equal(t, expected, spans[0])
+}
+
+func TestToDomainNoServiceName(t *testing.T) {
+	spans, err := ToDomain([]*tracev1.ResourceSpans{{
+		ScopeSpans: []*tracev1.ScopeSpans{{
+			Spans: []*tracev1.Span{{TraceId: testTraceID, SpanId: testSpanID, Name: "op"}},
+		}},
+	}})
+	require.NoError(t, err)
+	require.Len(t, spans, 1)
+	assert.Equal(t, NoServiceName, spans[0].Process.ServiceName)
+	assert.Empty(t, spans[0].References)
+	assert.Empty(t, spans[0].Tags)
+	assert.Nil(t, spans[0].Logs)
+}
+
+func TestToDomainInvalidIDs(t *testing.T) {
+	tests := []struct {
+		span *tracev1.Span
+		err  string
+	}{
+		{
+			span: &tracev1.Span{Name: "a", TraceId: []byte{1}, SpanId: testSpanID},
+			err:  `invalid trace ID in span "a"`,
+		},
+		{
+			span: &tracev1.Span{Name: "b", TraceId: testTraceID, SpanId: []byte{1}},
+			err:  `invalid span ID in span "b"`,
+		},
+		{
+			span: &tracev1.Span{Name: "c", TraceId: testTraceID, SpanId: testSpanID, ParentSpanId: []byte{1}},
+			err:  `invalid parent span ID in span "c"`,
+		},
+		{
+			span: &tracev1.Span{Name: "d", TraceId: testTraceID, SpanId: testSpanID,
+				Links: []*tracev1.Span_Link{{TraceId: []byte{1}, SpanId: testLinkID}}},
+			err: `invalid link trace ID in span "d"`,
+		},
+		{
+			span: &tracev1.Span{Name: "e", TraceId: testTraceID, SpanId: testSpanID,
+				Links: []*tracev1.Span_Link{{TraceId: testTraceID, SpanId: []byte{1}}}},
+			err: `invalid link span ID in span "e"`,
+		},
+	}
+	for _, test := range tests {
+		valid := &tracev1.Span{Name: "valid", TraceId: testTraceID, SpanId: testSpanID}
+		spans, err := ToDomain([]*tracev1.ResourceSpans{{
+			ScopeSpans: []*tracev1.ScopeSpans{{
+				Spans: []*tracev1.Span{test.span, valid},
+			}},
+		}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), test.err)
+		require.Len(t, spans, 1)
+		assert.Equal(t, "valid", spans[0].OperationName)
+	}
+}
+
+func TestAttributeToKeyValue(t *testing.T) {
+	tests := []struct {
+		value    *commonv1.AnyValue
+		expected model.KeyValue
+	}{
+		{
+			value:    &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: "v"}},
+			expected: model.String("k", "v"),
+		},
+		{
+			value:    &commonv1.AnyValue{Value: &commonv1.AnyValue_BoolValue{BoolValue: true}},
+			expected: model.Bool("k", true),
+		},
+		{
+			value:    &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: 42}},
+			expected: model.Int64("k", 42),
+		},
+		{
+			value:    &commonv1.AnyValue{Value: &commonv1.AnyValue_DoubleValue{DoubleValue: 1.5}},
+			expected: model.Float64("k", 1.5),
+		},
+		{
+			value: &commonv1.AnyValue{Value: &commonv1.AnyValue_ArrayValue{ArrayValue: &commonv1.ArrayValue{
+				Values: []*commonv1.AnyValue{
+					{Value: &commonv1.AnyValue_StringValue{StringValue: "a"}},
+					{Value: &commonv1.AnyValue_IntValue{IntValue: 1}},
+				},
+			}}},
+			expected: model.String("k", `["a",1]`),
+		},
+		{
+			value: &commonv1.AnyValue{Value: &commonv1.AnyValue_KvlistValue{KvlistValue: &commonv1.KeyValueList{
+				Values: []*commonv1.KeyValue{stringAttr("x", "y")},
+			}}},
+			expected: model.String("k", `{"x":"y"}`),
+		},
+		{
+			value:    &commonv1.AnyValue{Value: &commonv1.AnyValue_BytesValue{BytesValue: []byte{1, 2}}},
+			expected: model.Binary("k", []byte{1, 2}),
+		},
+		{
+			value:    nil,
+			expected: model.String("k", ""),
+		},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, attributeToKeyValue(&commonv1.KeyValue{Key: "k", Value: test.value}))
+	}
+}
@@ -57,6 +57,13 @@ func TestFromDomain(t *testing.T) {
 	assert.Equal(t, modelSpans, newModelSpans)
 }
 
+func TestFromDomainProcess(t *testing.T) {
+	jaegerBatch := loadBatch(t, "fixtures/thrift_batch_01.json")
+	process := ToDomainProcess(jaegerBatch.Process)
+	assert.Equal(t, process, ToDomainProcess(FromDomainProcess(process)))
+	assert.Nil(t, FromDomainProcess(nil))
+}
+
 func TestKeyValueToTag(t *testing.T) {
 	dToJ := domainToJaegerTransformer{}
 	jaegerTag := dToJ.keyValueToTag(&model.KeyValue{
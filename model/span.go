@@ -18,6 +18,8 @@ package model
 import (
 	"encoding/gob"
 	"io"
+	"strconv"
+	"strings"
 
 	"github.com/opentracing/opentracing-go/ext"
 )
@@ -31,6 +33,7 @@ const (
 	FirehoseFlag = Flags(8)
 
 	samplerType        = "sampler.type"
+	samplerParam       = "sampler.param"
 	samplerTypeUnknown = "unknown"
 )
 
@@ -73,6 +76,25 @@ func (s *Span) GetSamplerType() string {
 	return samplerTypeUnknown
 }
 
+// GetSamplerParam returns the numeric value of the `sampler.param` tag and whether it could be found.
+// Some clients report the param as a string, in which case it is parsed.
+func (s *Span) GetSamplerParam() (float64, bool) {
+	tag, ok := KeyValues(s.Tags).FindByKey(samplerParam)
+	if !ok {
+		return 0, false
+	}
+	switch tag.VType {
+	case Float64Type:
+		return tag.Float64(), true
+	case Int64Type:
+		return float64(tag.Int64()), true
+	case StringType:
+		param, err := strconv.ParseFloat(tag.VStr, 64)
+		return param, err == nil
+	}
+	return 0, false
+}
+
 // IsRPCClient returns true if the span represents a client side of an RPC,
 // as indicated by the `span.kind` tag set to `client`.
 func (s *Span) IsRPCClient() bool {
@@ -85,6 +107,19 @@ func (s *Span) IsRPCServer() bool {
 	return s.HasSpanKind(ext.SpanKindRPCServerEnum)
 }
 
+// IsError returns true if the span is marked as failed by the `error` tag,
+// set to true either as a boolean or as a case-insensitive string.
+func (s *Span) IsError() bool {
+	tag, ok := KeyValues(s.Tags).FindByKey(string(ext.Error))
+	if !ok {
+		return false
+	}
+	if tag.VType == BoolType {
+		return tag.Bool()
+	}
+	return strings.EqualFold(tag.AsString(), "true")
+}
+
 // NormalizeTimestamps changes all timestamps in this span to UTC.
 func (s *Span) NormalizeTimestamps() {
 	s.StartTime = s.StartTime.UTC()
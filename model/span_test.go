@@ -194,6 +194,23 @@ func TestIsRPCClientServer(t *testing.T) {
 	assert.False(t, span2.IsRPCServer())
 }
 
+func TestIsError(t *testing.T) {
+	tests := []struct {
+		tags     model.KeyValues
+		expected bool
+	}{
+		{tags: nil, expected: false},
+		{tags: model.KeyValues{model.Bool("error", true)}, expected: true},
+		{tags: model.KeyValues{model.Bool("error", false)}, expected: false},
+		{tags: model.KeyValues{model.String("error", "TRUE")}, expected: true},
+		{tags: model.KeyValues{model.String("error", "no")}, expected: false},
+	}
+	for _, test := range tests {
+		span := &model.Span{Tags: test.tags}
+		assert.Equal(t, test.expected, span.IsError(), "%v", test.tags)
+	}
+}
+
 func TestIsDebug(t *testing.T) {
 	flags := model.Flags(0)
 	flags.SetDebug()
@@ -241,6 +258,26 @@ func TestSamplerType(t *testing.T) {
 	assert.Equal(t, "unknown", span.GetSamplerType())
 }
 
+func TestSamplerParam(t *testing.T) {
+	tests := []struct {
+		kv       model.KeyValue
+		expected float64
+		found    bool
+	}{
+		{kv: model.Float64("sampler.param", 0.5), expected: 0.5, found: true},
+		{kv: model.Int64("sampler.param", 1), expected: 1, found: true},
+		{kv: model.String("sampler.param", "0.25"), expected: 0.25, found: true},
+		{kv: model.String("sampler.param", "nonsense")},
+		{kv: model.Bool("sampler.param", true)},
+		{kv: model.KeyValue{}},
+	}
+	for _, test := range tests {
+		param, found := makeSpan(test.kv).GetSamplerParam()
+		assert.Equal(t, test.expected, param)
+		assert.Equal(t, test.found, found)
+	}
+}
+
 func TestIsSampled(t *testing.T) {
 	flags := model.Flags(0)
 	flags.SetSampled()
@@ -0,0 +1,189 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// dnsLookupTimeout is the max duration of a lookup
+const dnsLookupTimeout = 10 * time.Second
+
+// DNSResolver looks up DNS records, it is implemented by net.Resolver.
+type DNSResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// DNSDiscoverer discovers instances from DNS records refreshed on an interval, and notifies
+// registered observers when they change. A name starting with an underscore, like
+// _jaeger-collector._tcp.example.com, is resolved with SRV records that provide the ports of
+// the instances. Any other name must be a host:port whose host is resolved with A or AAAA records.
+type DNSDiscoverer struct {
+	Dispatcher
+	name     string
+	host     string
+	port     string
+	resolver DNSResolver
+	timeout  time.Duration
+	logger   *zap.Logger
+
+	lock      sync.Mutex
+	instances []string
+	err       error
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewDNSDiscoverer creates a DNSDiscoverer and performs the first lookup. Records are looked up
+// again every refreshInterval, or never if it is not positive, until the discoverer is closed.
+// The default resolver of the system is used if resolver is nil.
+func NewDNSDiscoverer(name string, refreshInterval time.Duration, resolver DNSResolver, logger *zap.Logger) (*DNSDiscoverer, error) {
+	d := &DNSDiscoverer{
+		name:     name,
+		resolver: resolver,
+		timeout:  refreshInterval,
+		logger:   logger,
+		stopCh:   make(chan struct{}),
+	}
+	if d.resolver == nil {
+		d.resolver = net.DefaultResolver
+	}
+	if d.timeout <= 0 || d.timeout > dnsLookupTimeout {
+		d.timeout = dnsLookupTimeout
+	}
+	if !d.isSRV() {
+		host, port, err := net.SplitHostPort(name)
+		if err != nil {
+			return nil, err
+		}
+		if host == "" {
+			return nil, errors.New("DNS discovery requires a host name")
+		}
+		d.host, d.port = host, port
+	}
+	d.refresh()
+	if refreshInterval > 0 {
+		d.wg.Add(1)
+		go d.refreshPeriodically(refreshInterval)
+	}
+	return d, nil
+}
+
+// Instances implements Discoverer. It returns the instances of the last successful lookup, and
+// the error of the last lookup if it failed before any lookup succeeded.
+func (d *DNSDiscoverer) Instances() ([]string, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.instances == nil && d.err != nil {
+		return nil, d.err
+	}
+	return d.instances, nil
+}
+
+func (d *DNSDiscoverer) isSRV() bool {
+	return strings.HasPrefix(d.name, "_")
+}
+
+func (d *DNSDiscoverer) lookup() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+	var instances []string
+	if d.isSRV() {
+		_, records, err := d.resolver.LookupSRV(ctx, "", "", d.name)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			target := strings.TrimSuffix(record.Target, ".")
+			instances = append(instances, net.JoinHostPort(target, strconv.Itoa(int(record.Port))))
+		}
+	} else {
+		addresses, err := d.resolver.LookupHost(ctx, d.host)
+		if err != nil {
+			return nil, err
+		}
+		for _, address := range addresses {
+			instances = append(instances, net.JoinHostPort(address, d.port))
+		}
+	}
+	sort.Strings(instances)
+	return instances, nil
+}
+
+// refresh looks up the records and notifies the observers if the instances changed
+func (d *DNSDiscoverer) refresh() {
+	instances, err := d.lookup()
+	d.lock.Lock()
+	d.err = err
+	changed := err == nil && !sameInstances(d.instances, instances)
+	if changed {
+		d.instances = instances
+	}
+	d.lock.Unlock()
+
+	if err != nil {
+		d.logger.Error("Failed to look up DNS records of collectors", zap.String("name", d.name), zap.Error(err))
+		return
+	}
+	if changed {
+		d.logger.Info("Discovered collectors from DNS", zap.String("name", d.name), zap.Strings("instances", instances))
+		d.Notify(instances)
+	}
+}
+
+func (d *DNSDiscoverer) refreshPeriodically(interval time.Duration) {
+	defer d.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.refresh()
+		case <-d.stopCh:
+			return
+		}
+	}
+}
+
+// Close stops refreshing the records
+func (d *DNSDiscoverer) Close() error {
+	close(d.stopCh)
+	d.wg.Wait()
+	return nil
+}
+
+// sameInstances compares two sorted lists of instances
+func sameInstances(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
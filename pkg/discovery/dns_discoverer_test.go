@@ -0,0 +1,126 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeDNSResolver struct {
+	mux   sync.Mutex
+	hosts map[string][]string
+	srvs  map[string][]*net.SRV
+	err   error
+}
+
+func (r *fakeDNSResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.hosts[host], r.err
+}
+
+func (r *fakeDNSResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return name, r.srvs[name], r.err
+}
+
+func (r *fakeDNSResolver) set(update func(r *fakeDNSResolver)) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	update(r)
+}
+
+func TestDNSDiscovererHostRecords(t *testing.T) {
+	resolver := &fakeDNSResolver{hosts: map[string][]string{
+		"collector.example.com": {"10.0.0.2", "10.0.0.1", "fd00::1"},
+	}}
+	d, err := NewDNSDiscoverer("collector.example.com:14250", 0, resolver, zap.NewNop())
+	require.NoError(t, err)
+	defer d.Close()
+
+	instances, err := d.Instances()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1:14250", "10.0.0.2:14250", "[fd00::1]:14250"}, instances)
+}
+
+func TestDNSDiscovererSRVRecords(t *testing.T) {
+	resolver := &fakeDNSResolver{srvs: map[string][]*net.SRV{
+		"_jaeger-collector._tcp.example.com": {
+			{Target: "collector-1.example.com.", Port: 14250},
+			{Target: "collector-0.example.com.", Port: 14251},
+		},
+	}}
+	d, err := NewDNSDiscoverer("_jaeger-collector._tcp.example.com", 0, resolver, zap.NewNop())
+	require.NoError(t, err)
+	defer d.Close()
+
+	instances, err := d.Instances()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"collector-0.example.com:14251", "collector-1.example.com:14250"}, instances)
+}
+
+func TestDNSDiscovererRefresh(t *testing.T) {
+	resolver := &fakeDNSResolver{hosts: map[string][]string{"collector": {"10.0.0.1"}}}
+	d, err := NewDNSDiscoverer("collector:14250", time.Millisecond, resolver, zap.NewNop())
+	require.NoError(t, err)
+	defer d.Close()
+	ch := make(chan []string, 10)
+	d.Register(ch)
+
+	// lookup failures keep the last known instances
+	resolver.set(func(r *fakeDNSResolver) { r.err = errors.New("no such host") })
+	time.Sleep(10 * time.Millisecond)
+	instances, err := d.Instances()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1:14250"}, instances)
+
+	resolver.set(func(r *fakeDNSResolver) {
+		r.err = nil
+		r.hosts["collector"] = []string{"10.0.0.1", "10.0.0.2"}
+	})
+	select {
+	case instances := <-ch:
+		assert.Equal(t, []string{"10.0.0.1:14250", "10.0.0.2:14250"}, instances)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification of the new instances")
+	}
+	instances, err = d.Instances()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1:14250", "10.0.0.2:14250"}, instances)
+}
+
+func TestDNSDiscovererErrors(t *testing.T) {
+	_, err := NewDNSDiscoverer("collector", 0, &fakeDNSResolver{}, zap.NewNop())
+	assert.Error(t, err)
+
+	_, err = NewDNSDiscoverer(":14250", 0, &fakeDNSResolver{}, zap.NewNop())
+	assert.EqualError(t, err, "DNS discovery requires a host name")
+
+	d, err := NewDNSDiscoverer("collector:14250", 0, &fakeDNSResolver{err: errors.New("no such host")}, zap.NewNop())
+	require.NoError(t, err)
+	defer d.Close()
+	_, err = d.Instances()
+	assert.EqualError(t, err, "no such host")
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+)
+
+// FileDiscoverer discovers instances from a JSON or YAML file with a list of host:port,
+// and notifies registered observers when the file changes.
+type FileDiscoverer struct {
+	Dispatcher
+	path    string
+	logger  *zap.Logger
+	watcher *fsnotify.Watcher
+
+	lock      sync.Mutex
+	instances []string
+
+	wg sync.WaitGroup
+}
+
+// NewFileDiscoverer creates a FileDiscoverer, reading the file and watching it for changes until
+// the discoverer is closed. The directory of the file is watched, so that the file can be replaced
+// atomically, for example when it is mounted from a Kubernetes ConfigMap.
+func NewFileDiscoverer(path string, logger *zap.Logger) (*FileDiscoverer, error) {
+	instances, err := readInstancesFile(path)
+	if err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a watcher for the discovery file: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch the directory of the discovery file: %w", err)
+	}
+	d := &FileDiscoverer{
+		path:      path,
+		logger:    logger,
+		watcher:   watcher,
+		instances: instances,
+	}
+	d.wg.Add(1)
+	go d.watch()
+	return d, nil
+}
+
+// Instances implements Discoverer
+func (d *FileDiscoverer) Instances() ([]string, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.instances, nil
+}
+
+func (d *FileDiscoverer) watch() {
+	defer d.wg.Done()
+	for {
+		select {
+		case event, ok := <-d.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			// the file may be a symbolic link whose target changes with any file of the directory
+			d.reload()
+		case err, ok := <-d.watcher.Errors:
+			if !ok {
+				return
+			}
+			d.logger.Error("Failed to watch the discovery file", zap.String("file", d.path), zap.Error(err))
+		}
+	}
+}
+
+// reload reads the file and notifies the observers if the instances changed.
+// The last known instances are kept if the file is missing or invalid.
+func (d *FileDiscoverer) reload() {
+	instances, err := readInstancesFile(d.path)
+	if err != nil {
+		d.logger.Warn("Failed to read the discovery file, using the last known collectors", zap.String("file", d.path), zap.Error(err))
+		return
+	}
+	d.lock.Lock()
+	changed := !sameInstances(d.instances, instances)
+	if changed {
+		d.instances = instances
+	}
+	d.lock.Unlock()
+	if changed {
+		d.logger.Info("Discovered collectors from file", zap.String("file", d.path), zap.Strings("instances", instances))
+		d.Notify(instances)
+	}
+}
+
+// Close stops watching the file
+func (d *FileDiscoverer) Close() error {
+	err := d.watcher.Close()
+	d.wg.Wait()
+	return err
+}
+
+// readInstancesFile reads a list of host:port in JSON or YAML format
+func readInstancesFile(path string) ([]string, error) {
+	bytes, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the discovery file: %w", err)
+	}
+	var instances []string
+	// YAML is a superset of JSON
+	if err := yaml.Unmarshal(bytes, &instances); err != nil {
+		return nil, fmt.Errorf("failed to parse the discovery file: %w", err)
+	}
+	sort.Strings(instances)
+	return instances, nil
+}
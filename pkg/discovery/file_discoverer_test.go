@@ -0,0 +1,90 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	// write and rename so that the watcher never reads a partial file
+	tmp := path + ".tmp"
+	require.NoError(t, ioutil.WriteFile(tmp, []byte(content), 0600))
+	require.NoError(t, os.Rename(tmp, path))
+}
+
+func waitForInstances(t *testing.T, ch chan []string, expected []string) {
+	for {
+		select {
+		case instances := <-ch:
+			if assert.ObjectsAreEqual(expected, instances) {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no notification of instances %v", expected)
+		}
+	}
+}
+
+func TestFileDiscoverer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file-discoverer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "collectors.json")
+	writeFile(t, path, `["collector-1:14250", "collector-0:14250"]`)
+
+	d, err := NewFileDiscoverer(path, zap.NewNop())
+	require.NoError(t, err)
+	defer d.Close()
+	ch := make(chan []string, 10)
+	d.Register(ch)
+
+	instances, err := d.Instances()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"collector-0:14250", "collector-1:14250"}, instances)
+
+	writeFile(t, path, "- collector-2:14250\n")
+	waitForInstances(t, ch, []string{"collector-2:14250"})
+
+	// invalid content keeps the last known instances
+	writeFile(t, path, "{not a list")
+	writeFile(t, path, `["collector-3:14250"]`)
+	waitForInstances(t, ch, []string{"collector-3:14250"})
+	instances, err = d.Instances()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"collector-3:14250"}, instances)
+}
+
+func TestFileDiscovererErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file-discoverer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = NewFileDiscoverer(filepath.Join(dir, "missing.json"), zap.NewNop())
+	assert.Error(t, err)
+
+	path := filepath.Join(dir, "invalid.json")
+	writeFile(t, path, `{"collectors": 1}`)
+	_, err = NewFileDiscoverer(path, zap.NewNop())
+	assert.Error(t, err)
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gogocodec
+
+import (
+	"reflect"
+	"strings"
+
+	gogoproto "github.com/gogo/protobuf/proto"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/proto" // the default codec must be registered first so that it is overridden
+)
+
+// all protobuf types generated in this module use gogo
+const jaegerPkgPath = "github.com/jaegertracing/jaeger/"
+
+func init() {
+	encoding.RegisterCodec(newCodec())
+}
+
+// gogoCodec forces the use of gogo proto marshalling for Jaeger types that are generated
+// with gogo extensions (e.g. customtype), which the default golang/protobuf codec cannot handle.
+// All other types, such as OTLP, are marshalled with golang/protobuf.
+type gogoCodec struct{}
+
+var _ encoding.Codec = (*gogoCodec)(nil)
+
+func newCodec() *gogoCodec {
+	return &gogoCodec{}
+}
+
+// Name implements encoding.Codec
+func (c *gogoCodec) Name() string {
+	return "proto"
+}
+
+// Marshal implements encoding.Codec
+func (c *gogoCodec) Marshal(v interface{}) ([]byte, error) {
+	if useGogo(reflect.TypeOf(v)) {
+		return gogoproto.Marshal(v.(gogoproto.Message))
+	}
+	return proto.Marshal(v.(proto.Message))
+}
+
+// Unmarshal implements encoding.Codec
+func (c *gogoCodec) Unmarshal(data []byte, v interface{}) error {
+	if useGogo(reflect.TypeOf(v)) {
+		return gogoproto.Unmarshal(data, v.(gogoproto.Message))
+	}
+	return proto.Unmarshal(data, v.(proto.Message))
+}
+
+func useGogo(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return strings.HasPrefix(t.PkgPath(), jaegerPkgPath)
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gogocodec
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/encoding"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func TestCodecRegistered(t *testing.T) {
+	assert.IsType(t, &gogoCodec{}, encoding.GetCodec("proto"))
+}
+
+func TestCodecMarshallAndUnmarshallGogo(t *testing.T) {
+	c := newCodec()
+	s1 := &model.Span{OperationName: "foo", TraceID: model.NewTraceID(1, 2)}
+	data, err := c.Marshal(s1)
+	require.NoError(t, err)
+
+	s2 := &model.Span{}
+	require.NoError(t, c.Unmarshal(data, s2))
+	assert.Equal(t, s1, s2)
+}
+
+func TestCodecMarshallAndUnmarshallGolang(t *testing.T) {
+	c := newCodec()
+	v1 := &wrappers.StringValue{Value: "foo"}
+	data, err := c.Marshal(v1)
+	require.NoError(t, err)
+
+	v2 := &wrappers.StringValue{}
+	require.NoError(t, c.Unmarshal(data, v2))
+	assert.Equal(t, "foo", v2.Value)
+}
+
+func TestUseGogo(t *testing.T) {
+	assert.False(t, useGogo(nil))
+	assert.True(t, useGogo(reflect.TypeOf(model.Span{})))
+	assert.True(t, useGogo(reflect.TypeOf(&model.Span{})))
+	assert.False(t, useGogo(reflect.TypeOf(wrappers.StringValue{})))
+}
@@ -0,0 +1,612 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package queue
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyncPolicy determines when the persistent queue flushes written items to stable storage.
+type SyncPolicy string
+
+const (
+	// SyncAlways flushes every item to disk before Produce returns
+	SyncAlways SyncPolicy = "always"
+	// SyncInterval flushes items to disk periodically, every PersistentQueueOptions.SyncInterval
+	SyncInterval SyncPolicy = "interval"
+	// SyncNever leaves flushing to the operating system
+	SyncNever SyncPolicy = "never"
+
+	segmentFileSuffix  = ".wal"
+	checkpointFileName = "checkpoint"
+
+	// each record is prefixed by the payload length and its CRC32 checksum
+	recordHeaderSize = 8
+	checkpointSize   = 16
+)
+
+var errCorruptRecord = errors.New("corrupt record in write-ahead log segment")
+
+// PersistentQueueOptions holds configuration for PersistentQueue.
+type PersistentQueueOptions struct {
+	// Directory is where the segment files and the checkpoint are stored
+	Directory string
+	// SegmentSize is the size in bytes after which a new segment file is started
+	SegmentSize int64
+	// MaxDiskSize is the maximum total size in bytes of all segment files.
+	// New items are rejected when it is reached.
+	MaxDiskSize int64
+	// SyncPolicy determines when written items are flushed to disk
+	SyncPolicy SyncPolicy
+	// SyncInterval is the flush period used by SyncInterval policy
+	SyncInterval time.Duration
+}
+
+// PersistentQueue is a producer-consumer queue backed by a write-ahead log on disk.
+// Items are appended to segment files and delivered to consumers in order. An item is
+// acknowledged once the consumer returns without error; fully acknowledged segments are
+// deleted. Items that were not acknowledged, because the consumer failed or the queue
+// stopped before they were consumed, are delivered again when the queue is reopened on
+// the same directory, so consumers must tolerate duplicates.
+type PersistentQueue struct {
+	options PersistentQueueOptions
+
+	lock     sync.Mutex
+	cond     *sync.Cond
+	segments []*segment // ordered by id, the last one is being written
+	head     *os.File
+	diskSize int64
+	size     int        // number of items not yet acknowledged
+	read     position   // position of the next item to deliver
+	acked    position   // all items before this position were acknowledged
+	pending  []*ackable // items delivered but not acknowledged, in log order
+	dirty    bool       // acked position changed since the checkpoint was written
+	stopped  bool
+
+	reader   *os.File // only used by the dispatcher goroutine
+	readerID uint64
+
+	items  chan *persistentItem
+	stopCh chan struct{}
+	stopWG sync.WaitGroup
+}
+
+type segment struct {
+	id     uint64
+	size   int64
+	sealed bool // no more items will be written to the segment
+}
+
+type position struct {
+	segment uint64
+	offset  int64
+}
+
+type ackable struct {
+	end  position
+	done bool
+}
+
+type persistentItem struct {
+	payload []byte
+	ack     *ackable
+}
+
+// NewPersistentQueue opens the write-ahead log in options.Directory, creating it if necessary.
+// Items left from a previous run that were not acknowledged are delivered again once
+// the consumers are started.
+func NewPersistentQueue(options PersistentQueueOptions) (*PersistentQueue, error) {
+	if options.Directory == "" {
+		return nil, errors.New("persistent queue directory must be set")
+	}
+	if options.SegmentSize <= 0 || options.MaxDiskSize <= 0 {
+		return nil, errors.New("persistent queue segment size and max disk size must be positive")
+	}
+	switch options.SyncPolicy {
+	case SyncAlways, SyncNever:
+	case SyncInterval:
+		if options.SyncInterval <= 0 {
+			return nil, errors.New("persistent queue sync interval must be positive")
+		}
+	default:
+		return nil, fmt.Errorf("unknown persistent queue sync policy: %q", options.SyncPolicy)
+	}
+	if err := os.MkdirAll(options.Directory, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create persistent queue directory: %w", err)
+	}
+
+	q := &PersistentQueue{
+		options: options,
+		items:   make(chan *persistentItem),
+		stopCh:  make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.lock)
+	if err := q.replay(); err != nil {
+		return nil, err
+	}
+	if options.SyncPolicy == SyncInterval {
+		q.stopWG.Add(1)
+		go q.syncPeriodically()
+	}
+	return q, nil
+}
+
+// replay loads the checkpoint and the existing segments, and starts a new segment for writing.
+func (q *PersistentQueue) replay() error {
+	checkpoint, err := q.readCheckpoint()
+	if err != nil {
+		return err
+	}
+	ids, err := q.listSegments()
+	if err != nil {
+		return err
+	}
+	var lastID uint64
+	for _, id := range ids {
+		lastID = id
+		if id < checkpoint.segment {
+			// all items of the segment were acknowledged before its removal was interrupted
+			if err := os.Remove(q.segmentPath(id)); err != nil {
+				return fmt.Errorf("failed to remove acknowledged segment: %w", err)
+			}
+			continue
+		}
+		from := int64(0)
+		if id == checkpoint.segment {
+			from = checkpoint.offset
+		}
+		seg, count, err := q.recoverSegment(id, from)
+		if err != nil {
+			return err
+		}
+		q.segments = append(q.segments, seg)
+		q.diskSize += seg.size
+		q.size += count
+	}
+	if lastID < checkpoint.segment {
+		lastID = checkpoint.segment
+	}
+
+	if err := q.openHead(lastID + 1); err != nil {
+		return err
+	}
+	first := q.segments[0]
+	q.read = position{segment: first.id}
+	if first.id == checkpoint.segment {
+		q.read.offset = checkpoint.offset
+		if q.read.offset > first.size {
+			q.read.offset = first.size
+		}
+	}
+	q.acked = q.read
+	q.removeAcknowledgedSegments()
+	return nil
+}
+
+// recoverSegment validates the records of an existing segment, truncating it at the first
+// corrupt or incomplete record. It returns the number of records at or after offset from.
+func (q *PersistentQueue) recoverSegment(id uint64, from int64) (*segment, int, error) {
+	path := q.segmentPath(id)
+	f, err := os.OpenFile(filepath.Clean(path), os.O_RDWR, 0)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open persistent queue segment: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to stat persistent queue segment: %w", err)
+	}
+	var offset int64
+	count := 0
+	for offset < info.Size() {
+		_, n, err := readRecord(f, offset, info.Size())
+		if err != nil {
+			if err := f.Truncate(offset); err != nil {
+				return nil, 0, fmt.Errorf("failed to truncate persistent queue segment: %w", err)
+			}
+			break
+		}
+		if offset >= from {
+			count++
+		}
+		offset += n
+	}
+	return &segment{id: id, size: offset, sealed: true}, count, nil
+}
+
+// Produce appends the item to the write-ahead log. Returns false if the item could not be
+// written, because the queue is stopped, the max disk size is reached or of an I/O error.
+func (q *PersistentQueue) Produce(item []byte) bool {
+	record := make([]byte, recordHeaderSize+len(item))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(item)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(item))
+	copy(record[recordHeaderSize:], item)
+	recordSize := int64(len(record))
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.stopped {
+		return false
+	}
+	if q.diskSize+recordSize > q.options.MaxDiskSize {
+		return false
+	}
+	head := q.segments[len(q.segments)-1]
+	if head.size > 0 && head.size+recordSize > q.options.SegmentSize {
+		if err := q.rollHead(); err != nil {
+			return false
+		}
+		head = q.segments[len(q.segments)-1]
+	}
+	if _, err := q.head.Write(record); err != nil {
+		// discard a partially written record so that the segment stays readable
+		_ = q.head.Truncate(head.size)
+		return false
+	}
+	if q.options.SyncPolicy == SyncAlways {
+		if err := q.head.Sync(); err != nil {
+			return false
+		}
+	}
+	head.size += recordSize
+	q.diskSize += recordSize
+	q.size++
+	q.cond.Signal()
+	return true
+}
+
+// StartConsumers starts a given number of goroutines consuming items from the queue and
+// passing them into the consumer callback. An item is acknowledged when the consumer
+// returns nil, otherwise it stays in the log until the queue is reopened.
+func (q *PersistentQueue) StartConsumers(num int, consumer func(item []byte) error) {
+	q.stopWG.Add(num + 1)
+	go q.dispatch()
+	for i := 0; i < num; i++ {
+		go func() {
+			defer q.stopWG.Done()
+			for {
+				select {
+				case item := <-q.items:
+					if err := consumer(item.payload); err == nil {
+						q.ack(item.ack)
+					}
+				case <-q.stopCh:
+					return
+				}
+			}
+		}()
+	}
+}
+
+// dispatch reads items from the log in order and hands them over to the consumers.
+func (q *PersistentQueue) dispatch() {
+	defer q.stopWG.Done()
+	for {
+		item, ok := q.next()
+		if !ok {
+			return
+		}
+		select {
+		case q.items <- item:
+		case <-q.stopCh:
+			return
+		}
+	}
+}
+
+// next blocks until there is an item to deliver or the queue is stopped.
+func (q *PersistentQueue) next() (*persistentItem, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for {
+		if q.stopped {
+			return nil, false
+		}
+		seg := q.segment(q.read.segment)
+		if q.read.offset >= seg.size {
+			if seg.sealed {
+				q.read = position{segment: seg.id + 1}
+			} else {
+				q.cond.Wait()
+			}
+			continue
+		}
+		pos := q.read
+		payload, n, err := q.readAt(pos, seg.size)
+		if err != nil {
+			// skip the rest of an unreadable segment rather than blocking the queue
+			n = seg.size - pos.offset
+		}
+		a := &ackable{end: position{segment: pos.segment, offset: pos.offset + n}}
+		q.read = a.end
+		q.pending = append(q.pending, a)
+		if err != nil {
+			q.ackLocked(a)
+			continue
+		}
+		return &persistentItem{payload: payload, ack: a}, true
+	}
+}
+
+func (q *PersistentQueue) readAt(pos position, limit int64) ([]byte, int64, error) {
+	if q.reader == nil || q.readerID != pos.segment {
+		if q.reader != nil {
+			q.reader.Close()
+		}
+		f, err := os.Open(filepath.Clean(q.segmentPath(pos.segment)))
+		if err != nil {
+			q.reader = nil
+			return nil, 0, err
+		}
+		q.reader, q.readerID = f, pos.segment
+	}
+	return readRecord(q.reader, pos.offset, limit)
+}
+
+func readRecord(f *os.File, offset int64, limit int64) ([]byte, int64, error) {
+	if offset+recordHeaderSize > limit {
+		return nil, 0, errCorruptRecord
+	}
+	header := make([]byte, recordHeaderSize)
+	if _, err := f.ReadAt(header, offset); err != nil {
+		return nil, 0, err
+	}
+	length := int64(binary.BigEndian.Uint32(header[0:4]))
+	if offset+recordHeaderSize+length > limit {
+		return nil, 0, errCorruptRecord
+	}
+	payload := make([]byte, length)
+	if _, err := f.ReadAt(payload, offset+recordHeaderSize); err != nil {
+		return nil, 0, err
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, 0, errCorruptRecord
+	}
+	return payload, recordHeaderSize + length, nil
+}
+
+func (q *PersistentQueue) ack(a *ackable) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.size--
+	q.ackLocked(a)
+}
+
+// ackLocked marks the item done and advances the acknowledged position past
+// all the leading items that are done.
+func (q *PersistentQueue) ackLocked(a *ackable) {
+	a.done = true
+	advanced := false
+	for len(q.pending) > 0 && q.pending[0].done {
+		q.acked = q.pending[0].end
+		q.pending[0] = nil
+		q.pending = q.pending[1:]
+		advanced = true
+	}
+	if !advanced {
+		return
+	}
+	q.dirty = true
+	if q.options.SyncPolicy == SyncAlways {
+		_ = q.writeCheckpoint()
+	}
+	q.removeAcknowledgedSegments()
+}
+
+// removeAcknowledgedSegments deletes the sealed segments whose items were all acknowledged.
+func (q *PersistentQueue) removeAcknowledgedSegments() {
+	removed := false
+	for len(q.segments) > 1 {
+		s := q.segments[0]
+		if s.id > q.acked.segment || (s.id == q.acked.segment && q.acked.offset < s.size) {
+			break
+		}
+		if !removed && q.dirty {
+			// make sure the checkpoint does not point at a segment that no longer exists
+			if err := q.writeCheckpoint(); err != nil {
+				return
+			}
+		}
+		if err := os.Remove(q.segmentPath(s.id)); err != nil && !os.IsNotExist(err) {
+			return
+		}
+		removed = true
+		q.diskSize -= s.size
+		q.segments[0] = nil
+		q.segments = q.segments[1:]
+	}
+}
+
+func (q *PersistentQueue) segment(id uint64) *segment {
+	i := sort.Search(len(q.segments), func(i int) bool { return q.segments[i].id >= id })
+	if i < len(q.segments) && q.segments[i].id == id {
+		return q.segments[i]
+	}
+	// the segment was removed, continue from the start of the oldest remaining one
+	q.read = position{segment: q.segments[0].id}
+	return q.segments[0]
+}
+
+func (q *PersistentQueue) openHead(id uint64) error {
+	f, err := os.OpenFile(filepath.Clean(q.segmentPath(id)), os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to create persistent queue segment: %w", err)
+	}
+	q.head = f
+	q.segments = append(q.segments, &segment{id: id})
+	return nil
+}
+
+func (q *PersistentQueue) rollHead() error {
+	if q.options.SyncPolicy != SyncNever {
+		if err := q.head.Sync(); err != nil {
+			return err
+		}
+	}
+	if err := q.head.Close(); err != nil {
+		return err
+	}
+	head := q.segments[len(q.segments)-1]
+	if err := q.openHead(head.id + 1); err != nil {
+		return err
+	}
+	head.sealed = true
+	q.cond.Signal()
+	q.removeAcknowledgedSegments()
+	return nil
+}
+
+func (q *PersistentQueue) syncPeriodically() {
+	defer q.stopWG.Done()
+	ticker := time.NewTicker(q.options.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			q.lock.Lock()
+			_ = q.head.Sync()
+			if q.dirty {
+				_ = q.writeCheckpoint()
+			}
+			q.lock.Unlock()
+		case <-q.stopCh:
+			return
+		}
+	}
+}
+
+func (q *PersistentQueue) readCheckpoint() (position, error) {
+	data, err := ioutil.ReadFile(filepath.Join(q.options.Directory, checkpointFileName))
+	if os.IsNotExist(err) {
+		return position{}, nil
+	}
+	if err != nil {
+		return position{}, fmt.Errorf("failed to read persistent queue checkpoint: %w", err)
+	}
+	if len(data) != checkpointSize {
+		return position{}, errors.New("invalid persistent queue checkpoint")
+	}
+	return position{
+		segment: binary.BigEndian.Uint64(data[0:8]),
+		offset:  int64(binary.BigEndian.Uint64(data[8:16])),
+	}, nil
+}
+
+// writeCheckpoint atomically replaces the checkpoint file with the acknowledged position.
+func (q *PersistentQueue) writeCheckpoint() error {
+	data := make([]byte, checkpointSize)
+	binary.BigEndian.PutUint64(data[0:8], q.acked.segment)
+	binary.BigEndian.PutUint64(data[8:16], uint64(q.acked.offset))
+	path := filepath.Join(q.options.Directory, checkpointFileName)
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(filepath.Clean(tmp), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0640)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if q.options.SyncPolicy != SyncNever {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	q.dirty = false
+	return nil
+}
+
+func (q *PersistentQueue) listSegments() ([]uint64, error) {
+	files, err := ioutil.ReadDir(q.options.Directory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent queue segments: %w", err)
+	}
+	var ids []uint64
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, segmentFileSuffix) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentFileSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (q *PersistentQueue) segmentPath(id uint64) string {
+	return filepath.Join(q.options.Directory, fmt.Sprintf("%020d%s", id, segmentFileSuffix))
+}
+
+// Stop stops all consumers and flushes the log and the checkpoint to disk.
+// Items not yet acknowledged are delivered again when the queue is reopened.
+func (q *PersistentQueue) Stop() {
+	q.lock.Lock()
+	if q.stopped {
+		q.lock.Unlock()
+		return
+	}
+	q.stopped = true
+	q.cond.Broadcast()
+	q.lock.Unlock()
+
+	close(q.stopCh)
+	q.stopWG.Wait()
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.options.SyncPolicy != SyncNever {
+		_ = q.head.Sync()
+	}
+	q.head.Close()
+	if q.reader != nil {
+		q.reader.Close()
+	}
+	_ = q.writeCheckpoint()
+}
+
+// Size returns the number of items in the queue that were not yet acknowledged
+func (q *PersistentQueue) Size() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.size
+}
+
+// DiskSize returns the total size in bytes of the segment files
+func (q *PersistentQueue) DiskSize() int64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.diskSize
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "persistent-queue")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func testQueueOptions(dir string) PersistentQueueOptions {
+	return PersistentQueueOptions{
+		Directory:   dir,
+		SegmentSize: 1024,
+		MaxDiskSize: 1024 * 1024,
+		SyncPolicy:  SyncAlways,
+	}
+}
+
+type collectingConsumer struct {
+	mu    sync.Mutex
+	items []string
+	fail  func(item string) bool
+}
+
+func (c *collectingConsumer) consume(item []byte) error {
+	if c.fail != nil && c.fail(string(item)) {
+		return errors.New("consumer failed")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = append(c.items, string(item))
+	return nil
+}
+
+func (c *collectingConsumer) consumed() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.items...)
+}
+
+func waitForSize(t *testing.T, q *PersistentQueue, size int) {
+	for i := 0; i < 1000 && q.Size() != size; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	require.Equal(t, size, q.Size())
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentFileSuffix))
+	require.NoError(t, err)
+	return files
+}
+
+func TestPersistentQueueProduceConsume(t *testing.T) {
+	dir := tempDir(t)
+	q, err := NewPersistentQueue(testQueueOptions(dir))
+	require.NoError(t, err)
+
+	var expected []string
+	for i := 0; i < 100; i++ {
+		item := fmt.Sprintf("item-%03d", i)
+		expected = append(expected, item)
+		require.True(t, q.Produce([]byte(item)))
+	}
+	assert.Equal(t, 100, q.Size())
+	assert.True(t, len(segmentFiles(t, dir)) > 1, "expecting the log to be split into segments")
+
+	consumer := &collectingConsumer{}
+	q.StartConsumers(1, consumer.consume)
+	waitForSize(t, q, 0)
+	q.Stop()
+
+	assert.Equal(t, expected, consumer.consumed())
+	assert.Len(t, segmentFiles(t, dir), 1, "acknowledged segments must be removed")
+	assert.False(t, q.Produce([]byte("after stop")))
+}
+
+func TestPersistentQueueReplay(t *testing.T) {
+	for _, policy := range []SyncPolicy{SyncAlways, SyncInterval, SyncNever} {
+		t.Run(string(policy), func(t *testing.T) {
+			dir := tempDir(t)
+			options := testQueueOptions(dir)
+			options.SyncPolicy = policy
+			options.SyncInterval = time.Millisecond
+			q, err := NewPersistentQueue(options)
+			require.NoError(t, err)
+			for i := 0; i < 50; i++ {
+				require.True(t, q.Produce([]byte(fmt.Sprintf("item-%02d", i))))
+			}
+			// the consumer acknowledges the first 20 items only
+			consumer := &collectingConsumer{fail: func(item string) bool { return item >= "item-20" }}
+			q.StartConsumers(1, consumer.consume)
+			waitForSize(t, q, 30)
+			q.Stop()
+			assert.Len(t, consumer.consumed(), 20)
+
+			q, err = NewPersistentQueue(options)
+			require.NoError(t, err)
+			assert.Equal(t, 30, q.Size())
+			consumer = &collectingConsumer{}
+			q.StartConsumers(2, consumer.consume)
+			waitForSize(t, q, 0)
+			q.Stop()
+			assert.ElementsMatch(t, []string{
+				"item-20", "item-21", "item-22", "item-23", "item-24", "item-25", "item-26", "item-27", "item-28", "item-29",
+				"item-30", "item-31", "item-32", "item-33", "item-34", "item-35", "item-36", "item-37", "item-38", "item-39",
+				"item-40", "item-41", "item-42", "item-43", "item-44", "item-45", "item-46", "item-47", "item-48", "item-49",
+			}, consumer.consumed())
+
+			q, err = NewPersistentQueue(options)
+			require.NoError(t, err)
+			assert.Equal(t, 0, q.Size())
+			q.Stop()
+		})
+	}
+}
+
+func TestPersistentQueueMaxDiskSize(t *testing.T) {
+	options := testQueueOptions(tempDir(t))
+	options.MaxDiskSize = 2 * (recordHeaderSize + 4)
+	q, err := NewPersistentQueue(options)
+	require.NoError(t, err)
+	defer q.Stop()
+
+	assert.True(t, q.Produce([]byte("aaaa")))
+	assert.True(t, q.Produce([]byte("bbbb")))
+	assert.False(t, q.Produce([]byte("cccc")))
+	assert.EqualValues(t, options.MaxDiskSize, q.DiskSize())
+
+	consumer := &collectingConsumer{}
+	q.StartConsumers(1, consumer.consume)
+	waitForSize(t, q, 0)
+	assert.Equal(t, []string{"aaaa", "bbbb"}, consumer.consumed())
+}
+
+func TestPersistentQueueTruncatesCorruptTail(t *testing.T) {
+	dir := tempDir(t)
+	q, err := NewPersistentQueue(testQueueOptions(dir))
+	require.NoError(t, err)
+	require.True(t, q.Produce([]byte("complete")))
+	q.Stop()
+
+	files := segmentFiles(t, dir)
+	require.Len(t, files, 1)
+	f, err := os.OpenFile(files[0], os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0, 0, 0, 100, 1, 2}) // header of a record that was never written
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	q, err = NewPersistentQueue(testQueueOptions(dir))
+	require.NoError(t, err)
+	assert.Equal(t, 1, q.Size())
+	consumer := &collectingConsumer{}
+	q.StartConsumers(1, consumer.consume)
+	waitForSize(t, q, 0)
+	q.Stop()
+	assert.Equal(t, []string{"complete"}, consumer.consumed())
+}
+
+func TestPersistentQueueInvalidOptions(t *testing.T) {
+	dir := tempDir(t)
+	file := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(file, nil, 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, checkpointFileName), []byte("bad"), 0600))
+
+	tests := []struct {
+		name    string
+		modify  func(o *PersistentQueueOptions)
+		wantErr string
+	}{
+		{"no directory", func(o *PersistentQueueOptions) { o.Directory = "" }, "directory must be set"},
+		{"no segment size", func(o *PersistentQueueOptions) { o.SegmentSize = 0 }, "must be positive"},
+		{"no max disk size", func(o *PersistentQueueOptions) { o.MaxDiskSize = 0 }, "must be positive"},
+		{"unknown sync policy", func(o *PersistentQueueOptions) { o.SyncPolicy = "sometimes" }, "unknown persistent queue sync policy"},
+		{"no sync interval", func(o *PersistentQueueOptions) { o.SyncPolicy = SyncInterval }, "sync interval must be positive"},
+		{"directory is a file", func(o *PersistentQueueOptions) { o.Directory = file }, "failed to create persistent queue directory"},
+		{"bad checkpoint", func(o *PersistentQueueOptions) { o.Directory = dir }, "invalid persistent queue checkpoint"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := testQueueOptions(tempDir(t))
+			test.modify(&options)
+			_, err := NewPersistentQueue(options)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.wantErr)
+		})
+	}
+}
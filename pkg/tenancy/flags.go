@@ -0,0 +1,70 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"errors"
+	"flag"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	tenancyEnabled = "multi-tenancy.enabled"
+	tenancyHeader  = "multi-tenancy.header"
+	validTenants   = "multi-tenancy.tenants"
+
+	// DefaultHeader is the HTTP header, or gRPC metadata key, carrying the tenant
+	DefaultHeader = "x-tenant"
+)
+
+// Options describes the configuration properties for multitenancy
+type Options struct {
+	// Enabled requires every request to carry a tenant and keeps the data of tenants apart
+	Enabled bool
+	// Header is the HTTP header, or gRPC metadata key, carrying the tenant
+	Header string
+	// Tenants is the list of known tenants, required when multi-tenancy is enabled
+	Tenants []string
+}
+
+// AddFlags adds flags for tenancy to the FlagSet.
+func AddFlags(flagSet *flag.FlagSet) {
+	flagSet.Bool(tenancyEnabled, false, "Enable tenancy header when receiving or querying")
+	flagSet.String(tenancyHeader, DefaultHeader, "HTTP header, or gRPC metadata key, carrying the tenant")
+	flagSet.String(validTenants, "", "comma-separated list of allowed values for the tenancy header, required when multi-tenancy is enabled")
+}
+
+// InitFromViper creates tenancy.Options populated with values retrieved from Viper.
+func (opts *Options) InitFromViper(v *viper.Viper) *Options {
+	opts.Enabled = v.GetBool(tenancyEnabled)
+	opts.Header = v.GetString(tenancyHeader)
+	opts.Tenants = nil
+	if tenants := strings.ReplaceAll(v.GetString(validTenants), " ", ""); tenants != "" {
+		opts.Tenants = strings.Split(tenants, ",")
+	}
+	return opts
+}
+
+// Validate returns an error if multi-tenancy is enabled without a list of tenants.
+// The storage of every tenant is kept apart, so accepting any tenant would let
+// clients create storage readers and writers without bound.
+func (opts *Options) Validate() error {
+	if opts.Enabled && len(opts.Tenants) == 0 {
+		return errors.New("multi-tenancy requires a list of tenants, see --" + validTenants)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jaegertracing/jaeger/pkg/config"
+)
+
+func TestOptionsWithFlags(t *testing.T) {
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--multi-tenancy.enabled=true",
+		"--multi-tenancy.header=X-Team",
+		"--multi-tenancy.tenants=acme, megacorp",
+	})
+	opts := new(Options).InitFromViper(v)
+	assert.True(t, opts.Enabled)
+	assert.Equal(t, "X-Team", opts.Header)
+	assert.Equal(t, []string{"acme", "megacorp"}, opts.Tenants)
+}
+
+func TestOptionsDefaults(t *testing.T) {
+	v, _ := config.Viperize(AddFlags)
+	opts := new(Options).InitFromViper(v)
+	assert.False(t, opts.Enabled)
+	assert.Equal(t, DefaultHeader, opts.Header)
+	assert.Nil(t, opts.Tenants)
+}
+
+func TestOptionsValidate(t *testing.T) {
+	assert.NoError(t, (&Options{}).Validate())
+	assert.NoError(t, (&Options{Enabled: true, Tenants: []string{"acme"}}).Validate())
+	assert.EqualError(t, (&Options{Enabled: true}).Validate(),
+		"multi-tenancy requires a list of tenants, see --multi-tenancy.tenants")
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tenantedServerStream is a wrapper for ServerStream providing settable context
+type tenantedServerStream struct {
+	grpc.ServerStream
+	context context.Context
+}
+
+func (tss *tenantedServerStream) Context() context.Context {
+	return tss.context
+}
+
+// GetValidTenant returns the tenant carried by the incoming gRPC metadata of the context.
+// It returns a gRPC status error if the tenant is missing or unknown.
+func GetValidTenant(ctx context.Context, tm *Manager) (string, error) {
+	// Prefer a tenant already associated with the context, e.g. by an interceptor
+	if tenant := GetTenant(ctx); tenant != "" {
+		if !tm.Valid(tenant) {
+			return "", status.Errorf(codes.PermissionDenied, "unknown tenant")
+		}
+		return tenant, nil
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", status.Errorf(codes.Unauthenticated, "missing tenant header")
+	}
+	tenants := md.Get(tm.Header)
+	switch {
+	case len(tenants) < 1:
+		return "", status.Errorf(codes.Unauthenticated, "missing tenant header")
+	case len(tenants) > 1:
+		return "", status.Errorf(codes.PermissionDenied, "extra tenant header")
+	case !tm.Valid(tenants[0]):
+		return "", status.Errorf(codes.PermissionDenied, "unknown tenant")
+	}
+	return tenants[0], nil
+}
+
+// NewGuardingUnaryInterceptor rejects unary calls without a valid tenant,
+// and adds the tenant to the context of the other calls.
+func NewGuardingUnaryInterceptor(tm *Manager) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		tenant, err := GetValidTenant(ctx, tm)
+		if err != nil {
+			return nil, err
+		}
+		return handler(WithTenant(ctx, tenant), req)
+	}
+}
+
+// NewGuardingStreamInterceptor rejects streams without a valid tenant,
+// and adds the tenant to the context of the other streams.
+func NewGuardingStreamInterceptor(tm *Manager) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		tenant, err := GetValidTenant(ss.Context(), tm)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &tenantedServerStream{
+			ServerStream: ss,
+			context:      WithTenant(ss.Context(), tenant),
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type mockServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *mockServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestGetValidTenant(t *testing.T) {
+	tm := NewManager(&Options{Enabled: true, Tenants: []string{"acme"}})
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		tenant string
+		code   codes.Code
+	}{
+		{
+			name: "no metadata",
+			ctx:  context.Background(),
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "missing tenant",
+			ctx:  metadata.NewIncomingContext(context.Background(), metadata.Pairs("other", "acme")),
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "extra tenant",
+			ctx:  metadata.NewIncomingContext(context.Background(), metadata.Pairs(DefaultHeader, "acme", DefaultHeader, "acme")),
+			code: codes.PermissionDenied,
+		},
+		{
+			name: "unknown tenant",
+			ctx:  metadata.NewIncomingContext(context.Background(), metadata.Pairs(DefaultHeader, "megacorp")),
+			code: codes.PermissionDenied,
+		},
+		{
+			name:   "valid tenant",
+			ctx:    metadata.NewIncomingContext(context.Background(), metadata.Pairs(DefaultHeader, "acme")),
+			tenant: "acme",
+		},
+		{
+			name:   "tenant in context",
+			ctx:    WithTenant(context.Background(), "acme"),
+			tenant: "acme",
+		},
+		{
+			name: "unknown tenant in context",
+			ctx:  WithTenant(context.Background(), "megacorp"),
+			code: codes.PermissionDenied,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tenant, err := GetValidTenant(test.ctx, tm)
+			assert.Equal(t, test.code, status.Code(err))
+			assert.Equal(t, test.tenant, tenant)
+		})
+	}
+}
+
+func TestGuardingUnaryInterceptor(t *testing.T) {
+	interceptor := NewGuardingUnaryInterceptor(NewManager(&Options{Enabled: true}))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return GetTenant(ctx), nil
+	}
+
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(DefaultHeader, "acme"))
+	tenant, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", tenant)
+}
+
+func TestGuardingStreamInterceptor(t *testing.T) {
+	interceptor := NewGuardingStreamInterceptor(NewManager(&Options{Enabled: true}))
+	var tenant string
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		tenant = GetTenant(stream.Context())
+		return nil
+	}
+
+	err := interceptor(nil, &mockServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(DefaultHeader, "acme"))
+	err = interceptor(nil, &mockServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", tenant)
+}
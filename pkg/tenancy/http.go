@@ -0,0 +1,40 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"net/http"
+)
+
+// ExtractTenantHTTPHandler returns an http.Handler that adds the tenant of the request to its context.
+// Requests without a tenant are rejected with 401 and requests with an unknown tenant with 403.
+// The handler is returned as is when tenancy is disabled.
+func ExtractTenantHTTPHandler(tm *Manager, h http.Handler) http.Handler {
+	if !tm.Enabled {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant := r.Header.Get(tm.Header)
+		if tenant == "" {
+			http.Error(w, "missing tenant header", http.StatusUnauthorized)
+			return
+		}
+		if !tm.Valid(tenant) {
+			http.Error(w, "unknown tenant", http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), tenant)))
+	})
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractTenantHTTPHandler(t *testing.T) {
+	var tenant string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant = GetTenant(r.Context())
+	})
+	tests := []struct {
+		name    string
+		options Options
+		header  string
+		status  int
+		tenant  string
+	}{
+		{name: "disabled", options: Options{}, status: http.StatusOK},
+		{name: "missing tenant", options: Options{Enabled: true}, status: http.StatusUnauthorized},
+		{name: "unknown tenant", options: Options{Enabled: true, Tenants: []string{"acme"}}, header: "megacorp", status: http.StatusForbidden},
+		{name: "valid tenant", options: Options{Enabled: true, Tenants: []string{"acme"}}, header: "acme", status: http.StatusOK, tenant: "acme"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tenant = ""
+			handler := ExtractTenantHTTPHandler(NewManager(&test.options), next)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.header != "" {
+				req.Header.Set("X-Tenant", test.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, test.status, rec.Code)
+			assert.Equal(t, test.tenant, tenant)
+		})
+	}
+}
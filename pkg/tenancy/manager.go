@@ -0,0 +1,78 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"context"
+	"strings"
+)
+
+type contextKey string
+
+const tenantKey = contextKey("tenant")
+
+// Manager can check tenant usage for multi-tenant Jaeger configurations
+type Manager struct {
+	Enabled bool
+	Header  string
+	tenants map[string]struct{}
+}
+
+// NewManager creates a tenancy.Manager for given tenancy.Options.
+func NewManager(options *Options) *Manager {
+	header := options.Header
+	if header == "" {
+		header = DefaultHeader
+	}
+	var tenants map[string]struct{}
+	if len(options.Tenants) > 0 {
+		tenants = make(map[string]struct{}, len(options.Tenants))
+		for _, tenant := range options.Tenants {
+			tenants[tenant] = struct{}{}
+		}
+	}
+	return &Manager{
+		Enabled: options.Enabled,
+		// gRPC metadata keys are lowercase, while HTTP headers are case-insensitive
+		Header:  strings.ToLower(header),
+		tenants: tenants,
+	}
+}
+
+// Valid returns true if the tenant is not empty and, when a list of tenants is configured, one of them.
+func (tc *Manager) Valid(tenant string) bool {
+	if tenant == "" {
+		return false
+	}
+	if tc.tenants == nil {
+		return true
+	}
+	_, ok := tc.tenants[tenant]
+	return ok
+}
+
+// WithTenant creates a Context with a tenant association
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	if tenant == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// GetTenant retrieves a tenant associated with a Context, or an empty string if there is none
+func GetTenant(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey).(string)
+	return tenant
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManagerValid(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		tenant  string
+		valid   bool
+	}{
+		{name: "any tenant", options: Options{Enabled: true}, tenant: "acme", valid: true},
+		{name: "empty tenant", options: Options{Enabled: true}, tenant: "", valid: false},
+		{name: "known tenant", options: Options{Enabled: true, Tenants: []string{"acme"}}, tenant: "acme", valid: true},
+		{name: "unknown tenant", options: Options{Enabled: true, Tenants: []string{"acme"}}, tenant: "megacorp", valid: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tm := NewManager(&test.options)
+			assert.Equal(t, test.valid, tm.Valid(test.tenant))
+		})
+	}
+}
+
+func TestManagerHeader(t *testing.T) {
+	assert.Equal(t, DefaultHeader, NewManager(&Options{}).Header)
+	assert.Equal(t, "x-team", NewManager(&Options{Header: "X-Team"}).Header)
+}
+
+func TestContextTenant(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "", GetTenant(ctx))
+	assert.Equal(t, ctx, WithTenant(ctx, ""))
+	assert.Equal(t, "acme", GetTenant(WithTenant(ctx, "acme")))
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adaptive
+
+import (
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-client-go"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/model"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+)
+
+const (
+	// maxProbabilities bounds the number of distinct sampling probabilities remembered per operation.
+	maxProbabilities = 10
+)
+
+// aggregator accumulates the throughput of root spans seen by this collector and periodically
+// flushes it to the sampling store, where the processor picks it up to calculate probabilities.
+type aggregator struct {
+	sync.Mutex
+
+	operationsCounter   metrics.Counter
+	servicesCounter     metrics.Counter
+	currentThroughput   serviceOperationThroughput
+	aggregationInterval time.Duration
+	storage             samplingstore.Store
+	logger              *zap.Logger
+	stop                chan struct{}
+	bgFinished          sync.WaitGroup
+}
+
+// NewAggregator creates a throughput aggregator that flushes aggregated throughput
+// to storage once every aggregationInterval.
+func NewAggregator(
+	metricsFactory metrics.Factory,
+	interval time.Duration,
+	storage samplingstore.Store,
+	logger *zap.Logger,
+) strategystore.Aggregator {
+	metricsFactory = metricsFactory.Namespace(metrics.NSOptions{Name: "adaptive_sampling_aggregator"})
+	return &aggregator{
+		operationsCounter:   metricsFactory.Counter(metrics.Options{Name: "sampling_operations"}),
+		servicesCounter:     metricsFactory.Counter(metrics.Options{Name: "sampling_services"}),
+		currentThroughput:   make(serviceOperationThroughput),
+		aggregationInterval: interval,
+		storage:             storage,
+		logger:              logger,
+		stop:                make(chan struct{}),
+	}
+}
+
+func (a *aggregator) runAggregationLoop() {
+	defer a.bgFinished.Done()
+	ticker := time.NewTicker(a.aggregationInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.saveThroughput()
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+func (a *aggregator) saveThroughput() {
+	a.Lock()
+	current := a.currentThroughput
+	a.currentThroughput = make(serviceOperationThroughput)
+	a.Unlock()
+
+	totalOperations := 0
+	var throughput []*model.Throughput
+	for _, opThroughput := range current {
+		totalOperations += len(opThroughput)
+		for _, t := range opThroughput {
+			throughput = append(throughput, t)
+		}
+	}
+	a.operationsCounter.Inc(int64(totalOperations))
+	a.servicesCounter.Inc(int64(len(current)))
+	if len(throughput) == 0 {
+		return
+	}
+	if err := a.storage.InsertThroughput(throughput); err != nil {
+		a.logger.Error("failed to save throughput", zap.Error(err))
+	}
+}
+
+// RecordThroughput implements strategystore.Aggregator#RecordThroughput.
+func (a *aggregator) RecordThroughput(service, operation, samplerType string, probability float64) {
+	a.Lock()
+	defer a.Unlock()
+	if _, ok := a.currentThroughput[service]; !ok {
+		a.currentThroughput[service] = make(map[string]*model.Throughput)
+	}
+	throughput, ok := a.currentThroughput[service][operation]
+	if !ok {
+		throughput = &model.Throughput{
+			Service:       service,
+			Operation:     operation,
+			Probabilities: make(map[string]struct{}),
+		}
+		a.currentThroughput[service][operation] = throughput
+	}
+	if len(throughput.Probabilities) < maxProbabilities {
+		throughput.Probabilities[TruncateFloat(probability)] = struct{}{}
+	}
+	// Only probabilistically sampled root spans increment the throughput counter. For lowerbound
+	// sampled spans the count stays at 0, but the throughput is still saved so that the processor
+	// is made aware of the operation.
+	if samplerType == jaeger.SamplerTypeProbabilistic {
+		throughput.Count++
+	}
+}
+
+// Start implements strategystore.Aggregator#Start.
+func (a *aggregator) Start() {
+	a.bgFinished.Add(1)
+	go a.runAggregationLoop()
+}
+
+// Close implements strategystore.Aggregator#Close.
+func (a *aggregator) Close() error {
+	close(a.stop)
+	a.bgFinished.Wait()
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adaptive
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/model"
+	smocks "github.com/jaegertracing/jaeger/storage/samplingstore/mocks"
+)
+
+func TestAggregator(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+
+	mockStorage := &smocks.Store{}
+	saved := make(chan []*model.Throughput, 1)
+	mockStorage.On("InsertThroughput", mock.AnythingOfType("[]*model.Throughput")).
+		Run(func(args mock.Arguments) {
+			saved <- args.Get(0).([]*model.Throughput)
+		}).
+		Return(nil)
+
+	a := NewAggregator(metricsFactory, 5*time.Millisecond, mockStorage, zap.NewNop())
+	a.RecordThroughput("A", "GET", "probabilistic", 0.001)
+	a.RecordThroughput("B", "POST", "probabilistic", 0.001)
+	a.RecordThroughput("C", "GET", "probabilistic", 0.001)
+	a.RecordThroughput("A", "POST", "probabilistic", 0.001)
+	a.RecordThroughput("A", "GET", "probabilistic", 0.001)
+	a.RecordThroughput("A", "GET", "lowerbound", 0.001)
+
+	a.Start()
+	defer a.Close()
+	select {
+	case throughput := <-saved:
+		assert.Len(t, throughput, 4)
+		for _, tp := range throughput {
+			if tp.Service == "A" && tp.Operation == "GET" {
+				assert.EqualValues(t, 2, tp.Count)
+				assert.Equal(t, map[string]struct{}{"0.001000": {}}, tp.Probabilities)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("throughput was not saved")
+	}
+
+	metricsFactory.AssertCounterMetrics(t, []metricstest.ExpectedMetric{
+		{Name: "adaptive_sampling_aggregator.sampling_operations", Value: 4},
+		{Name: "adaptive_sampling_aggregator.sampling_services", Value: 3},
+	}...)
+}
+
+func TestAggregatorMaxProbabilities(t *testing.T) {
+	a := NewAggregator(metricstest.NewFactory(0), time.Minute, &smocks.Store{}, zap.NewNop()).(*aggregator)
+	for i := 0; i < maxProbabilities+5; i++ {
+		a.RecordThroughput("A", "GET", "probabilistic", float64(i)/100)
+	}
+	assert.Len(t, a.currentThroughput["A"]["GET"].Probabilities, maxProbabilities)
+	assert.EqualValues(t, maxProbabilities+5, a.currentThroughput["A"]["GET"].Count)
+}
+
+func TestAggregatorSaveError(t *testing.T) {
+	mockStorage := &smocks.Store{}
+	mockStorage.On("InsertThroughput", mock.Anything).Return(errors.New("storage error"))
+	a := NewAggregator(metricstest.NewFactory(0), time.Minute, mockStorage, zap.NewNop()).(*aggregator)
+
+	// nothing recorded, nothing saved
+	a.saveThroughput()
+	mockStorage.AssertNotCalled(t, "InsertThroughput", mock.Anything)
+
+	a.RecordThroughput("A", "GET", "probabilistic", 0.1)
+	a.saveThroughput()
+	mockStorage.AssertCalled(t, "InsertThroughput", mock.Anything)
+	assert.Empty(t, a.currentThroughput)
+}
@@ -15,20 +15,35 @@
 package adaptive
 
 import (
+	"errors"
 	"flag"
+	"os"
 
 	"github.com/spf13/viper"
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
+	"github.com/jaegertracing/jaeger/plugin/sampling/leaderelection"
+	"github.com/jaegertracing/jaeger/storage"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
 )
 
+// samplingLock is the name of the resource that collectors compete for to become the leader
+// responsible for calculating sampling probabilities.
+const samplingLock = "sampling_lock"
+
+var errNoSamplingStoreFactory = errors.New("adaptive sampling requires a storage backend that supports it, " +
+	"but the configured span storage does not")
+
 // Factory implements strategystore.Factory for an adaptive strategy store.
 type Factory struct {
 	options        Options
 	logger         *zap.Logger
 	metricsFactory metrics.Factory
+	lock           distributedlock.Lock
+	store          samplingstore.Store
 }
 
 // NewFactory creates a new Factory.
@@ -50,14 +65,45 @@ func (f *Factory) InitFromViper(v *viper.Viper) {
 }
 
 // Initialize implements strategystore.Factory
-func (f *Factory) Initialize(metricsFactory metrics.Factory, logger *zap.Logger) error {
+func (f *Factory) Initialize(metricsFactory metrics.Factory, ssFactory storage.SamplingStoreFactory, logger *zap.Logger) error {
+	if ssFactory == nil {
+		return errNoSamplingStoreFactory
+	}
 	f.logger = logger
 	f.metricsFactory = metricsFactory
+	var err error
+	if f.lock, err = ssFactory.CreateLock(); err != nil {
+		return err
+	}
+	if f.store, err = ssFactory.CreateSamplingStore(); err != nil {
+		return err
+	}
 	return nil
 }
 
 // CreateStrategyStore implements strategystore.Factory
-func (f *Factory) CreateStrategyStore() (strategystore.StrategyStore, error) {
-	// TODO
-	return nil, nil
+func (f *Factory) CreateStrategyStore() (strategystore.StrategyStore, strategystore.Aggregator, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, nil, err
+	}
+	participant := leaderelection.NewElectionParticipant(f.lock, samplingLock, leaderelection.ElectionParticipantOptions{
+		LeaderLeaseRefreshInterval:   f.options.LeaderLeaseRefreshInterval,
+		FollowerLeaseRefreshInterval: f.options.FollowerLeaseRefreshInterval,
+		Logger:                       f.logger,
+	})
+	p, err := NewProcessor(f.options, hostname, f.store, participant, f.metricsFactory, f.logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := participant.Start(); err != nil {
+		return nil, nil, err
+	}
+	if err := p.(*processor).Start(); err != nil {
+		return nil, nil, err
+	}
+
+	a := NewAggregator(f.metricsFactory, f.options.CalculationInterval, f.store, f.logger)
+	a.Start()
+	return p, a, nil
 }
@@ -15,16 +15,25 @@
 package adaptive
 
 import (
+	"errors"
+	"io"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/model"
 	ss "github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
 	"github.com/jaegertracing/jaeger/pkg/config"
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
+	lmocks "github.com/jaegertracing/jaeger/pkg/distributedlock/mocks"
 	"github.com/jaegertracing/jaeger/plugin"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+	smocks "github.com/jaegertracing/jaeger/storage/samplingstore/mocks"
 )
 
 var _ ss.Factory = new(Factory)
@@ -61,7 +70,48 @@ func TestFactory(t *testing.T) {
 	assert.Equal(t, time.Second, f.options.LeaderLeaseRefreshInterval)
 	assert.Equal(t, time.Second*2, f.options.FollowerLeaseRefreshInterval)
 
-	assert.NoError(t, f.Initialize(metrics.NullFactory, zap.NewNop()))
-	_, err := f.CreateStrategyStore()
-	assert.NoError(t, err)
+	lock := &lmocks.Lock{}
+	lock.On("Acquire", mock.Anything, mock.Anything).Return(true, nil)
+	store := &smocks.Store{}
+	store.On("GetLatestProbabilities").Return(model.ServiceOperationProbabilities{}, nil)
+	store.On("GetThroughput", mock.Anything, mock.Anything).Return([]*model.Throughput{}, nil)
+
+	assert.EqualError(t, f.Initialize(metrics.NullFactory, nil, zap.NewNop()), errNoSamplingStoreFactory.Error())
+	require.NoError(t, f.Initialize(metrics.NullFactory, &mockSamplingStoreFactory{lock: lock, store: store}, zap.NewNop()))
+	strategyStore, aggregator, err := f.CreateStrategyStore()
+	require.NoError(t, err)
+	assert.NotNil(t, strategyStore)
+	assert.NotNil(t, aggregator)
+	assert.NoError(t, aggregator.Close())
+	// the collector closes the strategy store through io.Closer on shutdown
+	assert.NoError(t, strategyStore.(io.Closer).Close())
+}
+
+func TestFactoryInitializeErrors(t *testing.T) {
+	f := NewFactory()
+	assert.EqualError(t, f.Initialize(metrics.NullFactory, &mockSamplingStoreFactory{lockErr: errors.New("lock error")}, zap.NewNop()), "lock error")
+	assert.EqualError(t, f.Initialize(metrics.NullFactory, &mockSamplingStoreFactory{storeErr: errors.New("store error")}, zap.NewNop()), "store error")
+}
+
+func TestFactoryCreateStrategyStoreError(t *testing.T) {
+	f := NewFactory()
+	require.NoError(t, f.Initialize(metrics.NullFactory, &mockSamplingStoreFactory{}, zap.NewNop()))
+	// zero-valued options are rejected by the processor
+	_, _, err := f.CreateStrategyStore()
+	assert.EqualError(t, err, errNonZero.Error())
+}
+
+type mockSamplingStoreFactory struct {
+	lock     distributedlock.Lock
+	lockErr  error
+	store    samplingstore.Store
+	storeErr error
+}
+
+func (m *mockSamplingStoreFactory) CreateLock() (distributedlock.Lock, error) {
+	return m.lock, m.lockErr
+}
+
+func (m *mockSamplingStoreFactory) CreateSamplingStore() (samplingstore.Store, error) {
+	return m.store, m.storeErr
 }
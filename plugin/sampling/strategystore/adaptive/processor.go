@@ -78,6 +78,7 @@ type throughputBucket struct {
 type processor struct {
 	sync.RWMutex
 	Options
+	ss.Notifier
 
 	electionParticipant leaderelection.ElectionParticipant
 	storage             samplingstore.Store
@@ -504,8 +505,9 @@ func (p *processor) generateStrategyResponses() {
 	p.RUnlock()
 
 	p.Lock()
-	defer p.Unlock()
 	p.strategyResponses = strategies
+	p.Unlock()
+	p.Notify()
 }
 
 func (p *processor) generateDefaultSamplingStrategyResponse() *sampling.SamplingStrategyResponse {
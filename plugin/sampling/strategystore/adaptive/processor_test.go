@@ -551,7 +551,10 @@ func TestGenerateStrategyResponses(t *testing.T) {
 			InitialSamplingProbability: 0.001,
 			MinSamplesPerSecond:        0.0001,
 		}}
+	updates, unsubscribe := p.Subscribe()
+	defer unsubscribe()
 	p.generateStrategyResponses()
+	assert.Len(t, updates, 1)
 
 	expectedResponse := map[string]*sampling.SamplingStrategyResponse{
 		"svcA": {
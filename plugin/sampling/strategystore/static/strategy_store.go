@@ -32,6 +32,7 @@ import (
 )
 
 type strategyStore struct {
+	ss.Notifier
 	logger *zap.Logger
 
 	storedStrategies atomic.Value // holds *storedStrategies
@@ -181,6 +182,7 @@ func (h *strategyStore) parseStrategies(strategies *strategies) {
 		}
 	}
 	h.storedStrategies.Store(newStore)
+	h.Notify()
 }
 
 // mergePerOperationStrategies merges two operation strategies a and b, where a takes precedence over b.
@@ -279,6 +279,9 @@ func TestAutoUpdateStrategy(t *testing.T) {
 	value := store.reloadSamplingStrategyFile(dstFile, string(srcBytes))
 	assert.Equal(t, string(srcBytes), value)
 
+	updates, unsubscribe := store.Subscribe()
+	defer unsubscribe()
+
 	// update file with new probability of 0.9
 	newStr := strings.Replace(string(srcBytes), "0.8", "0.9", 1)
 	require.NoError(t, ioutil.WriteFile(dstFile, []byte(newStr), 0644))
@@ -293,6 +296,12 @@ func TestAutoUpdateStrategy(t *testing.T) {
 		time.Sleep(1 * time.Millisecond)
 	}
 	assert.EqualValues(t, makeResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.9), *s)
+
+	select {
+	case <-updates:
+	case <-time.After(time.Second):
+		t.Fatal("subscriber was not notified of the reloaded strategies")
+	}
 }
 
 func TestAutoUpdateStrategyErrors(t *testing.T) {
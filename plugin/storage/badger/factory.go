@@ -20,6 +20,7 @@ import (
 	"io/ioutil"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dgraph-io/badger"
@@ -48,6 +49,9 @@ type Factory struct {
 	cache   *badgerStore.CacheStore
 	logger  *zap.Logger
 
+	tenantsLock  sync.Mutex
+	tenantCaches map[string]*badgerStore.CacheStore
+
 	tmpDir          string
 	maintenanceDone chan bool
 
@@ -128,6 +132,7 @@ func (f *Factory) Initialize(metricsFactory metrics.Factory, logger *zap.Logger)
 	f.store = store
 
 	f.cache = badgerStore.NewCacheStore(f.store, f.Options.Primary.SpanStoreTTL, true)
+	f.tenantCaches = make(map[string]*badgerStore.CacheStore)
 
 	f.metrics.ValueLogSpaceAvailable = metricsFactory.Gauge(metrics.Options{Name: valueLogSpaceAvailableName})
 	f.metrics.KeyLogSpaceAvailable = metricsFactory.Gauge(metrics.Options{Name: keyLogSpaceAvailableName})
@@ -161,6 +166,41 @@ func (f *Factory) CreateSpanWriter() (spanstore.Writer, error) {
 	return badgerStore.NewSpanWriter(f.store, f.cache, f.Options.Primary.SpanStoreTTL, f), nil
 }
 
+// CreateTenantSpanReader implements storage.TenantFactory
+func (f *Factory) CreateTenantSpanReader(tenant string) (spanstore.Reader, error) {
+	cache, err := f.tenantCache(tenant)
+	if err != nil {
+		return nil, err
+	}
+	return badgerStore.NewTraceReader(f.store, cache), nil
+}
+
+// CreateTenantSpanWriter implements storage.TenantFactory
+func (f *Factory) CreateTenantSpanWriter(tenant string) (spanstore.Writer, error) {
+	cache, err := f.tenantCache(tenant)
+	if err != nil {
+		return nil, err
+	}
+	return badgerStore.NewSpanWriter(f.store, cache, f.Options.Primary.SpanStoreTTL, f), nil
+}
+
+// tenantCache returns the cache shared by the readers and writers of the tenant, whose keys
+// are prefixed with the tenant
+func (f *Factory) tenantCache(tenant string) (*badgerStore.CacheStore, error) {
+	f.tenantsLock.Lock()
+	defer f.tenantsLock.Unlock()
+	if cache, ok := f.tenantCaches[tenant]; ok {
+		return cache, nil
+	}
+	prefix, err := badgerStore.TenantKeyPrefix(tenant)
+	if err != nil {
+		return nil, err
+	}
+	cache := badgerStore.NewCacheStoreWithKeyPrefix(f.store, f.Options.Primary.SpanStoreTTL, true, prefix)
+	f.tenantCaches[tenant] = cache
+	return cache, nil
+}
+
 // CreateDependencyReader implements storage.Factory
 func (f *Factory) CreateDependencyReader() (dependencystore.Reader, error) {
 	sr, _ := f.CreateSpanReader() // err is always nil
@@ -77,6 +77,27 @@ func TestForCodecov(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestTenantFactory(t *testing.T) {
+	f := NewFactory()
+	v, _ := config.Viperize(f.AddFlags)
+	f.InitFromViper(v)
+	assert.NoError(t, f.Initialize(metrics.NullFactory, zap.NewNop()))
+	defer f.Close()
+
+	writer, err := f.CreateTenantSpanWriter("acme")
+	assert.NoError(t, err)
+	assert.NotNil(t, writer)
+	reader, err := f.CreateTenantSpanReader("acme")
+	assert.NoError(t, err)
+	assert.NotNil(t, reader)
+	assert.Len(t, f.tenantCaches, 1)
+
+	_, err = f.CreateTenantSpanWriter("")
+	assert.Error(t, err)
+	_, err = f.CreateTenantSpanReader("")
+	assert.Error(t, err)
+}
+
 func TestMaintenanceRun(t *testing.T) {
 	// For Codecov - this does not test anything
 	f := NewFactory()
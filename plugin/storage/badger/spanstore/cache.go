@@ -31,17 +31,25 @@ type CacheStore struct {
 	services   map[string]uint64
 	operations map[string]map[string]uint64
 
-	store *badger.DB
-	ttl   time.Duration
+	store     *badger.DB
+	ttl       time.Duration
+	keyPrefix []byte // shared with the readers and writers using the cache
 }
 
 // NewCacheStore returns initialized CacheStore for badger use
 func NewCacheStore(db *badger.DB, ttl time.Duration, prefill bool) *CacheStore {
+	return NewCacheStoreWithKeyPrefix(db, ttl, prefill, nil)
+}
+
+// NewCacheStoreWithKeyPrefix returns initialized CacheStore for the keys starting with keyPrefix,
+// see TenantKeyPrefix. The readers and writers created with the cache use the same prefix.
+func NewCacheStoreWithKeyPrefix(db *badger.DB, ttl time.Duration, prefill bool, keyPrefix []byte) *CacheStore {
 	cs := &CacheStore{
 		services:   make(map[string]uint64),
 		operations: make(map[string]map[string]uint64),
 		ttl:        ttl,
 		store:      db,
+		keyPrefix:  keyPrefix,
 	}
 
 	if prefill {
@@ -67,7 +75,7 @@ func (c *CacheStore) loadServices() {
 		it := txn.NewIterator(opts)
 		defer it.Close()
 
-		serviceKey := []byte{serviceNameIndexKey}
+		serviceKey := withKeyPrefix(c.keyPrefix, []byte{serviceNameIndexKey})
 
 		// Seek all the services first
 		for it.Seek(serviceKey); it.ValidForPrefix(serviceKey); it.Next() {
@@ -94,6 +102,7 @@ func (c *CacheStore) loadOperations(service string) {
 		serviceKey := make([]byte, len(service)+1)
 		serviceKey[0] = operationNameIndexKey
 		copy(serviceKey[1:], service)
+		serviceKey = withKeyPrefix(c.keyPrefix, serviceKey)
 
 		// Seek all the services first
 		for it.Seek(serviceKey); it.ValidForPrefix(serviceKey); it.Next() {
@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
@@ -129,6 +130,81 @@ func TestValidation(t *testing.T) {
 		params.Tags = map[string]string{"A": "B"}
 		_, err = sr.FindTraces(context.Background(), params)
 		assert.EqualError(t, err, "service name must be set")
+
+		params.Tags = nil
+		params.TagPredicates = []spanstore.TagPredicate{{Key: "A", Operator: spanstore.TagExists}}
+		_, err = sr.FindTraces(context.Background(), params)
+		assert.EqualError(t, err, "service name must be set")
+	})
+}
+
+func TestTagPredicates(t *testing.T) {
+	runFactoryTest(t, func(tb testing.TB, sw spanstore.Writer, sr spanstore.Reader) {
+		startT := time.Now()
+		traceOrder := make([]uint64, 10)
+		for i := range traceOrder {
+			traceOrder[i] = rand.Uint64()
+			s := model.Span{
+				TraceID:       model.TraceID{Low: traceOrder[i], High: 1},
+				SpanID:        model.SpanID(rand.Uint64()),
+				OperationName: "operation",
+				Process:       &model.Process{ServiceName: "service"},
+				StartTime:     startT.Add(time.Duration(i) * time.Millisecond),
+				Duration:      time.Millisecond,
+				Tags: model.KeyValues{
+					model.Int64("http.status_code", int64(100*i)),
+					model.String("http.method", []string{"GET", "POST"}[i%2]),
+				},
+			}
+			assert.NoError(t, sw.WriteSpan(&s))
+		}
+
+		findTraces := func(predicates ...spanstore.TagPredicate) []uint64 {
+			params := &spanstore.TraceQueryParameters{
+				StartTimeMin:  startT,
+				StartTimeMax:  startT.Add(time.Hour),
+				ServiceName:   "service",
+				TagPredicates: predicates,
+				NumTraces:     3,
+			}
+			trs, err := sr.FindTraces(context.Background(), params)
+			require.NoError(t, err)
+			traceIDs, err := sr.FindTraceIDs(context.Background(), params)
+			require.NoError(t, err)
+			require.Len(t, traceIDs, len(trs))
+			var lows []uint64
+			for i, tr := range trs {
+				assert.Equal(t, traceIDs[i], tr.Spans[0].TraceID)
+				lows = append(lows, tr.Spans[0].TraceID.Low)
+			}
+			return lows
+		}
+
+		assert.Equal(t, []uint64{traceOrder[9], traceOrder[8], traceOrder[7]},
+			findTraces(spanstore.TagPredicate{Key: "http.status_code", Operator: spanstore.TagGreaterThanOrEqual, Value: "500"}))
+		assert.Equal(t, []uint64{traceOrder[9], traceOrder[7], traceOrder[5]},
+			findTraces(
+				spanstore.TagPredicate{Key: "http.status_code", Operator: spanstore.TagGreaterThanOrEqual, Value: "500"},
+				spanstore.TagPredicate{Key: "http.method", Operator: spanstore.TagEquals, Value: "POST"},
+			))
+		assert.Equal(t, []uint64{traceOrder[2], traceOrder[1], traceOrder[0]},
+			findTraces(spanstore.TagPredicate{Key: "http.status_code", Operator: spanstore.TagLessThan, Value: "300"}))
+		assert.Equal(t, []uint64{traceOrder[9], traceOrder[8], traceOrder[6]},
+			findTraces(
+				spanstore.TagPredicate{Key: "http.status_code", Operator: spanstore.TagNotEquals, Value: "700"},
+				spanstore.TagPredicate{Key: "http.status_code", Operator: spanstore.TagMatches, Value: "[6-9]00"},
+			))
+		assert.Equal(t, []uint64{traceOrder[9], traceOrder[8], traceOrder[7]},
+			findTraces(spanstore.TagPredicate{Key: "http.method", Operator: spanstore.TagExists}))
+		assert.Empty(t, findTraces(spanstore.TagPredicate{Key: "error", Operator: spanstore.TagExists}))
+
+		_, err := sr.FindTraces(context.Background(), &spanstore.TraceQueryParameters{
+			StartTimeMin:  startT,
+			StartTimeMax:  startT.Add(time.Hour),
+			ServiceName:   "service",
+			TagPredicates: []spanstore.TagPredicate{{Key: "http.status_code", Operator: spanstore.TagGreaterThan, Value: "abc"}},
+		})
+		assert.EqualError(t, err, `invalid number for tag http.status_code: strconv.ParseFloat: parsing "abc": invalid syntax`)
 	})
 }
 
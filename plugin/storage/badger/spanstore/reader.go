@@ -25,7 +25,7 @@ import (
 	"sort"
 
 	"github.com/dgraph-io/badger"
-	"github.com/golang/protobuf/proto"
+	"github.com/gogo/protobuf/proto"
 
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
@@ -62,10 +62,15 @@ const (
 	encodingTypeBits = 0x0F
 )
 
+// maxTagPredicateTraces is the max number of the most recent traces of a service loaded to be matched
+// against the tag predicates that cannot use the tag index, so that a query never loads every trace
+var maxTagPredicateTraces = 2000
+
 // TraceReader reads traces from the local badger store
 type TraceReader struct {
-	store *badger.DB
-	cache *CacheStore
+	store     *badger.DB
+	cache     *CacheStore
+	keyPrefix []byte
 }
 
 // executionPlan is internal structure to track the index filtering
@@ -85,8 +90,9 @@ type executionPlan struct {
 // NewTraceReader returns a TraceReader with cache
 func NewTraceReader(db *badger.DB, c *CacheStore) *TraceReader {
 	return &TraceReader{
-		store: db,
-		cache: c,
+		store:     db,
+		cache:     c,
+		keyPrefix: c.keyPrefix,
 	}
 }
 
@@ -114,7 +120,7 @@ func (r *TraceReader) getTraces(traceIDs []model.TraceID) ([]*model.Trace, error
 	prefixes := make([][]byte, 0, len(traceIDs))
 
 	for _, traceID := range traceIDs {
-		prefixes = append(prefixes, createPrimaryKeySeekPrefix(traceID))
+		prefixes = append(prefixes, withKeyPrefix(r.keyPrefix, createPrimaryKeySeekPrefix(traceID)))
 	}
 
 	err := r.store.View(func(txn *badger.Txn) error {
@@ -177,13 +183,13 @@ func (r *TraceReader) scanTimeRange(plan *executionPlan) ([]model.TraceID, error
 		it := txn.NewIterator(opts)
 		defer it.Close()
 
-		startIndex := []byte{spanKeyPrefix}
+		startIndex := withKeyPrefix(r.keyPrefix, []byte{spanKeyPrefix})
 		prevTraceID := []byte{}
 		for it.Seek(startIndex); it.ValidForPrefix(startIndex); it.Next() {
 			item := it.Item()
 
 			key := []byte{}
-			key = item.KeyCopy(key)
+			key = item.KeyCopy(key)[len(r.keyPrefix):]
 
 			timestamp := key[sizeOfTraceID+1 : sizeOfTraceID+1+8]
 			traceID := key[1 : sizeOfTraceID+1]
@@ -270,17 +276,26 @@ func serviceQueries(query *spanstore.TraceQueryParameters, indexSeeks [][]byte)
 		indexSeeks = append(indexSeeks, indexSearchKey)
 		if len(query.Tags) > 0 {
 			for k, v := range query.Tags {
-				tagSearch := []byte(query.ServiceName + k + v)
-				tagSearchKey := make([]byte, 0, len(tagSearch)+1)
-				tagSearchKey = append(tagSearchKey, tagIndexKey)
-				tagSearchKey = append(tagSearchKey, tagSearch...)
-				indexSeeks = append(indexSeeks, tagSearchKey)
+				indexSeeks = append(indexSeeks, tagIndexSeek(query.ServiceName, k, v))
+			}
+		}
+		for _, predicate := range query.TagPredicates {
+			if predicate.Operator == spanstore.TagEquals {
+				indexSeeks = append(indexSeeks, tagIndexSeek(query.ServiceName, predicate.Key, predicate.Value))
 			}
 		}
 	}
 	return indexSeeks
 }
 
+func tagIndexSeek(serviceName, key, value string) []byte {
+	tagSearch := []byte(serviceName + key + value)
+	tagSearchKey := make([]byte, 0, len(tagSearch)+1)
+	tagSearchKey = append(tagSearchKey, tagIndexKey)
+	tagSearchKey = append(tagSearchKey, tagSearch...)
+	return tagSearchKey
+}
+
 // indexSeeksToTraceIDs does the index scanning against badger based on the parsed index queries
 func (r *TraceReader) indexSeeksToTraceIDs(plan *executionPlan, indexSeeks [][]byte) ([]model.TraceID, error) {
 
@@ -397,6 +412,8 @@ func (r *TraceReader) durationQueries(plan *executionPlan, query *spanstore.Trac
 	}
 	binary.BigEndian.PutUint64(endKey[1:], durMax)
 	binary.BigEndian.PutUint64(startKey[1:], durMin)
+	startKey = withKeyPrefix(r.keyPrefix, startKey)
+	endKey = withKeyPrefix(r.keyPrefix, endKey)
 
 	// This is not unique index result - same TraceID can be matched from multiple spans
 	indexResults, _ := r.scanRangeIndex(plan, startKey, endKey)
@@ -448,26 +465,53 @@ func mergeJoinIds(left, right [][]byte) [][]byte {
 
 // FindTraces retrieves traces that match the traceQuery
 func (r *TraceReader) FindTraces(ctx context.Context, query *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
-	keys, err := r.FindTraceIDs(ctx, query)
+	keys, traces, err := r.findTraceIDs(query)
 	if err != nil {
 		return nil, err
 	}
+	if traces != nil {
+		return traces, nil
+	}
 
 	return r.getTraces(keys)
 }
 
 // FindTraceIDs retrieves only the TraceIDs that match the traceQuery, but not the trace data
 func (r *TraceReader) FindTraceIDs(ctx context.Context, query *spanstore.TraceQueryParameters) ([]model.TraceID, error) {
+	keys, _, err := r.findTraceIDs(query)
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// findTraceIDs retrieves the TraceIDs that match the traceQuery. It also returns the traces if it had to load
+// them to evaluate the tag predicates which cannot be answered by the tag index, i.e. all but the equality ones.
+func (r *TraceReader) findTraceIDs(query *spanstore.TraceQueryParameters) ([]model.TraceID, []*model.Trace, error) {
 	// Validate and set query defaults which were not defined
 	if err := validateQuery(query); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	setQueryDefaults(query)
 
+	var tagPredicates []spanstore.TagPredicate
+	for _, predicate := range query.TagPredicates {
+		if predicate.Operator != spanstore.TagEquals {
+			tagPredicates = append(tagPredicates, predicate)
+		}
+	}
+	tagMatchers, err := spanstore.NewTagMatchers(tagPredicates)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Find matches using indexes that are using service as part of the key
 	indexSeeks := make([][]byte, 0, 1)
 	indexSeeks = serviceQueries(query, indexSeeks)
+	for i := range indexSeeks {
+		indexSeeks[i] = withKeyPrefix(r.keyPrefix, indexSeeks[i])
+	}
 
 	startStampBytes := make([]byte, 8)
 	binary.BigEndian.PutUint64(startStampBytes, model.TimeAsEpochMicroseconds(query.StartTimeMin))
@@ -480,6 +524,10 @@ func (r *TraceReader) FindTraceIDs(ctx context.Context, query *spanstore.TraceQu
 		startTimeMax: endStampBytes,
 		limit:        query.NumTraces,
 	}
+	if len(tagMatchers) > 0 && plan.limit < maxTagPredicateTraces {
+		// query.NumTraces is applied once the traces are filtered by the tag matchers
+		plan.limit = maxTagPredicateTraces
+	}
 
 	if query.DurationMax != 0 || query.DurationMin != 0 {
 		plan.hashOuter = r.durationQueries(plan, query)
@@ -488,13 +536,42 @@ func (r *TraceReader) FindTraceIDs(ctx context.Context, query *spanstore.TraceQu
 	if len(indexSeeks) > 0 {
 		keys, err := r.indexSeeksToTraceIDs(plan, indexSeeks)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+		if len(tagMatchers) > 0 {
+			return r.filterTraces(keys, query, tagMatchers)
 		}
 
-		return keys, nil
+		return keys, nil, nil
 	}
 
-	return r.scanTimeRange(plan)
+	// Tag predicates require a service name, hence index seeks
+	keys, err := r.scanTimeRange(plan)
+	return keys, nil, err
+}
+
+// filterTraces loads the traces in batches until it finds query.NumTraces traces in which
+// each tag matcher is satisfied by a span of the queried service.
+func (r *TraceReader) filterTraces(traceIDs []model.TraceID, query *spanstore.TraceQueryParameters, tagMatchers []*spanstore.TagMatcher) ([]model.TraceID, []*model.Trace, error) {
+	keys := make([]model.TraceID, 0, query.NumTraces)
+	traces := make([]*model.Trace, 0, query.NumTraces)
+	for start := 0; start < len(traceIDs) && len(traces) < query.NumTraces; start += query.NumTraces {
+		end := start + query.NumTraces
+		if end > len(traceIDs) {
+			end = len(traceIDs)
+		}
+		batch, err := r.getTraces(traceIDs[start:end])
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, trace := range batch {
+			if len(traces) < query.NumTraces && spanstore.MatchTrace(trace, query.ServiceName, tagMatchers) {
+				keys = append(keys, trace.Spans[0].TraceID)
+				traces = append(traces, trace)
+			}
+		}
+	}
+	return keys, traces, nil
 }
 
 // validateQuery returns an error if certain restrictions are not met
@@ -502,7 +579,7 @@ func validateQuery(p *spanstore.TraceQueryParameters) error {
 	if p == nil {
 		return ErrMalformedRequestObject
 	}
-	if p.ServiceName == "" && len(p.Tags) > 0 {
+	if p.ServiceName == "" && (len(p.Tags) > 0 || len(p.TagPredicates) > 0) {
 		return ErrServiceNameNotSet
 	}
 	if p.ServiceName == "" && p.OperationName != "" {
@@ -611,7 +688,11 @@ func (r *TraceReader) scanRangeIndex(plan *executionPlan, indexStartValue []byte
 // scanRangeFunction seeks until the index end has been reached
 func scanRangeFunction(it *badger.Iterator, indexEndValue []byte) bool {
 	if it.Item() != nil {
-		compareSlice := it.Item().Key()[:len(indexEndValue)]
+		compareSlice := it.Item().Key()
+		// keys of other tenants may be shorter than the prefixed index value
+		if len(compareSlice) > len(indexEndValue) {
+			compareSlice = compareSlice[:len(indexEndValue)]
+		}
 		return bytes.Compare(indexEndValue, compareSlice) >= 0
 	}
 	return false
@@ -129,6 +129,104 @@ func TestDuplicateTraceIDDetection(t *testing.T) {
 	})
 }
 
+func TestTenantKeyPrefix(t *testing.T) {
+	prefix, err := TenantKeyPrefix("acme")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("acme\x00"), prefix)
+
+	_, err = TenantKeyPrefix("")
+	assert.Error(t, err)
+	_, err = TenantKeyPrefix("acme\x00")
+	assert.Error(t, err)
+	_, err = TenantKeyPrefix("\u00e9quipe")
+	assert.Error(t, err)
+}
+
+func TestTenantIsolation(t *testing.T) {
+	runWithBadger(t, func(store *badger.DB, t *testing.T) {
+		tenants := []string{"", "acme", "a-tenant-with-a-rather-long-name"}
+		readers := make([]*TraceReader, len(tenants))
+		for i, tenant := range tenants {
+			var prefix []byte
+			if tenant != "" {
+				var err error
+				prefix, err = TenantKeyPrefix(tenant)
+				assert.NoError(t, err)
+			}
+			cache := NewCacheStoreWithKeyPrefix(store, time.Duration(1*time.Hour), true, prefix)
+			sw := NewSpanWriter(store, cache, time.Duration(1*time.Hour), nil)
+			readers[i] = NewTraceReader(store, cache)
+
+			testSpan := createDummySpan()
+			testSpan.TraceID.Low = uint64(i)
+			testSpan.Process.ServiceName = "service" + tenant
+			assert.NoError(t, sw.WriteSpan(&testSpan))
+		}
+
+		for i, tenant := range tenants {
+			reader := readers[i]
+			for j := range tenants {
+				trace, err := reader.GetTrace(context.Background(), model.TraceID{High: 1, Low: uint64(j)})
+				assert.NoError(t, err)
+				if i == j {
+					assert.NotNil(t, trace)
+				} else {
+					assert.Nil(t, trace, "tenant %q reads the trace of tenant %q", tenant, tenants[j])
+				}
+			}
+
+			services, err := reader.GetServices(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"service" + tenant}, services)
+
+			queries := []*spanstore.TraceQueryParameters{
+				{ServiceName: "service" + tenant},
+				{ServiceName: "service" + tenant, Tags: map[string]string{"key": "value"}},
+				{DurationMin: time.Microsecond},
+				{},
+			}
+			for _, query := range queries {
+				query.StartTimeMax = time.Now().Add(time.Hour)
+				query.StartTimeMin = time.Now().Add(-1 * time.Hour)
+				traceIDs, err := reader.FindTraceIDs(context.Background(), query)
+				assert.NoError(t, err)
+				assert.Equal(t, []model.TraceID{{High: 1, Low: uint64(i)}}, traceIDs, "tenant %q", tenant)
+			}
+		}
+	})
+}
+
+func TestTagPredicatesTraceLimit(t *testing.T) {
+	defer func(limit int) { maxTagPredicateTraces = limit }(maxTagPredicateTraces)
+	maxTagPredicateTraces = 2
+	runWithBadger(t, func(store *badger.DB, t *testing.T) {
+		cache := NewCacheStore(store, time.Duration(1*time.Hour), true)
+		sw := NewSpanWriter(store, cache, time.Duration(1*time.Hour), nil)
+		reader := NewTraceReader(store, cache)
+
+		startTime := time.Now()
+		for i := 0; i < 3; i++ {
+			testSpan := createDummySpan()
+			testSpan.TraceID.Low = uint64(i)
+			testSpan.StartTime = startTime.Add(time.Duration(i) * time.Millisecond)
+			testSpan.Tags = model.KeyValues{model.Int64("attempt", int64(i))}
+			assert.NoError(t, sw.WriteSpan(&testSpan))
+		}
+
+		// only the most recent traces are matched against the predicates
+		query := &spanstore.TraceQueryParameters{
+			ServiceName:   "service",
+			StartTimeMin:  startTime.Add(-time.Hour),
+			StartTimeMax:  startTime.Add(time.Hour),
+			TagPredicates: []spanstore.TagPredicate{{Key: "attempt", Operator: spanstore.TagLessThan, Value: "2"}},
+			NumTraces:     1,
+		}
+		traceIDs, err := reader.FindTraceIDs(context.Background(), query)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.TraceID{{High: 1, Low: 1}}, traceIDs)
+	})
+}
+
 func createDummySpan() model.Span {
 	tid := time.Now()
 
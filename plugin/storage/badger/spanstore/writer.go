@@ -53,6 +53,7 @@ type SpanWriter struct {
 	cache        *CacheStore
 	closer       io.Closer
 	encodingType byte
+	keyPrefix    []byte
 }
 
 // NewSpanWriter returns a SpawnWriter with cache
@@ -63,6 +64,7 @@ func NewSpanWriter(db *badger.DB, c *CacheStore, ttl time.Duration, storageClose
 		cache:        c,
 		closer:       storageCloser,
 		encodingType: defaultEncoding, // TODO Make configurable
+		keyPrefix:    c.keyPrefix,
 	}
 }
 
@@ -80,27 +82,27 @@ func (w *SpanWriter) WriteSpan(span *model.Span) error {
 	}
 
 	entriesToStore = append(entriesToStore, trace)
-	entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(serviceNameIndexKey, []byte(span.Process.ServiceName), startTime, span.TraceID), nil, expireTime))
-	entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(operationNameIndexKey, []byte(span.Process.ServiceName+span.OperationName), startTime, span.TraceID), nil, expireTime))
+	entriesToStore = append(entriesToStore, w.createBadgerEntry(w.indexKey(serviceNameIndexKey, []byte(span.Process.ServiceName), startTime, span.TraceID), nil, expireTime))
+	entriesToStore = append(entriesToStore, w.createBadgerEntry(w.indexKey(operationNameIndexKey, []byte(span.Process.ServiceName+span.OperationName), startTime, span.TraceID), nil, expireTime))
 
 	// It doesn't matter if we overwrite Duration index keys, everything is read at Trace level in any case
 	durationValue := make([]byte, 8)
 	binary.BigEndian.PutUint64(durationValue, uint64(model.DurationAsMicroseconds(span.Duration)))
-	entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(durationIndexKey, durationValue, startTime, span.TraceID), nil, expireTime))
+	entriesToStore = append(entriesToStore, w.createBadgerEntry(w.indexKey(durationIndexKey, durationValue, startTime, span.TraceID), nil, expireTime))
 
 	for _, kv := range span.Tags {
 		// Convert everything to string since queries are done that way also
 		// KEY: it<serviceName><tagsKey><traceId> VALUE: <tagsValue>
-		entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(tagIndexKey, []byte(span.Process.ServiceName+kv.Key+kv.AsString()), startTime, span.TraceID), nil, expireTime))
+		entriesToStore = append(entriesToStore, w.createBadgerEntry(w.indexKey(tagIndexKey, []byte(span.Process.ServiceName+kv.Key+kv.AsString()), startTime, span.TraceID), nil, expireTime))
 	}
 
 	for _, kv := range span.Process.Tags {
-		entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(tagIndexKey, []byte(span.Process.ServiceName+kv.Key+kv.AsString()), startTime, span.TraceID), nil, expireTime))
+		entriesToStore = append(entriesToStore, w.createBadgerEntry(w.indexKey(tagIndexKey, []byte(span.Process.ServiceName+kv.Key+kv.AsString()), startTime, span.TraceID), nil, expireTime))
 	}
 
 	for _, log := range span.Logs {
 		for _, kv := range log.Fields {
-			entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(tagIndexKey, []byte(span.Process.ServiceName+kv.Key+kv.AsString()), startTime, span.TraceID), nil, expireTime))
+			entriesToStore = append(entriesToStore, w.createBadgerEntry(w.indexKey(tagIndexKey, []byte(span.Process.ServiceName+kv.Key+kv.AsString()), startTime, span.TraceID), nil, expireTime))
 		}
 	}
 
@@ -126,6 +128,38 @@ func (w *SpanWriter) WriteSpan(span *model.Span) error {
 	return err
 }
 
+// TenantKeyPrefix returns the prefix of the keys holding the spans of the tenant. Tenants are
+// restricted to printable ASCII, so that their keys never collide with the unprefixed keys
+// starting with spanKeyPrefix, nor with the keys of another tenant thanks to the terminator.
+func TenantKeyPrefix(tenant string) ([]byte, error) {
+	if tenant == "" {
+		return nil, fmt.Errorf("empty tenant")
+	}
+	for i := 0; i < len(tenant); i++ {
+		if tenant[i] < 0x20 || tenant[i] > 0x7E {
+			return nil, fmt.Errorf("invalid tenant %q: only printable ASCII characters are allowed", tenant)
+		}
+	}
+	prefix := make([]byte, len(tenant)+1)
+	copy(prefix, tenant) // the last byte is the 0x00 terminator
+	return prefix, nil
+}
+
+// withKeyPrefix returns the key prepended with the prefix, or the key itself if there is no prefix
+func withKeyPrefix(prefix, key []byte) []byte {
+	if len(prefix) == 0 {
+		return key
+	}
+	prefixed := make([]byte, len(prefix)+len(key))
+	copy(prefixed, prefix)
+	copy(prefixed[len(prefix):], key)
+	return prefixed
+}
+
+func (w *SpanWriter) indexKey(indexPrefixKey byte, value []byte, startTime uint64, traceID model.TraceID) []byte {
+	return withKeyPrefix(w.keyPrefix, createIndexKey(indexPrefixKey, value, startTime, traceID))
+}
+
 func createIndexKey(indexPrefixKey byte, value []byte, startTime uint64, traceID model.TraceID) []byte {
 	// KEY: indexKey<indexValue><startTime><traceId> (traceId is last 16 bytes of the key)
 	key := make([]byte, 1+len(value)+8+sizeOfTraceID)
@@ -154,7 +188,7 @@ func (w *SpanWriter) createTraceEntry(span *model.Span, startTime, expireTime ui
 		return nil, err
 	}
 
-	e := w.createBadgerEntry(pK, pV, expireTime)
+	e := w.createBadgerEntry(withKeyPrefix(w.keyPrefix, pK), pV, expireTime)
 	e.UserMeta = w.encodingType
 
 	return e, nil
@@ -18,6 +18,10 @@ package cassandra
 import (
 	"errors"
 	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
 
 	"github.com/spf13/viper"
 	"github.com/uber/jaeger-lib/metrics"
@@ -25,19 +29,29 @@ import (
 
 	"github.com/jaegertracing/jaeger/pkg/cassandra"
 	"github.com/jaegertracing/jaeger/pkg/cassandra/config"
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
+	cLock "github.com/jaegertracing/jaeger/plugin/pkg/distributedlock/cassandra"
 	cDepStore "github.com/jaegertracing/jaeger/plugin/storage/cassandra/dependencystore"
+	cSamplingStore "github.com/jaegertracing/jaeger/plugin/storage/cassandra/samplingstore"
 	cSpanStore "github.com/jaegertracing/jaeger/plugin/storage/cassandra/spanstore"
 	"github.com/jaegertracing/jaeger/plugin/storage/cassandra/spanstore/dbmodel"
 	"github.com/jaegertracing/jaeger/storage"
 	"github.com/jaegertracing/jaeger/storage/dependencystore"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
 const (
 	primaryStorageConfig = "cassandra"
 	archiveStorageConfig = "cassandra-archive"
+
+	// maxKeyspaceLength is the longest keyspace name allowed by Cassandra
+	maxKeyspaceLength = 48
 )
 
+// validTenant restricts tenants to the characters allowed in keyspace names
+var validTenant = regexp.MustCompile("^[a-z0-9_]+$")
+
 // Factory implements storage.Factory for Cassandra backend.
 type Factory struct {
 	Options *Options
@@ -50,13 +64,21 @@ type Factory struct {
 	primarySession cassandra.Session
 	archiveConfig  config.SessionBuilder
 	archiveSession cassandra.Session
+
+	tenantsLock    sync.Mutex
+	tenantSessions map[string]cassandra.Session
+	// tenantConfig returns the session builder of a tenant keyspace, overridden in tests
+	tenantConfig func(keyspace string) config.SessionBuilder
 }
 
 // NewFactory creates a new Factory.
 func NewFactory() *Factory {
-	return &Factory{
-		Options: NewOptions(primaryStorageConfig, archiveStorageConfig),
+	f := &Factory{
+		Options:        NewOptions(primaryStorageConfig, archiveStorageConfig),
+		tenantSessions: make(map[string]cassandra.Session),
 	}
+	f.tenantConfig = f.tenantKeyspaceConfig
+	return f
 }
 
 // AddFlags implements plugin.Configurable
@@ -120,6 +142,58 @@ func (f *Factory) CreateSpanWriter() (spanstore.Writer, error) {
 	return cSpanStore.NewSpanWriter(f.primarySession, f.Options.SpanStoreWriteCacheTTL, f.primaryMetricsFactory, f.logger, options...), nil
 }
 
+// CreateTenantSpanReader implements storage.TenantFactory
+func (f *Factory) CreateTenantSpanReader(tenant string) (spanstore.Reader, error) {
+	session, err := f.tenantSession(tenant)
+	if err != nil {
+		return nil, err
+	}
+	return cSpanStore.NewSpanReader(session, f.primaryMetricsFactory, f.logger), nil
+}
+
+// CreateTenantSpanWriter implements storage.TenantFactory
+func (f *Factory) CreateTenantSpanWriter(tenant string) (spanstore.Writer, error) {
+	session, err := f.tenantSession(tenant)
+	if err != nil {
+		return nil, err
+	}
+	options, err := writerOptions(f.Options)
+	if err != nil {
+		return nil, err
+	}
+	return cSpanStore.NewSpanWriter(session, f.Options.SpanStoreWriteCacheTTL, f.primaryMetricsFactory, f.logger, options...), nil
+}
+
+// tenantSession returns the session of the keyspace holding the spans of the tenant, named after
+// the primary keyspace and the tenant, e.g. jaeger_v1_dc1_acme. The keyspace must already exist.
+func (f *Factory) tenantSession(tenant string) (cassandra.Session, error) {
+	f.tenantsLock.Lock()
+	defer f.tenantsLock.Unlock()
+	if session, ok := f.tenantSessions[tenant]; ok {
+		return session, nil
+	}
+	if !validTenant.MatchString(tenant) {
+		return nil, fmt.Errorf("invalid tenant %q: Cassandra tenants must match %s", tenant, validTenant)
+	}
+	keyspace := f.Options.GetPrimary().Keyspace + "_" + tenant
+	if len(keyspace) > maxKeyspaceLength {
+		return nil, fmt.Errorf("invalid tenant %q: keyspace %s is longer than %d characters", tenant, keyspace, maxKeyspaceLength)
+	}
+	session, err := f.tenantConfig(keyspace).NewSession()
+	if err != nil {
+		return nil, err
+	}
+	f.tenantSessions[tenant] = session
+	return session, nil
+}
+
+// tenantKeyspaceConfig returns the primary configuration using the given keyspace
+func (f *Factory) tenantKeyspaceConfig(keyspace string) config.SessionBuilder {
+	cfg := *f.Options.GetPrimary()
+	cfg.Keyspace = keyspace
+	return &cfg
+}
+
 // CreateDependencyReader implements storage.Factory
 func (f *Factory) CreateDependencyReader() (dependencystore.Reader, error) {
 	version := cDepStore.GetDependencyVersion(f.primarySession)
@@ -146,6 +220,37 @@ func (f *Factory) CreateArchiveSpanWriter() (spanstore.Writer, error) {
 	return cSpanStore.NewSpanWriter(f.archiveSession, f.Options.SpanStoreWriteCacheTTL, f.archiveMetricsFactory, f.logger, options...), nil
 }
 
+// CreateLock implements storage.SamplingStoreFactory
+func (f *Factory) CreateLock() (distributedlock.Lock, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	return cLock.NewLock(f.primarySession, hostname), nil
+}
+
+// CreateSamplingStore implements storage.SamplingStoreFactory
+func (f *Factory) CreateSamplingStore() (samplingstore.Store, error) {
+	return cSamplingStore.New(f.primarySession, f.primaryMetricsFactory, f.logger), nil
+}
+
+// Close implements io.Closer and closes the sessions of the primary, archive and tenant keyspaces
+func (f *Factory) Close() error {
+	f.tenantsLock.Lock()
+	defer f.tenantsLock.Unlock()
+	for tenant, session := range f.tenantSessions {
+		session.Close()
+		delete(f.tenantSessions, tenant)
+	}
+	if f.archiveSession != nil {
+		f.archiveSession.Close()
+	}
+	if f.primarySession != nil {
+		f.primarySession.Close()
+	}
+	return nil
+}
+
 func writerOptions(opts *Options) ([]cSpanStore.Option, error) {
 	var tagFilters []dbmodel.TagFilter
 
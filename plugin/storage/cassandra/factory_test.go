@@ -17,6 +17,7 @@ package cassandra
 
 import (
 	"errors"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -34,6 +35,8 @@ import (
 
 var _ storage.Factory = new(Factory)
 var _ storage.ArchiveFactory = new(Factory)
+var _ storage.SamplingStoreFactory = new(Factory)
+var _ storage.TenantFactory = new(Factory)
 
 type mockSessionBuilder struct {
 	session *mocks.Session
@@ -92,6 +95,12 @@ func TestCassandraFactory(t *testing.T) {
 	_, err = f.CreateArchiveSpanWriter()
 	assert.EqualError(t, err, "archive storage not configured")
 
+	_, err = f.CreateLock()
+	assert.NoError(t, err)
+
+	_, err = f.CreateSamplingStore()
+	assert.NoError(t, err)
+
 	f.archiveConfig = newMockSessionBuilder(session, nil)
 	assert.NoError(t, f.Initialize(metrics.NullFactory, zap.NewNop()))
 
@@ -102,6 +111,79 @@ func TestCassandraFactory(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestCassandraFactoryTenants(t *testing.T) {
+	f := NewFactory()
+	v, command := config.Viperize(f.AddFlags)
+	command.ParseFlags([]string{"--cassandra.keyspace=jaeger"})
+	f.InitFromViper(v)
+
+	var (
+		session = &mocks.Session{}
+		query   = &mocks.Query{}
+	)
+	session.On("Query", mock.AnythingOfType("string"), mock.Anything).Return(query)
+	query.On("Exec").Return(nil)
+	f.primaryConfig = newMockSessionBuilder(session, nil)
+	assert.NoError(t, f.Initialize(metrics.NullFactory, zap.NewNop()))
+
+	var keyspaces []string
+	f.tenantConfig = func(keyspace string) cassCfg.SessionBuilder {
+		keyspaces = append(keyspaces, keyspace)
+		if keyspace == "jaeger_broken" {
+			return newMockSessionBuilder(nil, errors.New("made-up error"))
+		}
+		return newMockSessionBuilder(session, nil)
+	}
+
+	_, err := f.CreateTenantSpanWriter("acme")
+	assert.NoError(t, err)
+	_, err = f.CreateTenantSpanReader("acme")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"jaeger_acme"}, keyspaces)
+
+	_, err = f.CreateTenantSpanReader("broken")
+	assert.EqualError(t, err, "made-up error")
+	_, err = f.CreateTenantSpanWriter("Acme-Corp")
+	assert.EqualError(t, err, `invalid tenant "Acme-Corp": Cassandra tenants must match ^[a-z0-9_]+$`)
+	_, err = f.CreateTenantSpanWriter("a_tenant_with_a_name_longer_than_cassandra_allows")
+	assert.Error(t, err)
+
+	cfg := f.tenantKeyspaceConfig("jaeger_acme").(*cassCfg.Configuration)
+	assert.Equal(t, "jaeger_acme", cfg.Keyspace)
+	assert.Equal(t, "jaeger", f.Options.GetPrimary().Keyspace)
+}
+
+func TestCassandraFactoryClose(t *testing.T) {
+	f := NewFactory()
+	v, command := config.Viperize(f.AddFlags)
+	command.ParseFlags([]string{"--cassandra.keyspace=jaeger", "--cassandra-archive.enabled=true"})
+	f.InitFromViper(v)
+
+	primary, archive := &mocks.Session{}, &mocks.Session{}
+	tenantSessions := map[string]*mocks.Session{"jaeger_acme": {}, "jaeger_globex": {}}
+	for _, session := range []*mocks.Session{primary, archive, tenantSessions["jaeger_acme"], tenantSessions["jaeger_globex"]} {
+		session.On("Close").Return()
+	}
+	f.primaryConfig = newMockSessionBuilder(primary, nil)
+	f.archiveConfig = newMockSessionBuilder(archive, nil)
+	f.tenantConfig = func(keyspace string) cassCfg.SessionBuilder {
+		return newMockSessionBuilder(tenantSessions[keyspace], nil)
+	}
+	assert.NoError(t, f.Initialize(metrics.NullFactory, zap.NewNop()))
+	for _, tenant := range []string{"acme", "globex"} {
+		_, err := f.tenantSession(tenant)
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, io.Closer(f).Close())
+	primary.AssertCalled(t, "Close")
+	archive.AssertCalled(t, "Close")
+	for _, session := range tenantSessions {
+		session.AssertCalled(t, "Close")
+	}
+	assert.Empty(t, f.tenantSessions)
+}
+
 func TestExclusiveWhitelistBlacklist(t *testing.T) {
 	logger, logBuf := testutils.NewLogger()
 	f := NewFactory()
@@ -70,6 +70,10 @@ const (
 	limitMultiple = 3
 )
 
+// maxTagPredicateTraces is the max number of candidate traces loaded to be matched against the tag
+// predicates that cannot use the tag index, so that a query never loads every trace of a service
+var maxTagPredicateTraces = 2000
+
 var (
 	// ErrServiceNameNotSet occurs when attempting to query with an empty service name
 	ErrServiceNameNotSet = errors.New("service Name must be set")
@@ -217,7 +221,8 @@ func validateQuery(p *spanstore.TraceQueryParameters) error {
 	if p == nil {
 		return ErrMalformedRequestObject
 	}
-	if p.ServiceName == "" && len(p.Tags) > 0 {
+	hasTags := len(p.Tags) > 0 || len(p.TagPredicates) > 0
+	if p.ServiceName == "" && hasTags {
 		return ErrServiceNameNotSet
 	}
 	if p.StartTimeMin.IsZero() || p.StartTimeMax.IsZero() {
@@ -229,7 +234,7 @@ func validateQuery(p *spanstore.TraceQueryParameters) error {
 	if p.DurationMin != 0 && p.DurationMax != 0 && p.DurationMin > p.DurationMax {
 		return ErrDurationMinGreaterThanMax
 	}
-	if (p.DurationMin != 0 || p.DurationMax != 0) && len(p.Tags) > 0 {
+	if (p.DurationMin != 0 || p.DurationMax != 0) && hasTags {
 		return ErrDurationAndTagQueryNotSupported
 	}
 	return nil
@@ -237,10 +242,13 @@ func validateQuery(p *spanstore.TraceQueryParameters) error {
 
 // FindTraces retrieves traces that match the traceQuery
 func (s *SpanReader) FindTraces(ctx context.Context, traceQuery *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
-	uniqueTraceIDs, err := s.FindTraceIDs(ctx, traceQuery)
+	uniqueTraceIDs, traces, err := s.findTraces(ctx, traceQuery)
 	if err != nil {
 		return nil, err
 	}
+	if traces != nil {
+		return traces, nil
+	}
 	var retMe []*model.Trace
 	for _, traceID := range uniqueTraceIDs {
 		jTrace, err := s.GetTrace(ctx, traceID)
@@ -255,16 +263,48 @@ func (s *SpanReader) FindTraces(ctx context.Context, traceQuery *spanstore.Trace
 
 // FindTraceIDs retrieve traceIDs that match the traceQuery
 func (s *SpanReader) FindTraceIDs(ctx context.Context, traceQuery *spanstore.TraceQueryParameters) ([]model.TraceID, error) {
-	if err := validateQuery(traceQuery); err != nil {
+	traceIDs, _, err := s.findTraces(ctx, traceQuery)
+	if err != nil {
 		return nil, err
 	}
+	return traceIDs, nil
+}
+
+// findTraces retrieves the TraceIDs that match the traceQuery. It also returns the traces if it had to load
+// them to evaluate the tag predicates which cannot be answered by the tag index, i.e. all but the equality ones.
+func (s *SpanReader) findTraces(ctx context.Context, traceQuery *spanstore.TraceQueryParameters) ([]model.TraceID, []*model.Trace, error) {
+	if err := validateQuery(traceQuery); err != nil {
+		return nil, nil, err
+	}
 	if traceQuery.NumTraces == 0 {
 		traceQuery.NumTraces = defaultNumTraces
 	}
 
-	dbTraceIDs, err := s.findTraceIDs(ctx, traceQuery)
+	indexQuery := *traceQuery
+	indexQuery.TagPredicates = nil
+	var tagPredicates []spanstore.TagPredicate
+	for _, predicate := range traceQuery.TagPredicates {
+		if predicate.Operator == spanstore.TagEquals {
+			indexQuery.TagPredicates = append(indexQuery.TagPredicates, predicate)
+		} else {
+			tagPredicates = append(tagPredicates, predicate)
+		}
+	}
+	tagMatchers, err := spanstore.NewTagMatchers(tagPredicates)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if len(tagMatchers) > 0 && indexQuery.NumTraces < maxTagPredicateTraces {
+		// traceQuery.NumTraces is applied once the traces are filtered by the tag matchers
+		indexQuery.NumTraces = maxTagPredicateTraces
+	}
+
+	dbTraceIDs, err := s.findTraceIDs(ctx, &indexQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tagMatchers) > 0 {
+		return s.filterTraces(ctx, dbTraceIDs, traceQuery, tagMatchers)
 	}
 
 	var traceIDs []model.TraceID
@@ -274,7 +314,34 @@ func (s *SpanReader) FindTraceIDs(ctx context.Context, traceQuery *spanstore.Tra
 		}
 		traceIDs = append(traceIDs, t.ToDomain())
 	}
-	return traceIDs, nil
+	return traceIDs, nil, nil
+}
+
+// filterTraces loads the candidate traces until it finds traceQuery.NumTraces traces in which
+// each tag matcher is satisfied by a span of the queried service.
+func (s *SpanReader) filterTraces(
+	ctx context.Context,
+	dbTraceIDs dbmodel.UniqueTraceIDs,
+	traceQuery *spanstore.TraceQueryParameters,
+	tagMatchers []*spanstore.TagMatcher,
+) ([]model.TraceID, []*model.Trace, error) {
+	traceIDs := make([]model.TraceID, 0, traceQuery.NumTraces)
+	traces := make([]*model.Trace, 0, traceQuery.NumTraces)
+	for t := range dbTraceIDs {
+		if len(traces) >= traceQuery.NumTraces {
+			break
+		}
+		trace, err := s.readTrace(ctx, t)
+		if err != nil {
+			s.logger.Error("Failure to read trace", zap.String("trace_id", t.ToDomain().String()), zap.Error(err))
+			continue
+		}
+		if spanstore.MatchTrace(trace, traceQuery.ServiceName, tagMatchers) {
+			traceIDs = append(traceIDs, t.ToDomain())
+			traces = append(traces, trace)
+		}
+	}
+	return traceIDs, traces, nil
 }
 
 func (s *SpanReader) findTraceIDs(ctx context.Context, traceQuery *spanstore.TraceQueryParameters) (dbmodel.UniqueTraceIDs, error) {
@@ -287,7 +354,7 @@ func (s *SpanReader) findTraceIDs(ctx context.Context, traceQuery *spanstore.Tra
 		if err != nil {
 			return nil, err
 		}
-		if len(traceQuery.Tags) > 0 {
+		if len(traceQuery.Tags) > 0 || len(traceQuery.TagPredicates) > 0 {
 			tagTraceIds, err := s.queryByTagsAndLogs(ctx, traceQuery)
 			if err != nil {
 				return nil, err
@@ -299,7 +366,7 @@ func (s *SpanReader) findTraceIDs(ctx context.Context, traceQuery *spanstore.Tra
 		}
 		return traceIds, nil
 	}
-	if len(traceQuery.Tags) > 0 {
+	if len(traceQuery.Tags) > 0 || len(traceQuery.TagPredicates) > 0 {
 		return s.queryByTagsAndLogs(ctx, traceQuery)
 	}
 	return s.queryByService(ctx, traceQuery)
@@ -309,8 +376,16 @@ func (s *SpanReader) queryByTagsAndLogs(ctx context.Context, tq *spanstore.Trace
 	span, ctx := startSpanForQuery(ctx, "queryByTagsAndLogs", queryByTag)
 	defer span.Finish()
 
-	results := make([]dbmodel.UniqueTraceIDs, 0, len(tq.Tags))
+	tags := make([]spanstore.TagPredicate, 0, len(tq.Tags)+len(tq.TagPredicates))
 	for k, v := range tq.Tags {
+		tags = append(tags, spanstore.TagPredicate{Key: k, Operator: spanstore.TagEquals, Value: v})
+	}
+	// findTraces leaves only the predicates that are exact matches to the tag index
+	tags = append(tags, tq.TagPredicates...)
+
+	results := make([]dbmodel.UniqueTraceIDs, 0, len(tags))
+	for _, tag := range tags {
+		k, v := tag.Key, tag.Value
 		childSpan, _ := opentracing.StartSpanFromContext(ctx, "queryByTag")
 		childSpan.LogFields(otlog.String("tag.key", k), otlog.String("tag.value", v))
 		query := s.session.Query(
@@ -178,6 +178,7 @@ func TestSpanReaderFindTraces(t *testing.T) {
 		caption                           string
 		numTraces                         int
 		queryTags                         bool
+		queryTagPredicates                bool
 		queryOperation                    bool
 		queryDuration                     bool
 		mainQueryError                    error
@@ -198,6 +199,17 @@ func TestSpanReaderFindTraces(t *testing.T) {
 			expectedCount: 2,
 			queryTags:     true,
 		},
+		{
+			caption:            "tag predicate query",
+			expectedCount:      2,
+			queryTagPredicates: true,
+		},
+		{
+			caption:            "operation name and tag predicate query",
+			queryTagPredicates: true,
+			queryOperation:     true,
+			expectedCount:      2,
+		},
 		{
 			caption:       "with limit",
 			numTraces:     1,
@@ -375,6 +387,9 @@ func TestSpanReaderFindTraces(t *testing.T) {
 					queryParams.Tags = make(map[string]string)
 					queryParams.Tags["x"] = "y"
 				}
+				if testCase.queryTagPredicates {
+					queryParams.TagPredicates = []spanstore.TagPredicate{{Key: "x", Operator: spanstore.TagEquals, Value: "y"}}
+				}
 				if testCase.queryOperation {
 					queryParams.OperationName = "operation-b"
 				}
@@ -434,3 +449,88 @@ func TestTraceQueryParameterValidation(t *testing.T) {
 	err = validateQuery(tsp)
 	assert.EqualError(t, err, ErrStartAndEndTimeNotSet.Error())
 }
+
+func TestTagPredicateValidation(t *testing.T) {
+	tsp := &spanstore.TraceQueryParameters{
+		TagPredicates: []spanstore.TagPredicate{{Key: "http.status_code", Operator: spanstore.TagEquals, Value: "500"}},
+		StartTimeMin:  time.Now().Add(-1 * time.Hour),
+		StartTimeMax:  time.Now(),
+	}
+	err := validateQuery(tsp)
+	assert.EqualError(t, err, ErrServiceNameNotSet.Error())
+
+	tsp.ServiceName = "serviceName"
+	assert.NoError(t, validateQuery(tsp))
+
+	tsp.DurationMin = time.Minute
+	err = validateQuery(tsp)
+	assert.EqualError(t, err, ErrDurationAndTagQueryNotSupported.Error())
+
+	tsp.DurationMin = 0
+	tsp.TagPredicates = []spanstore.TagPredicate{{Key: "http.status_code", Operator: spanstore.TagGreaterThanOrEqual, Value: "500"}}
+	assert.NoError(t, validateQuery(tsp))
+}
+
+func TestSpanReaderFindTracesWithTagPredicates(t *testing.T) {
+	statusCodes := map[uint64]int64{1: 503, 2: 200, 3: 500}
+	withSpanReader(func(r *spanReaderTest) {
+		// the candidates are looked up by service, the tag index only answers the equality predicates
+		candidates := []uint64{1, 2, 3}
+		indexIter := &mocks.Iterator{}
+		indexIter.On("Scan", mock.MatchedBy(func(args []interface{}) bool {
+			if len(candidates) == 0 {
+				return false
+			}
+			*args[0].(*dbmodel.TraceID) = dbmodel.TraceIDFromDomain(model.NewTraceID(0, candidates[0]))
+			candidates = candidates[1:]
+			return true
+		})).Return(true)
+		indexIter.On("Scan", matchEverything()).Return(false)
+		indexIter.On("Close").Return(nil)
+		indexQuery := &mocks.Query{}
+		indexQuery.On("PageSize", 0).Return(indexQuery)
+		indexQuery.On("Iter").Return(indexIter)
+		r.session.On("Query", stringMatcher(queryByServiceName), mock.MatchedBy(func(v []interface{}) bool {
+			return v[len(v)-1] == maxTagPredicateTraces*limitMultiple
+		})).Return(indexQuery)
+
+		for low, statusCode := range statusCodes {
+			dbSpan := dbmodel.FromDomain(&model.Span{
+				TraceID: model.NewTraceID(0, low),
+				Tags:    model.KeyValues{model.Int64("http.status_code", statusCode)},
+				Process: model.NewProcess("service-a", nil),
+			})
+			loaded := false
+			loadIter := &mocks.Iterator{}
+			loadIter.On("Scan", mock.MatchedBy(func(args []interface{}) bool {
+				if loaded {
+					return false
+				}
+				loaded = true
+				*args[0].(*dbmodel.TraceID) = dbSpan.TraceID
+				*args[7].(*[]dbmodel.KeyValue) = dbSpan.Tags
+				*args[10].(*dbmodel.Process) = dbSpan.Process
+				return true
+			})).Return(true)
+			loadIter.On("Scan", matchEverything()).Return(false)
+			loadIter.On("Close").Return(nil)
+			loadQuery := &mocks.Query{}
+			loadQuery.On("Iter").Return(loadIter)
+			r.session.On("Query", stringMatcher(querySpanByTraceID), mock.MatchedBy(func(v []interface{}) bool {
+				return v[0] == dbSpan.TraceID
+			})).Return(loadQuery).Once()
+		}
+
+		traces, err := r.reader.FindTraces(context.Background(), &spanstore.TraceQueryParameters{
+			ServiceName:   "service-a",
+			TagPredicates: []spanstore.TagPredicate{{Key: "http.status_code", Operator: spanstore.TagGreaterThanOrEqual, Value: "500"}},
+			StartTimeMin:  time.Now().Add(-time.Hour),
+			StartTimeMax:  time.Now(),
+			NumTraces:     1,
+		})
+		require.NoError(t, err)
+		// only one of the two matching traces is returned
+		require.Len(t, traces, 1)
+		assert.True(t, traces[0].Spans[0].Tags[0].Int64() >= 500)
+	})
+}
@@ -18,6 +18,7 @@ package es
 import (
 	"flag"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -39,6 +40,9 @@ const (
 	archiveNamespace = "es-archive"
 )
 
+// validTenant restricts tenants to the characters allowed in index names
+var validTenant = regexp.MustCompile("^[a-z0-9][a-z0-9_-]*$")
+
 // Factory implements storage.Factory for Elasticsearch backend.
 type Factory struct {
 	Options *Options
@@ -100,12 +104,42 @@ func (f *Factory) Initialize(metricsFactory metrics.Factory, logger *zap.Logger)
 
 // CreateSpanReader implements storage.Factory
 func (f *Factory) CreateSpanReader() (spanstore.Reader, error) {
-	return createSpanReader(f.metricsFactory, f.logger, f.primaryClient, f.primaryConfig, false)
+	return createSpanReader(f.metricsFactory, f.logger, f.primaryClient, f.primaryConfig, f.primaryConfig.GetIndexPrefix(), false)
 }
 
 // CreateSpanWriter implements storage.Factory
 func (f *Factory) CreateSpanWriter() (spanstore.Writer, error) {
-	return createSpanWriter(f.metricsFactory, f.logger, f.primaryClient, f.primaryConfig, false)
+	return createSpanWriter(f.metricsFactory, f.logger, f.primaryClient, f.primaryConfig, f.primaryConfig.GetIndexPrefix(), false)
+}
+
+// CreateTenantSpanReader implements storage.TenantFactory
+func (f *Factory) CreateTenantSpanReader(tenant string) (spanstore.Reader, error) {
+	indexPrefix, err := tenantIndexPrefix(f.primaryConfig.GetIndexPrefix(), tenant)
+	if err != nil {
+		return nil, err
+	}
+	return createSpanReader(f.metricsFactory, f.logger, f.primaryClient, f.primaryConfig, indexPrefix, false)
+}
+
+// CreateTenantSpanWriter implements storage.TenantFactory
+func (f *Factory) CreateTenantSpanWriter(tenant string) (spanstore.Writer, error) {
+	indexPrefix, err := tenantIndexPrefix(f.primaryConfig.GetIndexPrefix(), tenant)
+	if err != nil {
+		return nil, err
+	}
+	return createSpanWriter(f.metricsFactory, f.logger, f.primaryClient, f.primaryConfig, indexPrefix, false)
+}
+
+// tenantIndexPrefix returns the prefix of the indices holding the spans of the tenant,
+// e.g. "prod-acme" for the tenant "acme" and the index prefix "prod".
+func tenantIndexPrefix(indexPrefix, tenant string) (string, error) {
+	if !validTenant.MatchString(tenant) {
+		return "", fmt.Errorf("invalid tenant %q: Elasticsearch tenants must match %s", tenant, validTenant)
+	}
+	if indexPrefix == "" {
+		return tenant, nil
+	}
+	return indexPrefix + "-" + tenant, nil
 }
 
 // CreateDependencyReader implements storage.Factory
@@ -119,7 +153,7 @@ func (f *Factory) CreateArchiveSpanReader() (spanstore.Reader, error) {
 	if !f.archiveConfig.IsStorageEnabled() {
 		return nil, nil
 	}
-	return createSpanReader(f.metricsFactory, f.logger, f.archiveClient, f.archiveConfig, true)
+	return createSpanReader(f.metricsFactory, f.logger, f.archiveClient, f.archiveConfig, f.archiveConfig.GetIndexPrefix(), true)
 }
 
 // CreateArchiveSpanWriter implements storage.ArchiveFactory
@@ -127,7 +161,7 @@ func (f *Factory) CreateArchiveSpanWriter() (spanstore.Writer, error) {
 	if !f.archiveConfig.IsStorageEnabled() {
 		return nil, nil
 	}
-	return createSpanWriter(f.metricsFactory, f.logger, f.archiveClient, f.archiveConfig, true)
+	return createSpanWriter(f.metricsFactory, f.logger, f.archiveClient, f.archiveConfig, f.archiveConfig.GetIndexPrefix(), true)
 }
 
 func createSpanReader(
@@ -135,6 +169,7 @@ func createSpanReader(
 	logger *zap.Logger,
 	client es.Client,
 	cfg config.ClientBuilder,
+	indexPrefix string,
 	archive bool,
 ) (spanstore.Reader, error) {
 	return esSpanStore.NewSpanReader(esSpanStore.SpanReaderParams{
@@ -143,7 +178,7 @@ func createSpanReader(
 		MetricsFactory:      mFactory,
 		MaxNumSpans:         cfg.GetMaxNumSpans(),
 		MaxSpanAge:          cfg.GetMaxSpanAge(),
-		IndexPrefix:         cfg.GetIndexPrefix(),
+		IndexPrefix:         indexPrefix,
 		TagDotReplacement:   cfg.GetTagDotReplacement(),
 		UseReadWriteAliases: cfg.GetUseReadWriteAliases(),
 		Archive:             archive,
@@ -155,6 +190,7 @@ func createSpanWriter(
 	logger *zap.Logger,
 	client es.Client,
 	cfg config.ClientBuilder,
+	indexPrefix string,
 	archive bool,
 ) (spanstore.Writer, error) {
 	var tags []string
@@ -171,7 +207,7 @@ func createSpanWriter(
 		Client:              client,
 		Logger:              logger,
 		MetricsFactory:      mFactory,
-		IndexPrefix:         cfg.GetIndexPrefix(),
+		IndexPrefix:         indexPrefix,
 		AllTagsAsFields:     cfg.GetAllTagsAsFields(),
 		TagKeysAsFields:     tags,
 		TagDotReplacement:   cfg.GetTagDotReplacement(),
@@ -38,6 +38,7 @@ import (
 )
 
 var _ storage.Factory = new(Factory)
+var _ storage.TenantFactory = new(Factory)
 
 type mockClientBuilder struct {
 	escfg.Configuration
@@ -92,6 +93,38 @@ func TestElasticsearchFactory(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestElasticsearchFactoryTenants(t *testing.T) {
+	f := NewFactory()
+	f.primaryConfig = &mockClientBuilder{}
+	f.archiveConfig = &mockClientBuilder{}
+	assert.NoError(t, f.Initialize(metrics.NullFactory, zap.NewNop()))
+
+	_, err := f.CreateTenantSpanReader("acme")
+	assert.NoError(t, err)
+	_, err = f.CreateTenantSpanWriter("acme")
+	assert.NoError(t, err)
+
+	_, err = f.CreateTenantSpanReader("Acme")
+	assert.EqualError(t, err, `invalid tenant "Acme": Elasticsearch tenants must match ^[a-z0-9][a-z0-9_-]*$`)
+	_, err = f.CreateTenantSpanWriter("")
+	assert.Error(t, err)
+}
+
+func TestTenantIndexPrefix(t *testing.T) {
+	prefix, err := tenantIndexPrefix("", "acme")
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", prefix)
+
+	prefix, err = tenantIndexPrefix("prod", "acme")
+	assert.NoError(t, err)
+	assert.Equal(t, "prod-acme", prefix)
+
+	for _, tenant := range []string{"", "-acme", "acme*", "ac me", "ACME"} {
+		_, err = tenantIndexPrefix("prod", tenant)
+		assert.Error(t, err, tenant)
+	}
+}
+
 func TestElasticsearchTagsFileDoNotExist(t *testing.T) {
 	f := NewFactory()
 	mockConf := &mockClientBuilder{}
@@ -21,6 +21,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/olivere/elastic"
@@ -37,13 +41,16 @@ import (
 )
 
 const (
-	spanIndex               = "jaeger-span-"
-	serviceIndex            = "jaeger-service-"
-	archiveIndexSuffix      = "archive"
-	archiveReadIndexSuffix  = archiveIndexSuffix + "-read"
-	archiveWriteIndexSuffix = archiveIndexSuffix + "-write"
-	traceIDAggregation      = "traceIDs"
-	indexPrefixSeparator    = "-"
+	spanIndex                 = "jaeger-span-"
+	serviceIndex              = "jaeger-service-"
+	archiveIndexSuffix        = "archive"
+	archiveReadIndexSuffix    = archiveIndexSuffix + "-read"
+	archiveWriteIndexSuffix   = archiveIndexSuffix + "-write"
+	traceIDAggregation        = "traceIDs"
+	operationStatsAggregation = "operationStats"
+	latencyAggregation        = "latency"
+	errorsAggregation         = "errors"
+	indexPrefixSeparator      = "-"
 
 	traceIDField           = "traceID"
 	durationField          = "duration"
@@ -57,6 +64,12 @@ const (
 	nestedLogFieldsField   = "logs.fields"
 	tagKeyField            = "key"
 	tagValueField          = "value"
+	errorTagKey            = "error"
+
+	// numericTagScript compares the value of a tag, indexed as a keyword, with a number.
+	numericTagScript = "if (!doc.containsKey(params.field) || doc[params.field].size() == 0) { return false; } " +
+		"try { return Double.parseDouble(doc[params.field].value) %s params.value; } " +
+		"catch (NumberFormatException e) { return false; }"
 
 	defaultDocCount  = 10000 // the default elasticsearch allowed limit
 	defaultNumTraces = 100
@@ -438,9 +451,14 @@ func validateQuery(p *spanstore.TraceQueryParameters) error {
 	if p == nil {
 		return ErrMalformedRequestObject
 	}
-	if p.ServiceName == "" && len(p.Tags) > 0 {
+	if p.ServiceName == "" && (len(p.Tags) > 0 || len(p.TagPredicates) > 0) {
 		return ErrServiceNameNotSet
 	}
+	for _, predicate := range p.TagPredicates {
+		if err := validateTagPredicate(predicate); err != nil {
+			return err
+		}
+	}
 	if p.StartTimeMin.IsZero() || p.StartTimeMax.IsZero() {
 		return ErrStartAndEndTimeNotSet
 	}
@@ -535,6 +553,109 @@ func (s *SpanReader) findTraceIDs(ctx context.Context, traceQuery *spanstore.Tra
 	return bucketToStringArray(traceIDBuckets)
 }
 
+// GetOperationStats implements spanstore.OperationStatsReader by aggregating the spans of the service in Elasticsearch.
+func (s *SpanReader) GetOperationStats(ctx context.Context, query *spanstore.OperationStatsQueryParameters) ([]spanstore.OperationStats, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GetOperationStats")
+	defer span.Finish()
+	//  Below is the JSON body to our HTTP GET request to ElasticSearch. This function creates this.
+	// {
+	//      "size": 0,
+	//      "query": {
+	//        "bool": {
+	//          "must": [
+	//            { "match": { "process.serviceName": "service1" }},
+	//            { "range":  { "startTime": { "gte": 0, "lte": 90000000000000000 }}}
+	//          ]
+	//        }
+	//      },
+	//      "aggs": {
+	//        "operationStats": {
+	//          "terms": { "size": 10000, "field": "operationName" },
+	//          "aggs": {
+	//            "latency": { "percentiles": { "field": "duration", "percents": [50, 99] }},
+	//            "errors": { "filter": { "bool": { "should": [ <tag error=true in tag or tags> ] }}}
+	//          }
+	//        }
+	//      }
+	//  }
+	boolQuery := elastic.NewBoolQuery().Must(
+		s.buildServiceNameQuery(query.ServiceName),
+		s.buildStartTimeQuery(query.StartTimeMin, query.StartTimeMax),
+	)
+	aggregation := elastic.NewTermsAggregation().
+		Size(defaultDocCount).
+		Field(operationNameField).
+		SubAggregation(errorsAggregation, elastic.NewFilterAggregation().Filter(s.buildErrorQuery()))
+	if len(query.Percentiles) > 0 {
+		aggregation.SubAggregation(latencyAggregation, elastic.NewPercentilesAggregation().
+			Field(durationField).
+			Percentiles(query.Percentiles...))
+	}
+	jaegerIndices := s.timeRangeIndices(s.spanIndexPrefix, query.StartTimeMin, query.StartTimeMax)
+
+	searchResult, err := s.client.Search(jaegerIndices...).
+		Size(0). // set to 0 because we don't want actual documents.
+		Aggregation(operationStatsAggregation, aggregation).
+		IgnoreUnavailable(true).
+		Query(boolQuery).
+		Do(ctx)
+	if err != nil {
+		logErrorToSpan(span, err)
+		return nil, fmt.Errorf("search operation stats failed: %w", err)
+	}
+	if searchResult.Aggregations == nil {
+		return []spanstore.OperationStats{}, nil
+	}
+	bucket, found := searchResult.Aggregations.Terms(operationStatsAggregation)
+	if !found {
+		return nil, errors.New("could not find aggregation of " + operationStatsAggregation)
+	}
+
+	stats := make([]spanstore.OperationStats, 0, len(bucket.Buckets))
+	for _, item := range bucket.Buckets {
+		operation, ok := item.Key.(string)
+		if !ok {
+			return nil, fmt.Errorf("non-string key found in aggregation of %s", operationStatsAggregation)
+		}
+		opStats := spanstore.OperationStats{
+			Operation: operation,
+			Count:     item.DocCount,
+			Latencies: make([]time.Duration, len(query.Percentiles)),
+		}
+		if errorsBucket, ok := item.Filter(errorsAggregation); ok {
+			opStats.Errors = errorsBucket.DocCount
+		}
+		if latency, ok := item.Percentiles(latencyAggregation); ok {
+			for i, percentile := range query.Percentiles {
+				opStats.Latencies[i] = model.MicrosecondsAsDuration(uint64(latency.Values[percentileKey(percentile)]))
+			}
+		}
+		stats = append(stats, opStats)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Operation < stats[j].Operation
+	})
+	return stats, nil
+}
+
+// percentileKey returns the key of the percentile in the response of a percentiles aggregation,
+// which Elasticsearch formats as a double, e.g. "50.0" or "99.9".
+func percentileKey(percentile float64) string {
+	key := strconv.FormatFloat(percentile, 'f', -1, 64)
+	if !strings.Contains(key, ".") {
+		key += ".0"
+	}
+	return key
+}
+
+// buildErrorQuery matches the spans tagged with error=true, like the UI does.
+func (s *SpanReader) buildErrorQuery() elastic.Query {
+	return elastic.NewBoolQuery().Should(
+		s.buildObjectQuery(objectTagsField, errorTagKey, "true"),
+		s.buildNestedQuery(nestedTagsField, errorTagKey, "true"),
+	)
+}
+
 func (s *SpanReader) buildTraceIDAggregation(numOfTraces int) elastic.Aggregation {
 	return elastic.NewTermsAggregation().
 		Size(numOfTraces).
@@ -577,6 +698,10 @@ func (s *SpanReader) buildFindTraceIDsQuery(traceQuery *spanstore.TraceQueryPara
 		tagQuery := s.buildTagQuery(k, v)
 		boolQuery.Must(tagQuery)
 	}
+
+	for _, predicate := range traceQuery.TagPredicates {
+		boolQuery.Must(s.buildTagPredicateQuery(predicate))
+	}
 	return boolQuery
 }
 
@@ -618,6 +743,122 @@ func (s *SpanReader) buildTagQuery(k string, v string) elastic.Query {
 	return elastic.NewBoolQuery().Should(queries...)
 }
 
+// buildTagPredicateQuery matches the spans with a tag, process tag or log field satisfying the predicate.
+// The values of the tags are indexed as keywords, so the numeric operators are evaluated by a script.
+func (s *SpanReader) buildTagPredicateQuery(predicate spanstore.TagPredicate) elastic.Query {
+	objectTagListLen := len(objectTagFieldList)
+	queries := make([]elastic.Query, len(nestedTagFieldList)+objectTagListLen)
+	kd := s.spanConverter.ReplaceDot(predicate.Key)
+	for i := range objectTagFieldList {
+		valueField := fmt.Sprintf("%s.%s", objectTagFieldList[i], kd)
+		queries[i] = buildTagValueQuery(valueField, predicate)
+	}
+	for i := range nestedTagFieldList {
+		keyField := fmt.Sprintf("%s.%s", nestedTagFieldList[i], tagKeyField)
+		valueField := fmt.Sprintf("%s.%s", nestedTagFieldList[i], tagValueField)
+		tagBoolQuery := elastic.NewBoolQuery().Must(
+			elastic.NewMatchQuery(keyField, predicate.Key),
+			buildTagValueQuery(valueField, predicate),
+		)
+		queries[i+objectTagListLen] = elastic.NewNestedQuery(nestedTagFieldList[i], tagBoolQuery)
+	}
+	return elastic.NewBoolQuery().Should(queries...)
+}
+
+func buildTagValueQuery(field string, predicate spanstore.TagPredicate) elastic.Query {
+	switch predicate.Operator {
+	case spanstore.TagNotEquals:
+		return elastic.NewBoolQuery().
+			Must(elastic.NewExistsQuery(field)).
+			MustNot(elastic.NewTermQuery(field, predicate.Value))
+	case spanstore.TagMatches:
+		return elastic.NewRegexpQuery(field, predicate.Value)
+	case spanstore.TagExists:
+		return elastic.NewExistsQuery(field)
+	case spanstore.TagGreaterThan, spanstore.TagGreaterThanOrEqual, spanstore.TagLessThan, spanstore.TagLessThanOrEqual:
+		// the value was validated by validateTagPredicate
+		value, _ := strconv.ParseFloat(predicate.Value, 64)
+		script := elastic.NewScript(fmt.Sprintf(numericTagScript, predicate.Operator)).
+			Lang("painless").
+			Params(map[string]interface{}{"field": field, "value": value})
+		return elastic.NewScriptQuery(script)
+	default:
+		return elastic.NewTermQuery(field, predicate.Value)
+	}
+}
+
+func validateTagPredicate(predicate spanstore.TagPredicate) error {
+	switch predicate.Operator {
+	case spanstore.TagEquals, spanstore.TagNotEquals, spanstore.TagExists:
+		return nil
+	case spanstore.TagMatches:
+		if err := validateTagRegexp(predicate.Value); err != nil {
+			return fmt.Errorf("invalid regular expression for tag %s: %w", predicate.Key, err)
+		}
+		return nil
+	case spanstore.TagGreaterThan, spanstore.TagGreaterThanOrEqual, spanstore.TagLessThan, spanstore.TagLessThanOrEqual:
+		if _, err := strconv.ParseFloat(predicate.Value, 64); err != nil {
+			return fmt.Errorf("invalid number for tag %s: %w", predicate.Key, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", spanstore.ErrUnsupportedTagOperator, predicate.Operator)
+	}
+}
+
+// validateTagRegexp accepts the regular expressions that match the same values with the RE2 syntax of the
+// other storages and with the Lucene syntax of the Elasticsearch regexp query. Both match the whole value,
+// but Lucene has no anchors, flags, non-capturing groups or backslash classes such as \d, and reserves
+// characters such as @, &, ~, # and " as operators, so these constructs are rejected rather than
+// silently matching different values. Reserved characters can still be matched by escaping them.
+func validateTagRegexp(pattern string) error {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return err
+	}
+	if err := validateRegexpOps(re); err != nil {
+		return err
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\':
+			i++
+			if i < len(pattern) && isAlphanumeric(pattern[i]) {
+				return fmt.Errorf("escape sequence %s is not supported by Elasticsearch", pattern[i-1:i+1])
+			}
+		case strings.IndexByte(luceneReservedChars, c) >= 0:
+			return fmt.Errorf("character %c must be escaped for Elasticsearch", c)
+		case strings.HasPrefix(pattern[i:], "(?"):
+			return errors.New("flags and non-capturing groups are not supported by Elasticsearch")
+		case strings.HasPrefix(pattern[i:], "[:"):
+			return errors.New("named character classes are not supported by Elasticsearch")
+		}
+	}
+	return nil
+}
+
+// luceneReservedChars are the operators of the Lucene regular expressions that are literals in RE2
+const luceneReservedChars = "@&~#\"<>"
+
+func validateRegexpOps(re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText:
+		return errors.New("anchors are not supported by Elasticsearch, the expression always matches the whole value")
+	case syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return errors.New("word boundaries are not supported by Elasticsearch")
+	}
+	for _, sub := range re.Sub {
+		if err := validateRegexpOps(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isAlphanumeric(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
 func (s *SpanReader) buildNestedQuery(field string, k string, v string) elastic.Query {
 	keyField := fmt.Sprintf("%s.%s", field, tagKeyField)
 	valueField := fmt.Sprintf("%s.%s", field, tagValueField)
@@ -845,6 +845,7 @@ func mockSearchService(r *spanReaderTest) *mock.Call {
 	searchService.On("Aggregation", stringMatcher(servicesAggregation), mock.AnythingOfType("*elastic.TermsAggregation")).Return(searchService)
 	searchService.On("Aggregation", stringMatcher(operationsAggregation), mock.AnythingOfType("*elastic.TermsAggregation")).Return(searchService)
 	searchService.On("Aggregation", stringMatcher(traceIDAggregation), mock.AnythingOfType("*elastic.TermsAggregation")).Return(searchService)
+	searchService.On("Aggregation", stringMatcher(operationStatsAggregation), mock.AnythingOfType("*elastic.TermsAggregation")).Return(searchService)
 	r.client.On("Search", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(searchService)
 	return searchService.On("Do", mock.MatchedBy(func(ctx context.Context) bool {
 		t := reflect.TypeOf(ctx).String()
@@ -1069,6 +1070,186 @@ func TestSpanReader_buildTagRegexEscapedQuery(t *testing.T) {
 	})
 }
 
+func TestSpanReader_buildTagPredicateQuery(t *testing.T) {
+	testCases := []struct {
+		predicate spanstore.TagPredicate
+		fixture   string
+	}{
+		{
+			predicate: spanstore.TagPredicate{Key: "http.status_code", Operator: spanstore.TagGreaterThanOrEqual, Value: "500"},
+			fixture:   "fixtures/query_04.json",
+		},
+		{
+			predicate: spanstore.TagPredicate{Key: "bat.foo", Operator: spanstore.TagNotEquals, Value: "spook"},
+			fixture:   "fixtures/query_05.json",
+		},
+	}
+	for _, tc := range testCases {
+		inStr, err := ioutil.ReadFile(tc.fixture)
+		require.NoError(t, err)
+		withSpanReader(func(r *spanReaderTest) {
+			tagQuery := r.reader.buildTagPredicateQuery(tc.predicate)
+			source, err := tagQuery.Source()
+			require.NoError(t, err)
+			// the source of scripts is a raw JSON message
+			actual, err := json.Marshal(source)
+			require.NoError(t, err)
+
+			assert.JSONEq(t, string(inStr), string(actual), tc.fixture)
+		})
+	}
+}
+
+func TestSpanReader_buildTagValueQuery(t *testing.T) {
+	testCases := []struct {
+		operator spanstore.TagOperator
+		expected string
+	}{
+		{operator: spanstore.TagEquals, expected: `{"term":{"tags.value":"spo.*"}}`},
+		{operator: spanstore.TagMatches, expected: `{"regexp":{"tags.value":{"value":"spo.*"}}}`},
+		{operator: spanstore.TagExists, expected: `{"exists":{"field":"tags.value"}}`},
+	}
+	for _, tc := range testCases {
+		query := buildTagValueQuery("tags.value", spanstore.TagPredicate{Key: "bat.foo", Operator: tc.operator, Value: "spo.*"})
+		actual, err := query.Source()
+		require.NoError(t, err)
+
+		expected := make(map[string]interface{})
+		json.Unmarshal([]byte(tc.expected), &expected)
+
+		assert.EqualValues(t, expected, actual, tc.operator)
+	}
+}
+
+func TestTagPredicateValidation(t *testing.T) {
+	tqp := &spanstore.TraceQueryParameters{
+		TagPredicates: []spanstore.TagPredicate{{Key: "http.status_code", Operator: spanstore.TagExists}},
+		StartTimeMin:  time.Now().Add(-1 * time.Hour),
+		StartTimeMax:  time.Now(),
+	}
+	assert.Equal(t, ErrServiceNameNotSet, validateQuery(tqp))
+
+	tqp.ServiceName = serviceName
+	assert.NoError(t, validateQuery(tqp))
+
+	tqp.TagPredicates = []spanstore.TagPredicate{{Key: "http.status_code", Operator: spanstore.TagGreaterThan, Value: "5xx"}}
+	assert.EqualError(t, validateQuery(tqp), `invalid number for tag http.status_code: strconv.ParseFloat: parsing "5xx": invalid syntax`)
+
+	for _, pattern := range []string{"5..", "GET|POST", `a\.b[0-9]{2,3}`, `user\@host`, "[^a-c]+"} {
+		tqp.TagPredicates = []spanstore.TagPredicate{{Key: "k", Operator: spanstore.TagMatches, Value: pattern}}
+		assert.NoError(t, validateQuery(tqp), pattern)
+	}
+	invalid := map[string]string{
+		"(":           "invalid regular expression for tag k: error parsing regexp: missing closing ): `(`",
+		"^5..$":       "invalid regular expression for tag k: anchors are not supported by Elasticsearch, the expression always matches the whole value",
+		`\bfoo`:       "invalid regular expression for tag k: word boundaries are not supported by Elasticsearch",
+		`\d+`:         `invalid regular expression for tag k: escape sequence \d is not supported by Elasticsearch`,
+		"user@host":   "invalid regular expression for tag k: character @ must be escaped for Elasticsearch",
+		"(?i)get":     "invalid regular expression for tag k: flags and non-capturing groups are not supported by Elasticsearch",
+		"(?:a|b)c":    "invalid regular expression for tag k: flags and non-capturing groups are not supported by Elasticsearch",
+		"[[:alpha:]]": "invalid regular expression for tag k: named character classes are not supported by Elasticsearch",
+	}
+	for pattern, expected := range invalid {
+		tqp.TagPredicates = []spanstore.TagPredicate{{Key: "k", Operator: spanstore.TagMatches, Value: pattern}}
+		assert.EqualError(t, validateQuery(tqp), expected, pattern)
+	}
+
+	tqp.TagPredicates = []spanstore.TagPredicate{{Key: "http.status_code", Operator: "~", Value: "5xx"}}
+	err := validateQuery(tqp)
+	assert.True(t, errors.Is(err, spanstore.ErrUnsupportedTagOperator))
+	assert.EqualError(t, err, "tag operator is not supported by the span storage: ~")
+}
+
+func TestSpanReader_buildFindTraceIDsQueryWithTagPredicates(t *testing.T) {
+	withSpanReader(func(r *spanReaderTest) {
+		predicate := spanstore.TagPredicate{Key: "http.status_code", Operator: spanstore.TagGreaterThanOrEqual, Value: "500"}
+		traceQuery := &spanstore.TraceQueryParameters{
+			StartTimeMin:  time.Time{}.Add(time.Second),
+			StartTimeMax:  time.Time{}.Add(2 * time.Second),
+			TagPredicates: []spanstore.TagPredicate{predicate},
+		}
+		actualQuery := r.reader.buildFindTraceIDsQuery(traceQuery)
+		actual, err := actualQuery.Source()
+		require.NoError(t, err)
+		expectedQuery := elastic.NewBoolQuery().
+			Must(
+				r.reader.buildStartTimeQuery(traceQuery.StartTimeMin, traceQuery.StartTimeMax),
+				r.reader.buildTagPredicateQuery(predicate))
+		expected, err := expectedQuery.Source()
+		require.NoError(t, err)
+		assert.EqualValues(t, expected, actual)
+	})
+}
+
+func TestSpanReader_GetOperationStats(t *testing.T) {
+	goodAggregations := make(map[string]*json.RawMessage)
+	rawMessage := []byte(`{"buckets": [
+		{"key": "op2", "doc_count": 10, "errors": {"doc_count": 3}, "latency": {"values": {"50.0": 1000.0, "99.9": 5000.5}}},
+		{"key": "op1", "doc_count": 2, "errors": {"doc_count": 0}, "latency": {"values": {"50.0": 20.0, "99.9": 30.0}}}
+	]}`)
+	goodAggregations[operationStatsAggregation] = (*json.RawMessage)(&rawMessage)
+	query := &spanstore.OperationStatsQueryParameters{
+		ServiceName:  serviceName,
+		StartTimeMin: time.Now().Add(-1 * time.Hour),
+		StartTimeMax: time.Now(),
+		Percentiles:  []float64{50, 99.9},
+	}
+
+	testCases := []struct {
+		caption       string
+		searchResult  *elastic.SearchResult
+		searchError   error
+		expected      []spanstore.OperationStats
+		expectedError string
+	}{
+		{
+			caption:      "aggregated stats",
+			searchResult: &elastic.SearchResult{Aggregations: elastic.Aggregations(goodAggregations)},
+			expected: []spanstore.OperationStats{
+				{Operation: "op1", Count: 2, Latencies: []time.Duration{20 * time.Microsecond, 30 * time.Microsecond}},
+				{Operation: "op2", Count: 10, Errors: 3, Latencies: []time.Duration{time.Millisecond, 5 * time.Millisecond}},
+			},
+		},
+		{
+			caption:      "no aggregations",
+			searchResult: &elastic.SearchResult{},
+			expected:     []spanstore.OperationStats{},
+		},
+		{
+			caption:       "missing aggregation",
+			searchResult:  &elastic.SearchResult{Aggregations: elastic.Aggregations(make(map[string]*json.RawMessage))},
+			expectedError: "could not find aggregation of operationStats",
+		},
+		{
+			caption:       "search error",
+			searchError:   errors.New("search failure"),
+			expectedError: "search operation stats failed: search failure",
+		},
+	}
+	for _, testCase := range testCases {
+		tc := testCase
+		t.Run(tc.caption, func(t *testing.T) {
+			withSpanReader(func(r *spanReaderTest) {
+				mockSearchService(r).Return(tc.searchResult, tc.searchError)
+				stats, err := r.reader.GetOperationStats(context.Background(), query)
+				if tc.expectedError != "" {
+					assert.EqualError(t, err, tc.expectedError)
+					assert.Nil(t, stats)
+				} else {
+					require.NoError(t, err)
+					assert.Equal(t, tc.expected, stats)
+				}
+			})
+		})
+	}
+}
+
+func TestPercentileKey(t *testing.T) {
+	assert.Equal(t, "50.0", percentileKey(50))
+	assert.Equal(t, "99.9", percentileKey(99.9))
+	assert.Equal(t, "0.5", percentileKey(0.5))
+}
+
 func TestSpanReader_GetEmptyIndex(t *testing.T) {
 	withSpanReader(func(r *spanReaderTest) {
 		mockSearchService(r).
@@ -135,6 +135,11 @@ func (f *Factory) CreateSpanWriter() (spanstore.Writer, error) {
 		}
 		writers = append(writers, writer)
 	}
+	return f.combineSpanWriters(writers), nil
+}
+
+// combineSpanWriters returns a spanstore.Writer writing to all the writers and downsampling the spans, if configured.
+func (f *Factory) combineSpanWriters(writers []spanstore.Writer) spanstore.Writer {
 	var spanWriter spanstore.Writer
 	if len(f.SpanWriterTypes) == 1 {
 		spanWriter = writers[0]
@@ -143,13 +148,57 @@ func (f *Factory) CreateSpanWriter() (spanstore.Writer, error) {
 	}
 	// Turn off DownsamplingWriter entirely if ratio == defaultDownsamplingRatio.
 	if f.DownsamplingRatio == defaultDownsamplingRatio {
-		return spanWriter, nil
+		return spanWriter
 	}
 	return spanstore.NewDownsamplingWriter(spanWriter, spanstore.DownsamplingOptions{
 		Ratio:          f.DownsamplingRatio,
 		HashSalt:       f.DownsamplingHashSalt,
 		MetricsFactory: f.metricsFactory.Namespace(metrics.NSOptions{Name: "downsampling_writer"}),
-	}), nil
+	})
+}
+
+// CreateTenantSpanReader implements storage.TenantFactory
+func (f *Factory) CreateTenantSpanReader(tenant string) (spanstore.Reader, error) {
+	factory, ok := f.factories[f.SpanReaderType]
+	if !ok {
+		return nil, fmt.Errorf("no %s backend registered for span store", f.SpanReaderType)
+	}
+	tenantFactory, ok := factory.(storage.TenantFactory)
+	if !ok {
+		return nil, storage.ErrTenancyNotSupported
+	}
+	return tenantFactory.CreateTenantSpanReader(tenant)
+}
+
+// CreateTenantSpanWriter implements storage.TenantFactory
+func (f *Factory) CreateTenantSpanWriter(tenant string) (spanstore.Writer, error) {
+	var writers []spanstore.Writer
+	for _, storageType := range f.SpanWriterTypes {
+		factory, ok := f.factories[storageType]
+		if !ok {
+			return nil, fmt.Errorf("no %s backend registered for span store", storageType)
+		}
+		tenantFactory, ok := factory.(storage.TenantFactory)
+		if !ok {
+			return nil, storage.ErrTenancyNotSupported
+		}
+		writer, err := tenantFactory.CreateTenantSpanWriter(tenant)
+		if err != nil {
+			return nil, err
+		}
+		writers = append(writers, writer)
+	}
+	return f.combineSpanWriters(writers), nil
+}
+
+// SupportsTenancy returns true if the backends of all span readers and writers implement storage.TenantFactory.
+func (f *Factory) SupportsTenancy() bool {
+	for _, storageType := range append([]string{f.SpanReaderType}, f.SpanWriterTypes...) {
+		if _, ok := f.factories[storageType].(storage.TenantFactory); !ok {
+			return false
+		}
+	}
+	return true
 }
 
 // CreateDependencyReader implements storage.Factory
@@ -229,3 +278,19 @@ func (f *Factory) CreateArchiveSpanWriter() (spanstore.Writer, error) {
 	}
 	return archive.CreateArchiveSpanWriter()
 }
+
+// CreateSamplingStoreFactory returns the backend used by adaptive sampling to store throughput and
+// probabilities. The first span writer backend implementing storage.SamplingStoreFactory is used.
+// It returns nil if none of the configured backends supports adaptive sampling.
+func (f *Factory) CreateSamplingStoreFactory() (storage.SamplingStoreFactory, error) {
+	for _, storageType := range f.SpanWriterTypes {
+		factory, ok := f.factories[storageType]
+		if !ok {
+			return nil, fmt.Errorf("no %s backend registered for span store", storageType)
+		}
+		if ssFactory, ok := factory.(storage.SamplingStoreFactory); ok {
+			return ssFactory, nil
+		}
+	}
+	return nil, nil
+}
@@ -38,6 +38,7 @@ import (
 
 var _ storage.Factory = new(Factory)
 var _ storage.ArchiveFactory = new(Factory)
+var _ storage.TenantFactory = new(Factory)
 
 func defaultCfg() FactoryConfig {
 	return FactoryConfig{
@@ -236,6 +237,62 @@ func TestCreateArchive(t *testing.T) {
 	assert.EqualError(t, err, "archive-span-writer-error")
 }
 
+func TestCreateTenant(t *testing.T) {
+	f, err := NewFactory(defaultCfg())
+	require.NoError(t, err)
+	assert.True(t, f.SupportsTenancy())
+
+	mock := &struct {
+		mocks.Factory
+		mocks.TenantFactory
+	}{}
+	f.factories[cassandraStorageType] = mock
+
+	tenantSpanReader := new(spanStoreMocks.Reader)
+	tenantSpanWriter := new(spanStoreMocks.Writer)
+	mock.TenantFactory.On("CreateTenantSpanReader", "acme").Return(tenantSpanReader, nil)
+	mock.TenantFactory.On("CreateTenantSpanWriter", "acme").Return(tenantSpanWriter, nil)
+	mock.TenantFactory.On("CreateTenantSpanWriter", "megacorp").Return(nil, errors.New("tenant-span-writer-error"))
+
+	r, err := f.CreateTenantSpanReader("acme")
+	require.NoError(t, err)
+	assert.Equal(t, tenantSpanReader, r)
+
+	w, err := f.CreateTenantSpanWriter("acme")
+	require.NoError(t, err)
+	assert.Equal(t, tenantSpanWriter, w)
+
+	_, err = f.CreateTenantSpanWriter("megacorp")
+	assert.EqualError(t, err, "tenant-span-writer-error")
+
+	f.factories[cassandraStorageType] = new(mocks.Factory)
+	assert.False(t, f.SupportsTenancy())
+	_, err = f.CreateTenantSpanReader("acme")
+	assert.Equal(t, storage.ErrTenancyNotSupported, err)
+	_, err = f.CreateTenantSpanWriter("acme")
+	assert.Equal(t, storage.ErrTenancyNotSupported, err)
+
+	delete(f.factories, cassandraStorageType)
+	_, err = f.CreateTenantSpanReader("acme")
+	assert.EqualError(t, err, "no cassandra backend registered for span store")
+	_, err = f.CreateTenantSpanWriter("acme")
+	assert.EqualError(t, err, "no cassandra backend registered for span store")
+}
+
+func TestCreateSamplingStoreFactory(t *testing.T) {
+	f, err := NewFactory(defaultCfg())
+	require.NoError(t, err)
+
+	ssFactory, err := f.CreateSamplingStoreFactory()
+	require.NoError(t, err)
+	assert.Equal(t, f.factories[cassandraStorageType], ssFactory)
+
+	f.factories[cassandraStorageType] = new(mocks.Factory)
+	ssFactory, err = f.CreateSamplingStoreFactory()
+	require.NoError(t, err)
+	assert.Nil(t, ssFactory)
+}
+
 func TestCreateError(t *testing.T) {
 	f, err := NewFactory(defaultCfg())
 	require.NoError(t, err)
@@ -274,6 +331,12 @@ func TestCreateError(t *testing.T) {
 		assert.Nil(t, w)
 		assert.EqualError(t, err, expectedErr)
 	}
+
+	{
+		ss, err := f.CreateSamplingStoreFactory()
+		assert.Nil(t, ss)
+		assert.EqualError(t, err, expectedErr)
+	}
 }
 
 type configurable struct {
@@ -20,6 +20,7 @@ import (
 	"io"
 	"time"
 
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
@@ -31,9 +32,10 @@ import (
 
 // grpcClient implements shared.StoragePlugin and reads/writes spans and dependencies
 type grpcClient struct {
-	readerClient     storage_v1.SpanReaderPluginClient
-	writerClient     storage_v1.SpanWriterPluginClient
-	depsReaderClient storage_v1.DependenciesReaderPluginClient
+	readerClient       storage_v1.SpanReaderPluginClient
+	writerClient       storage_v1.SpanWriterPluginClient
+	depsReaderClient   storage_v1.DependenciesReaderPluginClient
+	capabilitiesClient storage_v1.PluginCapabilitiesClient
 }
 
 // upgradeContextWithBearerToken turns the context into a gRPC outgoing context with bearer token
@@ -136,11 +138,15 @@ func (c *grpcClient) GetOperations(
 
 // FindTraces retrieves traces that match the traceQuery
 func (c *grpcClient) FindTraces(ctx context.Context, query *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
+	if err := c.checkTagPredicates(ctx, query); err != nil {
+		return nil, err
+	}
 	stream, err := c.readerClient.FindTraces(upgradeContextWithBearerToken(ctx), &storage_v1.FindTracesRequest{
 		Query: &storage_v1.TraceQueryParameters{
 			ServiceName:   query.ServiceName,
 			OperationName: query.OperationName,
 			Tags:          query.Tags,
+			TagPredicates: toProtoTagPredicates(query.TagPredicates),
 			StartTimeMin:  query.StartTimeMin,
 			StartTimeMax:  query.StartTimeMax,
 			DurationMin:   query.DurationMin,
@@ -174,11 +180,15 @@ func (c *grpcClient) FindTraces(ctx context.Context, query *spanstore.TraceQuery
 
 // FindTraceIDs retrieves traceIDs that match the traceQuery
 func (c *grpcClient) FindTraceIDs(ctx context.Context, query *spanstore.TraceQueryParameters) ([]model.TraceID, error) {
+	if err := c.checkTagPredicates(ctx, query); err != nil {
+		return nil, err
+	}
 	resp, err := c.readerClient.FindTraceIDs(upgradeContextWithBearerToken(ctx), &storage_v1.FindTraceIDsRequest{
 		Query: &storage_v1.TraceQueryParameters{
 			ServiceName:   query.ServiceName,
 			OperationName: query.OperationName,
 			Tags:          query.Tags,
+			TagPredicates: toProtoTagPredicates(query.TagPredicates),
 			StartTimeMin:  query.StartTimeMin,
 			StartTimeMax:  query.StartTimeMax,
 			DurationMin:   query.DurationMin,
@@ -193,6 +203,41 @@ func (c *grpcClient) FindTraceIDs(ctx context.Context, query *spanstore.TraceQue
 	return resp.TraceIDs, nil
 }
 
+// checkTagPredicates fails the queries with tag predicates if the plugin does not support them,
+// since the plugins built before tag predicates silently ignore them and would return unmatched traces.
+func (c *grpcClient) checkTagPredicates(ctx context.Context, query *spanstore.TraceQueryParameters) error {
+	if len(query.TagPredicates) == 0 {
+		return nil
+	}
+	resp, err := c.capabilitiesClient.Capabilities(upgradeContextWithBearerToken(ctx), &storage_v1.CapabilitiesRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		// the plugin predates PluginCapabilities
+		resp, err = &storage_v1.CapabilitiesResponse{}, nil
+	}
+	if err != nil {
+		return fmt.Errorf("plugin error: %w", err)
+	}
+	if !resp.TagPredicates {
+		return fmt.Errorf("%w: %s", spanstore.ErrUnsupportedTagOperator, query.TagPredicates[0].Operator)
+	}
+	return nil
+}
+
+func toProtoTagPredicates(predicates []spanstore.TagPredicate) []storage_v1.TagPredicate {
+	if len(predicates) == 0 {
+		return nil
+	}
+	protoPredicates := make([]storage_v1.TagPredicate, len(predicates))
+	for i, predicate := range predicates {
+		protoPredicates[i] = storage_v1.TagPredicate{
+			Key:      predicate.Key,
+			Operator: string(predicate.Operator),
+			Value:    predicate.Value,
+		}
+	}
+	return protoPredicates
+}
+
 // WriteSpan saves the span
 func (c *grpcClient) WriteSpan(span *model.Span) error {
 	_, err := c.writerClient.WriteSpan(context.Background(), &storage_v1.WriteSpanRequest{
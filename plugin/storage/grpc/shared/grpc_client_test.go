@@ -23,7 +23,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/proto-gen/storage_v1"
@@ -68,26 +70,30 @@ var (
 )
 
 type grpcClientTest struct {
-	client     *grpcClient
-	spanReader *grpcMocks.SpanReaderPluginClient
-	spanWriter *grpcMocks.SpanWriterPluginClient
-	depsReader *grpcMocks.DependenciesReaderPluginClient
+	client       *grpcClient
+	spanReader   *grpcMocks.SpanReaderPluginClient
+	spanWriter   *grpcMocks.SpanWriterPluginClient
+	depsReader   *grpcMocks.DependenciesReaderPluginClient
+	capabilities *grpcMocks.PluginCapabilitiesClient
 }
 
 func withGRPCClient(fn func(r *grpcClientTest)) {
 	spanReader := new(grpcMocks.SpanReaderPluginClient)
 	spanWriter := new(grpcMocks.SpanWriterPluginClient)
 	depReader := new(grpcMocks.DependenciesReaderPluginClient)
+	capabilities := new(grpcMocks.PluginCapabilitiesClient)
 
 	r := &grpcClientTest{
 		client: &grpcClient{
-			readerClient:     spanReader,
-			writerClient:     spanWriter,
-			depsReaderClient: depReader,
+			readerClient:       spanReader,
+			writerClient:       spanWriter,
+			depsReaderClient:   depReader,
+			capabilitiesClient: capabilities,
 		},
-		spanReader: spanReader,
-		spanWriter: spanWriter,
-		depsReader: depReader,
+		spanReader:   spanReader,
+		spanWriter:   spanWriter,
+		depsReader:   depReader,
+		capabilities: capabilities,
 	}
 	fn(r)
 }
@@ -271,6 +277,73 @@ func TestGRPCClientFindTraceIDs(t *testing.T) {
 	})
 }
 
+func TestGRPCClientFindTraceIDsWithTagPredicates(t *testing.T) {
+	withGRPCClient(func(r *grpcClientTest) {
+		r.capabilities.On("Capabilities", mock.Anything, &storage_v1.CapabilitiesRequest{}).
+			Return(&storage_v1.CapabilitiesResponse{TagPredicates: true}, nil)
+		r.spanReader.On("FindTraceIDs", mock.Anything, &storage_v1.FindTraceIDsRequest{
+			Query: &storage_v1.TraceQueryParameters{
+				TagPredicates: []storage_v1.TagPredicate{{Key: "http.status_code", Operator: ">=", Value: "500"}},
+			},
+		}).Return(&storage_v1.FindTraceIDsResponse{
+			TraceIDs: []model.TraceID{mockTraceID},
+		}, nil)
+
+		s, err := r.client.FindTraceIDs(context.Background(), &spanstore.TraceQueryParameters{
+			TagPredicates: []spanstore.TagPredicate{{Key: "http.status_code", Operator: spanstore.TagGreaterThanOrEqual, Value: "500"}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.TraceID{mockTraceID}, s)
+	})
+}
+
+func TestGRPCClientTagPredicatesNotSupported(t *testing.T) {
+	query := &spanstore.TraceQueryParameters{
+		TagPredicates: []spanstore.TagPredicate{{Key: "http.status_code", Operator: spanstore.TagGreaterThanOrEqual, Value: "500"}},
+	}
+	tests := []struct {
+		name     string
+		response *storage_v1.CapabilitiesResponse
+		err      error
+		expected string
+	}{
+		{
+			name:     "plugin without capabilities",
+			err:      status.Error(codes.Unimplemented, "unknown service storage.v1.PluginCapabilities"),
+			expected: "tag operator is not supported by the span storage: >=",
+		},
+		{
+			name:     "plugin without tag predicates",
+			response: &storage_v1.CapabilitiesResponse{},
+			expected: "tag operator is not supported by the span storage: >=",
+		},
+		{
+			name:     "capabilities error",
+			err:      errors.New("plugin crashed"),
+			expected: "plugin error: plugin crashed",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			withGRPCClient(func(r *grpcClientTest) {
+				r.capabilities.On("Capabilities", mock.Anything, &storage_v1.CapabilitiesRequest{}).
+					Return(test.response, test.err)
+
+				_, err := r.client.FindTraceIDs(context.Background(), query)
+				assert.EqualError(t, err, test.expected)
+				if test.response != nil || status.Code(test.err) == codes.Unimplemented {
+					assert.True(t, errors.Is(err, spanstore.ErrUnsupportedTagOperator))
+				}
+
+				_, err = r.client.FindTraces(context.Background(), query)
+				assert.EqualError(t, err, test.expected)
+				r.spanReader.AssertNotCalled(t, "FindTraceIDs", mock.Anything, mock.Anything)
+				r.spanReader.AssertNotCalled(t, "FindTraces", mock.Anything, mock.Anything)
+			})
+		})
+	}
+}
+
 func TestGRPCClientWriteSpan(t *testing.T) {
 	withGRPCClient(func(r *grpcClientTest) {
 		r.spanWriter.On("WriteSpan", mock.Anything, &storage_v1.WriteSpanRequest{
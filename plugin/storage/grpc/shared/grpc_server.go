@@ -30,6 +30,13 @@ type grpcServer struct {
 	Impl StoragePlugin
 }
 
+// Capabilities returns the optional features supported by the plugin
+func (s *grpcServer) Capabilities(ctx context.Context, r *storage_v1.CapabilitiesRequest) (*storage_v1.CapabilitiesResponse, error) {
+	return &storage_v1.CapabilitiesResponse{
+		TagPredicates: true,
+	}, nil
+}
+
 // GetDependencies returns all interservice dependencies
 func (s *grpcServer) GetDependencies(ctx context.Context, r *storage_v1.GetDependenciesRequest) (*storage_v1.GetDependenciesResponse, error) {
 	deps, err := s.Impl.DependencyReader().GetDependencies(r.EndTime, r.EndTime.Sub(r.StartTime))
@@ -106,6 +113,7 @@ func (s *grpcServer) FindTraces(r *storage_v1.FindTracesRequest, stream storage_
 		ServiceName:   r.Query.ServiceName,
 		OperationName: r.Query.OperationName,
 		Tags:          r.Query.Tags,
+		TagPredicates: fromProtoTagPredicates(r.Query.TagPredicates),
 		StartTimeMin:  r.Query.StartTimeMin,
 		StartTimeMax:  r.Query.StartTimeMax,
 		DurationMin:   r.Query.DurationMin,
@@ -132,6 +140,7 @@ func (s *grpcServer) FindTraceIDs(ctx context.Context, r *storage_v1.FindTraceID
 		ServiceName:   r.Query.ServiceName,
 		OperationName: r.Query.OperationName,
 		Tags:          r.Query.Tags,
+		TagPredicates: fromProtoTagPredicates(r.Query.TagPredicates),
 		StartTimeMin:  r.Query.StartTimeMin,
 		StartTimeMax:  r.Query.StartTimeMax,
 		DurationMin:   r.Query.DurationMin,
@@ -146,6 +155,21 @@ func (s *grpcServer) FindTraceIDs(ctx context.Context, r *storage_v1.FindTraceID
 	}, nil
 }
 
+func fromProtoTagPredicates(protoPredicates []storage_v1.TagPredicate) []spanstore.TagPredicate {
+	if len(protoPredicates) == 0 {
+		return nil
+	}
+	predicates := make([]spanstore.TagPredicate, len(protoPredicates))
+	for i, predicate := range protoPredicates {
+		predicates[i] = spanstore.TagPredicate{
+			Key:      predicate.Key,
+			Operator: spanstore.TagOperator(predicate.Operator),
+			Value:    predicate.Value,
+		}
+	}
+	return predicates
+}
+
 func (s *grpcServer) sendSpans(spans []*model.Span, sendFn func(*storage_v1.SpansResponseChunk) error) error {
 	chunk := make([]model.Span, 0, len(spans))
 	for i := 0; i < len(spans); i += spanBatchSize {
@@ -175,6 +175,30 @@ func TestGRPCServerFindTraceIDs(t *testing.T) {
 	})
 }
 
+func TestGRPCServerFindTraceIDsWithTagPredicates(t *testing.T) {
+	withGRPCServer(func(r *grpcServerTest) {
+		r.impl.spanReader.On("FindTraceIDs", mock.Anything, &spanstore.TraceQueryParameters{
+			TagPredicates: []spanstore.TagPredicate{{Key: "error", Operator: spanstore.TagExists}},
+		}).Return([]model.TraceID{mockTraceID}, nil)
+
+		s, err := r.server.FindTraceIDs(context.Background(), &storage_v1.FindTraceIDsRequest{
+			Query: &storage_v1.TraceQueryParameters{
+				TagPredicates: []storage_v1.TagPredicate{{Key: "error", Operator: "exists"}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, &storage_v1.FindTraceIDsResponse{TraceIDs: []model.TraceID{mockTraceID}}, s)
+	})
+}
+
+func TestGRPCServerCapabilities(t *testing.T) {
+	withGRPCServer(func(r *grpcServerTest) {
+		s, err := r.server.Capabilities(context.Background(), &storage_v1.CapabilitiesRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, &storage_v1.CapabilitiesResponse{TagPredicates: true}, s)
+	})
+}
+
 func TestGRPCServerWriteSpan(t *testing.T) {
 	withGRPCServer(func(r *grpcServerTest) {
 		r.impl.spanWriter.On("WriteSpan", &mockTraceSpans[0]).
@@ -20,6 +20,7 @@ import (
 	"github.com/hashicorp/go-plugin"
 	"google.golang.org/grpc"
 
+	_ "github.com/jaegertracing/jaeger/pkg/gogocodec" // force gogo codec registration
 	"github.com/jaegertracing/jaeger/proto-gen/storage_v1"
 	"github.com/jaegertracing/jaeger/storage/dependencystore"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
@@ -60,14 +61,16 @@ func (p *StorageGRPCPlugin) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server
 	storage_v1.RegisterSpanReaderPluginServer(s, server)
 	storage_v1.RegisterSpanWriterPluginServer(s, server)
 	storage_v1.RegisterDependenciesReaderPluginServer(s, server)
+	storage_v1.RegisterPluginCapabilitiesServer(s, server)
 	return nil
 }
 
 // GRPCClient is used by go-plugin to create a grpc plugin client
 func (*StorageGRPCPlugin) GRPCClient(ctx context.Context, broker *plugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
 	return &grpcClient{
-		readerClient:     storage_v1.NewSpanReaderPluginClient(c),
-		writerClient:     storage_v1.NewSpanWriterPluginClient(c),
-		depsReaderClient: storage_v1.NewDependenciesReaderPluginClient(c),
+		readerClient:       storage_v1.NewSpanReaderPluginClient(c),
+		writerClient:       storage_v1.NewSpanWriterPluginClient(c),
+		depsReaderClient:   storage_v1.NewDependenciesReaderPluginClient(c),
+		capabilitiesClient: storage_v1.NewPluginCapabilitiesClient(c),
 	}, nil
 }
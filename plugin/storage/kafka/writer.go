@@ -70,6 +70,12 @@ func NewSpanWriter(
 
 // WriteSpan writes the span to kafka.
 func (w *SpanWriter) WriteSpan(span *model.Span) error {
+	return w.WriteSpanWithHeaders(span, nil)
+}
+
+// WriteSpanWithHeaders writes the span to kafka, adding the headers to the message.
+// Headers require the producer to use Kafka protocol version 0.11 or later.
+func (w *SpanWriter) WriteSpanWithHeaders(span *model.Span, headers []sarama.RecordHeader) error {
 	spanBytes, err := w.marshaller.Marshal(span)
 	if err != nil {
 		w.metrics.SpansWrittenFailure.Inc(1)
@@ -79,9 +85,10 @@ func (w *SpanWriter) WriteSpan(span *model.Span) error {
 	// The AsyncProducer accepts messages on a channel and produces them asynchronously
 	// in the background as efficiently as possible
 	w.producer.Input() <- &sarama.ProducerMessage{
-		Topic: w.topic,
-		Key:   sarama.StringEncoder(span.TraceID.String()),
-		Value: sarama.ByteEncoder(spanBytes),
+		Topic:   w.topic,
+		Key:     sarama.StringEncoder(span.TraceID.String()),
+		Value:   sarama.ByteEncoder(spanBytes),
+		Headers: headers,
 	}
 	return nil
 }
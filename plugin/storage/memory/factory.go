@@ -17,12 +17,15 @@ package memory
 
 import (
 	"flag"
+	"sync"
 
 	"github.com/spf13/viper"
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
 	"github.com/jaegertracing/jaeger/storage/dependencystore"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -32,6 +35,10 @@ type Factory struct {
 	metricsFactory metrics.Factory
 	logger         *zap.Logger
 	store          *Store
+	samplingStore  *SamplingStore
+
+	tenantsLock  sync.Mutex
+	tenantStores map[string]*Store
 }
 
 // NewFactory creates a new Factory.
@@ -58,6 +65,8 @@ func (f *Factory) InitFromOptions(opts Options) {
 func (f *Factory) Initialize(metricsFactory metrics.Factory, logger *zap.Logger) error {
 	f.metricsFactory, f.logger = metricsFactory, logger
 	f.store = WithConfiguration(f.options.Configuration)
+	f.samplingStore = NewSamplingStore()
+	f.tenantStores = make(map[string]*Store)
 	logger.Info("Memory storage initialized", zap.Any("configuration", f.store.config))
 	return nil
 }
@@ -72,7 +81,39 @@ func (f *Factory) CreateSpanWriter() (spanstore.Writer, error) {
 	return f.store, nil
 }
 
+// CreateTenantSpanReader implements storage.TenantFactory
+func (f *Factory) CreateTenantSpanReader(tenant string) (spanstore.Reader, error) {
+	return f.tenantStore(tenant), nil
+}
+
+// CreateTenantSpanWriter implements storage.TenantFactory
+func (f *Factory) CreateTenantSpanWriter(tenant string) (spanstore.Writer, error) {
+	return f.tenantStore(tenant), nil
+}
+
+// tenantStore returns the store holding the spans of the tenant, creating it on first use
+func (f *Factory) tenantStore(tenant string) *Store {
+	f.tenantsLock.Lock()
+	defer f.tenantsLock.Unlock()
+	store, ok := f.tenantStores[tenant]
+	if !ok {
+		store = WithConfiguration(f.options.Configuration)
+		f.tenantStores[tenant] = store
+	}
+	return store
+}
+
 // CreateDependencyReader implements storage.Factory
 func (f *Factory) CreateDependencyReader() (dependencystore.Reader, error) {
 	return f.store, nil
 }
+
+// CreateLock implements storage.SamplingStoreFactory
+func (f *Factory) CreateLock() (distributedlock.Lock, error) {
+	return lock{}, nil
+}
+
+// CreateSamplingStore implements storage.SamplingStoreFactory
+func (f *Factory) CreateSamplingStore() (samplingstore.Store, error) {
+	return f.samplingStore, nil
+}
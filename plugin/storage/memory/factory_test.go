@@ -26,6 +26,8 @@ import (
 )
 
 var _ storage.Factory = new(Factory)
+var _ storage.SamplingStoreFactory = new(Factory)
+var _ storage.TenantFactory = new(Factory)
 
 func TestMemoryStorageFactory(t *testing.T) {
 	f := NewFactory()
@@ -40,6 +42,26 @@ func TestMemoryStorageFactory(t *testing.T) {
 	depReader, err := f.CreateDependencyReader()
 	assert.NoError(t, err)
 	assert.Equal(t, f.store, depReader)
+	lock, err := f.CreateLock()
+	assert.NoError(t, err)
+	assert.NotNil(t, lock)
+	samplingStore, err := f.CreateSamplingStore()
+	assert.NoError(t, err)
+	assert.Equal(t, f.samplingStore, samplingStore)
+}
+
+func TestMemoryStorageFactoryTenants(t *testing.T) {
+	f := NewFactory()
+	assert.NoError(t, f.Initialize(nil, zap.NewNop()))
+	acmeWriter, err := f.CreateTenantSpanWriter("acme")
+	assert.NoError(t, err)
+	acmeReader, err := f.CreateTenantSpanReader("acme")
+	assert.NoError(t, err)
+	assert.Equal(t, acmeWriter, acmeReader)
+	assert.NotEqual(t, f.store, acmeReader)
+	otherReader, err := f.CreateTenantSpanReader("megacorp")
+	assert.NoError(t, err)
+	assert.NotEqual(t, acmeReader, otherReader)
 }
 
 func TestWithConfiguration(t *testing.T) {
@@ -17,7 +17,6 @@ package memory
 
 import (
 	"context"
-	"errors"
 	"sort"
 	"sync"
 	"time"
@@ -208,10 +207,42 @@ func (m *Store) GetOperations(
 func (m *Store) FindTraces(ctx context.Context, query *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
 	m.RLock()
 	defer m.RUnlock()
+	traces, err := m.findTraces(query)
+	if err != nil {
+		return nil, err
+	}
+	var retMe []*model.Trace
+	for _, trace := range traces {
+		retMe = append(retMe, m.copyTrace(trace))
+	}
+	return retMe, nil
+}
+
+// FindTraceIDs returns the IDs of the traces FindTraces would return for the query
+func (m *Store) FindTraceIDs(ctx context.Context, query *spanstore.TraceQueryParameters) ([]model.TraceID, error) {
+	m.RLock()
+	defer m.RUnlock()
+	traces, err := m.findTraces(query)
+	if err != nil {
+		return nil, err
+	}
+	var retMe []model.TraceID
+	for _, trace := range traces {
+		retMe = append(retMe, trace.Spans[0].TraceID)
+	}
+	return retMe, nil
+}
+
+// findTraces returns the stored traces satisfying the query; the caller must hold the read lock.
+func (m *Store) findTraces(query *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
+	tagMatchers, err := spanstore.NewTagMatchers(query.TagPredicates)
+	if err != nil {
+		return nil, err
+	}
 	var retMe []*model.Trace
 	for _, trace := range m.traces {
-		if m.validTrace(trace, query) {
-			retMe = append(retMe, m.copyTrace(trace))
+		if m.validTrace(trace, query, tagMatchers) {
+			retMe = append(retMe, trace)
 		}
 	}
 
@@ -227,14 +258,20 @@ func (m *Store) FindTraces(ctx context.Context, query *spanstore.TraceQueryParam
 	return retMe, nil
 }
 
-// FindTraceIDs is not implemented.
-func (m *Store) FindTraceIDs(ctx context.Context, query *spanstore.TraceQueryParameters) ([]model.TraceID, error) {
-	return nil, errors.New("not implemented")
+// GetOperationStats implements spanstore.OperationStatsReader by aggregating all the spans in memory.
+func (m *Store) GetOperationStats(ctx context.Context, query *spanstore.OperationStatsQueryParameters) ([]spanstore.OperationStats, error) {
+	m.RLock()
+	defer m.RUnlock()
+	var spans []*model.Span
+	for _, trace := range m.traces {
+		spans = append(spans, trace.Spans...)
+	}
+	return spanstore.AggregateOperationStats(spans, query), nil
 }
 
-func (m *Store) validTrace(trace *model.Trace, query *spanstore.TraceQueryParameters) bool {
+func (m *Store) validTrace(trace *model.Trace, query *spanstore.TraceQueryParameters, tagMatchers []*spanstore.TagMatcher) bool {
 	for _, span := range trace.Spans {
-		if m.validSpan(span, query) {
+		if m.validSpan(span, query, tagMatchers) {
 			return true
 		}
 	}
@@ -250,7 +287,7 @@ func findKeyValueMatch(kvs model.KeyValues, key, value string) (model.KeyValue,
 	return model.KeyValue{}, false
 }
 
-func (m *Store) validSpan(span *model.Span, query *spanstore.TraceQueryParameters) bool {
+func (m *Store) validSpan(span *model.Span, query *spanstore.TraceQueryParameters, tagMatchers []*spanstore.TagMatcher) bool {
 	if query.ServiceName != span.Process.ServiceName {
 		return false
 	}
@@ -276,6 +313,11 @@ func (m *Store) validSpan(span *model.Span, query *spanstore.TraceQueryParameter
 			return false
 		}
 	}
+	for _, tagMatcher := range tagMatchers {
+		if !tagMatcher.Match(span) {
+			return false
+		}
+	}
 	return true
 }
 
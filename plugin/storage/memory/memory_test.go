@@ -393,6 +393,25 @@ func TestStoreGetTrace(t *testing.T) {
 				},
 			}, false,
 		},
+		{
+			&spanstore.TraceQueryParameters{
+				ServiceName: testingSpan.Process.ServiceName,
+				TagPredicates: []spanstore.TagPredicate{
+					{Key: "tagKey", Operator: spanstore.TagNotEquals, Value: "otherValue"},
+					{Key: "tagKey", Operator: spanstore.TagMatches, Value: "tag.*"},
+					{Key: "logKey", Operator: spanstore.TagExists},
+				},
+			}, true,
+		},
+		{
+			&spanstore.TraceQueryParameters{
+				ServiceName: testingSpan.Process.ServiceName,
+				TagPredicates: []spanstore.TagPredicate{
+					{Key: "tagKey", Operator: spanstore.TagEquals, Value: "tagValue"},
+					{Key: "missingKey", Operator: spanstore.TagExists},
+				},
+			}, false,
+		},
 	}
 	for _, testS := range testStruct {
 		withPopulatedMemoryStore(func(store *Store) {
@@ -410,10 +429,56 @@ func TestStoreGetTrace(t *testing.T) {
 	}
 }
 
+func TestStoreFindTracesInvalidTagPredicate(t *testing.T) {
+	withPopulatedMemoryStore(func(store *Store) {
+		traces, err := store.FindTraces(context.Background(), &spanstore.TraceQueryParameters{
+			ServiceName: testingSpan.Process.ServiceName,
+			TagPredicates: []spanstore.TagPredicate{
+				{Key: "tagKey", Operator: spanstore.TagGreaterThan, Value: "tagValue"},
+			},
+		})
+		assert.EqualError(t, err, `invalid number for tag tagKey: strconv.ParseFloat: parsing "tagValue": invalid syntax`)
+		assert.Nil(t, traces)
+	})
+}
+
+func TestStoreGetOperationStats(t *testing.T) {
+	withPopulatedMemoryStore(func(store *Store) {
+		store.WriteSpan(childSpan1)
+		store.WriteSpan(childSpan2)
+		stats, err := store.GetOperationStats(context.Background(), &spanstore.OperationStatsQueryParameters{
+			ServiceName: "childService",
+			Percentiles: []float64{50},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []spanstore.OperationStats{
+			{Operation: "childOperationName", Count: 2, Latencies: []time.Duration{time.Second * 5}},
+		}, stats)
+	})
+}
+
 func TestStore_FindTraceIDs(t *testing.T) {
-	withMemoryStore(func(store *Store) {
-		traceIDs, err := store.FindTraceIDs(context.Background(), nil)
+	withPopulatedMemoryStore(func(store *Store) {
+		traceIDs, err := store.FindTraceIDs(context.Background(), &spanstore.TraceQueryParameters{
+			ServiceName: testingSpan.Process.ServiceName,
+			NumTraces:   10,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.TraceID{traceID}, traceIDs)
+
+		traceIDs, err = store.FindTraceIDs(context.Background(), &spanstore.TraceQueryParameters{
+			ServiceName: "nonExistingService",
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, traceIDs)
+
+		traceIDs, err = store.FindTraceIDs(context.Background(), &spanstore.TraceQueryParameters{
+			ServiceName: testingSpan.Process.ServiceName,
+			TagPredicates: []spanstore.TagPredicate{
+				{Key: "tagKey", Operator: spanstore.TagGreaterThan, Value: "tagValue"},
+			},
+		})
+		assert.Error(t, err)
 		assert.Nil(t, traceIDs)
-		assert.EqualError(t, err, "not implemented")
 	})
 }
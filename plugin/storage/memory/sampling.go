@@ -0,0 +1,124 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/model"
+)
+
+// maxSamplingBuckets bounds the number of throughput and probability buckets kept in memory.
+const maxSamplingBuckets = 100
+
+type throughputBucket struct {
+	timestamp  time.Time
+	throughput []*model.Throughput
+}
+
+type probabilitiesBucket struct {
+	timestamp time.Time
+	hostname  string
+	data      model.ServiceOperationData
+}
+
+// SamplingStore is an in-memory implementation of samplingstore.Store.
+// It is only suitable for a single collector, since the data is not shared across processes.
+type SamplingStore struct {
+	sync.RWMutex
+	throughputs   []throughputBucket
+	probabilities []probabilitiesBucket
+	latest        model.ServiceOperationProbabilities
+}
+
+// NewSamplingStore creates an in-memory sampling store.
+func NewSamplingStore() *SamplingStore {
+	return &SamplingStore{}
+}
+
+// InsertThroughput implements samplingstore.Store#InsertThroughput.
+func (s *SamplingStore) InsertThroughput(throughput []*model.Throughput) error {
+	s.Lock()
+	defer s.Unlock()
+	s.throughputs = append(s.throughputs, throughputBucket{timestamp: time.Now(), throughput: throughput})
+	if len(s.throughputs) > maxSamplingBuckets {
+		s.throughputs = s.throughputs[len(s.throughputs)-maxSamplingBuckets:]
+	}
+	return nil
+}
+
+// InsertProbabilitiesAndQPS implements samplingstore.Store#InsertProbabilitiesAndQPS.
+func (s *SamplingStore) InsertProbabilitiesAndQPS(
+	hostname string,
+	probabilities model.ServiceOperationProbabilities,
+	qps model.ServiceOperationQPS,
+) error {
+	data := make(model.ServiceOperationData)
+	for svc, opProbabilities := range probabilities {
+		data[svc] = make(map[string]*model.ProbabilityAndQPS)
+		for op, probability := range opProbabilities {
+			data[svc][op] = &model.ProbabilityAndQPS{Probability: probability, QPS: qps[svc][op]}
+		}
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.probabilities = append(s.probabilities, probabilitiesBucket{timestamp: time.Now(), hostname: hostname, data: data})
+	if len(s.probabilities) > maxSamplingBuckets {
+		s.probabilities = s.probabilities[len(s.probabilities)-maxSamplingBuckets:]
+	}
+	s.latest = probabilities
+	return nil
+}
+
+// GetThroughput implements samplingstore.Store#GetThroughput.
+func (s *SamplingStore) GetThroughput(start, end time.Time) ([]*model.Throughput, error) {
+	s.RLock()
+	defer s.RUnlock()
+	var ret []*model.Throughput
+	for _, b := range s.throughputs {
+		if inRange(b.timestamp, start, end) {
+			ret = append(ret, b.throughput...)
+		}
+	}
+	return ret, nil
+}
+
+// GetProbabilitiesAndQPS implements samplingstore.Store#GetProbabilitiesAndQPS.
+func (s *SamplingStore) GetProbabilitiesAndQPS(start, end time.Time) (map[string][]model.ServiceOperationData, error) {
+	s.RLock()
+	defer s.RUnlock()
+	ret := make(map[string][]model.ServiceOperationData)
+	for _, b := range s.probabilities {
+		if inRange(b.timestamp, start, end) {
+			ret[b.hostname] = append(ret[b.hostname], b.data)
+		}
+	}
+	return ret, nil
+}
+
+// GetLatestProbabilities implements samplingstore.Store#GetLatestProbabilities.
+func (s *SamplingStore) GetLatestProbabilities() (model.ServiceOperationProbabilities, error) {
+	s.RLock()
+	defer s.RUnlock()
+	if s.latest == nil {
+		return model.ServiceOperationProbabilities{}, nil
+	}
+	return s.latest, nil
+}
+
+func inRange(ts, start, end time.Time) bool {
+	return !ts.Before(start) && !ts.After(end)
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/model"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
+)
+
+var _ samplingstore.Store = new(SamplingStore)
+
+func TestSamplingStoreThroughput(t *testing.T) {
+	s := NewSamplingStore()
+	start := time.Now().Add(-time.Minute)
+	throughput := []*model.Throughput{{Service: "svc", Operation: "op", Count: 10}}
+	require.NoError(t, s.InsertThroughput(throughput))
+
+	got, err := s.GetThroughput(start, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, throughput, got)
+
+	got, err = s.GetThroughput(start.Add(-time.Hour), start)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	for i := 0; i < maxSamplingBuckets+5; i++ {
+		require.NoError(t, s.InsertThroughput(throughput))
+	}
+	assert.Len(t, s.throughputs, maxSamplingBuckets)
+}
+
+func TestSamplingStoreProbabilities(t *testing.T) {
+	s := NewSamplingStore()
+	latest, err := s.GetLatestProbabilities()
+	require.NoError(t, err)
+	assert.Empty(t, latest)
+
+	start := time.Now().Add(-time.Minute)
+	probabilities := model.ServiceOperationProbabilities{"svc": {"op": 0.5}}
+	qps := model.ServiceOperationQPS{"svc": {"op": 2}}
+	require.NoError(t, s.InsertProbabilitiesAndQPS("host", probabilities, qps))
+
+	latest, err = s.GetLatestProbabilities()
+	require.NoError(t, err)
+	assert.Equal(t, probabilities, latest)
+
+	data, err := s.GetProbabilitiesAndQPS(start, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, data["host"], 1)
+	assert.Equal(t, &model.ProbabilityAndQPS{Probability: 0.5, QPS: 2}, data["host"][0]["svc"]["op"])
+}
+
+func TestLock(t *testing.T) {
+	acquired, err := lock{}.Acquire("resource", time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	forfeited, err := lock{}.Forfeit("resource")
+	require.NoError(t, err)
+	assert.True(t, forfeited)
+}
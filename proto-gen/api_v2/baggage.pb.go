@@ -0,0 +1,840 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: baggage.proto
+
+package api_v2
+
+import (
+	context "context"
+	fmt "fmt"
+	_ "github.com/gogo/protobuf/gogoproto"
+	proto "github.com/gogo/protobuf/proto"
+	golang_proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	io "io"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = golang_proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
+
+// BaggageRestriction limits the value length of a baggage key a service is allowed to set.
+type BaggageRestriction struct {
+	BaggageKey           string   `protobuf:"bytes,1,opt,name=baggageKey,proto3" json:"baggageKey,omitempty"`
+	MaxValueLength       int32    `protobuf:"varint,2,opt,name=maxValueLength,proto3" json:"maxValueLength,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaggageRestriction) Reset()         { *m = BaggageRestriction{} }
+func (m *BaggageRestriction) String() string { return proto.CompactTextString(m) }
+func (*BaggageRestriction) ProtoMessage()    {}
+func (*BaggageRestriction) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b9e101d0014c1cc3, []int{0}
+}
+func (m *BaggageRestriction) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *BaggageRestriction) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_BaggageRestriction.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *BaggageRestriction) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaggageRestriction.Merge(m, src)
+}
+func (m *BaggageRestriction) XXX_Size() int {
+	return m.Size()
+}
+func (m *BaggageRestriction) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaggageRestriction.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaggageRestriction proto.InternalMessageInfo
+
+func (m *BaggageRestriction) GetBaggageKey() string {
+	if m != nil {
+		return m.BaggageKey
+	}
+	return ""
+}
+
+func (m *BaggageRestriction) GetMaxValueLength() int32 {
+	if m != nil {
+		return m.MaxValueLength
+	}
+	return 0
+}
+
+type BaggageRestrictionsParameters struct {
+	ServiceName          string   `protobuf:"bytes,1,opt,name=serviceName,proto3" json:"serviceName,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaggageRestrictionsParameters) Reset()         { *m = BaggageRestrictionsParameters{} }
+func (m *BaggageRestrictionsParameters) String() string { return proto.CompactTextString(m) }
+func (*BaggageRestrictionsParameters) ProtoMessage()    {}
+func (*BaggageRestrictionsParameters) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b9e101d0014c1cc3, []int{1}
+}
+func (m *BaggageRestrictionsParameters) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *BaggageRestrictionsParameters) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_BaggageRestrictionsParameters.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *BaggageRestrictionsParameters) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaggageRestrictionsParameters.Merge(m, src)
+}
+func (m *BaggageRestrictionsParameters) XXX_Size() int {
+	return m.Size()
+}
+func (m *BaggageRestrictionsParameters) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaggageRestrictionsParameters.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaggageRestrictionsParameters proto.InternalMessageInfo
+
+func (m *BaggageRestrictionsParameters) GetServiceName() string {
+	if m != nil {
+		return m.ServiceName
+	}
+	return ""
+}
+
+type BaggageRestrictionsResponse struct {
+	BaggageRestrictions  []BaggageRestriction `protobuf:"bytes,1,rep,name=baggageRestrictions,proto3" json:"baggageRestrictions"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *BaggageRestrictionsResponse) Reset()         { *m = BaggageRestrictionsResponse{} }
+func (m *BaggageRestrictionsResponse) String() string { return proto.CompactTextString(m) }
+func (*BaggageRestrictionsResponse) ProtoMessage()    {}
+func (*BaggageRestrictionsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b9e101d0014c1cc3, []int{2}
+}
+func (m *BaggageRestrictionsResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *BaggageRestrictionsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_BaggageRestrictionsResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *BaggageRestrictionsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaggageRestrictionsResponse.Merge(m, src)
+}
+func (m *BaggageRestrictionsResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *BaggageRestrictionsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaggageRestrictionsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaggageRestrictionsResponse proto.InternalMessageInfo
+
+func (m *BaggageRestrictionsResponse) GetBaggageRestrictions() []BaggageRestriction {
+	if m != nil {
+		return m.BaggageRestrictions
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*BaggageRestriction)(nil), "jaeger.api_v2.BaggageRestriction")
+	golang_proto.RegisterType((*BaggageRestriction)(nil), "jaeger.api_v2.BaggageRestriction")
+	proto.RegisterType((*BaggageRestrictionsParameters)(nil), "jaeger.api_v2.BaggageRestrictionsParameters")
+	golang_proto.RegisterType((*BaggageRestrictionsParameters)(nil), "jaeger.api_v2.BaggageRestrictionsParameters")
+	proto.RegisterType((*BaggageRestrictionsResponse)(nil), "jaeger.api_v2.BaggageRestrictionsResponse")
+	golang_proto.RegisterType((*BaggageRestrictionsResponse)(nil), "jaeger.api_v2.BaggageRestrictionsResponse")
+}
+
+func init() { proto.RegisterFile("baggage.proto", fileDescriptor_b9e101d0014c1cc3) }
+func init() { golang_proto.RegisterFile("baggage.proto", fileDescriptor_b9e101d0014c1cc3) }
+
+var fileDescriptor_b9e101d0014c1cc3 = []byte{
+	// 276 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x4d, 0x4a, 0x4c, 0x4f,
+	0x4f, 0x4c, 0x4f, 0xd5, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0xe2, 0xcd, 0x4a, 0x4c, 0x4d, 0x4f,
+	0x2d, 0xd2, 0x4b, 0x2c, 0xc8, 0x8c, 0x2f, 0x33, 0x92, 0x12, 0x49, 0xcf, 0x4f, 0xcf, 0x07, 0xcb,
+	0xe8, 0x83, 0x58, 0x10, 0x45, 0x4a, 0x31, 0x5c, 0x42, 0x4e, 0x10, 0x5d, 0x41, 0xa9, 0xc5, 0x25,
+	0x45, 0x99, 0xc9, 0x25, 0x99, 0xf9, 0x79, 0x42, 0x72, 0x5c, 0x5c, 0x50, 0xb3, 0xbc, 0x53, 0x2b,
+	0x25, 0x18, 0x15, 0x18, 0x35, 0x38, 0x83, 0x90, 0x44, 0x84, 0xd4, 0xb8, 0xf8, 0x72, 0x13, 0x2b,
+	0xc2, 0x12, 0x73, 0x4a, 0x53, 0x7d, 0x52, 0xf3, 0xd2, 0x4b, 0x32, 0x24, 0x98, 0x14, 0x18, 0x35,
+	0x58, 0x83, 0xd0, 0x44, 0x95, 0x1c, 0xb9, 0x64, 0x31, 0x4d, 0x2f, 0x0e, 0x48, 0x2c, 0x4a, 0xcc,
+	0x4d, 0x2d, 0x49, 0x2d, 0x2a, 0x16, 0x52, 0xe0, 0xe2, 0x2e, 0x4e, 0x2d, 0x2a, 0xcb, 0x4c, 0x4e,
+	0xf5, 0x4b, 0xcc, 0x4d, 0x85, 0xda, 0x84, 0x2c, 0xa4, 0x54, 0xc1, 0x25, 0x8d, 0xc5, 0x88, 0xa0,
+	0xd4, 0xe2, 0x82, 0xfc, 0xbc, 0xe2, 0x54, 0xa1, 0x48, 0x2e, 0xe1, 0x24, 0x4c, 0x69, 0x09, 0x46,
+	0x05, 0x66, 0x0d, 0x6e, 0x23, 0x45, 0x3d, 0x94, 0x20, 0xd0, 0xc3, 0x34, 0xc8, 0x89, 0xe5, 0xc4,
+	0x3d, 0x79, 0x86, 0x20, 0x6c, 0x66, 0x18, 0x4d, 0x64, 0xe4, 0x92, 0xc4, 0xd4, 0xe1, 0x9b, 0x98,
+	0x97, 0x98, 0x9e, 0x5a, 0x24, 0x54, 0xc2, 0x25, 0xe6, 0x9e, 0x5a, 0x82, 0xc5, 0x69, 0x42, 0x3a,
+	0x04, 0x6d, 0x45, 0x0a, 0x01, 0x29, 0x2d, 0xc2, 0xaa, 0x61, 0x9e, 0x55, 0x62, 0x70, 0x92, 0x38,
+	0xf1, 0x48, 0x8e, 0xf1, 0xc2, 0x23, 0x39, 0xc6, 0x07, 0x8f, 0xe4, 0x18, 0x0f, 0x3c, 0x96, 0x63,
+	0x8c, 0x62, 0x83, 0xe8, 0x4b, 0x62, 0x03, 0xc7, 0xa7, 0x31, 0x60, 0x00, 0x9b, 0x27, 0xa4, 0x55,
+	0x05, 0x02, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// BaggageRestrictionManagerClient is the client API for BaggageRestrictionManager service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type BaggageRestrictionManagerClient interface {
+	GetBaggageRestrictions(ctx context.Context, in *BaggageRestrictionsParameters, opts ...grpc.CallOption) (*BaggageRestrictionsResponse, error)
+}
+
+type baggageRestrictionManagerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewBaggageRestrictionManagerClient(cc *grpc.ClientConn) BaggageRestrictionManagerClient {
+	return &baggageRestrictionManagerClient{cc}
+}
+
+func (c *baggageRestrictionManagerClient) GetBaggageRestrictions(ctx context.Context, in *BaggageRestrictionsParameters, opts ...grpc.CallOption) (*BaggageRestrictionsResponse, error) {
+	out := new(BaggageRestrictionsResponse)
+	err := c.cc.Invoke(ctx, "/jaeger.api_v2.BaggageRestrictionManager/GetBaggageRestrictions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BaggageRestrictionManagerServer is the server API for BaggageRestrictionManager service.
+type BaggageRestrictionManagerServer interface {
+	GetBaggageRestrictions(context.Context, *BaggageRestrictionsParameters) (*BaggageRestrictionsResponse, error)
+}
+
+func RegisterBaggageRestrictionManagerServer(s *grpc.Server, srv BaggageRestrictionManagerServer) {
+	s.RegisterService(&_BaggageRestrictionManager_serviceDesc, srv)
+}
+
+func _BaggageRestrictionManager_GetBaggageRestrictions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BaggageRestrictionsParameters)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BaggageRestrictionManagerServer).GetBaggageRestrictions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jaeger.api_v2.BaggageRestrictionManager/GetBaggageRestrictions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BaggageRestrictionManagerServer).GetBaggageRestrictions(ctx, req.(*BaggageRestrictionsParameters))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _BaggageRestrictionManager_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "jaeger.api_v2.BaggageRestrictionManager",
+	HandlerType: (*BaggageRestrictionManagerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBaggageRestrictions",
+			Handler:    _BaggageRestrictionManager_GetBaggageRestrictions_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "baggage.proto",
+}
+
+func (m *BaggageRestriction) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BaggageRestriction) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.BaggageKey) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintBaggage(dAtA, i, uint64(len(m.BaggageKey)))
+		i += copy(dAtA[i:], m.BaggageKey)
+	}
+	if m.MaxValueLength != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintBaggage(dAtA, i, uint64(m.MaxValueLength))
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func (m *BaggageRestrictionsParameters) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BaggageRestrictionsParameters) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.ServiceName) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintBaggage(dAtA, i, uint64(len(m.ServiceName)))
+		i += copy(dAtA[i:], m.ServiceName)
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func (m *BaggageRestrictionsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BaggageRestrictionsResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.BaggageRestrictions) > 0 {
+		for _, msg := range m.BaggageRestrictions {
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintBaggage(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func encodeVarintBaggage(dAtA []byte, offset int, v uint64) int {
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return offset + 1
+}
+func (m *BaggageRestriction) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.BaggageKey)
+	if l > 0 {
+		n += 1 + l + sovBaggage(uint64(l))
+	}
+	if m.MaxValueLength != 0 {
+		n += 1 + sovBaggage(uint64(m.MaxValueLength))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *BaggageRestrictionsParameters) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.ServiceName)
+	if l > 0 {
+		n += 1 + l + sovBaggage(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *BaggageRestrictionsResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.BaggageRestrictions) > 0 {
+		for _, e := range m.BaggageRestrictions {
+			l = e.Size()
+			n += 1 + l + sovBaggage(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func sovBaggage(x uint64) (n int) {
+	for {
+		n++
+		x >>= 7
+		if x == 0 {
+			break
+		}
+	}
+	return n
+}
+func sozBaggage(x uint64) (n int) {
+	return sovBaggage(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *BaggageRestriction) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowBaggage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BaggageRestriction: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BaggageRestriction: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BaggageKey", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBaggage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.BaggageKey = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxValueLength", wireType)
+			}
+			m.MaxValueLength = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBaggage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxValueLength |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipBaggage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BaggageRestrictionsParameters) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowBaggage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BaggageRestrictionsParameters: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BaggageRestrictionsParameters: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBaggage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ServiceName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipBaggage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BaggageRestrictionsResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowBaggage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BaggageRestrictionsResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BaggageRestrictionsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BaggageRestrictions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBaggage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.BaggageRestrictions = append(m.BaggageRestrictions, BaggageRestriction{})
+			if err := m.BaggageRestrictions[len(m.BaggageRestrictions)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipBaggage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipBaggage(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowBaggage
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowBaggage
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+			return iNdEx, nil
+		case 1:
+			iNdEx += 8
+			return iNdEx, nil
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowBaggage
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthBaggage
+			}
+			iNdEx += length
+			if iNdEx < 0 {
+				return 0, ErrInvalidLengthBaggage
+			}
+			return iNdEx, nil
+		case 3:
+			for {
+				var innerWire uint64
+				var start int = iNdEx
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return 0, ErrIntOverflowBaggage
+					}
+					if iNdEx >= l {
+						return 0, io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					innerWire |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				innerWireType := int(innerWire & 0x7)
+				if innerWireType == 4 {
+					break
+				}
+				next, err := skipBaggage(dAtA[start:])
+				if err != nil {
+					return 0, err
+				}
+				iNdEx = start + next
+				if iNdEx < 0 {
+					return 0, ErrInvalidLengthBaggage
+				}
+			}
+			return iNdEx, nil
+		case 4:
+			return iNdEx, nil
+		case 5:
+			iNdEx += 4
+			return iNdEx, nil
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+	}
+	panic("unreachable")
+}
+
+var (
+	ErrInvalidLengthBaggage = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowBaggage   = fmt.Errorf("proto: integer overflow")
+)
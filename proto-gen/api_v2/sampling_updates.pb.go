@@ -0,0 +1,716 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: sampling_updates.proto
+
+package api_v2
+
+import (
+	context "context"
+	fmt "fmt"
+	_ "github.com/gogo/protobuf/gogoproto"
+	proto "github.com/gogo/protobuf/proto"
+	golang_proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	io "io"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = golang_proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
+
+// SamplingStrategiesSubscription lists the services whose sampling strategy updates are pushed.
+type SamplingStrategiesSubscription struct {
+	ServiceNames         []string `protobuf:"bytes,1,rep,name=serviceNames,proto3" json:"serviceNames,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SamplingStrategiesSubscription) Reset()         { *m = SamplingStrategiesSubscription{} }
+func (m *SamplingStrategiesSubscription) String() string { return proto.CompactTextString(m) }
+func (*SamplingStrategiesSubscription) ProtoMessage()    {}
+func (*SamplingStrategiesSubscription) Descriptor() ([]byte, []int) {
+	return fileDescriptor_902fd1a7205ed01c, []int{0}
+}
+func (m *SamplingStrategiesSubscription) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SamplingStrategiesSubscription) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SamplingStrategiesSubscription.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SamplingStrategiesSubscription) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SamplingStrategiesSubscription.Merge(m, src)
+}
+func (m *SamplingStrategiesSubscription) XXX_Size() int {
+	return m.Size()
+}
+func (m *SamplingStrategiesSubscription) XXX_DiscardUnknown() {
+	xxx_messageInfo_SamplingStrategiesSubscription.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SamplingStrategiesSubscription proto.InternalMessageInfo
+
+func (m *SamplingStrategiesSubscription) GetServiceNames() []string {
+	if m != nil {
+		return m.ServiceNames
+	}
+	return nil
+}
+
+// SamplingStrategyUpdate carries the current sampling strategy of a service.
+type SamplingStrategyUpdate struct {
+	ServiceName          string                    `protobuf:"bytes,1,opt,name=serviceName,proto3" json:"serviceName,omitempty"`
+	Strategy             *SamplingStrategyResponse `protobuf:"bytes,2,opt,name=strategy,proto3" json:"strategy,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                  `json:"-"`
+	XXX_unrecognized     []byte                    `json:"-"`
+	XXX_sizecache        int32                     `json:"-"`
+}
+
+func (m *SamplingStrategyUpdate) Reset()         { *m = SamplingStrategyUpdate{} }
+func (m *SamplingStrategyUpdate) String() string { return proto.CompactTextString(m) }
+func (*SamplingStrategyUpdate) ProtoMessage()    {}
+func (*SamplingStrategyUpdate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_902fd1a7205ed01c, []int{1}
+}
+func (m *SamplingStrategyUpdate) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SamplingStrategyUpdate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SamplingStrategyUpdate.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SamplingStrategyUpdate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SamplingStrategyUpdate.Merge(m, src)
+}
+func (m *SamplingStrategyUpdate) XXX_Size() int {
+	return m.Size()
+}
+func (m *SamplingStrategyUpdate) XXX_DiscardUnknown() {
+	xxx_messageInfo_SamplingStrategyUpdate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SamplingStrategyUpdate proto.InternalMessageInfo
+
+func (m *SamplingStrategyUpdate) GetServiceName() string {
+	if m != nil {
+		return m.ServiceName
+	}
+	return ""
+}
+
+func (m *SamplingStrategyUpdate) GetStrategy() *SamplingStrategyResponse {
+	if m != nil {
+		return m.Strategy
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*SamplingStrategiesSubscription)(nil), "jaeger.api_v2.SamplingStrategiesSubscription")
+	golang_proto.RegisterType((*SamplingStrategiesSubscription)(nil), "jaeger.api_v2.SamplingStrategiesSubscription")
+	proto.RegisterType((*SamplingStrategyUpdate)(nil), "jaeger.api_v2.SamplingStrategyUpdate")
+	golang_proto.RegisterType((*SamplingStrategyUpdate)(nil), "jaeger.api_v2.SamplingStrategyUpdate")
+}
+
+func init() { proto.RegisterFile("sampling_updates.proto", fileDescriptor_902fd1a7205ed01c) }
+func init() { golang_proto.RegisterFile("sampling_updates.proto", fileDescriptor_902fd1a7205ed01c) }
+
+var fileDescriptor_902fd1a7205ed01c = []byte{
+	// 253 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0x2b, 0x4e, 0xcc, 0x2d,
+	0xc8, 0xc9, 0xcc, 0x4b, 0x8f, 0x2f, 0x2d, 0x48, 0x49, 0x2c, 0x49, 0x2d, 0xd6, 0x2b, 0x28, 0xca,
+	0x2f, 0xc9, 0x17, 0xe2, 0xcd, 0x4a, 0x4c, 0x4d, 0x4f, 0x2d, 0xd2, 0x4b, 0x2c, 0xc8, 0x8c, 0x2f,
+	0x33, 0x92, 0x12, 0x49, 0xcf, 0x4f, 0xcf, 0x07, 0xcb, 0xe8, 0x83, 0x58, 0x10, 0x45, 0x52, 0x7c,
+	0x30, 0xcd, 0x10, 0xbe, 0x92, 0x0b, 0x97, 0x5c, 0x30, 0x54, 0x24, 0xb8, 0xa4, 0x28, 0xb1, 0x24,
+	0x35, 0x3d, 0x33, 0xb5, 0x38, 0xb8, 0x34, 0xa9, 0x38, 0xb9, 0x28, 0xb3, 0xa0, 0x24, 0x33, 0x3f,
+	0x4f, 0x48, 0x89, 0x8b, 0xa7, 0x38, 0xb5, 0xa8, 0x2c, 0x33, 0x39, 0xd5, 0x2f, 0x31, 0x37, 0xb5,
+	0x58, 0x82, 0x51, 0x81, 0x59, 0x83, 0x33, 0x08, 0x45, 0x4c, 0xa9, 0x9e, 0x4b, 0x0c, 0xcd, 0x94,
+	0xca, 0x50, 0xb0, 0xdb, 0x84, 0x14, 0xb8, 0xb8, 0x91, 0x54, 0x4a, 0x30, 0x2a, 0x30, 0x6a, 0x70,
+	0x06, 0x21, 0x0b, 0x09, 0x39, 0x73, 0x71, 0x14, 0x43, 0xf5, 0x48, 0x30, 0x29, 0x30, 0x6a, 0x70,
+	0x1b, 0xa9, 0xeb, 0xa1, 0xf8, 0x44, 0x0f, 0xdd, 0xe8, 0xa0, 0xd4, 0xe2, 0x82, 0xfc, 0xbc, 0xe2,
+	0xd4, 0x20, 0xb8, 0x46, 0xa3, 0x06, 0x46, 0x2e, 0x71, 0xec, 0x2e, 0x28, 0x16, 0x4a, 0xe5, 0xe2,
+	0x84, 0x7a, 0x28, 0x29, 0x55, 0x48, 0x17, 0xbf, 0xd9, 0x68, 0x9e, 0x97, 0x52, 0x25, 0xe0, 0x14,
+	0x88, 0x1d, 0x4a, 0x0c, 0x06, 0x8c, 0x4e, 0x12, 0x27, 0x1e, 0xc9, 0x31, 0x5e, 0x78, 0x24, 0xc7,
+	0xf8, 0xe0, 0x91, 0x1c, 0xe3, 0x81, 0xc7, 0x72, 0x8c, 0x51, 0x6c, 0x10, 0x4d, 0x49, 0x6c, 0xe0,
+	0xa0, 0x36, 0x06, 0x0c, 0x00, 0xd9, 0x24, 0x66, 0xce, 0xb9, 0x01, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// SamplingStrategyUpdatesClient is the client API for SamplingStrategyUpdates service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type SamplingStrategyUpdatesClient interface {
+	// Subscribe pushes the current strategy of every subscribed service, and then the strategy of a service
+	// whenever it changes, e.g. when the strategies file is reloaded or adaptive probabilities are recalculated.
+	Subscribe(ctx context.Context, in *SamplingStrategiesSubscription, opts ...grpc.CallOption) (SamplingStrategyUpdates_SubscribeClient, error)
+}
+
+type samplingStrategyUpdatesClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewSamplingStrategyUpdatesClient(cc *grpc.ClientConn) SamplingStrategyUpdatesClient {
+	return &samplingStrategyUpdatesClient{cc}
+}
+
+func (c *samplingStrategyUpdatesClient) Subscribe(ctx context.Context, in *SamplingStrategiesSubscription, opts ...grpc.CallOption) (SamplingStrategyUpdates_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_SamplingStrategyUpdates_serviceDesc.Streams[0], "/jaeger.api_v2.SamplingStrategyUpdates/Subscribe", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &samplingStrategyUpdatesSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SamplingStrategyUpdates_SubscribeClient interface {
+	Recv() (*SamplingStrategyUpdate, error)
+	grpc.ClientStream
+}
+
+type samplingStrategyUpdatesSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *samplingStrategyUpdatesSubscribeClient) Recv() (*SamplingStrategyUpdate, error) {
+	m := new(SamplingStrategyUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SamplingStrategyUpdatesServer is the server API for SamplingStrategyUpdates service.
+type SamplingStrategyUpdatesServer interface {
+	// Subscribe pushes the current strategy of every subscribed service, and then the strategy of a service
+	// whenever it changes, e.g. when the strategies file is reloaded or adaptive probabilities are recalculated.
+	Subscribe(*SamplingStrategiesSubscription, SamplingStrategyUpdates_SubscribeServer) error
+}
+
+func RegisterSamplingStrategyUpdatesServer(s *grpc.Server, srv SamplingStrategyUpdatesServer) {
+	s.RegisterService(&_SamplingStrategyUpdates_serviceDesc, srv)
+}
+
+func _SamplingStrategyUpdates_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SamplingStrategiesSubscription)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SamplingStrategyUpdatesServer).Subscribe(m, &samplingStrategyUpdatesSubscribeServer{stream})
+}
+
+type SamplingStrategyUpdates_SubscribeServer interface {
+	Send(*SamplingStrategyUpdate) error
+	grpc.ServerStream
+}
+
+type samplingStrategyUpdatesSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *samplingStrategyUpdatesSubscribeServer) Send(m *SamplingStrategyUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _SamplingStrategyUpdates_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "jaeger.api_v2.SamplingStrategyUpdates",
+	HandlerType: (*SamplingStrategyUpdatesServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _SamplingStrategyUpdates_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "sampling_updates.proto",
+}
+
+func (m *SamplingStrategiesSubscription) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SamplingStrategiesSubscription) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.ServiceNames) > 0 {
+		for _, s := range m.ServiceNames {
+			dAtA[i] = 0xa
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func (m *SamplingStrategyUpdate) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SamplingStrategyUpdate) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.ServiceName) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintSamplingUpdates(dAtA, i, uint64(len(m.ServiceName)))
+		i += copy(dAtA[i:], m.ServiceName)
+	}
+	if m.Strategy != nil {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintSamplingUpdates(dAtA, i, uint64(m.Strategy.Size()))
+		n1, err := m.Strategy.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n1
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func encodeVarintSamplingUpdates(dAtA []byte, offset int, v uint64) int {
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return offset + 1
+}
+func (m *SamplingStrategiesSubscription) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.ServiceNames) > 0 {
+		for _, s := range m.ServiceNames {
+			l = len(s)
+			n += 1 + l + sovSamplingUpdates(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *SamplingStrategyUpdate) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.ServiceName)
+	if l > 0 {
+		n += 1 + l + sovSamplingUpdates(uint64(l))
+	}
+	if m.Strategy != nil {
+		l = m.Strategy.Size()
+		n += 1 + l + sovSamplingUpdates(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func sovSamplingUpdates(x uint64) (n int) {
+	for {
+		n++
+		x >>= 7
+		if x == 0 {
+			break
+		}
+	}
+	return n
+}
+func sozSamplingUpdates(x uint64) (n int) {
+	return sovSamplingUpdates(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *SamplingStrategiesSubscription) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSamplingUpdates
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SamplingStrategiesSubscription: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SamplingStrategiesSubscription: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceNames", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSamplingUpdates
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSamplingUpdates
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSamplingUpdates
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ServiceNames = append(m.ServiceNames, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSamplingUpdates(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSamplingUpdates
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSamplingUpdates
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SamplingStrategyUpdate) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSamplingUpdates
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SamplingStrategyUpdate: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SamplingStrategyUpdate: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSamplingUpdates
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSamplingUpdates
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSamplingUpdates
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ServiceName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Strategy", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSamplingUpdates
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSamplingUpdates
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSamplingUpdates
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Strategy == nil {
+				m.Strategy = &SamplingStrategyResponse{}
+			}
+			if err := m.Strategy.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSamplingUpdates(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSamplingUpdates
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSamplingUpdates
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipSamplingUpdates(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowSamplingUpdates
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowSamplingUpdates
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+			return iNdEx, nil
+		case 1:
+			iNdEx += 8
+			return iNdEx, nil
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowSamplingUpdates
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthSamplingUpdates
+			}
+			iNdEx += length
+			if iNdEx < 0 {
+				return 0, ErrInvalidLengthSamplingUpdates
+			}
+			return iNdEx, nil
+		case 3:
+			for {
+				var innerWire uint64
+				var start int = iNdEx
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return 0, ErrIntOverflowSamplingUpdates
+					}
+					if iNdEx >= l {
+						return 0, io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					innerWire |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				innerWireType := int(innerWire & 0x7)
+				if innerWireType == 4 {
+					break
+				}
+				next, err := skipSamplingUpdates(dAtA[start:])
+				if err != nil {
+					return 0, err
+				}
+				iNdEx = start + next
+				if iNdEx < 0 {
+					return 0, ErrInvalidLengthSamplingUpdates
+				}
+			}
+			return iNdEx, nil
+		case 4:
+			return iNdEx, nil
+		case 5:
+			iNdEx += 4
+			return iNdEx, nil
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+	}
+	panic("unreachable")
+}
+
+var (
+	ErrInvalidLengthSamplingUpdates = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowSamplingUpdates   = fmt.Errorf("proto: integer overflow")
+)
@@ -521,6 +521,7 @@ type TraceQueryParameters struct {
 	DurationMin          time.Duration     `protobuf:"bytes,6,opt,name=duration_min,json=durationMin,proto3,stdduration" json:"duration_min"`
 	DurationMax          time.Duration     `protobuf:"bytes,7,opt,name=duration_max,json=durationMax,proto3,stdduration" json:"duration_max"`
 	NumTraces            int32             `protobuf:"varint,8,opt,name=num_traces,json=numTraces,proto3" json:"num_traces,omitempty"`
+	TagPredicates        []TagPredicate    `protobuf:"bytes,9,rep,name=tag_predicates,json=tagPredicates,proto3" json:"tag_predicates"`
 	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
 	XXX_unrecognized     []byte            `json:"-"`
 	XXX_sizecache        int32             `json:"-"`
@@ -615,6 +616,78 @@ func (m *TraceQueryParameters) GetNumTraces() int32 {
 	return 0
 }
 
+func (m *TraceQueryParameters) GetTagPredicates() []TagPredicate {
+	if m != nil {
+		return m.TagPredicates
+	}
+	return nil
+}
+
+// TagPredicate is a condition on a tag of the spans. The operator is one of
+// "=", "!=", "=~", ">", ">=", "<", "<=" and "exists".
+type TagPredicate struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Operator             string   `protobuf:"bytes,2,opt,name=operator,proto3" json:"operator,omitempty"`
+	Value                string   `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TagPredicate) Reset()         { *m = TagPredicate{} }
+func (m *TagPredicate) String() string { return proto.CompactTextString(m) }
+func (*TagPredicate) ProtoMessage()    {}
+func (*TagPredicate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0d2c4ccf1453ffdb, []int{11}
+}
+func (m *TagPredicate) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TagPredicate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TagPredicate.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *TagPredicate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TagPredicate.Merge(m, src)
+}
+func (m *TagPredicate) XXX_Size() int {
+	return m.Size()
+}
+func (m *TagPredicate) XXX_DiscardUnknown() {
+	xxx_messageInfo_TagPredicate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TagPredicate proto.InternalMessageInfo
+
+func (m *TagPredicate) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *TagPredicate) GetOperator() string {
+	if m != nil {
+		return m.Operator
+	}
+	return ""
+}
+
+func (m *TagPredicate) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
 type FindTracesRequest struct {
 	Query                *TraceQueryParameters `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
@@ -626,7 +699,7 @@ func (m *FindTracesRequest) Reset()         { *m = FindTracesRequest{} }
 func (m *FindTracesRequest) String() string { return proto.CompactTextString(m) }
 func (*FindTracesRequest) ProtoMessage()    {}
 func (*FindTracesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0d2c4ccf1453ffdb, []int{11}
+	return fileDescriptor_0d2c4ccf1453ffdb, []int{12}
 }
 func (m *FindTracesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -673,7 +746,7 @@ func (m *SpansResponseChunk) Reset()         { *m = SpansResponseChunk{} }
 func (m *SpansResponseChunk) String() string { return proto.CompactTextString(m) }
 func (*SpansResponseChunk) ProtoMessage()    {}
 func (*SpansResponseChunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0d2c4ccf1453ffdb, []int{12}
+	return fileDescriptor_0d2c4ccf1453ffdb, []int{13}
 }
 func (m *SpansResponseChunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -720,7 +793,7 @@ func (m *FindTraceIDsRequest) Reset()         { *m = FindTraceIDsRequest{} }
 func (m *FindTraceIDsRequest) String() string { return proto.CompactTextString(m) }
 func (*FindTraceIDsRequest) ProtoMessage()    {}
 func (*FindTraceIDsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0d2c4ccf1453ffdb, []int{13}
+	return fileDescriptor_0d2c4ccf1453ffdb, []int{14}
 }
 func (m *FindTraceIDsRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -767,7 +840,7 @@ func (m *FindTraceIDsResponse) Reset()         { *m = FindTraceIDsResponse{} }
 func (m *FindTraceIDsResponse) String() string { return proto.CompactTextString(m) }
 func (*FindTraceIDsResponse) ProtoMessage()    {}
 func (*FindTraceIDsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0d2c4ccf1453ffdb, []int{14}
+	return fileDescriptor_0d2c4ccf1453ffdb, []int{15}
 }
 func (m *FindTraceIDsResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -796,6 +869,95 @@ func (m *FindTraceIDsResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_FindTraceIDsResponse proto.InternalMessageInfo
 
+type CapabilitiesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CapabilitiesRequest) Reset()         { *m = CapabilitiesRequest{} }
+func (m *CapabilitiesRequest) String() string { return proto.CompactTextString(m) }
+func (*CapabilitiesRequest) ProtoMessage()    {}
+func (*CapabilitiesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0d2c4ccf1453ffdb, []int{16}
+}
+func (m *CapabilitiesRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CapabilitiesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CapabilitiesRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *CapabilitiesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CapabilitiesRequest.Merge(m, src)
+}
+func (m *CapabilitiesRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *CapabilitiesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CapabilitiesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CapabilitiesRequest proto.InternalMessageInfo
+
+// CapabilitiesResponse lists the optional features supported by the plugin.
+// Plugins that do not implement PluginCapabilities support none of them.
+type CapabilitiesResponse struct {
+	// tag_predicates is true if the plugin filters the traces by the tag_predicates of the queries.
+	TagPredicates        bool     `protobuf:"varint,1,opt,name=tag_predicates,json=tagPredicates,proto3" json:"tag_predicates,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CapabilitiesResponse) Reset()         { *m = CapabilitiesResponse{} }
+func (m *CapabilitiesResponse) String() string { return proto.CompactTextString(m) }
+func (*CapabilitiesResponse) ProtoMessage()    {}
+func (*CapabilitiesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0d2c4ccf1453ffdb, []int{17}
+}
+func (m *CapabilitiesResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CapabilitiesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CapabilitiesResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *CapabilitiesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CapabilitiesResponse.Merge(m, src)
+}
+func (m *CapabilitiesResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *CapabilitiesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CapabilitiesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CapabilitiesResponse proto.InternalMessageInfo
+
+func (m *CapabilitiesResponse) GetTagPredicates() bool {
+	if m != nil {
+		return m.TagPredicates
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterType((*GetDependenciesRequest)(nil), "jaeger.storage.v1.GetDependenciesRequest")
 	golang_proto.RegisterType((*GetDependenciesRequest)(nil), "jaeger.storage.v1.GetDependenciesRequest")
@@ -821,6 +983,8 @@ func init() {
 	golang_proto.RegisterType((*TraceQueryParameters)(nil), "jaeger.storage.v1.TraceQueryParameters")
 	proto.RegisterMapType((map[string]string)(nil), "jaeger.storage.v1.TraceQueryParameters.TagsEntry")
 	golang_proto.RegisterMapType((map[string]string)(nil), "jaeger.storage.v1.TraceQueryParameters.TagsEntry")
+	proto.RegisterType((*TagPredicate)(nil), "jaeger.storage.v1.TagPredicate")
+	golang_proto.RegisterType((*TagPredicate)(nil), "jaeger.storage.v1.TagPredicate")
 	proto.RegisterType((*FindTracesRequest)(nil), "jaeger.storage.v1.FindTracesRequest")
 	golang_proto.RegisterType((*FindTracesRequest)(nil), "jaeger.storage.v1.FindTracesRequest")
 	proto.RegisterType((*SpansResponseChunk)(nil), "jaeger.storage.v1.SpansResponseChunk")
@@ -829,73 +993,83 @@ func init() {
 	golang_proto.RegisterType((*FindTraceIDsRequest)(nil), "jaeger.storage.v1.FindTraceIDsRequest")
 	proto.RegisterType((*FindTraceIDsResponse)(nil), "jaeger.storage.v1.FindTraceIDsResponse")
 	golang_proto.RegisterType((*FindTraceIDsResponse)(nil), "jaeger.storage.v1.FindTraceIDsResponse")
+	proto.RegisterType((*CapabilitiesRequest)(nil), "jaeger.storage.v1.CapabilitiesRequest")
+	golang_proto.RegisterType((*CapabilitiesRequest)(nil), "jaeger.storage.v1.CapabilitiesRequest")
+	proto.RegisterType((*CapabilitiesResponse)(nil), "jaeger.storage.v1.CapabilitiesResponse")
+	golang_proto.RegisterType((*CapabilitiesResponse)(nil), "jaeger.storage.v1.CapabilitiesResponse")
 }
 
 func init() { proto.RegisterFile("storage.proto", fileDescriptor_0d2c4ccf1453ffdb) }
 func init() { golang_proto.RegisterFile("storage.proto", fileDescriptor_0d2c4ccf1453ffdb) }
 
 var fileDescriptor_0d2c4ccf1453ffdb = []byte{
-	// 953 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0x4d, 0x73, 0xdb, 0x44,
-	0x18, 0x46, 0x89, 0x5d, 0xdb, 0xaf, 0x9d, 0x92, 0x6c, 0x0c, 0x08, 0xd1, 0xda, 0x41, 0x90, 0x0f,
-	0x98, 0x41, 0x26, 0xe6, 0x00, 0x03, 0x65, 0x00, 0x37, 0xa9, 0x27, 0x40, 0xa1, 0xa8, 0x19, 0x3a,
-	0x43, 0x19, 0x34, 0xeb, 0x68, 0x51, 0xd4, 0x44, 0x2b, 0x55, 0x5a, 0x79, 0x92, 0x03, 0x37, 0x7e,
-	0x00, 0x47, 0x4e, 0x5c, 0xf9, 0x1b, 0x1c, 0x7b, 0xe4, 0xcc, 0x21, 0x30, 0xe1, 0xc8, 0x9f, 0x60,
-	0xf6, 0x43, 0x8a, 0x6c, 0x6b, 0xf2, 0x35, 0xbd, 0x69, 0xdf, 0x7d, 0xde, 0xe7, 0xfd, 0xda, 0xf7,
-	0xb1, 0x61, 0x21, 0x61, 0x61, 0x8c, 0x3d, 0x62, 0x45, 0x71, 0xc8, 0x42, 0xb4, 0xf4, 0x04, 0x13,
-	0x8f, 0xc4, 0x56, 0x66, 0x1d, 0x6f, 0x1a, 0x6d, 0x2f, 0xf4, 0x42, 0x71, 0xdb, 0xe3, 0x5f, 0x12,
-	0x68, 0x74, 0xbd, 0x30, 0xf4, 0x0e, 0x49, 0x4f, 0x9c, 0x46, 0xe9, 0x8f, 0x3d, 0xe6, 0x07, 0x24,
-	0x61, 0x38, 0x88, 0x14, 0xa0, 0x33, 0x0d, 0x70, 0xd3, 0x18, 0x33, 0x3f, 0xa4, 0xea, 0xbe, 0x19,
-	0x84, 0x2e, 0x39, 0x94, 0x07, 0xf3, 0x37, 0x0d, 0x5e, 0x1e, 0x12, 0xb6, 0x45, 0x22, 0x42, 0x5d,
-	0x42, 0xf7, 0x7c, 0x92, 0xd8, 0xe4, 0x69, 0x4a, 0x12, 0x86, 0xee, 0x02, 0x24, 0x0c, 0xc7, 0xcc,
-	0xe1, 0x01, 0x74, 0x6d, 0x45, 0xdb, 0x68, 0xf6, 0x0d, 0x4b, 0x92, 0x5b, 0x19, 0xb9, 0xb5, 0x9b,
-	0x45, 0x1f, 0xd4, 0x9f, 0x9d, 0x74, 0x5f, 0xf8, 0xe5, 0xef, 0xae, 0x66, 0x37, 0x84, 0x1f, 0xbf,
-	0x41, 0x9f, 0x40, 0x9d, 0x50, 0x57, 0x52, 0xcc, 0x5d, 0x81, 0xa2, 0x46, 0xa8, 0xcb, 0xed, 0xe6,
-	0x08, 0x5e, 0x99, 0xc9, 0x2f, 0x89, 0x42, 0x9a, 0x10, 0x34, 0x84, 0x96, 0x5b, 0xb0, 0xeb, 0xda,
-	0xca, 0xfc, 0x46, 0xb3, 0x7f, 0xdb, 0x52, 0x9d, 0xc4, 0x91, 0xef, 0x8c, 0xfb, 0x56, 0xee, 0x7a,
-	0xfc, 0xa5, 0x4f, 0x0f, 0x06, 0x15, 0x1e, 0xc2, 0x9e, 0x70, 0x34, 0x3f, 0x82, 0xc5, 0x47, 0xb1,
-	0xcf, 0xc8, 0xc3, 0x08, 0xd3, 0xac, 0xfa, 0x75, 0xa8, 0x24, 0x11, 0xa6, 0xaa, 0xee, 0xe5, 0x29,
-	0x52, 0x81, 0x14, 0x00, 0x73, 0x19, 0x96, 0x0a, 0xce, 0x32, 0x35, 0x93, 0xc2, 0x8b, 0x43, 0xc2,
-	0x76, 0x63, 0xbc, 0x47, 0x32, 0xc2, 0xc7, 0x50, 0x67, 0xfc, 0xec, 0xf8, 0xae, 0x20, 0x6d, 0x0d,
-	0x3e, 0xe5, 0xa9, 0xfc, 0x75, 0xd2, 0x7d, 0xc7, 0xf3, 0xd9, 0x7e, 0x3a, 0xb2, 0xf6, 0xc2, 0xa0,
-	0x27, 0xc3, 0x70, 0xa0, 0x4f, 0x3d, 0x75, 0xea, 0xc9, 0x81, 0x09, 0xb6, 0x9d, 0xad, 0xd3, 0x93,
-	0x6e, 0x4d, 0x7d, 0xda, 0x35, 0xc1, 0xb8, 0xe3, 0x9a, 0x6d, 0x40, 0x43, 0xc2, 0x1e, 0x92, 0x78,
-	0xec, 0xef, 0xe5, 0x13, 0x34, 0x37, 0x61, 0x79, 0xc2, 0xaa, 0xfa, 0x66, 0x40, 0x3d, 0x51, 0x36,
-	0xd1, 0xb3, 0x86, 0x9d, 0x9f, 0xcd, 0xfb, 0xd0, 0x1e, 0x12, 0xf6, 0x75, 0x44, 0xe4, 0x93, 0xc9,
-	0x1f, 0x83, 0x0e, 0x35, 0x85, 0x11, 0xc9, 0x37, 0xec, 0xec, 0x88, 0x5e, 0x83, 0x06, 0xef, 0x83,
-	0x73, 0xe0, 0x53, 0x57, 0x8c, 0x98, 0xd3, 0x45, 0x98, 0x7e, 0xe1, 0x53, 0xd7, 0xbc, 0x03, 0x8d,
-	0x9c, 0x0b, 0x21, 0xa8, 0x50, 0x1c, 0x64, 0x04, 0xe2, 0xfb, 0x7c, 0xef, 0x9f, 0xe0, 0xa5, 0xa9,
-	0x64, 0x54, 0x05, 0x6b, 0x70, 0x33, 0xcc, 0xac, 0x5f, 0xe1, 0x20, 0xaf, 0x63, 0xca, 0x8a, 0xee,
-	0x00, 0xe4, 0x96, 0x44, 0x9f, 0x13, 0xef, 0xe3, 0x96, 0x35, 0xb3, 0x69, 0x56, 0x1e, 0xc2, 0x2e,
-	0xe0, 0xcd, 0xdf, 0x2b, 0xd0, 0x16, 0x9d, 0xfe, 0x26, 0x25, 0xf1, 0xf1, 0x03, 0x1c, 0xe3, 0x80,
-	0x30, 0x12, 0x27, 0xe8, 0x75, 0x68, 0xa9, 0xea, 0x9d, 0x42, 0x41, 0x4d, 0x65, 0xe3, 0xa1, 0xd1,
-	0x6a, 0x21, 0x43, 0x09, 0x92, 0xc5, 0x2d, 0x4c, 0x64, 0x88, 0xb6, 0xa1, 0xc2, 0xb0, 0x97, 0xe8,
-	0xf3, 0x22, 0xb5, 0xcd, 0x92, 0xd4, 0xca, 0x12, 0xb0, 0x76, 0xb1, 0x97, 0x6c, 0x53, 0x16, 0x1f,
-	0xdb, 0xc2, 0x1d, 0x7d, 0x0e, 0x37, 0xcf, 0x56, 0xd5, 0x09, 0x7c, 0xaa, 0x57, 0xae, 0xb0, 0x6b,
-	0xad, 0x7c, 0x5d, 0xef, 0xfb, 0x74, 0x9a, 0x0b, 0x1f, 0xe9, 0xd5, 0xeb, 0x71, 0xe1, 0x23, 0x74,
-	0x0f, 0x5a, 0x99, 0xf8, 0x88, 0xac, 0x6e, 0x08, 0xa6, 0x57, 0x67, 0x98, 0xb6, 0x14, 0x48, 0x12,
-	0xfd, 0xca, 0x89, 0x9a, 0x99, 0x23, 0xcf, 0x69, 0x82, 0x07, 0x1f, 0xe9, 0xb5, 0xeb, 0xf0, 0xe0,
-	0x23, 0x74, 0x1b, 0x80, 0xa6, 0x81, 0x23, 0xb6, 0x26, 0xd1, 0xeb, 0x2b, 0xda, 0x46, 0xd5, 0x6e,
-	0xd0, 0x34, 0x10, 0x4d, 0x4e, 0x8c, 0xf7, 0xa1, 0x91, 0x77, 0x16, 0x2d, 0xc2, 0xfc, 0x01, 0x39,
-	0x56, 0xb3, 0xe5, 0x9f, 0xa8, 0x0d, 0xd5, 0x31, 0x3e, 0x4c, 0xb3, 0x51, 0xca, 0xc3, 0x87, 0x73,
-	0x1f, 0x68, 0xa6, 0x0d, 0x4b, 0xf7, 0x7c, 0xea, 0x4a, 0x9a, 0x6c, 0x65, 0x3e, 0x86, 0xea, 0x53,
-	0x3e, 0x37, 0x25, 0x21, 0xeb, 0x97, 0x1c, 0xae, 0x2d, 0xbd, 0xcc, 0x6d, 0x40, 0x5c, 0x52, 0xf2,
-	0x47, 0x7f, 0x77, 0x3f, 0xa5, 0x07, 0xa8, 0x07, 0x55, 0xbe, 0x1e, 0x99, 0xd8, 0x95, 0xe9, 0x92,
-	0x92, 0x38, 0x89, 0x33, 0x77, 0x61, 0x39, 0x4f, 0x6d, 0x67, 0xeb, 0x79, 0x25, 0x37, 0x86, 0xf6,
-	0x24, 0xab, 0x5a, 0xcc, 0x1f, 0xa0, 0x91, 0x89, 0x9c, 0x4c, 0xb1, 0x35, 0xf8, 0xec, 0xba, 0x2a,
-	0x57, 0xcf, 0xd9, 0xeb, 0x4a, 0xe6, 0x92, 0xfe, 0x13, 0x58, 0xe4, 0x25, 0x0a, 0xc1, 0x8d, 0x1f,
-	0x1c, 0xa6, 0x9e, 0x4f, 0xd1, 0xb7, 0xd0, 0xc8, 0x05, 0x18, 0xbd, 0x51, 0x52, 0xc8, 0xb4, 0xb6,
-	0x1b, 0x6f, 0x9e, 0x0f, 0x92, 0xb5, 0xf4, 0xff, 0x9b, 0x97, 0xc1, 0x6c, 0x82, 0xdd, 0x3c, 0xd8,
-	0x23, 0xa8, 0x67, 0xc2, 0x8e, 0xcc, 0x12, 0x9a, 0x29, 0xd5, 0x37, 0x56, 0x4b, 0x30, 0xb3, 0x63,
-	0x7d, 0x57, 0x43, 0xdf, 0x43, 0xb3, 0xa0, 0xd5, 0x68, 0xb5, 0x9c, 0x7b, 0x4a, 0xe1, 0x8d, 0xb5,
-	0x8b, 0x60, 0x6a, 0x2e, 0x23, 0x58, 0x98, 0x50, 0x52, 0xb4, 0x5e, 0xee, 0x38, 0x23, 0xfc, 0xc6,
-	0xc6, 0xc5, 0x40, 0x15, 0xe3, 0x31, 0xc0, 0xd9, 0x12, 0xa0, 0xb2, 0x1e, 0xcf, 0xec, 0xc8, 0xe5,
-	0xdb, 0xe3, 0x40, 0xab, 0xf8, 0xe0, 0xd0, 0xda, 0x79, 0xf4, 0x67, 0xef, 0xdc, 0x58, 0xbf, 0x10,
-	0xa7, 0xa6, 0xfd, 0xb3, 0x06, 0xfa, 0xe4, 0xbf, 0x8c, 0xc2, 0xd4, 0xf7, 0xc5, 0xcf, 0x79, 0xf1,
-	0x1a, 0xbd, 0x55, 0xde, 0x97, 0x92, 0x3f, 0x52, 0xc6, 0xdb, 0x97, 0x81, 0xca, 0x34, 0x06, 0xb7,
-	0x9e, 0x9d, 0x76, 0xb4, 0x3f, 0x4f, 0x3b, 0xda, 0x3f, 0xa7, 0x1d, 0xed, 0x8f, 0x7f, 0x3b, 0xda,
-	0x77, 0xa0, 0xbc, 0x9c, 0xf1, 0xe6, 0xe8, 0x86, 0x50, 0xba, 0xf7, 0xfe, 0x0f, 0x00, 0x00, 0xff,
-	0xff, 0x22, 0xdc, 0xa9, 0x3a, 0x3c, 0x0a, 0x00, 0x00,
+	// 1054 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0xcb, 0x6f, 0xdc, 0x44,
+	0x18, 0xc7, 0xcd, 0xa6, 0xb1, 0xbf, 0xdd, 0x84, 0x64, 0xb2, 0x05, 0x63, 0xda, 0x6c, 0x30, 0xe4,
+	0x01, 0x12, 0x5e, 0x12, 0x0e, 0x20, 0x68, 0x05, 0xe4, 0xd1, 0x28, 0xd0, 0x42, 0x70, 0x23, 0x2a,
+	0x51, 0x84, 0x35, 0x1b, 0x0f, 0x8e, 0x9b, 0x78, 0xec, 0xda, 0xe3, 0x55, 0x72, 0xe0, 0xc6, 0x1f,
+	0xc0, 0x91, 0x13, 0x7f, 0x0b, 0xc7, 0x1e, 0x39, 0x73, 0x08, 0x28, 0x1c, 0x39, 0x73, 0x47, 0xf3,
+	0xb0, 0xd7, 0xbb, 0x6b, 0x25, 0x69, 0xc4, 0xcd, 0xf3, 0xcd, 0xef, 0xfb, 0x7d, 0x8f, 0xf9, 0x1e,
+	0x86, 0xe9, 0x8c, 0xc5, 0x29, 0x0e, 0x88, 0x93, 0xa4, 0x31, 0x8b, 0xd1, 0xdc, 0x53, 0x4c, 0x02,
+	0x92, 0x3a, 0x85, 0xb4, 0xbf, 0x66, 0xb5, 0x83, 0x38, 0x88, 0xc5, 0x6d, 0x97, 0x7f, 0x49, 0xa0,
+	0xd5, 0x09, 0xe2, 0x38, 0x38, 0x26, 0x5d, 0x71, 0xea, 0xe5, 0x3f, 0x74, 0x59, 0x18, 0x91, 0x8c,
+	0xe1, 0x28, 0x51, 0x80, 0x85, 0x51, 0x80, 0x9f, 0xa7, 0x98, 0x85, 0x31, 0x55, 0xf7, 0xcd, 0x28,
+	0xf6, 0xc9, 0xb1, 0x3c, 0xd8, 0xbf, 0x6a, 0xf0, 0xca, 0x0e, 0x61, 0x5b, 0x24, 0x21, 0xd4, 0x27,
+	0xf4, 0x20, 0x24, 0x99, 0x4b, 0x9e, 0xe5, 0x24, 0x63, 0x68, 0x13, 0x20, 0x63, 0x38, 0x65, 0x1e,
+	0x37, 0x60, 0x6a, 0x8b, 0xda, 0x6a, 0x73, 0xdd, 0x72, 0x24, 0xb9, 0x53, 0x90, 0x3b, 0xfb, 0x85,
+	0xf5, 0x0d, 0xfd, 0xf9, 0x59, 0xe7, 0xa5, 0x9f, 0xff, 0xec, 0x68, 0xae, 0x21, 0xf4, 0xf8, 0x0d,
+	0xfa, 0x04, 0x74, 0x42, 0x7d, 0x49, 0x71, 0xe3, 0x05, 0x28, 0xa6, 0x08, 0xf5, 0xb9, 0xdc, 0xee,
+	0xc1, 0xab, 0x63, 0xfe, 0x65, 0x49, 0x4c, 0x33, 0x82, 0x76, 0xa0, 0xe5, 0x57, 0xe4, 0xa6, 0xb6,
+	0x38, 0xb1, 0xda, 0x5c, 0xbf, 0xe3, 0xa8, 0x4c, 0xe2, 0x24, 0xf4, 0xfa, 0xeb, 0x4e, 0xa9, 0x7a,
+	0xfa, 0x20, 0xa4, 0x47, 0x1b, 0x0d, 0x6e, 0xc2, 0x1d, 0x52, 0xb4, 0x3f, 0x86, 0xd9, 0xc7, 0x69,
+	0xc8, 0xc8, 0xa3, 0x04, 0xd3, 0x22, 0xfa, 0x15, 0x68, 0x64, 0x09, 0xa6, 0x2a, 0xee, 0xf9, 0x11,
+	0x52, 0x81, 0x14, 0x00, 0x7b, 0x1e, 0xe6, 0x2a, 0xca, 0xd2, 0x35, 0x9b, 0xc2, 0xcb, 0x3b, 0x84,
+	0xed, 0xa7, 0xf8, 0x80, 0x14, 0x84, 0x4f, 0x40, 0x67, 0xfc, 0xec, 0x85, 0xbe, 0x20, 0x6d, 0x6d,
+	0x7c, 0xca, 0x5d, 0xf9, 0xe3, 0xac, 0xf3, 0x6e, 0x10, 0xb2, 0xc3, 0xbc, 0xe7, 0x1c, 0xc4, 0x51,
+	0x57, 0x9a, 0xe1, 0xc0, 0x90, 0x06, 0xea, 0xd4, 0x95, 0x0f, 0x26, 0xd8, 0x76, 0xb7, 0xce, 0xcf,
+	0x3a, 0x53, 0xea, 0xd3, 0x9d, 0x12, 0x8c, 0xbb, 0xbe, 0xdd, 0x06, 0xb4, 0x43, 0xd8, 0x23, 0x92,
+	0xf6, 0xc3, 0x83, 0xf2, 0x05, 0xed, 0x35, 0x98, 0x1f, 0x92, 0xaa, 0xbc, 0x59, 0xa0, 0x67, 0x4a,
+	0x26, 0x72, 0x66, 0xb8, 0xe5, 0xd9, 0x7e, 0x08, 0xed, 0x1d, 0xc2, 0xbe, 0x4a, 0x88, 0x2c, 0x99,
+	0xb2, 0x18, 0x4c, 0x98, 0x52, 0x18, 0xe1, 0xbc, 0xe1, 0x16, 0x47, 0xf4, 0x3a, 0x18, 0x3c, 0x0f,
+	0xde, 0x51, 0x48, 0x7d, 0xf1, 0xc4, 0x9c, 0x2e, 0xc1, 0xf4, 0x8b, 0x90, 0xfa, 0xf6, 0x5d, 0x30,
+	0x4a, 0x2e, 0x84, 0xa0, 0x41, 0x71, 0x54, 0x10, 0x88, 0xef, 0x8b, 0xb5, 0x7f, 0x84, 0x5b, 0x23,
+	0xce, 0xa8, 0x08, 0x96, 0x61, 0x26, 0x2e, 0xa4, 0x5f, 0xe2, 0xa8, 0x8c, 0x63, 0x44, 0x8a, 0xee,
+	0x02, 0x94, 0x92, 0xcc, 0xbc, 0x21, 0xea, 0xe3, 0xb6, 0x33, 0xd6, 0x69, 0x4e, 0x69, 0xc2, 0xad,
+	0xe0, 0xed, 0x7f, 0x1b, 0xd0, 0x16, 0x99, 0xfe, 0x3a, 0x27, 0xe9, 0xe9, 0x1e, 0x4e, 0x71, 0x44,
+	0x18, 0x49, 0x33, 0xf4, 0x06, 0xb4, 0x54, 0xf4, 0x5e, 0x25, 0xa0, 0xa6, 0x92, 0x71, 0xd3, 0x68,
+	0xa9, 0xe2, 0xa1, 0x04, 0xc9, 0xe0, 0xa6, 0x87, 0x3c, 0x44, 0xdb, 0xd0, 0x60, 0x38, 0xc8, 0xcc,
+	0x09, 0xe1, 0xda, 0x5a, 0x8d, 0x6b, 0x75, 0x0e, 0x38, 0xfb, 0x38, 0xc8, 0xb6, 0x29, 0x4b, 0x4f,
+	0x5d, 0xa1, 0x8e, 0x3e, 0x87, 0x99, 0x41, 0xab, 0x7a, 0x51, 0x48, 0xcd, 0xc6, 0x0b, 0xf4, 0x5a,
+	0xab, 0x6c, 0xd7, 0x87, 0x21, 0x1d, 0xe5, 0xc2, 0x27, 0xe6, 0xe4, 0xf5, 0xb8, 0xf0, 0x09, 0xba,
+	0x0f, 0xad, 0x62, 0xf8, 0x08, 0xaf, 0x6e, 0x0a, 0xa6, 0xd7, 0xc6, 0x98, 0xb6, 0x14, 0x48, 0x12,
+	0xfd, 0xc2, 0x89, 0x9a, 0x85, 0x22, 0xf7, 0x69, 0x88, 0x07, 0x9f, 0x98, 0x53, 0xd7, 0xe1, 0xc1,
+	0x27, 0xe8, 0x0e, 0x00, 0xcd, 0x23, 0x4f, 0x74, 0x4d, 0x66, 0xea, 0x8b, 0xda, 0xea, 0xa4, 0x6b,
+	0xd0, 0x3c, 0x12, 0x49, 0xce, 0xd0, 0x03, 0x98, 0x61, 0x38, 0xf0, 0x92, 0x94, 0xf8, 0xe1, 0x01,
+	0x66, 0x24, 0x33, 0x0d, 0xf1, 0x2e, 0x9d, 0xba, 0x77, 0xc1, 0xc1, 0x5e, 0x81, 0x53, 0x43, 0x65,
+	0x9a, 0x55, 0x64, 0x99, 0xf5, 0x01, 0x18, 0xe5, 0x3b, 0xa1, 0x59, 0x98, 0x38, 0x22, 0xa7, 0xaa,
+	0x52, 0xf8, 0x27, 0x6a, 0xc3, 0x64, 0x1f, 0x1f, 0xe7, 0x45, 0x61, 0xc8, 0xc3, 0x47, 0x37, 0x3e,
+	0xd4, 0x6c, 0x17, 0x5a, 0x55, 0xf6, 0x1a, 0x5d, 0x0b, 0x74, 0x59, 0x47, 0x71, 0x5a, 0x34, 0x4d,
+	0x71, 0x1e, 0xf0, 0x4e, 0x54, 0x78, 0x6d, 0x17, 0xe6, 0xee, 0x87, 0xd4, 0x97, 0x81, 0x16, 0x4d,
+	0x7d, 0x0f, 0x26, 0x9f, 0xf1, 0xca, 0x52, 0x43, 0x6e, 0xe5, 0x8a, 0xe5, 0xe7, 0x4a, 0x2d, 0x7b,
+	0x1b, 0x10, 0x1f, 0x7a, 0x65, 0x5b, 0x6e, 0x1e, 0xe6, 0xf4, 0x08, 0x75, 0x61, 0x92, 0x37, 0x70,
+	0x31, 0x8e, 0xeb, 0x26, 0xa7, 0xca, 0x97, 0xc4, 0xd9, 0xfb, 0x30, 0x5f, 0xba, 0xb6, 0xbb, 0xf5,
+	0x7f, 0x39, 0xd7, 0x87, 0xf6, 0x30, 0xab, 0x1a, 0x1d, 0xdf, 0x83, 0x51, 0x8c, 0x61, 0xe9, 0x62,
+	0x6b, 0xe3, 0xb3, 0xeb, 0xce, 0x61, 0xbd, 0x64, 0xd7, 0xd5, 0x20, 0xce, 0xec, 0x5b, 0x30, 0xbf,
+	0x89, 0x13, 0xdc, 0x0b, 0x8f, 0x43, 0x36, 0x58, 0xa6, 0xf6, 0x3d, 0x68, 0x0f, 0x8b, 0x95, 0x3b,
+	0x4b, 0x63, 0x25, 0xc7, 0xc3, 0xd5, 0x47, 0x6a, 0x69, 0xfd, 0x29, 0xcc, 0xf2, 0xc4, 0x89, 0x45,
+	0x93, 0xee, 0x1d, 0xe7, 0x41, 0x48, 0xd1, 0x37, 0x60, 0x94, 0x8b, 0x07, 0xbd, 0x59, 0x93, 0x9e,
+	0xd1, 0x9d, 0x66, 0xbd, 0x75, 0x31, 0x48, 0xba, 0xb4, 0xfe, 0xcf, 0x84, 0x34, 0xe6, 0x12, 0xec,
+	0x97, 0xc6, 0x1e, 0x83, 0x5e, 0x2c, 0x34, 0x64, 0xd7, 0xd0, 0x8c, 0x6c, 0x3b, 0x6b, 0xa9, 0x06,
+	0x33, 0x5e, 0x2c, 0xef, 0x69, 0xe8, 0x3b, 0x68, 0x56, 0x76, 0x14, 0x5a, 0xaa, 0xe7, 0x1e, 0xd9,
+	0x6c, 0xd6, 0xf2, 0x65, 0x30, 0x95, 0xde, 0x1e, 0x4c, 0x0f, 0x6d, 0x10, 0xb4, 0x52, 0xaf, 0x38,
+	0xb6, 0xf0, 0xac, 0xd5, 0xcb, 0x81, 0xca, 0xc6, 0x13, 0x80, 0x41, 0x6b, 0xa1, 0xba, 0x1c, 0x8f,
+	0x75, 0xde, 0xd5, 0xd3, 0xe3, 0x41, 0xab, 0x5a, 0xc6, 0x68, 0xf9, 0x22, 0xfa, 0x41, 0xf7, 0x58,
+	0x2b, 0x97, 0xe2, 0xd4, 0x6b, 0xff, 0xa4, 0x81, 0x39, 0xfc, 0x77, 0x55, 0x79, 0xf5, 0x43, 0xf1,
+	0x1b, 0x53, 0xbd, 0x46, 0x6f, 0xd7, 0xe7, 0xa5, 0xe6, 0x07, 0xd2, 0x7a, 0xe7, 0x2a, 0x50, 0xe5,
+	0x46, 0x0e, 0x48, 0xda, 0xac, 0x76, 0x09, 0x8f, 0x7e, 0xe8, 0x5c, 0x17, 0x7d, 0x4d, 0xb7, 0x59,
+	0x2b, 0x97, 0xe2, 0xa4, 0xd9, 0x8d, 0xdb, 0xcf, 0xcf, 0x17, 0xb4, 0xdf, 0xcf, 0x17, 0xb4, 0xbf,
+	0xce, 0x17, 0xb4, 0xdf, 0xfe, 0x5e, 0xd0, 0xbe, 0x05, 0xa5, 0xe2, 0xf5, 0xd7, 0x7a, 0x37, 0xc5,
+	0x62, 0x79, 0xff, 0xbf, 0x01, 0x00, 0xb4, 0x4e, 0xa8, 0x2a, 0xab, 0x0b, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1291,6 +1465,70 @@ var _DependenciesReaderPlugin_serviceDesc = grpc.ServiceDesc{
 	Metadata: "storage.proto",
 }
 
+// PluginCapabilitiesClient is the client API for PluginCapabilities service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type PluginCapabilitiesClient interface {
+	Capabilities(ctx context.Context, in *CapabilitiesRequest, opts ...grpc.CallOption) (*CapabilitiesResponse, error)
+}
+
+type pluginCapabilitiesClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewPluginCapabilitiesClient(cc *grpc.ClientConn) PluginCapabilitiesClient {
+	return &pluginCapabilitiesClient{cc}
+}
+
+func (c *pluginCapabilitiesClient) Capabilities(ctx context.Context, in *CapabilitiesRequest, opts ...grpc.CallOption) (*CapabilitiesResponse, error) {
+	out := new(CapabilitiesResponse)
+	err := c.cc.Invoke(ctx, "/jaeger.storage.v1.PluginCapabilities/Capabilities", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PluginCapabilitiesServer is the server API for PluginCapabilities service.
+type PluginCapabilitiesServer interface {
+	Capabilities(context.Context, *CapabilitiesRequest) (*CapabilitiesResponse, error)
+}
+
+func RegisterPluginCapabilitiesServer(s *grpc.Server, srv PluginCapabilitiesServer) {
+	s.RegisterService(&_PluginCapabilities_serviceDesc, srv)
+}
+
+func _PluginCapabilities_Capabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CapabilitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginCapabilitiesServer).Capabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jaeger.storage.v1.PluginCapabilities/Capabilities",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginCapabilitiesServer).Capabilities(ctx, req.(*CapabilitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _PluginCapabilities_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "jaeger.storage.v1.PluginCapabilities",
+	HandlerType: (*PluginCapabilitiesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Capabilities",
+			Handler:    _PluginCapabilities_Capabilities_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "storage.proto",
+}
+
 func (m *GetDependenciesRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -1694,6 +1932,57 @@ func (m *TraceQueryParameters) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintStorage(dAtA, i, uint64(m.NumTraces))
 	}
+	if len(m.TagPredicates) > 0 {
+		for _, msg := range m.TagPredicates {
+			dAtA[i] = 0x4a
+			i++
+			i = encodeVarintStorage(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func (m *TagPredicate) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TagPredicate) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Key) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintStorage(dAtA, i, uint64(len(m.Key)))
+		i += copy(dAtA[i:], m.Key)
+	}
+	if len(m.Operator) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintStorage(dAtA, i, uint64(len(m.Operator)))
+		i += copy(dAtA[i:], m.Operator)
+	}
+	if len(m.Value) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintStorage(dAtA, i, uint64(len(m.Value)))
+		i += copy(dAtA[i:], m.Value)
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
@@ -1828,6 +2117,58 @@ func (m *FindTraceIDsResponse) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
+func (m *CapabilitiesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CapabilitiesRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func (m *CapabilitiesResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CapabilitiesResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.TagPredicates {
+		dAtA[i] = 0x8
+		i++
+		if m.TagPredicates {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
 func encodeVarintStorage(dAtA []byte, offset int, v uint64) int {
 	for v >= 1<<7 {
 		dAtA[offset] = uint8(v&0x7f | 0x80)
@@ -2040,6 +2381,36 @@ func (m *TraceQueryParameters) Size() (n int) {
 	if m.NumTraces != 0 {
 		n += 1 + sovStorage(uint64(m.NumTraces))
 	}
+	if len(m.TagPredicates) > 0 {
+		for _, e := range m.TagPredicates {
+			l = e.Size()
+			n += 1 + l + sovStorage(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *TagPredicate) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovStorage(uint64(l))
+	}
+	l = len(m.Operator)
+	if l > 0 {
+		n += 1 + l + sovStorage(uint64(l))
+	}
+	l = len(m.Value)
+	if l > 0 {
+		n += 1 + l + sovStorage(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -2114,15 +2485,42 @@ func (m *FindTraceIDsResponse) Size() (n int) {
 	return n
 }
 
-func sovStorage(x uint64) (n int) {
-	for {
-		n++
-		x >>= 7
-		if x == 0 {
-			break
-		}
+func (m *CapabilitiesRequest) Size() (n int) {
+	if m == nil {
+		return 0
 	}
-	return n
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *CapabilitiesResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.TagPredicates {
+		n += 2
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func sovStorage(x uint64) (n int) {
+	for {
+		n++
+		x >>= 7
+		if x == 0 {
+			break
+		}
+	}
+	return n
 }
 func sozStorage(x uint64) (n int) {
 	return sovStorage(uint64((x << 1) ^ uint64((int64(x) >> 63))))
@@ -3433,6 +3831,190 @@ func (m *TraceQueryParameters) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TagPredicates", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStorage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStorage
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.TagPredicates = append(m.TagPredicates, TagPredicate{})
+			if err := m.TagPredicates[len(m.TagPredicates)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStorage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *TagPredicate) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStorage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TagPredicate: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TagPredicate: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStorage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthStorage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Operator", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStorage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthStorage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Operator = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStorage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthStorage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Value = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStorage(dAtA[iNdEx:])
@@ -3815,6 +4397,134 @@ func (m *FindTraceIDsResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *CapabilitiesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStorage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CapabilitiesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CapabilitiesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStorage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CapabilitiesResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStorage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CapabilitiesResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CapabilitiesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TagPredicates", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStorage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.TagPredicates = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStorage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipStorage(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
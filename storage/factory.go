@@ -21,7 +21,9 @@ import (
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/pkg/distributedlock"
 	"github.com/jaegertracing/jaeger/storage/dependencystore"
+	"github.com/jaegertracing/jaeger/storage/samplingstore"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -62,3 +64,26 @@ type ArchiveFactory interface {
 	// CreateArchiveSpanWriter creates a spanstore.Writer.
 	CreateArchiveSpanWriter() (spanstore.Writer, error)
 }
+
+// SamplingStoreFactory is an additional interface that can be implemented by a factory to support
+// adaptive sampling, which needs a place to keep aggregated throughput and a lock for leader election.
+type SamplingStoreFactory interface {
+	// CreateLock creates a distributedlock.Lock.
+	CreateLock() (distributedlock.Lock, error)
+
+	// CreateSamplingStore creates a samplingstore.Store.
+	CreateSamplingStore() (samplingstore.Store, error)
+}
+
+// ErrTenancyNotSupported can be returned when multi-tenancy is not supported by the backend.
+var ErrTenancyNotSupported = errors.New("multi-tenancy not supported")
+
+// TenantFactory is an additional interface that can be implemented by a factory to keep
+// the spans of every tenant apart from the spans of the other tenants.
+type TenantFactory interface {
+	// CreateTenantSpanReader creates a spanstore.Reader restricted to the spans of the tenant.
+	CreateTenantSpanReader(tenant string) (spanstore.Reader, error)
+
+	// CreateTenantSpanWriter creates a spanstore.Writer storing spans on behalf of the tenant.
+	CreateTenantSpanWriter(tenant string) (spanstore.Writer, error)
+}
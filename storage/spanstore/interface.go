@@ -31,6 +31,14 @@ type Writer interface {
 var (
 	// ErrTraceNotFound is returned by Reader's GetTrace if no data is found for given trace ID.
 	ErrTraceNotFound = errors.New("trace not found")
+
+	// ErrOperationStatsNotSupported is returned by OperationStatsReader's GetOperationStats if the storage
+	// cannot aggregate the stats natively, e.g. by a decorator wrapping a Reader that does not implement it.
+	ErrOperationStatsNotSupported = errors.New("operation stats are not supported by the span storage")
+
+	// ErrUnsupportedTagOperator is returned by Reader's FindTraces and FindTraceIDs if the storage
+	// cannot evaluate the operator of one of the TagPredicates of the query.
+	ErrUnsupportedTagOperator = errors.New("tag operator is not supported by the span storage")
 )
 
 // Reader finds and loads traces and other data from storage.
@@ -42,11 +50,17 @@ type Reader interface {
 	FindTraceIDs(ctx context.Context, query *TraceQueryParameters) ([]model.TraceID, error)
 }
 
+// OperationStatsReader is an optional capability of Readers that aggregate the stats of operations natively.
+type OperationStatsReader interface {
+	GetOperationStats(ctx context.Context, query *OperationStatsQueryParameters) ([]OperationStats, error)
+}
+
 // TraceQueryParameters contains parameters of a trace query.
 type TraceQueryParameters struct {
 	ServiceName   string
 	OperationName string
 	Tags          map[string]string
+	TagPredicates []TagPredicate
 	StartTimeMin  time.Time
 	StartTimeMax  time.Time
 	DurationMin   time.Duration
@@ -65,3 +79,22 @@ type Operation struct {
 	Name     string
 	SpanKind string
 }
+
+// OperationStatsQueryParameters contains parameters of an operation stats query.
+type OperationStatsQueryParameters struct {
+	ServiceName  string
+	StartTimeMin time.Time
+	StartTimeMax time.Time
+	// Percentiles are the latency percentiles to compute, between 0 and 100.
+	Percentiles []float64
+}
+
+// OperationStats contains the number of spans, the number of spans tagged as errors,
+// and the latency percentiles of an operation.
+type OperationStats struct {
+	Operation string
+	Count     int64
+	Errors    int64
+	// Latencies holds the latency percentiles, in the order of OperationStatsQueryParameters.Percentiles.
+	Latencies []time.Duration
+}
@@ -33,6 +33,7 @@ type ReadMetricsDecorator struct {
 	getTraceMetrics      *queryMetrics
 	getServicesMetrics   *queryMetrics
 	getOperationsMetrics *queryMetrics
+	getOpStatsMetrics    *queryMetrics
 }
 
 type queryMetrics struct {
@@ -63,6 +64,7 @@ func NewReadMetricsDecorator(spanReader spanstore.Reader, metricsFactory metrics
 		getTraceMetrics:      buildQueryMetrics("get_trace", metricsFactory),
 		getServicesMetrics:   buildQueryMetrics("get_services", metricsFactory),
 		getOperationsMetrics: buildQueryMetrics("get_operations", metricsFactory),
+		getOpStatsMetrics:    buildQueryMetrics("get_operation_stats", metricsFactory),
 	}
 }
 
@@ -115,3 +117,18 @@ func (m *ReadMetricsDecorator) GetOperations(
 	m.getOperationsMetrics.emit(err, time.Since(start), len(retMe))
 	return retMe, err
 }
+
+// GetOperationStats implements spanstore.OperationStatsReader#GetOperationStats.
+// It returns spanstore.ErrOperationStatsNotSupported if the wrapped reader does not implement it.
+func (m *ReadMetricsDecorator) GetOperationStats(ctx context.Context, query *spanstore.OperationStatsQueryParameters) ([]spanstore.OperationStats, error) {
+	statsReader, ok := m.spanReader.(spanstore.OperationStatsReader)
+	if !ok {
+		return nil, spanstore.ErrOperationStatsNotSupported
+	}
+	start := time.Now()
+	retMe, err := statsReader.GetOperationStats(ctx, query)
+	if err != spanstore.ErrOperationStatsNotSupported {
+		m.getOpStatsMetrics.emit(err, time.Since(start), len(retMe))
+	}
+	return retMe, err
+}
@@ -142,3 +142,47 @@ func TestFailingUnderlyingCalls(t *testing.T) {
 
 	checkExpectedExistingAndNonExistentCounters(t, counters, expecteds, gauges, existingKeys, nonExistentKeys)
 }
+
+type statsReader struct {
+	mocks.Reader
+	stats []spanstore.OperationStats
+	err   error
+}
+
+func (r *statsReader) GetOperationStats(ctx context.Context, query *spanstore.OperationStatsQueryParameters) ([]spanstore.OperationStats, error) {
+	return r.stats, r.err
+}
+
+func TestGetOperationStats(t *testing.T) {
+	query := &spanstore.OperationStatsQueryParameters{ServiceName: "something"}
+
+	mf := metricstest.NewFactory(0)
+	mrs := NewReadMetricsDecorator(&statsReader{stats: []spanstore.OperationStats{{Operation: "op"}}}, mf)
+	stats, err := mrs.GetOperationStats(context.Background(), query)
+	assert.NoError(t, err)
+	assert.Len(t, stats, 1)
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "requests", Tags: map[string]string{"operation": "get_operation_stats", "result": "ok"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "requests", Tags: map[string]string{"operation": "get_operation_stats", "result": "err"}, Value: 0},
+	)
+
+	mf = metricstest.NewFactory(0)
+	mrs = NewReadMetricsDecorator(&statsReader{err: errors.New("Failure")}, mf)
+	_, err = mrs.GetOperationStats(context.Background(), query)
+	assert.EqualError(t, err, "Failure")
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "requests", Tags: map[string]string{"operation": "get_operation_stats", "result": "err"}, Value: 1},
+	)
+
+	mf = metricstest.NewFactory(0)
+	mrs = NewReadMetricsDecorator(&statsReader{err: spanstore.ErrOperationStatsNotSupported}, mf)
+	_, err = mrs.GetOperationStats(context.Background(), query)
+	assert.Equal(t, spanstore.ErrOperationStatsNotSupported, err)
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "requests", Tags: map[string]string{"operation": "get_operation_stats", "result": "err"}, Value: 0},
+	)
+
+	mrs = NewReadMetricsDecorator(&mocks.Reader{}, metricstest.NewFactory(0))
+	_, err = mrs.GetOperationStats(context.Background(), query)
+	assert.Equal(t, spanstore.ErrOperationStatsNotSupported, err)
+}
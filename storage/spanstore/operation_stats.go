@@ -0,0 +1,74 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanstore
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// AggregateOperationStats computes the stats of the operations of the spans matching the query, for
+// storages that do not aggregate them natively. Operations are sorted by name, and the percentiles are
+// computed with the nearest-rank method.
+func AggregateOperationStats(spans []*model.Span, query *OperationStatsQueryParameters) []OperationStats {
+	durations := make(map[string][]time.Duration)
+	errors := make(map[string]int64)
+	for _, span := range spans {
+		if span.Process == nil || span.Process.ServiceName != query.ServiceName {
+			continue
+		}
+		if !query.StartTimeMin.IsZero() && span.StartTime.Before(query.StartTimeMin) {
+			continue
+		}
+		if !query.StartTimeMax.IsZero() && span.StartTime.After(query.StartTimeMax) {
+			continue
+		}
+		durations[span.OperationName] = append(durations[span.OperationName], span.Duration)
+		if span.IsError() {
+			errors[span.OperationName]++
+		}
+	}
+
+	stats := make([]OperationStats, 0, len(durations))
+	for operation, opDurations := range durations {
+		sort.Slice(opDurations, func(i, j int) bool {
+			return opDurations[i] < opDurations[j]
+		})
+		latencies := make([]time.Duration, len(query.Percentiles))
+		for i, percentile := range query.Percentiles {
+			rank := int(math.Ceil(percentile / 100 * float64(len(opDurations))))
+			if rank < 1 {
+				rank = 1
+			}
+			if rank > len(opDurations) {
+				rank = len(opDurations)
+			}
+			latencies[i] = opDurations[rank-1]
+		}
+		stats = append(stats, OperationStats{
+			Operation: operation,
+			Count:     int64(len(opDurations)),
+			Errors:    errors[operation],
+			Latencies: latencies,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Operation < stats[j].Operation
+	})
+	return stats
+}
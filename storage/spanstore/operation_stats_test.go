@@ -0,0 +1,74 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanstore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jaegertracing/jaeger/model"
+	. "github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+func statsSpan(service, operation string, start time.Time, duration time.Duration, tags ...model.KeyValue) *model.Span {
+	return &model.Span{
+		OperationName: operation,
+		Process:       model.NewProcess(service, nil),
+		StartTime:     start,
+		Duration:      duration,
+		Tags:          tags,
+	}
+}
+
+func TestAggregateOperationStats(t *testing.T) {
+	now := time.Now()
+	spans := []*model.Span{
+		statsSpan("svc", "b", now, 4*time.Millisecond),
+		statsSpan("svc", "b", now, 1*time.Millisecond, model.Bool("error", true)),
+		statsSpan("svc", "b", now, 3*time.Millisecond),
+		statsSpan("svc", "b", now, 2*time.Millisecond, model.String("error", "true")),
+		statsSpan("svc", "a", now, 5*time.Millisecond, model.Bool("error", false)),
+		statsSpan("other", "a", now, time.Second),
+		statsSpan("svc", "a", now.Add(-time.Hour), time.Second),
+		statsSpan("svc", "a", now.Add(time.Hour), time.Second),
+		{OperationName: "a", StartTime: now, Duration: time.Second},
+	}
+	stats := AggregateOperationStats(spans, &OperationStatsQueryParameters{
+		ServiceName:  "svc",
+		StartTimeMin: now.Add(-time.Minute),
+		StartTimeMax: now.Add(time.Minute),
+		Percentiles:  []float64{0.1, 50, 75, 100},
+	})
+	assert.Equal(t, []OperationStats{
+		{
+			Operation: "a",
+			Count:     1,
+			Latencies: []time.Duration{5 * time.Millisecond, 5 * time.Millisecond, 5 * time.Millisecond, 5 * time.Millisecond},
+		},
+		{
+			Operation: "b",
+			Count:     4,
+			Errors:    2,
+			Latencies: []time.Duration{1 * time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond, 4 * time.Millisecond},
+		},
+	}, stats)
+}
+
+func TestAggregateOperationStatsNoSpans(t *testing.T) {
+	stats := AggregateOperationStats(nil, &OperationStatsQueryParameters{ServiceName: "svc"})
+	assert.Empty(t, stats)
+}
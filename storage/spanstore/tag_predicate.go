@@ -0,0 +1,169 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanstore
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// TagOperator is the comparison a TagPredicate applies to the value of a tag.
+type TagOperator string
+
+const (
+	// TagEquals matches the tags whose value is equal to the predicate value.
+	TagEquals TagOperator = "="
+	// TagNotEquals matches the tags whose value is different from the predicate value.
+	TagNotEquals TagOperator = "!="
+	// TagMatches matches the tags whose whole value matches the predicate regular expression.
+	// The expression uses the RE2 syntax, but storages that evaluate it in the database may only
+	// accept a subset of it, e.g. Elasticsearch rejects anchors, flags and backslash classes.
+	TagMatches TagOperator = "=~"
+	// TagGreaterThan matches the numeric tags greater than the predicate value.
+	TagGreaterThan TagOperator = ">"
+	// TagGreaterThanOrEqual matches the numeric tags greater than or equal to the predicate value.
+	TagGreaterThanOrEqual TagOperator = ">="
+	// TagLessThan matches the numeric tags less than the predicate value.
+	TagLessThan TagOperator = "<"
+	// TagLessThanOrEqual matches the numeric tags less than or equal to the predicate value.
+	TagLessThanOrEqual TagOperator = "<="
+	// TagExists matches the tags with the predicate key, whatever their value.
+	TagExists TagOperator = "exists"
+)
+
+// TagPredicate is a condition on a tag of the spans, which are matched if one of their tags,
+// process tags or log fields has the predicate key and a value satisfying the operator.
+type TagPredicate struct {
+	Key      string
+	Operator TagOperator
+	Value    string
+}
+
+// TagMatcher evaluates a TagPredicate against spans, for storages that filter spans in memory.
+type TagMatcher struct {
+	predicate TagPredicate
+	regex     *regexp.Regexp
+	number    float64
+}
+
+// NewTagMatcher returns a TagMatcher for the predicate, or an error if the predicate is invalid.
+func NewTagMatcher(predicate TagPredicate) (*TagMatcher, error) {
+	m := &TagMatcher{predicate: predicate}
+	switch predicate.Operator {
+	case TagEquals, TagNotEquals, TagExists:
+	case TagMatches:
+		regex, err := regexp.Compile("^(?:" + predicate.Value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression for tag %s: %w", predicate.Key, err)
+		}
+		m.regex = regex
+	case TagGreaterThan, TagGreaterThanOrEqual, TagLessThan, TagLessThanOrEqual:
+		number, err := strconv.ParseFloat(predicate.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number for tag %s: %w", predicate.Key, err)
+		}
+		m.number = number
+	default:
+		return nil, fmt.Errorf("unknown tag operator: %s", predicate.Operator)
+	}
+	return m, nil
+}
+
+// NewTagMatchers returns the TagMatchers of the predicates, or the error of the first invalid predicate.
+func NewTagMatchers(predicates []TagPredicate) ([]*TagMatcher, error) {
+	matchers := make([]*TagMatcher, len(predicates))
+	for i, predicate := range predicates {
+		m, err := NewTagMatcher(predicate)
+		if err != nil {
+			return nil, err
+		}
+		matchers[i] = m
+	}
+	return matchers, nil
+}
+
+// MatchValue returns true if the value of a tag with the predicate key satisfies the operator.
+func (m *TagMatcher) MatchValue(value string) bool {
+	switch m.predicate.Operator {
+	case TagEquals:
+		return value == m.predicate.Value
+	case TagNotEquals:
+		return value != m.predicate.Value
+	case TagMatches:
+		return m.regex.MatchString(value)
+	case TagExists:
+		return true
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return false
+	}
+	switch m.predicate.Operator {
+	case TagGreaterThan:
+		return number > m.number
+	case TagGreaterThanOrEqual:
+		return number >= m.number
+	case TagLessThan:
+		return number < m.number
+	default:
+		return number <= m.number
+	}
+}
+
+// Match returns true if one of the tags, process tags or log fields of the span satisfies the predicate.
+func (m *TagMatcher) Match(span *model.Span) bool {
+	if m.matchKeyValues(span.Tags) {
+		return true
+	}
+	if span.Process != nil && m.matchKeyValues(span.Process.Tags) {
+		return true
+	}
+	for _, log := range span.Logs {
+		if m.matchKeyValues(log.Fields) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchTrace returns true if each of the matchers is satisfied by a span of the service in the trace.
+func MatchTrace(trace *model.Trace, serviceName string, matchers []*TagMatcher) bool {
+	for _, m := range matchers {
+		matched := false
+		for _, span := range trace.Spans {
+			if span.Process != nil && span.Process.ServiceName == serviceName && m.Match(span) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *TagMatcher) matchKeyValues(kvs []model.KeyValue) bool {
+	// (NB): we cannot use the KeyValues.FindKey function because there can be multiple tags with the same key
+	for _, kv := range kvs {
+		if kv.Key == m.predicate.Key && m.MatchValue(kv.AsString()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanstore_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+	. "github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+func TestTagMatcherMatchValue(t *testing.T) {
+	testCases := []struct {
+		predicate TagPredicate
+		matches   []string
+		misses    []string
+	}{
+		{
+			predicate: TagPredicate{Key: "k", Operator: TagEquals, Value: "500"},
+			matches:   []string{"500"},
+			misses:    []string{"5000", "404"},
+		},
+		{
+			predicate: TagPredicate{Key: "k", Operator: TagNotEquals, Value: "500"},
+			matches:   []string{"5000", "404"},
+			misses:    []string{"500"},
+		},
+		{
+			predicate: TagPredicate{Key: "k", Operator: TagMatches, Value: "5.."},
+			matches:   []string{"500", "503"},
+			misses:    []string{"5000", "404", "1500"},
+		},
+		{
+			predicate: TagPredicate{Key: "k", Operator: TagGreaterThan, Value: "500"},
+			matches:   []string{"501", "1e3"},
+			misses:    []string{"500", "404", "abc"},
+		},
+		{
+			predicate: TagPredicate{Key: "k", Operator: TagGreaterThanOrEqual, Value: "500"},
+			matches:   []string{"500", "500.5"},
+			misses:    []string{"499.9", "abc"},
+		},
+		{
+			predicate: TagPredicate{Key: "k", Operator: TagLessThan, Value: "500"},
+			matches:   []string{"404", "-1"},
+			misses:    []string{"500", "abc"},
+		},
+		{
+			predicate: TagPredicate{Key: "k", Operator: TagLessThanOrEqual, Value: "500"},
+			matches:   []string{"500", "404"},
+			misses:    []string{"501", "abc"},
+		},
+		{
+			predicate: TagPredicate{Key: "k", Operator: TagExists},
+			matches:   []string{"", "anything"},
+		},
+	}
+	for _, testCase := range testCases {
+		tc := testCase // capture loop var
+		t.Run(tc.predicate.Key+string(tc.predicate.Operator)+tc.predicate.Value, func(t *testing.T) {
+			m, err := NewTagMatcher(tc.predicate)
+			require.NoError(t, err)
+			for _, value := range tc.matches {
+				assert.True(t, m.MatchValue(value), value)
+			}
+			for _, value := range tc.misses {
+				assert.False(t, m.MatchValue(value), value)
+			}
+		})
+	}
+}
+
+func TestNewTagMatcherErrors(t *testing.T) {
+	_, err := NewTagMatcher(TagPredicate{Key: "k", Operator: TagMatches, Value: "("})
+	assert.EqualError(t, err, "invalid regular expression for tag k: error parsing regexp: missing closing ): `^(?:()$`")
+	_, err = NewTagMatcher(TagPredicate{Key: "k", Operator: TagGreaterThan, Value: "abc"})
+	assert.EqualError(t, err, `invalid number for tag k: strconv.ParseFloat: parsing "abc": invalid syntax`)
+	_, err = NewTagMatcher(TagPredicate{Key: "k", Operator: "~", Value: "abc"})
+	assert.EqualError(t, err, "unknown tag operator: ~")
+	_, err = NewTagMatchers([]TagPredicate{
+		{Key: "k", Operator: TagExists},
+		{Key: "k", Operator: "~"},
+	})
+	assert.EqualError(t, err, "unknown tag operator: ~")
+}
+
+func TestTagMatcherMatch(t *testing.T) {
+	span := &model.Span{
+		Tags:    model.KeyValues{model.Int64("http.status_code", 200), model.Int64("http.status_code", 503)},
+		Process: model.NewProcess("svc", []model.KeyValue{model.String("hostname", "host-1")}),
+		Logs: []model.Log{
+			{Fields: []model.KeyValue{model.String("event", "retry")}},
+		},
+	}
+	matchers, err := NewTagMatchers([]TagPredicate{
+		{Key: "http.status_code", Operator: TagGreaterThanOrEqual, Value: "500"},
+		{Key: "hostname", Operator: TagMatches, Value: "host-.*"},
+		{Key: "event", Operator: TagExists},
+	})
+	require.NoError(t, err)
+	for _, m := range matchers {
+		assert.True(t, m.Match(span))
+	}
+
+	m, err := NewTagMatcher(TagPredicate{Key: "error", Operator: TagExists})
+	require.NoError(t, err)
+	assert.False(t, m.Match(span))
+	assert.False(t, m.Match(&model.Span{}))
+}
+
+func TestMatchTrace(t *testing.T) {
+	trace := &model.Trace{Spans: []*model.Span{
+		{Tags: model.KeyValues{model.Int64("http.status_code", 503)}, Process: model.NewProcess("frontend", nil)},
+		{Tags: model.KeyValues{model.Bool("error", true)}, Process: model.NewProcess("db", nil)},
+	}}
+	matchers, err := NewTagMatchers([]TagPredicate{
+		{Key: "http.status_code", Operator: TagGreaterThanOrEqual, Value: "500"},
+		{Key: "error", Operator: TagNotEquals, Value: "false"},
+	})
+	require.NoError(t, err)
+	// the predicates must be satisfied by spans of the service
+	assert.False(t, MatchTrace(trace, "frontend", matchers))
+	assert.True(t, MatchTrace(trace, "frontend", matchers[:1]))
+	assert.True(t, MatchTrace(trace, "db", matchers[1:]))
+	assert.True(t, MatchTrace(trace, "db", nil))
+}
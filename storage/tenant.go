@@ -0,0 +1,153 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+// TenantSpanWriters creates a spanstore.Writer per tenant on first use and reuses it afterwards.
+// The writers are not closed, as their resources are usually shared with the primary writer
+// and released by the factory. The cache is bounded by the list of tenants, which is required
+// when multi-tenancy is enabled, see tenancy.Options.Validate.
+type TenantSpanWriters struct {
+	factory TenantFactory
+	lock    sync.Mutex
+	writers map[string]spanstore.Writer
+}
+
+// NewTenantSpanWriters creates TenantSpanWriters backed by the given factory.
+func NewTenantSpanWriters(factory TenantFactory) *TenantSpanWriters {
+	return &TenantSpanWriters{
+		factory: factory,
+		writers: make(map[string]spanstore.Writer),
+	}
+}
+
+// SpanWriter returns the spanstore.Writer of the tenant.
+func (w *TenantSpanWriters) SpanWriter(tenant string) (spanstore.Writer, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if writer, ok := w.writers[tenant]; ok {
+		return writer, nil
+	}
+	writer, err := w.factory.CreateTenantSpanWriter(tenant)
+	if err != nil {
+		return nil, err
+	}
+	w.writers[tenant] = writer
+	return writer, nil
+}
+
+// TenantSpanReader is a spanstore.Reader reading the spans of the tenant associated with
+// the context of each call, see tenancy.GetTenant. Calls without a tenant are served by
+// the default reader. Like TenantSpanWriters, it relies on the list of tenants to bound its cache.
+type TenantSpanReader struct {
+	factory       TenantFactory
+	defaultReader spanstore.Reader
+	lock          sync.Mutex
+	readers       map[string]spanstore.Reader
+}
+
+// NewTenantSpanReader creates a TenantSpanReader backed by the given factory.
+func NewTenantSpanReader(factory TenantFactory, defaultReader spanstore.Reader) *TenantSpanReader {
+	return &TenantSpanReader{
+		factory:       factory,
+		defaultReader: defaultReader,
+		readers:       make(map[string]spanstore.Reader),
+	}
+}
+
+func (r *TenantSpanReader) reader(ctx context.Context) (spanstore.Reader, error) {
+	tenant := tenancy.GetTenant(ctx)
+	if tenant == "" {
+		return r.defaultReader, nil
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if reader, ok := r.readers[tenant]; ok {
+		return reader, nil
+	}
+	reader, err := r.factory.CreateTenantSpanReader(tenant)
+	if err != nil {
+		return nil, err
+	}
+	r.readers[tenant] = reader
+	return reader, nil
+}
+
+// GetTrace implements spanstore.Reader
+func (r *TenantSpanReader) GetTrace(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
+	reader, err := r.reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reader.GetTrace(ctx, traceID)
+}
+
+// GetServices implements spanstore.Reader
+func (r *TenantSpanReader) GetServices(ctx context.Context) ([]string, error) {
+	reader, err := r.reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reader.GetServices(ctx)
+}
+
+// GetOperations implements spanstore.Reader
+func (r *TenantSpanReader) GetOperations(ctx context.Context, query spanstore.OperationQueryParameters) ([]spanstore.Operation, error) {
+	reader, err := r.reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reader.GetOperations(ctx, query)
+}
+
+// FindTraces implements spanstore.Reader
+func (r *TenantSpanReader) FindTraces(ctx context.Context, query *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
+	reader, err := r.reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reader.FindTraces(ctx, query)
+}
+
+// FindTraceIDs implements spanstore.Reader
+func (r *TenantSpanReader) FindTraceIDs(ctx context.Context, query *spanstore.TraceQueryParameters) ([]model.TraceID, error) {
+	reader, err := r.reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reader.FindTraceIDs(ctx, query)
+}
+
+// GetOperationStats implements spanstore.OperationStatsReader.
+// It returns spanstore.ErrOperationStatsNotSupported if the reader of the tenant does not implement it.
+func (r *TenantSpanReader) GetOperationStats(ctx context.Context, query *spanstore.OperationStatsQueryParameters) ([]spanstore.OperationStats, error) {
+	reader, err := r.reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statsReader, ok := reader.(spanstore.OperationStatsReader)
+	if !ok {
+		return nil, spanstore.ErrOperationStatsNotSupported
+	}
+	return statsReader.GetOperationStats(ctx, query)
+}
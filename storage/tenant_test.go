@@ -0,0 +1,146 @@
+// Copyright (c) 2020 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+	spanStoreMocks "github.com/jaegertracing/jaeger/storage/spanstore/mocks"
+)
+
+type fakeTenantFactory struct {
+	readers map[string]spanstore.Reader
+	writers map[string]spanstore.Writer
+	created int
+}
+
+func (f *fakeTenantFactory) CreateTenantSpanReader(tenant string) (spanstore.Reader, error) {
+	f.created++
+	if r, ok := f.readers[tenant]; ok {
+		return r, nil
+	}
+	return nil, errors.New("no reader")
+}
+
+func (f *fakeTenantFactory) CreateTenantSpanWriter(tenant string) (spanstore.Writer, error) {
+	f.created++
+	if w, ok := f.writers[tenant]; ok {
+		return w, nil
+	}
+	return nil, errors.New("no writer")
+}
+
+func TestTenantSpanWriters(t *testing.T) {
+	acme := &spanStoreMocks.Writer{}
+	factory := &fakeTenantFactory{writers: map[string]spanstore.Writer{"acme": acme}}
+	writers := NewTenantSpanWriters(factory)
+
+	w, err := writers.SpanWriter("acme")
+	require.NoError(t, err)
+	assert.Equal(t, acme, w)
+	w, err = writers.SpanWriter("acme")
+	require.NoError(t, err)
+	assert.Equal(t, acme, w)
+	assert.Equal(t, 1, factory.created)
+
+	_, err = writers.SpanWriter("megacorp")
+	assert.EqualError(t, err, "no writer")
+}
+
+func TestTenantSpanReader(t *testing.T) {
+	defaultReader := &spanStoreMocks.Reader{}
+	acmeReader := &spanStoreMocks.Reader{}
+	factory := &fakeTenantFactory{readers: map[string]spanstore.Reader{"acme": acmeReader}}
+	reader := NewTenantSpanReader(factory, defaultReader)
+
+	acmeCtx := tenancy.WithTenant(context.Background(), "acme")
+	acmeReader.On("GetServices", acmeCtx).Return([]string{"acme-svc"}, nil)
+	acmeReader.On("GetOperations", acmeCtx, mock.Anything).Return([]spanstore.Operation{{Name: "op"}}, nil)
+	acmeReader.On("GetTrace", acmeCtx, mock.Anything).Return(&model.Trace{}, nil)
+	acmeReader.On("FindTraces", acmeCtx, mock.Anything).Return([]*model.Trace{{}}, nil)
+	acmeReader.On("FindTraceIDs", acmeCtx, mock.Anything).Return([]model.TraceID{{Low: 1}}, nil)
+	defaultReader.On("GetServices", context.Background()).Return([]string{"svc"}, nil)
+
+	services, err := reader.GetServices(acmeCtx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme-svc"}, services)
+	operations, err := reader.GetOperations(acmeCtx, spanstore.OperationQueryParameters{ServiceName: "acme-svc"})
+	require.NoError(t, err)
+	assert.Len(t, operations, 1)
+	trace, err := reader.GetTrace(acmeCtx, model.TraceID{Low: 1})
+	require.NoError(t, err)
+	assert.NotNil(t, trace)
+	traces, err := reader.FindTraces(acmeCtx, &spanstore.TraceQueryParameters{})
+	require.NoError(t, err)
+	assert.Len(t, traces, 1)
+	traceIDs, err := reader.FindTraceIDs(acmeCtx, &spanstore.TraceQueryParameters{})
+	require.NoError(t, err)
+	assert.Len(t, traceIDs, 1)
+	assert.Equal(t, 1, factory.created)
+
+	services, err = reader.GetServices(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"svc"}, services)
+
+	unknownCtx := tenancy.WithTenant(context.Background(), "megacorp")
+	_, err = reader.GetServices(unknownCtx)
+	assert.EqualError(t, err, "no reader")
+	_, err = reader.GetOperations(unknownCtx, spanstore.OperationQueryParameters{})
+	assert.EqualError(t, err, "no reader")
+	_, err = reader.GetTrace(unknownCtx, model.TraceID{})
+	assert.EqualError(t, err, "no reader")
+	_, err = reader.FindTraces(unknownCtx, &spanstore.TraceQueryParameters{})
+	assert.EqualError(t, err, "no reader")
+	_, err = reader.FindTraceIDs(unknownCtx, &spanstore.TraceQueryParameters{})
+	assert.EqualError(t, err, "no reader")
+}
+
+type statsReader struct {
+	spanStoreMocks.Reader
+}
+
+func (r *statsReader) GetOperationStats(ctx context.Context, query *spanstore.OperationStatsQueryParameters) ([]spanstore.OperationStats, error) {
+	return []spanstore.OperationStats{{Operation: query.ServiceName}}, nil
+}
+
+func TestTenantSpanReaderGetOperationStats(t *testing.T) {
+	factory := &fakeTenantFactory{readers: map[string]spanstore.Reader{
+		"acme":   &statsReader{},
+		"globex": &spanStoreMocks.Reader{},
+	}}
+	reader := NewTenantSpanReader(factory, &statsReader{})
+	query := &spanstore.OperationStatsQueryParameters{ServiceName: "svc"}
+
+	stats, err := reader.GetOperationStats(tenancy.WithTenant(context.Background(), "acme"), query)
+	require.NoError(t, err)
+	assert.Equal(t, []spanstore.OperationStats{{Operation: "svc"}}, stats)
+	stats, err = reader.GetOperationStats(context.Background(), query)
+	require.NoError(t, err)
+	assert.Len(t, stats, 1)
+
+	_, err = reader.GetOperationStats(tenancy.WithTenant(context.Background(), "globex"), query)
+	assert.Equal(t, spanstore.ErrOperationStatsNotSupported, err)
+	_, err = reader.GetOperationStats(tenancy.WithTenant(context.Background(), "megacorp"), query)
+	assert.EqualError(t, err, "no reader")
+}